@@ -0,0 +1,285 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// CombinationStatement is published by an untrusted combiner to account for
+// every partial decryption it received: the transcript holds the valid
+// shares it interpolated over, i.e., the Participants, and the plaintext they
+// combine to, and Rejected holds all other shares. Third parties verify the
+// statement with VerifyCombinationStatement instead of trusting the
+// combiner, and each decryption server checks with CheckShareAccounted that
+// its share was not dropped. A combiner that publishes a wrong plaintext,
+// rejects a valid share or omits a share is caught, as long as the servers
+// check the statement, e.g., by comparing its Digest on a bulletin board.
+type CombinationStatement struct {
+	Transcript *DecryptionTranscript
+	Rejected   []*RejectedShare
+}
+
+// RejectedShare is a partial decryption the combiner did not combine and the
+// reason it gave. The reason is informative only; verifiers re-check the
+// share.
+type RejectedShare struct {
+	Share  *PartialDecryptionZKP
+	Reason string
+}
+
+// CombineAccountably verifies the proven partial decryptions of the level
+// one ciphertext and combines all valid shares of distinct servers, i.e.,
+// not only the first Threshold of them. The session of the first valid share
+// is recorded, and shares bound to another session, of another ciphertext or
+// with invalid proofs are listed as rejected. It returns an error wrapping
+// ErrTooFewShares if fewer than Threshold shares are valid.
+func (tk *ThresholdPublicKey) CombineAccountably(ct *Ciphertext, shares []*PartialDecryptionZKP) (*CombinationStatement, error) {
+	if ct == nil || ct.C == nil {
+		return nil, ErrInvalidCiphertext
+	}
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("threshold decryption is only supported for level one ciphertexts")
+	}
+
+	tr := &DecryptionTranscript{KeyFingerprint: tk.CommitteeFingerprint(), Ciphertext: ct}
+	st := &CombinationStatement{Transcript: tr}
+	sessionSet := false
+	for _, share := range shares {
+		if share == nil {
+			continue
+		}
+		err := tk.checkStatementShare(ct, share.Session, share)
+		if err == nil && !sessionSet {
+			tr.Session = share.Session
+			sessionSet = true
+		}
+		if err == nil && !bytes.Equal(share.Session, tr.Session) {
+			err = fmt.Errorf("share %d: proof is bound to another session", share.ID)
+		}
+		if err == nil && tr.hasParticipant(share.ID) {
+			err = fmt.Errorf("%w: server %d already participates", ErrDuplicateShareID, share.ID)
+		}
+		if err != nil {
+			st.Rejected = append(st.Rejected, &RejectedShare{Share: share, Reason: err.Error()})
+			continue
+		}
+		tr.Participants = append(tr.Participants, share.ID)
+		tr.PartialDecryptions = append(tr.PartialDecryptions, share)
+	}
+
+	if len(tr.PartialDecryptions) < tk.Threshold {
+		return nil, fmt.Errorf("%w: %d valid partial decryptions, threshold is %d", ErrTooFewShares, len(tr.PartialDecryptions), tk.Threshold)
+	}
+
+	plain := make([]*PartialDecryption, len(tr.PartialDecryptions))
+	for i, pd := range tr.PartialDecryptions {
+		plain[i] = &pd.PartialDecryption
+	}
+	m, err := tk.CombinePartialDecryptions(plain)
+	if err != nil {
+		return nil, err
+	}
+	tr.Plaintext = m
+	return st, nil
+}
+
+// VerifyCombinationStatement checks the statement against the threshold
+// public key: the transcript must pass VerifyDecryptionTranscript, and no
+// rejected share may be a valid partial decryption of the ciphertext in the
+// session of the transcript by a server that does not participate, in which
+// case the error wraps ErrDiscardedShare.
+func VerifyCombinationStatement(st *CombinationStatement, tk *ThresholdPublicKey) error {
+	if st == nil || st.Transcript == nil {
+		return errors.New("statement is missing the transcript")
+	}
+	if err := VerifyDecryptionTranscript(st.Transcript, tk); err != nil {
+		return err
+	}
+
+	for _, rejected := range st.Rejected {
+		if rejected == nil || rejected.Share == nil {
+			return errors.New("statement has an empty rejected share")
+		}
+		if err := st.Transcript.checkRejected(tk, rejected.Share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckShareAccounted is run by a decryption server on a published statement
+// with the share it sent. It returns an error wrapping ErrDiscardedShare if
+// the share is neither combined nor listed as rejected, in which case the
+// server can prove that the combiner dropped it by publishing the share.
+func (st *CombinationStatement) CheckShareAccounted(share *PartialDecryptionZKP) error {
+	if share == nil {
+		return fmt.Errorf("%w: missing partial decryption", ErrMalformedProof)
+	}
+	if st.Transcript != nil {
+		for _, pd := range st.Transcript.PartialDecryptions {
+			if sameShare(pd, share) {
+				return nil
+			}
+		}
+	}
+	for _, rejected := range st.Rejected {
+		if rejected != nil && sameShare(rejected.Share, share) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: share of server %d is missing from the statement", ErrDiscardedShare, share.ID)
+}
+
+// Digest returns a SHA-256 digest of the statement, which binds the
+// ciphertext, the session, every combined and rejected share and the
+// plaintext, so that the parties can compare or sign the statement without
+// exchanging it in full. Rejection reasons are not part of the digest.
+func (st *CombinationStatement) Digest() []byte {
+	h := sha256.New()
+	write := func(b []byte) {
+		binary.Write(h, binary.BigEndian, uint32(len(b)))
+		h.Write(b)
+	}
+	writeShare := func(pd *PartialDecryptionZKP) {
+		binary.Write(h, binary.BigEndian, int64(pd.ID))
+		for _, x := range []*gmp.Int{pd.C, pd.Decryption, pd.E, pd.Z} {
+			if x == nil {
+				x = ZeroBigInt
+			}
+			write(x.Bytes())
+		}
+		write(pd.Session)
+	}
+
+	write([]byte("paillier combination statement"))
+	if tr := st.Transcript; tr != nil {
+		write([]byte(tr.KeyFingerprint))
+		if tr.Ciphertext != nil && tr.Ciphertext.C != nil {
+			write(tr.Ciphertext.C.Bytes())
+		}
+		write(tr.Session)
+		binary.Write(h, binary.BigEndian, uint32(len(tr.PartialDecryptions)))
+		for _, pd := range tr.PartialDecryptions {
+			if pd != nil {
+				writeShare(pd)
+			}
+		}
+		if tr.Plaintext != nil {
+			write(tr.Plaintext.Bytes())
+		}
+	}
+	binary.Write(h, binary.BigEndian, uint32(len(st.Rejected)))
+	for _, rejected := range st.Rejected {
+		if rejected != nil && rejected.Share != nil {
+			writeShare(rejected.Share)
+		}
+	}
+	return h.Sum(nil)
+}
+
+// checkStatementShare returns nil iff the share is a valid partial decryption
+// of ct for tk bound to the session
+func (tk *ThresholdPublicKey) checkStatementShare(ct *Ciphertext, session []byte, share *PartialDecryptionZKP) error {
+	if share.C == nil || share.C.Cmp(ct.C) != 0 {
+		return fmt.Errorf("share %d: partial decryption is for another ciphertext", share.ID)
+	}
+	if !bytes.Equal(share.Session, session) {
+		return fmt.Errorf("share %d: proof is bound to another session", share.ID)
+	}
+
+	// verify against the given key rather than the one recorded in the proof
+	proof := *share
+	proof.Key = tk
+	return proof.VerifyErr()
+}
+
+// checkRejected returns an error wrapping ErrDiscardedShare if the rejected
+// share should have been combined
+func (tr *DecryptionTranscript) checkRejected(tk *ThresholdPublicKey, share *PartialDecryptionZKP) error {
+	if tr.hasParticipant(share.ID) {
+		return nil
+	}
+	if tk.checkStatementShare(tr.Ciphertext, tr.Session, share) == nil {
+		return fmt.Errorf("%w: share of server %d is valid", ErrDiscardedShare, share.ID)
+	}
+	return nil
+}
+
+func (tr *DecryptionTranscript) hasParticipant(id int) bool {
+	for _, participant := range tr.Participants {
+		if participant == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sameShare returns true iff the shares carry the same decryption and proof
+func sameShare(a, b *PartialDecryptionZKP) bool {
+	if a == nil || b == nil || a.ID != b.ID || !bytes.Equal(a.Session, b.Session) {
+		return false
+	}
+	for _, pair := range [][2]*gmp.Int{{a.C, b.C}, {a.Decryption, b.Decryption}, {a.E, b.E}, {a.Z, b.Z}} {
+		if pair[0] == nil || pair[1] == nil || pair[0].Cmp(pair[1]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type rejectedShareJSON struct {
+	Share  *PartialDecryptionZKP `json:"share"`
+	Reason string                `json:"reason,omitempty"`
+}
+
+type combinationStatementJSON struct {
+	Transcript *DecryptionTranscript `json:"transcript"`
+	Rejected   []*rejectedShareJSON  `json:"rejected,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. As in the transcript,
+// the rejected shares are encoded without their key.
+func (st *CombinationStatement) MarshalJSON() ([]byte, error) {
+	v := &combinationStatementJSON{Transcript: st.Transcript}
+	for _, rejected := range st.Rejected {
+		if rejected == nil {
+			continue
+		}
+		share := rejected.Share
+		if share != nil {
+			proof := *share
+			proof.Key = nil
+			share = &proof
+		}
+		v.Rejected = append(v.Rejected, &rejectedShareJSON{Share: share, Reason: rejected.Reason})
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The statement is
+// not verified.
+func (st *CombinationStatement) UnmarshalJSON(data []byte) error {
+	var v combinationStatementJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Transcript == nil {
+		return errors.New("statement is missing the transcript")
+	}
+
+	statement := CombinationStatement{Transcript: v.Transcript}
+	for _, rejected := range v.Rejected {
+		if rejected == nil || rejected.Share == nil {
+			return errors.New("statement has an empty rejected share")
+		}
+		statement.Rejected = append(statement.Rejected, &RejectedShare{Share: rejected.Share, Reason: rejected.Reason})
+	}
+	*st = statement
+	return nil
+}
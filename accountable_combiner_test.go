@@ -0,0 +1,127 @@
+package paillier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestCombineAccountably(t *testing.T) {
+	tsks := getCoordinatorKeys(t)
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(31))
+	session := []byte("tally-7")
+
+	shares := make([]*PartialDecryptionZKP, 5)
+	for i, tsk := range tsks {
+		var err error
+		if shares[i], err = tsk.PartialDecryptionWithSession(ct.C, session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an invalid share and a share of another session
+	broken := *shares[1]
+	broken.Decryption = new(gmp.Int).Add(broken.Decryption, OneBigInt)
+	stale, err := tsks[3].PartialDecryptionWithSession(ct.C, []byte("tally-6"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := []*PartialDecryptionZKP{shares[0], &broken, stale, shares[2], shares[3], shares[4], shares[0]}
+
+	st, err := tk.CombineAccountably(ct, received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(st.Transcript.Plaintext) != 31 {
+		t.Error("decrypted ", st.Transcript.Plaintext, " expected 31")
+	}
+	if len(st.Transcript.Participants) != 4 || len(st.Rejected) != 3 {
+		t.Errorf("%d participants and %d rejected shares, expected 4 and 3", len(st.Transcript.Participants), len(st.Rejected))
+	}
+	for _, share := range received {
+		if err := st.CheckShareAccounted(share); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// the auditor only sees the published statement and the public key
+	data, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var published CombinationStatement
+	if err := json.Unmarshal(data, &published); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyCombinationStatement(&published, tk); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(published.Digest(), st.Digest()) {
+		t.Error("digest changed by the encoding")
+	}
+
+	if _, err := tk.CombineAccountably(ct, []*PartialDecryptionZKP{shares[0], &broken, shares[2]}); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+}
+
+func TestCombinationStatementDetectsCheating(t *testing.T) {
+	tsks := getCoordinatorKeys(t)
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(8))
+
+	shares := make([]*PartialDecryptionZKP, 4)
+	for i := range shares {
+		var err error
+		if shares[i], err = tsks[i].PartialDecryptionWithZKP(ct.C); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st, err := tk.CombineAccountably(ct, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := st.Digest()
+
+	// a wrong plaintext
+	st.Transcript.Plaintext = gmp.NewInt(9)
+	if err := VerifyCombinationStatement(st, tk); err == nil {
+		t.Error("expected an error for a wrong plaintext")
+	}
+	if bytes.Equal(st.Digest(), digest) {
+		t.Error("digest does not bind the plaintext")
+	}
+	st.Transcript.Plaintext = gmp.NewInt(8)
+
+	// a valid share that is rejected instead of combined
+	tr := st.Transcript
+	dropped := tr.PartialDecryptions[3]
+	tr.Participants = tr.Participants[:3]
+	tr.PartialDecryptions = tr.PartialDecryptions[:3]
+	st.Rejected = []*RejectedShare{{Share: dropped, Reason: "invalid proof"}}
+	if err := VerifyCombinationStatement(st, tk); !errors.Is(err, ErrDiscardedShare) {
+		t.Error("expected ErrDiscardedShare, got ", err)
+	}
+
+	// a valid share that is omitted
+	st.Rejected = nil
+	if err := VerifyCombinationStatement(st, tk); err != nil {
+		t.Fatal("the statement without the share is consistent: ", err)
+	}
+	if err := st.CheckShareAccounted(dropped); !errors.Is(err, ErrDiscardedShare) {
+		t.Error("expected ErrDiscardedShare, got ", err)
+	}
+
+	other, err := tsks[4].PartialDecryptionWithZKP(tk.Encrypt(gmp.NewInt(8)).C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Rejected = []*RejectedShare{{Share: other}}
+	if err := VerifyCombinationStatement(st, tk); err != nil {
+		t.Error("a share of another ciphertext may be rejected: ", err)
+	}
+}
@@ -0,0 +1,188 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// AffineOperationProof is a non-interactive proof (Fiat-Shamir heuristic)
+// that a level one ciphertext d was computed from a ciphertext c as
+// d = c^a g^b rho^N mod N^2, i.e., as ConstMult(c, a) times an encryption of
+// b, where a and b in [0, N) are committed by the ciphertexts
+// A = g^a r_a^N and B = g^b r_b^N. It is the proof of correct multiplication
+// of [CDN 01] extended by the additive term.
+//
+// Since A and B are encryptions under the same key, they hide a and b only
+// if no single party can decrypt, e.g., under a threshold key. If the
+// verifier holds the secret key, use AffineProof, which does not commit to
+// a and b but bounds them up to a slack.
+//
+//	[CDN 01]: Ronald Cramer, Ivan Damgard, Jesper Buus Nielsen, (2001)
+//	          Multiparty Computation from Threshold Homomorphic Encryption
+type AffineOperationProof struct {
+	TA, TB, T *gmp.Int // commitments
+	ZA, ZB    *gmp.Int // responses for a and b mod N
+	WA, WB, W *gmp.Int // responses for r_a, r_b and rho mod N
+}
+
+// AffineWithProof returns d = c^a g^b rho^N mod N^2, the commitments
+// A = g^a r_a^N and B = g^b r_b^N and a proof that d was computed correctly
+func (pk *PublicKey) AffineWithProof(ct *Ciphertext, a, b *gmp.Int) (*Ciphertext, *Ciphertext, *Ciphertext, *AffineOperationProof, error) {
+	if ct.Level != EncLevelOne {
+		return nil, nil, nil, nil, errors.New("affine operation proofs are only supported for level one ciphertexts")
+	}
+
+	var rs [3]*gmp.Int
+	for i := range rs {
+		var err error
+		if rs[i], err = GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource()); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	ra, rb, rho := rs[0], rs[1], rs[2]
+
+	commitA := pk.EncryptWithR(a, ra)
+	commitB := pk.EncryptWithR(b, rb)
+	result := pk.ConstMult(ct, a)
+	result.C.Mul(result.C, pk.EncryptWithR(b, rho).C)
+	result.C.Mod(result.C, pk.GetN2())
+
+	proof, err := pk.ProveAffineOperation(ct, result, commitA, commitB, a, ra, b, rb, rho)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return result, commitA, commitB, proof, nil
+}
+
+// ProveAffineOperation proves that result = ct^a g^b rho^N mod N^2 for the a
+// and b committed by commitA = g^a ra^N and commitB = g^b rb^N
+func (pk *PublicKey) ProveAffineOperation(ct, result, commitA, commitB *Ciphertext, a, ra, b, rb, rho *gmp.Int) (*AffineOperationProof, error) {
+	if ct.Level != EncLevelOne || result.Level != EncLevelOne || commitA.Level != EncLevelOne || commitB.Level != EncLevelOne {
+		return nil, errors.New("affine operation proofs are only supported for level one ciphertexts")
+	}
+
+	if a.Sign() < 0 || a.Cmp(pk.N) >= 0 || b.Sign() < 0 || b.Cmp(pk.N) >= 0 {
+		return nil, errors.New("value is out of range")
+	}
+
+	random := pk.RandomSource()
+	alpha, err := GetRandomNumber(pk.N, random)
+	if err != nil {
+		return nil, err
+	}
+	beta, err := GetRandomNumber(pk.N, random)
+	if err != nil {
+		return nil, err
+	}
+	var us [3]*gmp.Int
+	for i := range us {
+		if us[i], err = GetRandomNumberInMultiplicativeGroup(pk.N, random); err != nil {
+			return nil, err
+		}
+	}
+
+	n2 := pk.GetN2()
+	ta := pk.EncryptWithR(alpha, us[0]).C
+	tb := pk.EncryptWithR(beta, us[1]).C
+	t := new(gmp.Int).Exp(ct.C, alpha, n2)
+	t.Mul(t, pk.EncryptWithR(beta, us[2]).C)
+	t.Mod(t, n2)
+
+	e := pk.affineOperationChallenge(ct, result, commitA, commitB, ta, tb, t)
+
+	// alpha + e*a = za + qa*N; g = N+1 has order N and is 1 mod N, so the
+	// quotient only moves into the response for c^alpha as c^qa
+	qa, za := new(gmp.Int).DivMod(new(gmp.Int).Add(alpha, new(gmp.Int).Mul(e, a)), pk.N, new(gmp.Int))
+	zb := new(gmp.Int).Add(beta, new(gmp.Int).Mul(e, b))
+	zb.Mod(zb, pk.N)
+
+	wa := new(gmp.Int).Exp(ra, e, pk.N)
+	wa.Mul(wa, us[0])
+	wa.Mod(wa, pk.N)
+
+	wb := new(gmp.Int).Exp(rb, e, pk.N)
+	wb.Mul(wb, us[1])
+	wb.Mod(wb, pk.N)
+
+	w := new(gmp.Int).Exp(rho, e, pk.N)
+	w.Mul(w, us[2])
+	w.Mul(w, new(gmp.Int).Exp(ct.C, qa, pk.N))
+	w.Mod(w, pk.N)
+
+	return &AffineOperationProof{TA: ta, TB: tb, T: t, ZA: za, ZB: zb, WA: wa, WB: wb, W: w}, nil
+}
+
+// VerifyAffineOperationProof returns true iff the proof shows that result =
+// ct^a g^b rho^N mod N^2 for the a and b committed by commitA and commitB
+func (pk *PublicKey) VerifyAffineOperationProof(ct, result, commitA, commitB *Ciphertext, proof *AffineOperationProof) bool {
+	return pk.VerifyAffineOperationProofErr(ct, result, commitA, commitB, proof) == nil
+}
+
+// VerifyAffineOperationProofErr verifies the proof as
+// VerifyAffineOperationProof and returns an error wrapping ErrMalformedProof,
+// ErrProofPart1 (commitment to a), ErrProofPart2 (commitment to b) or
+// ErrProofPart3 (result) if it is rejected
+func (pk *PublicKey) VerifyAffineOperationProofErr(ct, result, commitA, commitB *Ciphertext, proof *AffineOperationProof) error {
+	if proof == nil || proof.TA == nil || proof.TB == nil || proof.T == nil || proof.ZA == nil ||
+		proof.ZB == nil || proof.WA == nil || proof.WB == nil || proof.W == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	n2 := pk.GetN2()
+	for _, c := range []*Ciphertext{ct, result, commitA, commitB} {
+		if c == nil || c.C == nil {
+			return fmt.Errorf("%w: missing values", ErrMalformedProof)
+		}
+		if c.Level != EncLevelOne {
+			return fmt.Errorf("%w: ciphertexts must be level one ciphertexts", ErrMalformedProof)
+		}
+		if c.C.Sign() <= 0 || c.C.Cmp(n2) >= 0 {
+			return fmt.Errorf("%w: ciphertext is not in Z_N^2", ErrMalformedProof)
+		}
+	}
+
+	if proof.ZA.Sign() < 0 || proof.ZA.Cmp(pk.N) >= 0 || proof.ZB.Sign() < 0 || proof.ZB.Cmp(pk.N) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+
+	if err := pk.checkUnits(proof.WA, proof.WB, proof.W); err != nil {
+		return err
+	}
+
+	e := pk.affineOperationChallenge(ct, result, commitA, commitB, proof.TA, proof.TB, proof.T)
+
+	// g^ZA WA^N = TA A^e
+	rhs := new(gmp.Int).Exp(commitA.C, e, n2)
+	rhs.Mul(rhs, proof.TA)
+	rhs.Mod(rhs, n2)
+	if pk.EncryptWithR(proof.ZA, proof.WA).C.Cmp(rhs) != 0 {
+		return ErrProofPart1
+	}
+
+	// g^ZB WB^N = TB B^e
+	rhs = new(gmp.Int).Exp(commitB.C, e, n2)
+	rhs.Mul(rhs, proof.TB)
+	rhs.Mod(rhs, n2)
+	if pk.EncryptWithR(proof.ZB, proof.WB).C.Cmp(rhs) != 0 {
+		return ErrProofPart2
+	}
+
+	// c^ZA g^ZB W^N = T d^e
+	lhs := new(gmp.Int).Exp(ct.C, proof.ZA, n2)
+	lhs.Mul(lhs, pk.EncryptWithR(proof.ZB, proof.W).C)
+	lhs.Mod(lhs, n2)
+	rhs = new(gmp.Int).Exp(result.C, e, n2)
+	rhs.Mul(rhs, proof.T)
+	rhs.Mod(rhs, n2)
+	if lhs.Cmp(rhs) != 0 {
+		return ErrProofPart3
+	}
+
+	return nil
+}
+
+func (pk *PublicKey) affineOperationChallenge(ct, result, commitA, commitB *Ciphertext, ta, tb, t *gmp.Int) *gmp.Int {
+	return RandomOracleChallenge(pk.challengeBitLength(), pk.N, ct.C, result.C, commitA.C, commitB.C, ta, tb, t)
+}
@@ -0,0 +1,77 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestAffineOperationProof(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for i := 0; i < 10; i++ {
+		a, _ := GetRandomNumber(pk.N, pk.RandomSource())
+		b, _ := GetRandomNumber(pk.N, pk.RandomSource())
+		ct := pk.Encrypt(gmp.NewInt(int64(i + 3)))
+
+		result, commitA, commitB, proof, err := pk.AffineWithProof(ct, a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pk.VerifyAffineOperationProofErr(ct, result, commitA, commitB, proof); err != nil {
+			t.Error("affine operation proof is not complete: ", err)
+		}
+
+		expected := new(gmp.Int).Mul(a, gmp.NewInt(int64(i+3)))
+		expected.Add(expected, b)
+		expected.Mod(expected, pk.N)
+		if sk.Decrypt(result).Cmp(expected) != 0 {
+			t.Error("wrong result of the affine operation")
+		}
+	}
+}
+
+func TestAffineOperationProofSoundness(t *testing.T) {
+	_, pk := KeyGen(128)
+	ct := pk.Encrypt(gmp.NewInt(5))
+
+	result, commitA, commitB, proof, err := pk.AffineWithProof(ct, gmp.NewInt(3), gmp.NewInt(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the challenge depends on all ciphertexts, so the first equation fails
+	// for other commitments
+	other := pk.Encrypt(gmp.NewInt(3))
+	if err := pk.VerifyAffineOperationProofErr(ct, result, other, commitB, proof); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1 for another commitment to a, got ", err)
+	}
+	if err := pk.VerifyAffineOperationProofErr(ct, result, commitA, other, proof); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1 for another commitment to b, got ", err)
+	}
+
+	// a result computed with another a or b does not verify
+	wrong, _, _, _, err := pk.AffineWithProof(ct, gmp.NewInt(3), gmp.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.VerifyAffineOperationProof(ct, wrong, commitA, commitB, proof) {
+		t.Error("affine operation proof verifies for a wrong result")
+	}
+
+	tampered := *proof
+	tampered.W = new(gmp.Int).Add(proof.W, OneBigInt)
+	tampered.W.Mod(tampered.W, pk.N)
+	if err := pk.VerifyAffineOperationProofErr(ct, result, commitA, commitB, &tampered); !errors.Is(err, ErrProofPart3) && !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected ErrProofPart3 for a tampered response, got ", err)
+	}
+
+	if err := pk.VerifyAffineOperationProofErr(ct, result, commitA, commitB, &AffineOperationProof{}); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error, got ", err)
+	}
+
+	if _, _, _, _, err := pk.AffineWithProof(ct, pk.N, gmp.NewInt(4)); err == nil {
+		t.Error("expected error for a value out of range")
+	}
+}
@@ -0,0 +1,167 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// AggregatedDecryptionProof is a single non-interactive proof that all partial
+// decryptions of a batch of ciphertexts by one decryption server are correct.
+//
+// The per-ciphertext statements log_{c_j^4}(d_j^2) = log_V(V_i) are combined
+// with weights rho_j derived by hashing the whole batch into the statement
+// log_C(D) = log_V(V_i) for C = prod c_j^(4 rho_j) and D = prod d_j^(2 rho_j),
+// which is proven with the same sigma protocol as PartialDecryptionZKP. A batch
+// with an incorrect partial decryption passes only if the weights, which are
+// fixed once the batch is, cancel the error, which happens with negligible
+// probability. The proof has the size of a single PartialDecryptionZKP without
+// the ciphertext, independently of the size of the batch.
+type AggregatedDecryptionProof struct {
+	ID int      // the ID of the decryption server
+	E  *gmp.Int // the challenge
+	Z  *gmp.Int // the response
+}
+
+// PartialDecryptBatchWithProof partially decrypts every ciphertext of the
+// batch and returns the partial decryptions with one proof for the batch
+func (tsk *ThresholdSecretKey) PartialDecryptBatchWithProof(cts []*gmp.Int) ([]*PartialDecryption, *AggregatedDecryptionProof, error) {
+	if len(cts) == 0 {
+		return nil, nil, fmt.Errorf("%w: empty batch", ErrMalformedProof)
+	}
+
+	logEvent(EventDecryptionRequested, &tsk.ThresholdPublicKey, tsk.ID, nil)
+
+	pds := make([]*PartialDecryption, len(cts))
+	for i, c := range cts {
+		pd, err := tsk.partialDecrypt(c, tsk.GetN2())
+		if err != nil {
+			return nil, nil, err
+		}
+		pds[i] = pd
+	}
+
+	tk := &tsk.ThresholdPublicKey
+	c, d := tk.aggregateBatch(tsk.ID, cts, pds)
+
+	rBig, err := rand.Int(tsk.RandomSource(), ToBigInt(tsk.GetN2()))
+	if err != nil {
+		return nil, nil, err
+	}
+	r := ToGmpInt(rBig)
+
+	a := new(gmp.Int).Exp(c, r, tsk.GetN2())
+	b := new(gmp.Int).Exp(tsk.VerificationKey, r, tsk.GetN2())
+
+	proof := &AggregatedDecryptionProof{ID: tsk.ID}
+	proof.E = tk.aggregatedChallenge(tsk.ID, c, d, a, b)
+	proof.Z, err = tsk.computeZ(r, proof.E)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pds, proof, nil
+}
+
+// VerifyAggregatedDecryptionProof returns nil if the proof shows that pds are
+// the partial decryptions of cts by the server proof.ID and otherwise an error
+// wrapping ErrMalformedProof or ErrChallengeMismatch
+func (tk *ThresholdPublicKey) VerifyAggregatedDecryptionProof(cts []*gmp.Int, pds []*PartialDecryption, proof *AggregatedDecryptionProof) error {
+	done := startOperation(OpVerifyProof)
+
+	err := tk.verifyAggregated(cts, pds, proof)
+	done(err == nil)
+	if err != nil {
+		id := 0
+		if proof != nil {
+			id = proof.ID
+		}
+		err = &InvalidProofError{ID: id, Err: err}
+		logEvent(EventProofFailed, tk, id, err)
+	}
+
+	return err
+}
+
+func (tk *ThresholdPublicKey) verifyAggregated(cts []*gmp.Int, pds []*PartialDecryption, proof *AggregatedDecryptionProof) error {
+	if proof == nil || proof.E == nil || proof.Z == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if proof.ID < 1 || proof.ID > len(tk.VerificationKeys) {
+		return fmt.Errorf("%w: share ID is out of range", ErrMalformedProof)
+	}
+
+	if len(cts) == 0 || len(cts) != len(pds) {
+		return fmt.Errorf("%w: batch has %d ciphertexts and %d partial decryptions",
+			ErrMalformedProof, len(cts), len(pds))
+	}
+
+	if proof.E.Sign() < 0 || proof.Z.Sign() < 0 {
+		return fmt.Errorf("%w: negative challenge or response", ErrMalformedProof)
+	}
+
+	n2 := tk.GetN2()
+	values := []*gmp.Int{tk.VerificationKey, tk.VerificationKeys[proof.ID-1]}
+	for i := range cts {
+		if pds[i] == nil || pds[i].ID != proof.ID {
+			return fmt.Errorf("%w: partial decryption %d is not by the prover", ErrMalformedProof, i)
+		}
+		values = append(values, cts[i], pds[i].Decryption)
+	}
+	for _, x := range values {
+		if x == nil || x.Sign() <= 0 || x.Cmp(n2) >= 0 || new(gmp.Int).GCD(nil, nil, x, tk.N).Cmp(OneBigInt) != 0 {
+			return fmt.Errorf("%w: value is not a unit mod N^2", ErrMalformedProof)
+		}
+	}
+
+	c, d := tk.aggregateBatch(proof.ID, cts, pds)
+
+	// a = C^Z / D^E and b = V^Z / V_i^E
+	a := new(gmp.Int).Exp(d, proof.E, n2)
+	a.ModInverse(a, n2)
+	a.Mul(a, new(gmp.Int).Exp(c, proof.Z, n2))
+	a.Mod(a, n2)
+
+	b := new(gmp.Int).Exp(tk.VerificationKeys[proof.ID-1], proof.E, n2)
+	b.ModInverse(b, n2)
+	b.Mul(b, new(gmp.Int).Exp(tk.VerificationKey, proof.Z, n2))
+	b.Mod(b, n2)
+
+	if proof.E.Cmp(tk.aggregatedChallenge(proof.ID, c, d, a, b)) != 0 {
+		return ErrChallengeMismatch
+	}
+
+	return nil
+}
+
+// returns C = prod c_j^(4 rho_j) and D = prod d_j^(2 rho_j) mod N^2 where the
+// weights rho_j are derived from a hash of the whole batch
+func (tk *ThresholdPublicKey) aggregateBatch(id int, cts []*gmp.Int, pds []*PartialDecryption) (*gmp.Int, *gmp.Int) {
+	n2 := tk.GetN2()
+	bits := tk.challengeBitLength()
+
+	batch := []*gmp.Int{tk.N, tk.VerificationKey, tk.VerificationKeys[id-1]}
+	for i := range cts {
+		batch = append(batch, cts[i], pds[i].Decryption)
+	}
+	seed := RandomOracleChallenge(256, batch...)
+
+	c := gmp.NewInt(1)
+	d := gmp.NewInt(1)
+	for j := range cts {
+		rho := RandomOracleChallenge(bits, seed, gmp.NewInt(int64(j)))
+
+		c.Mul(c, new(gmp.Int).Exp(cts[j], new(gmp.Int).Mul(FourBigInt, rho), n2))
+		c.Mod(c, n2)
+		d.Mul(d, new(gmp.Int).Exp(pds[j].Decryption, new(gmp.Int).Mul(TwoBigInt, rho), n2))
+		d.Mod(d, n2)
+	}
+
+	return c, d
+}
+
+func (tk *ThresholdPublicKey) aggregatedChallenge(id int, c, d, a, b *gmp.Int) *gmp.Int {
+	return RandomOracleChallenge(256, tk.N, tk.VerificationKey, tk.VerificationKeys[id-1], c, d, a, b)
+}
@@ -0,0 +1,71 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestAggregatedDecryptionProof(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	cts := make([]*gmp.Int, 20)
+	for i := range cts {
+		cts[i] = tk.Encrypt(gmp.NewInt(int64(i))).C
+	}
+
+	pds1, proof1, err := tsks[0].PartialDecryptBatchWithProof(cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pds3, proof3, err := tsks[2].PartialDecryptBatchWithProof(cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tk.VerifyAggregatedDecryptionProof(cts, pds1, proof1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tk.VerifyAggregatedDecryptionProof(cts, pds3, proof3); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range cts {
+		m, err := tk.CombinePartialDecryptions([]*PartialDecryption{pds1[i], pds3[i]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Int64() != int64(i) {
+			t.Error("wrong decryption ", m, " is not ", i)
+		}
+	}
+
+	// one wrong partial decryption invalidates the proof of the batch
+	forged := append([]*PartialDecryption{}, pds1...)
+	forged[7] = &PartialDecryption{ID: 1, Decryption: new(gmp.Int).Mul(pds1[7].Decryption, pds1[7].Decryption)}
+	forged[7].Decryption.Mod(forged[7].Decryption, tk.GetN2())
+	if err := tk.VerifyAggregatedDecryptionProof(cts, forged, proof1); !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected challenge mismatch, got ", err)
+	}
+
+	if err := tk.VerifyAggregatedDecryptionProof(cts, pds3, proof1); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof for partial decryptions of another server, got ", err)
+	}
+	if err := tk.VerifyAggregatedDecryptionProof(cts[1:], pds1, proof1); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof for batch size mismatch, got ", err)
+	}
+	if err := tk.VerifyAggregatedDecryptionProof(cts[1:], pds1[1:], proof1); !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected challenge mismatch for a different batch, got ", err)
+	}
+}
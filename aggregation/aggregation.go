@@ -0,0 +1,296 @@
+// Package aggregation implements secure aggregation with a threshold
+// committee: many clients encrypt vectors of bounded values, an untrusted
+// aggregator adds the encryptions and the committee decrypts only the sum,
+// e.g., for federated learning or telemetry.
+//
+// Without range proofs, clients pack their values into as few ciphertexts as
+// possible, see paillier.Packer, and a malicious client can skew the sum
+// arbitrarily. With RangeProofs, clients encrypt every value separately with
+// a BitRangeProof that it is below 2^ValueBits, the aggregator rejects
+// contributions with invalid proofs and packs the sums homomorphically, so
+// the committee still decrypts few ciphertexts.
+package aggregation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+// Params are the public parameters of an aggregation, which the clients, the
+// aggregator and the committee agree on. They must be created with NewParams.
+type Params struct {
+	Key         *paillier.ThresholdPublicKey
+	Dimension   int  // number of values of a contribution
+	ValueBits   int  // values are in [0, 2^ValueBits)
+	MaxClients  int  // largest number of contributions in an aggregate
+	RangeProofs bool // contributions carry a range proof for every value
+
+	packer *paillier.Packer
+}
+
+// NewParams returns the parameters of an aggregation of up to maxClients
+// vectors of dimension values of valueBits bits each, without range proofs
+func NewParams(key *paillier.ThresholdPublicKey, dimension, valueBits, maxClients int) (*Params, error) {
+	if dimension < 1 || valueBits < 1 || maxClients < 1 {
+		return nil, errors.New("dimension, value bits and number of clients must be positive")
+	}
+
+	// the guard bits of a slot hold the carries of maxClients additions
+	guardBits := gmp.NewInt(int64(maxClients)).BitLen()
+	packer, err := key.NewPacker(valueBits, guardBits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Params{
+		Key:        key,
+		Dimension:  dimension,
+		ValueBits:  valueBits,
+		MaxClients: maxClients,
+		packer:     packer,
+	}, nil
+}
+
+// Contribution is the encrypted vector of one client. Without range proofs,
+// Ciphertexts are the packed values; with range proofs, they are the values
+// in order and Proofs[i] is the proof of Ciphertexts[i].
+type Contribution struct {
+	Ciphertexts []*paillier.Ciphertext
+	Proofs      []*paillier.BitRangeProof
+}
+
+// bound returns the exclusive upper bound of the values
+func (p *Params) bound() *gmp.Int {
+	return new(gmp.Int).Lsh(paillier.OneBigInt, uint(p.ValueBits))
+}
+
+// packedLength returns the number of ciphertexts of the packed values
+func (p *Params) packedLength() int {
+	return (p.Dimension + p.packer.Slots - 1) / p.packer.Slots
+}
+
+// Contribute encrypts the values of a client, which must be Dimension
+// values in [0, 2^ValueBits)
+func (p *Params) Contribute(values []*gmp.Int) (*Contribution, error) {
+	if len(values) != p.Dimension {
+		return nil, fmt.Errorf("contribution has %d values, expected %d", len(values), p.Dimension)
+	}
+	bound := p.bound()
+	for i, v := range values {
+		if v == nil || v.Sign() < 0 || v.Cmp(bound) >= 0 {
+			return nil, fmt.Errorf("value %d is out of range", i)
+		}
+	}
+
+	if !p.RangeProofs {
+		c := &Contribution{}
+		for start := 0; start < len(values); start += p.packer.Slots {
+			end := start + p.packer.Slots
+			if end > len(values) {
+				end = len(values)
+			}
+			pc, err := p.packer.Encrypt(values[start:end])
+			if err != nil {
+				return nil, err
+			}
+			c.Ciphertexts = append(c.Ciphertexts, pc.Ciphertext)
+		}
+		return c, nil
+	}
+
+	pk := &p.Key.PublicKey
+	c := &Contribution{
+		Ciphertexts: make([]*paillier.Ciphertext, len(values)),
+		Proofs:      make([]*paillier.BitRangeProof, len(values)),
+	}
+	for i, v := range values {
+		r, err := paillier.GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		c.Ciphertexts[i] = pk.EncryptWithR(v, r)
+		if c.Proofs[i], err = pk.ProveBitRange(c.Ciphertexts[i], v, r, bound); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Aggregate is the encrypted sum of the contributions of Count clients
+type Aggregate struct {
+	Ciphertexts []*paillier.Ciphertext // packed sums
+	Count       int
+}
+
+// Aggregator adds the contributions of the clients. It does not need any
+// secret and is safe for concurrent use.
+type Aggregator struct {
+	params *Params
+
+	mu      sync.Mutex
+	clients map[string]bool
+	seen    map[string]bool // ciphertexts, to reject copied contributions
+	sums    []*paillier.Ciphertext
+	count   int
+}
+
+// NewAggregator returns an aggregator without contributions
+func (p *Params) NewAggregator() *Aggregator {
+	return &Aggregator{params: p, clients: make(map[string]bool), seen: make(map[string]bool)}
+}
+
+// Add verifies the contribution of the client and adds it to the sum. It
+// returns an error if the client already contributed, the contribution
+// copies ciphertexts of another one, a range proof is invalid or the
+// aggregate already has MaxClients contributions.
+func (a *Aggregator) Add(client string, c *Contribution) error {
+	if err := a.params.check(c); err != nil {
+		return fmt.Errorf("client %s: %w", client, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.clients[client] {
+		return fmt.Errorf("client %s already contributed", client)
+	}
+	if a.count == a.params.MaxClients {
+		return fmt.Errorf("aggregate already has %d contributions", a.count)
+	}
+	for _, ct := range c.Ciphertexts {
+		if a.seen[string(ct.C.Bytes())] {
+			return fmt.Errorf("client %s: contribution copies another contribution", client)
+		}
+	}
+
+	pk := &a.params.Key.PublicKey
+	for i, ct := range c.Ciphertexts {
+		a.seen[string(ct.C.Bytes())] = true
+		if a.count == 0 {
+			a.sums = append(a.sums, ct.Clone())
+		} else {
+			a.sums[i] = pk.Add(a.sums[i], ct)
+		}
+	}
+	a.clients[client] = true
+	a.count++
+	return nil
+}
+
+// Len returns the number of contributions added
+func (a *Aggregator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.count
+}
+
+// Aggregate returns the encrypted sum of the contributions, which the
+// committee decrypts
+func (a *Aggregator) Aggregate() (*Aggregate, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.count == 0 {
+		return nil, errors.New("no contributions were added")
+	}
+	if !a.params.RangeProofs {
+		return &Aggregate{Ciphertexts: append([]*paillier.Ciphertext{}, a.sums...), Count: a.count}, nil
+	}
+
+	// pack the sums of the values homomorphically: slot j of a ciphertext is
+	// the sum times 2^(j * SlotWidth)
+	pk := &a.params.Key.PublicKey
+	packer := a.params.packer
+	agg := &Aggregate{Count: a.count}
+	for start := 0; start < len(a.sums); start += packer.Slots {
+		var slots []*paillier.Ciphertext
+		for j := 0; j < packer.Slots && start+j < len(a.sums); j++ {
+			shift := new(gmp.Int).Lsh(paillier.OneBigInt, uint(j*packer.SlotWidth()))
+			slots = append(slots, pk.ConstMult(a.sums[start+j], shift))
+		}
+		agg.Ciphertexts = append(agg.Ciphertexts, pk.Add(slots...))
+	}
+	return agg, nil
+}
+
+// check returns an error if the contribution has the wrong shape or an
+// invalid range proof
+func (p *Params) check(c *Contribution) error {
+	if c == nil {
+		return errors.New("missing contribution")
+	}
+
+	expected := p.packedLength()
+	if p.RangeProofs {
+		expected = p.Dimension
+		if len(c.Proofs) != p.Dimension {
+			return errors.New("contribution must have a range proof for every value")
+		}
+	} else if len(c.Proofs) != 0 {
+		return errors.New("contribution has unexpected range proofs")
+	}
+	if len(c.Ciphertexts) != expected {
+		return fmt.Errorf("contribution has %d ciphertexts, expected %d", len(c.Ciphertexts), expected)
+	}
+
+	pk := &p.Key.PublicKey
+	for i, ct := range c.Ciphertexts {
+		if ct == nil || ct.C == nil || ct.C.Sign() <= 0 || ct.C.Cmp(pk.GetN2()) >= 0 || ct.Level != paillier.EncLevelOne {
+			return fmt.Errorf("%w: ciphertext %d", paillier.ErrInvalidCiphertext, i)
+		}
+	}
+
+	if p.RangeProofs {
+		bound := p.bound()
+		for i, ct := range c.Ciphertexts {
+			if err := pk.VerifyBitRangeProofErr(ct, bound, c.Proofs[i]); err != nil {
+				return fmt.Errorf("value %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Decode returns the sums of the values from the decryptions of the
+// ciphertexts of the aggregate. It returns an error if a sum exceeds what
+// Count contributions can add up to.
+func (p *Params) Decode(agg *Aggregate, plaintexts []*gmp.Int) ([]*gmp.Int, error) {
+	if len(plaintexts) != len(agg.Ciphertexts) || len(plaintexts) != p.packedLength() {
+		return nil, errors.New("number of plaintexts does not match the aggregate")
+	}
+	if agg.Count < 1 || agg.Count > p.MaxClients {
+		return nil, errors.New("aggregate has an invalid number of contributions")
+	}
+
+	// every sum is at most Count * (2^ValueBits - 1)
+	bound := new(gmp.Int).Sub(p.bound(), paillier.OneBigInt)
+	bound.Mul(bound, gmp.NewInt(int64(agg.Count)))
+
+	var sums []*gmp.Int
+	for _, m := range plaintexts {
+		values, err := p.packer.Unpack(m, bound)
+		if err != nil {
+			return nil, err
+		}
+		sums = append(sums, values...)
+	}
+	return sums[:p.Dimension], nil
+}
+
+// Decrypt decrypts the aggregate with the committee, see
+// paillier.Coordinator, and returns the sums of the values
+func (p *Params) Decrypt(ctx context.Context, co *paillier.Coordinator, agg *Aggregate, session []byte) ([]*gmp.Int, error) {
+	plaintexts := make([]*gmp.Int, len(agg.Ciphertexts))
+	for i, ct := range agg.Ciphertexts {
+		var err error
+		if plaintexts[i], err = co.Decrypt(ctx, ct, session); err != nil {
+			return nil, err
+		}
+	}
+	return p.Decode(agg, plaintexts)
+}
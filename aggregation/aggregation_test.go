@@ -0,0 +1,146 @@
+package aggregation
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+func getCommittee(t *testing.T) (*paillier.ThresholdPublicKey, *paillier.Coordinator) {
+	tkh, err := paillier.NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := &tpks[0].ThresholdPublicKey
+	transports := make([]paillier.DecryptionTransport, len(tpks))
+	for i, tsk := range tpks {
+		transports[i] = paillier.LocalTransport(tsk)
+	}
+	return key, paillier.NewCoordinator(key, transports)
+}
+
+func testAggregation(t *testing.T, rangeProofs bool) {
+	key, coordinator := getCommittee(t)
+	params, err := NewParams(key, 7, 8, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.RangeProofs = rangeProofs
+
+	aggregator := params.NewAggregator()
+	expected := make([]int, params.Dimension)
+	for client := 0; client < params.MaxClients; client++ {
+		values := make([]*gmp.Int, params.Dimension)
+		for i := range values {
+			v := (client*31 + i*17) % 256
+			values[i] = gmp.NewInt(int64(v))
+			expected[i] += v
+		}
+
+		c, err := params.Contribute(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := aggregator.Add(fmt.Sprint("client-", client), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	agg, err := aggregator.Aggregate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agg.Ciphertexts) != 2 {
+		t.Errorf("aggregate has %d ciphertexts, expected 2", len(agg.Ciphertexts))
+	}
+
+	sums, err := params.Decrypt(context.Background(), coordinator, agg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sum := range sums {
+		if sum.Cmp(gmp.NewInt(int64(expected[i]))) != 0 {
+			t.Errorf("sum %d is %v, expected %d", i, sum, expected[i])
+		}
+	}
+}
+
+func TestAggregation(t *testing.T) {
+	testAggregation(t, false)
+}
+
+func TestAggregationWithRangeProofs(t *testing.T) {
+	testAggregation(t, true)
+}
+
+func TestAggregatorRejects(t *testing.T) {
+	key, _ := getCommittee(t)
+	params, err := NewParams(key, 3, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.RangeProofs = true
+
+	values := []*gmp.Int{gmp.NewInt(1), gmp.NewInt(2), gmp.NewInt(15)}
+	if _, err := params.Contribute([]*gmp.Int{gmp.NewInt(1), gmp.NewInt(2), gmp.NewInt(16)}); err == nil {
+		t.Error("expected an error for a value out of range")
+	}
+
+	aggregator := params.NewAggregator()
+	c, err := params.Contribute(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregator.Add("a", c); err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregator.Add("b", c); err == nil {
+		t.Error("expected an error for a copied contribution")
+	}
+
+	other, err := params.Contribute(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregator.Add("a", other); err == nil {
+		t.Error("expected an error for a second contribution of a client")
+	}
+
+	// a value out of range encrypted with the proof of another value
+	cheating, err := params.Contribute(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cheating.Ciphertexts[0] = key.Encrypt(gmp.NewInt(1000))
+	if err := aggregator.Add("c", cheating); err == nil {
+		t.Error("expected an error for an invalid range proof")
+	}
+
+	if err := aggregator.Add("d", other); err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregator.Add("e", &Contribution{Ciphertexts: other.Ciphertexts[:2], Proofs: other.Proofs[:2]}); err == nil {
+		t.Error("expected an error for a contribution of the wrong dimension")
+	}
+	last, err := params.Contribute(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aggregator.Add("f", last); err == nil {
+		t.Error("expected an error beyond MaxClients contributions")
+	}
+	if aggregator.Len() != 2 {
+		t.Errorf("aggregator has %d contributions, expected 2", aggregator.Len())
+	}
+}
@@ -0,0 +1,193 @@
+package paillier
+
+import (
+	"errors"
+	"io"
+	"math/big"
+)
+
+// The AHE interfaces describe an additively homomorphic encryption scheme
+// independently of Paillier so that aggregation logic can be written once and
+// later run on top of a different backend (e.g., exponential ElGamal or a
+// lattice-based scheme). Plaintexts are exchanged as *big.Int since the gmp
+// types are an implementation detail of this package.
+
+// AHECiphertext is an opaque ciphertext of an AHEScheme
+type AHECiphertext interface {
+	Bytes() []byte
+}
+
+// AHEPublicKey encrypts and homomorphically combines ciphertexts
+type AHEPublicKey interface {
+	// Encrypt encrypts a plaintext in [0, PlaintextModulus())
+	Encrypt(m *big.Int) (AHECiphertext, error)
+
+	// Add returns an encryption of the sum of the plaintexts
+	Add(a, b AHECiphertext) (AHECiphertext, error)
+
+	// ScalarMul returns an encryption of k times the plaintext
+	ScalarMul(ct AHECiphertext, k *big.Int) (AHECiphertext, error)
+
+	// PlaintextModulus returns the modulus of the plaintext space
+	PlaintextModulus() *big.Int
+
+	// Serialize encodes the public key
+	Serialize() ([]byte, error)
+
+	// DeserializeCiphertext decodes a ciphertext produced by AHECiphertext.Bytes
+	DeserializeCiphertext(data []byte) (AHECiphertext, error)
+}
+
+// AHESecretKey additionally decrypts ciphertexts
+type AHESecretKey interface {
+	AHEPublicKey
+
+	// Decrypt returns the plaintext in [0, PlaintextModulus())
+	Decrypt(ct AHECiphertext) (*big.Int, error)
+
+	// Public returns the public part of the key
+	Public() AHEPublicKey
+}
+
+// AHEScheme generates and decodes keys of an additively homomorphic scheme
+type AHEScheme interface {
+	Name() string
+	KeyGen(bits int, random io.Reader) (AHESecretKey, error)
+	DeserializePublicKey(data []byte) (AHEPublicKey, error)
+	DeserializeSecretKey(data []byte) (AHESecretKey, error)
+}
+
+// PaillierAHE is the AHEScheme backed by this package
+var PaillierAHE AHEScheme = paillierScheme{}
+
+var (
+	_ AHECiphertext = (*Ciphertext)(nil)
+	_ AHESecretKey  = aheSecretKey{}
+)
+
+type paillierScheme struct{}
+
+type ahePublicKey struct {
+	pk *PublicKey
+}
+
+type aheSecretKey struct {
+	ahePublicKey
+	sk *SecretKey
+}
+
+// AHE returns the public key as an AHEPublicKey
+func (pk *PublicKey) AHE() AHEPublicKey {
+	return ahePublicKey{pk}
+}
+
+// AHE returns the secret key as an AHESecretKey
+func (sk *SecretKey) AHE() AHESecretKey {
+	return aheSecretKey{ahePublicKey{&sk.PublicKey}, sk}
+}
+
+func (paillierScheme) Name() string {
+	return "paillier"
+}
+
+func (paillierScheme) KeyGen(bits int, random io.Reader) (AHESecretKey, error) {
+	sk, _, err := KeyGenWithRandom(bits, random)
+	if err != nil {
+		return nil, err
+	}
+	return sk.AHE(), nil
+}
+
+func (paillierScheme) DeserializePublicKey(data []byte) (AHEPublicKey, error) {
+	pk := new(PublicKey)
+	if err := pk.GobDecode(data); err != nil {
+		return nil, err
+	}
+	return pk.AHE(), nil
+}
+
+func (paillierScheme) DeserializeSecretKey(data []byte) (AHESecretKey, error) {
+	sk := new(SecretKey)
+	if err := sk.GobDecode(data); err != nil {
+		return nil, err
+	}
+	return sk.AHE(), nil
+}
+
+func (k ahePublicKey) Encrypt(m *big.Int) (AHECiphertext, error) {
+	if m == nil || m.Sign() < 0 || m.Cmp(ToBigInt(k.pk.N)) >= 0 {
+		return nil, errors.New("plaintext is out of range")
+	}
+	return k.pk.Encrypt(ToGmpInt(m)), nil
+}
+
+func (k ahePublicKey) Add(a, b AHECiphertext) (AHECiphertext, error) {
+	ca, err := k.ciphertext(a)
+	if err != nil {
+		return nil, err
+	}
+	cb, err := k.ciphertext(b)
+	if err != nil {
+		return nil, err
+	}
+	return k.pk.Add(ca, cb), nil
+}
+
+func (k ahePublicKey) ScalarMul(ct AHECiphertext, s *big.Int) (AHECiphertext, error) {
+	c, err := k.ciphertext(ct)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errors.New("scalar must not be nil")
+	}
+	// reduce negative scalars into [0, N) so the exponent is non-negative
+	return k.pk.ConstMult(c, ToGmpInt(new(big.Int).Mod(s, ToBigInt(k.pk.N)))), nil
+}
+
+func (k ahePublicKey) PlaintextModulus() *big.Int {
+	return ToBigInt(k.pk.N)
+}
+
+func (k ahePublicKey) Serialize() ([]byte, error) {
+	return k.pk.GobEncode()
+}
+
+func (k ahePublicKey) DeserializeCiphertext(data []byte) (AHECiphertext, error) {
+	ct, err := k.pk.NewCiphertextFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return k.ciphertext(ct)
+}
+
+// ciphertext checks that ct is a level one Paillier ciphertext under the key
+func (k ahePublicKey) ciphertext(ct AHECiphertext) (*Ciphertext, error) {
+	c, ok := ct.(*Ciphertext)
+	if !ok || c == nil || c.C == nil {
+		return nil, errors.New("not a paillier ciphertext")
+	}
+	if c.Level != EncLevelOne {
+		return nil, errors.New("ciphertext must be at encryption level one")
+	}
+	if c.C.Sign() <= 0 || c.C.Cmp(k.pk.GetN2()) >= 0 {
+		return nil, errors.New("ciphertext is out of range")
+	}
+	return c, nil
+}
+
+func (k aheSecretKey) Decrypt(ct AHECiphertext) (*big.Int, error) {
+	c, err := k.ciphertext(ct)
+	if err != nil {
+		return nil, err
+	}
+	return ToBigInt(k.sk.Decrypt(c)), nil
+}
+
+func (k aheSecretKey) Public() AHEPublicKey {
+	return k.ahePublicKey
+}
+
+func (k aheSecretKey) Serialize() ([]byte, error) {
+	return k.sk.GobEncode()
+}
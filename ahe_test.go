@@ -0,0 +1,118 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// aheWeightedSum is written against the AHE interfaces only
+func aheWeightedSum(pk AHEPublicKey, cts []AHECiphertext, weights []int64) (AHECiphertext, error) {
+	acc, err := pk.Encrypt(big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	for i, ct := range cts {
+		term, err := pk.ScalarMul(ct, big.NewInt(weights[i]))
+		if err != nil {
+			return nil, err
+		}
+		if acc, err = pk.Add(acc, term); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+func TestAHEAggregation(t *testing.T) {
+	sk, err := PaillierAHE.KeyGen(128, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := sk.Public().Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := PaillierAHE.DeserializePublicKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := []int64{3, 10, 7}
+	weights := []int64{2, -1, 5}
+	var cts []AHECiphertext
+	for _, v := range values {
+		ct, err := pk.Encrypt(big.NewInt(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// round trip every ciphertext through its serialized form
+		if ct, err = pk.DeserializeCiphertext(ct.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		cts = append(cts, ct)
+	}
+
+	sum, err := aheWeightedSum(pk, cts, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := sk.Decrypt(sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != 31 {
+		t.Error("wrong weighted sum ", m)
+	}
+
+	// -1 weights wrap around the plaintext modulus
+	neg, _ := pk.ScalarMul(cts[0], big.NewInt(-1))
+	m, _ = sk.Decrypt(neg)
+	if want := new(big.Int).Sub(pk.PlaintextModulus(), big.NewInt(3)); m.Cmp(want) != 0 {
+		t.Error("wrong negation ", m)
+	}
+}
+
+func TestAHESecretKeySerialization(t *testing.T) {
+	sk, _ := KeyGen(128)
+	ahe := sk.AHE()
+
+	data, err := ahe.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := PaillierAHE.DeserializeSecretKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, _ := ahe.Encrypt(big.NewInt(42))
+	if m, err := decoded.Decrypt(ct); err != nil || m.Int64() != 42 {
+		t.Error("decoded key failed to decrypt ", m, err)
+	}
+}
+
+func TestAHERejectsInvalidInputs(t *testing.T) {
+	sk, pk := KeyGen(128)
+	ahe := sk.AHE()
+
+	if _, err := ahe.Encrypt(big.NewInt(-1)); err == nil {
+		t.Error("negative plaintext was accepted")
+	}
+	if _, err := ahe.Encrypt(ToBigInt(pk.N)); err == nil {
+		t.Error("plaintext equal to N was accepted")
+	}
+
+	nested := pk.EncryptAtLevel(b(1), EncLevelTwo)
+	if _, err := ahe.Decrypt(nested); err == nil {
+		t.Error("level two ciphertext was accepted")
+	}
+
+	ct := pk.Encrypt(b(1))
+	ct.C = pk.GetN2()
+	if _, err := ahe.Add(ct, ct); err == nil {
+		t.Error("out of range ciphertext was accepted")
+	}
+}
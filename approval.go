@@ -0,0 +1,178 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// domain separation tags of the approval workflow
+var (
+	decryptionRequestTag = []byte("paillier-decryption-request-v1")
+	approvalSessionTag   = []byte("paillier-approval-session-v1")
+)
+
+// DecryptionRequest asks the decryption servers to partially decrypt a
+// ciphertext. The random nonce makes every request, and thus every set of
+// approvals, unique.
+type DecryptionRequest struct {
+	KeyFingerprint string   // fingerprint of the threshold public key
+	Ciphertext     *gmp.Int // the ciphertext to decrypt
+	Nonce          []byte
+	Reason         string // justification shown to the administrators
+}
+
+// ApprovalPolicy requires signed approvals of Required distinct administrators
+// out of Administrators, whose Ed25519 or ECDSA keys sign with ApproveDecryption
+type ApprovalPolicy struct {
+	Administrators []crypto.PublicKey
+	Required       int
+}
+
+// ApprovedDecrypter answers decryption requests only if they are approved
+// according to the policy
+type ApprovedDecrypter struct {
+	Key    *ThresholdSecretKey
+	Policy *ApprovalPolicy
+}
+
+// NewDecryptionRequest returns a request with a fresh nonce to decrypt c
+// under the key
+func NewDecryptionRequest(key *ThresholdPublicKey, c *gmp.Int, reason string) (*DecryptionRequest, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &DecryptionRequest{
+		KeyFingerprint: key.Fingerprint(),
+		Ciphertext:     c,
+		Nonce:          nonce,
+		Reason:         reason,
+	}, nil
+}
+
+// CanonicalBytes returns the encoding of the request signed by administrators
+func (r *DecryptionRequest) CanonicalBytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(decryptionRequestTag)
+	writeCanonicalBytes(&buf, []byte(r.KeyFingerprint))
+	writeCanonicalInt(&buf, r.Ciphertext)
+	writeCanonicalBytes(&buf, r.Nonce)
+	writeCanonicalBytes(&buf, []byte(r.Reason))
+	return buf.Bytes()
+}
+
+// ApproveDecryption signs the request with the identity key of an administrator
+func ApproveDecryption(r *DecryptionRequest, signer crypto.Signer) (*Attestation, error) {
+	return attest(decryptionRequestTag, r.CanonicalBytes(), signer)
+}
+
+// Verify returns nil if approvals contains valid signatures on the request of
+// at least Required distinct administrators. Any approval that is not a valid
+// signature of an administrator is an error.
+func (p *ApprovalPolicy) Verify(r *DecryptionRequest, approvals []*Attestation) error {
+	if p.Required < 1 || p.Required > len(p.Administrators) {
+		return errors.New("required approvals must be between 1 and the number of administrators")
+	}
+
+	administrators := make(map[string]bool, len(p.Administrators))
+	for _, admin := range p.Administrators {
+		der, err := x509.MarshalPKIXPublicKey(admin)
+		if err != nil {
+			return err
+		}
+		administrators[string(der)] = true
+	}
+
+	message := r.CanonicalBytes()
+	approved := make(map[string]bool)
+	for i, approval := range approvals {
+		if approval == nil || !administrators[string(approval.SignerKey)] {
+			return fmt.Errorf("approval %d is not by an administrator", i)
+		}
+		if approved[string(approval.SignerKey)] {
+			return fmt.Errorf("approval %d is a second approval of the same administrator", i)
+		}
+		if err := approval.verify(decryptionRequestTag, message); err != nil {
+			return fmt.Errorf("approval %d: %w", i, err)
+		}
+		approved[string(approval.SignerKey)] = true
+	}
+
+	if len(approved) < p.Required {
+		return fmt.Errorf("%d approvals, %d are required", len(approved), p.Required)
+	}
+
+	return nil
+}
+
+// ApprovalSession returns the digest of the request and its approvals which is
+// bound into the proofs of the partial decryptions as their Session
+func ApprovalSession(r *DecryptionRequest, approvals []*Attestation) []byte {
+	hash := sha256.New()
+	hash.Write(approvalSessionTag)
+	hash.Write(r.CanonicalBytes())
+	for _, approval := range approvals {
+		var buf bytes.Buffer
+		writeCanonicalBytes(&buf, approval.SignerKey)
+		writeCanonicalBytes(&buf, approval.Signature)
+		hash.Write(buf.Bytes())
+	}
+	return hash.Sum(nil)
+}
+
+// PartialDecrypt checks the request against the key and the policy and returns
+// the partial decryption with a proof bound to the approvals
+func (d *ApprovedDecrypter) PartialDecrypt(r *DecryptionRequest, approvals []*Attestation) (*PartialDecryptionZKP, error) {
+	if r.KeyFingerprint != d.Key.Fingerprint() {
+		return nil, errors.New("request is for a different key")
+	}
+
+	if r.Ciphertext == nil {
+		return nil, fmt.Errorf("%w: request has no ciphertext", ErrInvalidCiphertext)
+	}
+
+	if err := d.Policy.Verify(r, approvals); err != nil {
+		logEvent(EventDecryptionRequested, &d.Key.ThresholdPublicKey, d.Key.ID, err)
+		return nil, err
+	}
+
+	return d.Key.PartialDecryptionWithSession(r.Ciphertext, ApprovalSession(r, approvals))
+}
+
+// VerifyApprovedPartialDecryption checks that the partial decryption answers
+// the approved request: the approvals satisfy the policy, the proof is valid
+// under the key and is bound to the request and its approvals
+func VerifyApprovedPartialDecryption(key *ThresholdPublicKey, policy *ApprovalPolicy, r *DecryptionRequest, approvals []*Attestation, pd *PartialDecryptionZKP) error {
+	if r.KeyFingerprint != key.Fingerprint() {
+		return errors.New("request is for a different key")
+	}
+
+	if err := policy.Verify(r, approvals); err != nil {
+		return err
+	}
+
+	if pd.C == nil || r.Ciphertext == nil || pd.C.Cmp(r.Ciphertext) != 0 {
+		return errors.New("partial decryption is not of the requested ciphertext")
+	}
+
+	if !bytes.Equal(pd.Session, ApprovalSession(r, approvals)) {
+		return errors.New("partial decryption is not bound to the approvals")
+	}
+
+	return pd.VerifyErrWithKey(key)
+}
+
+// writes the 4-byte big-endian length followed by the value
+func writeCanonicalBytes(buf *bytes.Buffer, value []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(value)))
+	buf.Write(value)
+}
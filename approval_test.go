@@ -0,0 +1,98 @@
+package paillier
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestApprovedDecryption(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &tsks[0].ThresholdPublicKey
+
+	var signers []crypto.Signer
+	var admins []crypto.PublicKey
+	for i := 0; i < 3; i++ {
+		var signer crypto.Signer
+		if i%2 == 0 {
+			_, signer, err = ed25519.GenerateKey(rand.Reader)
+		} else {
+			signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, signer)
+		admins = append(admins, signer.Public())
+	}
+	policy := &ApprovalPolicy{Administrators: admins, Required: 2}
+
+	ct := key.Encrypt(gmp.NewInt(17))
+	request, err := NewDecryptionRequest(key, ct.C, "audit 2024-Q3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var approvals []*Attestation
+	for _, signer := range signers[1:] {
+		approval, err := ApproveDecryption(request, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		approvals = append(approvals, approval)
+	}
+
+	servers := []*ApprovedDecrypter{{tsks[0], policy}, {tsks[2], policy}}
+	if _, err := servers[0].PartialDecrypt(request, approvals[:1]); err == nil {
+		t.Error("expected error for a single approval")
+	}
+	if _, err := servers[0].PartialDecrypt(request, []*Attestation{approvals[0], approvals[0]}); err == nil {
+		t.Error("expected error for duplicate approvals")
+	}
+
+	other, err := NewDecryptionRequest(key, ct.C, "audit 2024-Q3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := servers[0].PartialDecrypt(other, approvals); err == nil {
+		t.Error("expected error for approvals of another request")
+	}
+
+	var pds []*PartialDecryptionZKP
+	for _, server := range servers {
+		pd, err := server.PartialDecrypt(request, approvals)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyApprovedPartialDecryption(key, policy, request, approvals, pd); err != nil {
+			t.Fatal(err)
+		}
+		pds = append(pds, pd)
+	}
+
+	if m, err := key.CombinePartialDecryptionsZKP(pds); err != nil || m.Int64() != 17 {
+		t.Error("wrong decryption ", m, err)
+	}
+
+	// the proof is bound to the approvals
+	pds[0].Session = ApprovalSession(other, approvals)
+	if pds[0].VerifyProof() {
+		t.Error("proof verifies for another session")
+	}
+	if err := VerifyApprovedPartialDecryption(key, policy, request, approvals, pds[0]); err == nil {
+		t.Error("expected error for proof of another session")
+	}
+}
@@ -0,0 +1,376 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// ArgmaxRequest is sent by the evaluator to the helper. Every comparison of
+// the argmax protocol takes three requests, of which exactly one field is
+// set: the request and the challenge of a Comparator and the selection of
+// the larger candidate.
+type ArgmaxRequest struct {
+	Compare   *ComparisonRequest
+	Challenge *ComparisonChallenge
+	Select    *ArgmaxSelection
+}
+
+// ArgmaxSelection asks the helper to select the first candidate iff Bit
+// decrypts to 1
+type ArgmaxSelection struct {
+	Bit         *Ciphertext
+	FirstValue  *Ciphertext
+	FirstIndex  *Ciphertext
+	SecondValue *Ciphertext
+	SecondIndex *Ciphertext
+}
+
+// ArgmaxResponse is returned by the helper and answers the field set in the
+// request: Bits answers Compare, Zero answers Challenge, and Value and Index
+// are the rerandomized candidate selected for Select
+type ArgmaxResponse struct {
+	Bits  *ComparisonBits
+	Zero  *ComparisonResponse
+	Value *Ciphertext
+	Index *Ciphertext
+}
+
+// ArgmaxEvaluator holds the state of the party that wants to learn the index
+// of the maximum of encrypted values in [0, 2^BitLength) with the help of
+// the holder of the secret key or of a decryption committee (see
+// ArgmaxHelper), e.g., for sealed-bid auctions and leaderboards.
+//
+// The protocol follows the argmax of [BPTG 15], section 4.4. The evaluator
+// visits the values in a secret random order and keeps the encrypted maximum
+// [m] and its encrypted position [i]. In every round it orders [m] and the
+// next value [x] by a random bit f and runs the bitwise comparison of
+// Comparator on them, which yields the encrypted bit [b] that the first
+// candidate is at least the second. It then additively blinds both
+// candidates and the helper decrypts b and returns the candidate it
+// selects. The helper learns the values the Comparator reveals to it, which
+// hide x - m statistically, and b, which is hidden by f; so it learns
+// neither the order of the values nor which candidate won. At the end the
+// helper decrypts the blinded position of the maximum for the evaluator, who
+// learns the argmax and keeps the maximum encrypted.
+//
+// The helper is assumed to follow the protocol; its responses are not proven.
+//
+//	[BPTG 15]: Raphael Bost, Raluca Ada Popa, Stephen Tu, Shafi Goldwasser, (2015)
+//	           Machine Learning Classification over Encrypted Data
+type ArgmaxEvaluator struct {
+	pk        *PublicKey
+	values    []*Ciphertext
+	bitLength int
+
+	permutation []int // position -> index of the value
+	position    int   // position of the next candidate
+	max         *Ciphertext
+	index       *Ciphertext // position of the maximum
+
+	// the comparison of the pending round: the candidates as value and
+	// position in the order of f, the result [b] and the masks of the
+	// selection
+	stage           argmaxStage
+	comparator      *Comparator
+	first, second   [2]*Ciphertext
+	bit             *Ciphertext
+	firstValueMask  *gmp.Int
+	firstIndexMask  *gmp.Int
+	secondValueMask *gmp.Int
+	secondIndexMask *gmp.Int
+
+	revealMask *gmp.Int
+}
+
+// argmaxStage is the request of a round the evaluator waits an answer for
+type argmaxStage int
+
+const (
+	argmaxCompare argmaxStage = iota + 1
+	argmaxChallenge
+	argmaxSelect
+)
+
+// ArgmaxHelper answers the requests of an ArgmaxEvaluator with a Decrypter,
+// i.e., a SecretKey or a ThresholdClient of a decryption committee
+type ArgmaxHelper struct {
+	Key       *PublicKey
+	Decrypter Decrypter
+}
+
+// NewArgmaxEvaluator returns the evaluator of the argmax of at least two
+// level one encryptions of values in [0, 2^bitLength) together with the
+// first request for the helper
+func (pk *PublicKey) NewArgmaxEvaluator(values []*Ciphertext, bitLength int) (*ArgmaxEvaluator, *ArgmaxRequest, error) {
+	if len(values) < 2 {
+		return nil, nil, errors.New("argmax needs at least two values")
+	}
+
+	for _, ct := range values {
+		if ct.Level != EncLevelOne {
+			return nil, nil, errors.New("argmax is only supported for level one ciphertexts")
+		}
+	}
+
+	if bitLength <= 0 {
+		return nil, nil, errors.New("bit length must be positive")
+	}
+
+	// the masked difference of the comparisons must not wrap around N
+	if DefaultAlertStatisticalSecurity+bitLength+2 >= pk.N.BitLen() {
+		return nil, nil, errors.New("public key is too small for the requested bit length")
+	}
+
+	permutation, err := randomPermutation(len(values), pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ae := &ArgmaxEvaluator{
+		pk:          pk,
+		values:      values,
+		bitLength:   bitLength,
+		permutation: permutation,
+		position:    1,
+		max:         values[permutation[0]],
+		index:       pk.Encrypt(gmp.NewInt(0)),
+	}
+
+	req, err := ae.compare()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ae, req, nil
+}
+
+// Next processes the response of the helper and returns the next request or
+// nil if all values have been compared
+func (ae *ArgmaxEvaluator) Next(resp *ArgmaxResponse) (*ArgmaxRequest, error) {
+	if resp == nil {
+		return nil, errors.New("incomplete argmax response")
+	}
+
+	switch ae.stage {
+	case argmaxCompare:
+		if resp.Bits == nil {
+			return nil, errors.New("incomplete argmax response")
+		}
+		challenge, err := ae.comparator.Challenge(resp.Bits)
+		if err != nil {
+			return nil, err
+		}
+		ae.stage = argmaxChallenge
+		return &ArgmaxRequest{Challenge: challenge}, nil
+
+	case argmaxChallenge:
+		if resp.Zero == nil {
+			return nil, errors.New("incomplete argmax response")
+		}
+		bit, err := ae.comparator.Finalize(resp.Zero)
+		if err != nil {
+			return nil, err
+		}
+		ae.bit = bit
+		return ae.selection()
+
+	case argmaxSelect:
+		if resp.Value == nil || resp.Index == nil {
+			return nil, errors.New("incomplete argmax response")
+		}
+		ae.max = ae.unblind(resp.Value, ae.firstValueMask, ae.secondValueMask)
+		ae.index = ae.unblind(resp.Index, ae.firstIndexMask, ae.secondIndexMask)
+		ae.stage = 0
+		ae.position++
+
+		if ae.position == len(ae.values) {
+			return nil, nil
+		}
+		return ae.compare()
+	}
+
+	return nil, errors.New("no pending argmax request")
+}
+
+// Reveal returns the blinded position of the maximum which the helper must
+// decrypt once all values have been compared
+func (ae *ArgmaxEvaluator) Reveal() (*Ciphertext, error) {
+	if ae.position < len(ae.values) {
+		return nil, errors.New("argmax is not complete")
+	}
+
+	mask, err := GetRandomNumber(ae.pk.N, ae.pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	ae.revealMask = mask
+	return ae.pk.Add(ae.index, ae.pk.Encrypt(mask)), nil
+}
+
+// Finalize returns the index of the maximum among the values and the
+// encrypted maximum from the decryption of the ciphertext returned by Reveal
+func (ae *ArgmaxEvaluator) Finalize(revealed *gmp.Int) (int, *Ciphertext, error) {
+	if ae.revealMask == nil {
+		return 0, nil, errors.New("blinded position was not revealed")
+	}
+
+	position := new(gmp.Int).Sub(revealed, ae.revealMask)
+	position.Mod(position, ae.pk.N)
+	if position.Cmp(gmp.NewInt(int64(len(ae.values)))) >= 0 {
+		return 0, nil, errors.New("revealed position is out of range")
+	}
+
+	return ae.permutation[position.Int64()], ae.max, nil
+}
+
+// starts the comparison of the current maximum with the next value, in the
+// order of a random bit f, and returns the request of the Comparator
+func (ae *ArgmaxEvaluator) compare() (*ArgmaxRequest, error) {
+	pk := ae.pk
+
+	flipBit, err := GetRandomNumber(TwoBigInt, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	candidate := [2]*Ciphertext{ae.values[ae.permutation[ae.position]], pk.Encrypt(gmp.NewInt(int64(ae.position)))}
+	current := [2]*Ciphertext{ae.max, ae.index}
+	ae.first, ae.second = candidate, current
+	if flipBit.Cmp(OneBigInt) == 0 {
+		ae.first, ae.second = current, candidate
+	}
+
+	comparator, req, err := pk.NewComparator(ae.first[0], ae.second[0], ae.bitLength)
+	if err != nil {
+		return nil, err
+	}
+
+	ae.comparator = comparator
+	ae.stage = argmaxCompare
+	return &ArgmaxRequest{Compare: req}, nil
+}
+
+// returns the request to select the first candidate iff [b] decrypts to 1,
+// with both candidates additively blinded
+func (ae *ArgmaxEvaluator) selection() (*ArgmaxRequest, error) {
+	pk := ae.pk
+
+	masks := make([]*gmp.Int, 4)
+	for i := range masks {
+		mask, err := GetRandomNumber(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		masks[i] = mask
+	}
+	ae.firstValueMask, ae.firstIndexMask = masks[0], masks[1]
+	ae.secondValueMask, ae.secondIndexMask = masks[2], masks[3]
+	ae.stage = argmaxSelect
+
+	return &ArgmaxRequest{Select: &ArgmaxSelection{
+		Bit:         ae.bit,
+		FirstValue:  pk.Add(ae.first[0], pk.Encrypt(masks[0])),
+		FirstIndex:  pk.Add(ae.first[1], pk.Encrypt(masks[1])),
+		SecondValue: pk.Add(ae.second[0], pk.Encrypt(masks[2])),
+		SecondIndex: pk.Add(ae.second[1], pk.Encrypt(masks[3])),
+	}}, nil
+}
+
+// removes the mask of the selected candidate from the selection:
+// [s] - [b]^(first - second) - [second]
+func (ae *ArgmaxEvaluator) unblind(selected *Ciphertext, first, second *gmp.Int) *Ciphertext {
+	pk := ae.pk
+	delta := new(gmp.Int).Sub(first, second)
+	delta.Mod(delta, pk.N)
+	return pk.Sub(selected, pk.ConstMult(ae.bit, delta), pk.Encrypt(second))
+}
+
+// NewArgmaxHelper returns the helper for evaluators with the public key pk
+func NewArgmaxHelper(pk *PublicKey, dec Decrypter) *ArgmaxHelper {
+	return &ArgmaxHelper{Key: pk, Decrypter: dec}
+}
+
+// Assist answers a request of the evaluator: it runs the ComparisonHelper on
+// the Comparator requests and returns the selected candidate for a
+// selection. The helper learns the comparison bit of the candidates but not
+// which of them is the larger one.
+func (h *ArgmaxHelper) Assist(req *ArgmaxRequest) (*ArgmaxResponse, error) {
+	if req == nil {
+		return nil, errors.New("incomplete argmax request")
+	}
+
+	comparison := NewComparisonHelper(h.Key, h.Decrypter)
+	switch {
+	case req.Compare != nil:
+		bits, err := comparison.Decompose(req.Compare)
+		if err != nil {
+			return nil, err
+		}
+		return &ArgmaxResponse{Bits: bits}, nil
+
+	case req.Challenge != nil:
+		zero, err := comparison.Evaluate(req.Challenge)
+		if err != nil {
+			return nil, err
+		}
+		return &ArgmaxResponse{Zero: zero}, nil
+
+	case req.Select != nil:
+		return h.selectCandidate(req.Select)
+	}
+
+	return nil, errors.New("incomplete argmax request")
+}
+
+// decrypts the comparison bit and returns the rerandomized candidate it
+// selects
+func (h *ArgmaxHelper) selectCandidate(sel *ArgmaxSelection) (*ArgmaxResponse, error) {
+	if sel.Bit == nil || sel.FirstValue == nil || sel.FirstIndex == nil ||
+		sel.SecondValue == nil || sel.SecondIndex == nil {
+		return nil, errors.New("incomplete argmax request")
+	}
+
+	b, err := h.Decrypter.TryDecrypt(sel.Bit)
+	if err != nil {
+		return nil, fmt.Errorf("comparison could not be decrypted: %w", err)
+	}
+
+	pk := h.Key
+	switch {
+	case b.Cmp(OneBigInt) == 0:
+		return &ArgmaxResponse{Value: pk.Randomize(sel.FirstValue), Index: pk.Randomize(sel.FirstIndex)}, nil
+	case b.Sign() == 0:
+		return &ArgmaxResponse{Value: pk.Randomize(sel.SecondValue), Index: pk.Randomize(sel.SecondIndex)}, nil
+	}
+	return nil, errors.New("comparison result is not a bit")
+}
+
+// Reveal decrypts the blinded position of the maximum for the evaluator
+func (h *ArgmaxHelper) Reveal(ct *Ciphertext) (*gmp.Int, error) {
+	m, err := h.Decrypter.TryDecrypt(ct)
+	if err != nil {
+		return nil, fmt.Errorf("blinded position could not be decrypted: %w", err)
+	}
+	return m, nil
+}
+
+// returns a uniformly random permutation of 0..n-1 (Fisher-Yates)
+func randomPermutation(n int, random RandomSource) ([]int, error) {
+	permutation := make([]int, n)
+	for i := range permutation {
+		permutation[i] = i
+	}
+
+	for i := n - 1; i > 0; i-- {
+		j, err := GetRandomNumber(gmp.NewInt(int64(i+1)), random)
+		if err != nil {
+			return nil, err
+		}
+		permutation[i], permutation[j.Int64()] = permutation[j.Int64()], permutation[i]
+	}
+
+	return permutation, nil
+}
@@ -0,0 +1,144 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func runArgmax(t *testing.T, pk *PublicKey, helper *ArgmaxHelper, values []int64) (int, *Ciphertext) {
+	cts := make([]*Ciphertext, len(values))
+	for i, v := range values {
+		cts[i] = pk.Encrypt(gmp.NewInt(v))
+	}
+
+	evaluator, req, err := pk.NewArgmaxEvaluator(cts, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for req != nil {
+		resp, err := helper.Assist(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req, err = evaluator.Next(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blinded, err := evaluator.Reveal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revealed, err := helper.Reveal(blinded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, max, err := evaluator.Finalize(revealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index, max
+}
+
+func TestArgmax(t *testing.T) {
+	sk, pk := KeyGen(128)
+	helper := NewArgmaxHelper(pk, sk)
+
+	for _, values := range [][]int64{
+		{3, 9},
+		{9, 3},
+		{5, 17, 2, 65535, 0, 1000},
+		{0, 0, 0, 1},
+		{42, 7, 7, 41},
+	} {
+		index, max := runArgmax(t, pk, helper, values)
+
+		expected := 0
+		for i, v := range values {
+			if v > values[expected] {
+				expected = i
+			}
+		}
+		if values[index] != values[expected] {
+			t.Error("wrong argmax ", index, " for ", values)
+		}
+		if m := sk.Decrypt(max); n(m) != int(values[expected]) {
+			t.Error("wrong maximum ", m, " for ", values)
+		}
+	}
+}
+
+func TestArgmaxWithCommittee(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(128, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := &tsks[0].ThresholdPublicKey
+	client := NewThresholdClient(key, []PartialDecrypter{tsks[0], tsks[1], tsks[2]})
+	helper := NewArgmaxHelper(&key.PublicKey, client)
+
+	if index, _ := runArgmax(t, &key.PublicKey, helper, []int64{12, 480, 3}); index != 1 {
+		t.Error("wrong argmax ", index)
+	}
+}
+
+func TestArgmaxParameters(t *testing.T) {
+	_, pk := KeyGen(64)
+	cts := []*Ciphertext{pk.Encrypt(b(1)), pk.Encrypt(b(2))}
+
+	if _, _, err := pk.NewArgmaxEvaluator(cts[:1], 8); err == nil {
+		t.Error("expected error for a single value")
+	}
+	if _, _, err := pk.NewArgmaxEvaluator(cts, 32); err == nil {
+		t.Error("expected error for a key that is too small")
+	}
+}
+
+func TestArgmaxRejectsMalformedMessages(t *testing.T) {
+	sk, pk := KeyGen(128)
+	helper := NewArgmaxHelper(pk, sk)
+	cts := []*Ciphertext{pk.Encrypt(b(5)), pk.Encrypt(b(17))}
+
+	evaluator, req, err := pk.NewArgmaxEvaluator(cts, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Compare == nil || req.Challenge != nil || req.Select != nil {
+		t.Fatal("first request is not a comparison request")
+	}
+
+	// the evaluator expects the bits of the comparison first
+	if _, err := evaluator.Next(&ArgmaxResponse{Value: cts[0], Index: cts[1]}); err == nil {
+		t.Error("expected error for a response to another request")
+	}
+
+	for req.Select == nil {
+		resp, err := helper.Assist(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req, err = evaluator.Next(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the helper only selects by a bit
+	sel := *req.Select
+	sel.Bit = pk.Encrypt(b(2))
+	if _, err := helper.Assist(&ArgmaxRequest{Select: &sel}); err == nil {
+		t.Error("expected error for a comparison result that is not a bit")
+	}
+	if _, err := helper.Assist(&ArgmaxRequest{}); err == nil {
+		t.Error("expected error for an empty request")
+	}
+}
@@ -0,0 +1,194 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// domain separation tags of the signed messages
+var (
+	keyAttestationTag      = []byte("paillier-key-attestation-v1")
+	identityAttestationTag = []byte("paillier-identity-attestation-v1")
+)
+
+// Attestable is implemented by keys with a canonical encoding,
+// i.e., *PublicKey and *ThresholdPublicKey
+type Attestable interface {
+	CanonicalBytes() []byte
+}
+
+// Attestation is a signature by an Ed25519 or ECDSA identity key.
+// An attestation chain starts with the attestation of a Paillier key and
+// every following attestation signs the identity key of its predecessor.
+type Attestation struct {
+	SignerKey []byte // PKIX, ASN.1 DER encoding of the identity public key
+	Signature []byte
+}
+
+// CanonicalBytes returns the canonical encoding of the public key: the
+// length-prefixed values N, G, H and K where a missing G is set to N+1
+func (pk *PublicKey) CanonicalBytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("paillier-public-key")
+	pk.writeCanonical(&buf)
+	return buf.Bytes()
+}
+
+// CanonicalBytes returns the canonical encoding of the threshold public key,
+// which covers the public key, the committee parameters and the verification keys
+func (tk *ThresholdPublicKey) CanonicalBytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("paillier-threshold-public-key")
+	tk.PublicKey.writeCanonical(&buf)
+
+	binary.Write(&buf, binary.BigEndian, uint32(tk.TotalNumberOfDecryptionServers))
+	binary.Write(&buf, binary.BigEndian, uint32(tk.Threshold))
+	writeCanonicalInt(&buf, tk.VerificationKey)
+	for _, vi := range tk.VerificationKeys {
+		writeCanonicalInt(&buf, vi)
+	}
+	// appended only for S > 1 so that the encoding of other keys is unchanged
+	if tk.S > 1 {
+		binary.Write(&buf, binary.BigEndian, uint32(tk.S))
+	}
+
+	return buf.Bytes()
+}
+
+// AttestKey signs the canonical encoding of key with the identity key signer,
+// which must be an ed25519.PrivateKey or an *ecdsa.PrivateKey
+func AttestKey(key Attestable, signer crypto.Signer) (*Attestation, error) {
+	return attest(keyAttestationTag, key.CanonicalBytes(), signer)
+}
+
+// AttestIdentity signs the identity key subject with the identity key signer
+// to extend an attestation chain
+func AttestIdentity(subject crypto.PublicKey, signer crypto.Signer) (*Attestation, error) {
+	der, err := x509.MarshalPKIXPublicKey(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return attest(identityAttestationTag, der, signer)
+}
+
+// VerifyAttestationChain checks that key is attested by the chain and that
+// the last signer of the chain is one of the trusted roots. Clients should
+// call it before encrypting to an imported key.
+func VerifyAttestationChain(key Attestable, chain []*Attestation, roots []crypto.PublicKey) error {
+	if len(chain) == 0 {
+		return errors.New("empty attestation chain")
+	}
+
+	tag := keyAttestationTag
+	message := key.CanonicalBytes()
+	for _, attestation := range chain {
+		if err := attestation.verify(tag, message); err != nil {
+			return err
+		}
+
+		tag = identityAttestationTag
+		message = attestation.SignerKey
+	}
+
+	for _, root := range roots {
+		der, err := x509.MarshalPKIXPublicKey(root)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(der, message) {
+			return nil
+		}
+	}
+
+	return errors.New("attestation chain does not end in a trusted root")
+}
+
+func attest(tag, message []byte, signer crypto.Signer) (*Attestation, error) {
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	signed := append(append([]byte{}, tag...), message...)
+
+	var signature []byte
+	switch signer.(type) {
+	case ed25519.PrivateKey:
+		signature, err = signer.Sign(rand.Reader, signed, crypto.Hash(0))
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256(signed)
+		signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, errors.New("identity key must be an Ed25519 or ECDSA key")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attestation{
+		SignerKey: der,
+		Signature: signature,
+	}, nil
+}
+
+func (a *Attestation) verify(tag, message []byte) error {
+	signerKey, err := x509.ParsePKIXPublicKey(a.SignerKey)
+	if err != nil {
+		return err
+	}
+
+	signed := append(append([]byte{}, tag...), message...)
+
+	valid := false
+	switch signerKey := signerKey.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(signerKey, signed, a.Signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		valid = ecdsa.VerifyASN1(signerKey, digest[:], a.Signature)
+	default:
+		return errors.New("identity key must be an Ed25519 or ECDSA key")
+	}
+
+	if !valid {
+		return errors.New("invalid attestation signature")
+	}
+
+	return nil
+}
+
+func (pk *PublicKey) writeCanonical(buf *bytes.Buffer) {
+	g := pk.G
+	if g == nil {
+		g = new(gmp.Int).Add(pk.N, OneBigInt)
+	}
+
+	writeCanonicalInt(buf, pk.N)
+	writeCanonicalInt(buf, g)
+	writeCanonicalInt(buf, pk.H)
+	writeCanonicalInt(buf, pk.K)
+}
+
+// writes the 4-byte big-endian length followed by the big-endian value;
+// nil is encoded as the empty value
+func writeCanonicalInt(buf *bytes.Buffer, x *gmp.Int) {
+	var value []byte
+	if x != nil {
+		value = x.Bytes()
+	}
+
+	binary.Write(buf, binary.BigEndian, uint32(len(value)))
+	buf.Write(value)
+}
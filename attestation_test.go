@@ -0,0 +1,65 @@
+package paillier
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestAttestationChain(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &tpks[0].ThresholdPublicKey
+
+	rootPub, rootKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committeeKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyAttestation, err := AttestKey(key, committeeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityAttestation, err := AttestIdentity(committeeKey.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := []*Attestation{keyAttestation, identityAttestation}
+	roots := []crypto.PublicKey{rootPub}
+
+	// the key received by a client has no generator G set
+	if err := VerifyAttestationChain(tpks[1].PublicKey(), chain, roots); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyAttestationChain(key, chain[:1], roots); err == nil {
+		t.Error("expected error for chain without trusted root")
+	}
+
+	other, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAttestationChain(&other[0].ThresholdPublicKey, chain, roots); err == nil {
+		t.Error("expected error for key of another committee")
+	}
+
+	if err := VerifyAttestationChain(&key.PublicKey, chain, roots); err == nil {
+		t.Error("threshold key attestation accepted for the plain public key")
+	}
+}
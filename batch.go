@@ -0,0 +1,108 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// EncryptBatch encrypts the plaintexts at the default encryption level on one
+// goroutine per core; the i-th ciphertext encrypts the i-th plaintext
+func (pk *PublicKey) EncryptBatch(ms []*gmp.Int) []*Ciphertext {
+	cts := make([]*Ciphertext, len(ms))
+	parallelFor(len(ms), func(i int) {
+		cts[i] = pk.Encrypt(ms[i])
+	})
+	return cts
+}
+
+// DecryptBatch decrypts the ciphertexts on one goroutine per core; the i-th
+// plaintext is the decryption of the i-th ciphertext
+func (sk *SecretKey) DecryptBatch(cts []*Ciphertext) []*gmp.Int {
+	ms := make([]*gmp.Int, len(cts))
+	parallelFor(len(cts), func(i int) {
+		ms[i] = sk.Decrypt(cts[i])
+	})
+	return ms
+}
+
+// PartialDecryptBatch partially decrypts the ciphertexts at their levels on
+// one goroutine per core. It returns the error of the first ciphertext that
+// cannot be decrypted with the key share.
+func (tsk *ThresholdSecretKey) PartialDecryptBatch(cts []*Ciphertext) ([]*PartialDecryption, error) {
+	shares := make([]*PartialDecryption, len(cts))
+	errs := make([]error, len(cts))
+	parallelFor(len(cts), func(i int) {
+		shares[i], errs[i] = tsk.PartialDecryptAtLevel(cts[i].C, cts[i].Level)
+	})
+
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// CombinePartialDecryptionsBatch combines the partial decryptions of several
+// ciphertexts at the same level on one goroutine per core. byServer holds
+// the results of PartialDecryptBatch of the participating servers, i.e.,
+// byServer[j][i] is the share of server j for the i-th ciphertext.
+func (tk *ThresholdPublicKey) CombinePartialDecryptionsBatch(byServer [][]*PartialDecryption, level EncryptionLevel) ([]*gmp.Int, error) {
+	if len(byServer) == 0 {
+		return nil, errors.New("no partial decryptions provided")
+	}
+
+	count := len(byServer[0])
+	for _, shares := range byServer {
+		if len(shares) != count {
+			return nil, errors.New("servers partially decrypted different numbers of ciphertexts")
+		}
+	}
+
+	ms := make([]*gmp.Int, count)
+	errs := make([]error, count)
+	parallelFor(count, func(i int) {
+		shares := make([]*PartialDecryption, len(byServer))
+		for j := range byServer {
+			shares[j] = byServer[j][i]
+		}
+		ms[i], errs[i] = tk.CombinePartialDecryptionsAtLevel(shares, level)
+	})
+
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// parallelFor calls fn for 0 <= i < n on one goroutine per core
+func parallelFor(n int, fn func(i int)) {
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += workers {
+				fn(i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// firstError returns the error with the lowest index, annotated with it
+func firstError(errs []error) error {
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("ciphertext %d: %w", i, err)
+		}
+	}
+	return nil
+}
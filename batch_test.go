@@ -0,0 +1,80 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestEncryptDecryptBatch(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	ms := make([]*gmp.Int, 50)
+	for i := range ms {
+		ms[i] = b(i * i)
+	}
+
+	cts := pk.EncryptBatch(ms)
+	if len(cts) != len(ms) {
+		t.Fatal("wrong number of ciphertexts ", len(cts))
+	}
+	for i, m := range sk.DecryptBatch(cts) {
+		if m.Cmp(ms[i]) != 0 {
+			t.Error("wrong decryption at index ", i, ": ", m)
+		}
+	}
+
+	if len(pk.EncryptBatch(nil)) != 0 || len(sk.DecryptBatch(nil)) != 0 {
+		t.Error("empty batches are not empty")
+	}
+}
+
+func TestThresholdDecryptBatch(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms := make([]*gmp.Int, 20)
+	for i := range ms {
+		ms[i] = b(3 * i)
+	}
+	cts := tsks[0].EncryptBatch(ms)
+
+	var byServer [][]*PartialDecryption
+	for _, tsk := range []*ThresholdSecretKey{tsks[0], tsks[2]} {
+		shares, err := tsk.PartialDecryptBatch(cts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byServer = append(byServer, shares)
+	}
+
+	decrypted, err := tsks[1].CombinePartialDecryptionsBatch(byServer, EncLevelOne)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range decrypted {
+		if m.Cmp(ms[i]) != 0 {
+			t.Error("wrong decryption at index ", i, ": ", m)
+		}
+	}
+
+	if _, err := tsks[1].CombinePartialDecryptionsBatch(byServer[:1], EncLevelOne); err == nil {
+		t.Error("combined fewer shares than the threshold")
+	}
+	if _, err := tsks[1].CombinePartialDecryptionsBatch([][]*PartialDecryption{byServer[0], byServer[1][1:]}, EncLevelOne); err == nil {
+		t.Error("combined batches of different lengths")
+	}
+
+	cts[5] = tsks[0].EncryptAtLevel(b(1), EncLevelTwo)
+	if _, err := tsks[0].PartialDecryptBatch(cts); err == nil {
+		t.Error("partially decrypted a ciphertext above the level of the key")
+	}
+}
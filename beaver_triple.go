@@ -0,0 +1,328 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// BeaverTriple is one party's additive share of a multiplication triple
+// (a, b, c) with c = a*b mod the modulus of the triple generation
+type BeaverTriple struct {
+	A, B, C *gmp.Int
+}
+
+// TripleRequest is sent by the key holder to the other party and contains
+// the encryptions of the key holder's shares a_1 and b_1 of every triple
+// together with proofs that they are in [0, Modulus)
+type TripleRequest struct {
+	Modulus *gmp.Int
+	A, B    []*Ciphertext
+	ProofsA []*RangeProof
+	ProofsB []*RangeProof
+}
+
+// TripleResponse is returned by the other party and contains the
+// encryptions [a_1*b_2 + a_2*b_1 + beta] together with proofs that they were
+// computed from the request with shares and masks in range
+type TripleResponse struct {
+	D      []*Ciphertext
+	Proofs []*AffineProof
+}
+
+// AffineProof is a non-interactive proof (Fiat-Shamir heuristic) that
+// D = X^b Y^a g^beta rho^N mod N^2 for known b, a in [0, Modulus) and beta in
+// [0, MaskBound), up to the slack of RangeProof
+type AffineProof struct {
+	T          *gmp.Int // commitment
+	ZB, ZA, ZM *gmp.Int // responses for b, a and beta over the integers
+	W          *gmp.Int // response for rho
+}
+
+// TripleGenerator holds the state of the key holder in the generation of
+// Beaver triples [Bea 91] for two-party MPC preprocessing. It is the
+// multiplicative-to-additive share conversion (MtA) of [Gil 99] for both
+// cross terms of (a_1 + a_2)(b_1 + b_2):
+//  1. the key holder sends [a_1], [b_1] with range proofs
+//  2. the other party picks a_2, b_2 and a mask beta, returns
+//     [a_1*b_2 + a_2*b_1 + beta] with a proof of the affine operation and
+//     keeps c_2 = a_2*b_2 - beta mod M
+//  3. the key holder decrypts alpha = a_1*b_2 + a_2*b_1 + beta and keeps
+//     c_1 = a_1*b_1 + alpha mod M
+//
+// The mask beta is large enough to statistically hide the cross terms from
+// the key holder, and the proofs keep a malicious party from choosing shares
+// out of range to learn the other party's shares. The public key must have
+// at least 2|M| + 380 bits, e.g., 512 bits for 64-bit moduli.
+//
+//	[Bea 91]: Donald Beaver, (1991)
+//	          Efficient Multiparty Protocols Using Circuit Randomization
+//	[Gil 99]: Niv Gilboa, (1999)
+//	          Two Party RSA Key Generation
+type TripleGenerator struct {
+	sk      *SecretKey
+	request *TripleRequest
+	a, b    []*gmp.Int
+}
+
+// NewTripleGenerator samples the key holder's shares of count triples
+// modulo modulus and returns the generator with the request for the other
+// party
+func (sk *SecretKey) NewTripleGenerator(modulus *gmp.Int, count int) (*TripleGenerator, *TripleRequest, error) {
+	pk := &sk.PublicKey
+	if err := pk.checkTripleParameters(modulus); err != nil {
+		return nil, nil, err
+	}
+
+	if count <= 0 {
+		return nil, nil, errors.New("number of triples must be positive")
+	}
+
+	tg := &TripleGenerator{sk: sk, a: make([]*gmp.Int, count), b: make([]*gmp.Int, count)}
+	req := &TripleRequest{
+		Modulus: modulus,
+		A:       make([]*Ciphertext, count),
+		B:       make([]*Ciphertext, count),
+		ProofsA: make([]*RangeProof, count),
+		ProofsB: make([]*RangeProof, count),
+	}
+
+	for i := 0; i < count; i++ {
+		var err error
+		if tg.a[i], req.A[i], req.ProofsA[i], err = pk.encryptShareWithProof(modulus); err != nil {
+			return nil, nil, err
+		}
+		if tg.b[i], req.B[i], req.ProofsB[i], err = pk.encryptShareWithProof(modulus); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tg.request = req
+	return tg, req, nil
+}
+
+// RespondTriples is run by the other party on a request of the key holder.
+// It verifies the range proofs and returns its shares of the triples and
+// the response for the key holder.
+func (pk *PublicKey) RespondTriples(req *TripleRequest) ([]*BeaverTriple, *TripleResponse, error) {
+	if err := pk.checkTripleParameters(req.Modulus); err != nil {
+		return nil, nil, err
+	}
+
+	count := len(req.A)
+	if count == 0 || len(req.B) != count || len(req.ProofsA) != count || len(req.ProofsB) != count {
+		return nil, nil, errors.New("malformed triple request")
+	}
+
+	modulus := req.Modulus
+	maskBound := tripleMaskBound(modulus)
+	triples := make([]*BeaverTriple, count)
+	resp := &TripleResponse{D: make([]*Ciphertext, count), Proofs: make([]*AffineProof, count)}
+
+	for i := 0; i < count; i++ {
+		if err := pk.VerifyRangeProofErr(req.A[i], modulus, req.ProofsA[i]); err != nil {
+			return nil, nil, fmt.Errorf("triple %d: %w", i, err)
+		}
+		if err := pk.VerifyRangeProofErr(req.B[i], modulus, req.ProofsB[i]); err != nil {
+			return nil, nil, fmt.Errorf("triple %d: %w", i, err)
+		}
+
+		random := pk.RandomSource()
+		a, err := GetRandomNumber(modulus, random)
+		if err != nil {
+			return nil, nil, err
+		}
+		b, err := GetRandomNumber(modulus, random)
+		if err != nil {
+			return nil, nil, err
+		}
+		beta, err := GetRandomNumber(maskBound, random)
+		if err != nil {
+			return nil, nil, err
+		}
+		rho, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// [a_1*b_2 + a_2*b_1 + beta]
+		d := pk.Add(pk.ConstMult(req.A[i], b), pk.ConstMult(req.B[i], a), pk.EncryptWithR(beta, rho))
+		d.EncMethod = RegularEncryption
+		resp.D[i] = d
+
+		resp.Proofs[i], err = pk.proveAffine(req.A[i], req.B[i], d, b, a, beta, rho, modulus, maskBound)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		c := new(gmp.Int).Mul(a, b)
+		c.Sub(c, beta)
+		c.Mod(c, modulus)
+		triples[i] = &BeaverTriple{A: a, B: b, C: c}
+	}
+
+	return triples, resp, nil
+}
+
+// Finalize verifies the response of the other party and returns the key
+// holder's shares of the triples
+func (tg *TripleGenerator) Finalize(resp *TripleResponse) ([]*BeaverTriple, error) {
+	req := tg.request
+	pk := &tg.sk.PublicKey
+	if len(resp.D) != len(tg.a) || len(resp.Proofs) != len(tg.a) {
+		return nil, errors.New("malformed triple response")
+	}
+
+	maskBound := tripleMaskBound(req.Modulus)
+	triples := make([]*BeaverTriple, len(tg.a))
+	for i := range tg.a {
+		if err := pk.VerifyAffineProofErr(req.A[i], req.B[i], resp.D[i], req.Modulus, maskBound, resp.Proofs[i]); err != nil {
+			return nil, fmt.Errorf("triple %d: %w", i, err)
+		}
+
+		c := new(gmp.Int).Mul(tg.a[i], tg.b[i])
+		c.Add(c, tg.sk.Decrypt(resp.D[i]))
+		c.Mod(c, req.Modulus)
+		triples[i] = &BeaverTriple{A: tg.a[i], B: tg.b[i], C: c}
+	}
+
+	return triples, nil
+}
+
+// proves that d = x^b y^a g^beta rho^N mod N^2
+func (pk *PublicKey) proveAffine(x, y, d *Ciphertext, b, a, beta, rho, modulus, maskBound *gmp.Int) (*AffineProof, error) {
+	n2 := pk.GetN2()
+	shareBound := new(gmp.Int).Lsh(modulus, slackBits)
+	maskSlack := new(gmp.Int).Lsh(maskBound, slackBits)
+	random := pk.RandomSource()
+
+	for {
+		alphaB, err := GetRandomNumber(shareBound, random)
+		if err != nil {
+			return nil, err
+		}
+		alphaA, err := GetRandomNumber(shareBound, random)
+		if err != nil {
+			return nil, err
+		}
+		gamma, err := GetRandomNumber(maskSlack, random)
+		if err != nil {
+			return nil, err
+		}
+		mu, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+		if err != nil {
+			return nil, err
+		}
+
+		t := new(gmp.Int).Exp(x.C, alphaB, n2)
+		t.Mul(t, new(gmp.Int).Exp(y.C, alphaA, n2))
+		t.Mul(t, pk.EncryptWithR(gamma, mu).C)
+		t.Mod(t, n2)
+
+		e := RandomOracleChallenge(slackChallengeBits, pk.N, x.C, y.C, d.C, modulus, maskBound, t)
+
+		// retry in the rare case that a response would reveal its witness
+		zb := new(gmp.Int).Add(alphaB, new(gmp.Int).Mul(e, b))
+		za := new(gmp.Int).Add(alphaA, new(gmp.Int).Mul(e, a))
+		zm := new(gmp.Int).Add(gamma, new(gmp.Int).Mul(e, beta))
+		if zb.Cmp(shareBound) >= 0 || za.Cmp(shareBound) >= 0 || zm.Cmp(maskSlack) >= 0 {
+			continue
+		}
+
+		w := new(gmp.Int).Exp(rho, e, pk.N)
+		w.Mul(w, mu)
+		w.Mod(w, pk.N)
+
+		return &AffineProof{T: t, ZB: zb, ZA: za, ZM: zm, W: w}, nil
+	}
+}
+
+// VerifyAffineProof returns true iff the proof shows that d = x^b y^a g^beta
+// rho^N mod N^2 for b, a in [0, modulus) and beta in [0, maskBound) up to the
+// slack of the proof
+func (pk *PublicKey) VerifyAffineProof(x, y, d *Ciphertext, modulus, maskBound *gmp.Int, proof *AffineProof) bool {
+	return pk.VerifyAffineProofErr(x, y, d, modulus, maskBound, proof) == nil
+}
+
+// VerifyAffineProofErr verifies the proof as VerifyAffineProof and returns an
+// error wrapping ErrMalformedProof or ErrProofPart1 if it is rejected
+func (pk *PublicKey) VerifyAffineProofErr(x, y, d *Ciphertext, modulus, maskBound *gmp.Int, proof *AffineProof) error {
+	if proof == nil || proof.T == nil || proof.ZB == nil || proof.ZA == nil || proof.ZM == nil ||
+		proof.W == nil || d == nil || d.C == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if x.Level != EncLevelOne || y.Level != EncLevelOne || d.Level != EncLevelOne {
+		return fmt.Errorf("%w: ciphertexts must be level one ciphertexts", ErrMalformedProof)
+	}
+
+	shareBound := new(gmp.Int).Lsh(modulus, slackBits)
+	maskSlack := new(gmp.Int).Lsh(maskBound, slackBits)
+	if proof.ZB.Sign() < 0 || proof.ZB.Cmp(shareBound) >= 0 || proof.ZA.Sign() < 0 ||
+		proof.ZA.Cmp(shareBound) >= 0 || proof.ZM.Sign() < 0 || proof.ZM.Cmp(maskSlack) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+
+	if err := pk.checkUnits(proof.W); err != nil {
+		return err
+	}
+
+	n2 := pk.GetN2()
+	e := RandomOracleChallenge(slackChallengeBits, pk.N, x.C, y.C, d.C, modulus, maskBound, proof.T)
+
+	// x^ZB y^ZA g^ZM W^N = T d^e
+	lhs := new(gmp.Int).Exp(x.C, proof.ZB, n2)
+	lhs.Mul(lhs, new(gmp.Int).Exp(y.C, proof.ZA, n2))
+	lhs.Mul(lhs, pk.EncryptWithR(proof.ZM, proof.W).C)
+	lhs.Mod(lhs, n2)
+
+	rhs := new(gmp.Int).Exp(d.C, e, n2)
+	rhs.Mul(rhs, proof.T)
+	rhs.Mod(rhs, n2)
+	if lhs.Cmp(rhs) != 0 {
+		return ErrProofPart1
+	}
+
+	return nil
+}
+
+// encrypts a random share in [0, modulus) with a range proof
+func (pk *PublicKey) encryptShareWithProof(modulus *gmp.Int) (*gmp.Int, *Ciphertext, *RangeProof, error) {
+	x, err := GetRandomNumber(modulus, pk.RandomSource())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ct := pk.EncryptWithR(x, r)
+	proof, err := pk.ProveRange(ct, x, r, modulus)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return x, ct, proof, nil
+}
+
+// returns the bound of the mask beta, which hides the cross terms of up to
+// 2 M^2 2^168 by another 2^40
+func tripleMaskBound(modulus *gmp.Int) *gmp.Int {
+	bound := new(gmp.Int).Mul(modulus, modulus)
+	return bound.Lsh(bound, slackBits+slackStatisticalSecurity+1)
+}
+
+// the decrypted alpha < 2 (M 2^168)^2 + maskBound 2^168 < M^2 2^378 must not
+// wrap mod N
+func (pk *PublicKey) checkTripleParameters(modulus *gmp.Int) error {
+	if modulus == nil || modulus.Cmp(TwoBigInt) < 0 {
+		return errors.New("modulus must be at least 2")
+	}
+
+	if pk.N.BitLen() < 2*modulus.BitLen()+2*slackBits+slackStatisticalSecurity+4 {
+		return errors.New("public key is too small for the modulus")
+	}
+
+	return nil
+}
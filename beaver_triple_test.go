@@ -0,0 +1,95 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestBeaverTriples(t *testing.T) {
+	sk, pk := KeyGen(512)
+	modulus, _ := new(gmp.Int).SetString("18446744073709551557", 10) // largest 64-bit prime
+
+	generator, req, err := sk.NewTripleGenerator(modulus, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares2, resp, err := pk.RespondTriples(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares1, err := generator.Finalize(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range shares1 {
+		a := new(gmp.Int).Add(shares1[i].A, shares2[i].A)
+		b := new(gmp.Int).Add(shares1[i].B, shares2[i].B)
+		c := new(gmp.Int).Add(shares1[i].C, shares2[i].C)
+		ab := new(gmp.Int).Mul(a, b)
+		if ab.Mod(ab, modulus).Cmp(c.Mod(c, modulus)) != 0 {
+			t.Error("triple ", i, " is not a multiplication triple")
+		}
+	}
+}
+
+func TestBeaverTripleProofs(t *testing.T) {
+	sk, pk := KeyGen(512)
+	modulus := gmp.NewInt(1000003)
+
+	generator, req, err := sk.NewTripleGenerator(modulus, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a key holder encrypts a share out of range
+	forged := *req
+	forged.A = []*Ciphertext{pk.Add(req.A[0], pk.Encrypt(new(gmp.Int).Lsh(modulus, 300)))}
+	if _, _, err := pk.RespondTriples(&forged); !errors.Is(err, ErrProofPart1) {
+		t.Error("share out of range accepted: ", err)
+	}
+
+	// the other party adds an offset to its response
+	_, resp, err := pk.RespondTriples(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.D[0] = pk.Add(resp.D[0], pk.Encrypt(OneBigInt))
+	if _, err := generator.Finalize(resp); !errors.Is(err, ErrProofPart1) {
+		t.Error("forged response accepted: ", err)
+	}
+
+	if _, _, err := sk.NewTripleGenerator(new(gmp.Int).Lsh(OneBigInt, 100), 1); err == nil {
+		t.Error("expected error for a modulus too large for the key")
+	}
+}
+
+func TestRangeProof(t *testing.T) {
+	_, pk := KeyGen(512)
+	bound := gmp.NewInt(1000)
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct := pk.EncryptWithR(b(999), r)
+	proof, err := pk.ProveRange(ct, b(999), r, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.VerifyRangeProofErr(ct, bound, proof); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := pk.ProveRange(ct, b(1000), r, bound); err == nil {
+		t.Error("expected error for a value out of range")
+	}
+
+	if pk.VerifyRangeProof(pk.Randomize(ct), bound, proof) {
+		t.Error("proof accepted for another ciphertext")
+	}
+}
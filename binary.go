@@ -0,0 +1,412 @@
+package paillier
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// The binary encoding starts with a version byte and a tag identifying the
+// encoded type, followed by the fields in declaration order. Integers are
+// encoded as a 4-byte big-endian length followed by the big-endian magnitude
+// (nil is the empty value) and counts and small parameters as 4-byte
+// big-endian values. Embedded keys are inlined, so a SecretKey encoding
+// contains the fields of its PublicKey. Threshold keys with a Damgard-Jurik
+// exponent S above one have tags of their own and encode S after Threshold.
+//
+// Ciphertext implements encoding.BinaryMarshaler, which gob prefers over its
+// native struct encoding; ciphertexts inside gob streams therefore use this
+// format as well.
+
+// binaryVersion is the first byte of every binary encoding
+const binaryVersion byte = 1
+
+const (
+	binaryTagPublicKey byte = iota + 1
+	binaryTagSecretKey
+	binaryTagThresholdPublicKey
+	binaryTagThresholdSecretKey
+	binaryTagCiphertext
+	binaryTagPartialDecryption
+	binaryTagPartialDecryptionZKP
+	binaryTagGeneralizedThresholdPublicKey
+	binaryTagGeneralizedThresholdSecretKey
+	binaryTagKeyGenState
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*PublicKey)(nil)
+	_ encoding.BinaryUnmarshaler = (*PublicKey)(nil)
+	_ encoding.BinaryMarshaler   = (*SecretKey)(nil)
+	_ encoding.BinaryUnmarshaler = (*SecretKey)(nil)
+	_ encoding.BinaryMarshaler   = (*ThresholdPublicKey)(nil)
+	_ encoding.BinaryUnmarshaler = (*ThresholdPublicKey)(nil)
+	_ encoding.BinaryMarshaler   = (*ThresholdSecretKey)(nil)
+	_ encoding.BinaryUnmarshaler = (*ThresholdSecretKey)(nil)
+	_ encoding.BinaryMarshaler   = (*Ciphertext)(nil)
+	_ encoding.BinaryUnmarshaler = (*Ciphertext)(nil)
+)
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (pk *PublicKey) MarshalBinary() ([]byte, error) {
+	buf := newBinaryBuffer(binaryTagPublicKey)
+	pk.writeBinary(buf)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (pk *PublicKey) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data, binaryTagPublicKey)
+	if err != nil {
+		return err
+	}
+
+	var v PublicKey
+	v.readBinary(r)
+	if err := r.done(); err != nil {
+		return err
+	}
+	if v.N == nil {
+		return errors.New("public key is missing N")
+	}
+
+	*pk = PublicKey{N: v.N, G: v.G, H: v.H, K: v.K}
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (sk *SecretKey) MarshalBinary() ([]byte, error) {
+	buf := newBinaryBuffer(binaryTagSecretKey)
+	sk.PublicKey.writeBinary(buf)
+	writeBinaryInts(buf, sk.Lambda, sk.Lm, sk.Mu, sk.m)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (sk *SecretKey) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data, binaryTagSecretKey)
+	if err != nil {
+		return err
+	}
+
+	var pk PublicKey
+	pk.readBinary(r)
+	lambda, lm, mu, m := r.readInt(), r.readInt(), r.readInt(), r.readInt()
+	if err := r.done(); err != nil {
+		return err
+	}
+	if pk.N == nil || lambda == nil {
+		return errors.New("secret key is missing the public key or Lambda")
+	}
+
+	*sk = SecretKey{PublicKey: PublicKey{N: pk.N, G: pk.G, H: pk.H, K: pk.K}, Lambda: lambda, Lm: lm, Mu: mu, m: m}
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (tk *ThresholdPublicKey) MarshalBinary() ([]byte, error) {
+	tag := binaryTagThresholdPublicKey
+	if tk.S > 1 {
+		tag = binaryTagGeneralizedThresholdPublicKey
+	}
+
+	buf := newBinaryBuffer(tag)
+	tk.writeBinary(buf)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (tk *ThresholdPublicKey) UnmarshalBinary(data []byte) error {
+	r, generalized, err := newThresholdBinaryReader(data, binaryTagThresholdPublicKey, binaryTagGeneralizedThresholdPublicKey)
+	if err != nil {
+		return err
+	}
+
+	var v ThresholdPublicKey
+	if err := v.readBinary(r, generalized); err != nil {
+		return err
+	}
+	if err := r.done(); err != nil {
+		return err
+	}
+
+	*tk = v
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (tsk *ThresholdSecretKey) MarshalBinary() ([]byte, error) {
+	tag := binaryTagThresholdSecretKey
+	if tsk.S > 1 {
+		tag = binaryTagGeneralizedThresholdSecretKey
+	}
+
+	buf := newBinaryBuffer(tag)
+	tsk.ThresholdPublicKey.writeBinary(buf)
+	binary.Write(buf, binary.BigEndian, uint32(tsk.ID))
+	writeBinaryInts(buf, tsk.Share)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (tsk *ThresholdSecretKey) UnmarshalBinary(data []byte) error {
+	r, generalized, err := newThresholdBinaryReader(data, binaryTagThresholdSecretKey, binaryTagGeneralizedThresholdSecretKey)
+	if err != nil {
+		return err
+	}
+
+	var tk ThresholdPublicKey
+	if err := tk.readBinary(r, generalized); err != nil {
+		return err
+	}
+	id := r.readUint32()
+	share := r.readInt()
+	if err := r.done(); err != nil {
+		return err
+	}
+
+	if share == nil {
+		return errors.New("threshold secret key is missing the share")
+	}
+	if id < 1 || id > uint32(tk.TotalNumberOfDecryptionServers) {
+		return errors.New("share ID is out of range")
+	}
+
+	*tsk = ThresholdSecretKey{ThresholdPublicKey: tk, ID: int(id), Share: share}
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (ct *Ciphertext) MarshalBinary() ([]byte, error) {
+	buf := newBinaryBuffer(binaryTagCiphertext)
+	binary.Write(buf, binary.BigEndian, uint32(ct.Level))
+	binary.Write(buf, binary.BigEndian, uint32(ct.EncMethod))
+	writeBinaryInts(buf, ct.C)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (ct *Ciphertext) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data, binaryTagCiphertext)
+	if err != nil {
+		return err
+	}
+
+	level, method := r.readUint32(), r.readUint32()
+	c := r.readInt()
+	if err := r.done(); err != nil {
+		return err
+	}
+
+	if c == nil {
+		return fmt.Errorf("%w: missing C", ErrInvalidCiphertext)
+	}
+	if level > uint32(MaxEncryptionLevel) {
+		return errors.New("unknown encryption level")
+	}
+	if EncryptionMethod(method) > MixedEncryption {
+		return errors.New("unknown encryption method")
+	}
+
+	*ct = Ciphertext{C: c, Level: EncryptionLevel(level), EncMethod: EncryptionMethod(method)}
+	return nil
+}
+
+func (pk *PublicKey) writeBinary(buf *bytes.Buffer) {
+	writeBinaryInts(buf, pk.N, pk.G, pk.H, pk.K)
+}
+
+func (pk *PublicKey) readBinary(r *binaryReader) {
+	pk.N, pk.G, pk.H, pk.K = r.readInt(), r.readInt(), r.readInt(), r.readInt()
+}
+
+func (tk *ThresholdPublicKey) writeBinary(buf *bytes.Buffer) {
+	tk.PublicKey.writeBinary(buf)
+	binary.Write(buf, binary.BigEndian, uint32(tk.TotalNumberOfDecryptionServers))
+	binary.Write(buf, binary.BigEndian, uint32(tk.Threshold))
+	if tk.S > 1 {
+		binary.Write(buf, binary.BigEndian, uint32(tk.S))
+	}
+	writeBinaryInts(buf, tk.VerificationKey)
+	binary.Write(buf, binary.BigEndian, uint32(len(tk.VerificationKeys)))
+	writeBinaryInts(buf, tk.VerificationKeys...)
+}
+
+func (tk *ThresholdPublicKey) readBinary(r *binaryReader, generalized bool) error {
+	var pk PublicKey
+	pk.readBinary(r)
+	total, threshold := r.readUint32(), r.readUint32()
+	var s uint32
+	if generalized {
+		s = r.readUint32()
+	}
+	v := r.readInt()
+
+	var vi []*gmp.Int
+	count := r.readUint32()
+	// every verification key takes at least its 4-byte length prefix
+	if uint64(count)*4 > uint64(len(r.data)) {
+		return errors.New("too many verification keys")
+	}
+	if count > 0 {
+		vi = make([]*gmp.Int, count)
+		for i := range vi {
+			vi[i] = r.readInt()
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	if pk.N == nil {
+		return errors.New("threshold key is missing the public key")
+	}
+	if err := checkThresholdParameters(int(total), int(threshold), vi); err != nil {
+		return err
+	}
+	if err := checkDamgardJurikExponent(int64(s)); err != nil {
+		return err
+	}
+
+	*tk = ThresholdPublicKey{
+		PublicKey:                      PublicKey{N: pk.N, G: pk.G, H: pk.H, K: pk.K},
+		TotalNumberOfDecryptionServers: int(total),
+		Threshold:                      int(threshold),
+		VerificationKey:                v,
+		VerificationKeys:               vi,
+		S:                              int(s),
+	}
+	return nil
+}
+
+// checkThresholdParameters validates decoded committee parameters
+func checkThresholdParameters(total, threshold int, vi []*gmp.Int) error {
+	if threshold < 1 || threshold > total {
+		return errors.New("threshold must be between 1 and the total number of decryption servers")
+	}
+	if vi != nil && len(vi) != total {
+		return errors.New("number of verification keys does not match the number of decryption servers")
+	}
+	return nil
+}
+
+// checkDamgardJurikExponent validates a decoded exponent S, where zero means one
+func checkDamgardJurikExponent(s int64) error {
+	if s < 0 || s > int64(MaxEncryptionLevel.S()) {
+		return errors.New("unsupported Damgard-Jurik exponent")
+	}
+	return nil
+}
+
+func newBinaryBuffer(tag byte) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(binaryVersion)
+	buf.WriteByte(tag)
+	return buf
+}
+
+func writeBinaryInts(buf *bytes.Buffer, values ...*gmp.Int) {
+	for _, x := range values {
+		writeCanonicalInt(buf, x)
+	}
+}
+
+// binaryReader consumes a binary encoding and records the first error
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func newBinaryReader(data []byte, tag byte) (*binaryReader, error) {
+	if len(data) < 2 {
+		return nil, errors.New("no data to decode")
+	}
+	if data[0] != binaryVersion {
+		return nil, errors.New("unsupported encoding version")
+	}
+	if data[1] != tag {
+		return nil, errors.New("encoding is of a different type")
+	}
+	return &binaryReader{data: data[2:]}, nil
+}
+
+// newThresholdBinaryReader accepts the tag of a threshold key and the tag of
+// its generalized variant and reports which one was found
+func newThresholdBinaryReader(data []byte, tag, generalizedTag byte) (*binaryReader, bool, error) {
+	if len(data) >= 2 && data[1] == generalizedTag {
+		r, err := newBinaryReader(data, generalizedTag)
+		return r, true, err
+	}
+	r, err := newBinaryReader(data, tag)
+	return r, false, err
+}
+
+func (r *binaryReader) readUint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 4 {
+		r.err = errors.New("unexpected end of data")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+// readBytes returns the next length-prefixed value, which must be at most
+// maxLength bytes long
+func (r *binaryReader) readBytes(maxLength int) []byte {
+	n := r.readUint32()
+	if r.err != nil {
+		return nil
+	}
+	if uint64(n) > uint64(maxLength) {
+		r.err = errors.New("value is too long")
+		return nil
+	}
+	return r.readFixed(int(n))
+}
+
+// readFixed returns the next n bytes
+func (r *binaryReader) readFixed(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data) {
+		r.err = errors.New("unexpected end of data")
+		return nil
+	}
+	value := r.data[:n]
+	r.data = r.data[n:]
+	return value
+}
+
+// readInt returns nil for the empty value
+func (r *binaryReader) readInt() *gmp.Int {
+	return r.readBoundedInt(len(r.data))
+}
+
+// readBoundedInt is readInt for values of at most maxLength bytes
+func (r *binaryReader) readBoundedInt(maxLength int) *gmp.Int {
+	value := r.readBytes(maxLength)
+	if len(value) == 0 {
+		return nil
+	}
+	return new(gmp.Int).SetBytes(value)
+}
+
+// done returns the first error or an error if data is left over
+func (r *binaryReader) done() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return errors.New("trailing data after encoding")
+	}
+	return nil
+}
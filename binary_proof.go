@@ -0,0 +1,187 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// BinaryProof is a non-interactive proof (Fiat-Shamir heuristic) that a level
+// one ciphertext c encrypts either 0 or 1.
+//
+// It is an OR-composition [CDS 94] of two proofs that c/g^j is an N-th residue
+// mod N^2 for j = 0 and j = 1. The prover simulates the branch of the bit that
+// was not encrypted; the two sub-challenges must add up to the Fiat-Shamir
+// challenge.
+//
+//	[CDS 94]: Ronald Cramer, Ivan Damgard, Berry Schoenmakers, (1994)
+//	          Proofs of Partial Knowledge and Simplified Design of
+//	          Witness Hiding Protocols
+type BinaryProof struct {
+	A0, A1 *gmp.Int // commitments
+	E0, E1 *gmp.Int // sub-challenges
+	Z0, Z1 *gmp.Int // responses
+}
+
+// binaryProofTag separates the Fiat-Shamir challenges of binary proofs from
+// those of other proofs over the same values
+var binaryProofTag = []byte("paillier-binary-proof-v1")
+
+// EncryptBitWithProof encrypts bit (0 or 1) and returns the ciphertext with
+// a proof that it encrypts a bit
+func (pk *PublicKey) EncryptBitWithProof(bit int) (*Ciphertext, *BinaryProof, error) {
+	if bit != 0 && bit != 1 {
+		return nil, nil, errors.New("value must be 0 or 1")
+	}
+
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ct := pk.EncryptWithR(gmp.NewInt(int64(bit)), r)
+	proof, err := pk.ProveBinary(ct, bit, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ct, proof, nil
+}
+
+// ProveBinary proves that the level one ciphertext ct = g^bit r^N mod N^2
+// encrypts a bit
+func (pk *PublicKey) ProveBinary(ct *Ciphertext, bit int, r *gmp.Int) (*BinaryProof, error) {
+	if bit != 0 && bit != 1 {
+		return nil, errors.New("value must be 0 or 1")
+	}
+
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("binary proofs are only supported for level one ciphertexts")
+	}
+
+	n2 := pk.GetN2()
+	bits := pk.challengeBitLength()
+	mod := new(gmp.Int).Lsh(OneBigInt, uint(bits))
+
+	a := make([]*gmp.Int, 2)
+	e := make([]*gmp.Int, 2)
+	z := make([]*gmp.Int, 2)
+
+	// simulate the proof for the other bit: a = z^N / u^e
+	other := 1 - bit
+	var err error
+	e[other], err = GetRandomNumber(mod, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	z[other], err = GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	u := pk.binaryProofStatement(ct, other)
+	a[other] = new(gmp.Int).Exp(u, e[other], n2)
+	a[other].ModInverse(a[other], n2)
+	a[other].Mul(a[other], new(gmp.Int).Exp(z[other], pk.N, n2))
+	a[other].Mod(a[other], n2)
+
+	// commit for the real bit: a = rho^N
+	rho, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	a[bit] = new(gmp.Int).Exp(rho, pk.N, n2)
+
+	challenge := pk.binaryProofChallenge(ct, a[0], a[1])
+
+	// e_bit = challenge - e_other mod 2^bits and z_bit = rho * r^e_bit mod N
+	e[bit] = new(gmp.Int).Sub(challenge, e[other])
+	e[bit].Mod(e[bit], mod)
+	z[bit] = new(gmp.Int).Exp(r, e[bit], pk.N)
+	z[bit].Mul(z[bit], rho)
+	z[bit].Mod(z[bit], pk.N)
+
+	return &BinaryProof{A0: a[0], A1: a[1], E0: e[0], E1: e[1], Z0: z[0], Z1: z[1]}, nil
+}
+
+// VerifyBinaryProof returns true iff the proof shows that ct encrypts 0 or 1
+func (pk *PublicKey) VerifyBinaryProof(ct *Ciphertext, proof *BinaryProof) bool {
+	return pk.VerifyBinaryProofErr(ct, proof) == nil
+}
+
+// VerifyBinaryProofErr verifies the proof as VerifyBinaryProof and returns an
+// error wrapping ErrMalformedProof, ErrChallengeMismatch, ErrProofPart1
+// (branch for 0) or ErrProofPart2 (branch for 1) if it is rejected
+func (pk *PublicKey) VerifyBinaryProofErr(ct *Ciphertext, proof *BinaryProof) error {
+	if proof == nil || proof.A0 == nil || proof.A1 == nil || proof.E0 == nil ||
+		proof.E1 == nil || proof.Z0 == nil || proof.Z1 == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if ct == nil || ct.C == nil || ct.Level != EncLevelOne {
+		return fmt.Errorf("%w: ciphertext must be a level one ciphertext", ErrMalformedProof)
+	}
+
+	n2 := pk.GetN2()
+	bits := pk.challengeBitLength()
+	mod := new(gmp.Int).Lsh(OneBigInt, uint(bits))
+
+	// without these checks, zero commitments and responses satisfy both
+	// branches for any ciphertext
+	if err := pk.checkUnitsModN2(ct.C, proof.A0, proof.A1); err != nil {
+		return err
+	}
+	if err := pk.checkUnits(proof.Z0, proof.Z1); err != nil {
+		return err
+	}
+	for _, e := range []*gmp.Int{proof.E0, proof.E1} {
+		if e.Sign() < 0 || e.Cmp(mod) >= 0 {
+			return fmt.Errorf("%w: sub-challenge is out of range", ErrMalformedProof)
+		}
+	}
+
+	challenge := pk.binaryProofChallenge(ct, proof.A0, proof.A1)
+	sum := new(gmp.Int).Add(proof.E0, proof.E1)
+	sum.Mod(sum, mod)
+	if sum.Cmp(challenge) != 0 {
+		return ErrChallengeMismatch
+	}
+
+	a := []*gmp.Int{proof.A0, proof.A1}
+	e := []*gmp.Int{proof.E0, proof.E1}
+	z := []*gmp.Int{proof.Z0, proof.Z1}
+
+	// z^N = a * u^e mod N^2
+	failures := []error{ErrProofPart1, ErrProofPart2}
+	for j := 0; j < 2; j++ {
+		lhs := new(gmp.Int).Exp(z[j], pk.N, n2)
+		rhs := new(gmp.Int).Exp(pk.binaryProofStatement(ct, j), e[j], n2)
+		rhs.Mul(rhs, a[j])
+		rhs.Mod(rhs, n2)
+		if lhs.Cmp(rhs) != 0 {
+			return failures[j]
+		}
+	}
+
+	return nil
+}
+
+// binaryProofChallenge returns the Fiat-Shamir challenge for the commitments,
+// bound to the generator of the key
+func (pk *PublicKey) binaryProofChallenge(ct *Ciphertext, a0, a1 *gmp.Int) *gmp.Int {
+	return RandomOracleChallenge(pk.challengeBitLength(), new(gmp.Int).SetBytes(binaryProofTag),
+		pk.N, pk.gExp(OneBigInt, EncLevelOne), ct.C, a0, a1)
+}
+
+// returns u = ct / g^j mod N^2 which is an N-th residue iff ct encrypts j
+func (pk *PublicKey) binaryProofStatement(ct *Ciphertext, j int) *gmp.Int {
+	if j == 0 {
+		return new(gmp.Int).Set(ct.C)
+	}
+
+	// gExp takes a missing G to be N+1, as for the keys of a committee
+	n2 := pk.GetN2()
+	u := new(gmp.Int).ModInverse(pk.gExp(OneBigInt, EncLevelOne), n2)
+	u.Mul(u, ct.C)
+	return u.Mod(u, n2)
+}
@@ -0,0 +1,133 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestBinaryProofCompleteness(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	for i := 0; i < 100; i++ {
+		ct, proof, err := pk.EncryptBitWithProof(i % 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !pk.VerifyBinaryProof(ct, proof) {
+			t.Error("binary proof is not complete")
+		}
+	}
+}
+
+func TestBinaryProofSoundness(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	for i := 0; i < 100; i++ {
+		r, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+		ct := pk.EncryptWithR(gmp.NewInt(2), r)
+
+		// a proof for a bit cannot be reused for another ciphertext
+		_, proof, err := pk.EncryptBitWithProof(i % 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pk.VerifyBinaryProof(ct, proof) {
+			t.Error("binary proof is not sound")
+		}
+
+		// a proof generated for a ciphertext of 2 does not verify
+		proof, err = pk.ProveBinary(ct, i%2, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pk.VerifyBinaryProof(ct, proof) {
+			t.Error("binary proof is not sound")
+		}
+	}
+
+	if _, _, err := pk.EncryptBitWithProof(2); err == nil {
+		t.Error("expected error for non-binary value")
+	}
+
+	if err := pk.VerifyBinaryProofErr(pk.Encrypt(gmp.NewInt(1)), &BinaryProof{}); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error, got ", err)
+	}
+}
+
+// forgeBinaryProof returns the all-zero proof whose sub-challenges add up to
+// the challenge; it satisfies the verification equations for any ciphertext
+// unless the values are checked to be units
+func forgeBinaryProof(pk *PublicKey, ct *Ciphertext) *BinaryProof {
+	zero := b(0)
+	return &BinaryProof{
+		A0: zero, A1: zero,
+		E0: pk.binaryProofChallenge(ct, zero, zero), E1: zero,
+		Z0: zero, Z1: zero,
+	}
+}
+
+func TestBinaryProofRejectsForgeries(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	for _, m := range []int64{0, 1, 2, 5} {
+		ct := pk.Encrypt(gmp.NewInt(m))
+
+		if err := pk.VerifyBinaryProofErr(ct, forgeBinaryProof(pk, ct)); !errors.Is(err, ErrMalformedProof) {
+			t.Errorf("zeroed proof for %d: expected malformed proof error, got %v", m, err)
+		}
+
+		garbage := &BinaryProof{}
+		for _, v := range []**gmp.Int{&garbage.A0, &garbage.A1, &garbage.E0, &garbage.E1, &garbage.Z0, &garbage.Z1} {
+			*v, _ = GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+		}
+		if pk.VerifyBinaryProof(ct, garbage) {
+			t.Errorf("garbage proof for %d is accepted", m)
+		}
+	}
+
+	// no proof for a ciphertext of 2 verifies, whichever bit it claims
+	r, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+	ct := pk.EncryptWithR(gmp.NewInt(2), r)
+	for bit := 0; bit < 2; bit++ {
+		proof, err := pk.ProveBinary(ct, bit, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pk.VerifyBinaryProof(ct, proof) {
+			t.Errorf("proof of %d for a ciphertext of 2 is accepted", bit)
+		}
+
+		// shifting the sub-challenges by 2^bits keeps their sum
+		shift := new(gmp.Int).Lsh(OneBigInt, uint(pk.challengeBitLength()))
+		proof.E0 = new(gmp.Int).Add(proof.E0, shift)
+		proof.E1 = new(gmp.Int).Sub(proof.E1, shift)
+		if err := pk.VerifyBinaryProofErr(ct, proof); !errors.Is(err, ErrMalformedProof) {
+			t.Error("expected malformed proof error for out of range sub-challenges, got ", err)
+		}
+	}
+}
+
+func TestBinaryProofTally(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	votes := []int{1, 0, 1, 1, 0}
+	tally := pk.Encrypt(gmp.NewInt(0))
+	for _, vote := range votes {
+		ct, proof, err := pk.EncryptBitWithProof(vote)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pk.VerifyBinaryProof(ct, proof) {
+			t.Fatal("ballot is rejected")
+		}
+		tally = pk.Add(tally, ct)
+	}
+
+	if sk.Decrypt(tally).Cmp(gmp.NewInt(3)) != 0 {
+		t.Error("wrong tally")
+	}
+}
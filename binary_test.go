@@ -0,0 +1,188 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestBinaryPublicKeyAndCiphertext(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	data, err := pk.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedPK PublicKey
+	if err := decodedPK.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decodedPK.N.Cmp(pk.N) != 0 || decodedPK.H.Cmp(pk.H) != 0 {
+		t.Error("public key does not match")
+	}
+
+	ct := decodedPK.Encrypt(b(42))
+	data, err = ct.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedCT Ciphertext
+	if err := decodedCT.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if m := sk.Decrypt(&decodedCT); n(m) != 42 {
+		t.Error("wrong decryption ", m)
+	}
+
+	// gob streams use the binary encoding of ciphertexts
+	gobCT, err := pk.NewCiphertextFromBytes(ct.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gobCT.C.Cmp(ct.C) != 0 || gobCT.EncMethod != ct.EncMethod {
+		t.Error("gob round trip changed the ciphertext")
+	}
+}
+
+func TestBinarySecretKey(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	data, err := sk.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded SecretKey
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := decoded.Decrypt(pk.Encrypt(b(7))); n(m) != 7 {
+		t.Error("decoded key decrypted to ", m)
+	}
+
+	again, _ := decoded.MarshalBinary()
+	if !bytes.Equal(data, again) {
+		t.Error("encoding is not stable")
+	}
+}
+
+func TestBinaryThresholdKeys(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := tsks[0].ThresholdPublicKey.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tk ThresholdPublicKey
+	if err := tk.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if tk.Threshold != 3 || tk.TotalNumberOfDecryptionServers != 5 || len(tk.VerificationKeys) != 5 {
+		t.Error("threshold parameters do not match")
+	}
+
+	ct := tk.Encrypt(b(11))
+	var pds []*PartialDecryption
+	for _, tsk := range tsks[:3] {
+		data, err := tsk.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded ThresholdSecretKey
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.ID != tsk.ID {
+			t.Error("wrong share ID ", decoded.ID)
+		}
+		pds = append(pds, decoded.PartialDecrypt(ct.C))
+	}
+
+	m, err := tk.CombinePartialDecryptions(pds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 11 {
+		t.Error("wrong decryption ", m)
+	}
+}
+
+func TestBinaryDamgardJurikThresholdKey(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 2, 1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tkh.S = 2
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := tsks[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tsk ThresholdSecretKey
+	if err := tsk.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if tsk.S != 2 {
+		t.Error("wrong exponent ", tsk.S)
+	}
+
+	ct := tsk.EncryptAtLevel(b(12), EncLevelTwo)
+	pd, err := tsk.PartialDecryptAtLevel(ct.C, EncLevelTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := tsk.CombinePartialDecryptionsAtLevel([]*PartialDecryption{pd}, EncLevelTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 12 {
+		t.Error("wrong decryption ", m)
+	}
+}
+
+func TestBinaryRejectsMalformedData(t *testing.T) {
+	_, pk := KeyGen(64)
+	data, _ := pk.MarshalBinary()
+
+	var ct Ciphertext
+	if err := ct.UnmarshalBinary(data); err == nil {
+		t.Error("public key decoded as a ciphertext")
+	}
+
+	var decoded PublicKey
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("truncated data was accepted")
+	}
+	if err := decoded.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error("trailing data was accepted")
+	}
+
+	bad := append([]byte{}, data...)
+	bad[0] = 0xff
+	if err := decoded.UnmarshalBinary(bad); err == nil {
+		t.Error("unknown version was accepted")
+	}
+
+	// a verification key count far beyond the data must not allocate
+	tk := &ThresholdPublicKey{PublicKey: *pk, TotalNumberOfDecryptionServers: 1, Threshold: 1}
+	data, _ = tk.MarshalBinary()
+	data[len(data)-1] = 0xff
+	data[len(data)-2] = 0xff
+	var decodedTK ThresholdPublicKey
+	if err := decodedTK.UnmarshalBinary(data); err == nil {
+		t.Error("oversized verification key count was accepted")
+	}
+}
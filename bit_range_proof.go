@@ -0,0 +1,176 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// BitRangeProof is a non-interactive proof that a level one ciphertext c
+// encrypts x with 0 <= x < Bound exactly, i.e., without the slack of
+// RangeProof, for any Bound with 2 <= Bound and 2^(k+1) < N where k is the
+// bit length of Bound-1.
+//
+// The prover encrypts the k bits of x and of Bound-1-x and proves with a
+// BinaryProof that every ciphertext encrypts a bit. The randomness of the
+// bit ciphertexts is chosen such that prod_j c_j^(2^j) = c for the bits of x
+// and prod_j c'_j^(2^j) = g^(Bound-1) / c for the bits of Bound-1-x, which
+// the verifier checks. The proof has 2k ciphertexts and binary proofs.
+type BitRangeProof struct {
+	Lower       []*gmp.Int // encryptions of the bits of x
+	LowerProofs []*BinaryProof
+	Upper       []*gmp.Int // encryptions of the bits of Bound-1-x
+	UpperProofs []*BinaryProof
+}
+
+// ProveBitRange proves that ct = g^x r^N mod N^2 encrypts x in [0, bound)
+func (pk *PublicKey) ProveBitRange(ct *Ciphertext, x, r, bound *gmp.Int) (*BitRangeProof, error) {
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("range proofs are only supported for level one ciphertexts")
+	}
+
+	k, err := pk.bitRangeLength(bound)
+	if err != nil {
+		return nil, err
+	}
+
+	if x.Sign() < 0 || x.Cmp(bound) >= 0 {
+		return nil, errors.New("value is out of range")
+	}
+
+	proof := &BitRangeProof{}
+	if proof.Lower, proof.LowerProofs, err = pk.proveBits(x, r, k); err != nil {
+		return nil, err
+	}
+
+	// g^(bound-1) / c encrypts bound-1-x with randomness r^-1
+	y := new(gmp.Int).Sub(bound, OneBigInt)
+	y.Sub(y, x)
+	rInv := new(gmp.Int).ModInverse(r, pk.N)
+	if proof.Upper, proof.UpperProofs, err = pk.proveBits(y, rInv, k); err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// VerifyBitRangeProof returns true iff the proof shows that ct encrypts a
+// value in [0, bound)
+func (pk *PublicKey) VerifyBitRangeProof(ct *Ciphertext, bound *gmp.Int, proof *BitRangeProof) bool {
+	return pk.VerifyBitRangeProofErr(ct, bound, proof) == nil
+}
+
+// VerifyBitRangeProofErr verifies the proof as VerifyBitRangeProof and
+// returns an error if it is rejected. The error wraps ErrMalformedProof, the
+// error of a rejected BinaryProof, ErrProofPart1 (the bits of x do not
+// combine to c) or ErrProofPart2 (the bits of bound-1-x do not combine to
+// g^(bound-1) / c).
+func (pk *PublicKey) VerifyBitRangeProofErr(ct *Ciphertext, bound *gmp.Int, proof *BitRangeProof) error {
+	if proof == nil || ct == nil || ct.C == nil || bound == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if ct.Level != EncLevelOne {
+		return fmt.Errorf("%w: ciphertext must be a level one ciphertext", ErrMalformedProof)
+	}
+
+	k, err := pk.bitRangeLength(bound)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProof, err)
+	}
+
+	n2 := pk.GetN2()
+	if ct.C.Sign() <= 0 || ct.C.Cmp(n2) >= 0 {
+		return fmt.Errorf("%w: ciphertext is not in Z_N^2", ErrMalformedProof)
+	}
+
+	lower, err := pk.verifyBits(proof.Lower, proof.LowerProofs, k)
+	if err != nil {
+		return err
+	}
+	if lower.Cmp(ct.C) != 0 {
+		return ErrProofPart1
+	}
+
+	upper, err := pk.verifyBits(proof.Upper, proof.UpperProofs, k)
+	if err != nil {
+		return err
+	}
+	upper.Mul(upper, ct.C)
+	upper.Mod(upper, n2)
+	if upper.Cmp(pk.gExp(new(gmp.Int).Sub(bound, OneBigInt), EncLevelOne)) != 0 {
+		return ErrProofPart2
+	}
+
+	return nil
+}
+
+// returns the number of bits k of bound-1 and checks that the sum of two
+// k-bit values cannot wrap around N
+func (pk *PublicKey) bitRangeLength(bound *gmp.Int) (int, error) {
+	if bound.Cmp(gmp.NewInt(2)) < 0 {
+		return 0, errors.New("bound must be at least 2")
+	}
+	k := new(gmp.Int).Sub(bound, OneBigInt).BitLen()
+	if k+1 >= pk.N.BitLen() {
+		return 0, errors.New("bound is too large for the modulus")
+	}
+	return k, nil
+}
+
+// encrypts the k bits b_j of value with randomness r_j such that
+// prod_j r_j^(2^j) = r mod N and proves that every ciphertext encrypts a bit
+func (pk *PublicKey) proveBits(value, r *gmp.Int, k int) ([]*gmp.Int, []*BinaryProof, error) {
+	randomness := make([]*gmp.Int, k)
+	product := gmp.NewInt(1)
+	for j := 1; j < k; j++ {
+		rj, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, nil, err
+		}
+		randomness[j] = rj
+		product.Mul(product, new(gmp.Int).Exp(rj, new(gmp.Int).Lsh(OneBigInt, uint(j)), pk.N))
+		product.Mod(product, pk.N)
+	}
+	randomness[0] = product.ModInverse(product, pk.N)
+	randomness[0].Mul(randomness[0], r)
+	randomness[0].Mod(randomness[0], pk.N)
+
+	cts := make([]*gmp.Int, k)
+	proofs := make([]*BinaryProof, k)
+	for j := 0; j < k; j++ {
+		bit := int(value.Bit(j))
+		ct := pk.EncryptWithR(gmp.NewInt(int64(bit)), randomness[j])
+		proof, err := pk.ProveBinary(ct, bit, randomness[j])
+		if err != nil {
+			return nil, nil, err
+		}
+		cts[j] = ct.C
+		proofs[j] = proof
+	}
+	return cts, proofs, nil
+}
+
+// verifies the binary proofs of the k bit ciphertexts and returns
+// prod_j c_j^(2^j) mod N^2
+func (pk *PublicKey) verifyBits(cts []*gmp.Int, proofs []*BinaryProof, k int) (*gmp.Int, error) {
+	if len(cts) != k || len(proofs) != k {
+		return nil, fmt.Errorf("%w: expected %d bits", ErrMalformedProof, k)
+	}
+
+	n2 := pk.GetN2()
+	product := gmp.NewInt(1)
+	for j, c := range cts {
+		if c == nil || c.Sign() <= 0 || c.Cmp(n2) >= 0 {
+			return nil, fmt.Errorf("%w: bit ciphertext is not in Z_N^2", ErrMalformedProof)
+		}
+		ct := &Ciphertext{C: c, Level: EncLevelOne, EncMethod: RegularEncryption}
+		if err := pk.VerifyBinaryProofErr(ct, proofs[j]); err != nil {
+			return nil, fmt.Errorf("bit %d: %w", j, err)
+		}
+		product.Mul(product, new(gmp.Int).Exp(c, new(gmp.Int).Lsh(OneBigInt, uint(j)), n2))
+		product.Mod(product, n2)
+	}
+	return product, nil
+}
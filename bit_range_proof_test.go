@@ -0,0 +1,105 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestBitRangeProofCompleteness(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	for _, bound := range []int64{2, 3, 10, 16, 1000} {
+		for _, x := range []int64{0, 1, bound / 2, bound - 1} {
+			r, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+			ct := pk.EncryptWithR(gmp.NewInt(x), r)
+			proof, err := pk.ProveBitRange(ct, gmp.NewInt(x), r, gmp.NewInt(bound))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := pk.VerifyBitRangeProofErr(ct, gmp.NewInt(bound), proof); err != nil {
+				t.Errorf("range proof of %d < %d is not complete: %v", x, bound, err)
+			}
+		}
+	}
+}
+
+func TestBitRangeProofSoundness(t *testing.T) {
+	_, pk := KeyGen(128)
+	bound := gmp.NewInt(10)
+
+	r, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+	ct := pk.EncryptWithR(gmp.NewInt(7), r)
+	proof, err := pk.ProveBitRange(ct, gmp.NewInt(7), r, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the proof does not verify for a smaller bound
+	if pk.VerifyBitRangeProof(ct, gmp.NewInt(7), proof) {
+		t.Error("range proof verifies for a smaller bound")
+	}
+
+	// the proof does not verify for another ciphertext
+	other := pk.EncryptWithR(gmp.NewInt(7), r)
+	other = pk.Add(other, pk.Encrypt(gmp.NewInt(0)))
+	if err := pk.VerifyBitRangeProofErr(other, bound, proof); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1 for another ciphertext, got ", err)
+	}
+
+	// 12 has 4 bits like bound-1 = 9, but bound-1-12 is negative
+	ct = pk.EncryptWithR(gmp.NewInt(12), r)
+	if _, err := pk.ProveBitRange(ct, gmp.NewInt(12), r, bound); err == nil {
+		t.Error("expected error for a value out of range")
+	}
+	forged, err := pk.ProveBitRange(ct, gmp.NewInt(12), r, gmp.NewInt(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Upper, forged.UpperProofs = proof.Upper, proof.UpperProofs
+	if err := pk.VerifyBitRangeProofErr(ct, bound, forged); !errors.Is(err, ErrProofPart2) {
+		t.Error("expected ErrProofPart2 for a value out of range, got ", err)
+	}
+
+	// 12 as the first bit of x and -3 as the first bit of bound-1-x combine
+	// correctly, so only the forged proofs of these bits stand in the way
+	one := pk.EncryptWithR(gmp.NewInt(0), OneBigInt)
+	oneProof, err := pk.ProveBinary(one, 0, OneBigInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rInv := new(gmp.Int).ModInverse(r, pk.N)
+	upper := pk.EncryptWithR(new(gmp.Int).Sub(pk.N, gmp.NewInt(3)), rInv)
+	forged = &BitRangeProof{
+		Lower:       []*gmp.Int{ct.C, one.C, one.C, one.C},
+		LowerProofs: []*BinaryProof{forgeBinaryProof(pk, ct), oneProof, oneProof, oneProof},
+		Upper:       []*gmp.Int{upper.C, one.C, one.C, one.C},
+		UpperProofs: []*BinaryProof{forgeBinaryProof(pk, upper), oneProof, oneProof, oneProof},
+	}
+	if err := pk.VerifyBitRangeProofErr(ct, bound, forged); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error for forged bit proofs, got ", err)
+	}
+
+	// a bit ciphertext must encrypt a bit
+	tampered := *proof
+	tampered.Lower = append([]*gmp.Int{}, proof.Lower...)
+	tampered.Lower[0] = pk.Encrypt(gmp.NewInt(2)).C
+	if err := pk.VerifyBitRangeProofErr(ct, bound, &tampered); err == nil {
+		t.Error("range proof with a non-binary bit verifies")
+	}
+
+	tampered = *proof
+	tampered.Lower = proof.Lower[:2]
+	if err := pk.VerifyBitRangeProofErr(ct, bound, &tampered); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error, got ", err)
+	}
+
+	if _, err := pk.ProveBitRange(ct, gmp.NewInt(0), r, OneBigInt); err == nil {
+		t.Error("expected error for a bound smaller than 2")
+	}
+	if _, err := pk.ProveBitRange(ct, gmp.NewInt(0), r, pk.N); err == nil {
+		t.Error("expected error for a bound too large for the modulus")
+	}
+}
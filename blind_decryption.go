@@ -0,0 +1,131 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// BlindingMode selects how Blind masks the plaintext of a ciphertext
+type BlindingMode int
+
+const (
+	// AdditiveBlinding adds a uniformly random mask r in Z_(N^s), so the
+	// decryption m + r reveals nothing about m
+	AdditiveBlinding BlindingMode = iota
+
+	// MultiplicativeBlinding multiplies by a uniformly random unit u of
+	// Z_(N^s). The decryption m * u hides m among the values with the same
+	// gcd with N^s; in particular it reveals whether m is zero, which is what
+	// e.g. equality tests rely on.
+	MultiplicativeBlinding
+)
+
+// String returns the name of the blinding mode
+func (mode BlindingMode) String() string {
+	switch mode {
+	case AdditiveBlinding:
+		return "additive"
+	case MultiplicativeBlinding:
+		return "multiplicative"
+	default:
+		return fmt.Sprintf("BlindingMode(%d)", int(mode))
+	}
+}
+
+// Blinding is the secret of a client that blinded a ciphertext with Blind.
+// It must not be sent to the decryption servers and can unblind one
+// decryption.
+type Blinding struct {
+	Mode  BlindingMode
+	Level EncryptionLevel
+
+	mask    *gmp.Int // r for additive blinding, u^-1 for multiplicative blinding
+	modulus *gmp.Int // N^s
+}
+
+// Blind returns a fresh encryption of the plaintext of ct masked according
+// to the mode, which the client sends for (threshold) decryption, and the
+// blinding that Unblind needs to recover the plaintext from the decryption.
+// The decryption servers only learn the masked plaintext.
+func (pk *PublicKey) Blind(ct *Ciphertext, mode BlindingMode) (*Ciphertext, *Blinding, error) {
+	if ct == nil || ct.C == nil {
+		return nil, nil, fmt.Errorf("%w: missing ciphertext", ErrInvalidCiphertext)
+	}
+	if ct.Level < EncLevelOne || ct.Level > MaxEncryptionLevel {
+		return nil, nil, fmt.Errorf("%w: unknown encryption level %d", ErrInvalidCiphertext, ct.Level)
+	}
+
+	_, ns, ns1 := pk.getModuliForLevel(ct.Level)
+	random := pk.RandomSource()
+	blinding := &Blinding{Mode: mode, Level: ct.Level, modulus: ns}
+
+	var blinded *Ciphertext
+	switch mode {
+	case AdditiveBlinding:
+		r, err := GetRandomNumber(ns, random)
+		if err != nil {
+			return nil, nil, err
+		}
+		c := new(gmp.Int).Mul(ct.C, pk.gExp(r, ct.Level))
+		blinded = &Ciphertext{c.Mod(c, ns1), ct.Level, MixedEncryption}
+		blinding.mask = r
+
+	case MultiplicativeBlinding:
+		u, err := GetRandomNumberInMultiplicativeGroup(ns, random)
+		if err != nil {
+			return nil, nil, err
+		}
+		blinded = pk.ConstMult(ct, u)
+		blinding.mask = new(gmp.Int).ModInverse(u, ns)
+
+	default:
+		return nil, nil, fmt.Errorf("unknown blinding mode %v", mode)
+	}
+
+	// the mask must not be recoverable from the randomness of the ciphertext
+	blinded, err := pk.Rerandomize(blinded, random)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blinded, blinding, nil
+}
+
+// Unblind returns the plaintext of the blinded ciphertext from its
+// decryption
+func (b *Blinding) Unblind(m *gmp.Int) (*gmp.Int, error) {
+	if b == nil || b.mask == nil {
+		return nil, errors.New("blinding was not created by Blind")
+	}
+	if m == nil || m.Sign() < 0 || m.Cmp(b.modulus) >= 0 {
+		return nil, errors.New("decryption is out of range")
+	}
+
+	res := new(gmp.Int)
+	switch b.Mode {
+	case AdditiveBlinding:
+		res.Sub(m, b.mask)
+	case MultiplicativeBlinding:
+		res.Mul(m, b.mask)
+	default:
+		return nil, fmt.Errorf("unknown blinding mode %v", b.Mode)
+	}
+	return res.Mod(res, b.modulus), nil
+}
+
+// BlindDecrypt blinds ct, decrypts it with dec, e.g., a ThresholdClient of
+// the decryption servers, and unblinds the result. The decrypter only learns
+// the masked plaintext.
+func (pk *PublicKey) BlindDecrypt(ct *Ciphertext, mode BlindingMode, dec Decrypter) (*gmp.Int, error) {
+	blinded, blinding, err := pk.Blind(ct, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := dec.TryDecrypt(blinded)
+	if err != nil {
+		return nil, fmt.Errorf("blinded ciphertext could not be decrypted: %w", err)
+	}
+	return blinding.Unblind(m)
+}
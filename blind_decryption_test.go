@@ -0,0 +1,64 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestBlindDecryption(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for _, mode := range []BlindingMode{AdditiveBlinding, MultiplicativeBlinding} {
+		for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+			for _, v := range []int64{0, 1, 42, 1 << 40} {
+				ct := pk.EncryptAtLevel(gmp.NewInt(v), level)
+
+				blinded, blinding, err := pk.Blind(ct, mode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if blinded.Level != level {
+					t.Errorf("%v blinding changed the level to %v", mode, blinded.Level)
+				}
+
+				masked := sk.Decrypt(blinded)
+				if v != 0 && masked.Cmp(gmp.NewInt(v)) == 0 {
+					t.Errorf("%v blinding did not mask %d", mode, v)
+				}
+
+				m, err := blinding.Unblind(masked)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m.Cmp(gmp.NewInt(v)) != 0 {
+					t.Errorf("%v blinding at level %v unblinded to %v, expected %d", mode, level, m, v)
+				}
+			}
+		}
+	}
+
+	if _, _, err := pk.Blind(pk.Encrypt(gmp.NewInt(1)), BlindingMode(7)); err == nil {
+		t.Error("expected an error for an unknown blinding mode")
+	}
+	if _, err := new(Blinding).Unblind(gmp.NewInt(1)); err == nil {
+		t.Error("expected an error for a blinding not created by Blind")
+	}
+}
+
+func TestBlindThresholdDecryption(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicOnly()
+	client := NewThresholdClient(tk, []PartialDecrypter{tsks[1], tsks[2]})
+
+	ct := tk.Encrypt(gmp.NewInt(1234))
+	for _, mode := range []BlindingMode{AdditiveBlinding, MultiplicativeBlinding} {
+		m, err := tk.BlindDecrypt(ct, mode, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n(m) != 1234 {
+			t.Errorf("%v blind decryption returned %v, expected 1234", mode, m)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package paillier
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// EncryptedBloomFilter is a Bloom filter whose cells are ciphertexts under a
+// threshold public key.
+//
+// Each contributor builds a filter over its own set of elements in which every
+// cell encrypts 0 or 1, so the filter reveals nothing about the set without the
+// committee. Filters of several contributors are combined with Union, which
+// homomorphically adds the cells; the union is therefore a counting Bloom filter
+// where each cell counts the contributors that set it. The membership count of
+// an element, i.e., (an upper bound on) the number of contributors whose set
+// contains the element, is the minimum of the element's cells and is obtained
+// by threshold decryption of these cells only.
+type EncryptedBloomFilter struct {
+	Key           *ThresholdPublicKey
+	HashFunctions int
+	Cells         []*Ciphertext
+}
+
+// NewEncryptedBloomFilter creates a filter of `size` cells with `hashFunctions`
+// hash functions containing the elements
+func NewEncryptedBloomFilter(key *ThresholdPublicKey, size, hashFunctions int, elements [][]byte) (*EncryptedBloomFilter, error) {
+	if size <= 0 || hashFunctions <= 0 {
+		return nil, errors.New("size and number of hash functions must be positive")
+	}
+
+	bf := &EncryptedBloomFilter{
+		Key:           key,
+		HashFunctions: hashFunctions,
+		Cells:         make([]*Ciphertext, size),
+	}
+
+	bits := make([]bool, size)
+	for _, element := range elements {
+		for _, pos := range bf.Positions(element) {
+			bits[pos] = true
+		}
+	}
+
+	for i, bit := range bits {
+		if bit {
+			bf.Cells[i] = key.EncryptOne()
+		} else {
+			bf.Cells[i] = key.EncryptZero()
+		}
+	}
+
+	return bf, nil
+}
+
+// Positions returns the distinct cells of the element, derived with double
+// hashing from SHA-256(element): pos_i = h1 + i*h2 mod size
+func (bf *EncryptedBloomFilter) Positions(element []byte) []int {
+	digest := sha256.Sum256(element)
+	size := uint64(len(bf.Cells))
+	h1 := binary.BigEndian.Uint64(digest[0:8]) % size
+	h2 := binary.BigEndian.Uint64(digest[8:16])%size | 1
+
+	seen := make(map[int]bool, bf.HashFunctions)
+	positions := make([]int, 0, bf.HashFunctions)
+	for i := uint64(0); i < uint64(bf.HashFunctions); i++ {
+		pos := int((h1 + i*h2) % size)
+		if !seen[pos] {
+			seen[pos] = true
+			positions = append(positions, pos)
+		}
+	}
+
+	return positions
+}
+
+// Union homomorphically adds the cells of another filter with the same
+// parameters and key
+func (bf *EncryptedBloomFilter) Union(other *EncryptedBloomFilter) error {
+	if bf.Key.N.Cmp(other.Key.N) != 0 {
+		return errors.New("filters are encrypted under different keys")
+	}
+
+	if len(bf.Cells) != len(other.Cells) || bf.HashFunctions != other.HashFunctions {
+		return errors.New("filters have different parameters")
+	}
+
+	for i, ct := range other.Cells {
+		bf.Cells[i] = bf.Key.Add(bf.Cells[i], ct)
+	}
+
+	return nil
+}
+
+// PartialDecryptMembership is run by each decryption server to partially
+// decrypt the cells of the element (in the order returned by Positions)
+func (tsk *ThresholdSecretKey) PartialDecryptMembership(bf *EncryptedBloomFilter, element []byte) ([]*PartialDecryptionZKP, error) {
+	positions := bf.Positions(element)
+	shares := make([]*PartialDecryptionZKP, len(positions))
+	for i, pos := range positions {
+		var err error
+		shares[i], err = tsk.PartialDecryptionWithZKP(bf.Cells[pos].C)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shares, nil
+}
+
+// MembershipCount combines the partial decryptions of the servers (as
+// returned by PartialDecryptMembership) and returns the minimum count over the
+// cells of the element
+func (bf *EncryptedBloomFilter) MembershipCount(element []byte, serverShares [][]*PartialDecryptionZKP) (*gmp.Int, error) {
+	positions := bf.Positions(element)
+
+	var count *gmp.Int
+	for i, pos := range positions {
+		shares := make([]*PartialDecryptionZKP, 0, len(serverShares))
+		for _, server := range serverShares {
+			if len(server) != len(positions) || server[i].C.Cmp(bf.Cells[pos].C) != 0 {
+				return nil, errors.New("partial decryptions do not match the element")
+			}
+			shares = append(shares, server[i])
+		}
+
+		cell, err := bf.Key.CombinePartialDecryptionsZKP(shares)
+		if err != nil {
+			return nil, err
+		}
+
+		if count == nil || cell.Cmp(count) < 0 {
+			count = cell
+		}
+	}
+
+	return count, nil
+}
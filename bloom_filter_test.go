@@ -0,0 +1,86 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestEncryptedBloomFilter(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &tpks[0].ThresholdPublicKey
+
+	sets := [][][]byte{
+		{[]byte("alice"), []byte("bob")},
+		{[]byte("alice"), []byte("carol")},
+		{[]byte("alice"), []byte("bob"), []byte("dave")},
+	}
+
+	union, err := NewEncryptedBloomFilter(key, 256, 4, sets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, set := range sets[1:] {
+		bf, err := NewEncryptedBloomFilter(key, 256, 4, set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := union.Union(bf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// counts are upper bounds due to false positives
+	expected := map[string]int{"alice": 3, "bob": 2, "carol": 1, "dave": 1}
+	for element, count := range expected {
+		shares1, err := tpks[0].PartialDecryptMembership(union, []byte(element))
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares2, err := tpks[1].PartialDecryptMembership(union, []byte(element))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := union.MembershipCount([]byte(element), [][]*PartialDecryptionZKP{shares1, shares2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n(res) < count {
+			t.Errorf("membership count of %v is %v but should be at least %v", element, res, count)
+		}
+	}
+
+	other, err := NewEncryptedBloomFilter(key, 128, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := union.Union(other); err == nil {
+		t.Error("expected error for filters with different sizes")
+	}
+}
+
+func TestBloomFilterPositions(t *testing.T) {
+	bf := &EncryptedBloomFilter{HashFunctions: 5, Cells: make([]*Ciphertext, 1000)}
+
+	positions := bf.Positions([]byte("element"))
+	if len(positions) != 5 {
+		t.Error("expected 5 distinct positions but got ", len(positions))
+	}
+
+	for i, pos := range bf.Positions([]byte("element")) {
+		if pos != positions[i] || pos < 0 || pos >= 1000 {
+			t.Error("positions are not deterministic or out of range")
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Kinds of the artifacts published with the Publish helpers
+const (
+	BulletinCiphertext                = "ciphertext"
+	BulletinPartialDecryption         = "partial-decryption"
+	BulletinAggregatedDecryptionProof = "aggregated-decryption-proof"
+	BulletinPublicKey                 = "public-key"
+)
+
+// BulletinEntry is an artifact published on a bulletin board
+type BulletinEntry struct {
+	Kind string // type of the artifact, e.g., BulletinCiphertext
+	Data []byte // encoding of the artifact
+}
+
+// InclusionReceipt is returned when an entry is published. The entries of a
+// board form a hash chain, Head_i = SHA256(Head_(i-1) || Digest_i) with an
+// all-zero Head_(-1), so a receipt commits to its entry and all earlier ones.
+type InclusionReceipt struct {
+	Index  uint64
+	Digest []byte // SHA256 of the entry
+	Head   []byte // head of the hash chain after the entry was appended
+}
+
+// BulletinBoard is an append-only log of published artifacts. Everyone can
+// fetch the entries and check receipts with VerifyInclusion; implementations
+// must be safe for concurrent use.
+type BulletinBoard interface {
+	Publish(entry *BulletinEntry) (*InclusionReceipt, error)
+	Fetch(index uint64) (*BulletinEntry, error)
+	Len() (uint64, error)
+}
+
+// VerifyInclusion recomputes the hash chain of the board up to the index of
+// the receipt and returns an error unless the receipt matches, i.e., unless
+// the entry and all entries before it are published unmodified
+func VerifyInclusion(board BulletinBoard, receipt *InclusionReceipt) error {
+	length, err := board.Len()
+	if err != nil {
+		return err
+	}
+
+	if receipt.Index >= length {
+		return errors.New("receipt refers to an entry that is not on the board")
+	}
+
+	var chain bulletinChain
+	for i := uint64(0); i <= receipt.Index; i++ {
+		entry, err := board.Fetch(i)
+		if err != nil {
+			return err
+		}
+		chain.append(entry)
+	}
+
+	last := len(chain.heads) - 1
+	if !bytes.Equal(chain.digests[last], receipt.Digest) {
+		return fmt.Errorf("entry %d does not match the receipt", receipt.Index)
+	}
+
+	if !bytes.Equal(chain.heads[last], receipt.Head) {
+		return fmt.Errorf("entries before %d were modified", receipt.Index)
+	}
+
+	return nil
+}
+
+// PublishCiphertext publishes the gob encoding of the ciphertext
+func PublishCiphertext(board BulletinBoard, ct *Ciphertext) (*InclusionReceipt, error) {
+	return publishGob(board, BulletinCiphertext, ct)
+}
+
+// PublishPartialDecryption publishes the gob encoding of the partial decryption
+// and its proof
+func PublishPartialDecryption(board BulletinBoard, pd *PartialDecryptionZKP) (*InclusionReceipt, error) {
+	return publishGob(board, BulletinPartialDecryption, pd)
+}
+
+// PublishAggregatedDecryptionProof publishes the gob encoding of the proof
+func PublishAggregatedDecryptionProof(board BulletinBoard, proof *AggregatedDecryptionProof) (*InclusionReceipt, error) {
+	return publishGob(board, BulletinAggregatedDecryptionProof, proof)
+}
+
+// PublishPublicKey publishes the gob encoding of the threshold public key,
+// which verifiers need to check the published proofs
+func PublishPublicKey(board BulletinBoard, tk *ThresholdPublicKey) (*InclusionReceipt, error) {
+	return publishGob(board, BulletinPublicKey, tk)
+}
+
+// FetchGob fetches the entry at index, checks that it is of the given kind
+// and decodes it into v, e.g., a *Ciphertext for BulletinCiphertext
+func FetchGob(board BulletinBoard, index uint64, kind string, v interface{}) error {
+	entry, err := board.Fetch(index)
+	if err != nil {
+		return err
+	}
+
+	if entry.Kind != kind {
+		return fmt.Errorf("entry %d is a %q, not a %q", index, entry.Kind, kind)
+	}
+
+	return gobDecode(entry.Data, v)
+}
+
+func publishGob(board BulletinBoard, kind string, v interface{}) (*InclusionReceipt, error) {
+	data, err := gobEncode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return board.Publish(&BulletinEntry{Kind: kind, Data: data})
+}
+
+// bulletinChain holds the digests and the heads of the hash chain of a board
+type bulletinChain struct {
+	digests [][]byte
+	heads   [][]byte
+}
+
+func (c *bulletinChain) append(entry *BulletinEntry) *InclusionReceipt {
+	digest := sha256.Sum256(encodeBulletinEntry(entry))
+
+	head := sha256.New()
+	if len(c.heads) == 0 {
+		head.Write(make([]byte, sha256.Size))
+	} else {
+		head.Write(c.heads[len(c.heads)-1])
+	}
+	head.Write(digest[:])
+
+	c.digests = append(c.digests, digest[:])
+	c.heads = append(c.heads, head.Sum(nil))
+
+	last := len(c.heads) - 1
+	return &InclusionReceipt{Index: uint64(last), Digest: c.digests[last], Head: c.heads[last]}
+}
+
+// encodes the entry as the 4-byte big-endian length of the kind, the kind,
+// the 4-byte big-endian length of the data and the data
+func encodeBulletinEntry(entry *BulletinEntry) []byte {
+	buf := make([]byte, 0, 8+len(entry.Kind)+len(entry.Data))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(entry.Kind)))
+	buf = append(buf, entry.Kind...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(entry.Data)))
+	return append(buf, entry.Data...)
+}
+
+// MemoryBulletinBoard is a BulletinBoard held in memory, e.g., for tests
+type MemoryBulletinBoard struct {
+	mu      sync.RWMutex
+	entries []*BulletinEntry
+	chain   bulletinChain
+}
+
+// NewMemoryBulletinBoard returns an empty in-memory bulletin board
+func NewMemoryBulletinBoard() *MemoryBulletinBoard {
+	return &MemoryBulletinBoard{}
+}
+
+// Publish appends a copy of the entry to the board
+func (b *MemoryBulletinBoard) Publish(entry *BulletinEntry) (*InclusionReceipt, error) {
+	entry = &BulletinEntry{Kind: entry.Kind, Data: append([]byte{}, entry.Data...)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, entry)
+	return b.chain.append(entry), nil
+}
+
+// Fetch returns a copy of the entry at index
+func (b *MemoryBulletinBoard) Fetch(index uint64) (*BulletinEntry, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if index >= uint64(len(b.entries)) {
+		return nil, errors.New("index is out of range")
+	}
+
+	entry := b.entries[index]
+	return &BulletinEntry{Kind: entry.Kind, Data: append([]byte{}, entry.Data...)}, nil
+}
+
+// Len returns the number of entries on the board
+func (b *MemoryBulletinBoard) Len() (uint64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return uint64(len(b.entries)), nil
+}
+
+// FileBulletinBoard is a BulletinBoard stored in an append-only file of
+// encoded entries. Every Publish is synced to stable storage before the
+// receipt is returned.
+type FileBulletinBoard struct {
+	mu      sync.RWMutex
+	file    *os.File
+	offsets []int64 // offset of every entry in the file
+	size    int64
+	chain   bulletinChain
+}
+
+// OpenFileBulletinBoard opens or creates the bulletin board stored at path and
+// recomputes the hash chain of the existing entries
+func OpenFileBulletinBoard(path string) (*FileBulletinBoard, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	b := &FileBulletinBoard{file: file}
+	for {
+		entry, n, err := b.readEntry(b.size, info.Size())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("bulletin board %s is corrupted at offset %d: %w", path, b.size, err)
+		}
+
+		b.offsets = append(b.offsets, b.size)
+		b.size += n
+		b.chain.append(entry)
+	}
+
+	return b, nil
+}
+
+// Publish appends the entry to the file
+func (b *FileBulletinBoard) Publish(entry *BulletinEntry) (*InclusionReceipt, error) {
+	data := encodeBulletinEntry(entry)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.file.WriteAt(data, b.size); err != nil {
+		return nil, err
+	}
+	if err := b.file.Sync(); err != nil {
+		return nil, err
+	}
+
+	b.offsets = append(b.offsets, b.size)
+	b.size += int64(len(data))
+	return b.chain.append(entry), nil
+}
+
+// Fetch reads the entry at index from the file
+func (b *FileBulletinBoard) Fetch(index uint64) (*BulletinEntry, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if index >= uint64(len(b.offsets)) {
+		return nil, errors.New("index is out of range")
+	}
+
+	entry, _, err := b.readEntry(b.offsets[index], b.size)
+	return entry, err
+}
+
+// Len returns the number of entries on the board
+func (b *FileBulletinBoard) Len() (uint64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return uint64(len(b.offsets)), nil
+}
+
+// Close closes the underlying file
+func (b *FileBulletinBoard) Close() error {
+	return b.file.Close()
+}
+
+// reads the entry at offset, which must end before end, and returns it with its
+// encoded size; io.EOF is only returned if there is no entry at offset
+func (b *FileBulletinBoard) readEntry(offset, end int64) (*BulletinEntry, int64, error) {
+	kind, err := b.readField(offset, end)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := b.readField(offset+4+int64(len(kind)), end)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &BulletinEntry{Kind: string(kind), Data: data}, 8 + int64(len(kind)+len(data)), nil
+}
+
+// reads a 4-byte big-endian length followed by as many bytes
+func (b *FileBulletinBoard) readField(offset, end int64) ([]byte, error) {
+	if offset >= end {
+		return nil, io.EOF
+	}
+
+	var length [4]byte
+	if _, err := b.file.ReadAt(length[:], offset); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(length[:]))
+	if offset+4+size > end {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	field := make([]byte, size)
+	if _, err := b.file.ReadAt(field, offset+4); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return field, nil
+}
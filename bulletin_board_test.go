@@ -0,0 +1,104 @@
+package paillier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func testBulletinBoard(t *testing.T, board BulletinBoard) []*InclusionReceipt {
+	tsk := getThresholdPrivateKey()
+	tk := &tsk.ThresholdPublicKey
+
+	ct := tk.Encrypt(gmp.NewInt(42))
+	pd, err := tsk.PartialDecryptionWithZKP(ct.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var receipts []*InclusionReceipt
+	for _, publish := range []func() (*InclusionReceipt, error){
+		func() (*InclusionReceipt, error) { return PublishPublicKey(board, tk) },
+		func() (*InclusionReceipt, error) { return PublishCiphertext(board, ct) },
+		func() (*InclusionReceipt, error) { return PublishPartialDecryption(board, pd) },
+	} {
+		receipt, err := publish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	for i, receipt := range receipts {
+		if receipt.Index != uint64(i) {
+			t.Error("wrong index ", receipt.Index)
+		}
+		if err := VerifyInclusion(board, receipt); err != nil {
+			t.Error(err)
+		}
+	}
+
+	var fetched PartialDecryptionZKP
+	if err := FetchGob(board, 2, BulletinPartialDecryption, &fetched); err != nil {
+		t.Fatal(err)
+	}
+	if !fetched.VerifyProof() {
+		t.Error("published proof does not verify")
+	}
+
+	if err := FetchGob(board, 1, BulletinPartialDecryption, &fetched); err == nil {
+		t.Error("expected error for entry of another kind")
+	}
+
+	return receipts
+}
+
+func TestMemoryBulletinBoard(t *testing.T) {
+	board := NewMemoryBulletinBoard()
+	receipts := testBulletinBoard(t, board)
+
+	// modifying an earlier entry invalidates the receipts of the later ones
+	board.entries[1].Data[3] ^= 1
+	if err := VerifyInclusion(board, receipts[2]); err == nil {
+		t.Error("expected error after modification of an earlier entry")
+	}
+	if err := VerifyInclusion(board, receipts[0]); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileBulletinBoard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "board")
+
+	board, err := OpenFileBulletinBoard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts := testBulletinBoard(t, board)
+	board.Close()
+
+	board, err = OpenFileBulletinBoard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, receipt := range receipts {
+		if err := VerifyInclusion(board, receipt); err != nil {
+			t.Error(err)
+		}
+	}
+	board.Close()
+
+	// a truncated entry is reported as corruption
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileBulletinBoard(path); err == nil {
+		t.Error("expected error for truncated board")
+	}
+}
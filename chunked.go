@@ -0,0 +1,172 @@
+package paillier
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Each chunk of a ChunkedCiphertext encrypts the plaintext
+//
+//	1 || index || count || data
+//
+// where index and count are 4-byte big-endian values. The leading one byte
+// keeps the leading zeros of the data, and the index and the number of chunks
+// let the decryption detect chunks that were reordered, dropped or copied
+// from another message.
+
+// chunkHeaderSize is the number of plaintext bytes of a chunk that are not data
+const chunkHeaderSize = 1 + 4 + 4
+
+// ChunkedCiphertext is the encryption of a message that does not fit into
+// the plaintext space of the key. The message is split into chunks, each
+// encrypted at level one, so that the chunks can be decrypted by a single
+// key or a committee like any other ciphertext.
+type ChunkedCiphertext struct {
+	Length int // byte length of the message
+	Chunks []*Ciphertext
+}
+
+// MaxChunkLength returns the number of message bytes encrypted per chunk by
+// EncryptBytes, which is zero if the key is too small for chunking
+func (pk *PublicKey) MaxChunkLength() int {
+	if size := (pk.N.BitLen()-1)/8 - chunkHeaderSize; size > 0 {
+		return size
+	}
+	return 0
+}
+
+// EncryptBytes encrypts a message of any length, splitting it into chunks of
+// MaxChunkLength bytes. The chunks are encrypted on one goroutine per core.
+func (pk *PublicKey) EncryptBytes(msg []byte) (*ChunkedCiphertext, error) {
+	size := pk.MaxChunkLength()
+	if size == 0 {
+		return nil, errors.New("key is too small to encrypt chunks")
+	}
+
+	count := (len(msg) + size - 1) / size
+	if count == 0 {
+		count = 1
+	}
+
+	ms := make([]*gmp.Int, count)
+	for i := range ms {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+		ms[i] = encodeChunk(i, count, msg[i*size:end])
+	}
+
+	return &ChunkedCiphertext{Length: len(msg), Chunks: pk.EncryptBatch(ms)}, nil
+}
+
+// DecryptBytes decrypts a message encrypted by EncryptBytes
+func (sk *SecretKey) DecryptBytes(cc *ChunkedCiphertext) ([]byte, error) {
+	if err := cc.check(); err != nil {
+		return nil, err
+	}
+	return cc.decode(sk.DecryptBatch(cc.Chunks))
+}
+
+// EncryptLargeInt encrypts a non-negative integer of any size with
+// EncryptBytes
+func (pk *PublicKey) EncryptLargeInt(m *gmp.Int) (*ChunkedCiphertext, error) {
+	if m == nil {
+		return nil, errors.New("missing plaintext")
+	}
+	if m.Sign() < 0 {
+		return nil, errors.New("plaintext is negative")
+	}
+	return pk.EncryptBytes(m.Bytes())
+}
+
+// DecryptLargeInt decrypts an integer encrypted by EncryptLargeInt
+func (sk *SecretKey) DecryptLargeInt(cc *ChunkedCiphertext) (*gmp.Int, error) {
+	msg, err := sk.DecryptBytes(cc)
+	if err != nil {
+		return nil, err
+	}
+	return new(gmp.Int).SetBytes(msg), nil
+}
+
+// PartialDecryptBytes partially decrypts the chunks of a message encrypted
+// by EncryptBytes with the key share
+func (tsk *ThresholdSecretKey) PartialDecryptBytes(cc *ChunkedCiphertext) ([]*PartialDecryption, error) {
+	if err := cc.check(); err != nil {
+		return nil, err
+	}
+	return tsk.PartialDecryptBatch(cc.Chunks)
+}
+
+// CombineBytes reassembles a message encrypted by EncryptBytes from the
+// partial decryptions of the committee. byServer holds the results of
+// PartialDecryptBytes of the participating servers, i.e., byServer[j][i] is
+// the share of server j for the i-th chunk. Integers encrypted by
+// EncryptLargeInt are recovered with SetBytes of the message.
+func (tk *ThresholdPublicKey) CombineBytes(cc *ChunkedCiphertext, byServer [][]*PartialDecryption) ([]byte, error) {
+	if err := cc.check(); err != nil {
+		return nil, err
+	}
+	for _, shares := range byServer {
+		if len(shares) != len(cc.Chunks) {
+			return nil, errors.New("number of partial decryptions does not match the number of chunks")
+		}
+		for i, share := range shares {
+			if share == nil || share.Decryption == nil {
+				return nil, fmt.Errorf("missing partial decryption of chunk %d", i)
+			}
+		}
+	}
+
+	ms, err := tk.CombinePartialDecryptionsBatch(byServer, EncLevelOne)
+	if err != nil {
+		return nil, err
+	}
+	return cc.decode(ms)
+}
+
+func encodeChunk(index, count int, data []byte) *gmp.Int {
+	buf := make([]byte, chunkHeaderSize, chunkHeaderSize+len(data))
+	buf[0] = 1
+	binary.BigEndian.PutUint32(buf[1:], uint32(index))
+	binary.BigEndian.PutUint32(buf[5:], uint32(count))
+	return new(gmp.Int).SetBytes(append(buf, data...))
+}
+
+func (cc *ChunkedCiphertext) check() error {
+	if cc == nil || len(cc.Chunks) == 0 {
+		return errors.New("chunked ciphertext has no chunks")
+	}
+	for i, ct := range cc.Chunks {
+		if ct == nil || ct.C == nil {
+			return fmt.Errorf("%w: missing chunk %d", ErrInvalidCiphertext, i)
+		}
+		if ct.Level != EncLevelOne {
+			return fmt.Errorf("chunk %d is not a level one ciphertext", i)
+		}
+	}
+	return nil
+}
+
+// decode reassembles the message from the decrypted chunks
+func (cc *ChunkedCiphertext) decode(ms []*gmp.Int) ([]byte, error) {
+	var msg []byte
+	for i, m := range ms {
+		buf := m.Bytes()
+		if len(buf) < chunkHeaderSize || buf[0] != 1 {
+			return nil, fmt.Errorf("chunk %d is malformed", i)
+		}
+		if int(binary.BigEndian.Uint32(buf[1:])) != i || int(binary.BigEndian.Uint32(buf[5:])) != len(ms) {
+			return nil, fmt.Errorf("chunk %d is out of order or from another message", i)
+		}
+		msg = append(msg, buf[chunkHeaderSize:]...)
+	}
+
+	if len(msg) != cc.Length {
+		return nil, errors.New("message length does not match the chunks")
+	}
+	return msg, nil
+}
@@ -0,0 +1,122 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestEncryptDecryptBytes(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	size := pk.MaxChunkLength()
+	for _, length := range []int{0, 1, size - 1, size, size + 1, 10 * size} {
+		msg := make([]byte, length)
+		rand.Read(msg)
+		if length > 0 {
+			msg[0] = 0 // leading zeros are kept
+		}
+
+		cc, err := pk.EncryptBytes(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := sk.DecryptBytes(cc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, msg) {
+			t.Errorf("wrong decryption of a %d byte message", length)
+		}
+	}
+
+	cc, err := pk.EncryptBytes(make([]byte, 3*size))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	swapped := &ChunkedCiphertext{Length: cc.Length, Chunks: []*Ciphertext{cc.Chunks[1], cc.Chunks[0], cc.Chunks[2]}}
+	if _, err := sk.DecryptBytes(swapped); err == nil {
+		t.Error("expected error for reordered chunks")
+	}
+
+	truncated := &ChunkedCiphertext{Length: 2 * size, Chunks: cc.Chunks[:2]}
+	if _, err := sk.DecryptBytes(truncated); err == nil {
+		t.Error("expected error for dropped chunks")
+	}
+
+	cc.Length++
+	if _, err := sk.DecryptBytes(cc); err == nil {
+		t.Error("expected error for wrong length")
+	}
+}
+
+func TestEncryptDecryptLargeInt(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	m := new(gmp.Int).Exp(pk.N, b(5), nil)
+	m.Add(m, b(12345))
+
+	cc, err := pk.EncryptLargeInt(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cc.Chunks) < 2 {
+		t.Error("large integer was not split into chunks")
+	}
+
+	decrypted, err := sk.DecryptLargeInt(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Cmp(m) != 0 {
+		t.Error("wrong decryption ", decrypted)
+	}
+
+	if _, err := pk.EncryptLargeInt(b(-1)); err == nil {
+		t.Error("expected error for negative integer")
+	}
+}
+
+func TestThresholdCombineBytes(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(128, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	msg := bytes.Repeat([]byte("threshold "), 10)
+	cc, err := tk.EncryptBytes(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var byServer [][]*PartialDecryption
+	for _, tsk := range tsks[1:] {
+		shares, err := tsk.PartialDecryptBytes(cc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byServer = append(byServer, shares)
+	}
+
+	decrypted, err := tk.CombineBytes(cc, byServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, msg) {
+		t.Error("wrong decryption ", decrypted)
+	}
+
+	if _, err := tk.CombineBytes(cc, [][]*PartialDecryption{byServer[0][1:], byServer[1][1:]}); err == nil {
+		t.Error("expected error for missing partial decryptions")
+	}
+}
@@ -0,0 +1,51 @@
+package paillier
+
+// Clone returns a deep copy of the public key that shares no integers with
+// pk, so that either can be modified or used by another goroutine without
+// affecting the other. The random source is kept; precomputed tables are not
+// copied and must be enabled again on the clone.
+func (pk *PublicKey) Clone() *PublicKey {
+	clone := pk.deepCopy()
+	clone.random = pk.random
+	return clone
+}
+
+// Clone returns a deep copy of the secret key, see PublicKey.Clone. Hardening
+// is kept.
+func (sk *SecretKey) Clone() *SecretKey {
+	return &SecretKey{
+		PublicKey: *sk.PublicKey.Clone(),
+		Lambda:    copyInt(sk.Lambda),
+		Lm:        copyInt(sk.Lm),
+		Mu:        copyInt(sk.Mu),
+		m:         copyInt(sk.m),
+		hardened:  sk.hardened,
+	}
+}
+
+// Clone returns a deep copy of the threshold public key including all
+// verification keys, see PublicKey.Clone. The Lagrange cache is not copied.
+func (tk *ThresholdPublicKey) Clone() *ThresholdPublicKey {
+	clone := tk.deepCopy()
+	clone.random = tk.random
+	return clone
+}
+
+// Clone returns a deep copy of the key share, see PublicKey.Clone. Hardening,
+// the security parameters and the ShareDecrypter are kept; the partial
+// decryption cache is not copied.
+func (tsk *ThresholdSecretKey) Clone() *ThresholdSecretKey {
+	return &ThresholdSecretKey{
+		ThresholdPublicKey: *tsk.ThresholdPublicKey.Clone(),
+		ID:                 tsk.ID,
+		Share:              copyInt(tsk.Share),
+		hardened:           tsk.hardened,
+		security:           tsk.security,
+		decrypter:          tsk.decrypter,
+	}
+}
+
+// Clone returns a deep copy of the ciphertext
+func (ct *Ciphertext) Clone() *Ciphertext {
+	return &Ciphertext{C: copyInt(ct.C), Level: ct.Level, EncMethod: ct.EncMethod}
+}
@@ -0,0 +1,67 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	pkClone := pk.Clone()
+	if !pkClone.Equal(pk) {
+		t.Fatal("clone of the public key is not equal to the key")
+	}
+	pkClone.N.Add(pkClone.N, OneBigInt)
+	if pk.Equal(pkClone) {
+		t.Error("clone of the public key shares N with the key")
+	}
+
+	skClone := sk.Clone()
+	skClone.Lambda.Add(skClone.Lambda, OneBigInt)
+	if sk.Lambda.Cmp(skClone.Lambda) == 0 {
+		t.Error("clone of the secret key shares Lambda with the key")
+	}
+
+	ct := pk.Encrypt(b(9))
+	if n(sk.Clone().Decrypt(ct)) != 9 {
+		t.Error("clone of the secret key does not decrypt")
+	}
+
+	ctClone := ct.Clone()
+	ctClone.C.Add(ctClone.C, OneBigInt)
+	if ct.C.Cmp(ctClone.C) == 0 {
+		t.Error("clone of the ciphertext shares C with the ciphertext")
+	}
+
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tkClone := tsks[0].ThresholdPublicKey.Clone()
+	if !tkClone.Equal(&tsks[0].ThresholdPublicKey) {
+		t.Fatal("clone of the threshold public key is not equal to the key")
+	}
+	tkClone.VerificationKeys[1].Add(tkClone.VerificationKeys[1], OneBigInt)
+	if tkClone.Equal(&tsks[0].ThresholdPublicKey) {
+		t.Error("clone of the threshold public key shares verification keys with the key")
+	}
+
+	tskClone := tsks[1].Clone()
+	if tskClone.ID != tsks[1].ID || tskClone.Share.Cmp(tsks[1].Share) != 0 {
+		t.Fatal("clone of the key share differs from the key share")
+	}
+	if err := tskClone.VerifyPartialDecryption(); err != nil {
+		t.Error(err)
+	}
+	tskClone.Share.Add(tskClone.Share, OneBigInt)
+	if tskClone.Share.Cmp(tsks[1].Share) == 0 {
+		t.Error("clone of the key share shares the share with the key")
+	}
+}
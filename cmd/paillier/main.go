@@ -0,0 +1,442 @@
+// Command paillier generates keys and runs the basic operations of the
+// package on files, e.g., for offline key ceremonies and for inspecting
+// protocol traffic.
+//
+// Usage:
+//
+//	paillier keygen [-bits 2048] [-servers n -threshold t] [-out prefix]
+//	paillier encrypt -key key.pem [plaintext...]
+//	paillier decrypt -key key.pem [ciphertext...]
+//	paillier partial-decrypt -key share.pem [-proof=false] [ciphertext...]
+//	paillier combine -key key.pem [-unverified] [share...]
+//	paillier verify -key key.pem [share...]
+//
+// Keys are PEM files as written by paillier.EncodePEM and are parsed with
+// paillier.ParsePEM; -min-bits lowers the minimum key length for test keys.
+// Plaintexts are decimal. Threshold decryption supports level one
+// ciphertexts only. Ciphertexts are the base64 encoding of
+// Ciphertext.MarshalBinary and partial decryptions the base64 encoding of
+// their Encode method. They are read from the arguments or, if there are
+// none, one per line from standard input, and written one per line to
+// standard output.
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "paillier:", err)
+		os.Exit(1)
+	}
+}
+
+// a command parses its flags from args, reads its inputs from the remaining
+// arguments or stdin and writes its results to stdout
+type command func(args []string, stdin io.Reader, stdout io.Writer) error
+
+var commands = map[string]command{
+	"keygen":          keygen,
+	"encrypt":         encrypt,
+	"decrypt":         decrypt,
+	"partial-decrypt": partialDecrypt,
+	"combine":         combine,
+	"verify":          verify,
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage())
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage())
+	}
+	return cmd(args[1:], stdin, stdout)
+}
+
+func usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "usage: paillier <command> [flags] [inputs], commands: " + strings.Join(names, ", ")
+}
+
+func keygen(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	bits := fs.Int("bits", 2048, "bit length of N")
+	servers := fs.Int("servers", 0, "number of decryption servers of a threshold key")
+	threshold := fs.Int("threshold", 0, "number of servers needed to decrypt")
+	out := fs.String("out", "paillier", "prefix of the written key files")
+	small := fs.Bool("insecure-small-keys", false, "allow keys below the minimum length, e.g., for tests")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *bits < paillier.DefaultMinPublicKeyBitLength && !*small {
+		return fmt.Errorf("%d-bit keys are below the minimum of %d bits, see -insecure-small-keys",
+			*bits, paillier.DefaultMinPublicKeyBitLength)
+	}
+
+	if *servers == 0 && *threshold == 0 {
+		sk, pk, err := paillier.KeyGenWithRandom(*bits, rand.Reader)
+		if err != nil {
+			return err
+		}
+		if err := writeKey(stdout, *out+".pub.pem", pk, 0644); err != nil {
+			return err
+		}
+		return writeKey(stdout, *out+".key.pem", sk, 0600)
+	}
+
+	tkg, err := paillier.NewThresholdKeyGenerator(*bits, *servers, *threshold, rand.Reader)
+	if err != nil {
+		return err
+	}
+	tkg.InsecureAllowSmallKeys = *small
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		return err
+	}
+
+	if err := writeKey(stdout, *out+".pub.pem", tsks[0].PublicOnly(), 0644); err != nil {
+		return err
+	}
+	for _, tsk := range tsks {
+		if err := writeKey(stdout, fmt.Sprintf("%s.share-%d.pem", *out, tsk.ID), tsk, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs, keyFile, limits := keyFlags("encrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := readKey(*keyFile, limits())
+	if err != nil {
+		return err
+	}
+	pk, err := publicKey(key)
+	if err != nil {
+		return err
+	}
+
+	return each(fs.Args(), stdin, func(input string) error {
+		m, ok := new(gmp.Int).SetString(input, 10)
+		if !ok {
+			return fmt.Errorf("%q is not a decimal plaintext", input)
+		}
+		ct, err := pk.EncryptWithRandom(m, nil)
+		if err != nil {
+			return err
+		}
+		data, err := ct.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return writeBase64(stdout, data)
+	})
+}
+
+func decrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs, keyFile, limits := keyFlags("decrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := readKey(*keyFile, limits())
+	if err != nil {
+		return err
+	}
+	sk, ok := key.(*paillier.SecretKey)
+	if !ok {
+		return fmt.Errorf("%s is not a secret key", *keyFile)
+	}
+
+	return each(fs.Args(), stdin, func(input string) error {
+		ct, err := parseCiphertext(&sk.PublicKey, input)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, sk.Decrypt(ct))
+		return err
+	})
+}
+
+func partialDecrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs, keyFile, limits := keyFlags("partial-decrypt")
+	proof := fs.Bool("proof", true, "prove the partial decryptions")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := readKey(*keyFile, limits())
+	if err != nil {
+		return err
+	}
+	tsk, ok := key.(*paillier.ThresholdSecretKey)
+	if !ok {
+		return fmt.Errorf("%s is not a threshold key share", *keyFile)
+	}
+
+	return each(fs.Args(), stdin, func(input string) error {
+		ct, err := parseCiphertext(&tsk.ThresholdPublicKey.PublicKey, input)
+		if err != nil {
+			return err
+		}
+		if ct.Level != paillier.EncLevelOne {
+			return errors.New("only level one ciphertexts can be partially decrypted")
+		}
+
+		if !*proof {
+			return writeBase64(stdout, tsk.PartialDecrypt(ct.C).Encode())
+		}
+		pd, err := tsk.PartialDecryptionWithZKP(ct.C)
+		if err != nil {
+			return err
+		}
+		data, err := pd.Encode()
+		if err != nil {
+			return err
+		}
+		return writeBase64(stdout, data)
+	})
+}
+
+func combine(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs, keyFile, limits := keyFlags("combine")
+	unverified := fs.Bool("unverified", false, "accept partial decryptions without proofs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := readKey(*keyFile, limits())
+	if err != nil {
+		return err
+	}
+	tk, err := thresholdKey(key)
+	if err != nil {
+		return err
+	}
+
+	// shares with proofs are verified against the ciphertext of the first
+	// of them, see Combiner.AddZKP
+	var combiner *paillier.Combiner
+	var plain []*paillier.PartialDecryption
+	err = each(fs.Args(), stdin, func(input string) error {
+		data, err := base64.StdEncoding.DecodeString(input)
+		if err != nil {
+			return err
+		}
+
+		if pd, err := tk.ParsePartialDecryptionZKP(data); err == nil {
+			if combiner == nil {
+				combiner = tk.NewCombiner(pd.C)
+			}
+			return combiner.AddZKP(pd)
+		} else if !*unverified {
+			return fmt.Errorf("%w (see -unverified for shares without proofs)", err)
+		}
+
+		pd, err := tk.ParsePartialDecryption(data)
+		if err != nil {
+			return err
+		}
+		plain = append(plain, pd)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if combiner == nil {
+		combiner = tk.NewCombiner(nil)
+	}
+	for _, pd := range plain {
+		if err := combiner.Add(pd); err != nil {
+			return err
+		}
+	}
+	m, err := combiner.Combine()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, m)
+	return err
+}
+
+func verify(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs, keyFile, limits := keyFlags("verify")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := readKey(*keyFile, limits())
+	if err != nil {
+		return err
+	}
+	tk, err := thresholdKey(key)
+	if err != nil {
+		return err
+	}
+
+	total, invalid := 0, 0
+	err = each(fs.Args(), stdin, func(input string) error {
+		total++
+		data, err := base64.StdEncoding.DecodeString(input)
+		if err == nil {
+			var pd *paillier.PartialDecryptionZKP
+			if pd, err = tk.ParsePartialDecryptionZKP(data); err == nil {
+				if err = pd.VerifyErrWithKey(tk); err == nil {
+					_, err = fmt.Fprintf(stdout, "share %d: ok\n", pd.ID)
+					return err
+				}
+			}
+		}
+
+		invalid++
+		_, err = fmt.Fprintf(stdout, "input %d: %v\n", total, err)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d partial decryptions are invalid", invalid, total)
+	}
+	return nil
+}
+
+// keyFlags returns a flag set with the -key and -min-bits flags shared by the
+// commands that read a key
+func keyFlags(name string) (*flag.FlagSet, *string, func() *paillier.ParseLimits) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	keyFile := fs.String("key", "", "PEM file of the key")
+	minBits := fs.Int("min-bits", 0, "minimum bit length of N (0 for the default)")
+
+	limits := func() *paillier.ParseLimits {
+		if *minBits == 0 {
+			return nil
+		}
+		return &paillier.ParseLimits{MinBitLength: *minBits}
+	}
+	return fs, keyFile, limits
+}
+
+func readKey(path string, limits *paillier.ParseLimits) (interface{}, error) {
+	if path == "" {
+		return nil, errors.New("missing -key")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := paillier.ParsePEM(data, limits)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// publicKey returns the public key of any key type
+func publicKey(key interface{}) (*paillier.PublicKey, error) {
+	switch k := key.(type) {
+	case *paillier.PublicKey:
+		return k, nil
+	case *paillier.SecretKey:
+		return &k.PublicKey, nil
+	case *paillier.ThresholdPublicKey:
+		return &k.PublicKey, nil
+	case *paillier.ThresholdSecretKey:
+		return &k.ThresholdPublicKey.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+// thresholdKey returns the threshold public key of a threshold key or share
+func thresholdKey(key interface{}) (*paillier.ThresholdPublicKey, error) {
+	switch k := key.(type) {
+	case *paillier.ThresholdPublicKey:
+		return k, nil
+	case *paillier.ThresholdSecretKey:
+		return k.PublicOnly(), nil
+	}
+	return nil, fmt.Errorf("%T is not a threshold key", key)
+}
+
+// writeKey writes the PEM encoding of the key to a new file and reports its
+// path; existing files are never overwritten
+func writeKey(stdout io.Writer, path string, key interface{}, perm os.FileMode) error {
+	data, err := paillier.EncodePEM(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, path)
+	return err
+}
+
+func parseCiphertext(pk *paillier.PublicKey, input string) (*paillier.Ciphertext, error) {
+	data, err := base64.StdEncoding.DecodeString(input)
+	if err != nil {
+		return nil, err
+	}
+	return pk.ParseCiphertext(data)
+}
+
+func writeBase64(stdout io.Writer, data []byte) error {
+	_, err := fmt.Fprintln(stdout, base64.StdEncoding.EncodeToString(data))
+	return err
+}
+
+// each calls fn for every argument or, without arguments, for every
+// non-empty line of stdin
+func each(args []string, stdin io.Reader, fn func(input string) error) error {
+	if len(args) > 0 {
+		for _, arg := range args {
+			if err := fn(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
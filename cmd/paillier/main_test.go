@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runLines runs the command and returns the lines of its output
+func runLines(t *testing.T, stdin string, args ...string) []string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := run(args, strings.NewReader(stdin), &out); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return strings.Fields(out.String())
+}
+
+func TestKeygenEncryptDecrypt(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "test")
+	files := runLines(t, "", "keygen", "-bits", "128", "-insecure-small-keys", "-out", prefix)
+	if len(files) != 2 {
+		t.Fatal("expected a public and a secret key file, got ", files)
+	}
+
+	cts := runLines(t, "", "encrypt", "-key", prefix+".pub.pem", "-min-bits", "128", "42", "7")
+	ms := runLines(t, strings.Join(cts, "\n"), "decrypt", "-key", prefix+".key.pem", "-min-bits", "128")
+	if len(ms) != 2 || ms[0] != "42" || ms[1] != "7" {
+		t.Error("wrong decryptions ", ms)
+	}
+
+	if err := run([]string{"keygen", "-bits", "128", "-insecure-small-keys", "-out", prefix}, nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for existing key files")
+	}
+	if err := run([]string{"decrypt", "-key", prefix + ".pub.pem", "-min-bits", "128"}, strings.NewReader(cts[0]), &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for decryption with a public key")
+	}
+	if err := run([]string{"encrypt", "-key", prefix + ".pub.pem", "42"}, nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for a key below the default minimum length")
+	}
+}
+
+func TestThresholdCeremony(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "ceremony")
+	files := runLines(t, "", "keygen", "-bits", "64", "-servers", "3", "-threshold", "2", "-insecure-small-keys", "-out", prefix)
+	if len(files) != 4 {
+		t.Fatal("expected a public key and three share files, got ", files)
+	}
+
+	ct := runLines(t, "", "encrypt", "-key", prefix+".pub.pem", "-min-bits", "64", "1234")[0]
+	var shares []string
+	for _, id := range []string{"1", "3"} {
+		shares = append(shares, runLines(t, ct, "partial-decrypt", "-key", prefix+".share-"+id+".pem", "-min-bits", "64")...)
+	}
+
+	lines := runLines(t, strings.Join(shares, "\n"), "verify", "-key", prefix+".pub.pem", "-min-bits", "64")
+	if strings.Join(lines, " ") != "share 1: ok share 3: ok" {
+		t.Error("unexpected verification output ", lines)
+	}
+
+	m := runLines(t, "", append([]string{"combine", "-key", prefix + ".pub.pem", "-min-bits", "64"}, shares...)...)
+	if len(m) != 1 || m[0] != "1234" {
+		t.Error("wrong combination ", m)
+	}
+
+	plain := runLines(t, ct, "partial-decrypt", "-key", prefix+".share-2.pem", "-min-bits", "64", "-proof=false")
+	args := []string{"combine", "-key", prefix + ".share-1.pem", "-min-bits", "64", shares[0], plain[0]}
+	if err := run(args, nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for a share without proof")
+	}
+	m = runLines(t, "", "combine", "-unverified", "-key", prefix+".share-1.pem", "-min-bits", "64", shares[0], plain[0])
+	if len(m) != 1 || m[0] != "1234" {
+		t.Error("wrong combination with an unverified share ", m)
+	}
+
+	// a share of another ciphertext
+	other := runLines(t, "", "encrypt", "-key", prefix+".pub.pem", "-min-bits", "64", "1")[0]
+	otherShare := runLines(t, other, "partial-decrypt", "-key", prefix+".share-2.pem", "-min-bits", "64")[0]
+	if err := run([]string{"combine", "-key", prefix + ".pub.pem", "-min-bits", "64", shares[0], otherShare}, nil, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for shares of different ciphertexts")
+	}
+
+	var out bytes.Buffer
+	tampered := []byte(shares[0])
+	tampered[len(tampered)/2] ^= 'A' ^ 'B'
+	if err := run([]string{"verify", "-key", prefix + ".pub.pem", "-min-bits", "64", string(tampered)}, nil, &out); err == nil {
+		t.Error("expected an error for a tampered share, got ", out.String())
+	}
+}
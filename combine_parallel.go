@@ -0,0 +1,65 @@
+package paillier
+
+import (
+	"runtime"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// parallelCombineShares is the number of shares from which combineShares
+// splits the shares into chunks that are combined on one goroutine per core
+const parallelCombineShares = 8
+
+// combineShares returns c' = prod_i c_i^(2 lambda_i) mod m. The powers are
+// computed simultaneously with multiExp. With many shares, they are split
+// into one chunk per core and the products of the chunks are multiplied in a
+// reduction tree, whose levels are parallel as well.
+func (tk *ThresholdPublicKey) combineShares(shares []*PartialDecryption, m *gmp.Int) *gmp.Int {
+	run := parallelFor
+	chunks := runtime.NumCPU()
+	if len(shares) < parallelCombineShares {
+		run = serialFor
+		chunks = 1
+	}
+	// keep enough shares per chunk for multiExp to pay off
+	if limit := len(shares) / multiExpMinBases; chunks > limit {
+		chunks = limit
+	}
+	if chunks < 1 {
+		chunks = 1
+	}
+
+	lambdas := tk.computeLambdas(shares)
+	bases := make([]*gmp.Int, len(shares))
+	exps := make([]*gmp.Int, len(shares))
+	for i, share := range shares {
+		bases[i] = share.Decryption
+		exps[i] = new(gmp.Int).Mul(TwoBigInt, lambdas[i])
+	}
+
+	factors := make([]*gmp.Int, chunks)
+	run(chunks, func(c int) {
+		lo, hi := c*len(shares)/chunks, (c+1)*len(shares)/chunks
+		factors[c] = multiExp(bases[lo:hi], exps[lo:hi], m)
+	})
+
+	for len(factors) > 1 {
+		next := make([]*gmp.Int, (len(factors)+1)/2)
+		run(len(factors)/2, func(i int) {
+			product := new(gmp.Int).Mul(factors[2*i], factors[2*i+1])
+			next[i] = product.Mod(product, m)
+		})
+		if len(factors)%2 == 1 {
+			next[len(next)-1] = factors[len(factors)-1]
+		}
+		factors = next
+	}
+	return factors[0]
+}
+
+// serialFor calls fn for 0 <= i < n on the calling goroutine
+func serialFor(n int, fn func(i int)) {
+	for i := 0; i < n; i++ {
+		fn(i)
+	}
+}
@@ -0,0 +1,71 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestCombineSharesMatchesSerial(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 11, 9, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(12))
+
+	// odd and even numbers of shares below and above the parallel threshold
+	for _, count := range []int{9, 10, 11} {
+		shares := make([]*PartialDecryption, count)
+		for i := range shares {
+			shares[i] = tsks[i].PartialDecrypt(ct.C)
+		}
+
+		cprime := OneBigInt
+		for _, share := range shares {
+			cprime = tk.updateCprime(cprime, tk.computeLambda(share, shares), share)
+		}
+		if tk.combineShares(shares, tk.GetN2()).Cmp(cprime) != 0 {
+			t.Errorf("%d shares: combined shares differ from the serial computation", count)
+		}
+
+		m, err := tk.CombinePartialDecryptions(shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Cmp(gmp.NewInt(12)) != 0 {
+			t.Errorf("%d shares: wrong plaintext %v", count, m)
+		}
+	}
+}
+
+func BenchmarkCombinePartialDecryptions(b *testing.B) {
+	tkg, err := NewThresholdKeyGenerator(512, 64, 64, rand.Reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		b.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(1))
+	shares := make([]*PartialDecryption, len(tsks))
+	for i, tsk := range tsks {
+		shares[i] = tsk.PartialDecrypt(ct.C)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tk.CombinePartialDecryptions(shares); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Combiner collects the partial decryptions of a ciphertext as they arrive,
+// e.g., from decryption servers over the network, and combines them once
+// Threshold shares from distinct servers were added. It is safe for
+// concurrent use.
+type Combiner struct {
+	key *ThresholdPublicKey
+	c   *gmp.Int
+
+	mu     sync.Mutex
+	shares []*PartialDecryption // in the order they were added
+	seen   map[int]bool
+}
+
+// NewCombiner returns a Combiner for the partial decryptions of the level one
+// ciphertext c
+func (tk *ThresholdPublicKey) NewCombiner(c *gmp.Int) *Combiner {
+	return &Combiner{key: tk, c: c, seen: make(map[int]bool)}
+}
+
+// Add adds a partial decryption without a proof. It returns an error if the
+// share ID is out of range, the share carries the fingerprint of another key
+// or a share of the same server was already added.
+func (cb *Combiner) Add(share *PartialDecryption) error {
+	if share == nil || share.Decryption == nil {
+		return errors.New("missing partial decryption")
+	}
+	if share.ID < 1 || share.ID > cb.key.TotalNumberOfDecryptionServers {
+		return fmt.Errorf("share ID %d is out of range", share.ID)
+	}
+	if share.KeyFingerprint != "" && share.KeyFingerprint != cb.key.CommitteeFingerprint() {
+		return fmt.Errorf("%w: share of server %d", ErrKeyMismatch, share.ID)
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.seen[share.ID] {
+		return fmt.Errorf("%w: share %d was already added", ErrDuplicateShareID, share.ID)
+	}
+	cb.seen[share.ID] = true
+	cb.shares = append(cb.shares, share)
+	return nil
+}
+
+// AddZKP verifies the proof of the partial decryption against the key and the
+// ciphertext of the combiner and adds it. The share is not added if the proof
+// is rejected, in which case the error is one of VerifyErrWithKey.
+func (cb *Combiner) AddZKP(share *PartialDecryptionZKP) error {
+	if share == nil {
+		return fmt.Errorf("%w: missing partial decryption", ErrMalformedProof)
+	}
+	if share.C == nil || share.C.Cmp(cb.c) != 0 {
+		return fmt.Errorf("share %d: partial decryption is for another ciphertext", share.ID)
+	}
+	if err := share.VerifyErrWithKey(cb.key); err != nil {
+		return err
+	}
+	return cb.Add(&share.PartialDecryption)
+}
+
+// Len returns the number of shares added
+func (cb *Combiner) Len() int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return len(cb.shares)
+}
+
+// Ready returns true once Threshold shares were added
+func (cb *Combiner) Ready() bool {
+	return cb.Len() >= cb.key.Threshold
+}
+
+// Combine combines the first Threshold shares that were added and returns the
+// plaintext. It returns an error if the combiner is not Ready.
+func (cb *Combiner) Combine() (*gmp.Int, error) {
+	cb.mu.Lock()
+	if len(cb.shares) < cb.key.Threshold {
+		cb.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d of %d partial decryptions were added", ErrTooFewShares, len(cb.shares), cb.key.Threshold)
+	}
+	shares := append([]*PartialDecryption{}, cb.shares[:cb.key.Threshold]...)
+	cb.mu.Unlock()
+
+	return cb.key.CombinePartialDecryptions(shares)
+}
@@ -0,0 +1,86 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestCombiner(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(31))
+	pds := batchPartialDecryptions(t, tsks, []*Ciphertext{ct})
+
+	combiner := tk.NewCombiner(ct.C)
+	if _, err := combiner.Combine(); err == nil {
+		t.Error("expected an error before the threshold is reached")
+	}
+
+	// shares arrive concurrently, one of them twice
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i, pd := range []*PartialDecryptionZKP{pds[4], pds[1], pds[4]} {
+		wg.Add(1)
+		go func(i int, pd *PartialDecryptionZKP) {
+			defer wg.Done()
+			errs[i] = combiner.AddZKP(pd)
+		}(i, pd)
+	}
+	wg.Wait()
+	if failed := len(errs) - countNil(errs); failed != 1 {
+		t.Error("expected exactly one duplicate share to be rejected, got ", errs)
+	}
+	if combiner.Ready() {
+		t.Error("combiner is ready with two shares")
+	}
+
+	// an invalid proof is not added
+	bad := *pds[0]
+	bad.E = new(gmp.Int).Add(pds[0].E, OneBigInt)
+	if err := combiner.AddZKP(&bad); !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected a challenge mismatch, got ", err)
+	}
+	other, err := tsks[0].PartialDecryptionWithZKP(tk.Encrypt(gmp.NewInt(31)).C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := combiner.AddZKP(other); err == nil {
+		t.Error("expected an error for a share of another ciphertext")
+	}
+
+	if err := combiner.Add(tsks[2].PartialDecrypt(ct.C)); err != nil {
+		t.Fatal(err)
+	}
+	if !combiner.Ready() || combiner.Len() != 3 {
+		t.Fatal("combiner is not ready with three shares")
+	}
+	m, err := combiner.Combine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Cmp(gmp.NewInt(31)) != 0 {
+		t.Error("wrong plaintext ", m)
+	}
+}
+
+func countNil(errs []error) int {
+	n := 0
+	for _, err := range errs {
+		if err == nil {
+			n++
+		}
+	}
+	return n
+}
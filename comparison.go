@@ -0,0 +1,305 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// ComparisonRequest is sent by the comparator to the key holder in the first
+// round of the comparison protocol and contains [d] = [x - y + 2^BitLength + r]
+type ComparisonRequest struct {
+	Masked    *Ciphertext
+	BitLength int
+}
+
+// ComparisonBits is the answer of the key holder to a ComparisonRequest: the
+// encrypted bits of d mod 2^BitLength, least significant first, and the
+// encryption of d / 2^BitLength
+type ComparisonBits struct {
+	Bits []*Ciphertext
+	High *Ciphertext
+}
+
+// ComparisonChallenge is sent by the comparator in the second round and
+// contains the blinded bitwise comparison in random order
+type ComparisonChallenge struct {
+	Blinded []*Ciphertext
+}
+
+// ComparisonResponse is returned by the key holder and contains [z], where
+// z = 1 iff a ciphertext of the challenge decrypts to zero
+type ComparisonResponse struct {
+	Zero *Ciphertext
+}
+
+// Comparator holds the state of the party that wants to learn an encryption
+// of the bit x >= y for level one encryptions [x] and [y] of values in
+// [0, 2^BitLength) with the help of the key holder (see ComparisonHelper),
+// e.g., for sealed-bid auctions or thresholding on encrypted data. Neither
+// party learns x, y or the result.
+//
+// The protocol is the comparison of [Veu 12] with the bitwise comparison of
+// [DGK 07] run on Paillier encryptions of the bits:
+//  1. the comparator masks z = x - y + 2^l, whose bit l is the result, as
+//     [d] = [z + r] for random r with l + StatisticalSecurity + 1 bits
+//  2. the key holder decrypts d and returns the encrypted bits of
+//     d mod 2^l and [d / 2^l]
+//  3. the comparator computes t = (d mod 2^l < r mod 2^l) with the DGK
+//     comparison: for every bit position i it computes
+//     [c_i] = [s + r_i - d_i + 3 sum_(j>i) (d_j xor r_j)] with s = 1 - 2f for
+//     a random bit f, which is zero at exactly one position iff the values
+//     compare in the direction given by f; it multiplies every [c_i] by a
+//     random unit and shuffles them
+//  4. the key holder returns the encryption of the bit whether any of them
+//     decrypts to zero, from which the comparator removes f to get [t]
+//  5. the result is [z_l] = [d / 2^l] - [r / 2^l] - [t]
+//
+// The bitwise comparison runs on 2(d mod 2^l) + 1 and 2(r mod 2^l), which
+// are never equal, so the DGK test does not need to distinguish equality.
+// The key holder learns d, which hides x - y statistically, and whether the
+// blinded comparison contains a zero, which is hidden by f. Both parties are
+// assumed to follow the protocol.
+//
+//	[Veu 12]: Thijs Veugen, (2012)
+//	          Improving the DGK comparison protocol
+//	[DGK 07]: Ivan Damgård, Martin Geisler, Mikkel Krøigaard, (2007)
+//	          Efficient and Secure Comparison for On-Line Auctions
+type Comparator struct {
+	pk        *PublicKey
+	bitLength int
+
+	r    *gmp.Int    // mask of z
+	flip bool        // f
+	high *Ciphertext // [d / 2^l]
+	done bool
+}
+
+// ComparisonHelper answers the requests of a Comparator with a Decrypter,
+// i.e., a SecretKey or a ThresholdClient of a decryption committee
+type ComparisonHelper struct {
+	Key       *PublicKey
+	Decrypter Decrypter
+}
+
+// NewComparator returns the comparator of the level one encryptions of x and
+// y in [0, 2^bitLength) together with the request for the key holder
+func (pk *PublicKey) NewComparator(x, y *Ciphertext, bitLength int) (*Comparator, *ComparisonRequest, error) {
+	if x.Level != EncLevelOne || y.Level != EncLevelOne {
+		return nil, nil, errors.New("comparisons are only supported for level one ciphertexts")
+	}
+	if bitLength <= 0 {
+		return nil, nil, errors.New("bit length must be positive")
+	}
+
+	// z + r < 2^(bitLength + StatisticalSecurity + 2) must not wrap around N
+	if DefaultAlertStatisticalSecurity+bitLength+2 >= pk.N.BitLen() {
+		return nil, nil, errors.New("public key is too small for the requested bit length")
+	}
+
+	bound := new(gmp.Int).Lsh(OneBigInt, uint(bitLength+DefaultAlertStatisticalSecurity+1))
+	r, err := GetRandomNumber(bound, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := &Comparator{pk: pk, bitLength: bitLength, r: r}
+
+	offset := new(gmp.Int).Lsh(OneBigInt, uint(bitLength))
+	offset.Add(offset, r)
+	masked := pk.Randomize(pk.addConstant(pk.Sub(x, y), offset))
+
+	return c, &ComparisonRequest{Masked: masked, BitLength: bitLength}, nil
+}
+
+// Challenge runs the bitwise comparison on the bits returned by the key
+// holder and returns the challenge for the key holder
+func (c *Comparator) Challenge(bits *ComparisonBits) (*ComparisonChallenge, error) {
+	if c.high != nil {
+		return nil, errors.New("challenge was already created")
+	}
+	if bits == nil || bits.High == nil || bits.High.C == nil || bits.High.Level != EncLevelOne || len(bits.Bits) != c.bitLength {
+		return nil, fmt.Errorf("comparison bits must have %d bits", c.bitLength)
+	}
+	for i, ct := range bits.Bits {
+		if ct == nil || ct.C == nil || ct.Level != EncLevelOne {
+			return nil, fmt.Errorf("%w: bit %d", ErrInvalidCiphertext, i)
+		}
+	}
+
+	pk := c.pk
+	random := pk.RandomSource()
+
+	flipBit, err := GetRandomNumber(TwoBigInt, random)
+	if err != nil {
+		return nil, err
+	}
+	c.flip = flipBit.Cmp(OneBigInt) == 0
+
+	// compare 2(d mod 2^l) + 1 with 2(r mod 2^l): bit 0 is 1 and 0, bit i + 1
+	// is bit i of d and r
+	d := make([]*Ciphertext, c.bitLength+1)
+	r := make([]uint, c.bitLength+1)
+	d[0] = pk.trivialEncryption(OneBigInt)
+	for i, ct := range bits.Bits {
+		d[i+1] = ct
+		r[i+1] = c.r.Bit(i)
+	}
+
+	permutation, err := randomPermutation(len(d), random)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &ComparisonChallenge{Blinded: make([]*Ciphertext, len(d))}
+	xors := pk.trivialEncryption(ZeroBigInt) // sum of the xors above position i
+	three := gmp.NewInt(3)
+	for i := len(d) - 1; i >= 0; i-- {
+		// s + r_i - d_i + 3 * xors
+		s := int64(1)
+		if c.flip {
+			s = -1
+		}
+		constant := gmp.NewInt(s + int64(r[i]))
+		ci := pk.addConstant(pk.Sub(pk.ConstMult(xors, three), d[i]), constant)
+
+		blind, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+		if err != nil {
+			return nil, err
+		}
+		challenge.Blinded[permutation[i]] = pk.Randomize(pk.ConstMult(ci, blind))
+
+		// d_i xor r_i is d_i if r_i = 0 and 1 - d_i otherwise
+		if r[i] == 0 {
+			xors = pk.Add(xors, d[i])
+		} else {
+			xors = pk.addConstant(pk.Sub(xors, d[i]), OneBigInt)
+		}
+	}
+
+	c.high = bits.High
+	return challenge, nil
+}
+
+// Finalize returns the encryption of 1 if x >= y and of 0 otherwise from the
+// response of the key holder
+func (c *Comparator) Finalize(resp *ComparisonResponse) (*Ciphertext, error) {
+	if c.high == nil {
+		return nil, errors.New("no pending comparison challenge")
+	}
+	if c.done {
+		return nil, errors.New("comparison was already finalized")
+	}
+	if resp == nil || resp.Zero == nil || resp.Zero.C == nil || resp.Zero.Level != EncLevelOne {
+		return nil, errors.New("incomplete comparison response")
+	}
+	c.done = true
+
+	pk := c.pk
+
+	// with f = 1 a zero means 2r' > 2d' + 1, i.e., t = 1; with f = 0 a zero
+	// means 2d' + 1 > 2r', i.e., t = 0
+	t := resp.Zero
+	if !c.flip {
+		t = pk.addConstant(pk.Sub(pk.trivialEncryption(ZeroBigInt), resp.Zero), OneBigInt)
+	}
+
+	rHigh := new(gmp.Int).Rsh(c.r, uint(c.bitLength))
+	result := pk.Sub(c.high, t)
+	result = pk.addConstant(result, new(gmp.Int).Sub(pk.N, rHigh))
+	return pk.Randomize(result), nil
+}
+
+// NewComparisonHelper returns the helper for comparators with the public key pk
+func NewComparisonHelper(pk *PublicKey, dec Decrypter) *ComparisonHelper {
+	return &ComparisonHelper{Key: pk, Decrypter: dec}
+}
+
+// Decompose decrypts the masked difference of the request and returns its
+// encrypted bits
+func (h *ComparisonHelper) Decompose(req *ComparisonRequest) (*ComparisonBits, error) {
+	if req == nil || req.Masked == nil || req.Masked.Level != EncLevelOne {
+		return nil, errors.New("comparisons are only supported for level one ciphertexts")
+	}
+	if req.BitLength <= 0 || req.BitLength+2 >= h.Key.N.BitLen() {
+		return nil, errors.New("bit length is out of range")
+	}
+
+	d, err := h.Decrypter.TryDecrypt(req.Masked)
+	if err != nil {
+		return nil, fmt.Errorf("masked difference could not be decrypted: %w", err)
+	}
+
+	bits, err := h.Key.EncryptBits(d, req.BitLength)
+	if err != nil {
+		return nil, err
+	}
+	return &ComparisonBits{
+		Bits: bits,
+		High: h.Key.Encrypt(new(gmp.Int).Rsh(d, uint(req.BitLength))),
+	}, nil
+}
+
+// Evaluate decrypts the blinded comparison of the challenge and returns the
+// encryption of 1 if any of its values is zero and of 0 otherwise
+func (h *ComparisonHelper) Evaluate(ch *ComparisonChallenge) (*ComparisonResponse, error) {
+	if ch == nil || len(ch.Blinded) == 0 {
+		return nil, errors.New("incomplete comparison challenge")
+	}
+
+	zero := int64(0)
+	for _, ct := range ch.Blinded {
+		if ct == nil || ct.C == nil || ct.Level != EncLevelOne {
+			return nil, errors.New("incomplete comparison challenge")
+		}
+		m, err := h.Decrypter.TryDecrypt(ct)
+		if err != nil {
+			return nil, fmt.Errorf("comparison could not be decrypted: %w", err)
+		}
+		if m.Sign() == 0 {
+			zero = 1
+		}
+	}
+	return &ComparisonResponse{Zero: h.Key.Encrypt(gmp.NewInt(zero))}, nil
+}
+
+// EncryptBits returns the encryptions of the lowest bitLength bits of the
+// non-negative value, least significant first
+func (pk *PublicKey) EncryptBits(value *gmp.Int, bitLength int) ([]*Ciphertext, error) {
+	if value == nil || value.Sign() < 0 {
+		return nil, errors.New("value must be non-negative")
+	}
+	if bitLength <= 0 {
+		return nil, errors.New("bit length must be positive")
+	}
+
+	bits := make([]*Ciphertext, bitLength)
+	for i := range bits {
+		bits[i] = pk.Encrypt(gmp.NewInt(int64(value.Bit(i))))
+	}
+	return bits, nil
+}
+
+// CombineBits returns the encryption of sum_i 2^i b_i from the encryptions of
+// the bits b_i, least significant first
+func (pk *PublicKey) CombineBits(bits []*Ciphertext) *Ciphertext {
+	sum := pk.trivialEncryption(ZeroBigInt)
+	for i := len(bits) - 1; i >= 0; i-- {
+		sum = pk.Add(pk.ConstMult(sum, TwoBigInt), bits[i])
+	}
+	return sum
+}
+
+// addConstant returns the level one encryption of m + k mod N without
+// encrypting k; the result has the randomness of ct
+func (pk *PublicKey) addConstant(ct *Ciphertext, k *gmp.Int) *Ciphertext {
+	c := new(gmp.Int).Mul(ct.C, pk.gExp(k, EncLevelOne))
+	return &Ciphertext{c.Mod(c, pk.GetN2()), EncLevelOne, MixedEncryption}
+}
+
+// trivialEncryption returns the level one encryption of m with randomness 1,
+// which must be randomized before it is revealed
+func (pk *PublicKey) trivialEncryption(m *gmp.Int) *Ciphertext {
+	return &Ciphertext{pk.gExp(m, EncLevelOne), EncLevelOne, RegularEncryption}
+}
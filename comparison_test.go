@@ -0,0 +1,139 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func runComparison(t *testing.T, pk *PublicKey, helper *ComparisonHelper, x, y int64, bitLength int) *Ciphertext {
+	comparator, req, err := pk.NewComparator(pk.Encrypt(gmp.NewInt(x)), pk.Encrypt(gmp.NewInt(y)), bitLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bits, err := helper.Decompose(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := comparator.Challenge(bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := helper.Evaluate(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := comparator.Finalize(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comparator.Finalize(resp); err == nil {
+		t.Error("expected an error for a second finalization")
+	}
+	return result
+}
+
+func TestComparison(t *testing.T) {
+	sk, pk := KeyGen(128)
+	helper := NewComparisonHelper(pk, sk)
+
+	for _, c := range []struct{ x, y int64 }{
+		{0, 0}, {1, 0}, {0, 1}, {7, 7}, {8, 7}, {7, 8},
+		{65535, 0}, {0, 65535}, {65535, 65535}, {32768, 32767}, {1000, 42},
+	} {
+		// the random flip makes both branches likely over a few runs
+		for run := 0; run < 4; run++ {
+			expected := int64(0)
+			if c.x >= c.y {
+				expected = 1
+			}
+			if m := sk.Decrypt(runComparison(t, pk, helper, c.x, c.y, 16)); n(m) != int(expected) {
+				t.Errorf("comparison of %d and %d decrypted to %v, expected %d", c.x, c.y, m, expected)
+			}
+		}
+	}
+}
+
+func TestComparisonThreshold(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(128, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tk := tsks[0].PublicOnly()
+	client := NewThresholdClient(tk, []PartialDecrypter{tsks[0], tsks[2]})
+	helper := NewComparisonHelper(&tk.PublicKey, client)
+
+	result := runComparison(t, &tk.PublicKey, helper, 300, 299, 10)
+	if m, err := client.TryDecrypt(result); err != nil || n(m) != 1 {
+		t.Error("comparison of 300 and 299 decrypted to ", m, " expected 1")
+	}
+}
+
+func TestComparisonErrors(t *testing.T) {
+	sk, pk := KeyGen(128)
+	x := pk.Encrypt(gmp.NewInt(1))
+
+	if _, _, err := pk.NewComparator(x, pk.NestedEncrypt(gmp.NewInt(1)), 8); err == nil {
+		t.Error("expected an error for a level two ciphertext")
+	}
+	if _, _, err := pk.NewComparator(x, x, 0); err == nil {
+		t.Error("expected an error for a zero bit length")
+	}
+	if _, _, err := pk.NewComparator(x, x, 100); err == nil {
+		t.Error("expected an error for a bit length too large for the key")
+	}
+
+	comparator, req, err := pk.NewComparator(x, x, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comparator.Finalize(&ComparisonResponse{Zero: pk.EncryptZero()}); err == nil {
+		t.Error("expected an error for a response before the challenge")
+	}
+
+	helper := NewComparisonHelper(pk, sk)
+	bits, err := helper.Decompose(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comparator.Challenge(&ComparisonBits{Bits: bits.Bits[1:], High: bits.High}); err == nil {
+		t.Error("expected an error for a missing bit")
+	}
+	if _, err := comparator.Challenge(bits); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comparator.Challenge(bits); err == nil {
+		t.Error("expected an error for a second challenge")
+	}
+}
+
+func TestEncryptBits(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	value := gmp.NewInt(0xb5)
+	bits, err := pk.EncryptBits(value, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ct := range bits {
+		if m := sk.Decrypt(ct); m.Cmp(gmp.NewInt(int64(value.Bit(i)))) != 0 {
+			t.Errorf("bit %d decrypted to %v, expected %d", i, m, value.Bit(i))
+		}
+	}
+	if m := sk.Decrypt(pk.CombineBits(bits)); m.Cmp(value) != 0 {
+		t.Error("combined bits decrypted to ", m, " expected ", value)
+	}
+
+	if _, err := pk.EncryptBits(gmp.NewInt(-1), 8); err == nil {
+		t.Error("expected an error for a negative value")
+	}
+}
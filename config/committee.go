@@ -0,0 +1,141 @@
+// Package config loads and validates the description of a threshold
+// decryption committee.
+//
+// A committee is described in JSON, e.g.,
+//
+//	{
+//	    "key_fingerprint": "4f0c...",
+//	    "threshold": 2,
+//	    "servers": [
+//	        {"id": 1, "endpoint": "dec1.example.com:7000", "share_fingerprint": "9a1e..."},
+//	        {"id": 2, "endpoint": "dec2.example.com:7000", "share_fingerprint": "03bb..."},
+//	        {"id": 3, "endpoint": "dec3.example.com:7000", "share_fingerprint": "c47d..."}
+//	    ]
+//	}
+//
+// YAML descriptions are not supported since the package has no third party
+// dependencies besides gmp; convert them to JSON first.
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sachaservan/paillier"
+)
+
+// Server describes a single decryption server of the committee
+type Server struct {
+	ID               int    `json:"id"`                // index of the secret share held by the server
+	Endpoint         string `json:"endpoint"`          // network address of the server
+	ShareFingerprint string `json:"share_fingerprint"` // fingerprint of the verification key of the share
+}
+
+// Committee describes a threshold decryption committee
+type Committee struct {
+	KeyFingerprint string   `json:"key_fingerprint"` // fingerprint of the threshold public key
+	Threshold      int      `json:"threshold"`
+	Servers        []Server `json:"servers"`
+}
+
+// Dialer connects to a decryption server of the committee
+type Dialer func(server Server) (paillier.PartialDecrypter, error)
+
+// Load parses a committee description
+func Load(r io.Reader) (*Committee, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	committee := new(Committee)
+	if err := decoder.Decode(committee); err != nil {
+		return nil, err
+	}
+
+	return committee, nil
+}
+
+// LoadFile parses the committee description stored in the file at path
+func LoadFile(path string) (*Committee, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// ShareFingerprint returns the hex encoded SHA-256 digest of the
+// verification key of the share with the given ID
+func ShareFingerprint(key *paillier.ThresholdPublicKey, id int) (string, error) {
+	if id < 1 || id > len(key.VerificationKeys) {
+		return "", errors.New("share ID is out of range")
+	}
+
+	digest := sha256.Sum256(key.VerificationKeys[id-1].Bytes())
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// Validate checks that the committee description matches the threshold
+// public key: the key and share fingerprints, the threshold and that every
+// share is held by exactly one server.
+func (c *Committee) Validate(key *paillier.ThresholdPublicKey) error {
+	if c.KeyFingerprint != key.Fingerprint() {
+		return errors.New("key fingerprint does not match the threshold key")
+	}
+
+	if c.Threshold != key.Threshold {
+		return errors.New("threshold does not match the threshold key")
+	}
+
+	if len(c.Servers) != key.TotalNumberOfDecryptionServers {
+		return errors.New("number of servers does not match the number of decryption servers")
+	}
+
+	seen := make(map[int]bool)
+	for _, server := range c.Servers {
+		if server.Endpoint == "" {
+			return fmt.Errorf("server %d has no endpoint", server.ID)
+		}
+
+		if seen[server.ID] {
+			return fmt.Errorf("share %d is assigned to several servers", server.ID)
+		}
+		seen[server.ID] = true
+
+		fingerprint, err := ShareFingerprint(key, server.ID)
+		if err != nil {
+			return err
+		}
+
+		if server.ShareFingerprint != fingerprint {
+			return fmt.Errorf("share fingerprint of server %d does not match the threshold key", server.ID)
+		}
+	}
+
+	return nil
+}
+
+// NewClient validates the committee description against key and returns a
+// threshold client connected to every server of the committee
+func (c *Committee) NewClient(key *paillier.ThresholdPublicKey, dial Dialer) (*paillier.ThresholdClient, error) {
+	if err := c.Validate(key); err != nil {
+		return nil, err
+	}
+
+	servers := make([]paillier.PartialDecrypter, len(c.Servers))
+	for i, server := range c.Servers {
+		conn, err := dial(server)
+		if err != nil {
+			return nil, err
+		}
+		servers[i] = conn
+	}
+
+	return paillier.NewThresholdClient(key, servers), nil
+}
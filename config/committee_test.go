@@ -0,0 +1,96 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+func getCommittee(t *testing.T) (*Committee, []*paillier.ThresholdSecretKey) {
+	tkh, err := paillier.NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := &tpks[0].ThresholdPublicKey
+	description := fmt.Sprintf(`{"key_fingerprint": %q, "threshold": 2, "servers": [`, key.Fingerprint())
+	for i := 1; i <= 3; i++ {
+		fingerprint, err := ShareFingerprint(key, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 1 {
+			description += ","
+		}
+		description += fmt.Sprintf(`{"id": %d, "endpoint": "server%d:7000", "share_fingerprint": %q}`, i, i, fingerprint)
+	}
+	description += "]}"
+
+	committee, err := Load(strings.NewReader(description))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return committee, tpks
+}
+
+func TestCommitteeNewClient(t *testing.T) {
+	committee, tpks := getCommittee(t)
+	key := &tpks[0].ThresholdPublicKey
+
+	dial := func(server Server) (paillier.PartialDecrypter, error) {
+		return tpks[server.ID-1], nil
+	}
+
+	client, err := committee.NewClient(key, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := client.TryDecrypt(client.Encrypt(gmp.NewInt(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != 100 {
+		t.Error("wrong decryption ", m, " is not 100")
+	}
+}
+
+func TestCommitteeValidate(t *testing.T) {
+	committee, tpks := getCommittee(t)
+	key := &tpks[0].ThresholdPublicKey
+
+	if err := committee.Validate(key); err != nil {
+		t.Fatal(err)
+	}
+
+	committee.Servers[1].ID = 1
+	if err := committee.Validate(key); err == nil {
+		t.Error("expected error for duplicate share")
+	}
+
+	committee.Servers[1].ID = 2
+	committee.Servers[2].ShareFingerprint = committee.Servers[0].ShareFingerprint
+	if err := committee.Validate(key); err == nil {
+		t.Error("expected error for wrong share fingerprint")
+	}
+
+	other, _ := getCommittee(t)
+	if err := other.Validate(key); err == nil {
+		t.Error("expected error for committee of another key")
+	}
+
+	if _, err := Load(strings.NewReader(`{"thresold": 2}`)); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
@@ -0,0 +1,222 @@
+package paillier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// DecryptionTransport sends a decryption request to one decryption server and
+// returns its proven partial decryption, e.g., thresholdrpc.Client for a
+// remote server or LocalTransport for a key share in the same process.
+// Implementations must be safe for concurrent use and should return when ctx
+// is done.
+type DecryptionTransport interface {
+	RequestPartialDecryption(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error)
+}
+
+// DecryptionTransportFunc adapts a function to a DecryptionTransport
+type DecryptionTransportFunc func(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error)
+
+// RequestPartialDecryption calls f
+func (f DecryptionTransportFunc) RequestPartialDecryption(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error) {
+	return f(ctx, c, session)
+}
+
+// LocalTransport returns a transport that partially decrypts with tsk
+func LocalTransport(tsk *ThresholdSecretKey) DecryptionTransport {
+	return DecryptionTransportFunc(func(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return tsk.PartialDecryptionWithSession(c, session)
+	})
+}
+
+// Coordinator runs threshold decryptions with a committee: it requests the
+// partial decryptions of all servers concurrently, verifies every proof,
+// retries servers that fail to respond and combines as soon as Threshold
+// valid shares arrived. A minority of servers that are offline, slow or
+// return invalid shares does not prevent the decryption.
+type Coordinator struct {
+	Key *ThresholdPublicKey
+
+	// Transports reach the decryption servers; a server is identified by its
+	// index in errors
+	Transports []DecryptionTransport
+
+	// RequestTimeout bounds every request to a server; zero means requests
+	// are only bounded by the context of Decrypt
+	RequestTimeout time.Duration
+
+	// Retries is the number of times a failed request to a server is repeated.
+	// Servers that return an invalid share are not retried.
+	Retries int
+
+	// RetryDelay is the delay before the first retry of a server, doubled for
+	// every further retry
+	RetryDelay time.Duration
+}
+
+// NewCoordinator returns a coordinator for the committee of key that tries
+// every server once
+func NewCoordinator(key *ThresholdPublicKey, transports []DecryptionTransport) *Coordinator {
+	return &Coordinator{Key: key, Transports: transports}
+}
+
+// DecryptionError reports why a decryption collected fewer than Threshold
+// valid shares. Errs maps the index of every server that did not contribute
+// to the last error of its requests. errors.Is matches ErrTooFewShares as
+// well as any of the errors, e.g., ErrInvalidProof.
+type DecryptionError struct {
+	Valid     int // number of valid shares
+	Threshold int
+	Errs      map[int]error
+}
+
+func (e *DecryptionError) Error() string {
+	servers := make([]int, 0, len(e.Errs))
+	for i := range e.Errs {
+		servers = append(servers, i)
+	}
+	sort.Ints(servers)
+
+	reasons := make([]string, len(servers))
+	for j, i := range servers {
+		reasons[j] = fmt.Sprintf("server %d: %v", i, e.Errs[i])
+	}
+	return fmt.Sprintf("%v: %d of %d valid; %s", ErrTooFewShares, e.Valid, e.Threshold, strings.Join(reasons, "; "))
+}
+
+func (e *DecryptionError) Unwrap() []error {
+	errs := []error{ErrTooFewShares}
+	for _, err := range e.Errs {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// errPermanent marks failures of a server that are not retried
+type errPermanent struct {
+	err error
+}
+
+func (e *errPermanent) Error() string { return e.err.Error() }
+func (e *errPermanent) Unwrap() error { return e.err }
+
+// Decrypt decrypts the level one ciphertext with the committee. The session
+// is bound into the proofs of the servers and may be nil, see
+// PartialDecryptionWithSession. It returns a *DecryptionError if fewer than
+// Threshold servers returned a valid share, or the error of ctx if it is done
+// first.
+func (co *Coordinator) Decrypt(ctx context.Context, ct *Ciphertext, session []byte) (*gmp.Int, error) {
+	if ct == nil || ct.C == nil || ct.C.Sign() < 0 || ct.C.Cmp(co.Key.GetN2()) >= 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("threshold decryption is only supported for level one ciphertexts")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		server int
+		err    error
+	}
+
+	combiner := co.Key.NewCombiner(ct.C)
+	// buffered so that servers still running after the decryption do not block
+	results := make(chan result, len(co.Transports))
+	for i, transport := range co.Transports {
+		go func(i int, transport DecryptionTransport) {
+			results <- result{server: i, err: co.collect(ctx, transport, combiner, ct.C, session)}
+		}(i, transport)
+	}
+
+	errs := make(map[int]error)
+	for range co.Transports {
+		var r result
+		select {
+		case r = <-results:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if r.err != nil {
+			errs[r.server] = r.err
+			continue
+		}
+		if combiner.Ready() {
+			return combiner.Combine()
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, &DecryptionError{Valid: combiner.Len(), Threshold: co.Key.Threshold, Errs: errs}
+}
+
+// collect requests the share of one server until it is added to the combiner,
+// the server returned an invalid share or the retries are exhausted
+func (co *Coordinator) collect(ctx context.Context, transport DecryptionTransport, combiner *Combiner, c *gmp.Int, session []byte) error {
+	delay := co.RetryDelay
+	var err error
+	for attempt := 0; attempt <= co.Retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+			delay *= 2
+		}
+
+		err = co.request(ctx, transport, combiner, c, session)
+		if err == nil {
+			return nil
+		}
+		var permanent *errPermanent
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func (co *Coordinator) request(ctx context.Context, transport DecryptionTransport, combiner *Combiner, c *gmp.Int, session []byte) error {
+	if co.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, co.RequestTimeout)
+		defer cancel()
+	}
+
+	share, err := transport.RequestPartialDecryption(ctx, c, session)
+	if err != nil {
+		return err
+	}
+	if share == nil {
+		return errors.New("server returned no partial decryption")
+	}
+
+	// a proof for another session could be replayed from an earlier request
+	if !bytes.Equal(share.Session, session) {
+		return &errPermanent{fmt.Errorf("share %d: proof is bound to another session", share.ID)}
+	}
+	if err := combiner.AddZKP(share); err != nil {
+		return &errPermanent{err}
+	}
+	return nil
+}
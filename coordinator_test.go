@@ -0,0 +1,137 @@
+package paillier
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func getCoordinatorKeys(t *testing.T) []*ThresholdSecretKey {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tsks
+}
+
+// fails the first failures requests, then forwards to the key share
+func flakyTransport(tsk *ThresholdSecretKey, failures int32) DecryptionTransport {
+	var calls int32
+	return DecryptionTransportFunc(func(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			return nil, errors.New("connection refused")
+		}
+		return tsk.PartialDecryptionWithSession(c, session)
+	})
+}
+
+// blocks until the request is canceled
+var hangingTransport = DecryptionTransportFunc(func(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+})
+
+// returns a share with a wrong decryption
+func cheatingTransport(tsk *ThresholdSecretKey) DecryptionTransport {
+	return DecryptionTransportFunc(func(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error) {
+		pd, err := tsk.PartialDecryptionWithSession(c, session)
+		if err != nil {
+			return nil, err
+		}
+		pd.Decryption = new(gmp.Int).Add(pd.Decryption, OneBigInt)
+		return pd, nil
+	})
+}
+
+func TestCoordinator(t *testing.T) {
+	tsks := getCoordinatorKeys(t)
+	tk := &tsks[0].ThresholdPublicKey
+	ct := tk.Encrypt(gmp.NewInt(1234))
+
+	// a minority of servers hangs or cheats, one recovers on retry
+	coordinator := NewCoordinator(tk, []DecryptionTransport{
+		LocalTransport(tsks[0]),
+		hangingTransport,
+		cheatingTransport(tsks[2]),
+		flakyTransport(tsks[3], 2),
+		LocalTransport(tsks[4]),
+	})
+	coordinator.RequestTimeout = 50 * time.Millisecond
+	coordinator.Retries = 2
+	coordinator.RetryDelay = time.Millisecond
+
+	m, err := coordinator.Decrypt(context.Background(), ct, []byte("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 1234 {
+		t.Error("decrypted ", m, " expected 1234")
+	}
+}
+
+func TestCoordinatorTooFewShares(t *testing.T) {
+	tsks := getCoordinatorKeys(t)
+	tk := &tsks[0].ThresholdPublicKey
+	ct := tk.Encrypt(gmp.NewInt(7))
+
+	coordinator := NewCoordinator(tk, []DecryptionTransport{
+		LocalTransport(tsks[0]),
+		cheatingTransport(tsks[1]),
+		flakyTransport(tsks[2], 1),
+		LocalTransport(tsks[3]),
+	})
+
+	_, err := coordinator.Decrypt(context.Background(), ct, nil)
+	var decryptionErr *DecryptionError
+	if !errors.As(err, &decryptionErr) {
+		t.Fatal("expected a DecryptionError, got ", err)
+	}
+	if !errors.Is(err, ErrTooFewShares) || !errors.Is(err, ErrInvalidProof) {
+		t.Error("expected ErrTooFewShares and ErrInvalidProof, got ", err)
+	}
+	if decryptionErr.Valid != 2 || len(decryptionErr.Errs) != 2 || decryptionErr.Errs[1] == nil || decryptionErr.Errs[2] == nil {
+		t.Errorf("unexpected report %+v", decryptionErr)
+	}
+}
+
+func TestCoordinatorSession(t *testing.T) {
+	tsks := getCoordinatorKeys(t)
+	tk := &tsks[0].ThresholdPublicKey
+	ct := tk.Encrypt(gmp.NewInt(7))
+
+	// a server replaying proofs of another session is rejected
+	replaying := DecryptionTransportFunc(func(ctx context.Context, c *gmp.Int, session []byte) (*PartialDecryptionZKP, error) {
+		return tsks[0].PartialDecryptionWithSession(c, []byte("old"))
+	})
+	coordinator := NewCoordinator(tk, []DecryptionTransport{replaying, LocalTransport(tsks[1]), LocalTransport(tsks[2])})
+	if _, err := coordinator.Decrypt(context.Background(), ct, []byte("new")); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+}
+
+func TestCoordinatorContext(t *testing.T) {
+	tsks := getCoordinatorKeys(t)
+	tk := &tsks[0].ThresholdPublicKey
+	ct := tk.Encrypt(gmp.NewInt(7))
+
+	coordinator := NewCoordinator(tk, []DecryptionTransport{LocalTransport(tsks[0]), hangingTransport, hangingTransport})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := coordinator.Decrypt(ctx, ct, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected context.DeadlineExceeded, got ", err)
+	}
+
+	if _, err := coordinator.Decrypt(context.Background(), &Ciphertext{C: tk.GetN2()}, nil); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext, got ", err)
+	}
+}
@@ -0,0 +1,143 @@
+package paillier
+
+import (
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// CRTPacker packs several values into a single plaintext using the Chinese
+// Remainder Theorem over pairwise coprime slot moduli m_1, ..., m_k.
+// The packed plaintext x in [0, M) with M = m_1 * ... * m_k satisfies
+// x = v_i mod m_i for the value v_i of slot i.
+//
+// Adding packed ciphertexts adds the slots modulo their slot moduli, and
+// multiplying by a packed vector of constants multiplies the slots
+// element-wise. Unlike bit-shift packing, a slot that exceeds its modulus
+// does not overflow into its neighbours and the slots may have different
+// ranges. The plaintext must however not wrap around modulo N, which limits
+// the number of additions to Headroom().
+type CRTPacker struct {
+	Key    *PublicKey
+	Moduli []*gmp.Int
+
+	modulus *gmp.Int   // M = product of the moduli
+	basis   []*gmp.Int // basis[i] = 1 mod m_i and 0 mod m_j for j != i
+}
+
+// NewCRTPacker returns a packer for the slot moduli which must be pairwise
+// coprime, greater than 1 and have a product smaller than N
+func (pk *PublicKey) NewCRTPacker(moduli []*gmp.Int) (*CRTPacker, error) {
+	if len(moduli) == 0 {
+		return nil, errors.New("at least one slot modulus is required")
+	}
+
+	modulus := gmp.NewInt(1)
+	for i, mi := range moduli {
+		if mi.Cmp(OneBigInt) <= 0 {
+			return nil, errors.New("slot moduli must be greater than 1")
+		}
+		for _, mj := range moduli[:i] {
+			if new(gmp.Int).GCD(nil, nil, mi, mj).Cmp(OneBigInt) != 0 {
+				return nil, errors.New("slot moduli must be pairwise coprime")
+			}
+		}
+		modulus.Mul(modulus, mi)
+	}
+
+	if modulus.Cmp(pk.N) >= 0 {
+		return nil, errors.New("product of the slot moduli must be smaller than N")
+	}
+
+	// basis[i] = (M/m_i) * ((M/m_i)^-1 mod m_i)
+	basis := make([]*gmp.Int, len(moduli))
+	for i, mi := range moduli {
+		yi := new(gmp.Int).Div(modulus, mi)
+		zi := new(gmp.Int).ModInverse(yi, mi)
+		basis[i] = new(gmp.Int).Mul(yi, zi)
+	}
+
+	cp := &CRTPacker{
+		Key:     pk,
+		Moduli:  moduli,
+		modulus: modulus,
+		basis:   basis,
+	}
+
+	return cp, nil
+}
+
+// Slots returns the number of slots
+func (cp *CRTPacker) Slots() int {
+	return len(cp.Moduli)
+}
+
+// Headroom returns the number of packed plaintexts that can be added
+// together before the sum wraps around modulo N
+func (cp *CRTPacker) Headroom() *gmp.Int {
+	return new(gmp.Int).Div(minusOne(cp.Key.N), minusOne(cp.modulus))
+}
+
+// Pack returns the plaintext x in [0, M) with x = values[i] mod m_i
+func (cp *CRTPacker) Pack(values []*gmp.Int) (*gmp.Int, error) {
+	if len(values) != len(cp.Moduli) {
+		return nil, errors.New("number of values does not match the number of slots")
+	}
+
+	x := gmp.NewInt(0)
+	for i, v := range values {
+		vi := new(gmp.Int).Mod(v, cp.Moduli[i])
+		x.Add(x, vi.Mul(vi, cp.basis[i]))
+	}
+
+	return x.Mod(x, cp.modulus), nil
+}
+
+// Unpack returns the value of each slot of the plaintext
+func (cp *CRTPacker) Unpack(m *gmp.Int) []*gmp.Int {
+	values := make([]*gmp.Int, len(cp.Moduli))
+	for i := range cp.Moduli {
+		values[i] = cp.Extract(m, i)
+	}
+	return values
+}
+
+// Extract returns the value of the slot of the plaintext
+func (cp *CRTPacker) Extract(m *gmp.Int, slot int) *gmp.Int {
+	return new(gmp.Int).Mod(m, cp.Moduli[slot])
+}
+
+// Encrypt packs and encrypts the values
+func (cp *CRTPacker) Encrypt(values []*gmp.Int) (*Ciphertext, error) {
+	x, err := cp.Pack(values)
+	if err != nil {
+		return nil, err
+	}
+	return cp.Key.Encrypt(x), nil
+}
+
+// Decrypt decrypts and unpacks the ciphertext
+func (cp *CRTPacker) Decrypt(sk *SecretKey, ct *Ciphertext) []*gmp.Int {
+	return cp.Unpack(sk.Decrypt(ct))
+}
+
+// Add homomorphically adds packed ciphertexts slot-wise
+func (cp *CRTPacker) Add(cts ...*Ciphertext) *Ciphertext {
+	return cp.Key.Add(cts...)
+}
+
+// SlotMult homomorphically multiplies each slot of the ciphertext with the
+// corresponding constant. The plaintext of the result is not reduced modulo M
+// and grows up to M^2, so it requires M^2 < N.
+func (cp *CRTPacker) SlotMult(ct *Ciphertext, constants []*gmp.Int) (*Ciphertext, error) {
+	k, err := cp.Pack(constants)
+	if err != nil {
+		return nil, err
+	}
+
+	if new(gmp.Int).Mul(cp.modulus, cp.modulus).Cmp(cp.Key.N) >= 0 {
+		return nil, errors.New("N is too small for slot-wise multiplication")
+	}
+
+	return cp.Key.ConstMult(ct, k), nil
+}
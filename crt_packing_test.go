@@ -0,0 +1,88 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestCRTPacking(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	cp, err := pk.NewCRTPacker([]*gmp.Int{b(101), b(256), b(65537), b(9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := []*gmp.Int{b(100), b(255), b(1), b(4)}
+	ct, err := cp.Encrypt(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range cp.Decrypt(sk, ct) {
+		if v.Cmp(values[i]) != 0 {
+			t.Error("wrong slot ", i, ": ", v, " is not ", values[i])
+		}
+	}
+
+	// slot-wise addition wraps around per slot
+	sum := cp.Add(ct, ct, ct)
+	expected := []int{300 % 101, 765 % 256, 3, 12 % 9}
+	m := sk.Decrypt(sum)
+	for i := range expected {
+		if n(cp.Extract(m, i)) != expected[i] {
+			t.Error("wrong slot ", i, ": ", cp.Extract(m, i), " is not ", expected[i])
+		}
+	}
+
+	if cp.Headroom().Cmp(b(3)) < 0 {
+		t.Error("headroom is too small ", cp.Headroom())
+	}
+}
+
+func TestCRTPackingSlotMult(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	cp, err := pk.NewCRTPacker([]*gmp.Int{b(1009), b(1013), b(1019)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, err := cp.Encrypt([]*gmp.Int{b(10), b(20), b(30)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod, err := cp.SlotMult(ct, []*gmp.Int{b(2), b(3), b(4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{20, 60, 120}
+	for i, v := range cp.Decrypt(sk, prod) {
+		if n(v) != expected[i] {
+			t.Error("wrong slot ", i, ": ", v, " is not ", expected[i])
+		}
+	}
+}
+
+func TestCRTPackerParameters(t *testing.T) {
+	_, pk := KeyGen(64)
+
+	if _, err := pk.NewCRTPacker([]*gmp.Int{b(6), b(9)}); err == nil {
+		t.Error("expected error for moduli that are not coprime")
+	}
+
+	if _, err := pk.NewCRTPacker([]*gmp.Int{pk.N, b(3)}); err == nil {
+		t.Error("expected error for moduli larger than N")
+	}
+
+	cp, err := pk.NewCRTPacker([]*gmp.Int{b(7), b(11)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cp.Pack([]*gmp.Int{b(1)}); err == nil {
+		t.Error("expected error for wrong number of values")
+	}
+}
@@ -1,8 +1,6 @@
 package paillier
 
 import (
-	"crypto/rand"
-
 	gmp "github.com/ncw/gmp"
 )
 
@@ -68,12 +66,12 @@ func (sk *SecretKey) proveDDLEQInstance(ct1, ct2 *Ciphertext, a, b *gmp.Int) (*D
 		panic("cannot prove re-encryption because inputs are wrong")
 	}
 
-	x, err := GetRandomNumberInMultiplicativeGroup(n, rand.Reader)
+	x, err := GetRandomNumberInMultiplicativeGroup(n, sk.RandomSource())
 	if err != nil {
 		return nil, err
 	}
 
-	y, err := GetRandomNumberInMultiplicativeGroup(n, rand.Reader)
+	y, err := GetRandomNumberInMultiplicativeGroup(n, sk.RandomSource())
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +98,7 @@ func (sk *SecretKey) proveDDLEQInstance(ct1, ct2 *Ciphertext, a, b *gmp.Int) (*D
 
 	f := new(gmp.Int).Set(y)
 	if chalBit {
-		s := sk.ExtractRandonness(ct1)
+		s := sk.ExtractRandomness(ct1)
 		an := new(gmp.Int).Exp(a, n, n2)
 		en := new(gmp.Int).Exp(e, n, n2)
 
@@ -0,0 +1,150 @@
+package paillier
+
+import (
+	"sync/atomic"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// crtParams holds the values precomputed from the factorization of N for
+// decryption with the Chinese remainder theorem, see [P99], section 7:
+//
+//	m_p = L_p(c^(p-1) mod p^2) * h_p mod p
+//	m_q = L_q(c^(q-1) mod q^2) * h_q mod q
+//	m   = m_q + q * ((m_p - m_q) * q^-1 mod p)
+//
+// where L_p(x) = (x-1)/p and h_p = L_p(g^(p-1) mod p^2)^-1 mod p. The two
+// exponentiations have half-size exponents and moduli of a quarter of the
+// size of N^2.
+//
+//	[P99]: Pascal Paillier, (1999)
+//	       Public-Key Cryptosystems Based on Composite Degree Residuosity Classes
+//	       EUROCRYPT '99
+type crtParams struct {
+	p, q        *gmp.Int
+	p2, q2      *gmp.Int // p^2 and q^2
+	pMinus1     *gmp.Int
+	qMinus1     *gmp.Int
+	hp, hq      *gmp.Int
+	qInv        *gmp.Int // q^-1 mod p
+	unavailable bool     // N could not be factored with Lambda
+}
+
+// lazyCRT caches the CRT parameters like lazyInt
+type lazyCRT struct {
+	value atomic.Value
+}
+
+func (l *lazyCRT) get(compute func() *crtParams) *crtParams {
+	if v, ok := l.value.Load().(*crtParams); ok {
+		return v
+	}
+
+	l.value.CompareAndSwap(nil, compute())
+	return l.value.Load().(*crtParams)
+}
+
+// crtParameters returns the CRT parameters of the key or nil if Lambda is not
+// phi(N), e.g., for keys created elsewhere, in which case decryption falls
+// back to a single exponentiation modulo N^2
+func (sk *SecretKey) crtParameters() *crtParams {
+	crt := sk.crt.get(func() *crtParams {
+		p, q := sk.factorWithPhi()
+		if p == nil {
+			return &crtParams{unavailable: true}
+		}
+
+		g := sk.G
+		if g == nil {
+			g = new(gmp.Int).Add(sk.N, OneBigInt)
+		}
+
+		crt := &crtParams{
+			p:       p,
+			q:       q,
+			p2:      new(gmp.Int).Mul(p, p),
+			q2:      new(gmp.Int).Mul(q, q),
+			pMinus1: minusOne(p),
+			qMinus1: minusOne(q),
+			qInv:    new(gmp.Int).ModInverse(q, p),
+		}
+		crt.hp = crtH(g, p, crt.p2, crt.pMinus1)
+		crt.hq = crtH(g, q, crt.q2, crt.qMinus1)
+		if crt.hp == nil || crt.hq == nil {
+			return &crtParams{unavailable: true}
+		}
+		return crt
+	})
+
+	if crt.unavailable {
+		return nil
+	}
+	return crt
+}
+
+// decryptCRT decrypts a level one ciphertext with the CRT parameters
+func (sk *SecretKey) decryptCRT(c *gmp.Int, crt *crtParams) *gmp.Int {
+	// p(p-1) is the order of Z*_{p^2}
+	ep := sk.blindExponent(crt.pMinus1, func() *gmp.Int { return new(gmp.Int).Mul(crt.p, crt.pMinus1) })
+	eq := sk.blindExponent(crt.qMinus1, func() *gmp.Int { return new(gmp.Int).Mul(crt.q, crt.qMinus1) })
+
+	mp := crtHalf(c, crt.p, crt.p2, ep, crt.hp)
+	mq := crtHalf(c, crt.q, crt.q2, eq, crt.hq)
+
+	// m = mq + q * ((mp - mq) * q^-1 mod p)
+	m := new(gmp.Int).Sub(mp, mq)
+	m.Mul(m, crt.qInv)
+	m.Mod(m, crt.p)
+	m.Mul(m, crt.q)
+	return m.Add(m, mq)
+}
+
+// crtHalf returns L_p(c^e mod p^2) * h_p mod p where e is p-1, possibly
+// blinded with a multiple of p(p-1)
+func crtHalf(c, p, p2, e, hp *gmp.Int) *gmp.Int {
+	u := new(gmp.Int).Exp(c, e, p2)
+	m := L(u, p)
+	m.Mul(m, hp)
+	return m.Mod(m, p)
+}
+
+// crtH returns L_p(g^(p-1) mod p^2)^-1 mod p or nil if it does not exist
+func crtH(g, p, p2, pMinus1 *gmp.Int) *gmp.Int {
+	h := L(new(gmp.Int).Exp(g, pMinus1, p2), p)
+	h.Mod(h, p)
+	if h.Sign() == 0 {
+		return nil
+	}
+	return h.ModInverse(h, p)
+}
+
+// factorWithPhi returns the prime factors of N if Lambda is phi(N), which is
+// the case for keys generated by KeyGen, and nil otherwise. The factors are
+// the roots of x^2 - (N - phi + 1)x + N.
+func (sk *SecretKey) factorWithPhi() (*gmp.Int, *gmp.Int) {
+	if sk.Lambda == nil {
+		return nil, nil
+	}
+
+	// sum = p + q
+	sum := new(gmp.Int).Sub(sk.N, sk.Lambda)
+	sum.Add(sum, OneBigInt)
+
+	// discriminant = (p + q)^2 - 4N = (q - p)^2
+	disc := new(gmp.Int).Mul(sum, sum)
+	disc.Sub(disc, new(gmp.Int).Mul(FourBigInt, sk.N))
+	if disc.Sign() <= 0 {
+		return nil, nil
+	}
+	root := new(gmp.Int).Sqrt(disc)
+
+	p := new(gmp.Int).Sub(sum, root)
+	p.Rsh(p, 1)
+	q := new(gmp.Int).Add(sum, root)
+	q.Rsh(q, 1)
+	if p.Cmp(OneBigInt) <= 0 || new(gmp.Int).Mul(p, q).Cmp(sk.N) != 0 {
+		return nil, nil
+	}
+
+	return p, q
+}
@@ -0,0 +1,59 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestDecryptCRT(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	crt := sk.crtParameters()
+	if crt == nil {
+		t.Fatal("CRT parameters are not available for a generated key")
+	}
+	if new(gmp.Int).Mul(crt.p, crt.q).Cmp(pk.N) != 0 {
+		t.Fatal("wrong factorization of N")
+	}
+
+	values := []*gmp.Int{b(0), b(1), b(123456789), minusOne(pk.N)}
+	for _, value := range values {
+		for _, ct := range []*Ciphertext{pk.Encrypt(value), pk.AltEncryptAtLevel(value, EncLevelOne)} {
+			if m := sk.decryptCRT(ct.C, crt); m.Cmp(value) != 0 {
+				t.Error("wrong CRT decryption ", m, " is not ", value)
+			}
+			if m := sk.Decrypt(ct); m.Cmp(value) != 0 {
+				t.Error("wrong decryption ", m, " is not ", value)
+			}
+		}
+	}
+}
+
+func TestDecryptWithoutFactorization(t *testing.T) {
+	sk, pk := KeyGen(128)
+	p, q := sk.factorWithPhi()
+
+	// Lambda = lcm(p-1, q-1) is a valid key which does not reveal the factors
+	other := &SecretKey{PublicKey: *pk, Lambda: lcm(minusOne(p), minusOne(q))}
+	if other.crtParameters() != nil {
+		t.Fatal("factored N with the Carmichael function")
+	}
+
+	ct := pk.Encrypt(b(77))
+	if m := other.Decrypt(ct); n(m) != 77 {
+		t.Error("wrong decryption ", m)
+	}
+}
+
+func BenchmarkDecryptWithoutCRT(b *testing.B) {
+	sk, pk := KeyGen(1024)
+	p, q := sk.factorWithPhi()
+	sk = &SecretKey{PublicKey: *pk, Lambda: lcm(minusOne(p), minusOne(q))}
+	c := pk.Encrypt(gmp.NewInt(12))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sk.Decrypt(c)
+	}
+}
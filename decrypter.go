@@ -0,0 +1,141 @@
+package paillier
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CryptoDecrypter adapts a SecretKey to the crypto.Decrypter interface
+// of the standard library. Ciphertexts are expected in the byte encoding
+// produced by Ciphertext.Bytes and plaintexts are returned as big-endian bytes.
+type CryptoDecrypter struct {
+	Key *SecretKey
+}
+
+// DecryptOpts are the options of CryptoDecrypter.Decrypt
+type DecryptOpts struct {
+	// PlaintextLength left-pads the plaintext with zeros to this many bytes,
+	// e.g., to recover a fixed-length key; longer plaintexts are rejected.
+	// Zero returns the shortest encoding, which is empty for zero.
+	PlaintextLength int
+}
+
+// ThresholdCryptoDecrypter adapts a ThresholdSecretKey to the
+// crypto.Decrypter interface of the standard library, e.g., to keep the key
+// share behind the same key-management plumbing as other keys. Decrypt
+// returns the encoding of a partial decryption with its proof, see
+// PartialDecryptionZKP.Encode, instead of a plaintext; the combiner decodes it
+// with ThresholdPublicKey.DecodePartialDecryptionZKP.
+type ThresholdCryptoDecrypter struct {
+	Key *ThresholdSecretKey
+}
+
+// PartialDecryptOpts are the options of ThresholdCryptoDecrypter.Decrypt
+type PartialDecryptOpts struct {
+	// Session is bound into the proof, see PartialDecryptionWithSession
+	Session []byte
+}
+
+var (
+	_ crypto.Decrypter = (*CryptoDecrypter)(nil)
+	_ crypto.Decrypter = (*ThresholdCryptoDecrypter)(nil)
+)
+
+// Public returns the *PublicKey of the secret key, as the Public method of
+// the private keys of the standard library does
+func (sk *SecretKey) Public() crypto.PublicKey {
+	return &sk.PublicKey
+}
+
+// Public returns the *ThresholdPublicKey of the key share
+func (tsk *ThresholdSecretKey) Public() crypto.PublicKey {
+	return &tsk.ThresholdPublicKey
+}
+
+// CryptoDecrypter returns the crypto.Decrypter for the secret key
+func (sk *SecretKey) CryptoDecrypter() *CryptoDecrypter {
+	return &CryptoDecrypter{Key: sk}
+}
+
+// Public returns the *PublicKey corresponding to the secret key
+func (d *CryptoDecrypter) Public() crypto.PublicKey {
+	return &d.Key.PublicKey
+}
+
+// Decrypt decrypts the byte encoded ciphertext.
+// The randomness source is not used by Paillier decryption and may be nil.
+// The options may be nil or a *DecryptOpts.
+func (d *CryptoDecrypter) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	var length int
+	switch opts := opts.(type) {
+	case nil:
+	case *DecryptOpts:
+		length = opts.PlaintextLength
+	default:
+		return nil, fmt.Errorf("invalid options of type %T for Paillier decryption", opts)
+	}
+
+	ct, err := d.Key.NewCiphertextFromBytes(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := d.Key.TryDecrypt(ct)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := m.Bytes()
+	if length == 0 {
+		return plaintext, nil
+	}
+	if len(plaintext) > length {
+		return nil, fmt.Errorf("plaintext is longer than %d bytes", length)
+	}
+	return append(make([]byte, length-len(plaintext)), plaintext...), nil
+}
+
+// CryptoDecrypter returns the crypto.Decrypter for the key share
+func (tsk *ThresholdSecretKey) CryptoDecrypter() *ThresholdCryptoDecrypter {
+	return &ThresholdCryptoDecrypter{Key: tsk}
+}
+
+// Public returns the *ThresholdPublicKey of the key share
+func (d *ThresholdCryptoDecrypter) Public() crypto.PublicKey {
+	return &d.Key.ThresholdPublicKey
+}
+
+// Decrypt partially decrypts the byte encoded level one ciphertext and
+// returns the encoding of the partial decryption and its proof. The
+// randomness of the proof is read from the random source of the key, not
+// from rand, which may be nil. The options may be nil or a
+// *PartialDecryptOpts.
+func (d *ThresholdCryptoDecrypter) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	var session []byte
+	switch opts := opts.(type) {
+	case nil:
+	case *PartialDecryptOpts:
+		session = opts.Session
+	default:
+		return nil, fmt.Errorf("invalid options of type %T for partial decryption", opts)
+	}
+
+	ct, err := d.Key.NewCiphertextFromBytes(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("partial decryptions with proofs require a level one ciphertext")
+	}
+	if err := d.Key.checkCiphertext(ct); err != nil {
+		return nil, err
+	}
+
+	pd, err := d.Key.PartialDecryptionWithSession(ct.C, session)
+	if err != nil {
+		return nil, err
+	}
+	return pd.Encode()
+}
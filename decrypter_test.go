@@ -0,0 +1,122 @@
+package paillier
+
+import (
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestCryptoDecrypter(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	var decrypter crypto.Decrypter = sk.CryptoDecrypter()
+
+	if pub, ok := decrypter.Public().(*PublicKey); !ok || pub.N.Cmp(pk.N) != 0 {
+		t.Error("public key does not match the secret key")
+	}
+
+	for i := 0; i < 100; i++ {
+		ct := pk.Encrypt(gmp.NewInt(int64(i)))
+		plaintext, err := decrypter.Decrypt(nil, ct.Bytes(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := new(gmp.Int).SetBytes(plaintext)
+		if !reflect.DeepEqual(ToBigInt(m), ToBigInt(gmp.NewInt(int64(i)))) {
+			t.Error("wrong decryption ", m, " is not ", i)
+		}
+	}
+
+	if _, err := decrypter.Decrypt(nil, []byte{}, nil); err == nil {
+		t.Error("expected error for empty ciphertext")
+	}
+
+	ct := &Ciphertext{C: pk.GetN2(), Level: EncLevelOne}
+	if _, err := decrypter.Decrypt(nil, ct.Bytes(), nil); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext, got ", err)
+	}
+}
+
+func TestCryptoDecrypterPlaintextLength(t *testing.T) {
+	sk, pk := KeyGen(64)
+	decrypter := sk.CryptoDecrypter()
+
+	if pub, ok := sk.Public().(*PublicKey); !ok || pub.N.Cmp(pk.N) != 0 {
+		t.Error("public key does not match the secret key")
+	}
+
+	ct := pk.Encrypt(gmp.NewInt(0x0102))
+	plaintext, err := decrypter.Decrypt(nil, ct.Bytes(), &DecryptOpts{PlaintextLength: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plaintext, []byte{0, 0, 1, 2}) {
+		t.Error("wrong padded plaintext ", plaintext)
+	}
+
+	if _, err := decrypter.Decrypt(nil, ct.Bytes(), &DecryptOpts{PlaintextLength: 1}); err == nil {
+		t.Error("expected error for plaintext longer than the length")
+	}
+
+	if _, err := decrypter.Decrypt(nil, ct.Bytes(), crypto.SHA256); err == nil {
+		t.Error("expected error for invalid options")
+	}
+}
+
+func TestThresholdCryptoDecrypter(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	ct := tk.Encrypt(b(42))
+	opts := &PartialDecryptOpts{Session: []byte("session")}
+	var pds []*PartialDecryptionZKP
+	for _, tsk := range tsks[:3] {
+		var decrypter crypto.Decrypter = tsk.CryptoDecrypter()
+		if pub, ok := decrypter.Public().(*ThresholdPublicKey); !ok || pub.N.Cmp(tk.N) != 0 {
+			t.Error("public key does not match the key share")
+		}
+
+		data, err := decrypter.Decrypt(nil, ct.Bytes(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pd, err := tk.DecodePartialDecryptionZKP(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pds = append(pds, pd)
+	}
+
+	m, err := tk.CombinePartialDecryptionsZKP(pds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 42 {
+		t.Error("wrong decryption ", m)
+	}
+
+	decrypter := tsks[0].CryptoDecrypter()
+	if _, err := decrypter.Decrypt(nil, ct.Bytes(), &DecryptOpts{}); err == nil {
+		t.Error("expected error for invalid options")
+	}
+	if _, err := decrypter.Decrypt(nil, tk.EncryptAtLevel(b(1), EncLevelTwo).Bytes(), nil); err == nil {
+		t.Error("expected error for level two ciphertext")
+	}
+	outOfRange := &Ciphertext{C: tk.GetN2(), Level: EncLevelOne}
+	if _, err := decrypter.Decrypt(nil, outOfRange.Bytes(), nil); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext, got ", err)
+	}
+}
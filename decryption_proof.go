@@ -0,0 +1,54 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// DecryptWithProof decrypts ct and proves that the plaintext is its correct
+// decryption, so that a decryption service can be audited by anyone holding
+// the public key. The proof is a PlaintextEqualityProof that ct encrypts the
+// same plaintext as g^m, i.e., that ct / g^m is an N^s-th residue, whose
+// root the key holder extracts with ExtractRandomness.
+func (sk *SecretKey) DecryptWithProof(ct *Ciphertext) (*gmp.Int, *PlaintextEqualityProof, error) {
+	m := sk.Decrypt(ct)
+	u := sk.ExtractRandomness(ct)
+	if u.Sign() == 0 {
+		return nil, nil, errors.New("ciphertext is not a unit")
+	}
+
+	proof, err := sk.ProvePlaintextEquality(sk.plaintextCiphertext(m, ct.Level), ct, u)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, proof, nil
+}
+
+// VerifyDecryptionProof returns true iff the proof shows that m is the
+// decryption of ct
+func (pk *PublicKey) VerifyDecryptionProof(ct *Ciphertext, m *gmp.Int, proof *PlaintextEqualityProof) bool {
+	return pk.VerifyDecryptionProofErr(ct, m, proof) == nil
+}
+
+// VerifyDecryptionProofErr verifies the proof as VerifyDecryptionProof and
+// returns an error wrapping ErrMalformedProof or ErrProofPart1 if it is
+// rejected
+func (pk *PublicKey) VerifyDecryptionProofErr(ct *Ciphertext, m *gmp.Int, proof *PlaintextEqualityProof) error {
+	if ct == nil || ct.C == nil || m == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	_, ns, _ := pk.getModuliForLevel(ct.Level)
+	if m.Sign() < 0 || m.Cmp(ns) >= 0 {
+		return fmt.Errorf("%w: plaintext is out of range", ErrMalformedProof)
+	}
+
+	return pk.VerifyPlaintextEqualityProofErr(pk.plaintextCiphertext(m, ct.Level), ct, proof)
+}
+
+// returns the encryption g^m of m with randomness 1
+func (pk *PublicKey) plaintextCiphertext(m *gmp.Int, level EncryptionLevel) *Ciphertext {
+	return &Ciphertext{C: pk.gExp(m, level), Level: level, EncMethod: RegularEncryption}
+}
@@ -0,0 +1,46 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestDecryptWithProof(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+		ct := pk.EncryptAtLevel(gmp.NewInt(1234), level)
+		m, proof, err := sk.DecryptWithProof(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Cmp(gmp.NewInt(1234)) != 0 {
+			t.Error("wrong plaintext")
+		}
+		if err := pk.VerifyDecryptionProofErr(ct, m, proof); err != nil {
+			t.Error("decryption proof is not complete: ", err)
+		}
+	}
+}
+
+func TestDecryptWithProofSoundness(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	ct := pk.Encrypt(gmp.NewInt(5))
+	m, proof, err := sk.DecryptWithProof(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pk.VerifyDecryptionProofErr(ct, gmp.NewInt(6), proof); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1 for a wrong plaintext, got ", err)
+	}
+	if pk.VerifyDecryptionProof(pk.Encrypt(gmp.NewInt(5)), m, proof) {
+		t.Error("decryption proof verifies for another ciphertext")
+	}
+	if err := pk.VerifyDecryptionProofErr(ct, pk.N, proof); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error for a plaintext out of range, got ", err)
+	}
+}
@@ -0,0 +1,198 @@
+package paillier
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// DecryptionTranscript records a threshold decryption so that auditors who
+// were not online during the protocol can verify after the fact that the
+// published plaintext is the decryption of the ciphertext, see
+// VerifyDecryptionTranscript. The proofs reference the key by its
+// fingerprint; the auditor supplies the key.
+type DecryptionTranscript struct {
+	KeyFingerprint     string
+	Ciphertext         *Ciphertext
+	Session            []byte // session bound into every proof, may be nil
+	Participants       []int  // IDs of the servers, in the order of the proofs
+	PartialDecryptions []*PartialDecryptionZKP
+	Plaintext          *gmp.Int
+}
+
+// CombineWithTranscript verifies the proven partial decryptions of the level
+// one ciphertext, combines them and returns the transcript of the
+// decryption. All shares must be bound to the same session, which is
+// recorded in the transcript.
+func (tk *ThresholdPublicKey) CombineWithTranscript(ct *Ciphertext, shares []*PartialDecryptionZKP) (*DecryptionTranscript, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("%w: no partial decryptions", ErrTooFewShares)
+	}
+
+	tr := &DecryptionTranscript{
+		KeyFingerprint:     tk.CommitteeFingerprint(),
+		Ciphertext:         ct,
+		PartialDecryptions: shares,
+	}
+	if shares[0] != nil && len(shares[0].Session) > 0 {
+		tr.Session = shares[0].Session
+	}
+	for _, share := range shares {
+		if share != nil {
+			tr.Participants = append(tr.Participants, share.ID)
+		}
+	}
+
+	m, err := tr.combine(tk)
+	if err != nil {
+		return nil, err
+	}
+	tr.Plaintext = m
+	return tr, nil
+}
+
+// VerifyDecryptionTranscript re-checks a published transcript against the
+// threshold public key and returns an error describing the first problem
+// found:
+//
+//   - the transcript is of tk and of a level one ciphertext,
+//   - the participants are the distinct servers of the partial decryptions,
+//   - every partial decryption is of the ciphertext, bound to the session
+//     and carries a valid proof for tk,
+//   - the partial decryptions of at least Threshold servers combine to the
+//     published plaintext.
+func VerifyDecryptionTranscript(tr *DecryptionTranscript, tk *ThresholdPublicKey) error {
+	if tr == nil || tr.Plaintext == nil {
+		return errors.New("transcript is missing the plaintext")
+	}
+	if tr.KeyFingerprint != tk.CommitteeFingerprint() {
+		return fmt.Errorf("%w: transcript is of key %s", ErrKeyMismatch, tr.KeyFingerprint)
+	}
+	if len(tr.Participants) != len(tr.PartialDecryptions) {
+		return errors.New("participants do not match the partial decryptions")
+	}
+
+	m, err := tr.combine(tk)
+	if err != nil {
+		return err
+	}
+	if m.Cmp(tr.Plaintext) != 0 {
+		return fmt.Errorf("published plaintext %v does not match the decryption %v", tr.Plaintext, m)
+	}
+	return nil
+}
+
+// combine verifies the partial decryptions of the transcript for tk and
+// returns the plaintext they combine to
+func (tr *DecryptionTranscript) combine(tk *ThresholdPublicKey) (*gmp.Int, error) {
+	ct := tr.Ciphertext
+	if ct == nil || ct.C == nil {
+		return nil, ErrInvalidCiphertext
+	}
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("threshold decryption is only supported for level one ciphertexts")
+	}
+	if len(tr.PartialDecryptions) < tk.Threshold {
+		return nil, fmt.Errorf("%w: %d partial decryptions, threshold is %d", ErrTooFewShares, len(tr.PartialDecryptions), tk.Threshold)
+	}
+
+	shares := make([]*PartialDecryption, len(tr.PartialDecryptions))
+	for i, pd := range tr.PartialDecryptions {
+		if pd == nil || pd.C == nil || pd.C.Cmp(ct.C) != 0 {
+			return nil, fmt.Errorf("partial decryption %d is not of the ciphertext", i)
+		}
+		if pd.ID != tr.Participants[i] {
+			return nil, fmt.Errorf("partial decryption %d is of server %d, expected %d", i, pd.ID, tr.Participants[i])
+		}
+		if !bytes.Equal(pd.Session, tr.Session) {
+			return nil, fmt.Errorf("share %d: proof is bound to another session", pd.ID)
+		}
+
+		// verify against the given key rather than the one recorded in the proof
+		proof := *pd
+		proof.Key = tk
+		if err := proof.VerifyErr(); err != nil {
+			return nil, err
+		}
+		shares[i] = &pd.PartialDecryption
+	}
+
+	// rejects duplicate IDs and shares carrying the fingerprint of another key
+	return tk.CombinePartialDecryptions(shares)
+}
+
+type decryptionTranscriptJSON struct {
+	KeyFingerprint     string                  `json:"key_fingerprint"`
+	Ciphertext         string                  `json:"ciphertext"`
+	Level              EncryptionLevel         `json:"level,omitempty"`
+	Session            string                  `json:"session,omitempty"`
+	Participants       []int                   `json:"participants"`
+	PartialDecryptions []*PartialDecryptionZKP `json:"partial_decryptions"`
+	Plaintext          string                  `json:"plaintext"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. The partial
+// decryptions are encoded without their key, which is referenced by the
+// fingerprint of the transcript.
+func (tr *DecryptionTranscript) MarshalJSON() ([]byte, error) {
+	v := &decryptionTranscriptJSON{
+		KeyFingerprint: tr.KeyFingerprint,
+		Session:        base64.RawURLEncoding.EncodeToString(tr.Session),
+		Participants:   tr.Participants,
+		Plaintext:      encodeJSONInt(tr.Plaintext),
+	}
+	if tr.Ciphertext != nil {
+		v.Ciphertext = encodeJSONInt(tr.Ciphertext.C)
+		v.Level = tr.Ciphertext.Level
+	}
+	for _, pd := range tr.PartialDecryptions {
+		if pd != nil {
+			proof := *pd
+			proof.Key = nil
+			pd = &proof
+		}
+		v.PartialDecryptions = append(v.PartialDecryptions, pd)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The transcript
+// is not verified.
+func (tr *DecryptionTranscript) UnmarshalJSON(data []byte) error {
+	var v decryptionTranscriptJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	ints, err := decodeJSONInts(v.Ciphertext, v.Plaintext)
+	if err != nil {
+		return err
+	}
+	if ints[0] == nil {
+		return errors.New("transcript is missing the ciphertext")
+	}
+	if v.Level < EncLevelOne || v.Level > MaxEncryptionLevel {
+		return errors.New("unsupported encryption level")
+	}
+
+	var session []byte
+	if v.Session != "" {
+		if session, err = base64.RawURLEncoding.DecodeString(v.Session); err != nil {
+			return err
+		}
+	}
+
+	*tr = DecryptionTranscript{
+		KeyFingerprint:     v.KeyFingerprint,
+		Ciphertext:         &Ciphertext{C: ints[0], Level: v.Level},
+		Session:            session,
+		Participants:       v.Participants,
+		PartialDecryptions: v.PartialDecryptions,
+		Plaintext:          ints[1],
+	}
+	return nil
+}
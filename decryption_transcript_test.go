@@ -0,0 +1,76 @@
+package paillier
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestDecryptionTranscript(t *testing.T) {
+	tsks := getCoordinatorKeys(t)
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(99))
+
+	shares := make([]*PartialDecryptionZKP, 3)
+	for i, j := range []int{4, 0, 2} {
+		var err error
+		if shares[i], err = tsks[j].PartialDecryptionWithSession(ct.C, []byte("request-1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr, err := tk.CombineWithTranscript(ct, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(tr.Plaintext) != 99 {
+		t.Error("decrypted ", tr.Plaintext, " expected 99")
+	}
+
+	// the auditor only sees the exported transcript and the public key
+	data, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var published DecryptionTranscript
+	if err := json.Unmarshal(data, &published); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyDecryptionTranscript(&published, tk); err != nil {
+		t.Fatal(err)
+	}
+
+	published.Plaintext = gmp.NewInt(100)
+	if err := VerifyDecryptionTranscript(&published, tk); err == nil {
+		t.Error("expected an error for a wrong plaintext")
+	}
+	published.Plaintext = gmp.NewInt(99)
+
+	published.Session = []byte("request-2")
+	if err := VerifyDecryptionTranscript(&published, tk); err == nil {
+		t.Error("expected an error for another session")
+	}
+	published.Session = []byte("request-1")
+
+	published.Participants = []int{5, 1, 2}
+	if err := VerifyDecryptionTranscript(&published, tk); err == nil {
+		t.Error("expected an error for wrong participants")
+	}
+	published.Participants = tr.Participants
+
+	published.PartialDecryptions[1].Decryption = new(gmp.Int).Add(published.PartialDecryptions[1].Decryption, OneBigInt)
+	if err := VerifyDecryptionTranscript(&published, tk); !errors.Is(err, ErrInvalidProof) {
+		t.Error("expected ErrInvalidProof, got ", err)
+	}
+
+	others := getCoordinatorKeys(t)
+	if err := VerifyDecryptionTranscript(tr, others[0].PublicOnly()); !errors.Is(err, ErrKeyMismatch) {
+		t.Error("expected ErrKeyMismatch, got ", err)
+	}
+
+	if _, err := tk.CombineWithTranscript(ct, shares[:2]); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+}
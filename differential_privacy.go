@@ -0,0 +1,155 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// SampleBernoulli returns true with probability p in [0, 1]
+func SampleBernoulli(p *big.Rat, random io.Reader) (bool, error) {
+	if p.Sign() < 0 || p.Cmp(big.NewRat(1, 1)) > 0 {
+		return false, errors.New("probability must be in [0, 1]")
+	}
+
+	u, err := rand.Int(random, p.Denom())
+	if err != nil {
+		return false, err
+	}
+	return u.Cmp(p.Num()) < 0, nil
+}
+
+// SampleBernoulliExp returns true with probability exp(-gamma) for gamma >= 0.
+// The sampler is exact and only uses rational arithmetic, see [CKS 20],
+// algorithm 1.
+//
+//	[CKS 20]: Clement Canonne, Gautam Kamath, Thomas Steinke, (2020)
+//	          The Discrete Gaussian for Differential Privacy
+func SampleBernoulliExp(gamma *big.Rat, random io.Reader) (bool, error) {
+	if gamma.Sign() < 0 {
+		return false, errors.New("gamma must be non-negative")
+	}
+
+	one := big.NewRat(1, 1)
+
+	// exp(-gamma) = exp(-1)^floor(gamma) * exp(-(gamma - floor(gamma)))
+	if gamma.Cmp(one) > 0 {
+		floor := new(big.Int).Quo(gamma.Num(), gamma.Denom())
+		for i := int64(0); i < floor.Int64(); i++ {
+			b, err := SampleBernoulliExp(one, random)
+			if err != nil || !b {
+				return false, err
+			}
+		}
+		gamma = new(big.Rat).Sub(gamma, new(big.Rat).SetInt(floor))
+	}
+
+	// gamma in [0, 1]: sample A_k ~ Bernoulli(gamma/k) until the first failure,
+	// then return true iff the number of trials is odd
+	k := int64(1)
+	for {
+		b, err := SampleBernoulli(new(big.Rat).Quo(gamma, big.NewRat(k, 1)), random)
+		if err != nil {
+			return false, err
+		}
+		if !b {
+			break
+		}
+		k++
+	}
+
+	return k%2 == 1, nil
+}
+
+// SampleDiscreteLaplace returns an integer X with Pr[X = x] proportional to
+// exp(-|x|/scale), also known as the two-sided geometric distribution.
+// The sampler is exact, see [CKS 20], algorithm 2.
+func SampleDiscreteLaplace(scale *big.Rat, random io.Reader) (*big.Int, error) {
+	if scale.Sign() <= 0 {
+		return nil, errors.New("scale must be positive")
+	}
+
+	// scale = t/s
+	t := scale.Num()
+	s := scale.Denom()
+	one := big.NewRat(1, 1)
+
+	for {
+		u, err := rand.Int(random, t)
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := SampleBernoulliExp(new(big.Rat).SetFrac(u, t), random)
+		if err != nil {
+			return nil, err
+		}
+		if !d {
+			continue
+		}
+
+		v := new(big.Int)
+		for {
+			a, err := SampleBernoulliExp(one, random)
+			if err != nil {
+				return nil, err
+			}
+			if !a {
+				break
+			}
+			v.Add(v, big.NewInt(1))
+		}
+
+		// y = floor((u + t*v) / s)
+		x := new(big.Int).Add(u, new(big.Int).Mul(t, v))
+		y := new(big.Int).Quo(x, s)
+
+		negative, err := SampleBernoulli(big.NewRat(1, 2), random)
+		if err != nil {
+			return nil, err
+		}
+		if negative && y.Sign() == 0 {
+			continue
+		}
+		if negative {
+			y.Neg(y)
+		}
+
+		return y, nil
+	}
+}
+
+// AddDiscreteLaplaceNoise homomorphically adds discrete Laplace noise with
+// scale sensitivity/epsilon to the encrypted aggregate, such that releasing
+// the decryption is epsilon-differentially private for aggregates whose value
+// changes by at most `sensitivity` when a single contribution changes.
+//
+// The noise can be added by the aggregator or by each contributor to its own
+// contribution. In the latter case each contributor adds the full noise, so
+// that the released aggregate is private as long as at least one contributor
+// is honest, at the cost of a larger total error.
+//
+// The decrypted aggregate must be decoded with DecodeSigned since the noise
+// may be negative.
+func (pk *PublicKey) AddDiscreteLaplaceNoise(ct *Ciphertext, epsilon *big.Rat, sensitivity int64, random io.Reader) (*Ciphertext, error) {
+	if epsilon.Sign() <= 0 {
+		return nil, errors.New("epsilon must be positive")
+	}
+
+	if sensitivity <= 0 {
+		return nil, errors.New("sensitivity must be positive")
+	}
+
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("noise can only be added to level one ciphertexts")
+	}
+
+	scale := new(big.Rat).Quo(big.NewRat(sensitivity, 1), epsilon)
+	noise, err := SampleDiscreteLaplace(scale, random)
+	if err != nil {
+		return nil, err
+	}
+
+	return pk.Add(ct, pk.Encrypt(pk.EncodeSigned(noise))), nil
+}
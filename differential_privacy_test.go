@@ -0,0 +1,91 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestSampleBernoulliExp(t *testing.T) {
+	trials := 20000
+
+	for _, gamma := range []*big.Rat{big.NewRat(0, 1), big.NewRat(1, 2), big.NewRat(3, 2)} {
+		count := 0
+		for i := 0; i < trials; i++ {
+			b, err := SampleBernoulliExp(gamma, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b {
+				count++
+			}
+		}
+
+		g, _ := gamma.Float64()
+		expected := math.Exp(-g)
+		if got := float64(count) / float64(trials); math.Abs(got-expected) > 0.02 {
+			t.Errorf("Bernoulli(exp(-%v)) frequency is %v, expected %v", gamma, got, expected)
+		}
+	}
+}
+
+func TestSampleDiscreteLaplace(t *testing.T) {
+	trials := 20000
+	scale := big.NewRat(2, 1)
+
+	sum := 0.0
+	zeros := 0
+	for i := 0; i < trials; i++ {
+		x, err := SampleDiscreteLaplace(scale, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += float64(x.Int64())
+		if x.Sign() == 0 {
+			zeros++
+		}
+	}
+
+	// Pr[X = 0] = (1 - e^(-1/scale)) / (1 + e^(-1/scale))
+	alpha := math.Exp(-0.5)
+	expected := (1 - alpha) / (1 + alpha)
+	if got := float64(zeros) / float64(trials); math.Abs(got-expected) > 0.02 {
+		t.Errorf("Pr[X = 0] is %v, expected %v", got, expected)
+	}
+
+	if mean := sum / float64(trials); math.Abs(mean) > 0.1 {
+		t.Errorf("mean of the noise is %v, expected 0", mean)
+	}
+}
+
+func TestAddDiscreteLaplaceNoise(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	ct := pk.Encrypt(gmp.NewInt(1000))
+	noisy, err := pk.AddDiscreteLaplaceNoise(ct, big.NewRat(1, 1), 1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := pk.DecodeSigned(sk.Decrypt(noisy)).Int64()
+	if res < 900 || res > 1100 {
+		t.Error("noisy aggregate is too far from the real value ", res)
+	}
+
+	if _, err := pk.AddDiscreteLaplaceNoise(ct, big.NewRat(0, 1), 1, rand.Reader); err == nil {
+		t.Error("expected error for epsilon 0")
+	}
+}
+
+func TestSignedEncoding(t *testing.T) {
+	_, pk := KeyGen(64)
+
+	for _, v := range []int64{0, 1, -1, 12345, -12345} {
+		if res := pk.DecodeSigned(pk.EncodeSigned(big.NewInt(v))); res.Int64() != v {
+			t.Error("wrong signed decoding ", res, " is not ", v)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package paillier
+
+import (
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// DefaultDivisionStatisticalSecurity is the statistical security parameter
+// used to blind the operands of the division protocol
+const DefaultDivisionStatisticalSecurity = 40
+
+// DivisionRequest is sent by the evaluator to the key holder and
+// contains the blinded dividend [t(x + u*d) + s] and divisor [t*d]
+type DivisionRequest struct {
+	Dividend *Ciphertext
+	Divisor  *Ciphertext
+}
+
+// DivisionResponse is returned by the key holder and contains the
+// encrypted quotient of the blinded values
+type DivisionResponse struct {
+	Quotient *Ciphertext
+}
+
+// DivisionEvaluator holds the state of the party that wants to compute
+// [floor(x/d)] from [x] and an encrypted or private divisor d with the
+// help of the key holder (see SecretKey.AssistDivision). Both x and d must be
+// in [0, 2^BitLength) and d must be non-zero.
+//
+// The protocol uses integer (rather than modular) blinding such that no
+// reduction modulo N occurs:
+//  1. the evaluator picks t in [2^(k-1), 2^k), s in [0, t) and u in [0, 2^(BitLength+k))
+//     where k is the statistical security parameter and sends
+//     [t(x + u*d) + s] and [t*d]
+//  2. the key holder decrypts both values and returns
+//     [floor((t(x + u*d) + s) / (t*d))] = [floor(x/d) + u]
+//  3. the evaluator removes the mask u and outputs [floor(x/d)]
+//
+// The equality in step 2 holds since x = q*d + p with 0 <= p < d implies
+// 0 <= t*p + s < t*d. The key holder does not learn the quotient, which is
+// hidden by u, but does learn t*d and approximately (x mod d)/d.
+// The key holder is assumed to follow the protocol.
+type DivisionEvaluator struct {
+	pk *PublicKey
+	u  *gmp.Int
+}
+
+// NewDivisionEvaluator blinds [x] and the encrypted divisor [d] and returns the
+// evaluator state together with the request for the key holder
+func (pk *PublicKey) NewDivisionEvaluator(x, d *Ciphertext, bitLength int) (*DivisionEvaluator, *DivisionRequest, error) {
+	if x.Level != EncLevelOne || d.Level != EncLevelOne {
+		return nil, nil, errors.New("division is only supported for level one ciphertexts")
+	}
+
+	security := DefaultDivisionStatisticalSecurity
+	if bitLength <= 0 || 2*bitLength+2*security+2 >= pk.N.BitLen() {
+		return nil, nil, errors.New("public key is too small for the requested bit length")
+	}
+
+	lower := new(gmp.Int).Lsh(OneBigInt, uint(security-1))
+	t, err := GetRandomNumber(lower, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+	t.Add(t, lower)
+
+	s, err := GetRandomNumber(t, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := GetRandomNumber(new(gmp.Int).Lsh(OneBigInt, uint(bitLength+security)), pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// [t*d] and [t(x + u*d) + s]
+	td := pk.ConstMult(d, t)
+	dividend := pk.Add(
+		pk.ConstMult(x, t),
+		pk.ConstMult(td, u),
+		pk.Encrypt(s),
+	)
+
+	req := &DivisionRequest{
+		Dividend: dividend,
+		Divisor:  pk.Randomize(td),
+	}
+
+	de := &DivisionEvaluator{
+		pk: pk,
+		u:  u,
+	}
+
+	return de, req, nil
+}
+
+// NewPrivateDivisionEvaluator is like NewDivisionEvaluator for a divisor that
+// is known to the evaluator but must be kept private from the key holder
+func (pk *PublicKey) NewPrivateDivisionEvaluator(x *Ciphertext, d *gmp.Int, bitLength int) (*DivisionEvaluator, *DivisionRequest, error) {
+	if d.Sign() <= 0 {
+		return nil, nil, errors.New("divisor must be positive")
+	}
+	return pk.NewDivisionEvaluator(x, pk.Encrypt(d), bitLength)
+}
+
+// AssistDivision is run by the key holder on a request of the evaluator
+func (sk *SecretKey) AssistDivision(req *DivisionRequest) (*DivisionResponse, error) {
+	if req.Dividend.Level != EncLevelOne || req.Divisor.Level != EncLevelOne {
+		return nil, errors.New("division is only supported for level one ciphertexts")
+	}
+
+	dividend := sk.Decrypt(req.Dividend)
+	divisor := sk.Decrypt(req.Divisor)
+
+	if divisor.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+
+	quotient := new(gmp.Int).Div(dividend, divisor)
+	return &DivisionResponse{Quotient: sk.Encrypt(quotient)}, nil
+}
+
+// Finalize removes the blinding from the response of the key holder
+// and returns [floor(x/d)]
+func (de *DivisionEvaluator) Finalize(resp *DivisionResponse) (*Ciphertext, error) {
+	if resp.Quotient == nil || resp.Quotient.Level != EncLevelOne {
+		return nil, errors.New("invalid division response")
+	}
+
+	pk := de.pk
+	return pk.Sub(resp.Quotient, pk.Encrypt(de.u)), nil
+}
@@ -0,0 +1,86 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestDivisionProtocol(t *testing.T) {
+	sk, pk := KeyGen(256)
+
+	values := [][2]int{{0, 1}, {1, 1}, {7, 2}, {100, 7}, {99, 100}, {65535, 3}, {12345, 12345}, {60000, 1}}
+
+	for _, v := range values {
+		x := pk.Encrypt(gmp.NewInt(int64(v[0])))
+		d := pk.Encrypt(gmp.NewInt(int64(v[1])))
+
+		evaluator, req, err := pk.NewDivisionEvaluator(x, d, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := sk.AssistDivision(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		q, err := evaluator.Finalize(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m := sk.Decrypt(q); n(m) != v[0]/v[1] {
+			t.Errorf("wrong division %v / %v = %v", v[0], v[1], m)
+		}
+	}
+}
+
+func TestPrivateDivisionProtocol(t *testing.T) {
+	sk, pk := KeyGen(256)
+
+	for i := 1; i < 50; i++ {
+		x := pk.Encrypt(gmp.NewInt(int64(1000 + i)))
+
+		evaluator, req, err := pk.NewPrivateDivisionEvaluator(x, gmp.NewInt(int64(i)), 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := sk.AssistDivision(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		q, err := evaluator.Finalize(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m := sk.Decrypt(q); n(m) != (1000+i)/i {
+			t.Errorf("wrong division %v / %v = %v", 1000+i, i, m)
+		}
+	}
+}
+
+func TestDivisionProtocolErrors(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	x := pk.Encrypt(gmp.NewInt(10))
+	if _, _, err := pk.NewPrivateDivisionEvaluator(x, gmp.NewInt(0), 16); err == nil {
+		t.Error("expected error for zero divisor")
+	}
+
+	if _, _, err := pk.NewDivisionEvaluator(x, pk.Encrypt(gmp.NewInt(2)), 32); err == nil {
+		t.Error("expected error for a key that is too small")
+	}
+
+	_, req, err := pk.NewDivisionEvaluator(x, pk.Encrypt(gmp.NewInt(0)), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sk.AssistDivision(req); err == nil {
+		t.Error("expected division by zero error")
+	}
+}
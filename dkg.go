@@ -0,0 +1,1103 @@
+package paillier
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+// ErrDKGRestart is returned by the steps of a DKGParticipant when the
+// candidate modulus of the current attempt was rejected. All parties reach
+// the same decision from the public values of the round and continue with
+// Start.
+var ErrDKGRestart = errors.New("candidate modulus rejected, start the next attempt")
+
+// dkgStatisticalSecurity is the number of bits by which masks exceed the
+// values they statistically hide
+const dkgStatisticalSecurity = 40
+
+// dkgRoundsPerAttempt is the number of transport rounds used by Run for one
+// attempt of the key generation
+const dkgRoundsPerAttempt = 6
+
+// DKGConfig holds the public parameters of a distributed key generation,
+// which all parties must agree on
+type DKGConfig struct {
+	PublicKeyBitLength int
+	Parties            int // parties have the IDs 1 to Parties
+	Threshold          int // number of shares needed to decrypt
+
+	// BiprimalityTests is the number of rounds of the biprimality test; a
+	// modulus that is not the product of two primes passes with probability
+	// at most 2^-BiprimalityTests
+	BiprimalityTests int
+
+	// MaxAttempts limits the number of candidate moduli; zero means no limit
+	MaxAttempts int
+
+	// FieldPrime is the prime over which the candidates are shared; it must
+	// be larger than the products computed in the protocol
+	FieldPrime *gmp.Int
+}
+
+// NewDKGConfig returns the parameters for parties to generate a key of at
+// most bits bits that threshold of them can decrypt with. The field prime
+// is derived deterministically so that every party can compute the same
+// config independently.
+func NewDKGConfig(bits, parties, threshold int) (*DKGConfig, error) {
+	config := &DKGConfig{
+		PublicKeyBitLength: bits,
+		Parties:            parties,
+		Threshold:          threshold,
+		BiprimalityTests:   40,
+	}
+	if err := config.checkParties(); err != nil {
+		return nil, err
+	}
+
+	// gamma = phi*R + N*S < 2 * parties * 2^(2 bits + statistical security)
+	fieldBits := 2*bits + dkgStatisticalSecurity + 2*config.partyBits() + 2
+	config.FieldPrime = nextPrime(new(gmp.Int).Lsh(OneBigInt, uint(fieldBits)))
+	return config, nil
+}
+
+func (c *DKGConfig) checkParties() error {
+	if c.Parties < 3 {
+		return errors.New("distributed key generation requires at least three parties")
+	}
+	if c.Threshold < 1 || c.Threshold > c.Parties {
+		return errors.New("threshold must be between one and the number of parties")
+	}
+	if c.PublicKeyBitLength < 64 {
+		return errors.New("public key bit length must be at least 64")
+	}
+	return nil
+}
+
+func (c *DKGConfig) check() error {
+	if err := c.checkParties(); err != nil {
+		return err
+	}
+	if c.BiprimalityTests < 1 {
+		return errors.New("at least one biprimality test is required")
+	}
+	if c.FieldPrime == nil || c.FieldPrime.BitLen() <= 2*c.PublicKeyBitLength+dkgStatisticalSecurity+2*c.partyBits() {
+		return errors.New("field prime is too small for the public key bit length")
+	}
+	return nil
+}
+
+// returns ceil(log2(Parties)), the number of bits a sum over all parties
+// may exceed its summands by
+func (c *DKGConfig) partyBits() int {
+	return bits.Len(uint(c.Parties - 1))
+}
+
+// returns the minimum number of qualified parties: Threshold of them must
+// hold shares of the key, and the sharings over the field need at least
+// three parties to hide anything
+func (c *DKGConfig) minQualified() int {
+	if c.Threshold > 3 {
+		return c.Threshold
+	}
+	return 3
+}
+
+// returns the bit length of the candidate summands, which is chosen such
+// that N = (sum p_i)(sum q_i) has at most PublicKeyBitLength bits
+func (c *DKGConfig) candidateBits() int {
+	return c.PublicKeyBitLength/2 - c.partyBits()
+}
+
+// DKGState is the step a DKGParticipant waits for
+type DKGState int
+
+// The states of a DKGParticipant in the order of the protocol
+const (
+	DKGStateStart       DKGState = iota // waiting for Start
+	DKGStateCandidate                   // waiting for the candidate shares
+	DKGStateModulus                     // waiting for the modulus shares
+	DKGStateBiprimality                 // waiting for the biprimality shares
+	DKGStateInverse                     // waiting for the inverse shares
+	DKGStateGamma                       // waiting for the gamma shares
+	DKGStateKey                         // waiting for the key shares
+	DKGStateDone                        // the key is available
+)
+
+// DKGCandidateShare is sent privately from party From to party To in the
+// first round and holds To's shares of From's summands of the candidate
+// primes p and q and of a random polynomial with a zero constant term,
+// together with the parties From considers qualified for the attempt
+type DKGCandidateShare struct {
+	From, To, Attempt int
+	P, Q, Zero        *gmp.Int
+	Qualified         []int
+}
+
+// DKGModulusShare is broadcast in the second round and holds the sender's
+// share of N = pq
+type DKGModulusShare struct {
+	From, Attempt int
+	N             *gmp.Int
+}
+
+// DKGBiprimalityShare is broadcast in the third round and holds the
+// sender's values of the biprimality tests of N
+type DKGBiprimalityShare struct {
+	From, Attempt int
+	Values        []*gmp.Int
+}
+
+// DKGInverseShare is sent privately in the fourth round and holds To's
+// shares of From's summands of the masks R and S and of a random polynomial
+// with a zero constant term
+type DKGInverseShare struct {
+	From, To, Attempt int
+	R, S, Zero        *gmp.Int
+}
+
+// DKGGammaShare is broadcast in the fifth round and holds the sender's
+// share of gamma = phi*R + N*S
+type DKGGammaShare struct {
+	From, Attempt int
+	Gamma         *gmp.Int
+}
+
+// DKGKeyShare is sent privately in the last round and holds the evaluation
+// at To of From's polynomial sharing its summand of the decryption exponent,
+// together with the commitments to the coefficients of the polynomial
+type DKGKeyShare struct {
+	From, To, Attempt int
+	Share             *gmp.Int
+	Commitments       []*gmp.Int
+}
+
+// DKGParticipant is the state machine of one party of the distributed
+// generation of a threshold Paillier key without a trusted dealer, which
+// follows the shared RSA modulus generation of [BF 97] and computes the
+// decryption exponent as in [HMRT 12]:
+//  1. every party i picks summands p_i, q_i of the primes p, q and shares
+//     them with polynomials over the field
+//  2. the parties compute N = pq with one multiplication of the sharings and
+//     reject it if it has a small factor
+//  3. the parties run the biprimality test on N and reject it if it fails
+//  4. every party i picks summands r_i, s_i of the masks R, S and shares them
+//  5. the parties compute gamma = phi*R + N*S, where phi = N - p - q + 1
+//  6. the exponent d = (gamma^-1 mod N) * phi * R, which is 0 mod phi and 1 mod
+//     N, is the sum of the summands (gamma^-1 mod N) * (gamma [i = 1] - N s_i),
+//     which the parties share over the integers with Feldman commitments
+//
+// The steps return the messages for the next round; messages with a To
+// field must be sent over confidential channels, e.g., with SecureTransport.
+//
+// Every attempt is run by the qualified parties, initially all of them, and
+// the qualified party with the smallest ID takes the special role of party 1
+// above. A qualified party whose message is missing in a round is
+// disqualified and the attempt is restarted with the remaining parties, as
+// long as at least Threshold and at least three of them remain; otherwise
+// the steps return an error wrapping ErrNotEnoughQualified. Disqualified
+// parties receive no share, but keep their IDs, so that the key still has
+// Parties decryption servers of which any Threshold qualified ones decrypt.
+// The parties exchange their qualified sets with the candidate shares and
+// all of them disqualify the parties missing from any set, so a party that
+// only some parties heard from is dropped at the next attempt.
+//
+// The protocol is secure against honest but curious parties controlling
+// fewer than half of the qualified parties.
+// The primes are not safe primes, so the proofs of partial decryptions only
+// show that the verification keys were used. A random candidate is a
+// biprime with probability about (PublicKeyBitLength ln 2 / 4)^-2, so many
+// attempts are needed for realistic key sizes.
+//
+//	[BF 97]:   Dan Boneh, Matthew Franklin, (1997)
+//	           Efficient Generation of Shared RSA Keys
+//	[HMRT 12]: Carmit Hazay, Gert Laessoe Mikkelsen, Tal Rabin, Tomas Toft, (2012)
+//	           Efficient RSA Key Generation and Threshold Paillier in the
+//	           Two-Party Setting
+type DKGParticipant struct {
+	Config *DKGConfig
+	ID     int
+
+	random    io.Reader
+	state     DKGState
+	attempt   int
+	qualified *QualifiedSet
+	members   []int // qualified parties of the current attempt
+	leader    int   // smallest member, which adds the public terms
+
+	p, q           *gmp.Int // own summands of the candidate primes
+	s              *gmp.Int // own summand of the mask S
+	pShare, qShare *gmp.Int // shares of p and q
+	n              *gmp.Int
+	v              *gmp.Int // generator of the verification keys
+	key            *ThresholdSecretKey
+}
+
+// NewDKGParticipant returns the state machine of party id, which draws its
+// randomness from random
+func NewDKGParticipant(config *DKGConfig, id int, random io.Reader) (*DKGParticipant, error) {
+	if err := config.check(); err != nil {
+		return nil, err
+	}
+	if id < 1 || id > config.Parties {
+		return nil, errors.New("party ID is out of range")
+	}
+	parties := make([]int, config.Parties)
+	for i := range parties {
+		parties[i] = i + 1
+	}
+	return &DKGParticipant{
+		Config:    config,
+		ID:        id,
+		random:    random,
+		qualified: NewQualifiedSet(parties, config.minQualified()),
+	}, nil
+}
+
+// State returns the step the participant waits for
+func (p *DKGParticipant) State() DKGState {
+	return p.state
+}
+
+// Attempt returns the number of the current attempt, starting at one
+func (p *DKGParticipant) Attempt() int {
+	return p.attempt
+}
+
+// Qualified returns the IDs of the parties that have not been disqualified
+func (p *DKGParticipant) Qualified() []int {
+	return p.qualified.Members()
+}
+
+// Disqualify removes the party from the qualified set, e.g., after a
+// justified complaint about its messages, and abandons the current attempt.
+// It returns ErrDKGRestart, after which all remaining parties continue with
+// Start, or an error wrapping ErrNotEnoughQualified.
+func (p *DKGParticipant) Disqualify(id int, reason string) error {
+	p.qualified.Disqualify(id, reason)
+	return p.restartQualified()
+}
+
+// Key returns the party's threshold secret key once the state is
+// DKGStateDone and nil before
+func (p *DKGParticipant) Key() *ThresholdSecretKey {
+	return p.key
+}
+
+// Start begins the next attempt and returns the candidate shares for the
+// qualified parties, indexed by ID-1 and including the party's own share;
+// the entries of disqualified parties are nil
+func (p *DKGParticipant) Start() ([]*DKGCandidateShare, error) {
+	if err := p.expectState(DKGStateStart); err != nil {
+		return nil, err
+	}
+	if p.Config.MaxAttempts > 0 && p.attempt >= p.Config.MaxAttempts {
+		return nil, fmt.Errorf("no biprime modulus found in %d attempts", p.attempt)
+	}
+	if err := p.qualified.Check(); err != nil {
+		return nil, err
+	}
+	if !p.qualified.Contains(p.ID) {
+		return nil, fmt.Errorf("party %d was disqualified: %s", p.ID, p.qualified.Reason(p.ID))
+	}
+	p.attempt++
+	p.members = p.qualified.Members()
+	p.leader = p.members[0]
+
+	var err error
+	if p.p, err = p.candidateSummand(); err != nil {
+		return nil, err
+	}
+	if p.q, err = p.candidateSummand(); err != nil {
+		return nil, err
+	}
+
+	l := p.privacyThreshold()
+	polys, err := p.sharingPolynomials([]*gmp.Int{p.p, p.q, ZeroBigInt}, []int{l + 1, l + 1, 2*l + 1})
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]*DKGCandidateShare, p.Config.Parties)
+	for _, j := range p.members {
+		shares[j-1] = &DKGCandidateShare{
+			From: p.ID, To: j, Attempt: p.attempt,
+			P:         polys[0].Evaluate(j),
+			Q:         polys[1].Evaluate(j),
+			Zero:      polys[2].Evaluate(j),
+			Qualified: p.members,
+		}
+	}
+
+	p.state = DKGStateCandidate
+	return shares, nil
+}
+
+// ReceiveCandidateShares takes the candidate shares sent to the party by the
+// qualified parties and returns its share of N for them. A nil entry or a
+// missing share counts as missing, as in the other steps.
+func (p *DKGParticipant) ReceiveCandidateShares(shares []*DKGCandidateShare) (*DKGModulusShare, error) {
+	if err := p.expectState(DKGStateCandidate); err != nil {
+		return nil, err
+	}
+
+	h := p.newHeaders()
+	pShare, qShare, zero := new(gmp.Int), new(gmp.Int), new(gmp.Int)
+	var dropped []int // parties another party does not consider qualified
+	for _, share := range shares {
+		if share == nil {
+			continue
+		}
+		if err := h.add(share.From, share.To, share.Attempt); err != nil {
+			return nil, err
+		}
+		if err := p.checkFieldElements(share.From, share.P, share.Q, share.Zero); err != nil {
+			return nil, err
+		}
+		for _, id := range p.members {
+			if !containsID(share.Qualified, id) {
+				p.qualified.Disqualify(id, fmt.Sprintf("disqualified by party %d", share.From))
+				dropped = append(dropped, id)
+			}
+		}
+		pShare.Add(pShare, share.P)
+		qShare.Add(qShare, share.Q)
+		zero.Add(zero, share.Zero)
+	}
+	if err := h.complete(); err != nil {
+		return nil, err
+	}
+	if len(dropped) > 0 {
+		return nil, p.restartQualified()
+	}
+
+	fp := p.Config.FieldPrime
+	p.pShare = pShare.Mod(pShare, fp)
+	p.qShare = qShare.Mod(qShare, fp)
+
+	// the zero sharing randomizes the product sharing, which has degree 2l
+	nShare := new(gmp.Int).Mul(p.pShare, p.qShare)
+	nShare.Add(nShare, zero)
+
+	p.state = DKGStateModulus
+	return &DKGModulusShare{From: p.ID, Attempt: p.attempt, N: nShare.Mod(nShare, fp)}, nil
+}
+
+// ReceiveModulusShares takes the modulus shares of the qualified parties, computes N
+// and returns the party's values of the biprimality test. It returns
+// ErrDKGRestart if N has a small factor.
+func (p *DKGParticipant) ReceiveModulusShares(shares []*DKGModulusShare) (*DKGBiprimalityShare, error) {
+	if err := p.expectState(DKGStateModulus); err != nil {
+		return nil, err
+	}
+
+	points := make([]*shamir.Share, 0, len(shares))
+	h := p.newHeaders()
+	for _, share := range shares {
+		if share == nil {
+			continue
+		}
+		if err := h.add(share.From, p.ID, share.Attempt); err != nil {
+			return nil, err
+		}
+		if err := p.checkFieldElements(share.From, share.N); err != nil {
+			return nil, err
+		}
+		points = append(points, &shamir.Share{X: share.From, Y: share.N})
+	}
+	if err := h.complete(); err != nil {
+		return nil, err
+	}
+
+	n, err := shamir.Reconstruct(points, p.Config.FieldPrime)
+	if err != nil {
+		return nil, err
+	}
+	p.n = n
+
+	if hasSmallPrimeFactor(ToBigInt(n)) {
+		return nil, p.restart()
+	}
+
+	// the leader holds phi/4 = (N + 1 - p_1 - q_1)/4 - sum_{i>1} (p_i + q_i)/4
+	exp := new(gmp.Int).Add(p.p, p.q)
+	if p.ID == p.leader {
+		exp.Sub(new(gmp.Int).Add(n, OneBigInt), exp)
+	}
+	exp.Rsh(exp, 2)
+
+	values := make([]*gmp.Int, p.Config.BiprimalityTests)
+	for k := range values {
+		values[k] = new(gmp.Int).Exp(dkgBiprimalityBase(n, p.attempt, k), exp, n)
+	}
+
+	p.state = DKGStateBiprimality
+	return &DKGBiprimalityShare{From: p.ID, Attempt: p.attempt, Values: values}, nil
+}
+
+// ReceiveBiprimalityShares takes the biprimality shares of the qualified
+// parties and returns the inverse shares for them, indexed by ID-1. It
+// returns ErrDKGRestart if N is not a biprime.
+func (p *DKGParticipant) ReceiveBiprimalityShares(shares []*DKGBiprimalityShare) ([]*DKGInverseShare, error) {
+	if err := p.expectState(DKGStateBiprimality); err != nil {
+		return nil, err
+	}
+
+	h := p.newHeaders()
+	var first *DKGBiprimalityShare
+	products := make([]*gmp.Int, p.Config.BiprimalityTests)
+	for k := range products {
+		products[k] = gmp.NewInt(1)
+	}
+	for _, share := range shares {
+		if share == nil {
+			continue
+		}
+		if err := h.add(share.From, p.ID, share.Attempt); err != nil {
+			return nil, err
+		}
+		if len(share.Values) != p.Config.BiprimalityTests {
+			return nil, fmt.Errorf("biprimality share of party %d has %d values", share.From, len(share.Values))
+		}
+		if share.From == p.leader {
+			first = share
+			continue
+		}
+		for k, value := range share.Values {
+			if value == nil {
+				return nil, fmt.Errorf("biprimality share of party %d is missing a value", share.From)
+			}
+			products[k].Mul(products[k], value)
+			products[k].Mod(products[k], p.n)
+		}
+	}
+	if err := h.complete(); err != nil {
+		return nil, err
+	}
+
+	// g^(phi/4) = +-1 mod N for all g with Jacobi symbol 1 iff N is a biprime
+	// with p = q = 3 mod 4, except with probability 1/2 per test
+	for k, value := range first.Values {
+		if value == nil {
+			return nil, fmt.Errorf("biprimality share of party %d is missing a value", p.leader)
+		}
+		minus := new(gmp.Int).Sub(p.n, products[k])
+		if value.Cmp(products[k]) != 0 && value.Cmp(minus) != 0 {
+			return nil, p.restart()
+		}
+	}
+
+	r, err := GetRandomNumber(p.n, p.random)
+	if err != nil {
+		return nil, err
+	}
+	sBound := new(gmp.Int).Lsh(p.n, dkgStatisticalSecurity)
+	if p.s, err = GetRandomNumber(sBound, p.random); err != nil {
+		return nil, err
+	}
+
+	l := p.privacyThreshold()
+	polys, err := p.sharingPolynomials([]*gmp.Int{r, p.s, ZeroBigInt}, []int{l + 1, l + 1, 2*l + 1})
+	if err != nil {
+		return nil, err
+	}
+
+	inverseShares := make([]*DKGInverseShare, p.Config.Parties)
+	for _, j := range p.members {
+		inverseShares[j-1] = &DKGInverseShare{
+			From: p.ID, To: j, Attempt: p.attempt,
+			R:    polys[0].Evaluate(j),
+			S:    polys[1].Evaluate(j),
+			Zero: polys[2].Evaluate(j),
+		}
+	}
+
+	p.state = DKGStateInverse
+	return inverseShares, nil
+}
+
+// ReceiveInverseShares takes the inverse shares sent to the party by the
+// qualified parties and returns its share of gamma for them
+func (p *DKGParticipant) ReceiveInverseShares(shares []*DKGInverseShare) (*DKGGammaShare, error) {
+	if err := p.expectState(DKGStateInverse); err != nil {
+		return nil, err
+	}
+
+	h := p.newHeaders()
+	rShare, sShare, zero := new(gmp.Int), new(gmp.Int), new(gmp.Int)
+	for _, share := range shares {
+		if share == nil {
+			continue
+		}
+		if err := h.add(share.From, share.To, share.Attempt); err != nil {
+			return nil, err
+		}
+		if err := p.checkFieldElements(share.From, share.R, share.S, share.Zero); err != nil {
+			return nil, err
+		}
+		rShare.Add(rShare, share.R)
+		sShare.Add(sShare, share.S)
+		zero.Add(zero, share.Zero)
+	}
+	if err := h.complete(); err != nil {
+		return nil, err
+	}
+
+	// the share of phi = N + 1 - p - q is a linear function of the shares
+	phiShare := new(gmp.Int).Add(p.n, OneBigInt)
+	phiShare.Sub(phiShare, p.pShare)
+	phiShare.Sub(phiShare, p.qShare)
+
+	gamma := new(gmp.Int).Mul(phiShare, rShare)
+	gamma.Add(gamma, new(gmp.Int).Mul(p.n, sShare))
+	gamma.Add(gamma, zero)
+
+	p.state = DKGStateGamma
+	return &DKGGammaShare{From: p.ID, Attempt: p.attempt, Gamma: gamma.Mod(gamma, p.Config.FieldPrime)}, nil
+}
+
+// ReceiveGammaShares takes the gamma shares of the qualified parties and
+// returns the key shares for them, indexed by ID-1. It returns ErrDKGRestart
+// in the unlikely case that gamma is not invertible mod N.
+func (p *DKGParticipant) ReceiveGammaShares(shares []*DKGGammaShare) ([]*DKGKeyShare, error) {
+	if err := p.expectState(DKGStateGamma); err != nil {
+		return nil, err
+	}
+
+	points := make([]*shamir.Share, 0, len(shares))
+	h := p.newHeaders()
+	for _, share := range shares {
+		if share == nil {
+			continue
+		}
+		if err := h.add(share.From, p.ID, share.Attempt); err != nil {
+			return nil, err
+		}
+		if err := p.checkFieldElements(share.From, share.Gamma); err != nil {
+			return nil, err
+		}
+		points = append(points, &shamir.Share{X: share.From, Y: share.Gamma})
+	}
+	if err := h.complete(); err != nil {
+		return nil, err
+	}
+
+	gamma, err := shamir.Reconstruct(points, p.Config.FieldPrime)
+	if err != nil {
+		return nil, err
+	}
+
+	gammaInv := new(gmp.Int).Mod(gamma, p.n)
+	if new(gmp.Int).GCD(nil, nil, gammaInv, p.n).Cmp(OneBigInt) != 0 {
+		return nil, p.restart()
+	}
+	gammaInv.ModInverse(gammaInv, p.n)
+
+	// d = gammaInv * (gamma - N*S) and party i holds the summand
+	// gammaInv * (gamma [i = leader] - N*s_i)
+	summand := new(gmp.Int).Neg(new(gmp.Int).Mul(p.n, p.s))
+	if p.ID == p.leader {
+		summand.Add(summand, gamma)
+	}
+	summand.Mul(summand, gammaInv)
+
+	if p.v, err = dkgVerificationBase(p.n); err != nil {
+		return nil, p.restart()
+	}
+
+	coefficients, err := p.integerPolynomial(summand)
+	if err != nil {
+		return nil, err
+	}
+
+	n2 := new(gmp.Int).Mul(p.n, p.n)
+	commitments := make([]*gmp.Int, len(coefficients))
+	for k, a := range coefficients {
+		commitments[k] = expSigned(p.v, a, n2)
+	}
+
+	keyShares := make([]*DKGKeyShare, p.Config.Parties)
+	for _, j := range p.members {
+		keyShares[j-1] = &DKGKeyShare{
+			From: p.ID, To: j, Attempt: p.attempt,
+			Share:       evaluateIntegerPolynomial(coefficients, j),
+			Commitments: commitments,
+		}
+	}
+
+	p.state = DKGStateKey
+	return keyShares, nil
+}
+
+// ReceiveKeyShares takes the key shares sent to the party by the qualified
+// parties, verifies them against their commitments and returns the party's
+// threshold secret key
+func (p *DKGParticipant) ReceiveKeyShares(shares []*DKGKeyShare) (*ThresholdSecretKey, error) {
+	if err := p.expectState(DKGStateKey); err != nil {
+		return nil, err
+	}
+
+	n2 := new(gmp.Int).Mul(p.n, p.n)
+	aggregated := make([]*gmp.Int, p.Config.Threshold)
+	for k := range aggregated {
+		aggregated[k] = gmp.NewInt(1)
+	}
+
+	h := p.newHeaders()
+	share := new(gmp.Int)
+	for _, ks := range shares {
+		if ks == nil {
+			continue
+		}
+		if err := h.add(ks.From, ks.To, ks.Attempt); err != nil {
+			return nil, err
+		}
+		if ks.Share == nil || len(ks.Commitments) != p.Config.Threshold {
+			return nil, fmt.Errorf("key share of party %d is malformed", ks.From)
+		}
+		for _, c := range ks.Commitments {
+			if c == nil || c.Sign() <= 0 || c.Cmp(n2) >= 0 {
+				return nil, fmt.Errorf("key share of party %d is malformed", ks.From)
+			}
+		}
+		if expSigned(p.v, ks.Share, n2).Cmp(evaluateInExponent(ks.Commitments, p.ID, n2)) != 0 {
+			return nil, fmt.Errorf("key share of party %d does not match its commitments", ks.From)
+		}
+
+		share.Add(share, ks.Share)
+		for k, c := range ks.Commitments {
+			aggregated[k].Mul(aggregated[k], c)
+			aggregated[k].Mod(aggregated[k], n2)
+		}
+	}
+	if err := h.complete(); err != nil {
+		return nil, err
+	}
+	if share.Sign() <= 0 {
+		return nil, errors.New("key share is not positive")
+	}
+
+	// v_j = v^(delta f(j)) for the sum f of the polynomials of the qualified
+	// parties, also for the disqualified parties, which hold no share
+	delta := Factorial(p.Config.Parties)
+	verificationKeys := make([]*gmp.Int, p.Config.Parties)
+	for j := range verificationKeys {
+		verificationKeys[j] = new(gmp.Int).Exp(evaluateInExponent(aggregated, j+1, n2), delta, n2)
+	}
+
+	key := new(ThresholdSecretKey)
+	key.N = p.n
+	key.G = new(gmp.Int).Add(p.n, OneBigInt)
+	key.TotalNumberOfDecryptionServers = p.Config.Parties
+	key.Threshold = p.Config.Threshold
+	key.VerificationKey = p.v
+	key.VerificationKeys = verificationKeys
+	key.ID = p.ID
+	key.Share = share
+
+	p.key = key
+	p.p, p.q, p.s, p.pShare, p.qShare = nil, nil, nil, nil, nil
+	p.state = DKGStateDone
+	return key, nil
+}
+
+// Run executes the protocol over the transport, on which the parties have
+// their IDs, and returns the party's key. Attempt a uses the rounds
+// round + 6(a-1) to round + 6a - 1. Messages to the party itself are not
+// sent over the transport. The transport must time out, see
+// MemoryNetwork.Timeout, so that parties that do not respond in a round
+// are disqualified by CollectRound instead of blocking the others.
+func (p *DKGParticipant) Run(t Transport, round int) (*ThresholdSecretKey, error) {
+	for {
+		key, err := p.runAttempt(t, round)
+		if !errors.Is(err, ErrDKGRestart) {
+			return key, err
+		}
+	}
+}
+
+func (p *DKGParticipant) runAttempt(t Transport, round int) (*ThresholdSecretKey, error) {
+	parties := p.Config.Parties
+
+	candidates, err := p.Start()
+	if err != nil {
+		return nil, err
+	}
+	round += dkgRoundsPerAttempt * (p.attempt - 1)
+
+	candidatesIn := make([]*DKGCandidateShare, parties)
+	err = p.exchange(t, round,
+		func(to int) dkgMessage { return candidates[to-1] },
+		func(from int) dkgMessage { candidatesIn[from-1] = new(DKGCandidateShare); return candidatesIn[from-1] })
+	if err != nil {
+		return nil, err
+	}
+	modulus, err := p.ReceiveCandidateShares(candidatesIn)
+	if err != nil {
+		return nil, err
+	}
+
+	moduli := make([]*DKGModulusShare, parties)
+	err = p.exchange(t, round+1,
+		func(int) dkgMessage { return modulus },
+		func(from int) dkgMessage { moduli[from-1] = new(DKGModulusShare); return moduli[from-1] })
+	if err != nil {
+		return nil, err
+	}
+	biprimality, err := p.ReceiveModulusShares(moduli)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := make([]*DKGBiprimalityShare, parties)
+	err = p.exchange(t, round+2,
+		func(int) dkgMessage { return biprimality },
+		func(from int) dkgMessage { tests[from-1] = new(DKGBiprimalityShare); return tests[from-1] })
+	if err != nil {
+		return nil, err
+	}
+	inverses, err := p.ReceiveBiprimalityShares(tests)
+	if err != nil {
+		return nil, err
+	}
+
+	inversesIn := make([]*DKGInverseShare, parties)
+	err = p.exchange(t, round+3,
+		func(to int) dkgMessage { return inverses[to-1] },
+		func(from int) dkgMessage { inversesIn[from-1] = new(DKGInverseShare); return inversesIn[from-1] })
+	if err != nil {
+		return nil, err
+	}
+	gamma, err := p.ReceiveInverseShares(inversesIn)
+	if err != nil {
+		return nil, err
+	}
+
+	gammas := make([]*DKGGammaShare, parties)
+	err = p.exchange(t, round+4,
+		func(int) dkgMessage { return gamma },
+		func(from int) dkgMessage { gammas[from-1] = new(DKGGammaShare); return gammas[from-1] })
+	if err != nil {
+		return nil, err
+	}
+	keyShares, err := p.ReceiveGammaShares(gammas)
+	if err != nil {
+		return nil, err
+	}
+
+	keySharesIn := make([]*DKGKeyShare, parties)
+	err = p.exchange(t, round+5,
+		func(to int) dkgMessage { return keyShares[to-1] },
+		func(from int) dkgMessage { keySharesIn[from-1] = new(DKGKeyShare); return keySharesIn[from-1] })
+	if err != nil {
+		return nil, err
+	}
+	return p.ReceiveKeyShares(keySharesIn)
+}
+
+// dkgMessage is implemented by the messages of the rounds
+type dkgMessage interface {
+	sender() int
+}
+
+func (m *DKGCandidateShare) sender() int   { return m.From }
+func (m *DKGModulusShare) sender() int     { return m.From }
+func (m *DKGBiprimalityShare) sender() int { return m.From }
+func (m *DKGInverseShare) sender() int     { return m.From }
+func (m *DKGGammaShare) sender() int       { return m.From }
+func (m *DKGKeyShare) sender() int         { return m.From }
+
+// sends outgoing(j) to every other qualified party j in the round and
+// decodes the message of every other qualified party i into incoming(i); the
+// party's own message is passed to incoming directly. The messages of the
+// parties that did not respond are left out, so that the next step
+// disqualifies them.
+func (p *DKGParticipant) exchange(t Transport, round int, outgoing func(to int) dkgMessage, incoming func(from int) dkgMessage) error {
+	others := make([]int, 0, len(p.members)-1)
+	for _, j := range p.members {
+		if j == p.ID {
+			continue
+		}
+		others = append(others, j)
+
+		data, err := gobEncode(outgoing(j))
+		if err != nil {
+			return err
+		}
+		if err := t.Send(j, round, data); err != nil {
+			return err
+		}
+	}
+
+	own, err := gobEncode(outgoing(p.ID))
+	if err != nil {
+		return err
+	}
+	if err := gobDecode(own, incoming(p.ID)); err != nil {
+		return err
+	}
+
+	result, err := CollectRound(t, round, others)
+	if err != nil {
+		return err
+	}
+
+	for from, data := range result.Messages {
+		msg := incoming(from)
+		if err := gobDecode(data, msg); err != nil {
+			return fmt.Errorf("message of party %d: %w", from, err)
+		}
+		if msg.sender() != from {
+			return fmt.Errorf("message of party %d claims to be from party %d", from, msg.sender())
+		}
+	}
+	return nil
+}
+
+func (p *DKGParticipant) expectState(state DKGState) error {
+	if p.state != state {
+		return fmt.Errorf("distributed key generation is in state %d, not %d", p.state, state)
+	}
+	return nil
+}
+
+// abandons the current attempt
+func (p *DKGParticipant) restart() error {
+	p.p, p.q, p.s, p.pShare, p.qShare = nil, nil, nil, nil, nil
+	p.n, p.v = nil, nil
+	p.state = DKGStateStart
+	return ErrDKGRestart
+}
+
+// abandons the current attempt after parties were disqualified and returns
+// ErrDKGRestart or an error wrapping ErrNotEnoughQualified if too few
+// qualified parties remain
+func (p *DKGParticipant) restartQualified() error {
+	err := p.restart()
+	if checkErr := p.qualified.Check(); checkErr != nil {
+		return checkErr
+	}
+	if !p.qualified.Contains(p.ID) {
+		return fmt.Errorf("party %d was disqualified: %s", p.ID, p.qualified.Reason(p.ID))
+	}
+	return err
+}
+
+// returns the number of parties a sharing over the field hides against;
+// products of two sharings have degree 2*privacyThreshold < len(members)
+func (p *DKGParticipant) privacyThreshold() int {
+	return (len(p.members) - 1) / 2
+}
+
+// returns a random summand of a candidate prime: the leader picks a summand
+// of candidateBits bits that is 3 mod 4 and the others pick multiples of 4
+// below 2^candidateBits, so that the sum is 3 mod 4 and has at least
+// candidateBits bits
+func (p *DKGParticipant) candidateSummand() (*gmp.Int, error) {
+	bits := p.Config.candidateBits()
+	x, err := GetRandomNumber(new(gmp.Int).Lsh(OneBigInt, uint(bits)), p.random)
+	if err != nil {
+		return nil, err
+	}
+
+	x.Rsh(x, 2)
+	x.Lsh(x, 2)
+	if p.ID == p.leader {
+		x.SetBit(x, bits-1, 1)
+		x.Add(x, gmp.NewInt(3))
+	}
+	return x, nil
+}
+
+// returns sharing polynomials over the field for the secrets with the given
+// numbers of coefficients
+func (p *DKGParticipant) sharingPolynomials(secrets []*gmp.Int, thresholds []int) ([]*shamir.Polynomial, error) {
+	polys := make([]*shamir.Polynomial, len(secrets))
+	for i, secret := range secrets {
+		var err error
+		polys[i], err = shamir.NewPolynomial(secret, thresholds[i], p.Config.FieldPrime, p.random)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return polys, nil
+}
+
+// returns the coefficients of a polynomial over the integers with constant
+// term secret; the other coefficients exceed the summands of the exponent,
+// which are below Parties N^3 2^(statistical security + 2), by another
+// 2^statistical security to hide them statistically
+func (p *DKGParticipant) integerPolynomial(secret *gmp.Int) ([]*gmp.Int, error) {
+	bound := new(gmp.Int).Exp(p.n, gmp.NewInt(3), nil)
+	bound.Lsh(bound, uint(2*dkgStatisticalSecurity+p.Config.partyBits()+2))
+
+	coefficients := make([]*gmp.Int, p.Config.Threshold)
+	coefficients[0] = secret
+	for k := 1; k < len(coefficients); k++ {
+		var err error
+		if coefficients[k], err = GetRandomNumber(bound, p.random); err != nil {
+			return nil, err
+		}
+	}
+	return coefficients, nil
+}
+
+func (p *DKGParticipant) checkFieldElements(from int, values ...*gmp.Int) error {
+	for _, x := range values {
+		if x == nil || x.Sign() < 0 || x.Cmp(p.Config.FieldPrime) >= 0 {
+			return fmt.Errorf("message of party %d is not in the field", from)
+		}
+	}
+	return nil
+}
+
+// dkgHeaders checks that a round has exactly one message of every qualified
+// party for the party and the current attempt
+type dkgHeaders struct {
+	p    *DKGParticipant
+	seen map[int]bool
+}
+
+func (p *DKGParticipant) newHeaders() *dkgHeaders {
+	return &dkgHeaders{p: p, seen: make(map[int]bool, p.Config.Parties)}
+}
+
+func (h *dkgHeaders) add(from, to, attempt int) error {
+	if from < 1 || from > h.p.Config.Parties {
+		return fmt.Errorf("message of unknown party %d", from)
+	}
+	if !containsID(h.p.members, from) {
+		return fmt.Errorf("message of disqualified party %d", from)
+	}
+	if to != h.p.ID {
+		return fmt.Errorf("message of party %d is for party %d", from, to)
+	}
+	if attempt != h.p.attempt {
+		return fmt.Errorf("message of party %d is for attempt %d, not %d", from, attempt, h.p.attempt)
+	}
+	if h.seen[from] {
+		return fmt.Errorf("two messages of party %d", from)
+	}
+	h.seen[from] = true
+	return nil
+}
+
+// complete disqualifies the qualified parties without a message in the
+// round and then abandons the attempt, see restartQualified
+func (h *dkgHeaders) complete() error {
+	var missing []int
+	for _, id := range h.p.members {
+		if !h.seen[id] {
+			missing = append(missing, id)
+			h.p.qualified.Disqualify(id, fmt.Sprintf("no message in attempt %d", h.p.attempt))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("parties %v did not respond: %w", missing, h.p.restartQualified())
+}
+
+// returns the integer f(x) for the coefficients of f
+func evaluateIntegerPolynomial(coefficients []*gmp.Int, x int) *gmp.Int {
+	result := new(gmp.Int)
+	xi := gmp.NewInt(1)
+	bx := gmp.NewInt(int64(x))
+	for _, a := range coefficients {
+		result.Add(result, new(gmp.Int).Mul(a, xi))
+		xi.Mul(xi, bx)
+	}
+	return result
+}
+
+// returns prod_k commitments[k]^(x^k) mod m
+func evaluateInExponent(commitments []*gmp.Int, x int, m *gmp.Int) *gmp.Int {
+	result := gmp.NewInt(1)
+	xi := gmp.NewInt(1)
+	bx := gmp.NewInt(int64(x))
+	for _, c := range commitments {
+		result.Mul(result, new(gmp.Int).Exp(c, xi, m))
+		result.Mod(result, m)
+		xi.Mul(xi, bx)
+	}
+	return result
+}
+
+// returns x^e mod m for a possibly negative exponent; x must be a unit
+func expSigned(x, e, m *gmp.Int) *gmp.Int {
+	if e.Sign() >= 0 {
+		return new(gmp.Int).Exp(x, e, m)
+	}
+	inv := new(gmp.Int).ModInverse(x, m)
+	return inv.Exp(inv, new(gmp.Int).Neg(e), m)
+}
+
+// returns true iff n is divisible by an odd prime below sieveBound
+func hasSmallPrimeFactor(n *big.Int) bool {
+	residue := new(big.Int)
+	for _, group := range sieveGroups {
+		m := residue.Mod(n, group.product).Uint64()
+		for _, prime := range group.primes {
+			if m%prime == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// returns the smallest prime larger than x
+func nextPrime(x *gmp.Int) *gmp.Int {
+	candidate := new(gmp.Int).Add(x, OneBigInt)
+	if candidate.Bit(0) == 0 {
+		candidate.Add(candidate, OneBigInt)
+	}
+	for !candidate.ProbablyPrime(20) {
+		candidate.Add(candidate, TwoBigInt)
+	}
+	return candidate
+}
+
+// returns a public pseudorandom element of Z_modulus derived from the
+// label and values, which all parties compute alike
+func dkgHash(modulus *gmp.Int, label string, values ...int) *gmp.Int {
+	var digest []byte
+	for block := 0; len(digest)*8 < modulus.BitLen()+dkgStatisticalSecurity; block++ {
+		hash := sha256.New()
+		hash.Write([]byte(label))
+		hash.Write(modulus.Bytes())
+		for _, v := range append(values, block) {
+			binary.Write(hash, binary.BigEndian, int64(v))
+		}
+		digest = hash.Sum(digest)
+	}
+	return new(gmp.Int).Mod(new(gmp.Int).SetBytes(digest), modulus)
+}
+
+// returns the base of the k'th biprimality test of the attempt, an element
+// of Z_N^* with Jacobi symbol 1
+func dkgBiprimalityBase(n *gmp.Int, attempt, k int) *gmp.Int {
+	for counter := 0; ; counter++ {
+		g := dkgHash(n, "paillier dkg biprimality", attempt, k, counter)
+		if big.Jacobi(ToBigInt(g), ToBigInt(n)) == 1 {
+			return g
+		}
+	}
+}
+
+// returns the generator of the verification keys, a random square mod N^2
+func dkgVerificationBase(n *gmp.Int) (*gmp.Int, error) {
+	n2 := new(gmp.Int).Mul(n, n)
+	r := dkgHash(n2, "paillier dkg verification key")
+	if new(gmp.Int).GCD(nil, nil, r, n).Cmp(OneBigInt) != 0 {
+		return nil, errors.New("verification base is not a unit")
+	}
+	return r.Mul(r, r).Mod(r, n2), nil
+}
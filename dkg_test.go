@@ -0,0 +1,355 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func newDKGParticipants(t *testing.T, bits, parties, threshold int) []*DKGParticipant {
+	config, err := NewDKGConfig(bits, parties, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.BiprimalityTests = 20
+
+	ps := make([]*DKGParticipant, parties)
+	for i := range ps {
+		if ps[i], err = NewDKGParticipant(config, i+1, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ps
+}
+
+// checks that either all or none of the parties rejected the candidate
+func dkgRestarted(t *testing.T, errs []error) bool {
+	restarts := 0
+	for _, err := range errs {
+		if errors.Is(err, ErrDKGRestart) {
+			restarts++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if restarts != 0 && restarts != len(errs) {
+		t.Fatal("parties disagree on the candidate")
+	}
+	return restarts > 0
+}
+
+// runs the participants in lockstep, delivering all messages directly
+func runDKGSteps(t *testing.T, ps []*DKGParticipant) []*ThresholdSecretKey {
+	n := len(ps)
+	errs := make([]error, n)
+	for {
+		candidates := make([][]*DKGCandidateShare, n)
+		for i, p := range ps {
+			if candidates[i], errs[i] = p.Start(); errs[i] != nil {
+				t.Fatal(errs[i])
+			}
+		}
+
+		moduli := make([]*DKGModulusShare, n)
+		for j, p := range ps {
+			in := make([]*DKGCandidateShare, n)
+			for i := range ps {
+				in[i] = candidates[i][j]
+			}
+			if moduli[j], errs[j] = p.ReceiveCandidateShares(in); errs[j] != nil {
+				t.Fatal(errs[j])
+			}
+		}
+
+		tests := make([]*DKGBiprimalityShare, n)
+		for i, p := range ps {
+			tests[i], errs[i] = p.ReceiveModulusShares(moduli)
+		}
+		if dkgRestarted(t, errs) {
+			continue
+		}
+
+		inverses := make([][]*DKGInverseShare, n)
+		for i, p := range ps {
+			inverses[i], errs[i] = p.ReceiveBiprimalityShares(tests)
+		}
+		if dkgRestarted(t, errs) {
+			continue
+		}
+
+		gammas := make([]*DKGGammaShare, n)
+		for j, p := range ps {
+			in := make([]*DKGInverseShare, n)
+			for i := range ps {
+				in[i] = inverses[i][j]
+			}
+			if gammas[j], errs[j] = p.ReceiveInverseShares(in); errs[j] != nil {
+				t.Fatal(errs[j])
+			}
+		}
+
+		keyShares := make([][]*DKGKeyShare, n)
+		for i, p := range ps {
+			keyShares[i], errs[i] = p.ReceiveGammaShares(gammas)
+		}
+		if dkgRestarted(t, errs) {
+			continue
+		}
+
+		keys := make([]*ThresholdSecretKey, n)
+		for j, p := range ps {
+			in := make([]*DKGKeyShare, n)
+			for i := range ps {
+				in[i] = keyShares[i][j]
+			}
+			if keys[j], errs[j] = p.ReceiveKeyShares(in); errs[j] != nil {
+				t.Fatal(errs[j])
+			}
+		}
+		return keys
+	}
+}
+
+func checkDKGKeys(t *testing.T, keys []*ThresholdSecretKey) {
+	for _, key := range keys[1:] {
+		if key.N.Cmp(keys[0].N) != 0 || key.VerificationKey.Cmp(keys[0].VerificationKey) != 0 {
+			t.Fatal("parties computed different public keys")
+		}
+		for i, vk := range key.VerificationKeys {
+			if vk.Cmp(keys[0].VerificationKeys[i]) != 0 {
+				t.Fatal("parties computed different verification keys")
+			}
+		}
+	}
+	if keys[0].N.ProbablyPrime(20) {
+		t.Fatal("modulus is prime")
+	}
+
+	tk := keys[0].PublicKey()
+	ct := tk.Encrypt(gmp.NewInt(1234))
+	threshold := keys[0].Threshold
+	for start := 0; start+threshold <= len(keys); start++ {
+		var shares []*PartialDecryptionZKP
+		for _, key := range keys[start : start+threshold] {
+			pd, err := key.PartialDecryptionWithZKP(ct.C)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := pd.VerifyErrWithKey(tk); err != nil {
+				t.Fatal(err)
+			}
+			shares = append(shares, pd)
+		}
+
+		m, err := tk.CombinePartialDecryptionsZKP(shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Int64() != 1234 {
+			t.Fatal("wrong decryption ", m)
+		}
+	}
+}
+
+func TestDKG(t *testing.T) {
+	ps := newDKGParticipants(t, 128, 3, 2)
+	keys := runDKGSteps(t, ps)
+	checkDKGKeys(t, keys)
+
+	if ps[0].State() != DKGStateDone || ps[0].Key() != keys[0] {
+		t.Error("participant is not done")
+	}
+	if bits := keys[0].N.BitLen(); bits > 128 || bits < 128-2*2-1 {
+		t.Error("modulus has ", bits, " bits")
+	}
+}
+
+func TestDKGFiveParties(t *testing.T) {
+	ps := newDKGParticipants(t, 128, 5, 3)
+	checkDKGKeys(t, runDKGSteps(t, ps))
+}
+
+func TestDKGOverTransport(t *testing.T) {
+	ps := newDKGParticipants(t, 128, 3, 3)
+	network := NewMemoryNetwork(1, 2, 3)
+
+	keys := make([]*ThresholdSecretKey, len(ps))
+	errs := make(chan error, len(ps))
+	for i, p := range ps {
+		go func(i int, p *DKGParticipant) {
+			var err error
+			keys[i], err = p.Run(network.Transport(p.ID), 10)
+			errs <- err
+		}(i, p)
+	}
+	for range ps {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkDKGKeys(t, keys)
+}
+
+func TestDKGRejectsBadMessages(t *testing.T) {
+	ps := newDKGParticipants(t, 128, 3, 2)
+	if _, err := ps[0].ReceiveCandidateShares(nil); err == nil {
+		t.Error("expected an error for a step out of order")
+	}
+
+	candidates := make([][]*DKGCandidateShare, len(ps))
+	for i, p := range ps {
+		var err error
+		if candidates[i], err = p.Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := []*DKGCandidateShare{candidates[0][0], candidates[1][0], candidates[2][1]}
+	if _, err := ps[0].ReceiveCandidateShares(in); err == nil {
+		t.Error("expected an error for a share for another party")
+	}
+
+	wrong := *candidates[2][0]
+	wrong.Attempt = 2
+	in = []*DKGCandidateShare{candidates[0][0], candidates[1][0], &wrong}
+	if _, err := ps[0].ReceiveCandidateShares(in); err == nil {
+		t.Error("expected an error for a share of another attempt")
+	}
+
+	wrong = *candidates[2][0]
+	wrong.P = ps[0].Config.FieldPrime
+	in = []*DKGCandidateShare{candidates[0][0], candidates[1][0], &wrong}
+	if _, err := ps[0].ReceiveCandidateShares(in); err == nil {
+		t.Error("expected an error for a share out of the field")
+	}
+
+	// with three parties, a missing party leaves too few qualified ones
+	in = []*DKGCandidateShare{candidates[0][0], candidates[1][0]}
+	if _, err := ps[0].ReceiveCandidateShares(in); !errors.Is(err, ErrNotEnoughQualified) {
+		t.Error("expected ErrNotEnoughQualified for a missing share, got ", err)
+	}
+}
+
+func TestDKGDropout(t *testing.T) {
+	ps := newDKGParticipants(t, 128, 4, 3)
+
+	candidates := make([][]*DKGCandidateShare, len(ps))
+	for i, p := range ps {
+		var err error
+		if candidates[i], err = p.Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// party 4 goes offline after the first round
+	moduli := make([]*DKGModulusShare, 3)
+	for j, p := range ps[:3] {
+		in := []*DKGCandidateShare{candidates[0][j], candidates[1][j], candidates[2][j], candidates[3][j]}
+		var err error
+		if moduli[j], err = p.ReceiveCandidateShares(in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range ps[:3] {
+		if _, err := p.ReceiveModulusShares(moduli); !errors.Is(err, ErrDKGRestart) {
+			t.Fatal("expected ErrDKGRestart, got ", err)
+		}
+		if _, err := p.ReceiveModulusShares(moduli); err == nil {
+			t.Fatal("expected an error for a step out of order")
+		}
+	}
+
+	keys := runDKGSteps(t, ps[:3])
+	checkDKGKeys(t, keys)
+	for _, key := range keys {
+		if key.TotalNumberOfDecryptionServers != 4 || len(key.VerificationKeys) != 4 {
+			t.Fatal("the disqualified party must keep its ID")
+		}
+	}
+	if q := ps[0].Qualified(); len(q) != 3 || q[2] != 3 {
+		t.Error("wrong qualified parties ", q)
+	}
+	if err := ps[1].Disqualify(3, "complaint"); !errors.Is(err, ErrNotEnoughQualified) {
+		t.Error("expected ErrNotEnoughQualified, got ", err)
+	}
+}
+
+func TestDKGDropoutOverTransport(t *testing.T) {
+	ps := newDKGParticipants(t, 128, 4, 3)
+	network := NewMemoryNetwork(1, 2, 3, 4)
+	network.Timeout = time.Second
+
+	// party 1 never comes online, so the leader is the smallest qualified
+	// party instead
+	online := []*DKGParticipant{ps[1], ps[2], ps[3]}
+	keys := make([]*ThresholdSecretKey, len(online))
+	errs := make(chan error, len(online))
+	for i, p := range online {
+		go func(i int, p *DKGParticipant) {
+			var err error
+			keys[i], err = p.Run(network.Transport(p.ID), 10)
+			errs <- err
+		}(i, p)
+	}
+	for range online {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkDKGKeys(t, keys)
+	for _, p := range online {
+		if reason := p.qualified.Reason(1); reason == "" {
+			t.Errorf("party 1 is still qualified for party %d", p.ID)
+		}
+		if p.leader != 2 {
+			t.Errorf("party %d took party %d as the leader, expected 2", p.ID, p.leader)
+		}
+	}
+}
+
+func TestDKGRejectsInvalidKeyShare(t *testing.T) {
+	ps := newDKGParticipants(t, 128, 3, 2)
+	keys := runDKGSteps(t, ps)
+
+	// replay the last round with a share that does not match the commitments
+	p := ps[1]
+	p.state = DKGStateKey
+	v := keys[0].VerificationKey
+	commitments := []*gmp.Int{v, v}
+	in := make([]*DKGKeyShare, len(ps))
+	for i := range in {
+		in[i] = &DKGKeyShare{From: i + 1, To: 2, Attempt: p.Attempt(), Share: gmp.NewInt(3), Commitments: commitments}
+	}
+	if _, err := p.ReceiveKeyShares(in); err != nil {
+		t.Fatal("consistent shares were rejected: ", err)
+	}
+
+	p.state = DKGStateKey
+	in[2] = &DKGKeyShare{From: 3, To: 2, Attempt: p.Attempt(), Share: gmp.NewInt(4), Commitments: commitments}
+	if _, err := p.ReceiveKeyShares(in); err == nil {
+		t.Error("expected an error for a share that does not match its commitments")
+	}
+}
+
+func TestDKGConfig(t *testing.T) {
+	if _, err := NewDKGConfig(128, 2, 2); err == nil {
+		t.Error("expected an error for two parties")
+	}
+	if _, err := NewDKGConfig(128, 3, 4); err == nil {
+		t.Error("expected an error for a threshold above the number of parties")
+	}
+
+	config, err := NewDKGConfig(128, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.FieldPrime = gmp.NewInt(7)
+	if _, err := NewDKGParticipant(config, 1, rand.Reader); err == nil {
+		t.Error("expected an error for a small field")
+	}
+}
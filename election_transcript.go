@@ -0,0 +1,189 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Kinds of the election artifacts published on a bulletin board
+const (
+	BulletinBallot = "ballot"
+	BulletinTally  = "tally"
+)
+
+// Ballot holds one encrypted bit per option of an election, e.g., yes/no per
+// candidate, with a proof for each ciphertext that it encrypts 0 or 1
+type Ballot struct {
+	Choices []*Ciphertext
+	Proofs  []*BinaryProof
+}
+
+// ElectionTally is the published result: the count of every option and the
+// partial decryptions of the encrypted count by the decryption servers
+type ElectionTally struct {
+	Counts             []*gmp.Int
+	PartialDecryptions [][]*PartialDecryptionZKP // per option
+}
+
+// ElectionTranscript is everything published during an election
+type ElectionTranscript struct {
+	Key     *ThresholdPublicKey
+	Ballots []*Ballot
+	Tally   *ElectionTally
+}
+
+// NewBallot encrypts the choices (0 or 1 per option) with validity proofs
+func NewBallot(pk *PublicKey, choices []int) (*Ballot, error) {
+	ballot := &Ballot{
+		Choices: make([]*Ciphertext, len(choices)),
+		Proofs:  make([]*BinaryProof, len(choices)),
+	}
+
+	for i, choice := range choices {
+		var err error
+		ballot.Choices[i], ballot.Proofs[i], err = pk.EncryptBitWithProof(choice)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ballot, nil
+}
+
+// TallyCiphertexts returns the homomorphic sum of the ballots for every one
+// of the given number of options; ballots must have been verified
+func TallyCiphertexts(pk *PublicKey, ballots []*Ballot, options int) []*Ciphertext {
+	tally := make([]*Ciphertext, options)
+	for j := range tally {
+		cts := []*Ciphertext{{C: gmp.NewInt(1), Level: EncLevelOne}}
+		for _, ballot := range ballots {
+			cts = append(cts, ballot.Choices[j])
+		}
+		tally[j] = pk.Add(cts...)
+	}
+	return tally
+}
+
+// VerifyElectionTranscript re-checks a published transcript end-to-end and
+// returns an error describing the first problem found:
+//
+//   - every ballot has one ciphertext per option with a valid binary proof,
+//   - the encrypted count of every option is the product of the ballots,
+//   - every published partial decryption is of the encrypted count, by a
+//     distinct server of the key and carries a valid proof,
+//   - the partial decryptions of at least Threshold servers combine to the
+//     published count.
+//
+// Transcripts of elections that shuffle ballots are not supported; verify
+// the shuffle separately with VerifyShuffle.
+func VerifyElectionTranscript(tr *ElectionTranscript) error {
+	if tr.Key == nil || tr.Key.N == nil || tr.Tally == nil {
+		return errors.New("transcript is missing the key or the tally")
+	}
+
+	options := len(tr.Tally.Counts)
+	if options == 0 || len(tr.Tally.PartialDecryptions) != options {
+		return errors.New("tally must have a count and partial decryptions for every option")
+	}
+
+	pk := &tr.Key.PublicKey
+	for i, ballot := range tr.Ballots {
+		if ballot == nil || len(ballot.Choices) != options || len(ballot.Proofs) != options {
+			return fmt.Errorf("ballot %d: wrong number of choices", i)
+		}
+
+		for j := range ballot.Choices {
+			if ballot.Choices[j] == nil || ballot.Choices[j].C == nil {
+				return fmt.Errorf("ballot %d, option %d: missing ciphertext", i, j)
+			}
+			if err := pk.VerifyBinaryProofErr(ballot.Choices[j], ballot.Proofs[j]); err != nil {
+				return fmt.Errorf("ballot %d, option %d: %w", i, j, err)
+			}
+		}
+	}
+
+	tally := TallyCiphertexts(pk, tr.Ballots, options)
+	for j, ct := range tally {
+		pds := tr.Tally.PartialDecryptions[j]
+		if len(pds) < tr.Key.Threshold {
+			return fmt.Errorf("option %d: %d partial decryptions, threshold is %d", j, len(pds), tr.Key.Threshold)
+		}
+
+		shares := make([]*PartialDecryption, len(pds))
+		for k, pd := range pds {
+			if pd == nil || pd.C == nil || pd.C.Cmp(ct.C) != 0 {
+				return fmt.Errorf("option %d: partial decryption %d is not of the encrypted count", j, k)
+			}
+			if err := pd.VerifyErrWithKey(tr.Key); err != nil {
+				return fmt.Errorf("option %d: %w", j, err)
+			}
+			shares[k] = &pd.PartialDecryption
+		}
+
+		count, err := tr.Key.CombinePartialDecryptions(shares)
+		if err != nil {
+			return fmt.Errorf("option %d: %w", j, err)
+		}
+
+		if tr.Tally.Counts[j] == nil || count.Cmp(tr.Tally.Counts[j]) != 0 {
+			return fmt.Errorf("option %d: published count %v does not match the decryption %v", j, tr.Tally.Counts[j], count)
+		}
+	}
+
+	return nil
+}
+
+// ReadElectionTranscript reads a transcript from a bulletin board on which the
+// threshold public key, the ballots and the tally were published with
+// PublishPublicKey, PublishBallot and PublishTally; other entries are skipped
+func ReadElectionTranscript(board BulletinBoard) (*ElectionTranscript, error) {
+	length, err := board.Len()
+	if err != nil {
+		return nil, err
+	}
+
+	tr := new(ElectionTranscript)
+	for i := uint64(0); i < length; i++ {
+		entry, err := board.Fetch(i)
+		if err != nil {
+			return nil, err
+		}
+
+		switch entry.Kind {
+		case BulletinPublicKey:
+			if tr.Key != nil {
+				return nil, fmt.Errorf("entry %d: second public key", i)
+			}
+			tr.Key = new(ThresholdPublicKey)
+			err = gobDecode(entry.Data, tr.Key)
+		case BulletinBallot:
+			ballot := new(Ballot)
+			err = gobDecode(entry.Data, ballot)
+			tr.Ballots = append(tr.Ballots, ballot)
+		case BulletinTally:
+			if tr.Tally != nil {
+				return nil, fmt.Errorf("entry %d: second tally", i)
+			}
+			tr.Tally = new(ElectionTally)
+			err = gobDecode(entry.Data, tr.Tally)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+
+	return tr, nil
+}
+
+// PublishBallot publishes the gob encoding of the ballot
+func PublishBallot(board BulletinBoard, ballot *Ballot) (*InclusionReceipt, error) {
+	return publishGob(board, BulletinBallot, ballot)
+}
+
+// PublishTally publishes the gob encoding of the tally
+func PublishTally(board BulletinBoard, tally *ElectionTally) (*InclusionReceipt, error) {
+	return publishGob(board, BulletinTally, tally)
+}
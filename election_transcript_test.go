@@ -0,0 +1,112 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestElectionTranscript(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	board := NewMemoryBulletinBoard()
+	if _, err := PublishPublicKey(board, tk); err != nil {
+		t.Fatal(err)
+	}
+
+	votes := [][]int{{1, 0, 1}, {0, 0, 1}, {1, 0, 0}, {1, 0, 1}}
+	var ballots []*Ballot
+	for _, choices := range votes {
+		ballot, err := NewBallot(&tk.PublicKey, choices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ballots = append(ballots, ballot)
+		if _, err := PublishBallot(board, ballot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tally := &ElectionTally{}
+	for _, ct := range TallyCiphertexts(&tk.PublicKey, ballots, 3) {
+		var pds []*PartialDecryptionZKP
+		for _, tsk := range tsks[1:] {
+			pd, err := tsk.PartialDecryptionWithZKP(ct.C)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pds = append(pds, pd)
+		}
+
+		count, err := tk.CombinePartialDecryptionsZKP(pds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tally.Counts = append(tally.Counts, count)
+		tally.PartialDecryptions = append(tally.PartialDecryptions, pds)
+	}
+	if _, err := PublishTally(board, tally); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := ReadElectionTranscript(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyElectionTranscript(tr); err != nil {
+		t.Fatal(err)
+	}
+	for j, expected := range []int64{3, 0, 3} {
+		if tr.Tally.Counts[j].Int64() != expected {
+			t.Error("wrong count ", tr.Tally.Counts[j], " for option ", j)
+		}
+	}
+
+	tr.Tally.Counts[1] = gmp.NewInt(1)
+	if err := VerifyElectionTranscript(tr); err == nil || !strings.Contains(err.Error(), "option 1") {
+		t.Error("expected error for wrong count, got ", err)
+	}
+	tr.Tally.Counts[1] = gmp.NewInt(0)
+
+	// a ballot that encrypts 2 for an option
+	invalid, err := NewBallot(&tk.PublicKey, []int{1, 0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid.Choices[0] = tk.Add(invalid.Choices[0], invalid.Choices[2])
+	tr.Ballots = append(tr.Ballots, invalid)
+	if err := VerifyElectionTranscript(tr); err == nil || !strings.Contains(err.Error(), "ballot 4, option 0") {
+		t.Error("expected error for invalid ballot, got ", err)
+	}
+
+	// a ballot that encrypts 5 for an option with a forged proof
+	forged, err := NewBallot(&tk.PublicKey, []int{0, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Choices[1] = tk.Encrypt(gmp.NewInt(5))
+	forged.Proofs[1] = forgeBinaryProof(&tk.PublicKey, forged.Choices[1])
+	tr.Ballots[4] = forged
+	err = VerifyElectionTranscript(tr)
+	if !errors.Is(err, ErrMalformedProof) || !strings.Contains(err.Error(), "ballot 4, option 1") {
+		t.Error("expected error for forged ballot, got ", err)
+	}
+
+	// a ballot removed after the tally was decrypted
+	tr.Ballots = tr.Ballots[:3]
+	if err := VerifyElectionTranscript(tr); err == nil {
+		t.Error("expected error for removed ballot")
+	}
+}
@@ -0,0 +1,49 @@
+package paillier
+
+// EncodedNumber is a plaintext mantissa with a base 16 exponent. It is the
+// representation of python-paillier (see python_interop.go), so real-valued
+// data encrypted here can be processed by either implementation.
+type EncodedNumber = PythonEncoding
+
+// EncryptedNumber is an encrypted mantissa with its exponent in the clear.
+// Use PythonAdd and PythonMul for exponent-aligning homomorphic operations.
+type EncryptedNumber = PythonEncryptedNumber
+
+// EncryptFloat64 encodes x exactly and encrypts it
+func (pk *PublicKey) EncryptFloat64(x float64) (*EncryptedNumber, error) {
+	e, err := pk.PythonEncodeFloat(x)
+	if err != nil {
+		return nil, err
+	}
+	return pk.PythonEncrypt(e), nil
+}
+
+// DecryptFloat64 decrypts x and returns its value rounded to a float
+func (sk *SecretKey) DecryptFloat64(x *EncryptedNumber) (float64, error) {
+	return sk.PythonDecodeFloat(sk.PythonDecrypt(x))
+}
+
+// AddFloat64 returns an encryption of x + k. Like python-paillier, k is
+// encoded with an exponent of at most that of x and added without encrypting
+// it, so the result has the randomness of x.
+func (pk *PublicKey) AddFloat64(x *EncryptedNumber, k float64) (*EncryptedNumber, error) {
+	e, err := pk.pythonEncodeFloat(k, x.Exponent)
+	if err != nil {
+		return nil, err
+	}
+
+	x = pk.pythonLowerExponent(x, e.Exponent)
+	return &EncryptedNumber{
+		Ciphertext: pk.EAddConstant(x.Ciphertext, ToBigInt(e.Encoding)),
+		Exponent:   x.Exponent,
+	}, nil
+}
+
+// MulFloat64 returns an encryption of x * k
+func (pk *PublicKey) MulFloat64(x *EncryptedNumber, k float64) (*EncryptedNumber, error) {
+	e, err := pk.PythonEncodeFloat(k)
+	if err != nil {
+		return nil, err
+	}
+	return pk.PythonMul(x, e), nil
+}
@@ -0,0 +1,63 @@
+package paillier
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncryptedNumberGradients(t *testing.T) {
+	sk, pk := KeyGen(256)
+
+	// aggregate real-valued gradients of several parties
+	gradients := []float64{0.125, -3.5, 1e-3, 42, -0.0625}
+	sum, err := pk.EncryptFloat64(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 0.0
+	for _, g := range gradients {
+		x, err := pk.EncryptFloat64(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum = pk.PythonAdd(sum, x)
+		expected += g
+	}
+
+	// average with a learning rate and add a bias term
+	scaled, err := pk.MulFloat64(sum, 0.5/float64(len(gradients)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shifted, err := pk.AddFloat64(scaled, 1.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = expected*0.5/float64(len(gradients)) + 1.25
+
+	value, err := sk.DecryptFloat64(shifted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(value-expected) > 1e-12 {
+		t.Error("wrong result ", value, " is not ", expected)
+	}
+}
+
+func TestAddFloat64LowersExponent(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	x, _ := pk.EncryptFloat64(2)
+	// 2 is encoded with a larger exponent than 0.001
+	y, err := pk.AddFloat64(x, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y.Exponent >= x.Exponent {
+		t.Error("exponent was not lowered")
+	}
+
+	if value, _ := sk.DecryptFloat64(y); value != 2.001 {
+		t.Error("wrong sum ", value)
+	}
+}
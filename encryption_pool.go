@@ -0,0 +1,130 @@
+package paillier
+
+import (
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// EncryptionPoolConfig configures an EncryptionPool
+type EncryptionPoolConfig struct {
+	// Size is the number of precomputed randomness terms the pool holds;
+	// zero selects 64
+	Size int
+
+	// Workers is the number of goroutines refilling the pool; zero selects one
+	Workers int
+
+	// Level is the level of the ciphertexts produced by the pool
+	Level EncryptionLevel
+
+	// WaitWhenEmpty makes Encrypt wait for a precomputed term when the pool
+	// is empty instead of computing one itself
+	WaitWhenEmpty bool
+}
+
+// EncryptionPool precomputes the randomness terms r^(N^s) mod N^(s+1) of
+// ciphertexts in background goroutines, so that encrypting only takes the
+// cheap g^m part, e.g., in request paths of latency-sensitive services. The
+// workers refill the pool as soon as terms are taken and block while it is
+// full. A term is handed out exactly once. An EncryptionPool is safe for
+// concurrent use; Close stops the workers.
+type EncryptionPool struct {
+	key    *PublicKey
+	config EncryptionPoolConfig
+
+	terms chan *gmp.Int
+	done  chan struct{}
+	once  sync.Once
+	wg    sync.WaitGroup
+}
+
+// NewEncryptionPool starts the workers of a pool for the key, which draw
+// their randomness from the random source of the key
+func (pk *PublicKey) NewEncryptionPool(config EncryptionPoolConfig) *EncryptionPool {
+	if config.Size <= 0 {
+		config.Size = 64
+	}
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+
+	pool := &EncryptionPool{
+		key:    pk,
+		config: config,
+		terms:  make(chan *gmp.Int, config.Size),
+		done:   make(chan struct{}),
+	}
+
+	pool.wg.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go pool.refill()
+	}
+
+	return pool
+}
+
+// Encrypt encrypts the plaintext with a precomputed randomness term. If the
+// pool is empty, the term is computed in place unless the pool was configured
+// to wait. A closed pool computes all terms in place.
+func (pool *EncryptionPool) Encrypt(m *gmp.Int) *Ciphertext {
+	var rn *gmp.Int
+	select {
+	case rn = <-pool.terms:
+	default:
+		if pool.config.WaitWhenEmpty {
+			select {
+			case rn = <-pool.terms:
+			case <-pool.done:
+			}
+		}
+	}
+
+	if rn == nil {
+		rn = pool.term()
+	}
+
+	defer startOperation(OpEncrypt)(true)
+	return pool.key.encryptWithRN(m, rn, pool.config.Level)
+}
+
+// Available returns the number of precomputed terms in the pool
+func (pool *EncryptionPool) Available() int {
+	return len(pool.terms)
+}
+
+// Close stops the workers and waits for them to exit
+func (pool *EncryptionPool) Close() {
+	pool.once.Do(func() { close(pool.done) })
+	pool.wg.Wait()
+}
+
+func (pool *EncryptionPool) refill() {
+	defer pool.wg.Done()
+
+	for {
+		rn := pool.term()
+		select {
+		case pool.terms <- rn:
+		case <-pool.done:
+			return
+		}
+	}
+}
+
+// term returns a fresh randomness term r^(N^s) mod N^(s+1)
+func (pool *EncryptionPool) term() *gmp.Int {
+	pk := pool.key
+
+	var r *gmp.Int
+	var err error
+	for {
+		r, err = GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err == nil {
+			break
+		}
+	}
+
+	_, ns, ns1 := pk.getModuliForLevel(pool.config.Level)
+	return new(gmp.Int).Exp(r, ns, ns1)
+}
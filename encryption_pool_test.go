@@ -0,0 +1,78 @@
+package paillier
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestEncryptionPool(t *testing.T) {
+	sk, pk := KeyGen(128)
+	pool := pk.NewEncryptionPool(EncryptionPoolConfig{Size: 4, Workers: 2})
+	defer pool.Close()
+
+	// the workers fill the pool up to its size
+	deadline := time.Now().Add(10 * time.Second)
+	for pool.Available() < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if pool.Available() != 4 {
+		t.Fatal("pool was not filled, available: ", pool.Available())
+	}
+
+	var wg sync.WaitGroup
+	cts := make([]*Ciphertext, 16)
+	for i := range cts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cts[i] = pool.Encrypt(b(i))
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for i, ct := range cts {
+		if m := sk.Decrypt(ct); n(m) != i {
+			t.Error("wrong decryption ", m, " is not ", i)
+		}
+		if seen[ct.C.String()] {
+			t.Error("randomness term was reused")
+		}
+		seen[ct.C.String()] = true
+	}
+}
+
+func TestEncryptionPoolLevelTwo(t *testing.T) {
+	sk, pk := KeyGen(64)
+	pool := pk.NewEncryptionPool(EncryptionPoolConfig{Level: EncLevelTwo, WaitWhenEmpty: true})
+
+	value := new(gmp.Int).Sub(pk.GetN2(), b(1))
+	ct := pool.Encrypt(value)
+	if ct.Level != EncLevelTwo {
+		t.Error("wrong level ", ct.Level)
+	}
+	if m := sk.Decrypt(ct); m.Cmp(value) != 0 {
+		t.Error("wrong decryption ", m)
+	}
+
+	// a closed pool still encrypts
+	pool.Close()
+	pool.Close()
+	if m := sk.Decrypt(pool.Encrypt(b(5))); n(m) != 5 {
+		t.Error("wrong decryption after close ", m)
+	}
+}
+
+func BenchmarkEncryptionPool(b *testing.B) {
+	_, pk := KeyGen(1024)
+	pool := pk.NewEncryptionPool(EncryptionPoolConfig{Size: 1024})
+	defer pool.Close()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pool.Encrypt(gmp.NewInt(100))
+	}
+}
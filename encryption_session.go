@@ -0,0 +1,71 @@
+package paillier
+
+import (
+	"io"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// EncryptionSession encrypts many messages under one key, e.g., in batch
+// jobs, drawing the randomness of the ciphertexts from a CTR_DRBG that is
+// seeded once per session instead of reading the random source of the key
+// for every ciphertext. The generator updates its state after every request,
+// so a compromise of the session state does not reveal the randomness of
+// earlier ciphertexts. An EncryptionSession is safe for concurrent use.
+type EncryptionSession struct {
+	key    *PublicKey
+	drbg   *CTRDRBG
+	source RandomSource // seeds the generator
+}
+
+// NewEncryptionSession seeds a session with 48 bytes of the random source of
+// the key
+func NewEncryptionSession(pk *PublicKey) (*EncryptionSession, error) {
+	source := pk.RandomSource()
+	entropy := make([]byte, ctrDRBGSeedLength)
+	if _, err := io.ReadFull(source, entropy); err != nil {
+		return nil, err
+	}
+
+	drbg, err := NewCTRDRBG(entropy, []byte("paillier encryption session"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptionSession{key: pk.WithRandomSource(drbg), drbg: drbg, source: source}, nil
+}
+
+// Key returns the key of the session, whose operations, e.g., proofs, also
+// draw their randomness from the session
+func (s *EncryptionSession) Key() *PublicKey {
+	return s.key
+}
+
+// Encrypt encrypts the plaintext at the default encryption level
+func (s *EncryptionSession) Encrypt(m *gmp.Int) *Ciphertext {
+	return s.key.Encrypt(m)
+}
+
+// EncryptAtLevel encrypts the plaintext at the specified level
+func (s *EncryptionSession) EncryptAtLevel(m *gmp.Int, level EncryptionLevel) *Ciphertext {
+	return s.key.EncryptAtLevel(m, level)
+}
+
+// EncryptBatch encrypts the plaintexts at the default encryption level
+func (s *EncryptionSession) EncryptBatch(ms []*gmp.Int) []*Ciphertext {
+	cts := make([]*Ciphertext, len(ms))
+	for i, m := range ms {
+		cts[i] = s.key.Encrypt(m)
+	}
+	return cts
+}
+
+// Rekey reseeds the session from the random source of the key, e.g., for
+// long-running sessions
+func (s *EncryptionSession) Rekey() error {
+	entropy := make([]byte, ctrDRBGSeedLength)
+	if _, err := io.ReadFull(s.source, entropy); err != nil {
+		return err
+	}
+	return s.drbg.Reseed(entropy, nil)
+}
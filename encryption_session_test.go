@@ -0,0 +1,57 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+type countingSource struct {
+	RandomSource
+	reads int
+}
+
+func (s *countingSource) Read(p []byte) (int, error) {
+	s.reads++
+	return s.RandomSource.Read(p)
+}
+
+func TestEncryptionSession(t *testing.T) {
+	sk, pk := KeyGen(128)
+	source := &countingSource{RandomSource: SystemRandom{}}
+	pk = pk.WithRandomSource(source)
+
+	session, err := NewEncryptionSession(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms := []*gmp.Int{b(0), b(1), b(42), b(42)}
+	cts := session.EncryptBatch(ms)
+	for i, ct := range cts {
+		if m := sk.Decrypt(ct); m.Cmp(ms[i]) != 0 {
+			t.Error("wrong decryption ", m)
+		}
+	}
+	if cts[2].C.Cmp(cts[3].C) == 0 {
+		t.Error("ciphertexts of the same plaintext are equal")
+	}
+
+	if ct := session.EncryptAtLevel(b(7), EncLevelTwo); sk.Decrypt(ct).Cmp(b(7)) != 0 {
+		t.Error("wrong decryption at level two")
+	}
+
+	if source.reads != 1 {
+		t.Error("random source read per ciphertext: ", source.reads)
+	}
+
+	if err := session.Rekey(); err != nil {
+		t.Fatal(err)
+	}
+	if source.reads != 2 {
+		t.Error("rekeying did not read the random source")
+	}
+	if m := sk.Decrypt(session.Encrypt(b(9))); m.Cmp(b(9)) != 0 {
+		t.Error("wrong decryption after rekeying ", m)
+	}
+}
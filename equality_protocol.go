@@ -0,0 +1,87 @@
+package paillier
+
+import (
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// EqualityRequest is sent by the tester to the key holder and contains the
+// blinded difference [r*(x-y)]
+type EqualityRequest struct {
+	Blinded *Ciphertext
+}
+
+// EqualityResponse is returned by the key holder. If the blinded difference
+// decrypts to zero, Randomness is s with Blinded = s^N mod N^2, which proves
+// that it encrypts zero.
+type EqualityResponse struct {
+	Equal      bool
+	Randomness *gmp.Int
+}
+
+// EqualityTester holds the state of the party that wants to learn whether
+// [x] and [y] encrypt the same value with the help of the key holder
+// (see SecretKey.AssistEqualityTest) without either party learning x or y.
+//
+// The protocol is the standard blinding based equality test:
+//  1. the tester sends [r*(x-y)] for a random unit r of Z_N
+//  2. the key holder decrypts it and reports whether it is zero, proving a
+//     zero by revealing the randomness of the ciphertext
+//  3. the tester checks the proof and outputs x = y iff the result is zero
+//
+// Since r is a unit, r*(x-y) is zero iff x = y mod N and otherwise uniformly
+// distributed over the units of Z_N for the key holder. Both parties learn
+// the result. The key holder cannot claim equality for different values but
+// can claim inequality for equal values undetected.
+type EqualityTester struct {
+	pk      *PublicKey
+	request *EqualityRequest
+}
+
+// NewEqualityTester blinds the difference of the ciphertexts x and y and
+// returns the tester state together with the request for the key holder
+func (pk *PublicKey) NewEqualityTester(x, y *Ciphertext) (*EqualityTester, *EqualityRequest, error) {
+	if x.Level != EncLevelOne || y.Level != EncLevelOne {
+		return nil, nil, errors.New("equality tests are only supported for level one ciphertexts")
+	}
+
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := &EqualityRequest{
+		Blinded: pk.Randomize(pk.ConstMult(pk.Sub(x, y), r)),
+	}
+
+	return &EqualityTester{pk: pk, request: req}, req, nil
+}
+
+// AssistEqualityTest is run by the key holder on a request of the tester.
+// The key holder only learns whether the values are equal.
+func (sk *SecretKey) AssistEqualityTest(req *EqualityRequest) (*EqualityResponse, error) {
+	if req.Blinded == nil || req.Blinded.Level != EncLevelOne {
+		return nil, errors.New("equality tests are only supported for level one ciphertexts")
+	}
+
+	if sk.Decrypt(req.Blinded).Sign() != 0 {
+		return &EqualityResponse{Equal: false}, nil
+	}
+
+	return &EqualityResponse{Equal: true, Randomness: sk.ExtractRandomness(req.Blinded)}, nil
+}
+
+// Finalize verifies the response of the key holder and returns true iff the
+// ciphertexts encrypt the same value
+func (et *EqualityTester) Finalize(resp *EqualityResponse) (bool, error) {
+	if !resp.Equal {
+		return false, nil
+	}
+
+	if resp.Randomness == nil || et.pk.EncryptWithR(ZeroBigInt, resp.Randomness).C.Cmp(et.request.Blinded.C) != 0 {
+		return false, errors.New("invalid proof of equality")
+	}
+
+	return true, nil
+}
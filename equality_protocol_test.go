@@ -0,0 +1,54 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestEqualityProtocol(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for _, values := range [][2]int64{{0, 0}, {5, 5}, {5, 6}, {6, 5}, {0, 1}} {
+		x := pk.Encrypt(gmp.NewInt(values[0]))
+		y := pk.Encrypt(gmp.NewInt(values[1]))
+
+		tester, req, err := pk.NewEqualityTester(x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := sk.AssistEqualityTest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		equal, err := tester.Finalize(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if equal != (values[0] == values[1]) {
+			t.Error("wrong equality result for ", values)
+		}
+	}
+}
+
+func TestEqualityProofSoundness(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	tester, req, err := pk.NewEqualityTester(pk.Encrypt(gmp.NewInt(3)), pk.Encrypt(gmp.NewInt(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cheating key holder claims equality for different values
+	cheat := &EqualityResponse{Equal: true, Randomness: sk.ExtractRandomness(req.Blinded)}
+	if _, err := tester.Finalize(cheat); err == nil {
+		t.Error("false claim of equality accepted")
+	}
+
+	if _, err := tester.Finalize(&EqualityResponse{Equal: true}); err == nil {
+		t.Error("claim of equality without proof accepted")
+	}
+}
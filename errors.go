@@ -0,0 +1,80 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors wrapped by combining, encryption and decryption; test for them with
+// errors.Is. ErrTooFewShares is usually retryable, e.g., once the shares of
+// more servers have arrived, while the others indicate invalid input or a
+// protocol violation.
+var (
+	// ErrTooFewShares -- fewer partial decryptions than the threshold were given
+	ErrTooFewShares = errors.New("too few partial decryptions")
+
+	// ErrDuplicateShareID -- two partial decryptions were created by the same server
+	ErrDuplicateShareID = errors.New("duplicate share ID")
+
+	// ErrInvalidProof -- the proof of a server was rejected, see InvalidProofError
+	ErrInvalidProof = errors.New("invalid proof")
+
+	// ErrMessageTooLarge -- the message does not fit into the plaintext space of the key
+	ErrMessageTooLarge = errors.New("message is too large")
+
+	// ErrInvalidCiphertext -- the ciphertext is missing or malformed
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+	// ErrInvalidKey -- the key is malformed, see ThresholdPublicKey.Validate
+	ErrInvalidKey = errors.New("invalid key")
+
+	// ErrKeyMismatch -- the partial decryption was produced under a different key
+	ErrKeyMismatch = errors.New("partial decryption was produced under a different key")
+
+	// ErrCiphertextKeyMismatch -- a SafeCiphertext was produced under a different key
+	ErrCiphertextKeyMismatch = errors.New("ciphertext was produced under a different key")
+
+	// ErrInexactDivision -- the divisor of EDivConstant did not divide the plaintext
+	ErrInexactDivision = errors.New("plaintext is not divisible by the constant")
+
+	// ErrPossibleOverflow -- the plaintext bound of a TrackedCiphertext reached N^s/2
+	ErrPossibleOverflow = errors.New("plaintext may overflow")
+
+	// ErrMalformedEncoding -- a strict parser rejected its input, see ParseError
+	ErrMalformedEncoding = errors.New("malformed encoding")
+
+	// ErrDiscardedShare -- a combiner left out a valid partial decryption, see CombinationStatement
+	ErrDiscardedShare = errors.New("valid partial decryption was discarded")
+)
+
+// InvalidProofError reports the server whose proof was rejected. Err is the
+// reason, e.g., ErrChallengeMismatch, and errors.Is matches ErrInvalidProof
+// as well as the reason.
+type InvalidProofError struct {
+	ID  int
+	Err error
+}
+
+func (e *InvalidProofError) Error() string {
+	return fmt.Sprintf("share %d: %v", e.ID, e.Err)
+}
+
+func (e *InvalidProofError) Unwrap() []error {
+	return []error{ErrInvalidProof, e.Err}
+}
+
+// ParseError reports the type a strict parser, e.g., ParseCiphertext, failed
+// to decode. Err is the reason and errors.Is matches ErrMalformedEncoding as
+// well as the reason, e.g., ErrInvalidKey.
+type ParseError struct {
+	Type string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parsing %s: %v", e.Type, e.Err)
+}
+
+func (e *ParseError) Unwrap() []error {
+	return []error{ErrMalformedEncoding, e.Err}
+}
@@ -0,0 +1,63 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestTypedErrors(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(5))
+
+	share := tsks[0].PartialDecrypt(ct.C)
+	if _, err := tk.CombinePartialDecryptions([]*PartialDecryption{share}); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+	if _, err := tk.CombinePartialDecryptions([]*PartialDecryption{share, share}); !errors.Is(err, ErrDuplicateShareID) {
+		t.Error("expected ErrDuplicateShareID, got ", err)
+	}
+
+	combiner := tk.NewCombiner(ct.C)
+	if _, err := combiner.Combine(); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares from the combiner, got ", err)
+	}
+	if err := combiner.Add(share); err != nil {
+		t.Fatal(err)
+	}
+	if err := combiner.Add(share); !errors.Is(err, ErrDuplicateShareID) {
+		t.Error("expected ErrDuplicateShareID from the combiner, got ", err)
+	}
+
+	pd, err := tsks[1].PartialDecryptionWithZKP(ct.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd.E = new(gmp.Int).Add(pd.E, OneBigInt)
+	err = pd.VerifyErr()
+	var proofErr *InvalidProofError
+	if !errors.As(err, &proofErr) || proofErr.ID != 2 {
+		t.Fatal("expected an InvalidProofError of server 2, got ", err)
+	}
+	if !errors.Is(err, ErrInvalidProof) || !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected ErrInvalidProof and ErrChallengeMismatch, got ", err)
+	}
+	if err.Error() != "share 2: proof challenge does not match" {
+		t.Error("unexpected message: ", err)
+	}
+
+	if _, err := tk.ProvePlaintextKnowledge(ct, tk.N, OneBigInt, nil); !errors.Is(err, ErrMessageTooLarge) {
+		t.Error("expected ErrMessageTooLarge, got ", err)
+	}
+}
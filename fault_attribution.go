@@ -0,0 +1,152 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// maxCrossCheckSubsets bounds the number of subsets of Threshold shares that
+// CombinePartialDecryptionsCrossChecked combines
+const maxCrossCheckSubsets = 1 << 12
+
+// ErrInvalidShares is wrapped by InvalidSharesError; test for it with errors.Is
+var ErrInvalidShares = errors.New("invalid partial decryptions")
+
+// InvalidSharesError reports the servers whose partial decryptions were
+// rejected. Errs[i] is the reason for IDs[i], e.g., an error wrapping
+// ErrChallengeMismatch, and errors.Is matches ErrInvalidShares as well as
+// any of the reasons.
+type InvalidSharesError struct {
+	IDs  []int
+	Errs []error
+}
+
+func (e *InvalidSharesError) Error() string {
+	ids := make([]string, len(e.IDs))
+	for i, id := range e.IDs {
+		ids[i] = fmt.Sprint(id)
+	}
+	return fmt.Sprintf("%v from servers %s", ErrInvalidShares, strings.Join(ids, ", "))
+}
+
+func (e *InvalidSharesError) Unwrap() []error {
+	return append([]error{ErrInvalidShares}, e.Errs...)
+}
+
+// CombinePartialDecryptionsCrossChecked combines partial decryptions without
+// proofs and identifies the servers whose shares are wrong. Given more than
+// Threshold shares, it combines every subset of Threshold shares: subsets of
+// correct shares all yield the plaintext, while a subset with a wrong share
+// yields an unrelated value. It returns the plaintext produced by the most
+// subsets and the IDs of the shares that are in none of them.
+//
+// This requires at least Threshold + 1 correct shares and, since colluding
+// servers can make the subsets of their shares agree, more correct shares
+// than wrong ones. The number of subsets must be at most 4096, e.g., 13
+// shares for a threshold of 6. It returns an error if no plaintext is
+// produced by more subsets than any other.
+func (tk *ThresholdPublicKey) CombinePartialDecryptionsCrossChecked(shares []*PartialDecryption) (*gmp.Int, []int, error) {
+	if err := tk.verifyPartialDecryptions(shares); err != nil {
+		return nil, nil, err
+	}
+	if len(shares) <= tk.Threshold {
+		return nil, nil, fmt.Errorf("cross-checking requires more than %d shares, got %d", tk.Threshold, len(shares))
+	}
+	if binomial(len(shares), tk.Threshold).Cmp(gmp.NewInt(maxCrossCheckSubsets)) > 0 {
+		return nil, nil, fmt.Errorf("cross-checking %d shares with threshold %d exceeds %d subsets",
+			len(shares), tk.Threshold, maxCrossCheckSubsets)
+	}
+
+	done := startOperation(OpCombine)
+
+	// plaintexts by their bytes, the number of subsets producing them and the
+	// IDs of the shares in these subsets
+	plaintexts := make(map[string]*gmp.Int)
+	counts := make(map[string]int)
+	supporters := make(map[string]map[int]bool)
+
+	subset := make([]*PartialDecryption, tk.Threshold)
+	forEachSubset(len(shares), tk.Threshold, func(indices []int) {
+		for i, index := range indices {
+			subset[i] = shares[index]
+		}
+		m := tk.combine(subset)
+		key := string(m.Bytes())
+		if plaintexts[key] == nil {
+			plaintexts[key] = m
+			supporters[key] = make(map[int]bool)
+		}
+		counts[key]++
+		for _, share := range subset {
+			supporters[key][share.ID] = true
+		}
+	})
+
+	best, unique := "", false
+	for key, count := range counts {
+		switch {
+		case best == "" || count > counts[best]:
+			best, unique = key, true
+		case count == counts[best]:
+			unique = false
+		}
+	}
+	if !unique || counts[best] < 2 {
+		done(false)
+		return nil, nil, errors.New("too many wrong shares to identify the plaintext")
+	}
+
+	var faulty []int
+	for _, share := range shares {
+		if !supporters[best][share.ID] {
+			faulty = append(faulty, share.ID)
+		}
+	}
+	sort.Ints(faulty)
+
+	done(true)
+	return plaintexts[best], faulty, nil
+}
+
+// combines the shares, whose number and IDs must have been checked
+func (tk *ThresholdPublicKey) combine(shares []*PartialDecryption) *gmp.Int {
+	return tk.computeDecryption(tk.combineShares(shares, tk.GetN2()))
+}
+
+// calls f with the indices of every subset of k elements of n in
+// lexicographic order; f must not modify the indices
+func forEachSubset(n, k int, f func(indices []int)) {
+	indices := make([]int, k)
+	for i := range indices {
+		indices[i] = i
+	}
+	for {
+		f(indices)
+
+		i := k - 1
+		for i >= 0 && indices[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		indices[i]++
+		for j := i + 1; j < k; j++ {
+			indices[j] = indices[j-1] + 1
+		}
+	}
+}
+
+// returns n choose k
+func binomial(n, k int) *gmp.Int {
+	ret := gmp.NewInt(1)
+	for i := 0; i < k; i++ {
+		ret.Mul(ret, gmp.NewInt(int64(n-i)))
+		ret.Quo(ret, gmp.NewInt(int64(i+1)))
+	}
+	return ret
+}
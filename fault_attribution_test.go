@@ -0,0 +1,152 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestCombinePartialDecryptionsZKPReportsInvalidShares(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 4, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(77))
+	pds := batchPartialDecryptions(t, tsks, []*Ciphertext{ct})
+
+	// one invalid share is skipped
+	pds[1].Decryption = new(gmp.Int).Add(pds[1].Decryption, OneBigInt)
+	m, err := tk.CombinePartialDecryptionsZKP(pds)
+	if err != nil || m.Cmp(gmp.NewInt(77)) != 0 {
+		t.Fatal("expected the plaintext from the valid shares, got ", m, err)
+	}
+
+	// with two invalid shares the threshold is not met
+	pds[3].E = new(gmp.Int).Add(pds[3].E, OneBigInt)
+	_, err = tk.CombinePartialDecryptionsZKP(pds)
+	var invalid *InvalidSharesError
+	if !errors.As(err, &invalid) {
+		t.Fatal("expected an InvalidSharesError, got ", err)
+	}
+	if !reflect.DeepEqual(invalid.IDs, []int{2, 4}) {
+		t.Error("expected servers 2 and 4, got ", invalid.IDs)
+	}
+	if !errors.Is(err, ErrInvalidShares) || !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("error does not wrap its reasons: ", err)
+	}
+}
+
+func TestCombinePartialDecryptionsCrossChecked(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(1234))
+
+	shares := make([]*PartialDecryption, len(tsks))
+	for i, tsk := range tsks {
+		shares[i] = tsk.PartialDecrypt(ct.C)
+	}
+
+	m, faulty, err := tk.CombinePartialDecryptionsCrossChecked(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Cmp(gmp.NewInt(1234)) != 0 || len(faulty) != 0 {
+		t.Error("expected the plaintext and no faulty shares, got ", m, faulty)
+	}
+
+	// replace the shares of servers 2 and 5 by random values; a common
+	// factor of all wrong shares would cancel in subsets of wrong shares only
+	corrupt := func(i int) {
+		d, err := GetRandomNumberInMultiplicativeGroup(tk.GetN2(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = &PartialDecryption{ID: shares[i].ID, Decryption: d}
+	}
+	corrupt(1)
+	corrupt(4)
+	m, faulty, err = tk.CombinePartialDecryptionsCrossChecked(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Cmp(gmp.NewInt(1234)) != 0 {
+		t.Error("wrong plaintext ", m)
+	}
+	if !reflect.DeepEqual(faulty, []int{2, 5}) {
+		t.Error("expected servers 2 and 5, got ", faulty)
+	}
+
+	// only two correct shares remain, which is a single correct subset
+	corrupt(0)
+	if _, _, err := tk.CombinePartialDecryptionsCrossChecked(shares); err == nil {
+		t.Error("expected an error without a majority of subsets")
+	}
+
+	if _, _, err := tk.CombinePartialDecryptionsCrossChecked(shares[:2]); err == nil {
+		t.Error("expected an error for threshold many shares")
+	}
+}
+
+func TestForEachSubset(t *testing.T) {
+	var subsets [][]int
+	forEachSubset(4, 2, func(indices []int) {
+		subsets = append(subsets, append([]int{}, indices...))
+	})
+	expected := [][]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}}
+	if !reflect.DeepEqual(subsets, expected) {
+		t.Error("wrong subsets ", subsets)
+	}
+	if binomial(4, 2).Int64() != 6 || binomial(13, 6).Int64() != 1716 {
+		t.Error("wrong binomial coefficient")
+	}
+}
+
+func TestCombinePartialDecryptionsZKPSelectsValidSubset(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(8))
+	pds := batchPartialDecryptions(t, tsks, []*Ciphertext{ct})
+
+	// an invalid share, a retransmitted share and more shares than needed
+	bad := *pds[0]
+	bad.Z = new(gmp.Int).Add(pds[0].Z, OneBigInt)
+	shares := []*PartialDecryptionZKP{&bad, pds[2], pds[2], pds[3], pds[4]}
+	m, err := tk.CombinePartialDecryptionsZKP(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Cmp(gmp.NewInt(8)) != 0 {
+		t.Error("wrong plaintext ", m)
+	}
+
+	// duplicate IDs are rejected when combining without proofs
+	dup := []*PartialDecryption{&pds[2].PartialDecryption, &pds[2].PartialDecryption}
+	if _, err := tk.CombinePartialDecryptions(dup); err == nil {
+		t.Error("expected an error for shares of the same server")
+	}
+}
@@ -0,0 +1,230 @@
+package paillier
+
+import (
+	"sync/atomic"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// ExpConfig tunes the exponentiations with fixed bases, e.g., with the
+// verification keys when verification tables are enabled
+type ExpConfig struct {
+	// Window is the window size in bits of the fixed-base tables. A table
+	// for b-bit exponents holds (2^Window-1)*b/Window residues and an
+	// exponentiation takes b/Window multiplications, so larger windows trade
+	// memory for speed. Zero selects a default by the size of the modulus.
+	Window int
+
+	// Plain disables the tables, so that all exponentiations use the
+	// sliding-window exponentiation of GMP, e.g., to save memory
+	Plain bool
+}
+
+// DefaultExpConfig is used by EnableVerificationTables
+var DefaultExpConfig ExpConfig
+
+// returns the window size for residues of modulusBits bits; the defaults keep
+// the table of V below about 16 MiB for N of up to 3072 bits
+func (c ExpConfig) window(modulusBits int) int {
+	switch {
+	case c.Window > 0:
+		return c.Window
+	case modulusBits <= 2048:
+		return 5
+	case modulusBits <= 4096:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// fixedBaseTable holds the powers base^(d * 2^(window*j)) mod modulus for all
+// digits 0 < d < 2^window and windows j of exponents up to bits bits. An
+// exponentiation then takes one multiplication per window and no squarings.
+type fixedBaseTable struct {
+	base    *gmp.Int
+	modulus *gmp.Int
+	window  int
+	bits    int
+	powers  [][]*gmp.Int // by window, then digit-1
+}
+
+func newFixedBaseTable(base, modulus *gmp.Int, bits, window int) *fixedBaseTable {
+	windows := (bits + window - 1) / window
+	table := &fixedBaseTable{
+		base:    base,
+		modulus: modulus,
+		window:  window,
+		bits:    windows * window,
+		powers:  make([][]*gmp.Int, windows),
+	}
+
+	g := new(gmp.Int).Mod(base, modulus) // base^(2^(window*j))
+	for j := range table.powers {
+		powers := make([]*gmp.Int, 1<<uint(window)-1)
+		powers[0] = new(gmp.Int).Set(g)
+		for d := 1; d < len(powers); d++ {
+			powers[d] = new(gmp.Int).Mul(powers[d-1], g)
+			powers[d].Mod(powers[d], modulus)
+		}
+		table.powers[j] = powers
+
+		g.Mul(powers[len(powers)-1], g)
+		g.Mod(g, modulus)
+	}
+
+	return table
+}
+
+// exp returns base^x mod modulus; exponents that do not fit the table are
+// computed with a plain exponentiation
+func (t *fixedBaseTable) exp(x *gmp.Int) *gmp.Int {
+	if x.Sign() < 0 || x.BitLen() > t.bits {
+		return new(gmp.Int).Exp(t.base, x, t.modulus)
+	}
+
+	result := gmp.NewInt(1)
+	for j, powers := range t.powers {
+		d := 0
+		for i := t.window - 1; i >= 0; i-- {
+			d = d<<1 | int(x.Bit(j*t.window+i))
+		}
+		if d > 0 {
+			result.Mul(result, powers[d-1])
+			result.Mod(result, t.modulus)
+		}
+	}
+	return result
+}
+
+// lazyTable is the fixedBaseTable analogue of lazyInt
+type lazyTable struct {
+	value atomic.Value
+}
+
+func (l *lazyTable) get(compute func() *fixedBaseTable) *fixedBaseTable {
+	if v, ok := l.value.Load().(*fixedBaseTable); ok {
+		return v
+	}
+
+	l.value.CompareAndSwap(nil, compute())
+	return l.value.Load().(*fixedBaseTable)
+}
+
+// verificationTables are the fixed-base tables of the bases V and V_i of the
+// partial decryption proofs; each table is built on its first use
+type verificationTables struct {
+	window int
+	v      lazyTable
+	vi     []lazyTable
+}
+
+// EnableVerificationTables makes the key precompute fixed-base tables for V
+// and the V_i when they are first used to verify a PartialDecryptionZKP,
+// which speeds up combiners that verify many proofs under the same key.
+// With DefaultExpConfig the table of V takes about 8 MiB for a 2048-bit N,
+// see EnableVerificationTablesWithConfig to tune it. Only proofs whose Key
+// is tk benefit, so combiners should set the Key of received proofs to their
+// own copy of the key as DecryptOverTransport does. The tables are safe for
+// concurrent use; this method must not be called concurrently with
+// verification.
+func (tk *ThresholdPublicKey) EnableVerificationTables() {
+	tk.EnableVerificationTablesWithConfig(DefaultExpConfig)
+}
+
+// EnableVerificationTablesWithConfig is EnableVerificationTables with the
+// window size of the tables chosen by config; config.Plain disables them
+func (tk *ThresholdPublicKey) EnableVerificationTablesWithConfig(config ExpConfig) {
+	if config.Plain {
+		tk.tables = nil
+		return
+	}
+
+	tk.tables = &verificationTables{
+		window: config.window(tk.GetN2().BitLen()),
+		vi:     make([]lazyTable, len(tk.VerificationKeys)),
+	}
+}
+
+// returns V^z mod N^2
+func (tk *ThresholdPublicKey) expV(z *gmp.Int) *gmp.Int {
+	if tk.tables == nil {
+		return new(gmp.Int).Exp(tk.VerificationKey, z, tk.GetN2())
+	}
+
+	// Z = r + e*delta*s with r < N^2 and s < N^S*m < N^(S+1)
+	table := tk.tables.v.get(func() *fixedBaseTable {
+		s := tk.S
+		if s < 1 {
+			s = 1
+		}
+		bits := (s+1)*tk.N.BitLen() + 256 + tk.delta().BitLen() + 1
+		return newFixedBaseTable(tk.VerificationKey, tk.GetN2(), bits, tk.tables.window)
+	})
+	return table.exp(z)
+}
+
+// returns V_id^e mod N^2
+func (tk *ThresholdPublicKey) expVi(id int, e *gmp.Int) *gmp.Int {
+	vi := tk.VerificationKeys[id-1]
+	if tk.tables == nil || id > len(tk.tables.vi) {
+		return new(gmp.Int).Exp(vi, e, tk.GetN2())
+	}
+
+	// challenges are SHA-256 digests
+	table := tk.tables.vi[id-1].get(func() *fixedBaseTable {
+		return newFixedBaseTable(vi, tk.GetN2(), 256, tk.tables.window)
+	})
+	return table.exp(e)
+}
+
+// encryptionTables are the fixed-base tables of the generator G, for keys
+// whose G is not N+1, and of the generator of the randomness of alternative
+// encryption at the first two levels; each table is built on its first use
+type encryptionTables struct {
+	window int
+	g      [2]lazyTable
+	h      [2]lazyTable
+}
+
+// EnableEncryptionTables makes the key precompute fixed-base tables for the
+// bases that encryption raises to fresh exponents: G, unless it is N+1 for
+// which no exponentiation is needed, and the generator of the randomness of
+// AltEncryptAtLevel. Only levels one and two use tables. The tables are safe
+// for concurrent use; this method must not be called concurrently with
+// encryption.
+func (pk *PublicKey) EnableEncryptionTables() {
+	pk.EnableEncryptionTablesWithConfig(DefaultExpConfig)
+}
+
+// EnableEncryptionTablesWithConfig is EnableEncryptionTables with the window
+// size of the tables chosen by config; config.Plain disables them
+func (pk *PublicKey) EnableEncryptionTablesWithConfig(config ExpConfig) {
+	if config.Plain {
+		pk.tables = nil
+		return
+	}
+
+	pk.tables = &encryptionTables{window: config.window(pk.GetN2().BitLen())}
+}
+
+// encryptionTable returns the table of the base G or, if randomness is set,
+// of the generator of the randomness at the level, or nil if there is none
+func (pk *PublicKey) encryptionTable(base *gmp.Int, level EncryptionLevel, randomness bool) *fixedBaseTable {
+	if pk.tables == nil || level < EncLevelOne || level > EncLevelTwo {
+		return nil
+	}
+
+	s, _, ns1 := pk.getModuliForLevel(level)
+	if randomness {
+		// the randomness is reduced mod K
+		return pk.tables.h[level].get(func() *fixedBaseTable {
+			return newFixedBaseTable(base, ns1, pk.K.BitLen(), pk.tables.window)
+		})
+	}
+
+	// plaintexts are below N^s
+	return pk.tables.g[level].get(func() *fixedBaseTable {
+		return newFixedBaseTable(base, ns1, s*pk.N.BitLen(), pk.tables.window)
+	})
+}
@@ -0,0 +1,149 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestFixedBaseTable(t *testing.T) {
+	modulus := gmp.NewInt(1000003 * 1000033)
+	base := gmp.NewInt(123457)
+	table := newFixedBaseTable(base, modulus, 70, 4)
+
+	for _, x := range []*gmp.Int{b(0), b(1), b(15), b(16), b(987654321), new(gmp.Int).Lsh(b(1), 71)} {
+		expected := new(gmp.Int).Exp(base, x, modulus)
+		if actual := table.exp(x); actual.Cmp(expected) != 0 {
+			t.Error("wrong power for exponent ", x, ": ", actual)
+		}
+	}
+}
+
+func TestExpConfigWindow(t *testing.T) {
+	if w := DefaultExpConfig.window(4096); w != 4 {
+		t.Error("unexpected default window for 4096 bits ", w)
+	}
+	if w := DefaultExpConfig.window(8192); w != 3 {
+		t.Error("unexpected default window for 8192 bits ", w)
+	}
+	if w := (ExpConfig{Window: 6}).window(4096); w != 6 {
+		t.Error("configured window ignored ", w)
+	}
+
+	key := getThresholdPrivateKey().PublicOnly()
+	key.EnableVerificationTablesWithConfig(ExpConfig{Window: 2})
+	if key.tables == nil || key.tables.window != 2 {
+		t.Error("configured window not used by the verification tables")
+	}
+	key.EnableVerificationTablesWithConfig(ExpConfig{Plain: true})
+	if key.tables != nil {
+		t.Error("plain exponentiation still uses tables")
+	}
+}
+
+func TestVerificationTables(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := tsks[0].PublicOnly()
+	key.EnableVerificationTables()
+
+	c := key.Encrypt(b(42)).C
+	var wg sync.WaitGroup
+	for _, tsk := range tsks {
+		pd, err := tsk.PartialDecryptionWithZKP(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pd.Key = key
+
+		wg.Add(2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer wg.Done()
+				if err := pd.VerifyErr(); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	pd, err := tsks[1].PartialDecryptionWithZKP(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd.Key = key
+	pd.Z.Add(pd.Z, b(1))
+	if pd.VerifyProof() {
+		t.Error("tampered proof accepted with verification tables")
+	}
+}
+
+func TestEncryptionTables(t *testing.T) {
+	sk, pk := KeyGen(64)
+	pk.EnableEncryptionTablesWithConfig(ExpConfig{Window: 3})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo, LevelForS(3)} {
+				ct := pk.AltEncryptAtLevel(b(i), level)
+				if m := sk.Decrypt(ct); n(m) != i {
+					t.Error("wrong decryption with encryption tables ", m)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// a generator other than N+1 is raised with its table
+	other := *pk
+	other.G = new(gmp.Int).Add(pk.G, pk.N)
+	other.EnableEncryptionTables()
+	for _, m := range []*gmp.Int{b(0), b(5), minusOne(pk.GetN2())} {
+		expected := new(gmp.Int).Exp(other.G, m, pk.GetN3())
+		if actual := other.gExp(m, EncLevelTwo); actual.Cmp(expected) != 0 {
+			t.Error("wrong power of G for ", m)
+		}
+	}
+
+	pk.EnableEncryptionTablesWithConfig(ExpConfig{Plain: true})
+	if pk.tables != nil {
+		t.Error("plain exponentiation still uses encryption tables")
+	}
+}
+
+func BenchmarkFixedBaseExp(b *testing.B) {
+	modulus, _ := rand.Prime(rand.Reader, 4096)
+	base, _ := rand.Int(rand.Reader, modulus)
+	x, _ := rand.Int(rand.Reader, modulus)
+	gmod, gbase, gx := ToGmpInt(modulus), ToGmpInt(base), ToGmpInt(x)
+
+	b.Run("plain", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			new(gmp.Int).Exp(gbase, gx, gmod)
+		}
+	})
+
+	for _, window := range []int{3, 4, 5} {
+		table := newFixedBaseTable(gbase, gmod, 4096, window)
+		b.Run(fmt.Sprintf("window=%d", window), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				table.exp(gx)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package paillier
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Keys embed each other (SecretKey embeds PublicKey, ThresholdSecretKey embeds
+// ThresholdPublicKey, ...) so every key type implements its own GobEncode and
+// GobDecode; otherwise the methods of the embedded key would be promoted and
+// silently drop the secret values. Ciphertext is encoded with its MarshalBinary
+// method (see binary.go) and types that only have exported fields, such as
+// PartialDecryption, are encoded natively by gob.
+
+// the protocol messages are registered so that they can be sent as interface
+// values, e.g., as elements of a []interface{} argument of a net/rpc call
+func init() {
+	gob.Register(&Ciphertext{})
+	gob.Register(&PartialDecryption{})
+	gob.Register(&PartialDecryptionZKP{})
+	gob.Register(&ThresholdMultiplicationRequest{})
+	gob.Register(&ThresholdMultiplicationResponse{})
+	gob.Register(&ZeroTestRequest{})
+	gob.Register(&ZeroTestBlinding{})
+	gob.Register(&ZeroTestResponse{})
+	gob.Register(&MultiRecipientCiphertext{})
+}
+
+// gobVersion is prepended to every encoding to permit backward compatible changes
+const gobVersion byte = 1
+
+type publicKeyGob struct {
+	N, G, H, K *gmp.Int
+}
+
+type secretKeyGob struct {
+	PublicKey         *PublicKey
+	Lambda, Lm, Mu, M *gmp.Int
+}
+
+type thresholdPublicKeyGob struct {
+	PublicKey                      *PublicKey
+	TotalNumberOfDecryptionServers int
+	Threshold                      int
+	VerificationKey                *gmp.Int
+	VerificationKeys               []*gmp.Int
+	S                              int
+}
+
+type thresholdSecretKeyGob struct {
+	ThresholdPublicKey *ThresholdPublicKey
+	ID                 int
+	Share              *gmp.Int
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (pk *PublicKey) GobEncode() ([]byte, error) {
+	return gobEncode(&publicKeyGob{pk.N, pk.G, pk.H, pk.K})
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (pk *PublicKey) GobDecode(data []byte) error {
+	var v publicKeyGob
+	if err := gobDecode(data, &v); err != nil {
+		return err
+	}
+
+	if v.N == nil {
+		return errors.New("public key is missing N")
+	}
+
+	*pk = PublicKey{N: v.N, G: v.G, H: v.H, K: v.K}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (sk *SecretKey) GobEncode() ([]byte, error) {
+	return gobEncode(&secretKeyGob{&sk.PublicKey, sk.Lambda, sk.Lm, sk.Mu, sk.m})
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (sk *SecretKey) GobDecode(data []byte) error {
+	var v secretKeyGob
+	if err := gobDecode(data, &v); err != nil {
+		return err
+	}
+
+	if v.PublicKey == nil || v.Lambda == nil {
+		return errors.New("secret key is missing the public key or Lambda")
+	}
+
+	*sk = SecretKey{PublicKey: *v.PublicKey, Lambda: v.Lambda, Lm: v.Lm, Mu: v.Mu, m: v.M}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (tk *ThresholdPublicKey) GobEncode() ([]byte, error) {
+	return gobEncode(&thresholdPublicKeyGob{
+		PublicKey:                      &tk.PublicKey,
+		TotalNumberOfDecryptionServers: tk.TotalNumberOfDecryptionServers,
+		Threshold:                      tk.Threshold,
+		VerificationKey:                tk.VerificationKey,
+		VerificationKeys:               tk.VerificationKeys,
+		S:                              tk.S,
+	})
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (tk *ThresholdPublicKey) GobDecode(data []byte) error {
+	var v thresholdPublicKeyGob
+	if err := gobDecode(data, &v); err != nil {
+		return err
+	}
+
+	if v.PublicKey == nil {
+		return errors.New("threshold key is missing the public key")
+	}
+
+	if v.Threshold < 1 || v.Threshold > v.TotalNumberOfDecryptionServers {
+		return errors.New("threshold must be between 1 and the total number of decryption servers")
+	}
+
+	if v.VerificationKeys != nil && len(v.VerificationKeys) != v.TotalNumberOfDecryptionServers {
+		return errors.New("number of verification keys does not match the number of decryption servers")
+	}
+
+	if err := checkDamgardJurikExponent(int64(v.S)); err != nil {
+		return err
+	}
+
+	*tk = ThresholdPublicKey{
+		PublicKey:                      *v.PublicKey,
+		TotalNumberOfDecryptionServers: v.TotalNumberOfDecryptionServers,
+		Threshold:                      v.Threshold,
+		VerificationKey:                v.VerificationKey,
+		VerificationKeys:               v.VerificationKeys,
+		S:                              v.S,
+	}
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface
+func (tsk *ThresholdSecretKey) GobEncode() ([]byte, error) {
+	return gobEncode(&thresholdSecretKeyGob{&tsk.ThresholdPublicKey, tsk.ID, tsk.Share})
+}
+
+// GobDecode implements the gob.GobDecoder interface
+func (tsk *ThresholdSecretKey) GobDecode(data []byte) error {
+	var v thresholdSecretKeyGob
+	if err := gobDecode(data, &v); err != nil {
+		return err
+	}
+
+	if v.ThresholdPublicKey == nil || v.Share == nil {
+		return errors.New("threshold secret key is missing the public key or share")
+	}
+
+	if v.ID < 1 || v.ID > v.ThresholdPublicKey.TotalNumberOfDecryptionServers {
+		return errors.New("share ID is out of range")
+	}
+
+	*tsk = ThresholdSecretKey{ThresholdPublicKey: *v.ThresholdPublicKey, ID: v.ID, Share: v.Share}
+	return nil
+}
+
+func gobEncode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(gobVersion)
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobDecode(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return errors.New("no data to decode")
+	}
+
+	if data[0] != gobVersion {
+		return errors.New("unsupported encoding version")
+	}
+
+	return gob.NewDecoder(bytes.NewReader(data[1:])).Decode(v)
+}
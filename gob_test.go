@@ -0,0 +1,161 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/gob"
+	"testing"
+)
+
+func gobRoundTrip(t *testing.T, in, out interface{}) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	if err := gob.NewDecoder(&buf).Decode(out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGobSecretKey(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	decodedPk := new(PublicKey)
+	gobRoundTrip(t, pk, decodedPk)
+
+	decodedSk := new(SecretKey)
+	gobRoundTrip(t, sk, decodedSk)
+
+	if decodedSk.Lambda.Cmp(sk.Lambda) != 0 || decodedSk.m.Cmp(sk.m) != 0 {
+		t.Fatal("secret values were not encoded")
+	}
+
+	ct := decodedPk.Encrypt(b(42))
+	decodedCt := new(Ciphertext)
+	gobRoundTrip(t, ct, decodedCt)
+
+	if m := decodedSk.Decrypt(decodedCt); n(m) != 42 {
+		t.Error("wrong decryption ", m, " is not 42")
+	}
+}
+
+func TestGobThresholdKeys(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := make([]*ThresholdSecretKey, len(tpks))
+	for i, tpk := range tpks {
+		decoded[i] = new(ThresholdSecretKey)
+		gobRoundTrip(t, tpk, decoded[i])
+
+		if decoded[i].ID != tpk.ID || decoded[i].Share.Cmp(tpk.Share) != 0 {
+			t.Fatal("share was not encoded")
+		}
+	}
+
+	tk := new(ThresholdPublicKey)
+	gobRoundTrip(t, &tpks[0].ThresholdPublicKey, tk)
+
+	ct := tk.Encrypt(b(7))
+	share1, err := decoded[0].PartialDecryptionWithZKP(ct.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share2, err := decoded[2].PartialDecryptionWithZKP(ct.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decodedShare := new(PartialDecryptionZKP)
+	gobRoundTrip(t, share2, decodedShare)
+
+	m, err := tk.CombinePartialDecryptionsZKP([]*PartialDecryptionZKP{share1, decodedShare})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 7 {
+		t.Error("wrong decryption ", m, " is not 7")
+	}
+}
+
+func TestGobDecodeInvalid(t *testing.T) {
+	if err := new(PublicKey).GobDecode(nil); err == nil {
+		t.Error("expected error for empty encoding")
+	}
+
+	_, pk := KeyGen(64)
+	data, err := pk.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data[0]++
+	if err := new(PublicKey).GobDecode(data); err == nil {
+		t.Error("expected error for unknown encoding version")
+	}
+
+	data[0]--
+	if err := new(PublicKey).GobDecode(data[:len(data)/2]); err == nil {
+		t.Error("expected error for truncated encoding")
+	}
+}
+
+func TestGobProtocolTypes(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	nested := tk.NestedEncrypt(b(3))
+	nested.EncMethod = MixedEncryption
+	ct := tk.Encrypt(b(9))
+	pd := tsks[1].PartialDecrypt(ct.C)
+	zkp, err := tsks[2].PartialDecryptionWithSession(ct.C, []byte("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the values are sent as interface values, which requires registration
+	var decoded []interface{}
+	gobRoundTrip(t, []interface{}{nested, pd, zkp}, &decoded)
+	if len(decoded) != 3 {
+		t.Fatal("wrong number of values ", len(decoded))
+	}
+
+	decodedCt, ok := decoded[0].(*Ciphertext)
+	if !ok || decodedCt.C.Cmp(nested.C) != 0 || decodedCt.Level != EncLevelTwo || decodedCt.EncMethod != MixedEncryption {
+		t.Error("ciphertext does not match ", decoded[0])
+	}
+
+	decodedPd, ok := decoded[1].(*PartialDecryption)
+	if !ok || decodedPd.ID != pd.ID || decodedPd.Decryption.Cmp(pd.Decryption) != 0 {
+		t.Error("partial decryption does not match ", decoded[1])
+	}
+
+	decodedZkp, ok := decoded[2].(*PartialDecryptionZKP)
+	if !ok {
+		t.Fatalf("decoded %T instead of a proof", decoded[2])
+	}
+	if decodedZkp.ID != zkp.ID || decodedZkp.Decryption.Cmp(zkp.Decryption) != 0 ||
+		decodedZkp.C.Cmp(zkp.C) != 0 || decodedZkp.E.Cmp(zkp.E) != 0 || decodedZkp.Z.Cmp(zkp.Z) != 0 ||
+		!bytes.Equal(decodedZkp.Session, zkp.Session) {
+		t.Error("proof does not match")
+	}
+	if err := decodedZkp.VerifyErrWithKey(tk); err != nil {
+		t.Error(err)
+	}
+}
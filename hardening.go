@@ -0,0 +1,90 @@
+package paillier
+
+import (
+	"crypto/subtle"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// The exponentiations of GMP take time that depends on the exponent. In
+// hardened mode, the exponents derived from secrets are randomized for every
+// operation, so that the timing of decryptions of attacker-supplied
+// ciphertexts does not correlate with a fixed secret exponent:
+//
+//   - SecretKey.Decrypt adds a random 64-bit multiple of the group order to
+//     Lambda, or to p-1 and q-1 when decrypting with the CRT.
+//   - ThresholdSecretKey partial decryptions split the exponent e into a
+//     random e1 < e and e - e1, since the servers do not know the group
+//     order.
+//
+// VerifyDecryption compares the decrypted values with constantTimeEqual in
+// every mode.
+
+// hardeningBlindingBits is the size of the random multiplier of the group
+// order added to secret exponents
+const hardeningBlindingBits = 64
+
+// EnableHardening makes decryptions blind the secret exponents, at the cost
+// of exponents that are 64 bits longer. See hardening.go.
+func (sk *SecretKey) EnableHardening() {
+	sk.hardened = true
+}
+
+// EnableHardening makes partial decryptions split the secret exponent, which
+// doubles their cost. See hardening.go.
+func (tsk *ThresholdSecretKey) EnableHardening() {
+	tsk.hardened = true
+}
+
+// blindExponent returns e + k*order for a random k of hardeningBlindingBits
+// bits if the key is hardened and e otherwise
+func (sk *SecretKey) blindExponent(e *gmp.Int, order func() *gmp.Int) *gmp.Int {
+	if !sk.hardened {
+		return e
+	}
+
+	k := randomBelow(new(gmp.Int).Lsh(OneBigInt, hardeningBlindingBits), sk.RandomSource())
+	k.Mul(k, order())
+	return k.Add(k, e)
+}
+
+// splitExp returns c^e mod m as c^e1 * c^(e-e1) mod m for a random e1 < e
+func (tsk *ThresholdSecretKey) splitExp(c, e, m *gmp.Int) *gmp.Int {
+	if e.Sign() <= 0 {
+		return new(gmp.Int).Exp(c, e, m)
+	}
+
+	e1 := randomBelow(e, tsk.RandomSource())
+	e2 := new(gmp.Int).Sub(e, e1)
+
+	result := new(gmp.Int).Exp(c, e1, m)
+	result.Mul(result, new(gmp.Int).Exp(c, e2, m))
+	return result.Mod(result, m)
+}
+
+// randomBelow returns a random number in [0, n), retrying on errors of the
+// random source like EncryptAtLevel
+func randomBelow(n *gmp.Int, random RandomSource) *gmp.Int {
+	for {
+		r, err := GetRandomNumber(n, random)
+		if err == nil {
+			return r
+		}
+	}
+}
+
+// constantTimeEqual reports whether x and y are equal in time that only
+// depends on the length of their encodings
+func constantTimeEqual(x, y *gmp.Int) bool {
+	xb, yb := x.Bytes(), y.Bytes()
+	size := len(xb)
+	if len(yb) > size {
+		size = len(yb)
+	}
+
+	xp := make([]byte, size)
+	yp := make([]byte, size)
+	copy(xp[size-len(xb):], xb)
+	copy(yp[size-len(yb):], yb)
+	return subtle.ConstantTimeCompare(xp, yp) == 1 && x.Sign() == y.Sign()
+}
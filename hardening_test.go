@@ -0,0 +1,73 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestHardenedDecrypt(t *testing.T) {
+	sk, pk := KeyGen(128)
+	sk.EnableHardening()
+
+	p, q := sk.factorWithPhi()
+	noCRT := &SecretKey{PublicKey: *pk, Lambda: lcm(minusOne(p), minusOne(q))}
+	noCRT.EnableHardening()
+
+	for _, key := range []*SecretKey{sk, noCRT} {
+		for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+			ct := pk.EncryptAtLevel(b(31337), level)
+			for i := 0; i < 3; i++ {
+				if m := key.Decrypt(ct); n(m) != 31337 {
+					t.Error("wrong hardened decryption ", m)
+				}
+			}
+		}
+	}
+}
+
+func TestHardenedPartialDecrypt(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := tsks[0].Encrypt(b(77)).C
+	expected := tsks[1].PartialDecrypt(c)
+	tsks[1].EnableHardening()
+	if pd := tsks[1].PartialDecrypt(c); pd.Decryption.Cmp(expected.Decryption) != 0 {
+		t.Error("hardened partial decryption differs")
+	}
+
+	pd, err := tsks[1].PartialDecryptionWithZKP(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pd.VerifyProof() {
+		t.Error("proof of a hardened partial decryption does not verify")
+	}
+}
+
+func TestConstantTimeEqual(t *testing.T) {
+	cases := []struct {
+		x, y  *gmp.Int
+		equal bool
+	}{
+		{b(0), b(0), true},
+		{b(258), b(258), true},
+		{b(258), b(2), false},
+		{b(1), b(-1), false},
+		{b(0), new(gmp.Int), true},
+	}
+	for _, c := range cases {
+		if constantTimeEqual(c.x, c.y) != c.equal {
+			t.Error("wrong comparison of ", c.x, " and ", c.y)
+		}
+	}
+}
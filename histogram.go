@@ -0,0 +1,219 @@
+package paillier
+
+import (
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Histogram maps a fixed set of categories to encrypted counters under
+// a threshold public key. Contributors submit updates that encrypt a one-hot
+// vector over the categories along with proofs that the update is valid,
+// and the committee jointly decrypts the final counts.
+type Histogram struct {
+	Key        *ThresholdPublicKey
+	Categories []string
+	Counters   []*Ciphertext // Counters[i] is the count of Categories[i]
+
+	index map[string]int
+}
+
+// HistogramUpdate increments exactly one counter of a histogram.
+// Counters[i] encrypts 1 for the chosen category and 0 otherwise, each with
+// a proof that it encrypts a bit. SumRandomness is the randomness of the
+// product of all counters, which shows that exactly one counter is set.
+type HistogramUpdate struct {
+	Counters      []*Ciphertext
+	Proofs        []*BinaryProof
+	SumRandomness *gmp.Int
+}
+
+// NewHistogram creates a histogram with all counters set to an encryption of 0
+func NewHistogram(key *ThresholdPublicKey, categories []string) (*Histogram, error) {
+	if len(categories) == 0 {
+		return nil, errors.New("histogram must have at least one category")
+	}
+
+	index := make(map[string]int, len(categories))
+	for i, category := range categories {
+		if _, ok := index[category]; ok {
+			return nil, errors.New("duplicate category " + category)
+		}
+		index[category] = i
+	}
+
+	counters := make([]*Ciphertext, len(categories))
+	for i := range counters {
+		counters[i] = key.EncryptZero()
+	}
+
+	return &Histogram{
+		Key:        key,
+		Categories: append([]string{}, categories...),
+		Counters:   counters,
+		index:      index,
+	}, nil
+}
+
+// NewUpdate is run by a contributor to create a proven update of the
+// counter of the category
+func (h *Histogram) NewUpdate(category string) (*HistogramUpdate, error) {
+	i, ok := h.categoryIndex(category)
+	if !ok {
+		return nil, errors.New("unknown category " + category)
+	}
+
+	pk := &h.Key.PublicKey
+	update := &HistogramUpdate{
+		Counters:      make([]*Ciphertext, len(h.Categories)),
+		Proofs:        make([]*BinaryProof, len(h.Categories)),
+		SumRandomness: gmp.NewInt(1),
+	}
+
+	for j := range h.Categories {
+		bit := 0
+		if j == i {
+			bit = 1
+		}
+
+		r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+
+		update.Counters[j] = pk.EncryptWithR(gmp.NewInt(int64(bit)), r)
+		update.Proofs[j], err = pk.ProveBinary(update.Counters[j], bit, r)
+		if err != nil {
+			return nil, err
+		}
+
+		update.SumRandomness.Mul(update.SumRandomness, r)
+		update.SumRandomness.Mod(update.SumRandomness, pk.N)
+	}
+
+	return update, nil
+}
+
+// VerifyUpdate returns an error if the update does not increment exactly
+// one counter by one
+func (h *Histogram) VerifyUpdate(update *HistogramUpdate) error {
+	if len(update.Counters) != len(h.Categories) || len(update.Proofs) != len(h.Categories) {
+		return errors.New("update does not match the histogram categories")
+	}
+
+	pk := &h.Key.PublicKey
+	for j, ct := range update.Counters {
+		if !pk.VerifyBinaryProof(ct, update.Proofs[j]) {
+			return errors.New("invalid proof for category " + h.Categories[j])
+		}
+	}
+
+	if update.SumRandomness == nil {
+		return errors.New("missing sum randomness")
+	}
+
+	// prod c_j = g^1 * R^N mod N^2
+	sum := pk.Add(update.Counters...)
+	if sum.C.Cmp(pk.EncryptWithR(OneBigInt, update.SumRandomness).C) != 0 {
+		return errors.New("update does not increment exactly one counter")
+	}
+
+	return nil
+}
+
+// Apply verifies the update and adds it to the counters
+func (h *Histogram) Apply(update *HistogramUpdate) error {
+	if err := h.VerifyUpdate(update); err != nil {
+		return err
+	}
+
+	for j, ct := range update.Counters {
+		h.Counters[j] = h.Key.Add(h.Counters[j], ct)
+	}
+
+	return nil
+}
+
+// Add increments the counter of the category
+func (h *Histogram) Add(category string) error {
+	update, err := h.NewUpdate(category)
+	if err != nil {
+		return err
+	}
+	return h.Apply(update)
+}
+
+// Merge adds the counters of another histogram over the same key and categories
+func (h *Histogram) Merge(other *Histogram) error {
+	if h.Key.N.Cmp(other.Key.N) != 0 {
+		return errors.New("histograms are encrypted under different keys")
+	}
+
+	if len(h.Categories) != len(other.Categories) {
+		return errors.New("histograms have different categories")
+	}
+	for i, category := range h.Categories {
+		if other.Categories[i] != category {
+			return errors.New("histograms have different categories")
+		}
+	}
+
+	for i, ct := range other.Counters {
+		h.Counters[i] = h.Key.Add(h.Counters[i], ct)
+	}
+
+	return nil
+}
+
+// PartialDecryptHistogram is run by each decryption server to produce
+// the proven partial decryptions of all counters of the histogram
+func (tsk *ThresholdSecretKey) PartialDecryptHistogram(h *Histogram) ([]*PartialDecryptionZKP, error) {
+	shares := make([]*PartialDecryptionZKP, len(h.Counters))
+	for i, ct := range h.Counters {
+		var err error
+		shares[i], err = tsk.PartialDecryptionWithZKP(ct.C)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shares, nil
+}
+
+// Finalize combines the partial decryptions of the servers (as returned by
+// PartialDecryptHistogram) and returns the count of each category
+func (h *Histogram) Finalize(serverShares [][]*PartialDecryptionZKP) (map[string]*gmp.Int, error) {
+	counts := make(map[string]*gmp.Int, len(h.Categories))
+
+	for i, category := range h.Categories {
+		shares := make([]*PartialDecryptionZKP, 0, len(serverShares))
+		for _, server := range serverShares {
+			if len(server) != len(h.Counters) {
+				return nil, errors.New("partial decryptions do not match the histogram")
+			}
+			if server[i].C.Cmp(h.Counters[i].C) != 0 {
+				return nil, errors.New("partial decryption is not for the counter of " + category)
+			}
+			shares = append(shares, server[i])
+		}
+
+		count, err := h.Key.CombinePartialDecryptionsZKP(shares)
+		if err != nil {
+			return nil, err
+		}
+		counts[category] = count
+	}
+
+	return counts, nil
+}
+
+func (h *Histogram) categoryIndex(category string) (int, bool) {
+	if h.index == nil {
+		h.index = make(map[string]int, len(h.Categories))
+		for i, c := range h.Categories {
+			h.index[c] = i
+		}
+	}
+
+	i, ok := h.index[category]
+	return i, ok
+}
@@ -0,0 +1,140 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func getHistogramKeys(t *testing.T) []*ThresholdSecretKey {
+	tkh, err := NewThresholdKeyGenerator(128, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tpks
+}
+
+func TestHistogram(t *testing.T) {
+	tpks := getHistogramKeys(t)
+	categories := []string{"red", "green", "blue"}
+
+	h1, err := NewHistogram(&tpks[0].ThresholdPublicKey, categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the public key of the committee has no G
+	h2, err := NewHistogram(tpks[1].PublicKey(), categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []string{"red", "red", "blue"} {
+		if err := h1.Add(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []string{"green", "red"} {
+		if err := h2.Add(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := h1.Add("yellow"); err == nil {
+		t.Error("expected error for unknown category")
+	}
+
+	if err := h1.Merge(h2); err != nil {
+		t.Fatal(err)
+	}
+
+	shares1, err := tpks[0].PartialDecryptHistogram(h1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares2, err := tpks[2].PartialDecryptHistogram(h1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := h1.Finalize([][]*PartialDecryptionZKP{shares1, shares2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{"red": 3, "green": 1, "blue": 1}
+	for c, count := range expected {
+		if n(counts[c]) != count {
+			t.Errorf("wrong count for %v: %v is not %v", c, counts[c], count)
+		}
+	}
+}
+
+func TestHistogramInvalidUpdates(t *testing.T) {
+	tpks := getHistogramKeys(t)
+	h, err := NewHistogram(&tpks[0].ThresholdPublicKey, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update1, err := h.NewUpdate("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	update2, err := h.NewUpdate("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each counter is a valid bit but two counters are set
+	cheat := &HistogramUpdate{
+		Counters:      []*Ciphertext{update1.Counters[0], update2.Counters[1]},
+		Proofs:        []*BinaryProof{update1.Proofs[0], update2.Proofs[1]},
+		SumRandomness: update1.SumRandomness,
+	}
+	if err := h.Apply(cheat); err == nil {
+		t.Error("accepted update that increments two counters")
+	}
+
+	// counter that encrypts 2
+	cheat = &HistogramUpdate{
+		Counters:      []*Ciphertext{h.Key.Add(update1.Counters[0], update1.Counters[0]), update1.Counters[1]},
+		Proofs:        update1.Proofs,
+		SumRandomness: update1.SumRandomness,
+	}
+	if err := h.Apply(cheat); err == nil {
+		t.Error("accepted update with a non-binary counter")
+	}
+
+	// counters 5 and -4 add up to one, so only the forged proofs stand in
+	// the way of the update
+	pk := &h.Key.PublicKey
+	r1, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+	r2, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+	counters := []*Ciphertext{
+		pk.EncryptWithR(b(5), r1),
+		pk.EncryptWithR(new(gmp.Int).Sub(pk.N, b(4)), r2),
+	}
+	cheat = &HistogramUpdate{
+		Counters:      counters,
+		Proofs:        []*BinaryProof{forgeBinaryProof(pk, counters[0]), forgeBinaryProof(pk, counters[1])},
+		SumRandomness: new(gmp.Int).Mod(new(gmp.Int).Mul(r1, r2), pk.N),
+	}
+	if err := h.Apply(cheat); err == nil {
+		t.Error("accepted update with out of range counters and forged proofs")
+	}
+
+	if err := h.Apply(update1); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := NewHistogram(&tpks[0].ThresholdPublicKey, []string{"a", "a"}); err == nil {
+		t.Error("expected error for duplicate categories")
+	}
+}
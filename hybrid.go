@@ -0,0 +1,91 @@
+package paillier
+
+import (
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Sealed payloads are encoded as
+//
+//	version || C || AES-256-GCM(payload)
+//
+// where C is the KEM encapsulation, see Encapsulate, left-padded to the byte
+// length of N^2. The AES key and the nonce are both derived from the
+// encapsulated key, which is fresh for every payload, and the version and C
+// are authenticated together with the additional data.
+
+// sealVersion is the first byte of every sealed payload
+const sealVersion byte = 1
+
+const (
+	sealKeyLength   = 32
+	sealNonceLength = 12
+)
+
+// SealBytes encrypts an arbitrary byte payload under the public key, e.g., to
+// protect metadata alongside homomorphic values. A random AES-256 key is
+// encapsulated under Paillier and the payload is encrypted with AES-GCM. The
+// additional data is authenticated but not encrypted and must be passed to
+// OpenBytes unchanged; it may be nil.
+func (pk *PublicKey) SealBytes(payload, additionalData []byte) ([]byte, error) {
+	key, encapsulation, err := pk.Encapsulate(sealKeyLength + sealNonceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 1+pk.sealedCiphertextSize())
+	header[0] = sealVersion
+	c := encapsulation.C.Bytes()
+	copy(header[len(header)-len(c):], c)
+
+	aead, err := newChannelAEAD(key[:sealKeyLength])
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(header, key[sealKeyLength:], payload, sealAdditionalData(header, additionalData)), nil
+}
+
+// OpenBytes decrypts a payload sealed by SealBytes with the same additional data
+func (sk *SecretKey) OpenBytes(sealed, additionalData []byte) ([]byte, error) {
+	size := sk.sealedCiphertextSize()
+	if len(sealed) < 1+size {
+		return nil, errors.New("sealed payload is too short")
+	}
+	if sealed[0] != sealVersion {
+		return nil, errors.New("unsupported encoding version")
+	}
+
+	header := sealed[:1+size]
+	c := new(gmp.Int).SetBytes(header[1:])
+	if c.Sign() <= 0 || c.Cmp(sk.GetN2()) >= 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	key, err := sk.Decapsulate(&Ciphertext{C: c, Level: EncLevelOne}, sealKeyLength+sealNonceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newChannelAEAD(key[:sealKeyLength])
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := aead.Open(nil, key[sealKeyLength:], sealed[1+size:], sealAdditionalData(header, additionalData))
+	if err != nil {
+		return nil, errors.New("wrong key or corrupted payload")
+	}
+	return payload, nil
+}
+
+// sealedCiphertextSize returns the byte length of the encapsulation in a
+// sealed payload
+func (pk *PublicKey) sealedCiphertextSize() int {
+	return (pk.GetN2().BitLen() + 7) / 8
+}
+
+func sealAdditionalData(header, additionalData []byte) []byte {
+	return append(append([]byte{}, header...), additionalData...)
+}
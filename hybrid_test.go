@@ -0,0 +1,50 @@
+package paillier
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealOpenBytes(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	payloads := [][]byte{nil, []byte("metadata"), bytes.Repeat([]byte{0xab}, 1000)}
+	for _, payload := range payloads {
+		sealed, err := pk.SealBytes(payload, []byte("context"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opened, err := sk.OpenBytes(sealed, []byte("context"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(opened, payload) {
+			t.Error("opened payload does not match the sealed payload")
+		}
+	}
+
+	sealed, err := pk.SealBytes([]byte("metadata"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sk.OpenBytes(sealed, []byte("context")); err == nil {
+		t.Error("expected error for wrong additional data")
+	}
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := sk.OpenBytes(tampered, nil); err == nil {
+		t.Error("expected error for tampered payload")
+	}
+
+	other, _ := KeyGen(128)
+	if _, err := other.OpenBytes(sealed, nil); err == nil {
+		t.Error("expected error for wrong key")
+	}
+
+	if _, err := sk.OpenBytes(sealed[:10], nil); err == nil {
+		t.Error("expected error for truncated payload")
+	}
+}
@@ -0,0 +1,32 @@
+package paillier
+
+import (
+	gmp "github.com/ncw/gmp"
+)
+
+// Encrypter is implemented by all key types that can encrypt plaintexts.
+// It is satisfied by *PublicKey, *SecretKey, *ThresholdPublicKey,
+// *ThresholdSecretKey and *ThresholdClient.
+type Encrypter interface {
+	Encrypt(m *gmp.Int) *Ciphertext
+}
+
+// Decrypter is implemented by all key types that can recover plaintexts.
+// It is satisfied by *SecretKey and *ThresholdClient so that applications
+// can switch between single-key and committee-backed decryption. Since a
+// committee may fail to answer, decryption returns an error instead of
+// panicking.
+type Decrypter interface {
+	TryDecrypt(ct *Ciphertext) (*gmp.Int, error)
+}
+
+var (
+	_ Encrypter = (*PublicKey)(nil)
+	_ Encrypter = (*SecretKey)(nil)
+	_ Encrypter = (*ThresholdPublicKey)(nil)
+	_ Encrypter = (*ThresholdSecretKey)(nil)
+	_ Encrypter = (*ThresholdClient)(nil)
+
+	_ Decrypter = (*SecretKey)(nil)
+	_ Decrypter = (*ThresholdClient)(nil)
+)
@@ -0,0 +1,279 @@
+package paillier
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// The JSON encodings represent integers as unpadded base64url strings of their
+// big-endian magnitude; nil is the empty string and the field is omitted.
+// Like with gob, ThresholdSecretKey and PartialDecryptionZKP embed types that
+// implement json.Marshaler, so they implement it themselves as otherwise the
+// embedded method would be promoted and drop their own fields.
+
+type thresholdPublicKeyJSON struct {
+	N                              string   `json:"n"`
+	G                              string   `json:"g,omitempty"`
+	H                              string   `json:"h,omitempty"`
+	K                              string   `json:"k,omitempty"`
+	TotalNumberOfDecryptionServers int      `json:"total_number_of_decryption_servers"`
+	Threshold                      int      `json:"threshold"`
+	VerificationKey                string   `json:"verification_key,omitempty"`
+	VerificationKeys               []string `json:"verification_keys,omitempty"`
+	S                              int      `json:"s,omitempty"`
+}
+
+type thresholdSecretKeyJSON struct {
+	thresholdPublicKeyJSON
+	ID    int    `json:"id"`
+	Share string `json:"share"`
+}
+
+type partialDecryptionJSON struct {
+	ID             int    `json:"id"`
+	Decryption     string `json:"decryption"`
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+}
+
+type partialDecryptionZKPJSON struct {
+	partialDecryptionJSON
+	Key     *ThresholdPublicKey `json:"key,omitempty"`
+	E       string              `json:"e"`
+	Z       string              `json:"z"`
+	C       string              `json:"c"`
+	Session string              `json:"session,omitempty"`
+	Hash    crypto.Hash         `json:"hash,omitempty"`
+	Domain  string              `json:"domain,omitempty"`
+	Bits    int                 `json:"challenge_bits,omitempty"`
+	A       string              `json:"a,omitempty"`
+	B       string              `json:"b,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (tk *ThresholdPublicKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tk.toJSON())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (tk *ThresholdPublicKey) UnmarshalJSON(data []byte) error {
+	var v thresholdPublicKeyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	return tk.fromJSON(&v)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (tsk *ThresholdSecretKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&thresholdSecretKeyJSON{
+		thresholdPublicKeyJSON: *tsk.ThresholdPublicKey.toJSON(),
+		ID:                     tsk.ID,
+		Share:                  encodeJSONInt(tsk.Share),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (tsk *ThresholdSecretKey) UnmarshalJSON(data []byte) error {
+	var v thresholdSecretKeyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var tk ThresholdPublicKey
+	if err := tk.fromJSON(&v.thresholdPublicKeyJSON); err != nil {
+		return err
+	}
+
+	share, err := decodeJSONInt(v.Share)
+	if err != nil {
+		return err
+	}
+	if share == nil {
+		return errors.New("threshold secret key is missing the share")
+	}
+	if v.ID < 1 || v.ID > tk.TotalNumberOfDecryptionServers {
+		return errors.New("share ID is out of range")
+	}
+
+	*tsk = ThresholdSecretKey{ThresholdPublicKey: tk, ID: v.ID, Share: share}
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (pd *PartialDecryption) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pd.toJSON())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (pd *PartialDecryption) UnmarshalJSON(data []byte) error {
+	var v partialDecryptionJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	return pd.fromJSON(&v)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (pd *PartialDecryptionZKP) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&partialDecryptionZKPJSON{
+		partialDecryptionJSON: *pd.PartialDecryption.toJSON(),
+		Key:                   pd.Key,
+		E:                     encodeJSONInt(pd.E),
+		Z:                     encodeJSONInt(pd.Z),
+		C:                     encodeJSONInt(pd.C),
+		Session:               base64.RawURLEncoding.EncodeToString(pd.Session),
+		Hash:                  pd.Hash,
+		Domain:                pd.Domain,
+		Bits:                  pd.ChallengeBits,
+		A:                     encodeJSONInt(pd.A),
+		B:                     encodeJSONInt(pd.B),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (pd *PartialDecryptionZKP) UnmarshalJSON(data []byte) error {
+	var v partialDecryptionZKPJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var partial PartialDecryption
+	if err := partial.fromJSON(&v.partialDecryptionJSON); err != nil {
+		return err
+	}
+
+	ints, err := decodeJSONInts(v.E, v.Z, v.C, v.A, v.B)
+	if err != nil {
+		return err
+	}
+
+	var session []byte
+	if v.Session != "" {
+		if session, err = base64.RawURLEncoding.DecodeString(v.Session); err != nil {
+			return err
+		}
+	}
+
+	*pd = PartialDecryptionZKP{
+		PartialDecryption: partial,
+		Key:               v.Key,
+		E:                 ints[0],
+		Z:                 ints[1],
+		C:                 ints[2],
+		Session:           session,
+		Hash:              v.Hash,
+		Domain:            v.Domain,
+		ChallengeBits:     v.Bits,
+		A:                 ints[3],
+		B:                 ints[4],
+	}
+	return nil
+}
+
+func (tk *ThresholdPublicKey) toJSON() *thresholdPublicKeyJSON {
+	var vi []string
+	for _, v := range tk.VerificationKeys {
+		vi = append(vi, encodeJSONInt(v))
+	}
+
+	return &thresholdPublicKeyJSON{
+		N:                              encodeJSONInt(tk.N),
+		G:                              encodeJSONInt(tk.G),
+		H:                              encodeJSONInt(tk.H),
+		K:                              encodeJSONInt(tk.K),
+		TotalNumberOfDecryptionServers: tk.TotalNumberOfDecryptionServers,
+		Threshold:                      tk.Threshold,
+		VerificationKey:                encodeJSONInt(tk.VerificationKey),
+		VerificationKeys:               vi,
+		S:                              tk.S,
+	}
+}
+
+func (tk *ThresholdPublicKey) fromJSON(v *thresholdPublicKeyJSON) error {
+	ints, err := decodeJSONInts(v.N, v.G, v.H, v.K, v.VerificationKey)
+	if err != nil {
+		return err
+	}
+	vi, err := decodeJSONInts(v.VerificationKeys...)
+	if err != nil {
+		return err
+	}
+
+	if ints[0] == nil {
+		return errors.New("threshold key is missing the public key")
+	}
+	if err := checkThresholdParameters(v.TotalNumberOfDecryptionServers, v.Threshold, vi); err != nil {
+		return err
+	}
+	if err := checkDamgardJurikExponent(int64(v.S)); err != nil {
+		return err
+	}
+
+	*tk = ThresholdPublicKey{
+		PublicKey:                      PublicKey{N: ints[0], G: ints[1], H: ints[2], K: ints[3]},
+		TotalNumberOfDecryptionServers: v.TotalNumberOfDecryptionServers,
+		Threshold:                      v.Threshold,
+		VerificationKey:                ints[4],
+		VerificationKeys:               vi,
+		S:                              v.S,
+	}
+	return nil
+}
+
+func (pd *PartialDecryption) toJSON() *partialDecryptionJSON {
+	return &partialDecryptionJSON{ID: pd.ID, Decryption: encodeJSONInt(pd.Decryption), KeyFingerprint: pd.KeyFingerprint}
+}
+
+func (pd *PartialDecryption) fromJSON(v *partialDecryptionJSON) error {
+	decryption, err := decodeJSONInt(v.Decryption)
+	if err != nil {
+		return err
+	}
+	if decryption == nil {
+		return errors.New("partial decryption is missing the decryption")
+	}
+
+	*pd = PartialDecryption{ID: v.ID, Decryption: decryption, KeyFingerprint: v.KeyFingerprint}
+	return nil
+}
+
+func encodeJSONInt(x *gmp.Int) string {
+	if x == nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(x.Bytes())
+}
+
+// decodeJSONInt returns nil for the empty string
+func decodeJSONInt(s string) (*gmp.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	value, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(gmp.Int).SetBytes(value), nil
+}
+
+// decodeJSONInts decodes every value and returns nil for no values
+func decodeJSONInts(values ...string) ([]*gmp.Int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	ints := make([]*gmp.Int, len(values))
+	for i, s := range values {
+		x, err := decodeJSONInt(s)
+		if err != nil {
+			return nil, err
+		}
+		ints[i] = x
+	}
+	return ints, nil
+}
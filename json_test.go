@@ -0,0 +1,110 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONThresholdDecryption(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(&tsks[0].ThresholdPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tk ThresholdPublicKey
+	if err := json.Unmarshal(data, &tk); err != nil {
+		t.Fatal(err)
+	}
+	if tk.Threshold != 2 || len(tk.VerificationKeys) != 3 || tk.N.Cmp(tsks[0].N) != 0 {
+		t.Error("threshold public key does not match")
+	}
+
+	ct := tk.Encrypt(b(99))
+	var pds []*PartialDecryptionZKP
+	for _, tsk := range tsks[:2] {
+		data, err := json.Marshal(tsk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded ThresholdSecretKey
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.ID != tsk.ID || decoded.Share.Cmp(tsk.Share) != 0 {
+			t.Error("threshold secret key does not match")
+		}
+
+		pd, err := decoded.PartialDecryptionWithSession(ct.C, []byte("request 1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// partial decryptions travel as JSON to the combiner
+		data, err = json.Marshal(pd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var received PartialDecryptionZKP
+		if err := json.Unmarshal(data, &received); err != nil {
+			t.Fatal(err)
+		}
+		if !received.VerifyProof() {
+			t.Error("partial decryption proof does not verify after decoding")
+		}
+		pds = append(pds, &received)
+	}
+
+	m, err := tk.CombinePartialDecryptionsZKP(pds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 99 {
+		t.Error("wrong decryption ", m)
+	}
+}
+
+func TestJSONPartialDecryptionFormat(t *testing.T) {
+	pd := &PartialDecryption{ID: 2, Decryption: b(0xfbff)}
+	data, err := json.Marshal(pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"id":2,"decryption":"-_8"}` {
+		t.Error("unexpected encoding ", string(data))
+	}
+
+	var decoded PartialDecryption
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != 2 || n(decoded.Decryption) != 0xfbff {
+		t.Error("partial decryption does not match")
+	}
+}
+
+func TestJSONRejectsMalformedData(t *testing.T) {
+	var pd PartialDecryption
+	if err := json.Unmarshal([]byte(`{"id":1,"decryption":"+/8="}`), &pd); err == nil {
+		t.Error("standard base64 was accepted")
+	}
+	if err := json.Unmarshal([]byte(`{"id":1}`), &pd); err == nil {
+		t.Error("missing decryption was accepted")
+	}
+
+	var tk ThresholdPublicKey
+	if err := json.Unmarshal([]byte(`{"n":"Bw","total_number_of_decryption_servers":2,"threshold":3}`), &tk); err == nil ||
+		!strings.Contains(err.Error(), "threshold") {
+		t.Error("invalid threshold was accepted ", err)
+	}
+}
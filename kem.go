@@ -0,0 +1,113 @@
+package paillier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// kemInfo is the HKDF context string used to derive KEM keys
+var kemInfo = []byte("paillier-kem-v1")
+
+// Encapsulate generates a fresh symmetric key of keyLen bytes together with
+// its encapsulation under the public key. The key is derived with
+// HKDF-SHA256 from a uniformly random plaintext in Z_N and is bound to the
+// encapsulation ciphertext. The encapsulation can be opened with
+// SecretKey.Decapsulate or, for threshold keys, with
+// ThresholdPublicKey.Decapsulate once the committee has partially decrypted it.
+func (pk *PublicKey) Encapsulate(keyLen int) ([]byte, *Ciphertext, error) {
+	if err := checkKEMKeyLength(keyLen); err != nil {
+		return nil, nil, err
+	}
+
+	seed, err := GetRandomNumber(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encapsulation := pk.Encrypt(seed)
+	return pk.deriveKEMKey(seed, encapsulation, keyLen), encapsulation, nil
+}
+
+// Decapsulate recovers the symmetric key of keyLen bytes from the encapsulation
+func (sk *SecretKey) Decapsulate(encapsulation *Ciphertext, keyLen int) ([]byte, error) {
+	if err := checkKEMKeyLength(keyLen); err != nil {
+		return nil, err
+	}
+
+	if encapsulation.Level != EncLevelOne {
+		return nil, errors.New("encapsulation must be a level one ciphertext")
+	}
+
+	seed := sk.Decrypt(encapsulation)
+	return sk.deriveKEMKey(seed, encapsulation, keyLen), nil
+}
+
+// Decapsulate recovers the symmetric key of keyLen bytes from the encapsulation
+// using the partial decryptions of the committee, so that access to the key
+// is gated by a threshold of decryption servers.
+func (tk *ThresholdPublicKey) Decapsulate(encapsulation *Ciphertext, shares []*PartialDecryptionZKP, keyLen int) ([]byte, error) {
+	if err := checkKEMKeyLength(keyLen); err != nil {
+		return nil, err
+	}
+
+	if encapsulation.Level != EncLevelOne {
+		return nil, errors.New("encapsulation must be a level one ciphertext")
+	}
+
+	for _, share := range shares {
+		if share.C.Cmp(encapsulation.C) != 0 {
+			return nil, errors.New("partial decryption is not for the encapsulation")
+		}
+	}
+
+	seed, err := tk.CombinePartialDecryptionsZKP(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	return tk.deriveKEMKey(seed, encapsulation, keyLen), nil
+}
+
+// HKDF-SHA256 can output at most 255 blocks
+func checkKEMKeyLength(keyLen int) error {
+	if keyLen <= 0 || keyLen > 255*sha256.Size {
+		return errors.New("invalid key length")
+	}
+	return nil
+}
+
+// derives the key as HKDF-SHA256(ikm = seed, salt = N, info = kemInfo || C)
+// where the seed is encoded with the fixed byte length of N
+func (pk *PublicKey) deriveKEMKey(seed *gmp.Int, encapsulation *Ciphertext, keyLen int) []byte {
+	size := (pk.N.BitLen() + 7) / 8
+	ikm := make([]byte, size)
+	seedBytes := seed.Bytes()
+	copy(ikm[size-len(seedBytes):], seedBytes)
+
+	info := append(append([]byte{}, kemInfo...), encapsulation.C.Bytes()...)
+	return hkdf(ikm, pk.N.Bytes(), info, keyLen)
+}
+
+// hkdf implements the extract-then-expand key derivation function of RFC 5869
+// instantiated with HMAC-SHA256
+func hkdf(ikm, salt, info []byte, length int) []byte {
+	extractor := hmac.New(sha256.New, salt)
+	extractor.Write(ikm)
+	prk := extractor.Sum(nil)
+
+	okm := make([]byte, 0, length+sha256.Size)
+	var block []byte
+	for counter := byte(1); len(okm) < length; counter++ {
+		expander := hmac.New(sha256.New, prk)
+		expander.Write(block)
+		expander.Write(info)
+		expander.Write([]byte{counter})
+		block = expander.Sum(nil)
+		okm = append(okm, block...)
+	}
+
+	return okm[:length]
+}
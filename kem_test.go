@@ -0,0 +1,88 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 5869, test case 1
+func TestHKDF(t *testing.T) {
+	ikm, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	expected, _ := hex.DecodeString("3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865")
+
+	if okm := hkdf(ikm, salt, info, 42); !bytes.Equal(okm, expected) {
+		t.Errorf("wrong HKDF output %x", okm)
+	}
+}
+
+func TestEncapsulateDecapsulate(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for i := 0; i < 100; i++ {
+		key, encapsulation, err := pk.Encapsulate(32)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(key) != 32 {
+			t.Fatal("wrong key length ", len(key))
+		}
+
+		decapsulated, err := sk.Decapsulate(encapsulation, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(key, decapsulated) {
+			t.Error("decapsulated key does not match the encapsulated key")
+		}
+	}
+
+	if _, _, err := pk.Encapsulate(0); err == nil {
+		t.Error("expected error for zero key length")
+	}
+}
+
+func TestThresholdDecapsulate(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, encapsulation, err := tpks[0].Encapsulate(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	share1, err := tpks[0].PartialDecryptionWithZKP(encapsulation.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share2, err := tpks[2].PartialDecryptionWithZKP(encapsulation.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decapsulated, err := tpks[1].Decapsulate(encapsulation, []*PartialDecryptionZKP{share1, share2}, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(key, decapsulated) {
+		t.Error("decapsulated key does not match the encapsulated key")
+	}
+
+	if _, err := tpks[1].Decapsulate(encapsulation, []*PartialDecryptionZKP{share1}, 16); err == nil {
+		t.Error("expected error with fewer shares than the threshold")
+	}
+}
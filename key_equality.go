@@ -0,0 +1,34 @@
+package paillier
+
+import (
+	"bytes"
+	"encoding/hex"
+)
+
+// Equal returns true if both public keys have the same canonical encoding,
+// see CanonicalBytes. A missing G equals the default generator N+1.
+func (pk *PublicKey) Equal(other *PublicKey) bool {
+	if pk == nil || other == nil {
+		return pk == other
+	}
+	return bytes.Equal(pk.CanonicalBytes(), other.CanonicalBytes())
+}
+
+// Equal returns true if both threshold public keys have the same canonical
+// encoding, i.e., the same public key, committee parameters and
+// verification keys
+func (tk *ThresholdPublicKey) Equal(other *ThresholdPublicKey) bool {
+	if tk == nil || other == nil {
+		return tk == other
+	}
+	return bytes.Equal(tk.CanonicalBytes(), other.CanonicalBytes())
+}
+
+// CommitteeFingerprint returns the hex encoded SHA-256 digest of the
+// canonical encoding of the threshold public key. Unlike the Fingerprint of
+// the embedded PublicKey, which identifies the key in committee descriptions
+// and decryption requests, it changes whenever the committee or its
+// verification keys change, e.g., after resharing.
+func (tk *ThresholdPublicKey) CommitteeFingerprint() string {
+	return hex.EncodeToString(tk.Digest())
+}
@@ -0,0 +1,114 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestPublicKeyEqual(t *testing.T) {
+	_, pk1 := KeyGen(64)
+	_, pk2 := KeyGen(64)
+
+	copied := &PublicKey{N: pk1.N, H: pk1.H, K: pk1.K}
+	if !pk1.Equal(copied) {
+		t.Error("key with the default generator is not equal to the original")
+	}
+	if pk1.Equal(pk2) || pk1.Equal(nil) {
+		t.Error("different keys are equal")
+	}
+}
+
+func TestThresholdPublicKeyCommitteeFingerprint(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	others, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+	other := &others[0].ThresholdPublicKey
+
+	if !tk.Equal(&tsks[1].ThresholdPublicKey) || tk.CommitteeFingerprint() != tsks[1].CommitteeFingerprint() {
+		t.Error("shares of the same key have different public keys")
+	}
+	if tk.Equal(other) || tk.CommitteeFingerprint() == other.CommitteeFingerprint() {
+		t.Error("different keys are equal")
+	}
+
+	// a key with the same modulus but other verification keys differs
+	modified := *tk
+	modified.VerificationKeys = append(modified.VerificationKeys[:1:1], b(4), b(9))
+	if tk.Equal(&modified) || tk.CommitteeFingerprint() == modified.CommitteeFingerprint() {
+		t.Error("keys with different verification keys are equal")
+	}
+
+	// committee descriptions and decryption requests reference the key by
+	// the Fingerprint of the public key, which must not depend on the shares
+	if tk.Fingerprint() != tk.PublicKey.Fingerprint() || modified.Fingerprint() != tk.Fingerprint() {
+		t.Error("fingerprint of the public key depends on the verification keys")
+	}
+}
+
+func TestCombineRejectsSharesOfAnotherKey(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	others, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	c := tk.Encrypt(b(5)).C
+	share1 := tsks[0].PartialDecrypt(c)
+	share2 := others[1].PartialDecrypt(c)
+	if share1.KeyFingerprint != tk.CommitteeFingerprint() {
+		t.Error("partial decryption does not carry the fingerprint of the key")
+	}
+
+	if _, err := tk.CombinePartialDecryptions([]*PartialDecryption{share1, share2}); !errors.Is(err, ErrKeyMismatch) {
+		t.Error("expected ErrKeyMismatch, got ", err)
+	}
+
+	cb := tk.NewCombiner(c)
+	if err := cb.Add(share2); !errors.Is(err, ErrKeyMismatch) {
+		t.Error("expected ErrKeyMismatch, got ", err)
+	}
+
+	// the fingerprint survives the compact encoding
+	if _, err := tk.DecodePartialDecryption(share2.Encode()); !errors.Is(err, ErrKeyMismatch) {
+		t.Error("expected ErrKeyMismatch, got ", err)
+	}
+	decoded, err := tk.DecodePartialDecryption(share1.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.KeyFingerprint != share1.KeyFingerprint {
+		t.Error("fingerprint was not decoded")
+	}
+
+	// shares without a fingerprint are still accepted
+	share3 := tsks[2].PartialDecrypt(c)
+	share3.KeyFingerprint = ""
+	m, err := tk.CombinePartialDecryptions([]*PartialDecryption{share1, share3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 5 {
+		t.Error("wrong decryption ", m)
+	}
+}
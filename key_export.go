@@ -0,0 +1,245 @@
+package paillier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// Encrypted key shares are encoded as
+//
+//	version || logN || r || p || salt || nonce || AES-256-GCM(MarshalBinary(tsk))
+//
+// where r and p are 4-byte big-endian values and the AES key is derived from
+// the passphrase with scrypt (RFC 7914) using the encoded parameters. The
+// header is authenticated as associated data, so neither the parameters nor the
+// salt can be changed without detection.
+
+// keyExportVersion is the first byte of every encrypted key share
+const keyExportVersion byte = 1
+
+const (
+	keyExportSaltLength  = 16
+	keyExportNonceLength = 12
+	keyExportHeaderSize  = 1 + 1 + 4 + 4 + keyExportSaltLength + keyExportNonceLength
+)
+
+// scrypt cost parameters for new exports, N = 2^15 and r = 8 use 32 MiB of memory
+const (
+	keyExportLogN = 15
+	keyExportR    = 8
+	keyExportP    = 1
+)
+
+// upper bounds on the parameters accepted by ImportEncrypted so that a
+// manipulated header cannot make the import exhaust memory
+const (
+	keyExportMaxLogN = 20
+	keyExportMaxR    = 32
+	keyExportMaxP    = 16
+)
+
+// ExportEncrypted returns the key share, including the share and the
+// verification keys, encrypted under a key derived from the passphrase
+func (tsk *ThresholdSecretKey) ExportEncrypted(passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	plaintext, err := tsk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, keyExportHeaderSize)
+	header[0] = keyExportVersion
+	header[1] = keyExportLogN
+	binary.BigEndian.PutUint32(header[2:], keyExportR)
+	binary.BigEndian.PutUint32(header[6:], keyExportP)
+	if _, err := io.ReadFull(tsk.RandomSource(), header[10:]); err != nil {
+		return nil, err
+	}
+
+	salt := header[10 : 10+keyExportSaltLength]
+	nonce := header[10+keyExportSaltLength:]
+
+	aead, err := newChannelAEAD(scrypt(passphrase, salt, keyExportLogN, keyExportR, keyExportP, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// ImportEncrypted decrypts a key share exported by ExportEncrypted and stores
+// it in tsk
+func (tsk *ThresholdSecretKey) ImportEncrypted(data, passphrase []byte) error {
+	if len(data) < keyExportHeaderSize {
+		return errors.New("encrypted key is too short")
+	}
+	if data[0] != keyExportVersion {
+		return errors.New("unsupported encoding version")
+	}
+
+	logN := int(data[1])
+	r := binary.BigEndian.Uint32(data[2:])
+	p := binary.BigEndian.Uint32(data[6:])
+	if logN < 1 || logN > keyExportMaxLogN || r < 1 || r > keyExportMaxR || p < 1 || p > keyExportMaxP {
+		return errors.New("unsupported key derivation parameters")
+	}
+
+	header := data[:keyExportHeaderSize]
+	salt := header[10 : 10+keyExportSaltLength]
+	nonce := header[10+keyExportSaltLength:]
+
+	aead, err := newChannelAEAD(scrypt(passphrase, salt, logN, int(r), int(p), 32))
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := aead.Open(nil, nonce, data[keyExportHeaderSize:], header)
+	if err != nil {
+		return errors.New("wrong passphrase or corrupted key")
+	}
+
+	return tsk.UnmarshalBinary(plaintext)
+}
+
+// scrypt implements the password-based key derivation function of RFC 7914
+// with N = 2^logN
+func scrypt(password, salt []byte, logN, r, p, keyLen int) []byte {
+	n := 1 << uint(logN)
+	blockSize := 128 * r
+
+	b := pbkdf2SHA256(password, salt, 1, p*blockSize)
+
+	x := make([]uint32, 32*r)
+	v := make([]uint32, 32*r*n)
+	y := make([]uint32, 32*r)
+	for i := 0; i < p; i++ {
+		scryptROMix(b[i*blockSize:(i+1)*blockSize], r, n, x, y, v)
+	}
+
+	return pbkdf2SHA256(password, b, 1, keyLen)
+}
+
+// scryptROMix mixes the block b in place, see RFC 7914 section 5
+func scryptROMix(b []byte, r, n int, x, y, v []uint32) {
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+
+	words := 32 * r
+	for i := 0; i < n; i++ {
+		copy(v[i*words:], x)
+		scryptBlockMix(x, y, r)
+	}
+	for i := 0; i < n; i++ {
+		// n is a power of two below 2^32, so the first word of the last
+		// 64-byte block determines Integerify(X) mod n
+		j := int(x[(2*r-1)*16] & uint32(n-1))
+		for k := range x {
+			x[k] ^= v[j*words+k]
+		}
+		scryptBlockMix(x, y, r)
+	}
+
+	for i, w := range x {
+		binary.LittleEndian.PutUint32(b[4*i:], w)
+	}
+}
+
+// scryptBlockMix computes BlockMix_{Salsa20/8, r} of b in place using y as
+// scratch space, see RFC 7914 section 4
+func scryptBlockMix(b, y []uint32, r int) {
+	var t [16]uint32
+	copy(t[:], b[(2*r-1)*16:])
+
+	for i := 0; i < 2*r; i++ {
+		for k := range t {
+			t[k] ^= b[i*16+k]
+		}
+		salsa208(&t)
+		// even blocks go to the first half and odd blocks to the second half
+		copy(y[((i%2)*r+i/2)*16:], t[:])
+	}
+
+	copy(b, y)
+}
+
+// salsa208 applies the Salsa20/8 core to b
+func salsa208(b *[16]uint32) {
+	x := *b
+	for i := 0; i < 8; i += 2 {
+		// columns
+		x[4] ^= bits.RotateLeft32(x[0]+x[12], 7)
+		x[8] ^= bits.RotateLeft32(x[4]+x[0], 9)
+		x[12] ^= bits.RotateLeft32(x[8]+x[4], 13)
+		x[0] ^= bits.RotateLeft32(x[12]+x[8], 18)
+		x[9] ^= bits.RotateLeft32(x[5]+x[1], 7)
+		x[13] ^= bits.RotateLeft32(x[9]+x[5], 9)
+		x[1] ^= bits.RotateLeft32(x[13]+x[9], 13)
+		x[5] ^= bits.RotateLeft32(x[1]+x[13], 18)
+		x[14] ^= bits.RotateLeft32(x[10]+x[6], 7)
+		x[2] ^= bits.RotateLeft32(x[14]+x[10], 9)
+		x[6] ^= bits.RotateLeft32(x[2]+x[14], 13)
+		x[10] ^= bits.RotateLeft32(x[6]+x[2], 18)
+		x[3] ^= bits.RotateLeft32(x[15]+x[11], 7)
+		x[7] ^= bits.RotateLeft32(x[3]+x[15], 9)
+		x[11] ^= bits.RotateLeft32(x[7]+x[3], 13)
+		x[15] ^= bits.RotateLeft32(x[11]+x[7], 18)
+
+		// rows
+		x[1] ^= bits.RotateLeft32(x[0]+x[3], 7)
+		x[2] ^= bits.RotateLeft32(x[1]+x[0], 9)
+		x[3] ^= bits.RotateLeft32(x[2]+x[1], 13)
+		x[0] ^= bits.RotateLeft32(x[3]+x[2], 18)
+		x[6] ^= bits.RotateLeft32(x[5]+x[4], 7)
+		x[7] ^= bits.RotateLeft32(x[6]+x[5], 9)
+		x[4] ^= bits.RotateLeft32(x[7]+x[6], 13)
+		x[5] ^= bits.RotateLeft32(x[4]+x[7], 18)
+		x[11] ^= bits.RotateLeft32(x[10]+x[9], 7)
+		x[8] ^= bits.RotateLeft32(x[11]+x[10], 9)
+		x[9] ^= bits.RotateLeft32(x[8]+x[11], 13)
+		x[10] ^= bits.RotateLeft32(x[9]+x[8], 18)
+		x[12] ^= bits.RotateLeft32(x[15]+x[14], 7)
+		x[13] ^= bits.RotateLeft32(x[12]+x[15], 9)
+		x[14] ^= bits.RotateLeft32(x[13]+x[12], 13)
+		x[15] ^= bits.RotateLeft32(x[14]+x[13], 18)
+	}
+
+	for i := range b {
+		b[i] += x[i]
+	}
+}
+
+// pbkdf2SHA256 implements PBKDF2 of RFC 8018 instantiated with HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations, length int) []byte {
+	prf := hmac.New(sha256.New, password)
+
+	dk := make([]byte, 0, length+sha256.Size)
+	var counter [4]byte
+	for block := uint32(1); len(dk) < length; block++ {
+		binary.BigEndian.PutUint32(counter[:], block)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter[:])
+		u := prf.Sum(nil)
+
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for k := range t {
+				t[k] ^= u[k]
+			}
+		}
+		dk = append(dk, t...)
+	}
+
+	return dk[:length]
+}
@@ -0,0 +1,88 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestScryptVectors(t *testing.T) {
+	// test vectors of RFC 7914 sections 11 and 12
+	dk := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	if hex.EncodeToString(dk) != "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"+
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783" {
+		t.Error("wrong PBKDF2 output ", hex.EncodeToString(dk))
+	}
+
+	tests := []struct {
+		password, salt string
+		logN, r, p     int
+		expected       string
+	}{
+		{"", "", 4, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442" +
+			"fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 10, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162" +
+			"2eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	}
+
+	for _, test := range tests {
+		dk := scrypt([]byte(test.password), []byte(test.salt), test.logN, test.r, test.p, 64)
+		if hex.EncodeToString(dk) != test.expected {
+			t.Errorf("wrong scrypt output for %q: %x", test.password, dk)
+		}
+	}
+}
+
+func TestExportEncrypted(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passphrase := []byte("correct horse battery staple")
+	data, err := tsks[1].ExportEncrypted(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, _ := tsks[1].MarshalBinary()
+	if bytes.Contains(data, tsks[1].Share.Bytes()) || bytes.Contains(data, plain[2:]) {
+		t.Error("export contains the plaintext share")
+	}
+
+	var imported ThresholdSecretKey
+	if err := imported.ImportEncrypted(data, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	if imported.ID != tsks[1].ID || imported.Share.Cmp(tsks[1].Share) != 0 ||
+		imported.VerificationKeys[2].Cmp(tsks[1].VerificationKeys[2]) != 0 {
+		t.Error("imported key does not match")
+	}
+
+	if err := imported.ImportEncrypted(data, []byte("wrong")); err == nil {
+		t.Error("wrong passphrase was accepted")
+	}
+
+	// the parameters are authenticated
+	tampered := append([]byte{}, data...)
+	tampered[1]--
+	if err := imported.ImportEncrypted(tampered, passphrase); err == nil {
+		t.Error("tampered parameters were accepted")
+	}
+
+	tampered = append([]byte{}, data...)
+	tampered[1] = 40
+	if err := imported.ImportEncrypted(tampered, passphrase); err == nil {
+		t.Error("excessive scrypt cost was accepted")
+	}
+
+	if _, err := tsks[1].ExportEncrypted(nil); err == nil {
+		t.Error("empty passphrase was accepted")
+	}
+}
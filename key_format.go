@@ -0,0 +1,78 @@
+package paillier
+
+import (
+	"fmt"
+)
+
+// Keys implement fmt.Stringer and fmt.GoStringer so that printing a key with
+// %v, %s or %#v, e.g., in a log message, shows its bit length and fingerprint
+// but never the secret values Lambda, Mu or Share. Use the binary, JSON or
+// PEM encodings to export keys.
+
+var (
+	_ fmt.Stringer   = (*PublicKey)(nil)
+	_ fmt.GoStringer = (*PublicKey)(nil)
+	_ fmt.Stringer   = (*SecretKey)(nil)
+	_ fmt.GoStringer = (*SecretKey)(nil)
+	_ fmt.Stringer   = (*ThresholdPublicKey)(nil)
+	_ fmt.GoStringer = (*ThresholdPublicKey)(nil)
+	_ fmt.Stringer   = (*ThresholdSecretKey)(nil)
+	_ fmt.GoStringer = (*ThresholdSecretKey)(nil)
+)
+
+func (pk *PublicKey) String() string {
+	return fmt.Sprintf("PublicKey{bits: %d, fingerprint: %s}", pk.bitLen(), pk.safeFingerprint())
+}
+
+func (pk *PublicKey) GoString() string {
+	return "paillier." + pk.String()
+}
+
+func (sk *SecretKey) String() string {
+	return fmt.Sprintf("SecretKey{bits: %d, fingerprint: %s, secret: REDACTED}", sk.bitLen(), sk.safeFingerprint())
+}
+
+func (sk *SecretKey) GoString() string {
+	return "paillier." + sk.String()
+}
+
+func (tk *ThresholdPublicKey) String() string {
+	return fmt.Sprintf("ThresholdPublicKey{bits: %d, threshold: %d of %d, fingerprint: %s}",
+		tk.bitLen(), tk.Threshold, tk.TotalNumberOfDecryptionServers, tk.safeFingerprint())
+}
+
+func (tk *ThresholdPublicKey) GoString() string {
+	return "paillier." + tk.String()
+}
+
+func (tsk *ThresholdSecretKey) String() string {
+	return fmt.Sprintf("ThresholdSecretKey{id: %d, bits: %d, threshold: %d of %d, fingerprint: %s, share: REDACTED}",
+		tsk.ID, tsk.bitLen(), tsk.Threshold, tsk.TotalNumberOfDecryptionServers, tsk.ThresholdPublicKey.safeFingerprint())
+}
+
+func (tsk *ThresholdSecretKey) GoString() string {
+	return "paillier." + tsk.String()
+}
+
+func (pk *PublicKey) bitLen() int {
+	if pk.N == nil {
+		return 0
+	}
+	return pk.N.BitLen()
+}
+
+// the fingerprints of keys without N are replaced by "none" instead of
+// panicking while formatting
+func (pk *PublicKey) safeFingerprint() string {
+	if pk.N == nil {
+		return "none"
+	}
+	return pk.Fingerprint()
+}
+
+func (tk *ThresholdPublicKey) safeFingerprint() string {
+	if tk.N == nil {
+		return "none"
+	}
+	return tk.CommitteeFingerprint()
+}
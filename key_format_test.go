@@ -0,0 +1,51 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestKeyFormattingRedactsSecrets(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	for _, format := range []string{"%v", "%s", "%+v", "%#v"} {
+		out := fmt.Sprintf(format, sk)
+		if strings.Contains(out, sk.Lambda.String()) {
+			t.Errorf("%s of the secret key leaks the secret: %s", format, out)
+		}
+		if !strings.Contains(out, pk.Fingerprint()) {
+			t.Errorf("%s of the secret key does not show the fingerprint: %s", format, out)
+		}
+
+		out = fmt.Sprintf(format, pk)
+		if strings.Contains(out, pk.N.String()) || !strings.Contains(out, pk.Fingerprint()) {
+			t.Errorf("%s of the public key does not show the fingerprint instead of N: %s", format, out)
+		}
+	}
+
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"%v", "%#v"} {
+		out := fmt.Sprintf(format, tsks[1])
+		if strings.Contains(out, tsks[1].Share.String()) {
+			t.Errorf("%s of the key share leaks the share: %s", format, out)
+		}
+		if !strings.Contains(out, "id: 2") || !strings.Contains(out, "2 of 3") || !strings.Contains(out, tsks[1].CommitteeFingerprint()) {
+			t.Errorf("%s of the key share does not show its metadata: %s", format, out)
+		}
+	}
+
+	if out := fmt.Sprint(new(SecretKey)); !strings.Contains(out, "none") {
+		t.Error("empty key is not formatted: ", out)
+	}
+}
@@ -0,0 +1,430 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// KeySwitchMask is the contribution of one decryption server to a key
+// switch: the encryptions of its random mask r under the source key and the
+// target key with a proof that they encrypt the same integer
+type KeySwitchMask struct {
+	ID     int
+	Source *Ciphertext // [r] under the threshold key
+	Target *Ciphertext // [r] under the target key
+	Proof  *KeySwitchProof
+}
+
+// KeySwitchProof is a non-interactive proof (Fiat-Shamir heuristic) of
+// knowledge of an integer x and randomness rA, rB with
+// cA = gA^x rA^NA mod NA^2 and cB = gB^x rB^NB mod NB^2 and 0 <= x < Bound,
+// up to the slack of RangeProof: the verifier is only convinced that
+// |x| < Bound * 2^168.
+type KeySwitchProof struct {
+	A, B   *gmp.Int // commitments gA^alpha rhoA^NA and gB^alpha rhoB^NB
+	Z      *gmp.Int // alpha + e*x over the integers
+	WA, WB *gmp.Int // rhoA * rA^e mod NA and rhoB * rB^e mod NB
+}
+
+// KeySwitchRequest is sent by the coordinator of a key switch to the
+// decryption servers once Threshold masks were collected
+type KeySwitchRequest struct {
+	Ciphertext *Ciphertext
+	Masks      []*KeySwitchMask
+}
+
+// KeySwitch holds the state of the coordinator that transforms a level one
+// encryption of m < 2^BitLength under the threshold key into an encryption
+// of m under the target key, without the coordinator or the decryption
+// servers learning m.
+//
+// The protocol masks m with integers, so that the masked value does not wrap
+// around either modulus:
+//  1. every participating server i picks r_i in [0, 2^(BitLength+40)) and
+//     publishes [r_i] under both keys with a KeySwitchProof (see
+//     ThresholdSecretKey.NewKeySwitchMember)
+//  2. the coordinator collects the masks of Threshold servers and sends the
+//     ciphertext with the masks to them; every server checks that its own
+//     mask is included and partially decrypts d = m + S + sum_i r_i, where the
+//     public offset S keeps d positive for masks within the slack of the
+//     proofs
+//  3. the coordinator combines d and outputs the rerandomized
+//     [d - S] * prod_i [r_i]^-1 = [m] under the target key
+//
+// Every server's own mask statistically hides m in d, so m stays hidden as
+// long as one of the participating servers is honest. The proofs keep a
+// server from using different masks under the two keys; a server that
+// withholds its partial decryption stalls the switch. The threshold key
+// must have more than BitLength + 210 + log2(Threshold) bits, e.g., 2048
+// bits for plaintexts of up to 1800 bits, and the target key more than
+// BitLength bits.
+type KeySwitch struct {
+	Source    *ThresholdPublicKey
+	Target    *PublicKey
+	BitLength int
+
+	ct       *Ciphertext
+	masks    []*KeySwitchMask
+	seen     map[int]bool
+	request  *KeySwitchRequest
+	combiner *Combiner
+	done     bool
+}
+
+// KeySwitchMember is the state of one decryption server in a key switch. A
+// member partially decrypts a single request, since reusing its mask for
+// several ciphertexts would reveal the differences of their plaintexts.
+type KeySwitchMember struct {
+	tsk       *ThresholdSecretKey
+	target    *PublicKey
+	bitLength int
+	mask      *KeySwitchMask
+	done      bool
+}
+
+// NewKeySwitch returns the coordinator of the switch of the level one
+// ciphertext ct with a plaintext below 2^bitLength to the target key
+func (tk *ThresholdPublicKey) NewKeySwitch(ct *Ciphertext, target *PublicKey, bitLength int) (*KeySwitch, error) {
+	if ct == nil || ct.C == nil || ct.Level != EncLevelOne {
+		return nil, errors.New("key switching is only supported for level one ciphertexts")
+	}
+	if err := checkKeySwitchParameters(tk, target, bitLength); err != nil {
+		return nil, err
+	}
+
+	return &KeySwitch{Source: tk, Target: target, BitLength: bitLength, ct: ct, seen: make(map[int]bool)}, nil
+}
+
+// NewKeySwitchMember picks the random mask of the server for a switch to
+// the target key and returns the member state together with the mask to
+// send to the coordinator
+func (tsk *ThresholdSecretKey) NewKeySwitchMember(target *PublicKey, bitLength int) (*KeySwitchMember, *KeySwitchMask, error) {
+	if err := checkKeySwitchParameters(&tsk.ThresholdPublicKey, target, bitLength); err != nil {
+		return nil, nil, err
+	}
+
+	source := &tsk.ThresholdPublicKey.PublicKey
+	random := source.RandomSource()
+
+	x, err := GetRandomNumber(keySwitchMaskBound(bitLength), random)
+	if err != nil {
+		return nil, nil, err
+	}
+	rA, err := GetRandomNumberInMultiplicativeGroup(source.N, random)
+	if err != nil {
+		return nil, nil, err
+	}
+	rB, err := GetRandomNumberInMultiplicativeGroup(target.N, target.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mask := &KeySwitchMask{
+		ID:     tsk.ID,
+		Source: source.EncryptWithR(x, rA),
+		Target: target.EncryptWithR(x, rB),
+	}
+	if mask.Proof, err = proveKeySwitch(source, target, mask.Source, mask.Target, x, rA, rB, keySwitchMaskBound(bitLength)); err != nil {
+		return nil, nil, err
+	}
+
+	member := &KeySwitchMember{tsk: tsk, target: target, bitLength: bitLength, mask: mask}
+	return member, mask, nil
+}
+
+// AddMask verifies the mask of a server and adds it. Request uses the first
+// Threshold masks; masks added after Request are rejected.
+func (ks *KeySwitch) AddMask(mask *KeySwitchMask) error {
+	if ks.request != nil {
+		return errors.New("masks of Threshold servers were already collected")
+	}
+	if err := ks.Source.checkKeySwitchMask(ks.Target, ks.BitLength, mask); err != nil {
+		return err
+	}
+	if ks.seen[mask.ID] {
+		return fmt.Errorf("%w: mask of server %d was already added", ErrDuplicateShareID, mask.ID)
+	}
+	ks.seen[mask.ID] = true
+	ks.masks = append(ks.masks, mask)
+	return nil
+}
+
+// Request returns the request for the servers whose masks were added. It
+// returns an error wrapping ErrTooFewShares before Threshold masks were added.
+func (ks *KeySwitch) Request() (*KeySwitchRequest, error) {
+	if ks.request != nil {
+		return ks.request, nil
+	}
+	if len(ks.masks) < ks.Source.Threshold {
+		return nil, fmt.Errorf("%w: %d of %d masks were added", ErrTooFewShares, len(ks.masks), ks.Source.Threshold)
+	}
+
+	ks.request = &KeySwitchRequest{Ciphertext: ks.ct, Masks: ks.masks[:ks.Source.Threshold]}
+	ks.combiner = ks.Source.NewCombiner(ks.Source.keySwitchBlinded(ks.request, ks.BitLength).C)
+	return ks.request, nil
+}
+
+// AddPartialDecryption verifies the partial decryption of a server and adds
+// it; see Combiner.AddZKP for the errors
+func (ks *KeySwitch) AddPartialDecryption(share *PartialDecryptionZKP) error {
+	if ks.combiner == nil {
+		return errors.New("request was not created")
+	}
+	return ks.combiner.AddZKP(share)
+}
+
+// Finalize combines the partial decryptions and returns the encryption of
+// the plaintext under the target key
+func (ks *KeySwitch) Finalize() (*Ciphertext, error) {
+	if ks.combiner == nil {
+		return nil, errors.New("request was not created")
+	}
+	if ks.done {
+		return nil, errors.New("key switch was already finalized")
+	}
+
+	d, err := ks.combiner.Combine()
+	if err != nil {
+		return nil, err
+	}
+	ks.done = true
+
+	// m = d - S - sum_i r_i over the integers
+	target := ks.Target
+	m := new(gmp.Int).Sub(d, keySwitchOffset(ks.Source, ks.BitLength))
+	result := target.Encrypt(m.Mod(m, target.N))
+	for _, mask := range ks.request.Masks {
+		result = target.Sub(result, mask.Target)
+	}
+	return target.Randomize(result), nil
+}
+
+// PartialDecrypt checks that the request includes the mask of the member and
+// the masks of Threshold distinct servers with valid proofs and returns the
+// partial decryption of the masked plaintext
+func (m *KeySwitchMember) PartialDecrypt(req *KeySwitchRequest) (*PartialDecryptionZKP, error) {
+	if m.done {
+		return nil, errors.New("member already decrypted a key switch")
+	}
+	if req == nil || req.Ciphertext == nil || req.Ciphertext.C == nil || req.Ciphertext.Level != EncLevelOne {
+		return nil, errors.New("key switching is only supported for level one ciphertexts")
+	}
+
+	tk := &m.tsk.ThresholdPublicKey
+	if len(req.Masks) != tk.Threshold {
+		return nil, fmt.Errorf("request must have the masks of %d servers", tk.Threshold)
+	}
+
+	own := false
+	seen := make(map[int]bool)
+	for _, mask := range req.Masks {
+		if err := tk.checkKeySwitchMask(m.target, m.bitLength, mask); err != nil {
+			return nil, err
+		}
+		if seen[mask.ID] {
+			return nil, fmt.Errorf("%w: mask of server %d", ErrDuplicateShareID, mask.ID)
+		}
+		seen[mask.ID] = true
+
+		if mask.ID == m.mask.ID {
+			if mask.Source.C.Cmp(m.mask.Source.C) != 0 || mask.Target.C.Cmp(m.mask.Target.C) != 0 {
+				return nil, fmt.Errorf("request has another mask of server %d", mask.ID)
+			}
+			own = true
+		}
+	}
+	if !own {
+		return nil, fmt.Errorf("request does not include the mask of server %d", m.mask.ID)
+	}
+	m.done = true
+
+	return m.tsk.PartialDecryptionWithZKP(tk.keySwitchBlinded(req, m.bitLength).C)
+}
+
+// SwitchKey runs the protocol with local decryption servers, of which the
+// first Threshold are used, e.g., to migrate a dataset held by a single
+// operator
+func (tk *ThresholdPublicKey) SwitchKey(ct *Ciphertext, target *PublicKey, bitLength int, servers []*ThresholdSecretKey) (*Ciphertext, error) {
+	if len(servers) < tk.Threshold {
+		return nil, fmt.Errorf("%w: %d servers, threshold is %d", ErrTooFewShares, len(servers), tk.Threshold)
+	}
+
+	ks, err := tk.NewKeySwitch(ct, target, bitLength)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*KeySwitchMember, tk.Threshold)
+	for i, tsk := range servers[:tk.Threshold] {
+		member, mask, err := tsk.NewKeySwitchMember(target, bitLength)
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.AddMask(mask); err != nil {
+			return nil, err
+		}
+		members[i] = member
+	}
+
+	req, err := ks.Request()
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		share, err := member.PartialDecrypt(req)
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.AddPartialDecryption(share); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks.Finalize()
+}
+
+// keySwitchBlinded returns [m + S + sum_i r_i] under the threshold key
+func (tk *ThresholdPublicKey) keySwitchBlinded(req *KeySwitchRequest, bitLength int) *Ciphertext {
+	pk := &tk.PublicKey
+	blinded := pk.addConstant(req.Ciphertext, keySwitchOffset(tk, bitLength))
+	for _, mask := range req.Masks {
+		blinded = pk.Add(blinded, mask.Source)
+	}
+	return blinded
+}
+
+// checkKeySwitchMask returns an error if the mask is not of a server of the
+// key or its proof is rejected
+func (tk *ThresholdPublicKey) checkKeySwitchMask(target *PublicKey, bitLength int, mask *KeySwitchMask) error {
+	if mask == nil || mask.Source == nil || mask.Target == nil || mask.Source.C == nil || mask.Target.C == nil {
+		return errors.New("missing key switch mask")
+	}
+	if mask.ID < 1 || mask.ID > tk.TotalNumberOfDecryptionServers {
+		return fmt.Errorf("mask ID %d is out of range", mask.ID)
+	}
+	if mask.Source.Level != EncLevelOne || mask.Target.Level != EncLevelOne {
+		return fmt.Errorf("%w: mask of server %d", ErrInvalidCiphertext, mask.ID)
+	}
+	if err := verifyKeySwitch(&tk.PublicKey, target, mask.Source, mask.Target, keySwitchMaskBound(bitLength), mask.Proof); err != nil {
+		return &InvalidProofError{ID: mask.ID, Err: err}
+	}
+	return nil
+}
+
+// keySwitchMaskBound returns the exclusive bound 2^(bitLength+40) of the masks
+func keySwitchMaskBound(bitLength int) *gmp.Int {
+	return new(gmp.Int).Lsh(OneBigInt, uint(bitLength+slackStatisticalSecurity))
+}
+
+// keySwitchOffset returns S = Threshold * 2^(bitLength+40+168), which
+// exceeds the absolute value of the sum of Threshold masks accepted by the
+// proofs
+func keySwitchOffset(tk *ThresholdPublicKey, bitLength int) *gmp.Int {
+	s := new(gmp.Int).Lsh(keySwitchMaskBound(bitLength), slackBits)
+	return s.Mul(s, gmp.NewInt(int64(tk.Threshold)))
+}
+
+// checkKeySwitchParameters returns an error if m + 2S does not fit into the
+// plaintext space of the threshold key or m into that of the target key
+func checkKeySwitchParameters(tk *ThresholdPublicKey, target *PublicKey, bitLength int) error {
+	if target == nil || target.N == nil {
+		return errors.New("missing target key")
+	}
+	if bitLength <= 0 {
+		return errors.New("bit length must be positive")
+	}
+	if tk.S > 1 {
+		return errors.New("key switching is not supported for Damgard-Jurik exponents above one")
+	}
+
+	// m + S + sum_i r_i < 2^bitLength + 2S must be smaller than N
+	bound := new(gmp.Int).Lsh(keySwitchOffset(tk, bitLength), 1)
+	bound.Add(bound, new(gmp.Int).Lsh(OneBigInt, uint(bitLength)))
+	if bound.Cmp(tk.N) >= 0 {
+		return errors.New("threshold key is too small for the requested bit length")
+	}
+	if target.N.BitLen() <= bitLength {
+		return errors.New("target key is too small for the requested bit length")
+	}
+	return nil
+}
+
+// proveKeySwitch proves that cA under pkA and cB under pkB encrypt the same
+// integer x in [0, bound)
+func proveKeySwitch(pkA, pkB *PublicKey, cA, cB *Ciphertext, x, rA, rB, bound *gmp.Int) (*KeySwitchProof, error) {
+	zBound := new(gmp.Int).Lsh(bound, slackBits)
+	for {
+		alpha, err := GetRandomNumber(zBound, pkA.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		rhoA, err := GetRandomNumberInMultiplicativeGroup(pkA.N, pkA.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		rhoB, err := GetRandomNumberInMultiplicativeGroup(pkB.N, pkB.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+
+		a := pkA.EncryptWithR(alpha, rhoA).C
+		b := pkB.EncryptWithR(alpha, rhoB).C
+		e := RandomOracleChallenge(slackChallengeBits, pkA.N, pkB.N, cA.C, cB.C, bound, a, b)
+
+		// retry in the rare case that the response would reveal x
+		z := new(gmp.Int).Mul(e, x)
+		z.Add(z, alpha)
+		if z.Cmp(zBound) >= 0 {
+			continue
+		}
+
+		wA := new(gmp.Int).Exp(rA, e, pkA.N)
+		wA.Mul(wA, rhoA)
+		wA.Mod(wA, pkA.N)
+
+		wB := new(gmp.Int).Exp(rB, e, pkB.N)
+		wB.Mul(wB, rhoB)
+		wB.Mod(wB, pkB.N)
+
+		return &KeySwitchProof{A: a, B: b, Z: z, WA: wA, WB: wB}, nil
+	}
+}
+
+// verifyKeySwitch returns an error wrapping ErrMalformedProof, ErrProofPart1
+// (gA^Z WA^NA = A cA^e) or ErrProofPart2 (gB^Z WB^NB = B cB^e) if the proof
+// is rejected
+func verifyKeySwitch(pkA, pkB *PublicKey, cA, cB *Ciphertext, bound *gmp.Int, proof *KeySwitchProof) error {
+	if proof == nil || proof.A == nil || proof.B == nil || proof.Z == nil || proof.WA == nil || proof.WB == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+	zBound := new(gmp.Int).Lsh(bound, slackBits)
+	if proof.Z.Sign() < 0 || proof.Z.Cmp(zBound) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+	if err := pkA.checkUnits(proof.WA); err != nil {
+		return err
+	}
+	if err := pkB.checkUnits(proof.WB); err != nil {
+		return err
+	}
+
+	e := RandomOracleChallenge(slackChallengeBits, pkA.N, pkB.N, cA.C, cB.C, bound, proof.A, proof.B)
+
+	check := func(pk *PublicKey, c, commitment, w *gmp.Int) bool {
+		n2 := pk.GetN2()
+		lhs := pk.EncryptWithR(proof.Z, w).C
+		rhs := new(gmp.Int).Exp(c, e, n2)
+		rhs.Mul(rhs, commitment)
+		rhs.Mod(rhs, n2)
+		return lhs.Cmp(rhs) == 0
+	}
+	if !check(pkA, cA.C, proof.A, proof.WA) {
+		return ErrProofPart1
+	}
+	if !check(pkB, cB.C, proof.B, proof.WB) {
+		return ErrProofPart2
+	}
+	return nil
+}
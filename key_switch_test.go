@@ -0,0 +1,122 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func getKeySwitchKeys(t *testing.T) []*ThresholdSecretKey {
+	tkh, err := NewThresholdKeyGenerator(256, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tsks
+}
+
+func TestKeySwitch(t *testing.T) {
+	tsks := getKeySwitchKeys(t)
+	tk := tsks[0].PublicOnly()
+	sk, target := KeyGen(128)
+
+	for _, v := range []int64{0, 1, 4096, 1<<32 - 1} {
+		switched, err := tk.SwitchKey(tk.Encrypt(gmp.NewInt(v)), target, 32, tsks[1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := sk.Decrypt(switched); m.Cmp(gmp.NewInt(v)) != 0 {
+			t.Errorf("switched %d to an encryption of %v", v, m)
+		}
+	}
+
+	if _, err := tk.SwitchKey(tk.Encrypt(gmp.NewInt(1)), target, 64, tsks); err == nil {
+		t.Error("expected an error for a bit length too large for the key")
+	}
+}
+
+func TestKeySwitchMembers(t *testing.T) {
+	tsks := getKeySwitchKeys(t)
+	tk := tsks[0].PublicOnly()
+	sk, target := KeyGen(128)
+
+	ks, err := tk.NewKeySwitch(tk.Encrypt(gmp.NewInt(77)), target, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members := make([]*KeySwitchMember, len(tsks))
+	masks := make([]*KeySwitchMask, len(tsks))
+	for i, tsk := range tsks {
+		if members[i], masks[i], err = tsk.NewKeySwitchMember(target, 16); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a mask with different values under the two keys
+	cheating := *masks[0]
+	cheating.Target = target.Add(masks[0].Target, target.Encrypt(gmp.NewInt(1)))
+	if err := ks.AddMask(&cheating); !errors.Is(err, ErrInvalidProof) {
+		t.Error("expected ErrInvalidProof, got ", err)
+	}
+
+	if _, err := ks.Request(); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+	for _, i := range []int{2, 0} {
+		if err := ks.AddMask(masks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ks.AddMask(masks[2]); !errors.Is(err, ErrDuplicateShareID) {
+		t.Error("expected ErrDuplicateShareID, got ", err)
+	}
+
+	req, err := ks.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.AddMask(masks[1]); err == nil {
+		t.Error("expected an error for a mask after the request")
+	}
+
+	// a server whose mask is not part of the request refuses to decrypt
+	if _, err := members[1].PartialDecrypt(req); err == nil {
+		t.Error("expected an error for a request without the mask of the server")
+	}
+
+	// the coordinator cannot swap in a mask it knows
+	forged := &KeySwitchRequest{Ciphertext: req.Ciphertext, Masks: []*KeySwitchMask{masks[2], masks[1]}}
+	forged.Masks[1] = &KeySwitchMask{ID: 1, Source: masks[1].Source, Target: masks[1].Target, Proof: masks[1].Proof}
+	if _, err := members[0].PartialDecrypt(forged); err == nil {
+		t.Error("expected an error for a request with another mask of the server")
+	}
+
+	for _, i := range []int{0, 2} {
+		share, err := members[i].PartialDecrypt(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ks.AddPartialDecryption(share); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := members[0].PartialDecrypt(req); err == nil {
+		t.Error("expected an error for a second decryption by a member")
+	}
+
+	switched, err := ks.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := sk.Decrypt(switched); n(m) != 77 {
+		t.Error("switched 77 to an encryption of ", m)
+	}
+}
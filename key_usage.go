@@ -0,0 +1,210 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UsageKind is a kind of operation counted by a UsageTracker
+type UsageKind int
+
+const (
+	UsageEncryption UsageKind = iota
+	UsageDecryption
+	UsageProof
+)
+
+// Errors wrapped by the RotationError returned by UsageTracker.Record;
+// test for them with errors.Is
+var (
+	// ErrRotationRecommended -- the key reached the warning level of the policy
+	ErrRotationRecommended = errors.New("key rotation recommended")
+
+	// ErrRotationRequired -- the key exceeded the policy and must not be used
+	ErrRotationRequired = errors.New("key rotation required")
+)
+
+// UsageStore persists the usage of keys, e.g., in the database that holds the
+// keys, so that counters survive restarts
+type UsageStore interface {
+	// Save is called after every recorded operation
+	Save(usage *KeyUsage) error
+	// Load returns the last saved usage of the key or nil if there is none
+	Load(fingerprint string) (*KeyUsage, error)
+}
+
+// KeyUsage counts the operations performed with a key
+type KeyUsage struct {
+	KeyFingerprint string
+	Created        time.Time // first use of the key
+	Encryptions    uint64
+	Decryptions    uint64
+	Proofs         uint64
+}
+
+// RotationPolicy limits the use of a key; zero values disable a limit
+type RotationPolicy struct {
+	MaxOperations uint64        // total number of counted operations
+	MaxAge        time.Duration // time since the first use of the key
+
+	// WarnFraction is the fraction of a limit from which Record returns
+	// ErrRotationRecommended, e.g., 0.9; no warnings are given if zero
+	WarnFraction float64
+}
+
+// RotationError reports that a key reached a limit of its rotation policy.
+// It wraps ErrRotationRequired if the operation was refused and
+// ErrRotationRecommended if it was performed.
+type RotationError struct {
+	KeyFingerprint string
+	Reason         string
+	Required       bool
+}
+
+func (e *RotationError) Error() string {
+	return fmt.Sprintf("key %s: %s", e.KeyFingerprint, e.Reason)
+}
+
+func (e *RotationError) Unwrap() error {
+	if e.Required {
+		return ErrRotationRequired
+	}
+	return ErrRotationRecommended
+}
+
+// UsageTracker counts the operations performed with a key and enforces
+// a rotation policy. It is safe for concurrent use.
+type UsageTracker struct {
+	Policy RotationPolicy
+
+	mu    sync.Mutex
+	store UsageStore
+	usage KeyUsage
+	now   func() time.Time
+}
+
+// NewUsageTracker creates a tracker for the key. If store is not nil, the
+// counters are restored from the last saved usage and every update is persisted.
+func NewUsageTracker(pk *PublicKey, policy RotationPolicy, store UsageStore) (*UsageTracker, error) {
+	t := &UsageTracker{
+		Policy: policy,
+		store:  store,
+		usage:  KeyUsage{KeyFingerprint: pk.Fingerprint()},
+		now:    time.Now,
+	}
+
+	if store == nil {
+		return t, nil
+	}
+
+	usage, err := store.Load(t.usage.KeyFingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if usage != nil {
+		if usage.KeyFingerprint != t.usage.KeyFingerprint {
+			return nil, errors.New("stored usage is for a different key")
+		}
+		t.usage = *usage
+	}
+
+	return t, nil
+}
+
+// Record counts one operation of the given kind before it is performed.
+// It returns an error wrapping ErrRotationRequired without counting if the
+// key exceeds the policy and the operation must be refused, and an error
+// wrapping ErrRotationRecommended after counting if the key reached the
+// warning level; errors of the store are returned as is.
+func (t *UsageTracker) Record(kind UsageKind) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.check(1); err != nil && errors.Is(err, ErrRotationRequired) {
+		return err
+	}
+
+	usage := t.usage
+	if usage.Created.IsZero() {
+		usage.Created = t.now()
+	}
+
+	switch kind {
+	case UsageEncryption:
+		usage.Encryptions++
+	case UsageDecryption:
+		usage.Decryptions++
+	case UsageProof:
+		usage.Proofs++
+	default:
+		return errors.New("unknown usage kind")
+	}
+
+	if t.store != nil {
+		if err := t.store.Save(&usage); err != nil {
+			return err
+		}
+	}
+	t.usage = usage
+
+	return t.check(0)
+}
+
+// Check returns the error Record would return for the next operation without
+// counting it, or nil if the key is within the warning level
+func (t *UsageTracker) Check() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.check(1)
+}
+
+// Usage returns a copy of the current counters
+func (t *UsageTracker) Usage() KeyUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.usage
+}
+
+// checks the policy for the usage with pending additional operations
+func (t *UsageTracker) check(pending uint64) error {
+	operations := t.usage.Encryptions + t.usage.Decryptions + t.usage.Proofs + pending
+	var age time.Duration
+	if !t.usage.Created.IsZero() {
+		age = t.now().Sub(t.usage.Created)
+	}
+
+	if t.Policy.MaxOperations > 0 && operations > t.Policy.MaxOperations {
+		return t.rotationError(true, "%d operations exceed the limit of %d", operations, t.Policy.MaxOperations)
+	}
+	if t.Policy.MaxAge > 0 && age > t.Policy.MaxAge {
+		return t.rotationError(true, "age %v exceeds the limit of %v", age, t.Policy.MaxAge)
+	}
+
+	if t.Policy.WarnFraction <= 0 {
+		return nil
+	}
+
+	// the warning is about the last counted operation
+	operations -= pending
+	if t.Policy.MaxOperations > 0 && float64(operations) >= t.Policy.WarnFraction*float64(t.Policy.MaxOperations) {
+		return t.rotationError(false, "%d of %d operations used", operations, t.Policy.MaxOperations)
+	}
+	if t.Policy.MaxAge > 0 && float64(age) >= t.Policy.WarnFraction*float64(t.Policy.MaxAge) {
+		return t.rotationError(false, "age %v of %v reached", age, t.Policy.MaxAge)
+	}
+
+	return nil
+}
+
+func (t *UsageTracker) rotationError(required bool, format string, args ...interface{}) error {
+	return &RotationError{
+		KeyFingerprint: t.usage.KeyFingerprint,
+		Reason:         fmt.Sprintf(format, args...),
+		Required:       required,
+	}
+}
@@ -0,0 +1,93 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type memoryUsageStore struct {
+	usages map[string]KeyUsage
+}
+
+func (s *memoryUsageStore) Save(usage *KeyUsage) error {
+	s.usages[usage.KeyFingerprint] = *usage
+	return nil
+}
+
+func (s *memoryUsageStore) Load(fingerprint string) (*KeyUsage, error) {
+	usage, ok := s.usages[fingerprint]
+	if !ok {
+		return nil, nil
+	}
+	return &usage, nil
+}
+
+func TestUsageTracker(t *testing.T) {
+	_, pk := KeyGen(64)
+	store := &memoryUsageStore{usages: make(map[string]KeyUsage)}
+	policy := RotationPolicy{MaxOperations: 10, WarnFraction: 0.8}
+
+	tracker, err := NewUsageTracker(pk, policy, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 7; i++ {
+		if err := tracker.Record(UsageEncryption); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tracker.Record(UsageDecryption); !errors.Is(err, ErrRotationRecommended) {
+		t.Error("expected warning at 8 operations, got ", err)
+	}
+
+	// the counters survive a restart
+	tracker, err = NewUsageTracker(pk, policy, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage := tracker.Usage(); usage.Encryptions != 7 || usage.Decryptions != 1 {
+		t.Error("counters were not restored ", usage)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := tracker.Record(UsageProof); !errors.Is(err, ErrRotationRecommended) {
+			t.Error("expected warning, got ", err)
+		}
+	}
+
+	err = tracker.Record(UsageEncryption)
+	var rotationErr *RotationError
+	if !errors.Is(err, ErrRotationRequired) || !errors.As(err, &rotationErr) || rotationErr.KeyFingerprint != pk.Fingerprint() {
+		t.Error("expected hard stop after 10 operations, got ", err)
+	}
+	if usage := tracker.Usage(); usage.Encryptions != 7 {
+		t.Error("refused operation was counted")
+	}
+}
+
+func TestUsageTrackerMaxAge(t *testing.T) {
+	_, pk := KeyGen(64)
+	tracker, err := NewUsageTracker(pk, RotationPolicy{MaxAge: time.Hour, WarnFraction: 0.5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	if err := tracker.Record(UsageEncryption); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(40 * time.Minute)
+	if err := tracker.Check(); !errors.Is(err, ErrRotationRecommended) {
+		t.Error("expected warning after 40 minutes, got ", err)
+	}
+
+	now = now.Add(time.Hour)
+	if err := tracker.Record(UsageEncryption); !errors.Is(err, ErrRotationRequired) {
+		t.Error("expected hard stop after 100 minutes, got ", err)
+	}
+}
@@ -0,0 +1,60 @@
+package paillier
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestKeyGenContextCancellation(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := KeyGenContext(ctx, 2048, nil); !errors.Is(err, context.Canceled) {
+		t.Error("expected context.Canceled, got ", err)
+	}
+	if _, _, err := KeyGenContext(ctx, 2048, &KeyGenOptions{SafePrimes: true}); !errors.Is(err, context.Canceled) {
+		t.Error("expected context.Canceled for safe primes, got ", err)
+	}
+
+	tkg, err := NewThresholdKeyGenerator(4096, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := tkg.GenerateKeysContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected context.DeadlineExceeded, got ", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("key generation was not aborted promptly: ", elapsed)
+	}
+
+	// the search goroutines have stopped
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines leaked", after-before)
+	}
+}
+
+func TestKeyGenContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, _, err := KeyGenContext(ctx, 128, &KeyGenOptions{SafePrimes: true}); err != nil {
+		t.Error(err)
+	}
+
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	if _, err := tkg.GenerateKeysContext(ctx); err != nil {
+		t.Error(err)
+	}
+}
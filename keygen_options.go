@@ -0,0 +1,112 @@
+package paillier
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"time"
+)
+
+// safePrimeTimeout bounds the search for each safe prime of a key
+const safePrimeTimeout = 120 * time.Second
+
+// KeyGenOptions configure KeyGenWithOptions
+type KeyGenOptions struct {
+	// Random is the source of randomness; nil means crypto/rand.Reader
+	Random io.Reader
+
+	// SafePrimes requires p = 2p' + 1 and q = 2q' + 1 for primes p' and q',
+	// as assumed by several security proofs, e.g., of threshold Paillier.
+	// Safe primes are much rarer than primes, so they are searched on one
+	// goroutine per core. Keys of ThresholdKeyGenerator always have safe
+	// primes.
+	SafePrimes bool
+
+	// Progress, if set, receives the progress of the prime search
+	Progress KeyGenProgressFunc
+
+	// Deterministic searches for safe primes on a single goroutine so that
+	// the keys only depend on the bytes read from Random, see KeyGenFromSeed.
+	// It is implied if Random is a *CTRDRBG.
+	Deterministic bool
+}
+
+func (opts *KeyGenOptions) random() io.Reader {
+	if opts.Random == nil {
+		return rand.Reader
+	}
+	return opts.Random
+}
+
+// returns two primes of bits bits, which are safe primes if required, and
+// reports the search to progress
+func (opts *KeyGenOptions) generatePrimes(ctx context.Context, bits int, random io.Reader, progress *keyGenProgress) (*big.Int, *big.Int, error) {
+	if opts.SafePrimes {
+		p, q, err := generateSafePrimePairWithTimeout(ctx, bits, safePrimeConcurrency(random, opts.Deterministic), random, progress)
+		return p.p, q.p, err
+	}
+
+	p, err := generatePrime(ctx, bits, random, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+	q, err := generatePrime(ctx, bits, random, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, q, nil
+}
+
+// generateSafePrimePairWithTimeout searches for a pair of safe primes, see
+// generateSafePrimePair, on concurrency goroutines until ctx is done
+func generateSafePrimePairWithTimeout(ctx context.Context, bits, concurrency int, random io.Reader, progress *keyGenProgress) (p, q safePrime, err error) {
+	err = withSafePrimeTimeout(ctx, func(ctx context.Context) error {
+		p, q, err = generateSafePrimePair(ctx, bits, concurrency, random, progress)
+		return err
+	})
+	return p, q, err
+}
+
+// generateSafePrimeWithTimeout searches for a safe prime on concurrency
+// goroutines until ctx is done
+func generateSafePrimeWithTimeout(ctx context.Context, bits, concurrency int, random io.Reader) (p, q *big.Int, err error) {
+	err = withSafePrimeTimeout(ctx, func(ctx context.Context) error {
+		p, q, err = GenerateSafePrimeContext(ctx, bits, concurrency, random)
+		return err
+	})
+	return p, q, err
+}
+
+// runs search with ctx, which is bounded by safePrimeTimeout if it has no
+// deadline
+func withSafePrimeTimeout(ctx context.Context, search func(context.Context) error) error {
+	bounded := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		bounded, cancel = context.WithTimeout(ctx, safePrimeTimeout)
+		defer cancel()
+	}
+
+	err := search(bounded)
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("generator timed out after %v", safePrimeTimeout)
+	}
+	return err
+}
+
+// safePrimeConcurrency returns the number of goroutines searching for a safe
+// prime with randomness from random
+func safePrimeConcurrency(random io.Reader, deterministic bool) int {
+	if deterministic {
+		return 1
+	}
+	if _, ok := random.(*CTRDRBG); ok {
+		// concurrent reads from a deterministic reader are not reproducible
+		return 1
+	}
+	return runtime.NumCPU()
+}
@@ -0,0 +1,28 @@
+package paillier
+
+import (
+	"math/big"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestKeyGenWithSafePrimes(t *testing.T) {
+	sk, pk, err := KeyGenWithOptions(128, &KeyGenOptions{SafePrimes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, q := sk.factorWithPhi()
+	for _, prime := range []*gmp.Int{p, q} {
+		half := new(big.Int).Rsh(ToBigInt(prime), 1)
+		if !prime.ProbablyPrime(20) || !half.ProbablyPrime(20) {
+			t.Errorf("%v is not a safe prime", prime)
+		}
+	}
+
+	ct := pk.Encrypt(gmp.NewInt(42))
+	if sk.Decrypt(ct).Cmp(gmp.NewInt(42)) != 0 {
+		t.Error("wrong decryption")
+	}
+}
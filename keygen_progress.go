@@ -0,0 +1,87 @@
+package paillier
+
+import (
+	"sync"
+)
+
+// KeyGenPhase identifies the step of key generation a KeyGenProgress reports
+type KeyGenPhase string
+
+const (
+	// KeyGenCandidatesTested -- prime candidates that survived sieving were
+	// tested for primality; reported repeatedly while the search runs
+	KeyGenCandidatesTested KeyGenPhase = "candidates_tested"
+
+	// KeyGenPrimeFound -- a prime, or a safe prime, was found
+	KeyGenPrimeFound KeyGenPhase = "prime_found"
+
+	// KeyGenShareDealt -- the secret key share of a decryption server was
+	// created
+	KeyGenShareDealt KeyGenPhase = "share_dealt"
+)
+
+// KeyGenProgress reports the progress of a key generation. The counters are
+// totals since the key generation started.
+type KeyGenProgress struct {
+	Phase      KeyGenPhase
+	Candidates int // prime candidates tested for primality
+	Primes     int // primes found; a safe prime counts once
+	Shares     int // threshold secret key shares dealt
+}
+
+// KeyGenProgressFunc receives the progress of a key generation, e.g., to
+// drive a progress bar or a health check: the number of tested candidates
+// keeps increasing while primes are searched for. It is not called
+// concurrently and must return quickly as it delays the search.
+type KeyGenProgressFunc func(progress KeyGenProgress)
+
+// keyGenProgress accumulates the progress of a key generation and reports
+// it. A nil *keyGenProgress reports nothing.
+type keyGenProgress struct {
+	mu       sync.Mutex
+	report   KeyGenProgressFunc
+	progress KeyGenProgress
+}
+
+func newKeyGenProgress(report KeyGenProgressFunc) *keyGenProgress {
+	if report == nil {
+		return nil
+	}
+	return &keyGenProgress{report: report}
+}
+
+func (kp *keyGenProgress) candidatesTested(count int) {
+	if kp == nil || count == 0 {
+		return
+	}
+	kp.update(KeyGenCandidatesTested, func(progress *KeyGenProgress) {
+		progress.Candidates += count
+	})
+}
+
+func (kp *keyGenProgress) primeFound() {
+	if kp == nil {
+		return
+	}
+	kp.update(KeyGenPrimeFound, func(progress *KeyGenProgress) {
+		progress.Primes++
+	})
+}
+
+func (kp *keyGenProgress) shareDealt() {
+	if kp == nil {
+		return
+	}
+	kp.update(KeyGenShareDealt, func(progress *KeyGenProgress) {
+		progress.Shares++
+	})
+}
+
+func (kp *keyGenProgress) update(phase KeyGenPhase, apply func(*KeyGenProgress)) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	apply(&kp.progress)
+	kp.progress.Phase = phase
+	kp.report(kp.progress)
+}
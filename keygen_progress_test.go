@@ -0,0 +1,79 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+type progressRecorder struct {
+	reports []KeyGenProgress
+}
+
+func (r *progressRecorder) record(progress KeyGenProgress) {
+	r.reports = append(r.reports, progress)
+}
+
+func (r *progressRecorder) last(phase KeyGenPhase) (KeyGenProgress, bool) {
+	for i := len(r.reports) - 1; i >= 0; i-- {
+		if r.reports[i].Phase == phase {
+			return r.reports[i], true
+		}
+	}
+	return KeyGenProgress{}, false
+}
+
+func TestThresholdKeyGenProgress(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 4, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	recorder := &progressRecorder{}
+	tkg.Progress = recorder.record
+	if _, err := tkg.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the counters never decrease
+	for i := 1; i < len(recorder.reports); i++ {
+		prev, cur := recorder.reports[i-1], recorder.reports[i]
+		if cur.Candidates < prev.Candidates || cur.Primes < prev.Primes || cur.Shares < prev.Shares {
+			t.Fatalf("progress went backwards from %+v to %+v", prev, cur)
+		}
+	}
+
+	found, ok := recorder.last(KeyGenPrimeFound)
+	if !ok || found.Primes < 2 {
+		t.Error("expected at least two safe primes to be reported, got ", found)
+	}
+	if tested, ok := recorder.last(KeyGenCandidatesTested); !ok || tested.Candidates < found.Primes {
+		t.Error("expected tested candidates to be reported, got ", tested)
+	}
+	final := recorder.reports[len(recorder.reports)-1]
+	if final.Phase != KeyGenShareDealt || final.Shares != 4 {
+		t.Error("expected the four shares to be reported last, got ", final)
+	}
+}
+
+func TestKeyGenProgress(t *testing.T) {
+	recorder := &progressRecorder{}
+	if _, _, err := KeyGenWithOptions(128, &KeyGenOptions{Progress: recorder.record}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, ok := recorder.last(KeyGenPrimeFound)
+	if !ok || found.Primes < 2 {
+		t.Error("expected at least two primes to be reported, got ", found)
+	}
+	if found.Candidates < found.Primes {
+		t.Error("fewer candidates than primes reported: ", found)
+	}
+	if _, ok := recorder.last(KeyGenShareDealt); ok {
+		t.Error("unexpected share reported for a regular key")
+	}
+
+	// no progress is reported without a callback
+	if newKeyGenProgress(nil) != nil {
+		t.Error("expected a nil reporter without a callback")
+	}
+}
@@ -0,0 +1,245 @@
+package paillier
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+// A key generation ceremony can be split into two steps instead of calling
+// GenerateKeys: GeneratePrimes searches for the safe primes, which may take
+// hours for large keys, and DealKeys deals the shares. In between, and after
+// dealing, State returns the intermediate state of the generator, which can
+// be saved with MarshalBinary, audited, and passed to RestoreKeyGenerator in
+// another process, e.g., on an air-gapped machine that deals the shares.
+//
+// The state contains the factorization of N and, once dealt, the polynomial
+// that determines every share, so it is as sensitive as all the shares
+// together and must be destroyed after the ceremony.
+
+// KeyGenState is the intermediate state of a ThresholdKeyGenerator. P, Q and V
+// are nil before GeneratePrimes and Coefficients is nil before DealKeys.
+type KeyGenState struct {
+	PublicKeyBitLength             int
+	TotalNumberOfDecryptionServers int
+	Threshold                      int
+	S                              int
+	Security                       SecurityParams
+
+	P, Q         *gmp.Int   // the safe primes with N = P*Q
+	V            *gmp.Int   // the generator of the verification keys
+	Coefficients []*gmp.Int // the coefficients of the hiding polynomial
+}
+
+// GeneratePrimes searches for the safe primes of the key and computes the
+// values derived from them, discarding a previously dealt polynomial. It is
+// the first step of GenerateKeys, see State.
+func (tkg *ThresholdKeyGenerator) GeneratePrimes(ctx context.Context) error {
+	tkg.progress = newKeyGenProgress(tkg.Progress)
+	if err := tkg.checkParameters(); err != nil {
+		return err
+	}
+
+	tkg.polynomial = nil
+	return tkg.initNumerialValues(ctx)
+}
+
+// DealKeys returns the keys of the decryption servers for the primes of
+// GeneratePrimes. The hiding polynomial is chosen on the first call, or taken
+// from the state the generator was restored from, so later calls deal the
+// same shares.
+func (tkg *ThresholdKeyGenerator) DealKeys() ([]*ThresholdSecretKey, error) {
+	if tkg.v == nil {
+		return nil, errors.New("Safe primes have not been generated")
+	}
+	if err := tkg.checkParameters(); err != nil {
+		return nil, err
+	}
+
+	if tkg.polynomial == nil {
+		if err := tkg.generateHidingPolynomial(); err != nil {
+			return nil, err
+		}
+	}
+	tsks := tkg.createPrivateKeys()
+	logEvent(EventKeyGenerated, &tsks[0].ThresholdPublicKey, 0, nil)
+	return tsks, nil
+}
+
+// State returns a copy of the intermediate state of the generator
+func (tkg *ThresholdKeyGenerator) State() *KeyGenState {
+	state := &KeyGenState{
+		PublicKeyBitLength:             tkg.PublicKeyBitLength,
+		TotalNumberOfDecryptionServers: tkg.TotalNumberOfDecryptionServers,
+		Threshold:                      tkg.Threshold,
+		S:                              tkg.S,
+		Security:                       tkg.Security,
+		P:                              copyInt(tkg.p),
+		Q:                              copyInt(tkg.q),
+		V:                              copyInt(tkg.v),
+	}
+	if tkg.polynomial != nil {
+		state.Coefficients = make([]*gmp.Int, len(tkg.polynomial.Coefficients))
+		for i, a := range tkg.polynomial.Coefficients {
+			state.Coefficients[i] = copyInt(a)
+		}
+	}
+	return state
+}
+
+// RestoreKeyGenerator returns a generator that continues from the state.
+// It checks that P and Q are distinct safe primes of the expected length and
+// that V and the coefficients are consistent with them, so that a corrupted
+// or tampered checkpoint is rejected instead of producing broken keys. The
+// caller sets the fields that are not part of the state, e.g.,
+// InsecureAllowSmallKeys, before calling DealKeys.
+func RestoreKeyGenerator(state *KeyGenState, random io.Reader) (*ThresholdKeyGenerator, error) {
+	tkg := &ThresholdKeyGenerator{
+		PublicKeyBitLength:             state.PublicKeyBitLength,
+		TotalNumberOfDecryptionServers: state.TotalNumberOfDecryptionServers,
+		Threshold:                      state.Threshold,
+		S:                              state.S,
+		Security:                       state.Security,
+		random:                         random,
+	}
+	if err := tkg.validate(); err != nil {
+		return nil, err
+	}
+	if state.S < 0 || state.S > MaxEncryptionLevel.S() {
+		return nil, fmt.Errorf("Damgard-Jurik exponent must be between 1 and %d, got %d", MaxEncryptionLevel.S(), state.S)
+	}
+
+	if state.P == nil || state.Q == nil {
+		if state.V != nil || state.Coefficients != nil {
+			return nil, errors.New("Key generation state has values but no primes")
+		}
+		return tkg, nil
+	}
+	if err := tkg.restorePrimes(state.P, state.Q); err != nil {
+		return nil, err
+	}
+
+	if state.V == nil {
+		if state.Coefficients != nil {
+			return nil, errors.New("Key generation state has coefficients but no V")
+		}
+		return tkg, tkg.computeV()
+	}
+	if state.V.Sign() <= 0 || state.V.Cmp(tkg.n2) >= 0 || new(gmp.Int).GCD(nil, nil, state.V, tkg.n).Cmp(OneBigInt) != 0 {
+		return nil, errors.New("V is not a unit mod N^2")
+	}
+	tkg.v = copyInt(state.V)
+
+	if state.Coefficients != nil {
+		if err := tkg.restorePolynomial(state.Coefficients); err != nil {
+			return nil, err
+		}
+	}
+	return tkg, nil
+}
+
+// restorePrimes sets the primes and the values derived from them
+func (tkg *ThresholdKeyGenerator) restorePrimes(p, q *gmp.Int) error {
+	for _, prime := range []*gmp.Int{p, q} {
+		if prime.BitLen() != tkg.PublicKeyBitLength/2 || !isSafePrime(prime) {
+			return fmt.Errorf("%v is not a safe prime of %d bits", prime, tkg.PublicKeyBitLength/2)
+		}
+	}
+
+	tkg.p, tkg.q = copyInt(p), copyInt(q)
+	tkg.p1 = new(gmp.Int).Rsh(tkg.p, 1)
+	tkg.q1 = new(gmp.Int).Rsh(tkg.q, 1)
+	if !tkg.arePsAndQsGood() {
+		return errors.New("P and Q are not distinct")
+	}
+
+	tkg.initShortcuts()
+	tkg.initD()
+	return nil
+}
+
+// restorePolynomial sets the hiding polynomial, whose constant term must be d
+func (tkg *ThresholdKeyGenerator) restorePolynomial(coefficients []*gmp.Int) error {
+	if len(coefficients) != tkg.Threshold {
+		return fmt.Errorf("%d coefficients for threshold %d", len(coefficients), tkg.Threshold)
+	}
+	if coefficients[0] == nil || coefficients[0].Cmp(tkg.d) != 0 {
+		return errors.New("Constant coefficient does not match the primes")
+	}
+
+	polynomial := &shamir.Polynomial{Coefficients: make([]*gmp.Int, len(coefficients)), Modulus: tkg.nm}
+	for i, a := range coefficients {
+		if a == nil || a.Sign() < 0 || a.Cmp(tkg.nm) >= 0 {
+			return fmt.Errorf("Coefficient %d is out of range", i)
+		}
+		polynomial.Coefficients[i] = copyInt(a)
+	}
+	tkg.polynomial = polynomial
+	return nil
+}
+
+// isSafePrime returns true iff p and (p-1)/2 are probably prime
+func isSafePrime(p *gmp.Int) bool {
+	q := new(big.Int).Rsh(ToBigInt(p), 1)
+	return ToBigInt(p).ProbablyPrime(20) && q.ProbablyPrime(20)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (state *KeyGenState) MarshalBinary() ([]byte, error) {
+	buf := newBinaryBuffer(binaryTagKeyGenState)
+	for _, v := range []int{
+		state.PublicKeyBitLength, state.TotalNumberOfDecryptionServers, state.Threshold, state.S,
+		state.Security.ChallengeBits, state.Security.StatisticalBits,
+	} {
+		if v < 0 {
+			return nil, errors.New("key generation state has a negative parameter")
+		}
+		binary.Write(buf, binary.BigEndian, uint32(v))
+	}
+	writeBinaryInts(buf, state.P, state.Q, state.V)
+	binary.Write(buf, binary.BigEndian, uint32(len(state.Coefficients)))
+	writeBinaryInts(buf, state.Coefficients...)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. The
+// values are checked by RestoreKeyGenerator.
+func (state *KeyGenState) UnmarshalBinary(data []byte) error {
+	r, err := newBinaryReader(data, binaryTagKeyGenState)
+	if err != nil {
+		return err
+	}
+
+	var v KeyGenState
+	v.PublicKeyBitLength = int(r.readUint32())
+	v.TotalNumberOfDecryptionServers = int(r.readUint32())
+	v.Threshold = int(r.readUint32())
+	v.S = int(r.readUint32())
+	v.Security.ChallengeBits = int(r.readUint32())
+	v.Security.StatisticalBits = int(r.readUint32())
+	v.P, v.Q, v.V = r.readInt(), r.readInt(), r.readInt()
+
+	count := r.readUint32()
+	// every coefficient takes at least its 4-byte length prefix
+	if uint64(count)*4 > uint64(len(r.data)) {
+		return errors.New("too many coefficients")
+	}
+	if count > 0 {
+		v.Coefficients = make([]*gmp.Int, count)
+		for i := range v.Coefficients {
+			v.Coefficients[i] = r.readInt()
+		}
+	}
+	if err := r.done(); err != nil {
+		return err
+	}
+
+	*state = v
+	return nil
+}
@@ -0,0 +1,118 @@
+package paillier
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// roundTripKeyGenState saves the state of the generator and restores it as
+// a separate process would
+func roundTripKeyGenState(t *testing.T, tkg *ThresholdKeyGenerator) *ThresholdKeyGenerator {
+	t.Helper()
+	data, err := tkg.State().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state KeyGenState
+	if err := state.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreKeyGenerator(&state, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.InsecureAllowSmallKeys = true
+	return restored
+}
+
+func TestKeyGenState(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+
+	if _, err := tkg.DealKeys(); err == nil {
+		t.Error("expected an error for dealing before the primes are generated")
+	}
+	if err := tkg.GeneratePrimes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the shares are dealt in another process
+	dealer := roundTripKeyGenState(t, tkg)
+	tsks, err := dealer.DealKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tsks[0].N.Cmp(new(gmp.Int).Mul(tkg.p, tkg.q)) != 0 {
+		t.Error("dealt keys have a different modulus")
+	}
+
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(99))
+	m, err := tk.CombinePartialDecryptions([]*PartialDecryption{tsks[0].PartialDecrypt(ct.C), tsks[2].PartialDecrypt(ct.C)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 99 {
+		t.Error("wrong decryption ", m)
+	}
+
+	// a checkpoint after dealing deals the same shares again
+	again, err := roundTripKeyGenState(t, dealer).DealKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tsks {
+		if again[i].Share.Cmp(tsks[i].Share) != 0 || again[i].VerificationKey.Cmp(tsks[i].VerificationKey) != 0 {
+			t.Errorf("share %d differs after restoring", i+1)
+		}
+	}
+}
+
+func TestRestoreKeyGeneratorRejectsTampering(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	if _, err := tkg.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	state := tkg.State()
+
+	notPrime := *state
+	notPrime.P = new(gmp.Int).Add(state.P, TwoBigInt)
+	if _, err := RestoreKeyGenerator(&notPrime, rand.Reader); err == nil {
+		t.Error("expected an error for a P that is not a safe prime")
+	}
+
+	same := *state
+	same.Q = state.P
+	if _, err := RestoreKeyGenerator(&same, rand.Reader); err == nil {
+		t.Error("expected an error for P = Q")
+	}
+
+	secret := *state
+	secret.Coefficients = append([]*gmp.Int{gmp.NewInt(1)}, state.Coefficients[1:]...)
+	if _, err := RestoreKeyGenerator(&secret, rand.Reader); err == nil {
+		t.Error("expected an error for a constant coefficient other than d")
+	}
+
+	short := *state
+	short.Coefficients = state.Coefficients[:1]
+	if _, err := RestoreKeyGenerator(&short, rand.Reader); err == nil {
+		t.Error("expected an error for too few coefficients")
+	}
+
+	data, _ := state.MarshalBinary()
+	var decoded KeyGenState
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("expected an error for a truncated state")
+	}
+}
@@ -0,0 +1,105 @@
+package paillier
+
+import (
+	"container/list"
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+// lagrangeCache is an LRU cache of the Lagrange coefficients of share
+// combining keyed by the set of server IDs. A nil cache is disabled.
+type lagrangeCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *lagrangeCacheEntry, most recent first
+	entries map[string]*list.Element
+}
+
+type lagrangeCacheEntry struct {
+	key     string
+	lambdas map[int]*gmp.Int // by server ID
+}
+
+// EnableLagrangeCache keeps the Lagrange coefficients of the last size sets
+// of servers whose partial decryptions were combined. The coefficients only
+// depend on the set of servers, so a combiner that receives the shares of
+// the same servers for many ciphertexts computes them once per set. The
+// combining constant is always cached. A size of zero disables the cache.
+// The cache is safe for concurrent use; this method must not be called
+// concurrently with combining.
+func (tk *ThresholdPublicKey) EnableLagrangeCache(size int) {
+	if size <= 0 {
+		tk.lagrange = nil
+		return
+	}
+
+	tk.lagrange = &lagrangeCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// returns the Lagrange coefficient of each share for the set of shares
+func (tk *ThresholdPublicKey) computeLambdas(shares []*PartialDecryption) []*gmp.Int {
+	ids := make([]int, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+
+	lambdas := tk.lagrange.get(ids, func() map[int]*gmp.Int {
+		ret := make(map[int]*gmp.Int, len(ids))
+		for _, id := range ids {
+			ret[id] = shamir.LagrangeCoefficient(id, ids, tk.delta())
+		}
+		return ret
+	})
+
+	ret := make([]*gmp.Int, len(shares))
+	for i, id := range ids {
+		ret[i] = lambdas[id]
+	}
+	return ret
+}
+
+// returns the cached coefficients of the IDs or those computed by compute,
+// which are cached. The returned coefficients must not be modified.
+func (c *lagrangeCache) get(ids []int, compute func() map[int]*gmp.Int) map[int]*gmp.Int {
+	if c == nil {
+		return compute()
+	}
+
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+	key := make([]byte, 0, 4*len(sorted))
+	for _, id := range sorted {
+		key = binary.BigEndian.AppendUint32(key, uint32(id))
+	}
+
+	c.mu.Lock()
+	if element, ok := c.entries[string(key)]; ok {
+		c.order.MoveToFront(element)
+		c.mu.Unlock()
+		return element.Value.(*lagrangeCacheEntry).lambdas
+	}
+	c.mu.Unlock()
+
+	lambdas := compute()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[string(key)]; !ok {
+		c.entries[string(key)] = c.order.PushFront(&lagrangeCacheEntry{key: string(key), lambdas: lambdas})
+		if c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*lagrangeCacheEntry).key)
+		}
+	}
+	return lambdas
+}
@@ -0,0 +1,61 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestLagrangeCache(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	tk.EnableLagrangeCache(2)
+
+	combine := func(m int64, servers ...int) {
+		t.Helper()
+		ct := tk.Encrypt(gmp.NewInt(m))
+		shares := make([]*PartialDecryption, len(servers))
+		for i, server := range servers {
+			shares[i] = tsks[server-1].PartialDecrypt(ct.C)
+		}
+		result, err := tk.CombinePartialDecryptions(shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Cmp(gmp.NewInt(m)) != 0 {
+			t.Errorf("servers %v: wrong plaintext %v", servers, result)
+		}
+	}
+
+	// the order of the shares does not matter
+	combine(1, 1, 2, 3)
+	combine(2, 3, 1, 2)
+	if tk.lagrange.order.Len() != 1 {
+		t.Error("expected a single cached set, got ", tk.lagrange.order.Len())
+	}
+
+	// the set {1, 2, 3} is the least recently used and is evicted
+	combine(3, 2, 4, 5)
+	combine(4, 1, 4, 5)
+	if tk.lagrange.order.Len() != 2 {
+		t.Error("expected two cached sets, got ", tk.lagrange.order.Len())
+	}
+	if _, ok := tk.lagrange.entries[string([]byte{0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3})]; ok {
+		t.Error("least recently used set was not evicted")
+	}
+
+	tk.EnableLagrangeCache(0)
+	if tk.lagrange != nil {
+		t.Error("cache was not disabled")
+	}
+	combine(5, 1, 2, 3)
+}
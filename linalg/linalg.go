@@ -0,0 +1,202 @@
+// Package linalg provides encrypted vectors and matrices on top of Paillier
+// ciphertexts, e.g., for privacy-preserving linear regression.
+//
+// Elements are signed integers encoded with PublicKey.EncodeSigned, so every
+// intermediate result must stay below N/2 in absolute value. Element-wise
+// operations and dot products are distributed over all cores.
+package linalg
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/sachaservan/paillier"
+)
+
+// EncryptedVector is a vector of encrypted signed integers
+type EncryptedVector struct {
+	Key      *paillier.PublicKey
+	Elements []*paillier.Ciphertext
+}
+
+// EncryptedMatrix is a matrix of encrypted signed integers in row-major order
+type EncryptedMatrix struct {
+	Key        *paillier.PublicKey
+	Rows, Cols int
+	Elements   []*paillier.Ciphertext
+}
+
+// EncryptVector encrypts the values
+func EncryptVector(pk *paillier.PublicKey, values []*big.Int) *EncryptedVector {
+	elements := make([]*paillier.Ciphertext, len(values))
+	parallelFor(len(values), func(i int) {
+		elements[i] = pk.Encrypt(pk.EncodeSigned(values[i]))
+	})
+	return &EncryptedVector{Key: pk, Elements: elements}
+}
+
+// Len returns the number of elements
+func (v *EncryptedVector) Len() int {
+	return len(v.Elements)
+}
+
+// Add returns the element-wise sum of the vectors
+func (v *EncryptedVector) Add(w *EncryptedVector) (*EncryptedVector, error) {
+	if v.Len() != w.Len() {
+		return nil, fmt.Errorf("length mismatch: %d and %d", v.Len(), w.Len())
+	}
+
+	elements := make([]*paillier.Ciphertext, v.Len())
+	parallelFor(len(elements), func(i int) {
+		elements[i] = v.Key.Add(v.Elements[i], w.Elements[i])
+	})
+	return &EncryptedVector{Key: v.Key, Elements: elements}, nil
+}
+
+// ScalarMul returns the vector multiplied by k
+func (v *EncryptedVector) ScalarMul(k *big.Int) *EncryptedVector {
+	elements := make([]*paillier.Ciphertext, v.Len())
+	parallelFor(len(elements), func(i int) {
+		elements[i] = v.Key.ECMult(v.Elements[i], k)
+	})
+	return &EncryptedVector{Key: v.Key, Elements: elements}
+}
+
+// Dot returns an encryption of the dot product with the plaintext vector
+func (v *EncryptedVector) Dot(p []*big.Int) (*paillier.Ciphertext, error) {
+	if v.Len() != len(p) {
+		return nil, fmt.Errorf("length mismatch: %d and %d", v.Len(), len(p))
+	}
+	return v.dot(p), nil
+}
+
+func (v *EncryptedVector) dot(p []*big.Int) *paillier.Ciphertext {
+	terms := make([]*paillier.Ciphertext, len(p))
+	parallelFor(len(p), func(i int) {
+		terms[i] = v.Key.ECMult(v.Elements[i], p[i])
+	})
+	return v.Key.EAddMany(terms...)
+}
+
+// Decrypt returns the signed plaintexts, e.g., with a *SecretKey or a
+// *ThresholdClient, or the first error of the decrypter
+func (v *EncryptedVector) Decrypt(d paillier.Decrypter) ([]*big.Int, error) {
+	values := make([]*big.Int, v.Len())
+	errs := make([]error, v.Len())
+	parallelFor(len(values), func(i int) {
+		m, err := d.TryDecrypt(v.Elements[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		values[i] = v.Key.DecodeSigned(m)
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// EncryptMatrix encrypts the rows, which must all have the same length
+func EncryptMatrix(pk *paillier.PublicKey, rows [][]*big.Int) (*EncryptedMatrix, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("matrix has no rows")
+	}
+
+	cols := len(rows[0])
+	values := make([]*big.Int, 0, len(rows)*cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, fmt.Errorf("row %d has %d columns instead of %d", i, len(row), cols)
+		}
+		values = append(values, row...)
+	}
+
+	v := EncryptVector(pk, values)
+	return &EncryptedMatrix{Key: pk, Rows: len(rows), Cols: cols, Elements: v.Elements}, nil
+}
+
+// At returns the element in row i and column j
+func (m *EncryptedMatrix) At(i, j int) *paillier.Ciphertext {
+	return m.Elements[i*m.Cols+j]
+}
+
+// Row returns row i as a vector that shares the ciphertexts of the matrix
+func (m *EncryptedMatrix) Row(i int) *EncryptedVector {
+	return &EncryptedVector{Key: m.Key, Elements: m.Elements[i*m.Cols : (i+1)*m.Cols]}
+}
+
+// Add returns the element-wise sum of the matrices
+func (m *EncryptedMatrix) Add(o *EncryptedMatrix) (*EncryptedMatrix, error) {
+	if m.Rows != o.Rows || m.Cols != o.Cols {
+		return nil, fmt.Errorf("dimension mismatch: %dx%d and %dx%d", m.Rows, m.Cols, o.Rows, o.Cols)
+	}
+
+	sum, err := m.vector().Add(o.vector())
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedMatrix{Key: m.Key, Rows: m.Rows, Cols: m.Cols, Elements: sum.Elements}, nil
+}
+
+// ScalarMul returns the matrix multiplied by k
+func (m *EncryptedMatrix) ScalarMul(k *big.Int) *EncryptedMatrix {
+	product := m.vector().ScalarMul(k)
+	return &EncryptedMatrix{Key: m.Key, Rows: m.Rows, Cols: m.Cols, Elements: product.Elements}
+}
+
+// MulVector returns the encrypted product of the matrix with the plaintext
+// column vector
+func (m *EncryptedMatrix) MulVector(p []*big.Int) (*EncryptedVector, error) {
+	if len(p) != m.Cols {
+		return nil, fmt.Errorf("length mismatch: %d columns and %d values", m.Cols, len(p))
+	}
+
+	elements := make([]*paillier.Ciphertext, m.Rows)
+	for i := range elements {
+		elements[i] = m.Row(i).dot(p)
+	}
+	return &EncryptedVector{Key: m.Key, Elements: elements}, nil
+}
+
+// Decrypt returns the signed plaintexts row by row
+func (m *EncryptedMatrix) Decrypt(d paillier.Decrypter) ([][]*big.Int, error) {
+	values, err := m.vector().Decrypt(d)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]*big.Int, m.Rows)
+	for i := range rows {
+		rows[i] = values[i*m.Cols : (i+1)*m.Cols]
+	}
+	return rows, nil
+}
+
+func (m *EncryptedMatrix) vector() *EncryptedVector {
+	return &EncryptedVector{Key: m.Key, Elements: m.Elements}
+}
+
+// parallelFor calls fn for 0 <= i < n on one goroutine per core
+func parallelFor(n int, fn func(i int)) {
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < n; i += workers {
+				fn(i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
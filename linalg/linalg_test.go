@@ -0,0 +1,98 @@
+package linalg
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sachaservan/paillier"
+)
+
+func ints(values ...int64) []*big.Int {
+	result := make([]*big.Int, len(values))
+	for i, v := range values {
+		result[i] = big.NewInt(v)
+	}
+	return result
+}
+
+func equal(a []*big.Int, b ...int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Int64() != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEncryptedVector(t *testing.T) {
+	sk, pk := paillier.KeyGen(128)
+
+	v := EncryptVector(pk, ints(1, -2, 3))
+	w := EncryptVector(pk, ints(10, 20, -30))
+
+	sum, err := v.Add(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, err := sum.Decrypt(sk); err != nil || !equal(values, 11, 18, -27) {
+		t.Error("wrong sum ", values)
+	}
+
+	if values, err := v.ScalarMul(big.NewInt(-4)).Decrypt(sk); err != nil || !equal(values, -4, 8, -12) {
+		t.Error("wrong scalar product ", values)
+	}
+
+	dot, err := v.Dot(ints(5, 6, -7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := pk.DecodeSigned(sk.Decrypt(dot)); m.Int64() != 5-12-21 {
+		t.Error("wrong dot product ", m)
+	}
+
+	if _, err := v.Add(EncryptVector(pk, ints(1))); err == nil {
+		t.Error("vectors of different lengths were added")
+	}
+	if _, err := v.Dot(ints(1, 2)); err == nil {
+		t.Error("dot product of different lengths was computed")
+	}
+}
+
+func TestEncryptedMatrix(t *testing.T) {
+	sk, pk := paillier.KeyGen(128)
+
+	m, err := EncryptMatrix(pk, [][]*big.Int{ints(1, 2), ints(3, 4), ints(-5, 6)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := m.MulVector(ints(2, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, err := product.Decrypt(sk); err != nil || !equal(values, 0, 2, -16) {
+		t.Error("wrong matrix vector product ", values)
+	}
+
+	doubled, err := m.Add(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := doubled.ScalarMul(big.NewInt(3)).Decrypt(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(rows[0], 6, 12) || !equal(rows[1], 18, 24) || !equal(rows[2], -30, 36) {
+		t.Error("wrong matrix ", rows)
+	}
+
+	if _, err := EncryptMatrix(pk, [][]*big.Int{ints(1, 2), ints(3)}); err == nil {
+		t.Error("ragged matrix was accepted")
+	}
+	if _, err := m.MulVector(ints(1, 2, 3)); err == nil {
+		t.Error("vector of wrong length was accepted")
+	}
+}
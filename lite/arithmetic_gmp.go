@@ -0,0 +1,28 @@
+//go:build gmp && cgo
+
+package lite
+
+import (
+	"math/big"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// GMP is the Arithmetic backed by GMP, which is 2 to 3 times as fast as
+// math/big for 3072-bit keys. Builds with the gmp tag use it by default.
+type GMP struct{}
+
+func init() {
+	defaultArithmetic = GMP{}
+}
+
+// Exp implements the Arithmetic interface
+func (GMP) Exp(x, y, m *big.Int) *big.Int {
+	z := new(gmp.Int).Exp(toGMP(x), toGMP(y), toGMP(m))
+	return new(big.Int).SetBytes(z.Bytes())
+}
+
+// toGMP converts a non-negative integer
+func toGMP(x *big.Int) *gmp.Int {
+	return new(gmp.Int).SetBytes(x.Bytes())
+}
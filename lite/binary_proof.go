@@ -0,0 +1,153 @@
+package lite
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// BinaryProof is the OR-proof of the main package that a level one
+// ciphertext encrypts 0 or 1
+type BinaryProof struct {
+	A0, A1 *big.Int // commitments
+	E0, E1 *big.Int // sub-challenges
+	Z0, Z1 *big.Int // responses
+}
+
+// binaryProofTag matches the domain tag of the binary proofs of the main
+// package
+var binaryProofTag = []byte("paillier-binary-proof-v1")
+
+// EncryptBitWithProof encrypts bit (0 or 1) and returns the ciphertext with
+// a proof that it encrypts a bit
+func (pk *PublicKey) EncryptBitWithProof(bit int) (*Ciphertext, *BinaryProof, error) {
+	if bit != 0 && bit != 1 {
+		return nil, nil, errors.New("value must be 0 or 1")
+	}
+
+	r, err := pk.randomUnit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ct, err := pk.EncryptWithR(big.NewInt(int64(bit)), r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bits := pk.challengeBitLength()
+	mod := new(big.Int).Lsh(one, uint(bits))
+
+	a := make([]*big.Int, 2)
+	e := make([]*big.Int, 2)
+	z := make([]*big.Int, 2)
+
+	// simulate the proof for the other bit: a = z^N / u^e
+	other := 1 - bit
+	if e[other], err = rand.Int(pk.random(), mod); err != nil {
+		return nil, nil, err
+	}
+	if z[other], err = pk.randomUnit(); err != nil {
+		return nil, nil, err
+	}
+	a[other] = pk.exp(pk.statement(ct, other), e[other], pk.n2)
+	a[other].ModInverse(a[other], pk.n2)
+	a[other].Mul(a[other], pk.exp(z[other], pk.N, pk.n2))
+	a[other].Mod(a[other], pk.n2)
+
+	// commit for the real bit: a = rho^N
+	rho, err := pk.randomUnit()
+	if err != nil {
+		return nil, nil, err
+	}
+	a[bit] = pk.exp(rho, pk.N, pk.n2)
+
+	c := pk.binaryProofChallenge(ct, a[0], a[1])
+
+	e[bit] = new(big.Int).Sub(c, e[other])
+	e[bit].Mod(e[bit], mod)
+	z[bit] = pk.exp(r, e[bit], pk.N)
+	z[bit].Mul(z[bit], rho)
+	z[bit].Mod(z[bit], pk.N)
+
+	return ct, &BinaryProof{A0: a[0], A1: a[1], E0: e[0], E1: e[1], Z0: z[0], Z1: z[1]}, nil
+}
+
+// VerifyBinaryProof returns true iff the proof shows that ct encrypts 0 or 1
+func (pk *PublicKey) VerifyBinaryProof(ct *Ciphertext, proof *BinaryProof) bool {
+	if proof == nil || proof.A0 == nil || proof.A1 == nil || proof.E0 == nil ||
+		proof.E1 == nil || proof.Z0 == nil || proof.Z1 == nil {
+		return false
+	}
+
+	if ct == nil || ct.C == nil {
+		return false
+	}
+
+	bits := pk.challengeBitLength()
+	mod := new(big.Int).Lsh(one, uint(bits))
+
+	// without these checks, zero commitments and responses satisfy both
+	// branches for any ciphertext
+	if !pk.units(pk.n2, ct.C, proof.A0, proof.A1) || !pk.units(pk.N, proof.Z0, proof.Z1) {
+		return false
+	}
+	for _, x := range []*big.Int{proof.E0, proof.E1} {
+		if x.Sign() < 0 || x.Cmp(mod) >= 0 {
+			return false
+		}
+	}
+
+	sum := new(big.Int).Add(proof.E0, proof.E1)
+	sum.Mod(sum, mod)
+	if sum.Cmp(pk.binaryProofChallenge(ct, proof.A0, proof.A1)) != 0 {
+		return false
+	}
+
+	a := []*big.Int{proof.A0, proof.A1}
+	e := []*big.Int{proof.E0, proof.E1}
+	z := []*big.Int{proof.Z0, proof.Z1}
+
+	// z^N = a * u^e mod N^2
+	for j := 0; j < 2; j++ {
+		lhs := pk.exp(z[j], pk.N, pk.n2)
+		rhs := pk.exp(pk.statement(ct, j), e[j], pk.n2)
+		rhs.Mul(rhs, a[j])
+		rhs.Mod(rhs, pk.n2)
+		if lhs.Cmp(rhs) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// binaryProofChallenge returns the challenge of the main package for the
+// commitments, with the generator g = N+1
+func (pk *PublicKey) binaryProofChallenge(ct *Ciphertext, a0, a1 *big.Int) *big.Int {
+	return challenge(pk.challengeBitLength(), new(big.Int).SetBytes(binaryProofTag),
+		pk.N, new(big.Int).Add(pk.N, one), ct.C, a0, a1)
+}
+
+// units returns true iff 0 < x < m and x is coprime to N for all the values
+func (pk *PublicKey) units(m *big.Int, values ...*big.Int) bool {
+	gcd := new(big.Int)
+	for _, x := range values {
+		if x.Sign() <= 0 || x.Cmp(m) >= 0 || gcd.GCD(nil, nil, x, pk.N).Cmp(one) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// returns u = ct / g^j mod N^2 with g = N+1
+func (pk *PublicKey) statement(ct *Ciphertext, j int) *big.Int {
+	if j == 0 {
+		return new(big.Int).Set(ct.C)
+	}
+
+	u := new(big.Int).Add(pk.N, one)
+	u.ModInverse(u, pk.n2)
+	u.Mul(u, ct.C)
+	return u.Mod(u, pk.n2)
+}
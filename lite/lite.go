@@ -0,0 +1,235 @@
+// Package lite implements the client side subset of the Paillier cryptosystem,
+// i.e., encryption, homomorphic addition and binary proofs for level one
+// ciphertexts, without cgo, reflection or precomputed tables.
+//
+// The main package depends on GMP through cgo and therefore does not compile
+// for js/wasm or with TinyGo. This package only depends on math/big and the
+// standard library, so browser and embedded clients can encrypt their
+// contributions locally. Servers that prefer speed can build with the gmp tag
+// to back the exponentiations with GMP, see Arithmetic. Ciphertexts and proofs are compatible with the main
+// package: ciphertexts are encoded in the binary format of the v2 package and
+// BinaryProof values verify with PublicKey.VerifyBinaryProof.
+package lite
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+)
+
+var one = big.NewInt(1)
+
+// binaryVersion and the method byte of the v2 ciphertext encoding
+const (
+	binaryVersion      byte = 2
+	levelOne           byte = 1
+	regularEncryption  byte = 0
+	mixedEncryption    byte = 2
+	ciphertextOverhead      = 3
+)
+
+// PublicKey is a Paillier public key with generator G = N+1
+type PublicKey struct {
+	N  *big.Int
+	n2 *big.Int
+
+	// Random is the source of randomness, crypto/rand if nil
+	Random io.Reader
+
+	// LowMemory selects square-and-multiply exponentiation reusing its
+	// temporaries instead of the windowed exponentiation of math/big, which
+	// precomputes a table of 16 values of the size of N^2. Operations are
+	// about 1.5 to 2 times slower but the total memory allocated per
+	// operation, which bounds the peak, stays below the following ceilings
+	// for a 2048-bit N (scaling linearly with the size of N):
+	//
+	//	Encrypt, EncryptWithR        12 KiB  (default mode: 27 KiB)
+	//	Add of two ciphertexts        3 KiB  (default mode:  3 KiB)
+	//	ConstMult, 64-bit constant    6 KiB  (default mode: 23 KiB)
+	//	EncryptBitWithProof          56 KiB  (default mode: 128 KiB)
+	//	VerifyBinaryProof            48 KiB  (default mode: 114 KiB)
+	LowMemory bool
+
+	// Arithmetic performs the modular exponentiations if set, overriding
+	// LowMemory and the default backend, see Arithmetic
+	Arithmetic Arithmetic
+}
+
+// Arithmetic is a backend for the modular exponentiations, which are the
+// whole cost of encryption and proofs. The package uses math/big unless it is
+// built with the gmp tag, which selects GMP through cgo, or a key sets its
+// own backend, e.g., a hardware accelerator.
+type Arithmetic interface {
+	// Exp returns x^y mod m for y >= 0 and m > 0 without modifying its
+	// arguments
+	Exp(x, y, m *big.Int) *big.Int
+}
+
+// defaultArithmetic is set by the gmp build tag
+var defaultArithmetic Arithmetic
+
+// Ciphertext is a level one ciphertext
+type Ciphertext struct {
+	C     *big.Int
+	mixed bool // result of a homomorphic operation
+}
+
+// NewPublicKey returns the public key with the big-endian modulus n,
+// e.g., as obtained from the N.Bytes() of a key of the main package
+func NewPublicKey(n []byte) (*PublicKey, error) {
+	N := new(big.Int).SetBytes(n)
+	if N.BitLen() < 64 || N.Bit(0) == 0 {
+		return nil, errors.New("invalid modulus")
+	}
+
+	return &PublicKey{
+		N:  N,
+		n2: new(big.Int).Mul(N, N),
+	}, nil
+}
+
+// Encrypt encrypts the plaintext 0 <= m < N
+func (pk *PublicKey) Encrypt(m *big.Int) (*Ciphertext, error) {
+	r, err := pk.randomUnit()
+	if err != nil {
+		return nil, err
+	}
+
+	return pk.EncryptWithR(m, r)
+}
+
+// EncryptWithR encrypts the plaintext 0 <= m < N with the randomness r
+// as (1 + mN) r^N mod N^2
+func (pk *PublicKey) EncryptWithR(m, r *big.Int) (*Ciphertext, error) {
+	if m.Sign() < 0 || m.Cmp(pk.N) >= 0 {
+		return nil, errors.New("plaintext is out of range")
+	}
+
+	c := new(big.Int).Mul(m, pk.N)
+	c.Add(c, one)
+	c.Mul(c, pk.exp(r, pk.N, pk.n2))
+	c.Mod(c, pk.n2)
+
+	return &Ciphertext{C: c}, nil
+}
+
+// Add homomorphically adds encrypted values
+func (pk *PublicKey) Add(cts ...*Ciphertext) *Ciphertext {
+	c := big.NewInt(1)
+	for _, ct := range cts {
+		c.Mul(c, ct.C)
+		c.Mod(c, pk.n2)
+	}
+
+	return &Ciphertext{C: c, mixed: true}
+}
+
+// ConstMult multiplies an encrypted value by the constant k >= 0
+func (pk *PublicKey) ConstMult(ct *Ciphertext, k *big.Int) *Ciphertext {
+	return &Ciphertext{C: pk.exp(ct.C, k, pk.n2), mixed: true}
+}
+
+// MarshalBinary encodes the ciphertext in the format of the v2 package
+func (ct *Ciphertext) MarshalBinary() ([]byte, error) {
+	method := regularEncryption
+	if ct.mixed {
+		method = mixedEncryption
+	}
+
+	data := []byte{binaryVersion, levelOne, method}
+	return append(data, ct.C.Bytes()...), nil
+}
+
+// UnmarshalBinary decodes a level one ciphertext in the format of the v2 package
+func (ct *Ciphertext) UnmarshalBinary(data []byte) error {
+	if len(data) <= ciphertextOverhead {
+		return errors.New("ciphertext encoding is too short")
+	}
+
+	if data[0] != binaryVersion || data[1] != levelOne {
+		return errors.New("unsupported ciphertext encoding")
+	}
+
+	ct.C = new(big.Int).SetBytes(data[ciphertextOverhead:])
+	ct.mixed = data[2] != regularEncryption
+	return nil
+}
+
+func (pk *PublicKey) random() io.Reader {
+	if pk.Random == nil {
+		return rand.Reader
+	}
+	return pk.Random
+}
+
+// exp returns x^y mod m for y >= 0
+func (pk *PublicKey) exp(x, y, m *big.Int) *big.Int {
+	switch {
+	case pk.Arithmetic != nil:
+		return pk.Arithmetic.Exp(x, y, m)
+	case pk.LowMemory:
+	case defaultArithmetic != nil:
+		return defaultArithmetic.Exp(x, y, m)
+	default:
+		return new(big.Int).Exp(x, y, m)
+	}
+
+	// left-to-right square-and-multiply reusing the product and quotient
+	base := new(big.Int).Mod(x, m)
+	z := big.NewInt(1)
+	product := new(big.Int)
+	quotient := new(big.Int)
+	for i := y.BitLen() - 1; i >= 0; i-- {
+		product.Mul(z, z)
+		quotient.QuoRem(product, m, z)
+		if y.Bit(i) == 1 {
+			product.Mul(z, base)
+			quotient.QuoRem(product, m, z)
+		}
+	}
+
+	return z
+}
+
+// returns a random element of Z_N^*
+func (pk *PublicKey) randomUnit() (*big.Int, error) {
+	gcd := new(big.Int)
+	for {
+		r, err := rand.Int(pk.random(), pk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Sign() > 0 && gcd.GCD(nil, nil, r, pk.N).Cmp(one) == 0 {
+			return r, nil
+		}
+	}
+}
+
+// challengeBitLength and challenge match the Fiat-Shamir challenges of the
+// main package
+func (pk *PublicKey) challengeBitLength() int {
+	bits := pk.N.BitLen()/2 - 1
+	if bits > 256 {
+		bits = 256
+	}
+	return bits
+}
+
+func challenge(bits int, values ...*big.Int) *big.Int {
+	hash := sha256.New()
+	for _, v := range values {
+		b := v.Bytes()
+		hash.Write([]byte{byte(len(b) >> 24), byte(len(b) >> 16), byte(len(b) >> 8), byte(len(b))})
+		hash.Write(b)
+	}
+
+	e := new(big.Int).SetBytes(hash.Sum(nil))
+	if bits < 256 {
+		e.Rsh(e, uint(256-bits))
+	}
+
+	return e
+}
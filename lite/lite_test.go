@@ -0,0 +1,206 @@
+package lite
+
+import (
+	"crypto/rand"
+	"math/big"
+	"runtime"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+	paillier "github.com/sachaservan/paillier"
+	v2 "github.com/sachaservan/paillier/v2"
+)
+
+func TestCompatibility(t *testing.T) {
+	sk, pk := paillier.KeyGen(128)
+
+	litePk, err := NewPublicKey(pk.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := litePk.Encrypt(big.NewInt(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, proof, err := litePk.EncryptBitWithProof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !litePk.VerifyBinaryProof(b, proof) {
+		t.Fatal("binary proof does not verify")
+	}
+
+	sum := litePk.Add(litePk.ConstMult(a, big.NewInt(2)), b)
+	data, err := sum.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, err := v2.ParseCiphertext(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := sk.Decrypt(ct.V1()); m.Int64() != 41 {
+		t.Error("wrong decryption ", m, " is not 41")
+	}
+
+	gmpProof := &paillier.BinaryProof{
+		A0: toGmp(proof.A0), A1: toGmp(proof.A1),
+		E0: toGmp(proof.E0), E1: toGmp(proof.E1),
+		Z0: toGmp(proof.Z0), Z1: toGmp(proof.Z1),
+	}
+	if !pk.VerifyBinaryProof(&paillier.Ciphertext{C: toGmp(b.C)}, gmpProof) {
+		t.Error("binary proof does not verify with the main package")
+	}
+
+	if litePk.VerifyBinaryProof(a, proof) {
+		t.Error("binary proof verified for another ciphertext")
+	}
+
+	if _, err := litePk.Encrypt(litePk.N); err == nil {
+		t.Error("expected error for plaintext out of range")
+	}
+}
+
+func TestBinaryProofRejectsForgeries(t *testing.T) {
+	_, pk := paillier.KeyGen(128)
+	litePk, err := NewPublicKey(pk.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []int64{2, 5} {
+		ct, err := litePk.Encrypt(big.NewInt(m))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// zero commitments and responses satisfy both verification equations
+		zero := new(big.Int)
+		forged := &BinaryProof{
+			A0: zero, A1: zero,
+			E0: litePk.binaryProofChallenge(ct, zero, zero), E1: zero,
+			Z0: zero, Z1: zero,
+		}
+		if litePk.VerifyBinaryProof(ct, forged) {
+			t.Errorf("zeroed proof for %d is accepted", m)
+		}
+
+		garbage := &BinaryProof{}
+		for _, v := range []**big.Int{&garbage.A0, &garbage.A1, &garbage.E0, &garbage.E1, &garbage.Z0, &garbage.Z1} {
+			if *v, err = litePk.randomUnit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if litePk.VerifyBinaryProof(ct, garbage) {
+			t.Errorf("garbage proof for %d is accepted", m)
+		}
+	}
+}
+
+func toGmp(x *big.Int) *gmp.Int {
+	return new(gmp.Int).SetBytes(x.Bytes())
+}
+
+func TestLowMemoryCeilings(t *testing.T) {
+	p, _ := rand.Prime(rand.Reader, 1024)
+	q, _ := rand.Prime(rand.Reader, 1024)
+	pk, err := NewPublicKey(new(big.Int).Mul(p, q).Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, proof, err := pk.EncryptBitWithProof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pk.randomUnit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := pk.EncryptWithR(big.NewInt(7), r)
+
+	pk.LowMemory = true
+	if actual, _ := pk.EncryptWithR(big.NewInt(7), r); actual.C.Cmp(expected.C) != 0 {
+		t.Fatal("low memory encryption differs")
+	}
+
+	ceilings := []struct {
+		name  string
+		bytes uint64
+		op    func()
+	}{
+		{"Encrypt", 12 << 10, func() { pk.Encrypt(big.NewInt(7)) }},
+		{"Add", 3 << 10, func() { pk.Add(ct, ct) }},
+		{"ConstMult", 6 << 10, func() { pk.ConstMult(ct, new(big.Int).SetUint64(1<<63)) }},
+		{"EncryptBitWithProof", 56 << 10, func() { pk.EncryptBitWithProof(0) }},
+		{"VerifyBinaryProof", 48 << 10, func() {
+			if !pk.VerifyBinaryProof(ct, proof) {
+				t.Error("binary proof does not verify in low memory mode")
+			}
+		}},
+	}
+
+	for _, c := range ceilings {
+		if allocated := allocatedBytes(c.op); allocated > c.bytes {
+			t.Errorf("%s allocated %d bytes, ceiling is %d", c.name, allocated, c.bytes)
+		}
+	}
+}
+
+// returns the average number of bytes allocated by op
+func allocatedBytes(op func()) uint64 {
+	const runs = 3
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < runs; i++ {
+		op()
+	}
+	runtime.ReadMemStats(&after)
+	return (after.TotalAlloc - before.TotalAlloc) / runs
+}
+
+// countingArithmetic counts the exponentiations it performs with math/big
+type countingArithmetic struct {
+	calls int
+}
+
+func (a *countingArithmetic) Exp(x, y, m *big.Int) *big.Int {
+	a.calls++
+	return new(big.Int).Exp(x, y, m)
+}
+
+func TestArithmetic(t *testing.T) {
+	p, _ := rand.Prime(rand.Reader, 256)
+	q, _ := rand.Prime(rand.Reader, 256)
+	pk, err := NewPublicKey(new(big.Int).Mul(p, q).Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pk.randomUnit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := pk.EncryptWithR(big.NewInt(9), r)
+
+	backend := &countingArithmetic{}
+	pk.Arithmetic = backend
+	pk.LowMemory = true
+	if actual, _ := pk.EncryptWithR(big.NewInt(9), r); actual.C.Cmp(expected.C) != 0 {
+		t.Error("encryption with a custom backend differs")
+	}
+
+	ct, proof, err := pk.EncryptBitWithProof(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.VerifyBinaryProof(ct, proof) {
+		t.Error("proof with a custom backend does not verify")
+	}
+	if backend.calls == 0 {
+		t.Error("custom backend was not used")
+	}
+}
@@ -0,0 +1,83 @@
+package paillier
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies a key lifecycle event
+type EventType string
+
+const (
+	// EventKeyGenerated -- a key pair or a set of threshold keys was generated
+	EventKeyGenerated EventType = "key_generated"
+
+	// EventShareValidated -- a threshold secret key was checked with
+	// VerifyPartialDecryption; Err is set if the share is invalid
+	EventShareValidated EventType = "share_validated"
+
+	// EventDecryptionRequested -- a ciphertext was decrypted or partially decrypted
+	EventDecryptionRequested EventType = "decryption_requested"
+
+	// EventProofFailed -- a partial decryption proof did not verify
+	EventProofFailed EventType = "proof_failed"
+)
+
+// Event is a structured key lifecycle event
+type Event struct {
+	Type           EventType
+	Time           time.Time
+	KeyFingerprint string // fingerprint of the public or threshold public key involved
+	ShareID        int    // ID of the threshold share involved, 0 if none
+	Err            error
+}
+
+// Logger receives the key lifecycle events of the package, e.g., to forward
+// them to a security monitoring system. Implementations must be safe for
+// concurrent use and must not block.
+type Logger interface {
+	LogEvent(event *Event)
+}
+
+type loggerHolder struct {
+	logger Logger
+}
+
+var eventLogger atomic.Value
+
+// SetLogger installs the logger receiving all key lifecycle events.
+// Passing nil disables logging, which is the default.
+func SetLogger(logger Logger) {
+	eventLogger.Store(loggerHolder{logger})
+}
+
+// fingerprinter is implemented by *PublicKey and *ThresholdPublicKey; the
+// events of threshold keys carry their CommitteeFingerprint
+type fingerprinter interface {
+	eventFingerprint() string
+}
+
+func (pk *PublicKey) eventFingerprint() string {
+	return pk.Fingerprint()
+}
+
+func (tk *ThresholdPublicKey) eventFingerprint() string {
+	return tk.CommitteeFingerprint()
+}
+
+// logEvent reports an event for the key if a logger is installed;
+// the fingerprint is only computed in that case
+func logEvent(eventType EventType, key fingerprinter, shareID int, err error) {
+	holder, _ := eventLogger.Load().(loggerHolder)
+	if holder.logger == nil {
+		return
+	}
+
+	holder.logger.LogEvent(&Event{
+		Type:           eventType,
+		Time:           time.Now(),
+		KeyFingerprint: key.eventFingerprint(),
+		ShareID:        shareID,
+		Err:            err,
+	})
+}
@@ -0,0 +1,75 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+)
+
+type recordingLogger struct {
+	sync.Mutex
+	events []*Event
+}
+
+func (l *recordingLogger) LogEvent(event *Event) {
+	l.Lock()
+	defer l.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *recordingLogger) count(eventType EventType) int {
+	l.Lock()
+	defer l.Unlock()
+	count := 0
+	for _, event := range l.events {
+		if event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLogger(t *testing.T) {
+	logger := new(recordingLogger)
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	tkh, err := NewThresholdKeyGenerator(32, 2, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logger.count(EventKeyGenerated) != 1 {
+		t.Fatal("expected a key generation event")
+	}
+
+	if err := tpks[1].VerifyPartialDecryption(); err != nil {
+		t.Fatal(err)
+	}
+
+	share, err := tpks[0].PartialDecryptionWithZKP(tpks[0].Encrypt(b(3)).C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share.Decryption.Add(share.Decryption, OneBigInt)
+	if share.VerifyProof() {
+		t.Fatal("tampered proof verified")
+	}
+
+	if logger.count(EventShareValidated) != 1 ||
+		logger.count(EventDecryptionRequested) != 2 ||
+		logger.count(EventProofFailed) != 1 {
+		t.Error("wrong events logged")
+	}
+
+	last := logger.events[len(logger.events)-1]
+	if last.ShareID != 1 || last.KeyFingerprint != tpks[0].CommitteeFingerprint() {
+		t.Error("proof failure event does not identify the share")
+	}
+}
@@ -0,0 +1,64 @@
+package paillier
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Operation identifies an instrumented operation
+type Operation string
+
+const (
+	// OpKeyGen -- KeyGen and ThresholdKeyGenerator.GenerateKeys
+	OpKeyGen Operation = "keygen"
+
+	// OpEncrypt -- regular and alternative encryption at any level
+	OpEncrypt Operation = "encrypt"
+
+	// OpDecrypt -- decryption with a secret key
+	OpDecrypt Operation = "decrypt"
+
+	// OpPartialDecrypt -- partial decryption with a threshold secret key
+	OpPartialDecrypt Operation = "partial_decrypt"
+
+	// OpCombine -- combining partial decryptions
+	OpCombine Operation = "combine"
+
+	// OpVerifyProof -- verification of a partial decryption proof
+	OpVerifyProof Operation = "verify_proof"
+)
+
+// MetricsSink receives a counter increment and a latency observation for
+// every instrumented operation, e.g., to export them as Prometheus metrics.
+// Implementations must be safe for concurrent use.
+type MetricsSink interface {
+	IncCounter(op Operation, success bool)
+	ObserveLatency(op Operation, elapsed time.Duration)
+}
+
+type metricsSinkHolder struct {
+	sink MetricsSink
+}
+
+var metricsSink atomic.Value
+
+// SetMetricsSink installs the sink invoked by all instrumented operations.
+// Passing nil disables the instrumentation, which is the default.
+func SetMetricsSink(sink MetricsSink) {
+	metricsSink.Store(metricsSinkHolder{sink})
+}
+
+// startOperation starts timing op and returns the function that reports it
+func startOperation(op Operation) func(success bool) {
+	holder, _ := metricsSink.Load().(metricsSinkHolder)
+	if holder.sink == nil {
+		return func(bool) {}
+	}
+
+	sink := holder.sink
+	start := time.Now()
+	return func(success bool) {
+		sink.ObserveLatency(op, time.Since(start))
+		sink.IncCounter(op, success)
+	}
+}
@@ -0,0 +1,80 @@
+package paillier
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+type recordingSink struct {
+	sync.Mutex
+	successes map[Operation]int
+	failures  map[Operation]int
+	latencies map[Operation]int
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{
+		successes: make(map[Operation]int),
+		failures:  make(map[Operation]int),
+		latencies: make(map[Operation]int),
+	}
+}
+
+func (s *recordingSink) IncCounter(op Operation, success bool) {
+	s.Lock()
+	defer s.Unlock()
+	if success {
+		s.successes[op]++
+	} else {
+		s.failures[op]++
+	}
+}
+
+func (s *recordingSink) ObserveLatency(op Operation, elapsed time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.latencies[op]++
+}
+
+func TestMetricsSink(t *testing.T) {
+	sink := newRecordingSink()
+	SetMetricsSink(sink)
+	defer SetMetricsSink(nil)
+
+	sk, pk := KeyGen(64)
+	sk.Decrypt(pk.Encrypt(b(1)))
+	pk.AltEncryptAtLevel(b(2), EncLevelOne)
+
+	share := &PartialDecryptionZKP{
+		PartialDecryption: PartialDecryption{ID: 1, Decryption: b(1)},
+		Key:               &ThresholdPublicKey{PublicKey: *pk, TotalNumberOfDecryptionServers: 1, Threshold: 1, VerificationKey: b(1), VerificationKeys: []*gmp.Int{b(1)}},
+		E:                 b(1),
+		Z:                 b(1),
+		C:                 b(1),
+	}
+	share.VerifyProof()
+
+	expected := map[Operation][2]int{
+		OpKeyGen:      {1, 0},
+		OpEncrypt:     {2, 0},
+		OpDecrypt:     {1, 0},
+		OpVerifyProof: {0, 1},
+	}
+	for op, counts := range expected {
+		if sink.successes[op] != counts[0] || sink.failures[op] != counts[1] {
+			t.Errorf("wrong counters for %s: %d successes, %d failures", op, sink.successes[op], sink.failures[op])
+		}
+		if sink.latencies[op] != counts[0]+counts[1] {
+			t.Errorf("wrong number of latency observations for %s", op)
+		}
+	}
+
+	SetMetricsSink(nil)
+	pk.Encrypt(b(1))
+	if sink.successes[OpEncrypt] != 2 {
+		t.Error("sink was invoked after it was removed")
+	}
+}
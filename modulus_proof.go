@@ -0,0 +1,230 @@
+package paillier
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// modulusProofRounds is the number of challenges of a ModulusProof; a
+// modulus that is not the product of two primes passes each challenge with
+// probability at most 1/2. The challenges are hashed to modulusHashSlack
+// more bits than N so that they are close to uniform in Z_N.
+const (
+	modulusProofRounds = 80
+	modulusHashSlack   = 64
+)
+
+// ModulusProof is a non-interactive proof (Fiat-Shamir heuristic) that N is
+// the product of two distinct primes p, q = 3 mod 4 which only needs the
+// public key to verify. For the challenges y_i derived from N and W, the
+// proof contains
+//   - the N-th roots Z_i of y_i, which exist for all y_i iff gcd(N, phi(N)) = 1,
+//     i.e., N is square-free, and
+//   - fourth roots X_i of (-1)^A_i W^B_i y_i for a W with Jacobi symbol -1,
+//     which exist for all y_i only if N has at most two prime factors, both
+//     3 mod 4.
+//
+// This is the Paillier-Blum modulus proof of [CGGMP 20], section 6.3, based
+// on [GMR 98].
+//
+//	[CGGMP 20]: Ran Canetti, Rosario Gennaro, Steven Goldfeder, Nikolaos
+//	            Makriyannis, Udi Peled, (2020)
+//	            UC Non-Interactive, Proactive, Threshold ECDSA with
+//	            Identifiable Aborts
+//	[GMR 98]:   Rosario Gennaro, Daniele Micciancio, Tal Rabin, (1998)
+//	            An Efficient Non-Interactive Statistical Zero-Knowledge Proof
+//	            System for Quasi-Safe Prime Products
+type ModulusProof struct {
+	W    *gmp.Int
+	X    []*gmp.Int
+	A, B []bool
+	Z    []*gmp.Int
+}
+
+// ProveModulus proves that N is the product of two primes p, q = 3 mod 4.
+// It requires the factorization of N, which is available for keys generated
+// by KeyGen, whose factors are 3 mod 4.
+func (sk *SecretKey) ProveModulus() (*ModulusProof, error) {
+	p, q := sk.factorWithPhi()
+	if p == nil {
+		return nil, errors.New("the factorization of N is not available")
+	}
+	return proveModulus(sk.N, p, q, sk.RandomSource())
+}
+
+// GenerateKeysWithModulusProof is GenerateKeys that also returns a proof
+// that N is the product of two primes. The dealer publishes the proof with
+// the public key so that the servers and all users of the key can check it
+// with VerifyModulusProof.
+func (tkg *ThresholdKeyGenerator) GenerateKeysWithModulusProof() ([]*ThresholdSecretKey, *ModulusProof, error) {
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := proveModulus(tkg.n, tkg.p, tkg.q, tkg.random)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tsks, proof, nil
+}
+
+// VerifyModulusProof returns true iff the proof shows that N is the product
+// of two primes
+func (pk *PublicKey) VerifyModulusProof(proof *ModulusProof) bool {
+	return pk.VerifyModulusProofErr(proof) == nil
+}
+
+// VerifyModulusProofErr verifies the proof as VerifyModulusProof and returns
+// an error wrapping ErrMalformedProof, ErrProofPart1 (N-th roots) or
+// ErrProofPart2 (fourth roots) if it is rejected
+func (pk *PublicKey) VerifyModulusProofErr(proof *ModulusProof) error {
+	n := pk.N
+	if proof == nil || proof.W == nil || len(proof.X) != modulusProofRounds || len(proof.A) != modulusProofRounds ||
+		len(proof.B) != modulusProofRounds || len(proof.Z) != modulusProofRounds {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if n.Bit(0) == 0 || n.ProbablyPrime(20) {
+		return fmt.Errorf("%w: N is even or prime", ErrMalformedProof)
+	}
+
+	if proof.W.Sign() <= 0 || proof.W.Cmp(n) >= 0 || big.Jacobi(ToBigInt(proof.W), ToBigInt(n)) != -1 {
+		return fmt.Errorf("%w: W does not have Jacobi symbol -1", ErrMalformedProof)
+	}
+
+	minusOne := new(gmp.Int).Sub(n, OneBigInt)
+	for i := 0; i < modulusProofRounds; i++ {
+		x, z := proof.X[i], proof.Z[i]
+		if x == nil || z == nil || x.Sign() <= 0 || x.Cmp(n) >= 0 || z.Sign() <= 0 || z.Cmp(n) >= 0 {
+			return fmt.Errorf("%w: root is not in Z_N", ErrMalformedProof)
+		}
+
+		y := modulusProofChallenge(n, proof.W, i)
+		if new(gmp.Int).Exp(z, n, n).Cmp(y) != 0 {
+			return ErrProofPart1
+		}
+
+		if proof.A[i] {
+			y.Mul(y, minusOne)
+		}
+		if proof.B[i] {
+			y.Mul(y, proof.W)
+		}
+		y.Mod(y, n)
+		if new(gmp.Int).Exp(x, FourBigInt, n).Cmp(y) != 0 {
+			return ErrProofPart2
+		}
+	}
+
+	return nil
+}
+
+func proveModulus(n, p, q *gmp.Int, random io.Reader) (*ModulusProof, error) {
+	three := gmp.NewInt(3)
+	if new(gmp.Int).Mod(p, FourBigInt).Cmp(three) != 0 || new(gmp.Int).Mod(q, FourBigInt).Cmp(three) != 0 {
+		return nil, errors.New("prime factors of N must be 3 mod 4")
+	}
+
+	pMinus1 := new(gmp.Int).Sub(p, OneBigInt)
+	qMinus1 := new(gmp.Int).Sub(q, OneBigInt)
+	phi := new(gmp.Int).Mul(pMinus1, qMinus1)
+	nInv := new(gmp.Int).ModInverse(n, phi)
+	if nInv.Sign() == 0 {
+		return nil, errors.New("N is not coprime to phi(N)")
+	}
+
+	// ((p+1)/4)^2 maps a square mod p to its fourth root that is a square
+	fourthRoot := func(p, pMinus1 *gmp.Int) *gmp.Int {
+		e := new(gmp.Int).Add(p, OneBigInt)
+		e.Rsh(e, 2)
+		e.Mul(e, e)
+		return e.Mod(e, pMinus1)
+	}
+	ep, eq := fourthRoot(p, pMinus1), fourthRoot(q, qMinus1)
+	qInv := new(gmp.Int).ModInverse(q, p)
+
+	var w *gmp.Int
+	for {
+		var err error
+		if w, err = GetRandomNumber(n, random); err != nil {
+			return nil, err
+		}
+		if big.Jacobi(ToBigInt(w), ToBigInt(n)) == -1 {
+			break
+		}
+	}
+
+	proof := &ModulusProof{
+		W: w,
+		X: make([]*gmp.Int, modulusProofRounds),
+		A: make([]bool, modulusProofRounds),
+		B: make([]bool, modulusProofRounds),
+		Z: make([]*gmp.Int, modulusProofRounds),
+	}
+	minusOne := new(gmp.Int).Sub(n, OneBigInt)
+	for i := 0; i < modulusProofRounds; i++ {
+		y := modulusProofChallenge(n, w, i)
+		if new(gmp.Int).GCD(nil, nil, y, n).Cmp(OneBigInt) != 0 {
+			return nil, errors.New("challenge is not a unit mod N")
+		}
+		proof.Z[i] = new(gmp.Int).Exp(y, nInv, n)
+
+		// exactly one of (-1)^a w^b y is a square mod p and mod q since -1 is
+		// a non-square mod both and w mod exactly one of them
+		for k := 0; k < 4; k++ {
+			a, b := k&1 == 1, k&2 == 2
+			v := new(gmp.Int).Set(y)
+			if a {
+				v.Mul(v, minusOne)
+			}
+			if b {
+				v.Mul(v, w)
+			}
+			v.Mod(v, n)
+			if big.Jacobi(ToBigInt(v), ToBigInt(p)) != 1 || big.Jacobi(ToBigInt(v), ToBigInt(q)) != 1 {
+				continue
+			}
+
+			// combine the fourth roots mod p and q with the CRT
+			xp := new(gmp.Int).Exp(v, ep, p)
+			xq := new(gmp.Int).Exp(v, eq, q)
+			x := new(gmp.Int).Sub(xp, xq)
+			x.Mul(x, qInv)
+			x.Mod(x, p)
+			x.Mul(x, q)
+			x.Add(x, xq)
+
+			proof.X[i], proof.A[i], proof.B[i] = x, a, b
+			break
+		}
+		if proof.X[i] == nil {
+			return nil, errors.New("no fourth root found, N is not a Blum integer")
+		}
+	}
+
+	return proof, nil
+}
+
+// returns the i'th challenge y_i in Z_N of a modulus proof
+func modulusProofChallenge(n, w *gmp.Int, i int) *gmp.Int {
+	var digest []byte
+	for block := 0; len(digest)*8 < n.BitLen()+modulusHashSlack; block++ {
+		hash := sha256.New()
+		hash.Write([]byte("paillier modulus proof"))
+		for _, v := range []*gmp.Int{n, w} {
+			b := v.Bytes()
+			binary.Write(hash, binary.BigEndian, uint32(len(b)))
+			hash.Write(b)
+		}
+		binary.Write(hash, binary.BigEndian, int64(i))
+		binary.Write(hash, binary.BigEndian, int64(block))
+		digest = hash.Sum(digest)
+	}
+	return new(gmp.Int).Mod(new(gmp.Int).SetBytes(digest), n)
+}
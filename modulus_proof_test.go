@@ -0,0 +1,82 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestModulusProof(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	proof, err := sk.ProveModulus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.VerifyModulusProofErr(proof); err != nil {
+		t.Error("modulus proof is not complete: ", err)
+	}
+
+	// the proof does not verify for another modulus
+	_, other := KeyGen(128)
+	if err := other.VerifyModulusProofErr(proof); err == nil {
+		t.Error("modulus proof verifies for another modulus")
+	}
+
+	tampered := *proof
+	tampered.X = append([]*gmp.Int{}, proof.X...)
+	tampered.X[3] = new(gmp.Int).Sub(pk.N, OneBigInt)
+	if err := pk.VerifyModulusProofErr(&tampered); !errors.Is(err, ErrProofPart2) {
+		t.Error("expected ErrProofPart2 for a wrong fourth root, got ", err)
+	}
+
+	tampered = *proof
+	tampered.Z = proof.Z[1:]
+	if err := pk.VerifyModulusProofErr(&tampered); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error, got ", err)
+	}
+}
+
+func TestModulusProofRejectsBadModuli(t *testing.T) {
+	p, q, r := gmp.NewInt(1000003), gmp.NewInt(1000039), gmp.NewInt(1000099)
+
+	// the product of two primes 3 mod 4 is 1 mod 4, so a product of three
+	// primes cannot be passed off as a product of two
+	n := new(gmp.Int).Mul(p, q)
+	n.Mul(n, r)
+	if _, err := proveModulus(n, p, new(gmp.Int).Mul(q, r), rand.Reader); err == nil {
+		t.Error("expected error for a product of three primes")
+	}
+
+	// a proof for a square-free modulus does not verify for a modulus with
+	// a square factor
+	n = new(gmp.Int).Mul(p, q)
+	proof, err := proveModulus(n, p, q, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(&PublicKey{N: n}).VerifyModulusProof(proof) {
+		t.Error("modulus proof is not complete")
+	}
+	squared := &PublicKey{N: new(gmp.Int).Mul(n, p)}
+	if squared.VerifyModulusProof(proof) {
+		t.Error("modulus proof verifies for a modulus with a square factor")
+	}
+}
+
+func TestGenerateKeysWithModulusProof(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, proof, err := tkg.GenerateKeysWithModulusProof()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tsks[0].PublicOnly().VerifyModulusProofErr(proof); err != nil {
+		t.Error("modulus proof of the threshold key is not complete: ", err)
+	}
+}
@@ -0,0 +1,160 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// MaxMoneyScale is the largest number of decimal places supported by MoneyCodec
+const MaxMoneyScale = 18
+
+// EncryptedMoney is an encrypted monetary amount. The plaintext is the signed
+// amount in minor units, i.e., amount * 10^Scale.
+type EncryptedMoney struct {
+	Currency   string
+	Scale      int
+	Ciphertext *Ciphertext
+}
+
+// MoneyCodec encrypts monetary amounts of a single currency with a fixed
+// number of decimal places, e.g., Scale=2 for cents. Amounts are encoded
+// exactly as signed integers in minor units (see EncodeSigned), and all
+// homomorphic operations refuse to mix amounts of different currencies or
+// scales.
+type MoneyCodec struct {
+	Key      *PublicKey
+	Currency string
+	Scale    int
+
+	factor *big.Int // 10^Scale
+}
+
+// NewMoneyCodec returns a codec for the currency (an upper-case ISO 4217 code
+// such as "EUR") with `scale` decimal places
+func (pk *PublicKey) NewMoneyCodec(currency string, scale int) (*MoneyCodec, error) {
+	if len(currency) != 3 {
+		return nil, errors.New("currency must be a three letter code")
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return nil, errors.New("currency must be a three letter code")
+		}
+	}
+
+	if scale < 0 || scale > MaxMoneyScale {
+		return nil, fmt.Errorf("scale must be between 0 and %d", MaxMoneyScale)
+	}
+
+	return &MoneyCodec{
+		Key:      pk,
+		Currency: currency,
+		Scale:    scale,
+		factor:   new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil),
+	}, nil
+}
+
+// Encrypt encrypts the amount, which must be representable with
+// `Scale` decimal places
+func (mc *MoneyCodec) Encrypt(amount *big.Rat) (*EncryptedMoney, error) {
+	minor := new(big.Rat).Mul(amount, new(big.Rat).SetInt(mc.factor))
+	if !minor.IsInt() {
+		return nil, fmt.Errorf("amount %s has more than %d decimal places", amount.FloatString(MaxMoneyScale), mc.Scale)
+	}
+
+	if err := mc.checkRange(minor.Num()); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedMoney{
+		Currency:   mc.Currency,
+		Scale:      mc.Scale,
+		Ciphertext: mc.Key.Encrypt(mc.Key.EncodeSigned(minor.Num())),
+	}, nil
+}
+
+// EncryptString parses and encrypts a decimal amount such as "-12.34"
+func (mc *MoneyCodec) EncryptString(amount string) (*EncryptedMoney, error) {
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, errors.New("invalid amount " + amount)
+	}
+	return mc.Encrypt(r)
+}
+
+// Decrypt returns the exact amount
+func (mc *MoneyCodec) Decrypt(sk *SecretKey, em *EncryptedMoney) (*big.Rat, error) {
+	if err := mc.check(em); err != nil {
+		return nil, err
+	}
+
+	minor := sk.DecodeSigned(sk.Decrypt(em.Ciphertext))
+	return new(big.Rat).SetFrac(minor, mc.factor), nil
+}
+
+// Format returns the amount as a decimal string with `Scale` decimal places
+func (mc *MoneyCodec) Format(amount *big.Rat) string {
+	return amount.FloatString(mc.Scale)
+}
+
+// Add homomorphically adds amounts of the codec's currency and scale
+func (mc *MoneyCodec) Add(ems ...*EncryptedMoney) (*EncryptedMoney, error) {
+	if len(ems) == 0 {
+		return nil, errors.New("no amounts provided")
+	}
+
+	cts := make([]*Ciphertext, len(ems))
+	for i, em := range ems {
+		if err := mc.check(em); err != nil {
+			return nil, err
+		}
+		cts[i] = em.Ciphertext
+	}
+
+	return mc.wrap(mc.Key.Add(cts...)), nil
+}
+
+// Sub homomorphically subtracts b from a
+func (mc *MoneyCodec) Sub(a, b *EncryptedMoney) (*EncryptedMoney, error) {
+	if err := mc.check(a); err != nil {
+		return nil, err
+	}
+	if err := mc.check(b); err != nil {
+		return nil, err
+	}
+
+	return mc.wrap(mc.Key.Sub(a.Ciphertext, b.Ciphertext)), nil
+}
+
+// MulInt homomorphically multiplies the amount with a (possibly negative)
+// integer, e.g., a quantity
+func (mc *MoneyCodec) MulInt(em *EncryptedMoney, k *big.Int) (*EncryptedMoney, error) {
+	if err := mc.check(em); err != nil {
+		return nil, err
+	}
+
+	return mc.wrap(mc.Key.ConstMult(em.Ciphertext, mc.Key.EncodeSigned(k))), nil
+}
+
+func (mc *MoneyCodec) wrap(ct *Ciphertext) *EncryptedMoney {
+	return &EncryptedMoney{Currency: mc.Currency, Scale: mc.Scale, Ciphertext: ct}
+}
+
+func (mc *MoneyCodec) check(em *EncryptedMoney) error {
+	if em.Currency != mc.Currency {
+		return fmt.Errorf("currency mismatch: %s is not %s", em.Currency, mc.Currency)
+	}
+	if em.Scale != mc.Scale {
+		return fmt.Errorf("scale mismatch: %d is not %d", em.Scale, mc.Scale)
+	}
+	return nil
+}
+
+// amounts in minor units must be smaller than N/2 in absolute value
+func (mc *MoneyCodec) checkRange(minor *big.Int) error {
+	half := new(big.Int).Rsh(ToBigInt(mc.Key.N), 1)
+	if new(big.Int).Abs(minor).Cmp(half) >= 0 {
+		return fmt.Errorf("%w: amount does not fit into the public key", ErrMessageTooLarge)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package paillier
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestMoneyCodec(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	mc, err := pk.NewMoneyCodec("EUR", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	salaries := []string{"2500.00", "3100.50", "-120.25", "0.01"}
+	ems := make([]*EncryptedMoney, len(salaries))
+	for i, s := range salaries {
+		if ems[i], err = mc.EncryptString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, err := mc.Add(ems...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := mc.Decrypt(sk, total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mc.Format(res) != "5480.26" {
+		t.Error("wrong total ", mc.Format(res))
+	}
+
+	diff, err := mc.Sub(ems[0], ems[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := mc.Decrypt(sk, diff); mc.Format(res) != "-600.50" {
+		t.Error("wrong difference ", mc.Format(res))
+	}
+
+	scaled, err := mc.MulInt(ems[2], big.NewInt(-3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := mc.Decrypt(sk, scaled); mc.Format(res) != "360.75" {
+		t.Error("wrong product ", mc.Format(res))
+	}
+}
+
+func TestMoneyCodecChecks(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	eur, _ := pk.NewMoneyCodec("EUR", 2)
+	usd, _ := pk.NewMoneyCodec("USD", 2)
+	eur3, _ := pk.NewMoneyCodec("EUR", 3)
+
+	a, _ := eur.EncryptString("1.00")
+	b, _ := usd.EncryptString("1.00")
+	c, _ := eur3.EncryptString("1.000")
+
+	if _, err := eur.Add(a, b); err == nil {
+		t.Error("expected currency mismatch error")
+	}
+	if _, err := eur.Add(a, c); err == nil {
+		t.Error("expected scale mismatch error")
+	}
+	if _, err := eur.EncryptString("1.001"); err == nil {
+		t.Error("expected error for too many decimal places")
+	}
+	if _, err := pk.NewMoneyCodec("eur", 2); err == nil {
+		t.Error("expected error for invalid currency code")
+	}
+	if _, err := pk.NewMoneyCodec("EUR", 19); err == nil {
+		t.Error("expected error for invalid scale")
+	}
+}
@@ -0,0 +1,131 @@
+package paillier
+
+import (
+	gmp "github.com/ncw/gmp"
+)
+
+// multiExp returns prod_i bases[i]^exps[i] mod modulus. Negative exponents
+// raise the inverse of the base, which must then be a unit.
+//
+// The powers are computed simultaneously (Shamir's trick) with interleaved
+// sliding windows: every base gets its own table of odd powers and windows,
+// but all of them share a single chain of squarings, so k exponentiations
+// with b-bit exponents take about b squarings and k*b/(w+1) multiplications
+// instead of k*b squarings. Since the multiplications are slower than those
+// within an exponentiation of GMP, this only pays off from multiExpMinBases
+// bases with exponents of similar length; fewer bases are raised one by one.
+// For the same reason, the two-term products of the partial decryption proofs
+// are not computed with multiExp: their challenges have 256 bits, so merging
+// them into the squarings of the responses saves little.
+func multiExp(bases, exps []*gmp.Int, modulus *gmp.Int) *gmp.Int {
+	if len(bases) < multiExpMinBases {
+		result := gmp.NewInt(1)
+		for i, base := range bases {
+			power := new(gmp.Int).Exp(base, new(gmp.Int).Abs(exps[i]), modulus)
+			if exps[i].Sign() < 0 {
+				power.ModInverse(power, modulus)
+			}
+			result.Mul(result, power)
+			result.Mod(result, modulus)
+		}
+		return result
+	}
+
+	type term struct {
+		powers []*gmp.Int // base^1, base^3, ..., base^(2^w-1)
+		exp    *gmp.Int
+		window int
+		end    int // bit at which the current window is multiplied in
+		digit  int // odd value of the current window
+	}
+
+	terms := make([]*term, 0, len(bases))
+	bits := 0
+	for i, base := range bases {
+		e := exps[i]
+		if e.Sign() == 0 {
+			continue
+		}
+
+		b := new(gmp.Int).Mod(base, modulus)
+		if e.Sign() < 0 {
+			b.ModInverse(b, modulus)
+			e = new(gmp.Int).Neg(e)
+		}
+
+		t := &term{exp: e, window: multiExpWindow(e.BitLen()), end: -1}
+		t.powers = make([]*gmp.Int, 1<<uint(t.window-1))
+		t.powers[0] = b
+		if len(t.powers) > 1 {
+			b2 := new(gmp.Int).Mul(b, b)
+			b2.Mod(b2, modulus)
+			for j := 1; j < len(t.powers); j++ {
+				t.powers[j] = new(gmp.Int).Mul(t.powers[j-1], b2)
+				t.powers[j].Mod(t.powers[j], modulus)
+			}
+		}
+
+		terms = append(terms, t)
+		if e.BitLen() > bits {
+			bits = e.BitLen()
+		}
+	}
+
+	result := gmp.NewInt(1)
+	started := false
+	for pos := bits - 1; pos >= 0; pos-- {
+		if started {
+			result.Mul(result, result)
+			result.Mod(result, modulus)
+		}
+
+		for _, t := range terms {
+			// start a window at the highest set bit that is not yet covered
+			if t.end < 0 && t.exp.Bit(pos) == 1 {
+				low := pos - t.window + 1
+				if low < 0 {
+					low = 0
+				}
+				for t.exp.Bit(low) == 0 {
+					low++
+				}
+				t.digit = 0
+				for j := pos; j >= low; j-- {
+					t.digit = t.digit<<1 | int(t.exp.Bit(j))
+				}
+				t.end = low
+			}
+
+			if t.end == pos {
+				result.Mul(result, t.powers[t.digit>>1])
+				result.Mod(result, modulus)
+				started = true
+				t.end = -1
+			}
+		}
+	}
+
+	return result
+}
+
+// multiExpMinBases is the number of bases from which multiExp computes the
+// powers simultaneously
+const multiExpMinBases = 4
+
+// multiExpWindow returns the window size for exponents of the given bit
+// length, which balances the precomputation of 2^(w-1) odd powers against
+// the bits/(w+1) multiplications of an exponentiation
+func multiExpWindow(bits int) int {
+	switch {
+	case bits <= 16:
+		return 1
+	case bits <= 64:
+		return 3
+	case bits <= 256:
+		return 4
+	case bits <= 1024:
+		return 5
+	default:
+		return 6
+	}
+}
@@ -0,0 +1,73 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestMultiExp(t *testing.T) {
+	modulus, _ := rand.Prime(rand.Reader, 256)
+	gmod := ToGmpInt(modulus)
+
+	// exponents of every window size, negative and zero exponents
+	for _, bits := range []int{1, 8, 40, 200, 700, 1500} {
+		for count := 0; count <= 5; count++ {
+			bases := make([]*gmp.Int, count)
+			exps := make([]*gmp.Int, count)
+			expected := gmp.NewInt(1)
+			for i := range bases {
+				base, _ := rand.Int(rand.Reader, modulus)
+				base.Add(base, big.NewInt(1))
+				e, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+				if i%3 == 1 {
+					e.Neg(e)
+				}
+				if i == 3 {
+					e.SetInt64(0)
+				}
+				bases[i], exps[i] = ToGmpInt(base), ToGmpInt(e)
+
+				expected.Mul(expected, (&ThresholdPublicKey{}).exp(bases[i], exps[i], gmod))
+				expected.Mod(expected, gmod)
+			}
+
+			if got := multiExp(bases, exps, gmod); got.Cmp(expected) != 0 {
+				t.Errorf("%d bases with %d-bit exponents: got %v, expected %v", count, bits, got, expected)
+			}
+		}
+	}
+}
+
+func BenchmarkMultiExp(b *testing.B) {
+	modulus, _ := rand.Prime(rand.Reader, 2048)
+	gmod := ToGmpInt(modulus)
+
+	for _, count := range []int{4, 16, 100} {
+		bases := make([]*gmp.Int, count)
+		exps := make([]*gmp.Int, count)
+		for i := range bases {
+			base, _ := rand.Int(rand.Reader, modulus)
+			e, _ := rand.Int(rand.Reader, modulus)
+			bases[i], exps[i] = ToGmpInt(base), ToGmpInt(e)
+		}
+
+		b.Run(fmt.Sprintf("sequential/%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				result := gmp.NewInt(1)
+				for j := range bases {
+					result.Mul(result, new(gmp.Int).Exp(bases[j], exps[j], gmod))
+					result.Mod(result, gmod)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("simultaneous/%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				multiExp(bases, exps, gmod)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package paillier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Fingerprint returns the hex encoded SHA-256 digest of the canonical
+// encoding of the public key, so keys have the same fingerprint iff they are
+// Equal. A missing G is taken to be the default generator N+1.
+//
+// Earlier versions hashed the unframed bytes of N and G only; fingerprints
+// stored with them, e.g., in committee descriptions, decryption requests or
+// key usage stores, must be recomputed.
+func (pk *PublicKey) Fingerprint() string {
+	digest := sha256.Sum256(pk.CanonicalBytes())
+	return hex.EncodeToString(digest[:])
+}
+
+// EncryptForAll encrypts the same plaintext under each of the public keys
+// and returns the ciphertexts indexed by the fingerprint of the key.
+// The plaintext is validated once and the expensive nonce exponentiations
+// r^N mod N^2 are computed in parallel, one goroutine per key.
+// Keys with the same fingerprint receive a single ciphertext. Recipients that
+// must be convinced that they all received the same plaintext are served by
+// EncryptForAllWithProof instead.
+func EncryptForAll(m *gmp.Int, pks []*PublicKey) (map[string]*Ciphertext, error) {
+	if m == nil {
+		return nil, errors.New("missing plaintext")
+	}
+	if m.Sign() < 0 {
+		return nil, errors.New("plaintext must be non-negative")
+	}
+
+	recipients := make(map[string]*PublicKey, len(pks))
+	for i, pk := range pks {
+		if pk == nil || pk.N == nil {
+			return nil, fmt.Errorf("missing recipient key %d", i)
+		}
+		if m.Cmp(pk.N) >= 0 {
+			return nil, errors.New("plaintext must be smaller than N for every key")
+		}
+		recipients[pk.Fingerprint()] = pk
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	cts := make(map[string]*Ciphertext, len(pks))
+
+	for fingerprint, pk := range recipients {
+		wg.Add(1)
+		go func(fingerprint string, pk *PublicKey) {
+			defer wg.Done()
+
+			ct, err := pk.encryptForRecipient(m)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			cts[fingerprint] = ct
+		}(fingerprint, pk)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return cts, nil
+}
+
+// encrypts m < N with a fresh nonce; EncryptWithR computes g^m as 1 + m*N
+// when g = N+1 and also accepts keys without G, e.g., of a committee
+func (pk *PublicKey) encryptForRecipient(m *gmp.Int) (*Ciphertext, error) {
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	return pk.EncryptWithR(m, r), nil
+}
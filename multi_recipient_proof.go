@@ -0,0 +1,217 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// MultiRecipientCiphertext holds one level one encryption of the same
+// plaintext per recipient key, in the order of the keys it was created for,
+// and a proof that all of them encrypt the same integer x < 2^BitLength.
+// Each recipient verifies the proof against the keys, decrypts its own
+// ciphertext and thereby learns the plaintext of every other recipient
+// without comparing it out-of-band, see DecryptMultiRecipient.
+type MultiRecipientCiphertext struct {
+	Ciphertexts []*Ciphertext
+	BitLength   int
+	Proof       *CrossKeyEqualityProof
+}
+
+// CrossKeyEqualityProof is a non-interactive proof (Fiat-Shamir heuristic)
+// of knowledge of an integer x and randomness r_i such that
+// c_i = g_i^x r_i^N_i mod N_i^2 for each of several keys. It generalizes the
+// proof of the key switch masks to any number of keys: the response Z over
+// the integers is shared by all keys. As for RangeProof, the proof has a
+// slack and only convinces the verifier that |x| < 2^(BitLength+168).
+type CrossKeyEqualityProof struct {
+	A []*gmp.Int // commitments g_i^alpha rho_i^N_i
+	Z *gmp.Int   // alpha + e*x over the integers
+	W []*gmp.Int // rho_i * r_i^e mod N_i
+}
+
+// EncryptForAllWithProof encrypts m < 2^bitLength under each of the public
+// keys and proves that the ciphertexts encrypt the same plaintext. Every
+// modulus must exceed 2^(bitLength+169), so that a plaintext within the slack
+// of the proof cannot be read differently by different recipients.
+func EncryptForAllWithProof(m *gmp.Int, pks []*PublicKey, bitLength int) (*MultiRecipientCiphertext, error) {
+	if err := checkMultiRecipientParameters(pks, bitLength); err != nil {
+		return nil, err
+	}
+	bound := new(gmp.Int).Lsh(OneBigInt, uint(bitLength))
+	if m.Sign() < 0 || m.Cmp(bound) >= 0 {
+		return nil, errors.New("plaintext is out of range")
+	}
+
+	cts := make([]*Ciphertext, len(pks))
+	rs := make([]*gmp.Int, len(pks))
+	for i, pk := range pks {
+		r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		cts[i] = pk.EncryptWithR(m, r)
+		rs[i] = r
+	}
+
+	proof, err := proveCrossKeyEquality(pks, cts, m, rs, bound)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiRecipientCiphertext{Ciphertexts: cts, BitLength: bitLength, Proof: proof}, nil
+}
+
+// VerifyMultiRecipient returns nil iff the ciphertexts are level one
+// encryptions under the keys, given in the order used for the encryption,
+// and the proof shows that they encrypt the same plaintext. A rejected proof
+// yields an error wrapping ErrMalformedProof or ErrProofPart1, the latter
+// naming the key whose check failed.
+func VerifyMultiRecipient(mc *MultiRecipientCiphertext, pks []*PublicKey) error {
+	if mc == nil {
+		return errors.New("missing multi-recipient ciphertext")
+	}
+	if err := checkMultiRecipientParameters(pks, mc.BitLength); err != nil {
+		return err
+	}
+	if len(mc.Ciphertexts) != len(pks) {
+		return fmt.Errorf("%d ciphertexts for %d keys", len(mc.Ciphertexts), len(pks))
+	}
+	for i, ct := range mc.Ciphertexts {
+		if ct == nil || ct.C == nil || ct.Level != EncLevelOne {
+			return fmt.Errorf("%w: ciphertext %d", ErrInvalidCiphertext, i)
+		}
+	}
+
+	bound := new(gmp.Int).Lsh(OneBigInt, uint(mc.BitLength))
+	return verifyCrossKeyEquality(pks, mc.Ciphertexts, bound, mc.Proof)
+}
+
+// DecryptMultiRecipient verifies the multi-recipient ciphertext against the
+// keys, which must include the public key of sk, and returns the plaintext
+// of the ciphertext for sk. Once the proof is verified, a plaintext below
+// 2^BitLength is the plaintext of every ciphertext, so a decryption out of
+// this range means the sender cheated and an error is returned.
+func (sk *SecretKey) DecryptMultiRecipient(mc *MultiRecipientCiphertext, pks []*PublicKey) (*gmp.Int, error) {
+	if err := VerifyMultiRecipient(mc, pks); err != nil {
+		return nil, err
+	}
+
+	fingerprint := sk.PublicKey.Fingerprint()
+	for i, pk := range pks {
+		if pk.Fingerprint() != fingerprint {
+			continue
+		}
+		m := sk.Decrypt(mc.Ciphertexts[i])
+		if m.BitLen() > mc.BitLength {
+			return nil, errors.New("plaintext is out of range")
+		}
+		return m, nil
+	}
+	return nil, errors.New("secret key is not among the recipients")
+}
+
+// checkMultiRecipientParameters returns an error if there are no keys, a key
+// is given twice or a modulus does not leave room for the slack of the proof
+func checkMultiRecipientParameters(pks []*PublicKey, bitLength int) error {
+	if len(pks) == 0 {
+		return errors.New("no recipient keys")
+	}
+	if bitLength <= 0 {
+		return errors.New("bit length must be positive")
+	}
+
+	seen := make(map[string]bool, len(pks))
+	for i, pk := range pks {
+		if pk == nil || pk.N == nil {
+			return fmt.Errorf("missing recipient key %d", i)
+		}
+		if pk.N.BitLen() <= bitLength+slackBits+1 {
+			return fmt.Errorf("recipient key %d is too small for the requested bit length", i)
+		}
+		fingerprint := pk.Fingerprint()
+		if seen[fingerprint] {
+			return fmt.Errorf("recipient key %d is given twice", i)
+		}
+		seen[fingerprint] = true
+	}
+	return nil
+}
+
+// crossKeyChallenge returns the challenge for the statement and commitments
+func crossKeyChallenge(pks []*PublicKey, cts []*Ciphertext, bound *gmp.Int, commitments []*gmp.Int) *gmp.Int {
+	values := []*gmp.Int{bound}
+	for i, pk := range pks {
+		values = append(values, pk.N, cts[i].C, commitments[i])
+	}
+	return RandomOracleChallenge(slackChallengeBits, values...)
+}
+
+// proveCrossKeyEquality proves that cts[i] = g_i^x rs[i]^N_i mod N_i^2 for
+// all keys with x in [0, bound)
+func proveCrossKeyEquality(pks []*PublicKey, cts []*Ciphertext, x *gmp.Int, rs []*gmp.Int, bound *gmp.Int) (*CrossKeyEqualityProof, error) {
+	zBound := new(gmp.Int).Lsh(bound, slackBits)
+	rhos := make([]*gmp.Int, len(pks))
+	commitments := make([]*gmp.Int, len(pks))
+	for {
+		alpha, err := GetRandomNumber(zBound, pks[0].RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		for i, pk := range pks {
+			if rhos[i], err = GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource()); err != nil {
+				return nil, err
+			}
+			commitments[i] = pk.EncryptWithR(alpha, rhos[i]).C
+		}
+		e := crossKeyChallenge(pks, cts, bound, commitments)
+
+		// retry in the rare case that the response would reveal x
+		z := new(gmp.Int).Mul(e, x)
+		z.Add(z, alpha)
+		if z.Cmp(zBound) >= 0 {
+			continue
+		}
+
+		ws := make([]*gmp.Int, len(pks))
+		for i, pk := range pks {
+			ws[i] = new(gmp.Int).Exp(rs[i], e, pk.N)
+			ws[i].Mul(ws[i], rhos[i])
+			ws[i].Mod(ws[i], pk.N)
+		}
+		return &CrossKeyEqualityProof{A: commitments, Z: z, W: ws}, nil
+	}
+}
+
+// verifyCrossKeyEquality returns an error wrapping ErrMalformedProof or
+// ErrProofPart1 (g_i^Z W_i^N_i = A_i c_i^e) if the proof is rejected
+func verifyCrossKeyEquality(pks []*PublicKey, cts []*Ciphertext, bound *gmp.Int, proof *CrossKeyEqualityProof) error {
+	if proof == nil || proof.Z == nil || len(proof.A) != len(pks) || len(proof.W) != len(pks) {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+	zBound := new(gmp.Int).Lsh(bound, slackBits)
+	if proof.Z.Sign() < 0 || proof.Z.Cmp(zBound) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+	for i, pk := range pks {
+		if proof.A[i] == nil || proof.W[i] == nil {
+			return fmt.Errorf("%w: missing values", ErrMalformedProof)
+		}
+		if err := pk.checkUnits(proof.W[i]); err != nil {
+			return err
+		}
+	}
+
+	e := crossKeyChallenge(pks, cts, bound, proof.A)
+	for i, pk := range pks {
+		n2 := pk.GetN2()
+		lhs := pk.EncryptWithR(proof.Z, proof.W[i]).C
+		rhs := new(gmp.Int).Exp(cts[i].C, e, n2)
+		rhs.Mul(rhs, proof.A[i])
+		rhs.Mod(rhs, n2)
+		if lhs.Cmp(rhs) != 0 {
+			return fmt.Errorf("%w: key %d", ErrProofPart1, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestEncryptForAllWithProof(t *testing.T) {
+	sks := make([]*SecretKey, 3)
+	pks := make([]*PublicKey, 3)
+	for i := range sks {
+		sks[i], pks[i] = KeyGen(256 + 64*i)
+	}
+
+	m := gmp.NewInt(987654321)
+	mc, err := EncryptForAllWithProof(m, pks, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sk := range sks {
+		res, err := sk.DecryptMultiRecipient(mc, pks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Cmp(m) != 0 {
+			t.Error("recipient ", i, " decrypted ", res, " expected ", m)
+		}
+	}
+
+	// the keys must be given in the order of the encryption
+	if err := VerifyMultiRecipient(mc, []*PublicKey{pks[1], pks[0], pks[2]}); err == nil {
+		t.Error("expected an error for permuted keys")
+	}
+	if err := VerifyMultiRecipient(mc, pks[:2]); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+	other, _ := KeyGen(256)
+	if _, err := other.DecryptMultiRecipient(mc, pks); err == nil {
+		t.Error("expected an error for a key that is not a recipient")
+	}
+
+	if _, err := EncryptForAllWithProof(m, []*PublicKey{pks[0], pks[0]}, 64); err == nil {
+		t.Error("expected an error for a duplicate key")
+	}
+	if _, err := EncryptForAllWithProof(m, pks, 88); err == nil {
+		t.Error("expected an error for a key that is too small")
+	}
+	if _, err := EncryptForAllWithProof(new(gmp.Int).Lsh(OneBigInt, 64), pks, 64); err == nil {
+		t.Error("expected an error for a plaintext out of range")
+	}
+}
+
+func TestEncryptForAllWithProofInconsistent(t *testing.T) {
+	pks := make([]*PublicKey, 3)
+	for i := range pks {
+		_, pks[i] = KeyGen(256)
+	}
+
+	mc, err := EncryptForAllWithProof(gmp.NewInt(5), pks, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a ciphertext of another plaintext under the second key
+	forged := *mc
+	forged.Ciphertexts = append([]*Ciphertext{}, mc.Ciphertexts...)
+	forged.Ciphertexts[1] = pks[1].Encrypt(gmp.NewInt(6))
+	if err := VerifyMultiRecipient(&forged, pks); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1, got ", err)
+	}
+
+	// the same plaintext with a proof for other ciphertexts
+	again, err := EncryptForAllWithProof(gmp.NewInt(5), pks, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Ciphertexts[1] = again.Ciphertexts[1]
+	if err := VerifyMultiRecipient(&forged, pks); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1, got ", err)
+	}
+
+	// a response beyond the slack
+	forged = *mc
+	proof := *mc.Proof
+	proof.Z = new(gmp.Int).Lsh(OneBigInt, 32+slackBits)
+	forged.Proof = &proof
+	if err := VerifyMultiRecipient(&forged, pks); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected ErrMalformedProof, got ", err)
+	}
+
+	proof = *mc.Proof
+	proof.W = proof.W[:2]
+	if err := VerifyMultiRecipient(&forged, pks); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected ErrMalformedProof, got ", err)
+	}
+}
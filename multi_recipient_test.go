@@ -0,0 +1,109 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestEncryptForAll(t *testing.T) {
+	sks := make([]*SecretKey, 5)
+	pks := make([]*PublicKey, 5)
+	for i := range sks {
+		sks[i], pks[i] = KeyGen(128)
+	}
+
+	m := gmp.NewInt(123456)
+	cts, err := EncryptForAll(m, append(pks, pks[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cts) != len(pks) {
+		t.Fatal("expected one ciphertext per key but got ", len(cts))
+	}
+
+	for i, sk := range sks {
+		ct, ok := cts[pks[i].Fingerprint()]
+		if !ok {
+			t.Fatal("missing ciphertext for key ", i)
+		}
+
+		if res := sk.Decrypt(ct); res.Cmp(m) != 0 {
+			t.Error("wrong decryption ", res, " is not ", m)
+		}
+	}
+}
+
+func TestEncryptForAllCommitteeKey(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicKey()
+	sk, pk := KeyGen(128)
+
+	// the public key of a committee has no G
+	m := gmp.NewInt(4242)
+	cts, err := EncryptForAll(m, []*PublicKey{&tk.PublicKey, pk})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct := cts[tk.PublicKey.Fingerprint()]
+	shares := []*PartialDecryption{tsks[0].PartialDecrypt(ct.C), tsks[2].PartialDecrypt(ct.C)}
+	if res, err := tk.CombinePartialDecryptions(shares); err != nil || res.Cmp(m) != 0 {
+		t.Error("wrong committee decryption ", res, err)
+	}
+	if res := sk.Decrypt(cts[pk.Fingerprint()]); res.Cmp(m) != 0 {
+		t.Error("wrong decryption ", res, " is not ", m)
+	}
+}
+
+func TestEncryptForAllPlaintextTooLarge(t *testing.T) {
+	_, pk1 := KeyGen(64)
+	_, pk2 := KeyGen(128)
+
+	if _, err := EncryptForAll(pk2.N, []*PublicKey{pk1, pk2}); err == nil {
+		t.Error("expected error for plaintext larger than N")
+	}
+
+	if _, err := EncryptForAll(gmp.NewInt(-1), []*PublicKey{pk1}); err == nil {
+		t.Error("expected error for negative plaintext")
+	}
+
+	if _, err := EncryptForAll(nil, []*PublicKey{pk1}); err == nil {
+		t.Error("expected error for a missing plaintext")
+	}
+	if _, err := EncryptForAll(gmp.NewInt(1), []*PublicKey{pk1, nil}); err == nil {
+		t.Error("expected error for a missing key")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	_, pk1 := KeyGen(64)
+	_, pk2 := KeyGen(64)
+
+	if pk1.Fingerprint() == pk2.Fingerprint() {
+		t.Error("different keys have the same fingerprint")
+	}
+
+	if pk1.Fingerprint() != pk1.Fingerprint() {
+		t.Error("fingerprint is not deterministic")
+	}
+
+	// N and G are framed, so N || G cannot be split differently
+	split1 := &PublicKey{N: b(0x0102), G: b(0x03)}
+	split2 := &PublicKey{N: b(0x01), G: b(0x0203)}
+	if split1.Fingerprint() == split2.Fingerprint() {
+		t.Error("keys with the same concatenation of N and G have the same fingerprint")
+	}
+
+	// the fingerprint agrees with Equal, which covers H and K
+	other := *pk1
+	other.H = new(gmp.Int).Add(pk1.H, OneBigInt)
+	if pk1.Equal(&other) || pk1.Fingerprint() == other.Fingerprint() {
+		t.Error("keys with different H have the same fingerprint")
+	}
+	withoutG := &PublicKey{N: pk1.N, H: pk1.H, K: pk1.K}
+	if !pk1.Equal(withoutG) || pk1.Fingerprint() != withoutG.Fingerprint() {
+		t.Error("a missing G is not the default generator")
+	}
+}
@@ -0,0 +1,236 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// MultiplicationRequest is sent by the evaluator to the key holder and
+// contains the blinded factors [x+a] and [y+b]
+type MultiplicationRequest struct {
+	BlindedX *Ciphertext
+	BlindedY *Ciphertext
+}
+
+// MultiplicationResponse is returned by the key holder and contains
+// [(x+a)*(y+b)] along with a proof that it was computed correctly
+type MultiplicationResponse struct {
+	Product *Ciphertext
+	Proof   *MultiplicationProof
+}
+
+// MultiplicationProof is a non-interactive proof (Fiat-Shamir heuristic) that
+// cz = cy^X * s^N mod N^2 where X is the plaintext of cx = g^X * r^N mod N^2.
+// See [CDN 01], section 8 for the interactive version.
+//
+//	[CDN 01]: Ronald Cramer, Ivan Damgard, Jesper Buus Nielsen, (2001)
+//	          Multiparty Computation from Threshold Homomorphic Encryption
+type MultiplicationProof struct {
+	A, B   *gmp.Int // commitments
+	Z      *gmp.Int // response in Z_N
+	T1, T2 *gmp.Int // responses in Z_N^*
+}
+
+// MultiplicationEvaluator holds the state of the party that wants to compute
+// [x*y] from [x] and [y] without learning x or y, with the help of the key holder
+// (see SecretKey.AssistMultiplication).
+//
+// The protocol is the standard blinding based multiplication:
+//  1. the evaluator sends [x+a], [y+b] for random a, b in Z_N
+//  2. the key holder decrypts both, returns [(x+a)(y+b)] and proves correctness
+//  3. the evaluator outputs [(x+a)(y+b)] * [x]^-b * [y]^-a * [-ab] = [xy]
+type MultiplicationEvaluator struct {
+	pk      *PublicKey
+	x, y    *Ciphertext
+	a, b    *gmp.Int
+	request *MultiplicationRequest
+}
+
+// NewMultiplicationEvaluator blinds the ciphertexts x and y and returns
+// the evaluator state together with the request for the key holder
+func (pk *PublicKey) NewMultiplicationEvaluator(x, y *Ciphertext) (*MultiplicationEvaluator, *MultiplicationRequest, error) {
+	if x.Level != EncLevelOne || y.Level != EncLevelOne {
+		return nil, nil, errors.New("multiplication is only supported for level one ciphertexts")
+	}
+
+	a, err := GetRandomNumber(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := GetRandomNumber(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := &MultiplicationRequest{
+		BlindedX: pk.Add(x, pk.Encrypt(a)),
+		BlindedY: pk.Add(y, pk.Encrypt(b)),
+	}
+
+	me := &MultiplicationEvaluator{
+		pk:      pk,
+		x:       x,
+		y:       y,
+		a:       a,
+		b:       b,
+		request: req,
+	}
+
+	return me, req, nil
+}
+
+// AssistMultiplication is run by the key holder on a request of the evaluator.
+// The key holder only learns the blinded values x+a and y+b.
+func (sk *SecretKey) AssistMultiplication(req *MultiplicationRequest) (*MultiplicationResponse, error) {
+	if req.BlindedX.Level != EncLevelOne || req.BlindedY.Level != EncLevelOne {
+		return nil, errors.New("multiplication is only supported for level one ciphertexts")
+	}
+
+	X := sk.Decrypt(req.BlindedX)
+	r := sk.ExtractRandomness(req.BlindedX)
+
+	s, err := GetRandomNumberInMultiplicativeGroup(sk.N, sk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	// [XY] = [Y]^X * s^N
+	product := sk.ConstMult(req.BlindedY, X)
+	product = sk.Add(product, sk.EncryptWithR(ZeroBigInt, s))
+	product.EncMethod = RegularEncryption
+
+	proof, err := sk.proveMultiplication(req.BlindedX, req.BlindedY, product, X, r, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultiplicationResponse{Product: product, Proof: proof}, nil
+}
+
+// Finalize verifies the response of the key holder and returns [x*y]
+func (me *MultiplicationEvaluator) Finalize(resp *MultiplicationResponse) (*Ciphertext, error) {
+	pk := me.pk
+
+	if !pk.VerifyMultiplicationProof(me.request.BlindedX, me.request.BlindedY, resp.Product, resp.Proof) {
+		return nil, errors.New("invalid multiplication proof")
+	}
+
+	negA := new(gmp.Int).Sub(pk.N, me.a)
+	negB := new(gmp.Int).Sub(pk.N, me.b)
+	negAB := new(gmp.Int).Mul(me.a, me.b)
+	negAB.Mod(negAB, pk.N)
+	negAB.Sub(pk.N, negAB)
+
+	return pk.Add(
+		resp.Product,
+		pk.ConstMult(me.x, negB),
+		pk.ConstMult(me.y, negA),
+		pk.Encrypt(negAB),
+	), nil
+}
+
+func (pk *PublicKey) proveMultiplication(cx, cy, cz *Ciphertext, x, r, s *gmp.Int) (*MultiplicationProof, error) {
+	n2 := pk.GetN2()
+
+	u, err := GetRandomNumber(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	v, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	w, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	// A = g^u v^N, B = cy^u w^N
+	A := pk.EncryptWithR(u, v).C
+	B := new(gmp.Int).Exp(cy.C, u, n2)
+	B.Mul(B, new(gmp.Int).Exp(w, pk.N, n2))
+	B.Mod(B, n2)
+
+	e := RandomOracleChallenge(pk.challengeBitLength(), pk.N, cx.C, cy.C, cz.C, A, B)
+
+	// u + e*x = q*N + z
+	q, z := new(gmp.Int).DivMod(
+		new(gmp.Int).Add(u, new(gmp.Int).Mul(e, x)),
+		pk.N,
+		new(gmp.Int),
+	)
+
+	// T1 = v r^e g^q, T2 = w s^e cy^q; the responses are only raised to the
+	// power N mod N^2, so they are reduced mod N
+	t1 := new(gmp.Int).Exp(r, e, n2)
+	t1.Mul(t1, v)
+	t1.Mul(t1, pk.gExp(q, EncLevelOne))
+	t1.Mod(t1, pk.N)
+
+	t2 := new(gmp.Int).Exp(s, e, n2)
+	t2.Mul(t2, w)
+	t2.Mul(t2, new(gmp.Int).Exp(cy.C, q, n2))
+	t2.Mod(t2, pk.N)
+
+	return &MultiplicationProof{A: A, B: B, Z: z, T1: t1, T2: t2}, nil
+}
+
+// VerifyMultiplicationProof checks that cz encrypts the product of the plaintexts
+// of cx and cy, where the prover knows the plaintext of cx
+func (pk *PublicKey) VerifyMultiplicationProof(cx, cy, cz *Ciphertext, proof *MultiplicationProof) bool {
+	return pk.VerifyMultiplicationProofErr(cx, cy, cz, proof) == nil
+}
+
+// VerifyMultiplicationProofErr verifies the proof as VerifyMultiplicationProof
+// and returns an error wrapping ErrMalformedProof, ErrProofPart1 (g^z T1^N = A cx^e)
+// or ErrProofPart2 (cy^z T2^N = B cz^e) if it is rejected
+func (pk *PublicKey) VerifyMultiplicationProofErr(cx, cy, cz *Ciphertext, proof *MultiplicationProof) error {
+	if proof == nil || proof.A == nil || proof.B == nil || proof.Z == nil || proof.T1 == nil || proof.T2 == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+	for _, ct := range []*Ciphertext{cx, cy, cz} {
+		if ct == nil || ct.C == nil {
+			return ErrInvalidCiphertext
+		}
+	}
+
+	// without these checks, zero commitments and responses satisfy both
+	// equations for any product
+	if err := pk.checkUnitsModN2(cx.C, cy.C, cz.C, proof.A, proof.B); err != nil {
+		return err
+	}
+	if err := pk.checkUnits(proof.T1, proof.T2); err != nil {
+		return err
+	}
+	if proof.Z.Sign() < 0 || proof.Z.Cmp(pk.N) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+
+	n2 := pk.GetN2()
+	e := RandomOracleChallenge(pk.challengeBitLength(), pk.N, cx.C, cy.C, cz.C, proof.A, proof.B)
+
+	// g^z T1^N = A cx^e
+	lhs := pk.EncryptWithR(proof.Z, proof.T1).C
+	rhs := new(gmp.Int).Exp(cx.C, e, n2)
+	rhs.Mul(rhs, proof.A)
+	rhs.Mod(rhs, n2)
+	if lhs.Cmp(rhs) != 0 {
+		return ErrProofPart1
+	}
+
+	// cy^z T2^N = B cz^e
+	lhs = new(gmp.Int).Exp(cy.C, proof.Z, n2)
+	lhs.Mul(lhs, new(gmp.Int).Exp(proof.T2, pk.N, n2))
+	lhs.Mod(lhs, n2)
+	rhs = new(gmp.Int).Exp(cz.C, e, n2)
+	rhs.Mul(rhs, proof.B)
+	rhs.Mod(rhs, n2)
+	if lhs.Cmp(rhs) != 0 {
+		return ErrProofPart2
+	}
+
+	return nil
+}
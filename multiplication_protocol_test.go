@@ -0,0 +1,96 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestMultiplicationProtocol(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for i := 0; i < 50; i++ {
+		x := pk.Encrypt(gmp.NewInt(int64(i)))
+		y := pk.Encrypt(gmp.NewInt(int64(3*i + 7)))
+
+		evaluator, req, err := pk.NewMultiplicationEvaluator(x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := sk.AssistMultiplication(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xy, err := evaluator.Finalize(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m := sk.Decrypt(xy); n(m) != i*(3*i+7) {
+			t.Error("wrong multiplication ", m, " is not ", i*(3*i+7))
+		}
+	}
+}
+
+func TestMultiplicationProofSoundness(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	x := pk.Encrypt(gmp.NewInt(6))
+	y := pk.Encrypt(gmp.NewInt(7))
+
+	evaluator, req, err := pk.NewMultiplicationEvaluator(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := sk.AssistMultiplication(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cheating key holder adds one to the product
+	cheat := &MultiplicationResponse{
+		Product: pk.Add(resp.Product, pk.Encrypt(gmp.NewInt(1))),
+		Proof:   resp.Proof,
+	}
+
+	if _, err := evaluator.Finalize(cheat); err == nil {
+		t.Error("multiplication proof is not sound")
+	}
+
+	// the challenge binds the product, so the first equation fails already
+	if err := pk.VerifyMultiplicationProofErr(req.BlindedX, req.BlindedY, cheat.Product, cheat.Proof); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected the first verification equation to fail, got ", err)
+	}
+
+	if _, err := evaluator.Finalize(&MultiplicationResponse{Product: resp.Product}); err == nil {
+		t.Error("accepted response without proof")
+	}
+
+	// zero commitments and responses satisfy both equations for any product
+	zero := b(0)
+	forged := &MultiplicationProof{A: zero, B: zero, Z: b(1), T1: zero, T2: zero}
+	if err := pk.VerifyMultiplicationProofErr(req.BlindedX, req.BlindedY, cheat.Product, forged); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error for a zeroed proof, got ", err)
+	}
+	if _, err := evaluator.Finalize(&MultiplicationResponse{Product: cheat.Product, Proof: forged}); err == nil {
+		t.Error("accepted a forged proof")
+	}
+
+	// responses out of range
+	for _, proof := range []*MultiplicationProof{
+		{A: resp.Proof.A, B: resp.Proof.B, Z: new(gmp.Int).Add(resp.Proof.Z, pk.N), T1: resp.Proof.T1, T2: resp.Proof.T2},
+		{A: resp.Proof.A, B: resp.Proof.B, Z: resp.Proof.Z, T1: new(gmp.Int).Add(resp.Proof.T1, pk.N), T2: resp.Proof.T2},
+		{A: resp.Proof.A, B: resp.Proof.B, Z: resp.Proof.Z, T1: resp.Proof.T1, T2: pk.N},
+	} {
+		if err := pk.VerifyMultiplicationProofErr(req.BlindedX, req.BlindedY, resp.Product, proof); !errors.Is(err, ErrMalformedProof) {
+			t.Error("expected malformed proof error, got ", err)
+		}
+	}
+	if err := pk.VerifyMultiplicationProofErr(req.BlindedX, req.BlindedY, resp.Product, resp.Proof); err != nil {
+		t.Error("honest proof is rejected: ", err)
+	}
+}
@@ -0,0 +1,256 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// bit lengths of the challenge and of the statistical hiding of a
+// NoSmallFactorProof, l and epsilon in [CGGMP 20]
+const (
+	noSmallFactorChallengeBits = 256
+	noSmallFactorSlackBits     = 512
+)
+
+// RingPedersenParams are the parameters of the ring-Pedersen commitments
+// s^x t^y mod N over a product N of two safe primes, where s and t generate
+// the same subgroup of squares. The verifier of a NoSmallFactorProof
+// generates them with NewRingPedersenParams and keeps the trapdoor secret;
+// in threshold-ECDSA protocols they are part of every party's public key.
+type RingPedersenParams struct {
+	N, S, T *gmp.Int
+}
+
+// NewRingPedersenParams returns ring-Pedersen parameters with a modulus of
+// the given bit length: t is a random square and s = t^lambda for a random
+// lambda which is discarded
+func NewRingPedersenParams(bits int, random io.Reader) (*RingPedersenParams, error) {
+	if bits%2 != 0 || bits < 32 {
+		return nil, errors.New("bit length must be an even number of at least 32")
+	}
+
+	p, p1, err := GenerateSafePrime(bits/2, 4, 120*time.Second, random)
+	if err != nil {
+		return nil, err
+	}
+	var q, q1 *gmp.Int
+	for {
+		bq, bq1, err := GenerateSafePrime(bits/2, 4, 120*time.Second, random)
+		if err != nil {
+			return nil, err
+		}
+		if bq.Cmp(p) != 0 {
+			q, q1 = ToGmpInt(bq), ToGmpInt(bq1)
+			break
+		}
+	}
+
+	n := new(gmp.Int).Mul(ToGmpInt(p), q)
+	order := new(gmp.Int).Mul(ToGmpInt(p1), q1) // order of the squares mod n
+
+	tau, err := GetRandomNumberInMultiplicativeGroup(n, random)
+	if err != nil {
+		return nil, err
+	}
+	t := new(gmp.Int).Mul(tau, tau)
+	t.Mod(t, n)
+
+	lambda, err := GetRandomNumber(order, random)
+	if err != nil {
+		return nil, err
+	}
+	s := new(gmp.Int).Exp(t, lambda, n)
+
+	return &RingPedersenParams{N: n, S: s, T: t}, nil
+}
+
+// NoSmallFactorProof is a non-interactive proof (Fiat-Shamir heuristic) that
+// the prime factors p and q of N have about the same size, i.e., that both
+// are at least sqrt(N) / 2^(l+epsilon) for l = 256 and epsilon = 512. The
+// prover commits to p and q with the ring-Pedersen parameters of the
+// verifier and proves that the committed values are in range and multiply
+// to N. Together with a ModulusProof, it is the set of modulus proofs that
+// threshold-ECDSA protocols such as [CGGMP 20] require of Paillier keys; it
+// is the no-small-factor proof of section C.5. The bound is only meaningful
+// for moduli of well over 2(l+epsilon) = 1536 bits, e.g., 2048 bits.
+type NoSmallFactorProof struct {
+	P, Q, A, B, T, Sigma *gmp.Int // commitments
+	Z1, Z2, W1, W2, V    *gmp.Int // responses over the integers
+}
+
+// ProveNoSmallFactor proves that N has no small factors for the given
+// ring-Pedersen parameters of the verifier. It requires the factorization of
+// N, which is available for keys generated by KeyGen.
+func (sk *SecretKey) ProveNoSmallFactor(params *RingPedersenParams) (*NoSmallFactorProof, error) {
+	p, q := sk.factorWithPhi()
+	if p == nil {
+		return nil, errors.New("the factorization of N is not available")
+	}
+	return proveNoSmallFactor(sk.N, p, q, params, sk.RandomSource())
+}
+
+// ProveNoSmallFactor proves that the modulus of the generated keys has no
+// small factors for the given ring-Pedersen parameters of the verifier. It
+// must be called after GenerateKeys.
+func (tkg *ThresholdKeyGenerator) ProveNoSmallFactor(params *RingPedersenParams) (*NoSmallFactorProof, error) {
+	if tkg.n == nil {
+		return nil, errors.New("keys have not been generated")
+	}
+	return proveNoSmallFactor(tkg.n, tkg.p, tkg.q, params, tkg.random)
+}
+
+// VerifyNoSmallFactorProof returns true iff the proof shows that N has no
+// small factors for the ring-Pedersen parameters of the verifier
+func (pk *PublicKey) VerifyNoSmallFactorProof(params *RingPedersenParams, proof *NoSmallFactorProof) bool {
+	return pk.VerifyNoSmallFactorProofErr(params, proof) == nil
+}
+
+// VerifyNoSmallFactorProofErr verifies the proof as VerifyNoSmallFactorProof
+// and returns an error wrapping ErrMalformedProof, ErrProofPart1 (commitment
+// to p), ErrProofPart2 (commitment to q) or ErrProofPart3 (p*q = N) if it is
+// rejected
+func (pk *PublicKey) VerifyNoSmallFactorProofErr(params *RingPedersenParams, proof *NoSmallFactorProof) error {
+	if params == nil || params.N == nil || params.S == nil || params.T == nil {
+		return errors.New("missing ring-Pedersen parameters")
+	}
+	if proof == nil || proof.P == nil || proof.Q == nil || proof.A == nil || proof.B == nil || proof.T == nil ||
+		proof.Sigma == nil || proof.Z1 == nil || proof.Z2 == nil || proof.W1 == nil || proof.W2 == nil || proof.V == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	nHat := params.N
+	for _, v := range []*gmp.Int{proof.P, proof.Q, proof.A, proof.B, proof.T} {
+		if v.Sign() <= 0 || v.Cmp(nHat) >= 0 || new(gmp.Int).GCD(nil, nil, v, nHat).Cmp(OneBigInt) != 0 {
+			return fmt.Errorf("%w: commitment is not a unit mod the ring-Pedersen modulus", ErrMalformedProof)
+		}
+	}
+
+	bound := noSmallFactorBound(pk.N)
+	for _, z := range []*gmp.Int{proof.Z1, proof.Z2} {
+		if new(gmp.Int).Abs(z).Cmp(bound) > 0 {
+			return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+		}
+	}
+
+	e := noSmallFactorChallenge(pk.N, params, proof)
+
+	// s^Z1 t^W1 = A P^e
+	if !ringPedersenEqual(params, params.S, proof.Z1, proof.W1, proof.A, proof.P, e) {
+		return ErrProofPart1
+	}
+
+	// s^Z2 t^W2 = B Q^e
+	if !ringPedersenEqual(params, params.S, proof.Z2, proof.W2, proof.B, proof.Q, e) {
+		return ErrProofPart2
+	}
+
+	// Q^Z1 t^V = T R^e for R = s^N t^Sigma
+	r := expSigned(params.S, pk.N, nHat)
+	r.Mul(r, expSigned(params.T, proof.Sigma, nHat))
+	r.Mod(r, nHat)
+	if !ringPedersenEqual(params, proof.Q, proof.Z1, proof.V, proof.T, r, e) {
+		return ErrProofPart3
+	}
+
+	return nil
+}
+
+func proveNoSmallFactor(n, p, q *gmp.Int, params *RingPedersenParams, random io.Reader) (*NoSmallFactorProof, error) {
+	if params == nil || params.N == nil || params.S == nil || params.T == nil {
+		return nil, errors.New("missing ring-Pedersen parameters")
+	}
+
+	nHat := params.N
+	nnHat := new(gmp.Int).Mul(n, nHat)
+	bound := noSmallFactorBound(n)
+	hidingBits := uint(noSmallFactorChallengeBits + noSmallFactorSlackBits)
+
+	// alpha, beta in +-sqrt(N) 2^(l+epsilon); mu, nu in +-2^l N^; sigma in
+	// +-2^l N N^; r in +-2^(l+epsilon) N N^; x, y in +-2^(l+epsilon) N^
+	bounds := []*gmp.Int{
+		bound,
+		bound,
+		new(gmp.Int).Lsh(nHat, noSmallFactorChallengeBits),
+		new(gmp.Int).Lsh(nHat, noSmallFactorChallengeBits),
+		new(gmp.Int).Lsh(nnHat, noSmallFactorChallengeBits),
+		new(gmp.Int).Lsh(nnHat, hidingBits),
+		new(gmp.Int).Lsh(nHat, hidingBits),
+		new(gmp.Int).Lsh(nHat, hidingBits),
+	}
+
+	for {
+		values := make([]*gmp.Int, len(bounds))
+		for i, b := range bounds {
+			var err error
+			if values[i], err = getRandomSigned(b, random); err != nil {
+				return nil, err
+			}
+		}
+		alpha, beta, mu, nu, sigma, r, x, y := values[0], values[1], values[2], values[3], values[4], values[5], values[6], values[7]
+
+		proof := &NoSmallFactorProof{Sigma: sigma}
+		proof.P = ringPedersenCommit(params, params.S, p, mu)
+		proof.Q = ringPedersenCommit(params, params.S, q, nu)
+		proof.A = ringPedersenCommit(params, params.S, alpha, x)
+		proof.B = ringPedersenCommit(params, params.S, beta, y)
+		proof.T = ringPedersenCommit(params, proof.Q, alpha, r)
+
+		e := noSmallFactorChallenge(n, params, proof)
+
+		// sigma' = sigma - nu p
+		sigmaHat := new(gmp.Int).Mul(nu, p)
+		sigmaHat.Sub(sigma, sigmaHat)
+
+		proof.Z1 = new(gmp.Int).Add(alpha, new(gmp.Int).Mul(e, p))
+		proof.Z2 = new(gmp.Int).Add(beta, new(gmp.Int).Mul(e, q))
+		proof.W1 = new(gmp.Int).Add(x, new(gmp.Int).Mul(e, mu))
+		proof.W2 = new(gmp.Int).Add(y, new(gmp.Int).Mul(e, nu))
+		proof.V = new(gmp.Int).Add(r, new(gmp.Int).Mul(e, sigmaHat))
+
+		// retry in the rare case that a response is out of range
+		if new(gmp.Int).Abs(proof.Z1).Cmp(bound) > 0 || new(gmp.Int).Abs(proof.Z2).Cmp(bound) > 0 {
+			continue
+		}
+		return proof, nil
+	}
+}
+
+// returns sqrt(N) 2^(l+epsilon), the bound of the responses for p and q
+func noSmallFactorBound(n *gmp.Int) *gmp.Int {
+	bound := new(gmp.Int).Sqrt(n)
+	bound.Add(bound, OneBigInt)
+	return bound.Lsh(bound, noSmallFactorChallengeBits+noSmallFactorSlackBits)
+}
+
+func noSmallFactorChallenge(n *gmp.Int, params *RingPedersenParams, proof *NoSmallFactorProof) *gmp.Int {
+	return RandomOracleChallenge(noSmallFactorChallengeBits, n, params.N, params.S, params.T,
+		proof.P, proof.Q, proof.A, proof.B, proof.T, proof.Sigma)
+}
+
+// returns base^x t^y mod N for the ring-Pedersen parameters
+func ringPedersenCommit(params *RingPedersenParams, base, x, y *gmp.Int) *gmp.Int {
+	c := expSigned(base, x, params.N)
+	c.Mul(c, expSigned(params.T, y, params.N))
+	return c.Mod(c, params.N)
+}
+
+// returns true iff base^x t^y = a b^e mod N
+func ringPedersenEqual(params *RingPedersenParams, base, x, y, a, b, e *gmp.Int) bool {
+	rhs := new(gmp.Int).Exp(b, e, params.N)
+	rhs.Mul(rhs, a)
+	rhs.Mod(rhs, params.N)
+	return ringPedersenCommit(params, base, x, y).Cmp(rhs) == 0
+}
+
+// returns a uniformly random integer in [-bound, bound]
+func getRandomSigned(bound *gmp.Int, random io.Reader) (*gmp.Int, error) {
+	x, err := GetRandomNumber(new(gmp.Int).Lsh(bound, 1), random)
+	if err != nil {
+		return nil, err
+	}
+	return x.Sub(x, bound), nil
+}
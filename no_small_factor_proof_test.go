@@ -0,0 +1,50 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestNoSmallFactorProof(t *testing.T) {
+	sk, pk := KeyGen(128)
+	params, err := NewRingPedersenParams(128, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := sk.ProveNoSmallFactor(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.VerifyNoSmallFactorProofErr(params, proof); err != nil {
+		t.Error("no-small-factor proof is not complete: ", err)
+	}
+
+	// the proof is bound to the modulus and the parameters of the verifier
+	_, other := KeyGen(128)
+	if other.VerifyNoSmallFactorProof(params, proof) {
+		t.Error("no-small-factor proof verifies for another modulus")
+	}
+	otherParams, err := NewRingPedersenParams(128, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.VerifyNoSmallFactorProof(otherParams, proof) {
+		t.Error("no-small-factor proof verifies for other parameters")
+	}
+
+	tampered := *proof
+	tampered.Z1 = new(gmp.Int).Add(proof.Z1, OneBigInt)
+	if err := pk.VerifyNoSmallFactorProofErr(params, &tampered); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1 for a tampered response, got ", err)
+	}
+
+	tampered = *proof
+	tampered.Z2 = new(gmp.Int).Lsh(noSmallFactorBound(pk.N), 1)
+	if err := pk.VerifyNoSmallFactorProofErr(params, &tampered); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error for a response out of range, got ", err)
+	}
+}
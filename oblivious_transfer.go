@@ -0,0 +1,150 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// OTRequest is sent by the receiver of an oblivious transfer to the sender.
+// Choices[i] encrypts 1 for the chosen message and 0 otherwise, each with a
+// proof that it encrypts a bit, and Randomness is the product R of their
+// randomness, which shows that prod_i Choices[i] = g R^N mod N^2, i.e., that
+// exactly one message is chosen.
+type OTRequest struct {
+	Choices    []*Ciphertext
+	Proofs     []*BinaryProof
+	Randomness *gmp.Int
+}
+
+// OTResponse is returned by the sender and encrypts the chosen message
+type OTResponse struct {
+	Ciphertext *Ciphertext
+}
+
+// OTReceiver holds the state of the receiver of a 1-out-of-n oblivious
+// transfer, which is 1-out-of-2 for n = 2:
+//  1. the receiver encrypts the unit vector of its choice with proofs
+//  2. the sender returns [sum_i choice_i * m_i] = prod_i [choice_i]^m_i,
+//     rerandomized (SecretKey.NewOTReceiver, PublicKey.RespondOT)
+//  3. the receiver decrypts the chosen message
+//
+// The sender learns nothing about the choice and the receiver learns only
+// the chosen message, since the proofs force the choice vector to be a unit
+// vector. Messages are at most MaxOTMessageLength bytes long; longer
+// messages can be transferred by sending keys of a symmetric cipher.
+// All messages are gob-serializable.
+type OTReceiver struct {
+	sk *SecretKey
+	n  int
+}
+
+// NewOTReceiver returns the receiver of the message with index choice among
+// n messages together with the request for the sender
+func (sk *SecretKey) NewOTReceiver(n, choice int) (*OTReceiver, *OTRequest, error) {
+	if n < 2 {
+		return nil, nil, errors.New("oblivious transfer needs at least two messages")
+	}
+
+	if choice < 0 || choice >= n {
+		return nil, nil, errors.New("choice is out of range")
+	}
+
+	pk := &sk.PublicKey
+	req := &OTRequest{
+		Choices:    make([]*Ciphertext, n),
+		Proofs:     make([]*BinaryProof, n),
+		Randomness: gmp.NewInt(1),
+	}
+
+	for i := 0; i < n; i++ {
+		bit := 0
+		if i == choice {
+			bit = 1
+		}
+
+		r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req.Choices[i] = pk.EncryptWithR(gmp.NewInt(int64(bit)), r)
+		req.Proofs[i], err = pk.ProveBinary(req.Choices[i], bit, r)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req.Randomness.Mul(req.Randomness, r)
+		req.Randomness.Mod(req.Randomness, pk.N)
+	}
+
+	return &OTReceiver{sk: sk, n: n}, req, nil
+}
+
+// RespondOT is run by the sender on a request of the receiver and returns
+// the encryption of the chosen message after verifying the request
+func (pk *PublicKey) RespondOT(req *OTRequest, messages [][]byte) (*OTResponse, error) {
+	if len(messages) != len(req.Choices) || len(req.Proofs) != len(req.Choices) {
+		return nil, errors.New("there must be one choice and proof per message")
+	}
+
+	if len(messages) < 2 {
+		return nil, errors.New("oblivious transfer needs at least two messages")
+	}
+
+	if req.Randomness == nil {
+		return nil, fmt.Errorf("%w: missing randomness", ErrMalformedProof)
+	}
+
+	product := gmp.NewInt(1)
+	for i, ct := range req.Choices {
+		if err := pk.VerifyBinaryProofErr(ct, req.Proofs[i]); err != nil {
+			return nil, fmt.Errorf("choice %d: %w", i, err)
+		}
+		product.Mul(product, ct.C)
+		product.Mod(product, pk.GetN2())
+	}
+
+	if pk.EncryptWithR(OneBigInt, req.Randomness).C.Cmp(product) != 0 {
+		return nil, errors.New("choices do not select exactly one message")
+	}
+
+	terms := make([]*Ciphertext, len(messages))
+	for i, msg := range messages {
+		m, err := pk.encodeOTMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		terms[i] = pk.ConstMult(req.Choices[i], m)
+	}
+
+	return &OTResponse{Ciphertext: pk.Randomize(pk.Add(terms...))}, nil
+}
+
+// Receive decrypts the chosen message from the response of the sender
+func (r *OTReceiver) Receive(resp *OTResponse) ([]byte, error) {
+	if resp.Ciphertext == nil || resp.Ciphertext.Level != EncLevelOne {
+		return nil, errors.New("invalid oblivious transfer response")
+	}
+
+	encoded := r.sk.Decrypt(resp.Ciphertext).Bytes()
+	if len(encoded) == 0 || encoded[0] != 1 {
+		return nil, errors.New("invalid oblivious transfer message")
+	}
+	return encoded[1:], nil
+}
+
+// MaxOTMessageLength returns the maximum length in bytes of the messages of
+// an oblivious transfer under the key
+func (pk *PublicKey) MaxOTMessageLength() int {
+	return (pk.N.BitLen()-1)/8 - 1
+}
+
+// prefixes the message with a one byte so that leading zeros are kept
+func (pk *PublicKey) encodeOTMessage(msg []byte) (*gmp.Int, error) {
+	if len(msg) > pk.MaxOTMessageLength() {
+		return nil, fmt.Errorf("%w: OT message is longer than %d bytes", ErrMessageTooLarge, pk.MaxOTMessageLength())
+	}
+	return new(gmp.Int).SetBytes(append([]byte{1}, msg...)), nil
+}
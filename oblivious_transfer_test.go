@@ -0,0 +1,87 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestObliviousTransfer(t *testing.T) {
+	sk, pk := KeyGen(256)
+	messages := [][]byte{[]byte("zero"), {0, 0, 1}, []byte(""), []byte("three")}
+
+	for _, n := range []int{2, len(messages)} {
+		for choice := 0; choice < n; choice++ {
+			receiver, req, err := sk.NewOTReceiver(n, choice)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the round messages are serializable
+			data, err := gobEncode(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			received := new(OTRequest)
+			if err := gobDecode(data, received); err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := pk.RespondOT(received, messages[:n])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, err := receiver.Receive(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(msg, messages[choice]) {
+				t.Errorf("wrong message %q for choice %d of %d", msg, choice, n)
+			}
+		}
+	}
+}
+
+func TestObliviousTransferMalformedChoice(t *testing.T) {
+	sk, pk := KeyGen(256)
+	messages := [][]byte{[]byte("left"), []byte("right")}
+
+	// a receiver that chooses both messages
+	_, req, err := sk.NewOTReceiver(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := sk.NewOTReceiver(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Choices[1], req.Proofs[1] = other.Choices[1], other.Proofs[1]
+	if _, err := pk.RespondOT(req, messages); err == nil {
+		t.Error("request choosing both messages accepted")
+	}
+
+	// choices 2 and -1 add up to one and would reveal 2*m_0 - m_1, so only
+	// the forged proofs stand in the way of the request
+	r0, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+	r1, _ := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+	choices := []*Ciphertext{
+		pk.EncryptWithR(gmp.NewInt(2), r0),
+		pk.EncryptWithR(new(gmp.Int).Sub(pk.N, OneBigInt), r1),
+	}
+	forged := &OTRequest{
+		Choices:    choices,
+		Proofs:     []*BinaryProof{forgeBinaryProof(pk, choices[0]), forgeBinaryProof(pk, choices[1])},
+		Randomness: new(gmp.Int).Mod(new(gmp.Int).Mul(r0, r1), pk.N),
+	}
+	if _, err := pk.RespondOT(forged, messages); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error for forged choices, got ", err)
+	}
+
+	if _, err := pk.RespondOT(other, [][]byte{messages[0], make([]byte, pk.MaxOTMessageLength()+1)}); err == nil {
+		t.Error("expected error for a message that is too long")
+	}
+}
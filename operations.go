@@ -1,8 +1,12 @@
 package paillier
 
 import (
-	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"sync"
 
 	gmp "github.com/ncw/gmp"
 )
@@ -28,6 +32,65 @@ func (pk *PublicKey) Add(cts ...*Ciphertext) *Ciphertext {
 	}
 }
 
+// eAddManyChunk is the number of ciphertexts above which EAddMany multiplies
+// chunks of the input in parallel
+const eAddManyChunk = 1024
+
+// EAddMany homomorphically adds the ciphertexts, which must all be at the same
+// level. Unlike Add it accumulates in place without allocating per ciphertext,
+// and large inputs are split into chunks that are multiplied in parallel.
+// The sum of no ciphertexts is the (deterministic) encryption 1 of zero.
+func (pk *PublicKey) EAddMany(cts ...*Ciphertext) *Ciphertext {
+	if len(cts) == 0 {
+		return &Ciphertext{gmp.NewInt(1), EncLevelOne, RegularEncryption}
+	}
+
+	level := cts[0].Level
+	_, _, ns1 := pk.getModuliForLevel(level)
+
+	chunks := (len(cts) + eAddManyChunk - 1) / eAddManyChunk
+	if chunks > runtime.NumCPU() {
+		chunks = runtime.NumCPU()
+	}
+	size := (len(cts) + chunks - 1) / chunks
+
+	products := make([]*gmp.Int, chunks)
+	var wg sync.WaitGroup
+	for i := range products {
+		end := (i + 1) * size
+		if end > len(cts) {
+			end = len(cts)
+		}
+
+		wg.Add(1)
+		go func(i int, part []*Ciphertext) {
+			defer wg.Done()
+			products[i] = productMod(part, ns1)
+		}(i, cts[i*size:end])
+	}
+	wg.Wait()
+
+	accumulator := products[0]
+	tmp := new(gmp.Int)
+	for _, p := range products[1:] {
+		tmp.Mul(accumulator, p)
+		accumulator.Mod(tmp, ns1)
+	}
+
+	return &Ciphertext{accumulator, level, MixedEncryption}
+}
+
+// productMod returns the product of the ciphertexts mod m
+func productMod(cts []*Ciphertext, m *gmp.Int) *gmp.Int {
+	accumulator := gmp.NewInt(1)
+	tmp := new(gmp.Int)
+	for _, c := range cts {
+		tmp.Mul(accumulator, c.C)
+		accumulator.Mod(tmp, m)
+	}
+	return accumulator
+}
+
 // Sub homomorphically subtracts encrypted values from the first value
 func (pk *PublicKey) Sub(cts ...*Ciphertext) *Ciphertext {
 
@@ -54,6 +117,25 @@ func (pk *PublicKey) Sub(cts ...*Ciphertext) *Ciphertext {
 	}
 }
 
+// EAddConstant returns an encryption of m + k mod N^s without encrypting k,
+// i.e., by multiplying ct with g^k. The result has the randomness of ct, so
+// it should be randomized before it is revealed to a party that knows ct.
+func (pk *PublicKey) EAddConstant(ct *Ciphertext, k *big.Int) *Ciphertext {
+	_, ns, ns1 := pk.getModuliForLevel(ct.Level)
+
+	kMod := ToGmpInt(new(big.Int).Mod(k, ToBigInt(ns)))
+	gk := pk.gExp(kMod, ct.Level)
+
+	c := new(gmp.Int).Mod(new(gmp.Int).Mul(ct.C, gk), ns1)
+	return &Ciphertext{c, ct.Level, MixedEncryption}
+}
+
+// ESub returns an encryption of m1 - m2 mod N^s by multiplying ct1 with the
+// inverse of ct2. Use EncodeSigned and DecodeSigned for negative differences.
+func (pk *PublicKey) ESub(ct1, ct2 *Ciphertext) *Ciphertext {
+	return pk.Sub(ct1, ct2)
+}
+
 // ConstMult multiplies an encrypted value by constant
 func (pk *PublicKey) ConstMult(ct *Ciphertext, k *gmp.Int) *Ciphertext {
 
@@ -63,23 +145,98 @@ func (pk *PublicKey) ConstMult(ct *Ciphertext, k *gmp.Int) *Ciphertext {
 	return &Ciphertext{m, ct.Level, ct.EncMethod}
 }
 
+// ECMult returns an encryption of k*m where m is the plaintext of ct. Unlike
+// ConstMult, k may be negative, in which case the inverse of the ciphertext
+// is raised to |k|.
+func (pk *PublicKey) ECMult(ct *Ciphertext, k *big.Int) *Ciphertext {
+	_, _, ns1 := pk.getModuliForLevel(ct.Level)
+
+	base := ct.C
+	if k.Sign() < 0 {
+		base = new(gmp.Int).ModInverse(ct.C, ns1)
+	}
+
+	m := new(gmp.Int).Exp(base, ToGmpInt(new(big.Int).Abs(k)), ns1)
+	return &Ciphertext{m, ct.Level, ct.EncMethod}
+}
+
+// EDivConstant returns an encryption of m/k where m is the plaintext of ct
+// and k is a positive divisor of m, e.g., the average of an encrypted sum of
+// k values that is known to be a multiple of k. It is ECMult by k^-1 mod N^s,
+// so if k does not divide m the result is an encryption of m*k^-1 mod N^s,
+// which is unrelated to the quotient; CheckQuotient detects this after
+// decryption. To average sums that may not be multiples of the count, scale
+// the values before encryption, see EncodeFixedPoint, or divide after
+// decryption. It returns an error if k is not positive or not coprime to N.
+func (pk *PublicKey) EDivConstant(ct *Ciphertext, k *big.Int) (*Ciphertext, error) {
+	if k.Sign() <= 0 {
+		return nil, errors.New("divisor must be positive")
+	}
+
+	_, ns, _ := pk.getModuliForLevel(ct.Level)
+	kInv := new(big.Int).ModInverse(k, ToBigInt(ns))
+	if kInv == nil {
+		return nil, errors.New("divisor is not coprime to N")
+	}
+	return pk.ECMult(ct, kInv), nil
+}
+
+// CheckQuotient checks that q, the decryption of the result of EDivConstant
+// of a ciphertext at the given level by k, is the exact quotient m/k, i.e.,
+// that k divides m. This is the case iff q*k < N^s, otherwise it returns an
+// error wrapping ErrInexactDivision.
+func (pk *PublicKey) CheckQuotient(q *gmp.Int, k *big.Int, level EncryptionLevel) error {
+	if k.Sign() <= 0 {
+		return errors.New("divisor must be positive")
+	}
+
+	_, ns, _ := pk.getModuliForLevel(level)
+	if q.Sign() < 0 || new(gmp.Int).Mul(q, ToGmpInt(k)).Cmp(ns) >= 0 {
+		return fmt.Errorf("%w: the plaintext is not a multiple of %v", ErrInexactDivision, k)
+	}
+	return nil
+}
+
 // Randomize randomizes an encryption
 func (pk *PublicKey) Randomize(ct *Ciphertext) *Ciphertext {
 	return pk.Add(ct, pk.Encrypt(ZeroBigInt))
 }
 
-// ExtractRandonness returns the randomness used in the encryption
+// Rerandomize returns a fresh ciphertext of the same plaintext and level that
+// is unlinkable to ct, by multiplying ct with r^(N^s) for a random unit r
+// read from random
+func (pk *PublicKey) Rerandomize(ct *Ciphertext, random io.Reader) (*Ciphertext, error) {
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ns, ns1 := pk.getModuliForLevel(ct.Level)
+	rn := new(gmp.Int).Exp(r, ns, ns1)
+	c := new(gmp.Int).Mod(new(gmp.Int).Mul(ct.C, rn), ns1)
+	return &Ciphertext{c, ct.Level, ct.EncMethod}, nil
+}
+
+// ExtractRandonness returns the randomness used in the encryption.
+//
+// Deprecated: use ExtractRandomness, which is the same method with a correctly
+// spelled name, or ExtractNonce.
+func (sk *SecretKey) ExtractRandonness(ct *Ciphertext) *gmp.Int {
+	return sk.ExtractRandomness(ct)
+}
+
+// ExtractRandomness returns the randomness used in the encryption
 // See the following stack exchange post:
 // https://crypto.stackexchange.com/questions/46736/how-to-prove-correct-decryption-in-paillier-cryptosystem
 // for explanation
-func (sk *SecretKey) ExtractRandonness(ct *Ciphertext) *gmp.Int {
+func (sk *SecretKey) ExtractRandomness(ct *Ciphertext) *gmp.Int {
 
 	_, ns, ns1 := sk.getModuliForLevel(ct.Level)
 
 	nsInv := new(gmp.Int).ModInverse(ns, sk.Lambda)
 
 	v := sk.Decrypt(ct)
-	gv := new(gmp.Int).Exp(sk.G, v, ns1)
+	gv := sk.gExp(v, ct.Level)
 	gvInv := gv.ModInverse(gv, ns1)
 
 	z := gvInv.Mul(gvInv, ct.C) // make a ciphertext encrypting zero to isolate randomness
@@ -90,6 +247,32 @@ func (sk *SecretKey) ExtractRandonness(ct *Ciphertext) *gmp.Int {
 	return res
 }
 
+// ExtractNonce returns the nonce r in Z*_N of a regular encryption
+// c = (1+N)^m r^(N^s) mod N^(s+1), which is unique. Revealing it proves the
+// plaintext of the ciphertext without the secret key: anyone can re-encrypt
+// the plaintext with EncryptWithRAtLevel and compare. Unlike
+// ExtractRandomness, it returns an error wrapping ErrInvalidCiphertext if ct
+// is not a unit mod N^(s+1) and an error if ct was not encrypted regularly,
+// e.g., by AltEncryptAtLevel, whose randomness is not of this form.
+func (sk *SecretKey) ExtractNonce(ct *Ciphertext) (*gmp.Int, error) {
+	if ct == nil || ct.C == nil {
+		return nil, fmt.Errorf("%w: missing ciphertext", ErrInvalidCiphertext)
+	}
+	if ct.Level < EncLevelOne || ct.Level > MaxEncryptionLevel {
+		return nil, fmt.Errorf("%w: unknown encryption level %d", ErrInvalidCiphertext, ct.Level)
+	}
+	if ct.EncMethod != RegularEncryption {
+		return nil, errors.New("nonces can only be extracted from regular encryptions")
+	}
+
+	_, _, ns1 := sk.getModuliForLevel(ct.Level)
+	if ct.C.Sign() <= 0 || ct.C.Cmp(ns1) >= 0 || new(gmp.Int).GCD(nil, nil, ct.C, sk.N).Cmp(OneBigInt) != 0 {
+		return nil, fmt.Errorf("%w: ciphertext is not a unit mod N^%d", ErrInvalidCiphertext, ct.Level.S()+1)
+	}
+
+	return sk.ExtractRandomness(ct), nil
+}
+
 // NestedRandomize homomorphically randomizes a nested encryption
 // (only works with doubly encrypted values)
 // returns randomized ciphertext and randomness used
@@ -102,8 +285,8 @@ func (pk *PublicKey) NestedRandomize(ct *Ciphertext) (*Ciphertext, *gmp.Int, *gm
 	n2 := pk.GetN2()
 	n3 := pk.GetN3()
 
-	a, _ := GetRandomNumberInMultiplicativeGroup(n, rand.Reader)
-	b, _ := GetRandomNumberInMultiplicativeGroup(n, rand.Reader)
+	a, _ := GetRandomNumberInMultiplicativeGroup(n, pk.RandomSource())
+	b, _ := GetRandomNumberInMultiplicativeGroup(n, pk.RandomSource())
 
 	an := new(gmp.Int).Exp(a, n, n2)
 	bn2 := new(gmp.Int).Exp(b, n2, n3)
@@ -138,11 +321,3 @@ func (pk *PublicKey) NestedSub(ct1 *Ciphertext, ct2 *Ciphertext) *Ciphertext {
 
 	return pk.ConstMult(ct1, neg)
 }
-
-func (sk *SecretKey) String() string {
-	ret := fmt.Sprintf("g     :  %s\n", sk.G.String())
-	ret += fmt.Sprintf("n     :  %s\n", sk.N.String())
-	ret += fmt.Sprintf("lambda:  %s\n", sk.Lambda.String())
-	ret += fmt.Sprintf("mu    :  %s\n", sk.Mu.String())
-	return ret
-}
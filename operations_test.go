@@ -1,6 +1,9 @@
 package paillier
 
 import (
+	"bytes"
+	"crypto/rand"
+	"errors"
 	"math/big"
 	"reflect"
 	"testing"
@@ -24,6 +27,46 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+func TestEAddMany(t *testing.T) {
+	privateKey, _ := KeyGen(64)
+	pk := privateKey.PublicKey
+
+	for _, count := range []int{0, 1, 5, 3*eAddManyChunk + 17} {
+		cts := make([]*Ciphertext, count)
+		for i := range cts {
+			cts[i] = pk.Encrypt(gmp.NewInt(int64(i % 100)))
+		}
+
+		expected := 0
+		for i := 0; i < count; i++ {
+			expected += i % 100
+		}
+
+		if m := privateKey.Decrypt(pk.EAddMany(cts...)); n(m) != expected {
+			t.Error("wrong sum of ", count, " ciphertexts ", m, " is not ", expected)
+		}
+	}
+}
+
+func BenchmarkEAddMany(b *testing.B) {
+	_, pk := KeyGen(1024)
+	cts := make([]*Ciphertext, 10000)
+	for i := range cts {
+		cts[i] = pk.Encrypt(gmp.NewInt(int64(i)))
+	}
+
+	b.Run("Add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pk.Add(cts...)
+		}
+	})
+	b.Run("EAddMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pk.EAddMany(cts...)
+		}
+	})
+}
+
 func TestSub(t *testing.T) {
 	privateKey, _ := KeyGen(64)
 	pk := privateKey.PublicKey
@@ -40,6 +83,39 @@ func TestSub(t *testing.T) {
 	}
 }
 
+func TestESub(t *testing.T) {
+	privateKey, _ := KeyGen(64)
+	pk := privateKey.PublicKey
+
+	balance := pk.Encrypt(pk.EncodeSigned(big.NewInt(25)))
+	withdrawal := pk.Encrypt(pk.EncodeSigned(big.NewInt(40)))
+
+	m := pk.DecodeSigned(privateKey.Decrypt(pk.ESub(balance, withdrawal)))
+	if m.Int64() != -15 {
+		t.Error("wrong subtraction ", m, " is not -15")
+	}
+}
+
+func TestEAddConstant(t *testing.T) {
+	privateKey, _ := KeyGen(64)
+	pk := privateKey.PublicKey
+
+	ciphertext1 := pk.Encrypt(gmp.NewInt(20))
+	if m := privateKey.Decrypt(pk.EAddConstant(ciphertext1, big.NewInt(22))); n(m) != 42 {
+		t.Error("wrong addition ", m, " is not 42")
+	}
+
+	m := pk.DecodeSigned(privateKey.Decrypt(pk.EAddConstant(ciphertext1, big.NewInt(-30))))
+	if m.Int64() != -10 {
+		t.Error("wrong addition of negative constant ", m, " is not -10")
+	}
+
+	ciphertext2 := pk.EncryptAtLevel(gmp.NewInt(5), EncLevelTwo)
+	if m := privateKey.Decrypt(pk.EAddConstant(ciphertext2, big.NewInt(7))); n(m) != 12 {
+		t.Error("wrong addition at level two ", m, " is not 12")
+	}
+}
+
 func TestMult(t *testing.T) {
 	privateKey, _ := KeyGen(64)
 	pk := privateKey.PublicKey
@@ -53,6 +129,98 @@ func TestMult(t *testing.T) {
 	}
 }
 
+func TestECMult(t *testing.T) {
+	privateKey, _ := KeyGen(64)
+	pk := privateKey.PublicKey
+
+	ciphertext1 := pk.Encrypt(gmp.NewInt(40))
+	ciphertext2 := pk.ECMult(ciphertext1, big.NewInt(-3))
+	m := pk.DecodeSigned(privateKey.Decrypt(ciphertext2))
+	if m.Int64() != -120 {
+		t.Error("wrong multiplication ", m, " is not -120")
+	}
+
+	ciphertext3 := pk.ECMult(pk.EncryptAtLevel(gmp.NewInt(7), EncLevelTwo), big.NewInt(6))
+	if m := privateKey.Decrypt(ciphertext3); n(m) != 42 {
+		t.Error("wrong multiplication at level two ", m, " is not 42")
+	}
+
+	ciphertext4 := pk.ECMult(ciphertext1, big.NewInt(0))
+	if m := privateKey.Decrypt(ciphertext4); m.Sign() != 0 {
+		t.Error("wrong multiplication by zero ", m)
+	}
+}
+
+func TestEDivConstant(t *testing.T) {
+	privateKey, _ := KeyGen(64)
+	pk := privateKey.PublicKey
+
+	// the average of 5 encrypted values
+	values := []int64{3, 14, 15, 92, 6}
+	cts := make([]*Ciphertext, len(values))
+	for i, v := range values {
+		cts[i] = pk.Encrypt(gmp.NewInt(v))
+	}
+	average, err := pk.EDivConstant(pk.Add(cts...), big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := privateKey.Decrypt(average)
+	if n(m) != 26 {
+		t.Error("wrong division ", m, " is not 26")
+	}
+	if err := pk.CheckQuotient(m, big.NewInt(5), EncLevelOne); err != nil {
+		t.Error(err)
+	}
+
+	ct, err := pk.EDivConstant(pk.EncryptAtLevel(gmp.NewInt(42), EncLevelTwo), big.NewInt(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := privateKey.Decrypt(ct); n(m) != 7 {
+		t.Error("wrong division at level two ", m, " is not 7")
+	}
+
+	// 43 is not a multiple of 6
+	ct, err = pk.EDivConstant(pk.Encrypt(gmp.NewInt(43)), big.NewInt(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.CheckQuotient(privateKey.Decrypt(ct), big.NewInt(6), EncLevelOne); !errors.Is(err, ErrInexactDivision) {
+		t.Error("expected ErrInexactDivision, got ", err)
+	}
+
+	for _, k := range []*big.Int{big.NewInt(0), big.NewInt(-2), ToBigInt(pk.N)} {
+		if _, err := pk.EDivConstant(cts[0], k); err == nil {
+			t.Error("expected an error for the divisor ", k)
+		}
+	}
+}
+
+func TestRerandomize(t *testing.T) {
+	privateKey, _ := KeyGen(64)
+	pk := privateKey.PublicKey
+
+	for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+		ciphertext1 := pk.EncryptAtLevel(gmp.NewInt(33), level)
+		ciphertext2, err := pk.Rerandomize(ciphertext1, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ciphertext2.C.Cmp(ciphertext1.C) == 0 || ciphertext2.Level != level {
+			t.Error("ciphertext was not rerandomized at level ", level)
+		}
+		if m := privateKey.Decrypt(ciphertext2); n(m) != 33 {
+			t.Error("wrong decryption ", m, " is not 33")
+		}
+	}
+
+	if _, err := pk.Rerandomize(pk.Encrypt(gmp.NewInt(1)), bytes.NewReader(nil)); err == nil {
+		t.Error("expected error for exhausted randomness")
+	}
+}
+
 func TestDoubleEncryptAdd(t *testing.T) {
 
 	sk, pk := KeyGen(64)
@@ -138,7 +306,7 @@ func TestExtractRandomnessWithRegularEncryption(t *testing.T) {
 		rand := gmp.NewInt(int64(i * i))
 
 		ciphertextLevelOne := pk.EncryptWithRAtLevel(value, rand, EncLevelOne)
-		got := sk.ExtractRandonness(ciphertextLevelOne)
+		got := sk.ExtractRandomness(ciphertextLevelOne)
 		expected := rand
 
 		if !reflect.DeepEqual(ToBigInt(got), ToBigInt(expected)) {
@@ -153,7 +321,7 @@ func TestExtractRandomnessWithRegularEncryption(t *testing.T) {
 		rand := gmp.NewInt(int64(i * i))
 
 		ciphertextLevelTwo := pk.EncryptWithRAtLevel(value, rand, EncLevelTwo)
-		got := sk.ExtractRandonness(ciphertextLevelTwo)
+		got := sk.ExtractRandomness(ciphertextLevelTwo)
 		expected := rand
 
 		if !reflect.DeepEqual(ToBigInt(got), ToBigInt(expected)) {
@@ -208,3 +376,41 @@ func BenchmarkExpGmpInt(b *testing.B) {
 		s.Exp(s, s, pk.GetN2())
 	}
 }
+
+func TestExtractNonce(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+		r, err := GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := pk.EncryptWithRAtLevel(gmp.NewInt(42), r, level)
+
+		nonce, err := sk.ExtractNonce(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nonce.Cmp(r) != 0 {
+			t.Errorf("level %d: extracted nonce %v, expected %v", level, nonce, r)
+		}
+
+		// the nonce proves the plaintext without the secret key
+		if pk.EncryptWithRAtLevel(gmp.NewInt(42), nonce, level).C.Cmp(ct.C) != 0 {
+			t.Errorf("level %d: re-encryption with the nonce differs", level)
+		}
+		if sk.ExtractRandonness(ct).Cmp(nonce) != 0 {
+			t.Errorf("level %d: deprecated method returned another nonce", level)
+		}
+	}
+
+	if _, err := sk.ExtractNonce(&Ciphertext{C: pk.N, Level: EncLevelOne}); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext for a non-unit, got ", err)
+	}
+	if _, err := sk.ExtractNonce(&Ciphertext{Level: EncLevelOne}); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext for a missing ciphertext, got ", err)
+	}
+	if _, err := sk.ExtractNonce(pk.AltEncryptAtLevel(gmp.NewInt(1), EncLevelOne)); err == nil {
+		t.Error("expected an error for an alternative encryption")
+	}
+}
@@ -0,0 +1,156 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Packer packs several bounded non-negative integers into one plaintext by
+// bit shifting: slot i occupies bits [i*w, (i+1)*w) where the slot width
+// w = SlotBits + GuardBits. Values have at most SlotBits bits and the guard
+// bits absorb the growth of homomorphic additions, so a single encryption,
+// addition or (threshold) decryption processes all slots at once.
+//
+// Every PackedCiphertext carries a public bound on its slot values. Operations
+// refuse to produce a bound that no longer fits into a slot, and decryption
+// reports slots that exceed the bound, which happens if a ciphertext was
+// combined with values outside of the Packer.
+type Packer struct {
+	Key       *PublicKey
+	SlotBits  int
+	GuardBits int
+	Slots     int
+}
+
+// PackedCiphertext is an encryption of packed slots whose values are at most Bound
+type PackedCiphertext struct {
+	Ciphertext *Ciphertext
+	Bound      *gmp.Int
+}
+
+// NewPacker returns a packer with as many slots of SlotBits + GuardBits bits
+// as fit into a plaintext. With g guard bits, 2^g packed ciphertexts of
+// maximal values can be added.
+func (pk *PublicKey) NewPacker(slotBits, guardBits int) (*Packer, error) {
+	if slotBits < 1 || guardBits < 0 {
+		return nil, errors.New("slots must have at least one bit and guard bits must not be negative")
+	}
+
+	// the packed plaintext must stay below N
+	slots := (pk.N.BitLen() - 1) / (slotBits + guardBits)
+	if slots < 1 {
+		return nil, errors.New("slot width exceeds the plaintext space")
+	}
+
+	return &Packer{Key: pk, SlotBits: slotBits, GuardBits: guardBits, Slots: slots}, nil
+}
+
+// SlotWidth returns the number of bits of a slot, including the guard bits
+func (p *Packer) SlotWidth() int {
+	return p.SlotBits + p.GuardBits
+}
+
+// Pack returns the plaintext holding the values, which must each have at
+// most SlotBits bits. Fewer values than slots leave the remaining slots zero.
+func (p *Packer) Pack(values []*gmp.Int) (*gmp.Int, error) {
+	if len(values) > p.Slots {
+		return nil, fmt.Errorf("%d values do not fit into %d slots", len(values), p.Slots)
+	}
+
+	x := new(gmp.Int)
+	for i := len(values) - 1; i >= 0; i-- {
+		v := values[i]
+		if v.Sign() < 0 || v.BitLen() > p.SlotBits {
+			return nil, fmt.Errorf("value of slot %d does not fit into %d bits", i, p.SlotBits)
+		}
+		x.Lsh(x, uint(p.SlotWidth()))
+		x.Add(x, v)
+	}
+
+	return x, nil
+}
+
+// Unpack returns the values of all slots of the plaintext and an error if a
+// slot exceeds the bound or the plaintext does not fit into the slots
+func (p *Packer) Unpack(m *gmp.Int, bound *gmp.Int) ([]*gmp.Int, error) {
+	width := uint(p.SlotWidth())
+	if m.BitLen() > p.Slots*int(width) {
+		return nil, errors.New("plaintext overflows the slots")
+	}
+
+	mask := new(gmp.Int).Sub(new(gmp.Int).Lsh(OneBigInt, width), OneBigInt)
+	rest := new(gmp.Int).Set(m)
+	values := make([]*gmp.Int, p.Slots)
+	for i := range values {
+		values[i] = new(gmp.Int).And(rest, mask)
+		if values[i].Cmp(bound) > 0 {
+			return nil, fmt.Errorf("slot %d exceeds its bound", i)
+		}
+		rest.Rsh(rest, width)
+	}
+
+	return values, nil
+}
+
+// Encrypt packs and encrypts the values
+func (p *Packer) Encrypt(values []*gmp.Int) (*PackedCiphertext, error) {
+	x, err := p.Pack(values)
+	if err != nil {
+		return nil, err
+	}
+
+	bound := new(gmp.Int).Sub(new(gmp.Int).Lsh(OneBigInt, uint(p.SlotBits)), OneBigInt)
+	return &PackedCiphertext{Ciphertext: p.Key.Encrypt(x), Bound: bound}, nil
+}
+
+// Decrypt decrypts and unpacks the ciphertext, e.g., with a *SecretKey or a
+// *ThresholdClient
+func (p *Packer) Decrypt(d Decrypter, pc *PackedCiphertext) ([]*gmp.Int, error) {
+	m, err := d.TryDecrypt(pc.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return p.Unpack(m, pc.Bound)
+}
+
+// Add homomorphically adds packed ciphertexts slot-wise
+func (p *Packer) Add(pcs ...*PackedCiphertext) (*PackedCiphertext, error) {
+	if len(pcs) == 0 {
+		return nil, errors.New("no ciphertexts provided")
+	}
+
+	bound := new(gmp.Int)
+	cts := make([]*Ciphertext, len(pcs))
+	for i, pc := range pcs {
+		bound.Add(bound, pc.Bound)
+		cts[i] = pc.Ciphertext
+	}
+
+	if err := p.checkBound(bound); err != nil {
+		return nil, err
+	}
+	return &PackedCiphertext{Ciphertext: p.Key.EAddMany(cts...), Bound: bound}, nil
+}
+
+// MulConst homomorphically multiplies every slot with the non-negative constant
+func (p *Packer) MulConst(pc *PackedCiphertext, k *gmp.Int) (*PackedCiphertext, error) {
+	if k.Sign() < 0 {
+		return nil, errors.New("constant must not be negative")
+	}
+
+	bound := new(gmp.Int).Mul(pc.Bound, k)
+	if err := p.checkBound(bound); err != nil {
+		return nil, err
+	}
+	return &PackedCiphertext{Ciphertext: p.Key.ConstMult(pc.Ciphertext, k), Bound: bound}, nil
+}
+
+// checkBound returns an error if slot values up to bound would overflow
+func (p *Packer) checkBound(bound *gmp.Int) error {
+	if bound.BitLen() > p.SlotWidth() {
+		return fmt.Errorf("slot values would exceed %d bits", p.SlotWidth())
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestPackerAggregation(t *testing.T) {
+	sk, pk := KeyGen(256)
+
+	packer, err := pk.NewPacker(16, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packer.Slots != 255/20 {
+		t.Fatal("wrong number of slots ", packer.Slots)
+	}
+
+	// 16 = 2^4 parties each contribute a vector of maximal values
+	var pcs []*PackedCiphertext
+	for party := 0; party < 16; party++ {
+		values := make([]*gmp.Int, packer.Slots)
+		for i := range values {
+			values[i] = gmp.NewInt(int64(65535 - party*i))
+		}
+		pc, err := packer.Encrypt(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pcs = append(pcs, pc)
+	}
+
+	sum, err := packer.Add(pcs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := packer.Decrypt(sk, sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if expected := 16*65535 - 120*i; n(v) != expected {
+			t.Error("wrong slot ", i, ": ", v, " is not ", expected)
+		}
+	}
+
+	// a 17th addition could overflow into the next slot
+	if _, err := packer.Add(sum, pcs[0]); err == nil {
+		t.Error("overflowing addition was accepted")
+	}
+
+	doubled, err := packer.MulConst(pcs[1], b(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, _ := packer.Decrypt(sk, doubled); n(values[3]) != 2*(65535-3) {
+		t.Error("wrong product ", values[3])
+	}
+	if _, err := packer.MulConst(sum, b(2)); err == nil {
+		t.Error("overflowing multiplication was accepted")
+	}
+}
+
+func TestPackerDetectsOverflow(t *testing.T) {
+	sk, pk := KeyGen(128)
+	packer, _ := pk.NewPacker(8, 2)
+
+	if _, err := packer.Encrypt([]*gmp.Int{b(256)}); err == nil {
+		t.Error("value wider than a slot was accepted")
+	}
+	if _, err := packer.Pack(make([]*gmp.Int, packer.Slots+1)); err == nil {
+		t.Error("too many values were accepted")
+	}
+
+	// adding an unpacked value behind the packer's back exceeds the bound
+	pc, _ := packer.Encrypt([]*gmp.Int{b(255), b(1)})
+	pc.Ciphertext = pk.Add(pc.Ciphertext, pk.Encrypt(b(1)))
+	if _, err := packer.Decrypt(sk, pc); err == nil {
+		t.Error("slot exceeding its bound was not detected")
+	}
+}
+
+func TestPackerThresholdDecryption(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+	client := NewThresholdClient(tk, []PartialDecrypter{tsks[1], tsks[2]})
+
+	packer, _ := tk.NewPacker(10, 3)
+	pc, _ := packer.Encrypt([]*gmp.Int{b(1), b(1023), b(512)})
+	values, err := packer.Decrypt(client, pc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(values[0]) != 1 || n(values[1]) != 1023 || n(values[2]) != 512 || values[3].Sign() != 0 {
+		t.Error("wrong values ", values)
+	}
+}
@@ -2,10 +2,14 @@ package paillier
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/gob"
 	"errors"
+	"fmt"
+	"io"
 	"math/big"
+	"sync/atomic"
 
 	gmp "github.com/ncw/gmp"
 )
@@ -22,6 +26,21 @@ const (
 	EncLevelTwo
 )
 
+// MaxEncryptionLevel is the largest level accepted when decoding ciphertexts
+// and keys; ciphertexts at this level are 33 times as long as N
+const MaxEncryptionLevel EncryptionLevel = 31
+
+// LevelForS returns the level of the Damgard-Jurik scheme with exponent s >= 1,
+// i.e., with plaintexts in Z_{N^s} and ciphertexts in Z_{N^(s+1)}
+func LevelForS(s int) EncryptionLevel {
+	return EncryptionLevel(s - 1)
+}
+
+// S returns the Damgard-Jurik exponent s of the level
+func (l EncryptionLevel) S() int {
+	return int(l) + 1
+}
+
 // EncryptionMethod specifies which encryption algorithm was used to
 // encrypt the ciphertext
 type EncryptionMethod int
@@ -49,16 +68,26 @@ type PublicKey struct {
 	H *gmp.Int // generator for quadratic residues mod N^2
 	K *gmp.Int // power of two = 2^|bits N / 2| for statistical secuirity
 
-	n2 *gmp.Int // cache value of N^2
-	n3 *gmp.Int // cache value of N^3
-	h1 *gmp.Int // cache for generator of QR mod N^2
-	h2 *gmp.Int // cache for generator of QR mod N^3
+	n2 lazyInt // cache value of N^2
+	n3 lazyInt // cache value of N^3
+	h1 lazyInt // cache for generator of QR mod N^2
+	h2 lazyInt // cache for generator of QR mod N^3
+
+	recovery atomic.Value // cache of the *recoveryTable of the highest level used
+
+	random RandomSource // source of randomness, see SetRandomSource
+
+	tables *encryptionTables // see EnableEncryptionTables
 }
 
 // SecretKey contains the necessary values needed to decrypt a ciphertext
 type SecretKey struct {
 	PublicKey
 	Lambda, Lm, Mu, m *gmp.Int
+
+	mu       lazyInt // cache of Lambda^-1 mod N
+	crt      lazyCRT // cache of the parameters for CRT decryption
+	hardened bool    // see EnableHardening
 }
 
 // Ciphertext contains the encryption of a value
@@ -70,23 +99,33 @@ type Ciphertext struct {
 
 // GetN2 returns N^2 where N is the Paillier modulus
 func (pk *PublicKey) GetN2() *gmp.Int {
-	if pk.n2 != nil {
-		return pk.n2
-	}
-
-	pk.n2 = new(gmp.Int).Mul(pk.N, pk.N)
-	return pk.n2
+	return pk.n2.get(func() *gmp.Int {
+		return new(gmp.Int).Mul(pk.N, pk.N)
+	})
 }
 
 // GetN3 returns N^3 where N is the Paillier modulus
 func (pk *PublicKey) GetN3() *gmp.Int {
-	if pk.n3 != nil {
-		return pk.n3
+	return pk.n3.get(func() *gmp.Int {
+		return new(gmp.Int).Mul(pk.GetN2(), pk.N)
+	})
+}
+
+// lazyInt caches a value that is computed on first use. It is safe for
+// concurrent use: goroutines racing on the first use may each compute the
+// value but all of them return the one that was stored first.
+// The cached value is shared and must not be modified.
+type lazyInt struct {
+	value atomic.Value
+}
+
+func (l *lazyInt) get(compute func() *gmp.Int) *gmp.Int {
+	if v, ok := l.value.Load().(*gmp.Int); ok {
+		return v
 	}
 
-	pk.n3 = new(gmp.Int).Mul(pk.N, pk.N)
-	pk.n3.Mul(pk.n3, pk.N)
-	return pk.n3
+	l.value.CompareAndSwap(nil, compute())
+	return l.value.Load().(*gmp.Int)
 }
 
 // KeyGen generates a new keypair.
@@ -104,13 +143,59 @@ func (pk *PublicKey) GetN3() *gmp.Int {
 //               with Applications to Electronic Voting
 //               Aarhus University, Dept. of Computer Science, BRICSs
 func KeyGen(secparam int) (*SecretKey, *PublicKey) {
+	sk, pk, err := KeyGenWithRandom(secparam, rand.Reader)
+	if err != nil {
+		panic("KeyGen: " + err.Error())
+	}
+
+	return sk, pk
+}
+
+// KeyGenWithRandom generates a new keypair as KeyGen but reads all randomness
+// from random. The keys only depend on the bytes read from random, so a
+// deterministic reader such as NewDeterministicReader yields reproducible keys.
+func KeyGenWithRandom(secparam int, random io.Reader) (*SecretKey, *PublicKey, error) {
+	return KeyGenWithOptions(secparam, &KeyGenOptions{Random: random})
+}
+
+// KeyGenFromSeed generates a new keypair as KeyGen with all randomness drawn
+// from NewDeterministicReader(seed), e.g., for test fixtures and known-answer
+// tests. The same seed and secparam yield the same keys in all versions of
+// the package, which consume the reader as follows. A prime candidate is
+// read as secparam/2 bits in big-endian order with the two most significant
+// bits and the least significant bit set, and candidates are read until one
+// is prime; pairs of primes are drawn until they differ and are both 3 mod 4.
+// H is then the square of a unit drawn as specified by GetRandomNumber.
+// Keys derived from a low-entropy seed are insecure.
+func KeyGenFromSeed(secparam int, seed []byte) (*SecretKey, *PublicKey, error) {
+	return KeyGenWithOptions(secparam, &KeyGenOptions{Random: NewDeterministicReader(seed), Deterministic: true})
+}
+
+// KeyGenWithOptions generates a new keypair as KeyGen with the options opts,
+// which may be nil for the defaults
+func KeyGenWithOptions(secparam int, opts *KeyGenOptions) (*SecretKey, *PublicKey, error) {
+	return KeyGenContext(context.Background(), secparam, opts)
+}
+
+// KeyGenContext is KeyGenWithOptions but aborts the search for the primes
+// when ctx is done, in which case ctx.Err() is returned
+func KeyGenContext(ctx context.Context, secparam int, opts *KeyGenOptions) (*SecretKey, *PublicKey, error) {
+	done := startOperation(OpKeyGen)
+
+	if opts == nil {
+		opts = &KeyGenOptions{}
+	}
+	random := opts.random()
+	progress := newKeyGenProgress(opts.Progress)
 
 	if secparam%2 != 0 {
-		panic("KeyGen: secparam must be divisible by 2")
+		done(false)
+		return nil, nil, errors.New("secparam must be divisible by 2")
 	}
 
 	if secparam < 64 {
-		panic("KeyGen: secparam must be at least 64 bits")
+		done(false)
+		return nil, nil, errors.New("secparam must be at least 64 bits")
 	}
 
 	// generate the prime factors
@@ -119,13 +204,10 @@ func KeyGen(secparam int) (*SecretKey, *PublicKey) {
 	m := new(gmp.Int)
 	for {
 
-		p1, err := rand.Prime(rand.Reader, secparam/2)
+		p1, q1, err := opts.generatePrimes(ctx, secparam/2, random, progress)
 		if err != nil {
-			continue
-		}
-		q1, err := rand.Prime(rand.Reader, secparam/2)
-		if err != nil {
-			continue
+			done(false)
+			return nil, nil, err
 		}
 
 		modTestP := new(big.Int).Mod(p1, big.NewInt(4))
@@ -144,8 +226,6 @@ func KeyGen(secparam int) (*SecretKey, *PublicKey) {
 	}
 
 	n := new(gmp.Int).Mul(p, q)
-	n2 := new(gmp.Int).Mul(n, n)
-	n3 := new(gmp.Int).Mul(n2, n)
 
 	g := new(gmp.Int).Add(n, gmp.NewInt(1)) // generator = n + 1
 	k := new(gmp.Int).Exp(TwoBigInt, gmp.NewInt(int64(secparam/2)), nil)
@@ -155,18 +235,17 @@ func KeyGen(secparam int) (*SecretKey, *PublicKey) {
 	// see "Akternative encryption" section in
 	// https://citeseerx.ist.psu.edu/viewdoc/download?doi=10.1.1.67.9647&rep=rep1&type=pdf
 	// for explanation on how to generate a generator for the group of quadratic residues
-	h, err := GetRandomGeneratorOfTheQuadraticResidue(n, rand.Reader)
+	h, err := GetRandomGeneratorOfTheQuadraticResidue(n, random)
 	if err != nil {
-		panic(err)
+		done(false)
+		return nil, nil, err
 	}
 
 	pk := &PublicKey{
-		N:  n,
-		G:  g,
-		H:  h,
-		K:  k,
-		n2: n2,
-		n3: n3,
+		N: n,
+		G: g,
+		H: h,
+		K: k,
 	}
 
 	sk := &SecretKey{
@@ -175,7 +254,51 @@ func KeyGen(secparam int) (*SecretKey, *PublicKey) {
 		m:         m,
 	}
 
-	return sk, pk
+	done(true)
+	logEvent(EventKeyGenerated, pk, 0, nil)
+
+	return sk, pk, nil
+}
+
+// generatePrime returns a random prime of exactly bits bits with the two most
+// significant bits set. Unlike crypto/rand.Prime, which deliberately reads a
+// random number of extra bytes, the prime only depends on the bytes read from
+// random. It returns ctx.Err() once ctx is done. The search is reported to
+// progress.
+func generatePrime(ctx context.Context, bits int, random io.Reader, progress *keyGenProgress) (*big.Int, error) {
+	b := uint(bits % 8)
+	if b == 0 {
+		b = 8
+	}
+
+	buf := make([]byte, (bits+7)/8)
+	p := new(big.Int)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+
+		buf[0] &= uint8(int(1<<b) - 1)
+		if b >= 2 {
+			buf[0] |= 3 << (b - 2)
+		} else {
+			buf[0] |= 1
+			if len(buf) > 1 {
+				buf[1] |= 0x80
+			}
+		}
+		buf[len(buf)-1] |= 1
+
+		p.SetBytes(buf)
+		progress.candidatesTested(1)
+		if p.ProbablyPrime(20) {
+			progress.primeFound()
+			return p, nil
+		}
+	}
 }
 
 // EncryptWithR encrypts a plaintext into a cypher one with random `r` specified
@@ -188,7 +311,8 @@ func (pk *PublicKey) EncryptWithR(m *gmp.Int, r *gmp.Int) *Ciphertext {
 
 // Encrypt a plaintext. The plain text must be smaller that
 // N and bigger than or equal zero.
-// Returns an error if an error has be returned by io.Reader.
+// Reading the randomness is retried until it succeeds; use EncryptWithRandom
+// to get an error instead and to check the plaintext.
 func (pk *PublicKey) Encrypt(m *gmp.Int) *Ciphertext {
 	return pk.EncryptAtLevel(m, DefaultEncryptionLevel)
 }
@@ -204,14 +328,21 @@ func (pk *PublicKey) NestedEncrypt(m *gmp.Int) *Ciphertext {
 
 // EncryptWithRAtLevel encrypts a plaintext as EncryptWithR but in the space N^s
 func (pk *PublicKey) EncryptWithRAtLevel(m *gmp.Int, r *gmp.Int, level EncryptionLevel) *Ciphertext {
+	defer startOperation(OpEncrypt)(true)
 
 	_, ns, ns1 := pk.getModuliForLevel(level)
+	rn := new(gmp.Int).Exp(r, ns, ns1)
+	return pk.encryptWithRN(m, rn, level)
+}
+
+// encryptWithRN encrypts a plaintext with the randomness term rn = r^(N^s)
+func (pk *PublicKey) encryptWithRN(m, rn *gmp.Int, level EncryptionLevel) *Ciphertext {
+	_, _, ns1 := pk.getModuliForLevel(level)
 
 	// g is _always_ equal n+1
 	// Threshold encryption is safe only for g=n+1 choice.
 	// See [DJN 10], section 5.1
-	gm := new(gmp.Int).Exp(pk.G, m, ns1)
-	rn := new(gmp.Int).Exp(r, ns, ns1)
+	gm := pk.gExp(m, level)
 
 	c := new(gmp.Int).Mod(new(gmp.Int).Mul(gm, rn), ns1)
 	return &Ciphertext{c, level, RegularEncryption}
@@ -219,6 +350,7 @@ func (pk *PublicKey) EncryptWithRAtLevel(m *gmp.Int, r *gmp.Int, level Encryptio
 
 // AltEncryptWithRAtLevel encrypts a plaintext as EncryptWithR but in the space N^s
 func (pk *PublicKey) AltEncryptWithRAtLevel(m *gmp.Int, r *gmp.Int, level EncryptionLevel) *Ciphertext {
+	defer startOperation(OpEncrypt)(true)
 
 	_, _, ns1 := pk.getModuliForLevel(level)
 
@@ -230,8 +362,13 @@ func (pk *PublicKey) AltEncryptWithRAtLevel(m *gmp.Int, r *gmp.Int, level Encryp
 	// g is _always_ equal n+1
 	// Threshold encryption is safe only for g=n+1 choice.
 	// See [DJN 10], section 5.1
-	gm := new(gmp.Int).Exp(pk.G, m, ns1)
-	hr := new(gmp.Int).Exp(h, r, ns1)
+	gm := pk.gExp(m, level)
+	var hr *gmp.Int
+	if table := pk.encryptionTable(h, level, true); table != nil {
+		hr = table.exp(r)
+	} else {
+		hr = new(gmp.Int).Exp(h, r, ns1)
+	}
 
 	c := new(gmp.Int).Mod(new(gmp.Int).Mul(gm, hr), ns1)
 	return &Ciphertext{c, level, AlternativeEncryption}
@@ -246,7 +383,7 @@ func (pk *PublicKey) AltEncryptAtLevel(m *gmp.Int, level EncryptionLevel) *Ciphe
 	var r *gmp.Int
 	var err error
 	for {
-		r, err = GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+		r, err = GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
 		if err == nil {
 			break
 		}
@@ -260,7 +397,7 @@ func (pk *PublicKey) EncryptAtLevel(m *gmp.Int, level EncryptionLevel) *Cipherte
 	var r *gmp.Int
 	var err error
 	for {
-		r, err = GetRandomNumberInMultiplicativeGroup(pk.N, rand.Reader)
+		r, err = GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
 		if err == nil {
 			break
 		}
@@ -268,6 +405,84 @@ func (pk *PublicKey) EncryptAtLevel(m *gmp.Int, level EncryptionLevel) *Cipherte
 	return pk.EncryptWithRAtLevel(m, r, level)
 }
 
+// EncryptWithRandom encrypts a plaintext as Encrypt but reads the randomness
+// from random, or from the random source of the key if random is nil, and
+// returns an error instead of retrying if reading fails. Plaintexts that are
+// negative or not smaller than N are rejected, the latter with an error
+// wrapping ErrMessageTooLarge.
+func (pk *PublicKey) EncryptWithRandom(m *gmp.Int, random io.Reader) (*Ciphertext, error) {
+	return pk.EncryptAtLevelWithRandom(m, DefaultEncryptionLevel, random)
+}
+
+// EncryptAtLevelWithRandom is EncryptWithRandom at the given level, i.e., for
+// plaintexts in Z_{N^s}
+func (pk *PublicKey) EncryptAtLevelWithRandom(m *gmp.Int, level EncryptionLevel, random io.Reader) (*Ciphertext, error) {
+	ct, _, err := pk.EncryptReturningNonceAtLevel(m, level, random)
+	return ct, err
+}
+
+// EncryptReturningNonce encrypts a plaintext as EncryptWithRandom and also
+// returns the nonce r in Z*_N of the ciphertext, e.g., to prove knowledge of
+// the plaintext or to open the ciphertext like a commitment later. The nonce
+// must be kept as secret as the plaintext.
+func (pk *PublicKey) EncryptReturningNonce(m *gmp.Int, random io.Reader) (*Ciphertext, *gmp.Int, error) {
+	return pk.EncryptReturningNonceAtLevel(m, DefaultEncryptionLevel, random)
+}
+
+// EncryptReturningNonceAtLevel is EncryptReturningNonce at the given level
+func (pk *PublicKey) EncryptReturningNonceAtLevel(m *gmp.Int, level EncryptionLevel, random io.Reader) (*Ciphertext, *gmp.Int, error) {
+	if err := pk.checkPlaintext(m, level); err != nil {
+		return nil, nil, err
+	}
+	if random == nil {
+		random = pk.RandomSource()
+	}
+
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk.EncryptWithRAtLevel(m, r, level), r, nil
+}
+
+// EncryptWithNonce encrypts a plaintext with the nonce r as EncryptWithR but
+// returns an error if the plaintext is not in Z_N or r is not in Z*_N, e.g.,
+// to reproduce test vectors or ciphertexts whose nonce was revealed.
+// The ciphertext is deterministic, so r must be random and secret for it to
+// hide the plaintext.
+func (pk *PublicKey) EncryptWithNonce(m, r *gmp.Int) (*Ciphertext, error) {
+	return pk.EncryptWithNonceAtLevel(m, r, DefaultEncryptionLevel)
+}
+
+// EncryptWithNonceAtLevel is EncryptWithNonce at the given level
+func (pk *PublicKey) EncryptWithNonceAtLevel(m, r *gmp.Int, level EncryptionLevel) (*Ciphertext, error) {
+	if err := pk.checkPlaintext(m, level); err != nil {
+		return nil, err
+	}
+	if r == nil || r.Sign() <= 0 || r.Cmp(pk.N) >= 0 || new(gmp.Int).GCD(nil, nil, r, pk.N).Cmp(OneBigInt) != 0 {
+		return nil, errors.New("nonce is not in Z*_N")
+	}
+	return pk.EncryptWithRAtLevel(m, r, level), nil
+}
+
+// checkPlaintext returns an error if the level is unknown or m is not in
+// Z_{N^s}
+func (pk *PublicKey) checkPlaintext(m *gmp.Int, level EncryptionLevel) error {
+	if level < EncLevelOne || level > MaxEncryptionLevel {
+		return fmt.Errorf("unknown encryption level %d", level)
+	}
+	if m == nil {
+		return errors.New("missing plaintext")
+	}
+	if m.Sign() < 0 {
+		return errors.New("plaintext is negative")
+	}
+	if s, ns, _ := pk.getModuliForLevel(level); m.Cmp(ns) >= 0 {
+		return fmt.Errorf("%w: plaintext is not smaller than N^%d", ErrMessageTooLarge, s)
+	}
+	return nil
+}
+
 // EncryptZero returns a fresh encryption of 0
 func (pk *PublicKey) EncryptZero() *Ciphertext {
 	return pk.Encrypt(gmp.NewInt(0))
@@ -290,47 +505,88 @@ func (pk *PublicKey) EncryptOneAtLevel(level EncryptionLevel) *Ciphertext {
 
 // Decrypt a ciphertext to plaintext message.
 func (sk *SecretKey) Decrypt(ct *Ciphertext) *gmp.Int {
+	defer startOperation(OpDecrypt)(true)
+	logEvent(EventDecryptionRequested, &sk.PublicKey, 0, nil)
+
+	if ct.Level == EncLevelOne {
+		if crt := sk.crtParameters(); crt != nil {
+			return sk.decryptCRT(ct.C, crt)
+		}
+	}
 
 	s, ns, ns1 := sk.getModuliForLevel(ct.Level)
 
-	tmp := new(gmp.Int).Exp(ct.C, sk.Lambda, ns1) // c^lambda mod N^s+1
-	ml := sk.recoveryAlgorithm(tmp, s)            // recoveryAlgorithm outputs m*lambda
-	mu := new(gmp.Int).ModInverse(sk.Lambda, ns)  // lambda^-1
+	// N^s*Lambda is a multiple of the order of Z*_{N^(s+1)}
+	exp := sk.blindExponent(sk.Lambda, func() *gmp.Int { return new(gmp.Int).Mul(ns, sk.Lambda) })
+	tmp := new(gmp.Int).Exp(ct.C, exp, ns1) // c^lambda mod N^s+1
+	ml := sk.recoveryAlgorithm(tmp, s)      // recoveryAlgorithm outputs m*lambda
+
+	var mu *gmp.Int // lambda^-1
+	if ct.Level == EncLevelOne {
+		mu = sk.mu.get(func() *gmp.Int {
+			return new(gmp.Int).ModInverse(sk.Lambda, sk.N)
+		})
+	} else {
+		mu = new(gmp.Int).ModInverse(sk.Lambda, ns)
+	}
 
 	m := new(gmp.Int).Mod(new(gmp.Int).Mul(ml, mu), ns)
 
 	return m
 }
 
+// TryDecrypt decrypts a ciphertext as Decrypt, but returns an error wrapping
+// ErrInvalidCiphertext instead of panicking if the ciphertext is missing or
+// not in Z_{N^(s+1)}. It implements the Decrypter interface.
+func (sk *SecretKey) TryDecrypt(ct *Ciphertext) (*gmp.Int, error) {
+	if err := sk.checkCiphertext(ct); err != nil {
+		return nil, err
+	}
+	return sk.Decrypt(ct), nil
+}
+
+// checkCiphertext returns an error wrapping ErrInvalidCiphertext if the
+// ciphertext is missing or not in Z_{N^(s+1)}
+func (pk *PublicKey) checkCiphertext(ct *Ciphertext) error {
+	if ct == nil || ct.C == nil || ct.Level < EncLevelOne {
+		return ErrInvalidCiphertext
+	}
+	_, _, ns1 := pk.getModuliForLevel(ct.Level)
+	if ct.C.Sign() <= 0 || ct.C.Cmp(ns1) >= 0 {
+		return fmt.Errorf("%w: ciphertext is out of range", ErrInvalidCiphertext)
+	}
+	return nil
+}
+
 // recovery algorithm used as a subroutine in the decryption alg of the generalized
 // paillier scheme.
 // See [J03] Proof of Theorem 2.1 for algorithm descryption
-func (sk *SecretKey) recoveryAlgorithm(a *gmp.Int, s int) *gmp.Int {
+func (pk *PublicKey) recoveryAlgorithm(a *gmp.Int, s int) *gmp.Int {
 
 	i := gmp.NewInt(0)
 
+	constants := pk.recoveryConstants(s)
 	for j := 1; j <= s; j++ {
-		nj := new(gmp.Int).Exp(sk.N, gmp.NewInt(int64(j)), nil)    // n^j+1
-		nj1 := new(gmp.Int).Exp(sk.N, gmp.NewInt(int64(j+1)), nil) // n^j+1
+		nj := constants.powers[j]    // n^j
+		nj1 := constants.powers[j+1] // n^j+1
 
 		amod := new(gmp.Int).Mod(a, nj1)
 
-		t1 := L(amod, sk.N)
+		t1 := L(amod, pk.N)
 		t2 := new(gmp.Int).SetBytes(i.Bytes())
 
 		for k := 2; k <= j; k++ {
-			nk := new(gmp.Int).Exp(sk.N, gmp.NewInt(int64(k-1)), nil) // n^k-1
-			i.Sub(i, OneBigInt)                                       // i = i-1
+			nk := constants.powers[k-1] // n^k-1
+			i.Sub(i, OneBigInt)         // i = i-1
 
 			t2.Mul(t2, i).Mod(t2, nj) // t2 = t2*i mod n^j
 
-			// compute t2 = t1 - (t2*n^k-1) / k! mod n^j
-			t2.Mul(t2, nk)
-			kFac := Factorial(k)
-			kFac.ModInverse(kFac, nj)
-			t2.Mul(t2, kFac) // t2 = (t2*n^k-1) / k!
-			t2.Sub(t1, t2)   // t2 = t1 - (t2*n^k-1) / k!
-			t1.Mod(t2, nj)   // t1 =  t1 - (t2*n^k-1) / k! mod nj
+			// compute t1 = t1 - (t2*n^k-1) / k! mod n^j; t2 is kept for the
+			// next k
+			tmp := new(gmp.Int).Mul(t2, nk)
+			tmp.Mul(tmp, constants.invFactorials[j][k]) // tmp = (t2*n^k-1) / k!
+			tmp.Sub(t1, tmp)                            // tmp = t1 - (t2*n^k-1) / k!
+			t1.Mod(tmp, nj)                             // t1 =  t1 - (t2*n^k-1) / k! mod nj
 		}
 
 		i = t1
@@ -363,12 +619,7 @@ func (sk *SecretKey) DecryptNestedCiphertextLayer(ct *Ciphertext) *Ciphertext {
 	}
 
 	ctValue := sk.Decrypt(ct)
-	if ct.Level == EncLevelTwo {
-		return &Ciphertext{C: ctValue, Level: EncLevelOne, EncMethod: MixedEncryption}
-	}
-
-	// TODO: support decrypting arbitrary layers
-	panic("not implemented")
+	return &Ciphertext{C: ctValue, Level: ct.Level - 1, EncMethod: MixedEncryption}
 }
 
 // NewCiphertextFromBytes initializes a ciphertext from a byte encoding.
@@ -400,37 +651,60 @@ func (ct *Ciphertext) Bytes() []byte {
 	return buf.Bytes()
 }
 
+// gExp returns g^m mod N^(s+1). For the standard generator g = N+1, which is
+// used if G is nil, g^m = 1 + mN mod N^2 at level one and no exponentiation
+// is needed.
+func (pk *PublicKey) gExp(m *gmp.Int, level EncryptionLevel) *gmp.Int {
+	_, _, ns1 := pk.getModuliForLevel(level)
+
+	standard := pk.G == nil || pk.G.Cmp(new(gmp.Int).Add(pk.N, OneBigInt)) == 0
+	if standard && level == EncLevelOne {
+		// N+1 has order N, so m can be reduced mod N
+		gm := new(gmp.Int).Mod(m, pk.N)
+		gm.Mul(gm, pk.N)
+		return gm.Add(gm, OneBigInt)
+	}
+
+	g := pk.G
+	if g == nil {
+		g = new(gmp.Int).Add(pk.N, OneBigInt)
+	}
+	if table := pk.encryptionTable(g, level, false); table != nil {
+		return table.exp(m)
+	}
+	return new(gmp.Int).Exp(g, m, ns1)
+}
+
+// getModuliForLevel returns s, N^s and N^(s+1) for the level. The moduli are
+// cached and must not be modified.
 func (pk *PublicKey) getModuliForLevel(level EncryptionLevel) (int, *gmp.Int, *gmp.Int) {
-	s := 1
-	modPrevLevel := pk.N
-	mod := pk.GetN2()
-	if level == EncLevelTwo {
-		s = 2
-		modPrevLevel = pk.GetN2()
-		mod = pk.GetN3()
+	switch level {
+	case EncLevelOne:
+		return 1, pk.N, pk.GetN2()
+	case EncLevelTwo:
+		return 2, pk.GetN2(), pk.GetN3()
 	}
 
-	return s, modPrevLevel, mod
+	s := level.S()
+	powers := pk.recoveryConstants(s).powers
+	return s, powers[s], powers[s+1]
 }
 
+// getGeneratorOfQuadraticResiduesForLevel returns (N^s - H)^(N^s) mod N^(s+1)
 func (pk *PublicKey) getGeneratorOfQuadraticResiduesForLevel(level EncryptionLevel) *gmp.Int {
-
-	if level == EncLevelOne {
-		if pk.h1 == nil {
-			h1 := new(gmp.Int).Sub(pk.N, pk.H)
-			h1.Exp(h1, pk.N, pk.GetN2())
-			pk.h1 = h1
-		}
-		return pk.h1
+	compute := func() *gmp.Int {
+		_, ns, ns1 := pk.getModuliForLevel(level)
+		h := new(gmp.Int).Sub(ns, pk.H)
+		return h.Exp(h, ns, ns1)
 	}
 
-	if pk.h2 == nil {
-		h2 := new(gmp.Int).Sub(pk.GetN2(), pk.H)
-		h2.Exp(h2, pk.GetN2(), pk.GetN3())
-		pk.h2 = h2
+	switch level {
+	case EncLevelOne:
+		return pk.h1.get(compute)
+	case EncLevelTwo:
+		return pk.h2.get(compute)
 	}
-
-	return pk.h2
+	return compute()
 }
 
 // L is the function is paillier defined as (u-1)/n
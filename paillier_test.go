@@ -1,8 +1,11 @@
 package paillier
 
 import (
+	"crypto/rand"
+	"errors"
 	"math/big"
 	"reflect"
+	"sync"
 	"testing"
 
 	gmp "github.com/ncw/gmp"
@@ -89,6 +92,70 @@ func TestEncryptDecryptLevel2(t *testing.T) {
 	}
 }
 
+func TestDamgardJurikLevels(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	for s := 1; s <= 5; s++ {
+		level := LevelForS(s)
+		if level.S() != s {
+			t.Error("wrong exponent ", level.S())
+		}
+
+		// the largest plaintext of the level
+		_, ns, ns1 := pk.getModuliForLevel(level)
+		value := new(gmp.Int).Sub(ns, gmp.NewInt(1))
+		ct := pk.EncryptAtLevel(value, level)
+		if ct.C.Cmp(ns1) >= 0 {
+			t.Error("ciphertext exceeds N^(s+1)")
+		}
+		if m := sk.Decrypt(ct); m.Cmp(value) != 0 {
+			t.Error("wrong decryption at s=", s)
+		}
+
+		sum := pk.Add(pk.EncryptAtLevel(b(5), level), pk.AltEncryptAtLevel(b(7), level))
+		sum = pk.EAddConstant(sum, big.NewInt(30))
+		if m := sk.Decrypt(sum); n(m) != 42 {
+			t.Error("wrong sum at s=", s, ": ", m)
+		}
+	}
+}
+
+func TestDecryptNestedCiphertextLevel3(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	ct := pk.EncryptAtLevel(b(9), EncLevelOne)
+	ct = pk.EncryptAtLevel(ct.C, EncLevelTwo)
+	ct = pk.EncryptAtLevel(ct.C, LevelForS(3))
+
+	ct = sk.DecryptNestedCiphertextLayer(ct)
+	if ct.Level != EncLevelTwo {
+		t.Fatal("wrong level ", ct.Level)
+	}
+	if m := sk.NestedDecrypt(ct); n(m) != 9 {
+		t.Error("wrong decryption ", m)
+	}
+}
+
+func TestGeneratorFastPath(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	for _, m := range []*gmp.Int{b(0), b(1), minusOne(pk.N), new(gmp.Int).Add(pk.GetN2(), b(3))} {
+		expected := new(gmp.Int).Exp(pk.G, m, pk.GetN2())
+		if pk.gExp(m, EncLevelOne).Cmp(expected) != 0 {
+			t.Error("wrong power of the standard generator for ", m)
+		}
+	}
+
+	// a key without G uses the standard generator
+	noG := &SecretKey{PublicKey: PublicKey{N: pk.N, H: pk.H, K: pk.K}, Lambda: sk.Lambda}
+	if m := noG.Decrypt(noG.Encrypt(b(17))); n(m) != 17 {
+		t.Error("wrong decryption without G ", m)
+	}
+	if m := noG.Decrypt(noG.EncryptAtLevel(b(17), EncLevelTwo)); n(m) != 17 {
+		t.Error("wrong decryption at level two without G ", m)
+	}
+}
+
 func TestDoubleEncryptDecrypt(t *testing.T) {
 
 	for i := 0; i < 1000; i++ {
@@ -179,3 +246,114 @@ func Decrypt(c *Ciphertext, sk *SecretKey) *gmp.Int {
 func Encrypt(m *gmp.Int, pk *PublicKey) *Ciphertext {
 	return pk.Encrypt(m)
 }
+
+func TestConcurrentFirstUse(t *testing.T) {
+	_, pk := KeyGen(128)
+	fresh := &PublicKey{N: pk.N, G: pk.G, H: pk.H, K: pk.K}
+
+	var wg sync.WaitGroup
+	results := make([]*gmp.Int, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fresh.AltEncryptAtLevel(b(i), EncLevelTwo)
+			results[i] = fresh.GetN3()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, n3 := range results {
+		if n3 != results[0] {
+			t.Fatal("goroutines observed different cached values")
+		}
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("entropy source failed")
+}
+
+func TestEncryptWithRandom(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	ct, err := pk.EncryptWithRandom(gmp.NewInt(77), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk.Decrypt(ct).Cmp(gmp.NewInt(77)) != 0 {
+		t.Error("wrong decryption")
+	}
+	if ct, err = pk.EncryptWithRandom(gmp.NewInt(78), nil); err != nil || sk.Decrypt(ct).Cmp(gmp.NewInt(78)) != 0 {
+		t.Error("encryption with the random source of the key failed: ", err)
+	}
+
+	if _, err := pk.EncryptWithRandom(gmp.NewInt(1), failingReader{}); err == nil || err.Error() != "entropy source failed" {
+		t.Error("expected the error of the reader, got ", err)
+	}
+	if _, err := pk.EncryptWithRandom(pk.N, rand.Reader); !errors.Is(err, ErrMessageTooLarge) {
+		t.Error("expected ErrMessageTooLarge, got ", err)
+	}
+	if _, err := pk.EncryptWithRandom(gmp.NewInt(-1), rand.Reader); err == nil {
+		t.Error("expected an error for a negative plaintext")
+	}
+	if _, err := pk.EncryptWithRandom(nil, rand.Reader); err == nil {
+		t.Error("expected an error for a missing plaintext")
+	}
+
+	// N fits into the plaintext space of level two
+	ct, err = pk.EncryptAtLevelWithRandom(pk.N, EncLevelTwo, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk.Decrypt(ct).Cmp(pk.N) != 0 {
+		t.Error("wrong decryption at level two")
+	}
+	if _, err := pk.EncryptAtLevelWithRandom(gmp.NewInt(1), -1, rand.Reader); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestEncryptWithNonce(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	ct, r, err := pk.EncryptReturningNonce(gmp.NewInt(9), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk.Decrypt(ct).Cmp(gmp.NewInt(9)) != 0 {
+		t.Error("wrong decryption")
+	}
+	if nonce, err := sk.ExtractNonce(ct); err != nil || nonce.Cmp(r) != 0 {
+		t.Error("returned nonce is not the nonce of the ciphertext: ", err)
+	}
+
+	// the ciphertext is reproduced with the returned nonce
+	again, err := pk.EncryptWithNonce(gmp.NewInt(9), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.C.Cmp(ct.C) != 0 {
+		t.Error("encryption with the nonce differs")
+	}
+
+	ct, r, err = pk.EncryptReturningNonceAtLevel(gmp.NewInt(10), EncLevelTwo, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := pk.EncryptWithNonceAtLevel(gmp.NewInt(10), r, EncLevelTwo); err != nil || again.C.Cmp(ct.C) != 0 {
+		t.Error("level two encryption with the nonce differs: ", err)
+	}
+
+	p, _ := sk.factorWithPhi()
+	for _, bad := range []*gmp.Int{nil, gmp.NewInt(0), pk.N, p} {
+		if _, err := pk.EncryptWithNonce(gmp.NewInt(1), bad); err == nil {
+			t.Error("expected an error for the nonce ", bad)
+		}
+	}
+	if _, err := pk.EncryptWithNonce(pk.N, OneBigInt); !errors.Is(err, ErrMessageTooLarge) {
+		t.Error("expected ErrMessageTooLarge, got ", err)
+	}
+}
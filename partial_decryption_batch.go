@@ -0,0 +1,158 @@
+package paillier
+
+import (
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// batchVerificationBits is the bit length of the random weights of
+// VerifyBatch; a batch with an invalid proof passes the combined check with
+// probability at most 2^-batchVerificationBits
+const batchVerificationBits = 64
+
+// VerifyBatch verifies the proofs of the partial decryptions as
+// VerifyErrWithKey, but checks all proofs that carry their commitments A and
+// B with a random linear combination [BGR 98]: with random weights w_i,
+//
+//	prod_c (c^4)^(sum_i w_i Z_i) = prod_i A_i^w_i (c_i^2)^(w_i E_i)
+//	V^(sum_i w_i Z_i)            = prod_i B_i^w_i v_i^(w_i E_i)
+//
+// where the first product is over the distinct ciphertexts. The exponents
+// Z_i are longer than N^2, while w_i and E_i have 64 and 256 bits, so k
+// proofs for the same ciphertext cost about two long exponentiations instead
+// of 2k. Both sides are squared, like c and c_i in the proofs, so that the
+// check does not depend on elements of order two.
+//
+// If the combined check fails, the proofs are verified one by one and the
+// error of the first invalid proof is returned, so a misbehaving server is
+// still identified.
+//
+//	[BGR 98]: Mihir Bellare, Juan A. Garay, Tal Rabin, (1998)
+//	          Fast Batch Verification for Modular Exponentiation and
+//	          Digital Signatures
+func (tk *ThresholdPublicKey) VerifyBatch(pds []*PartialDecryptionZKP) error {
+	n2 := tk.GetN2()
+	bound := new(gmp.Int).Lsh(OneBigInt, batchVerificationBits)
+
+	var batched []*PartialDecryptionZKP
+	for _, pd := range pds {
+		if pd == nil {
+			return fmt.Errorf("%w: missing partial decryption", ErrMalformedProof)
+		}
+		if err := pd.checkKey(tk); err != nil {
+			return err
+		}
+		if pd.A == nil || pd.B == nil {
+			if err := pd.VerifyErr(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := pd.checkCommitments(); err != nil {
+			return &InvalidProofError{ID: pd.ID, Err: err}
+		}
+		batched = append(batched, pd)
+	}
+	if len(batched) == 0 {
+		return nil
+	}
+
+	done := startOperation(OpVerifyProof)
+
+	// exponents of c^4 per ciphertext, of V and of the v_i per server
+	cExponents := make(map[string]*gmp.Int)
+	cBases := make(map[string]*gmp.Int)
+	vExponent := new(gmp.Int)
+	viExponents := make(map[int]*gmp.Int)
+
+	rhs1 := gmp.NewInt(1)
+	rhs2 := gmp.NewInt(1)
+	for _, pd := range batched {
+		w, err := GetRandomNumber(bound, tk.RandomSource())
+		if err != nil {
+			done(false)
+			return err
+		}
+		w.Add(w, OneBigInt)
+
+		wz := new(gmp.Int).Mul(w, pd.Z)
+		key := string(pd.C.Bytes())
+		if cExponents[key] == nil {
+			cExponents[key] = new(gmp.Int)
+			cBases[key] = pd.C
+		}
+		cExponents[key].Add(cExponents[key], wz)
+		vExponent.Add(vExponent, wz)
+
+		we := new(gmp.Int).Mul(w, pd.E)
+		if viExponents[pd.ID] == nil {
+			viExponents[pd.ID] = new(gmp.Int)
+		}
+		viExponents[pd.ID].Add(viExponents[pd.ID], we)
+
+		ci2 := new(gmp.Int).Mul(pd.Decryption, pd.Decryption)
+		rhs1.Mul(rhs1, new(gmp.Int).Exp(ci2, we, n2))
+		rhs1.Mul(rhs1, new(gmp.Int).Exp(pd.A, w, n2))
+		rhs1.Mod(rhs1, n2)
+		rhs2.Mul(rhs2, new(gmp.Int).Exp(pd.B, w, n2))
+		rhs2.Mod(rhs2, n2)
+	}
+
+	lhs1 := gmp.NewInt(1)
+	for key, e := range cExponents {
+		c4 := new(gmp.Int).Exp(cBases[key], FourBigInt, n2)
+		lhs1.Mul(lhs1, c4.Exp(c4, e, n2))
+		lhs1.Mod(lhs1, n2)
+	}
+	// the exponents are longer than those the fixed-base tables are built for
+	lhs2 := new(gmp.Int).Exp(tk.VerificationKey, vExponent, n2)
+	for id, e := range viExponents {
+		rhs2.Mul(rhs2, new(gmp.Int).Exp(tk.VerificationKeys[id-1], e, n2))
+		rhs2.Mod(rhs2, n2)
+	}
+
+	ok := true
+	for _, side := range [][2]*gmp.Int{{lhs1, rhs1}, {lhs2, rhs2}} {
+		l := new(gmp.Int).Exp(side[0], TwoBigInt, n2)
+		r := new(gmp.Int).Exp(side[1], TwoBigInt, n2)
+		ok = ok && l.Cmp(r) == 0
+	}
+	done(ok)
+	if ok {
+		return nil
+	}
+
+	for _, pd := range batched {
+		if err := pd.VerifyErr(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checks the values of the proof and that the challenge is the hash of the
+// commitments A and B
+func (pd *PartialDecryptionZKP) checkCommitments() error {
+	if err := pd.checkValues(); err != nil {
+		return err
+	}
+
+	n2 := pd.Key.GetN2()
+	for _, x := range []*gmp.Int{pd.A, pd.B} {
+		if x.Sign() <= 0 || x.Cmp(n2) >= 0 || new(gmp.Int).GCD(nil, nil, x, pd.Key.N).Cmp(OneBigInt) != 0 {
+			return fmt.Errorf("%w: commitment is not a unit mod N^2", ErrMalformedProof)
+		}
+	}
+
+	c4 := new(gmp.Int).Exp(pd.C, FourBigInt, nil)
+	ci2 := new(gmp.Int).Exp(pd.Decryption, TwoBigInt, nil)
+	opts := pd.options()
+	if err := opts.check(); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProof, err)
+	}
+	if computeHash(opts, pd.A, pd.B, c4, ci2).Cmp(pd.E) != 0 {
+		return ErrChallengeMismatch
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// returns the partial decryptions with proofs of all servers for every
+// ciphertext
+func batchPartialDecryptions(t *testing.T, tsks []*ThresholdSecretKey, cts []*Ciphertext) []*PartialDecryptionZKP {
+	var pds []*PartialDecryptionZKP
+	for _, ct := range cts {
+		for _, tsk := range tsks {
+			pd, err := tsk.PartialDecryptionWithZKP(ct.C)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pds = append(pds, pd)
+		}
+	}
+	return pds
+}
+
+func TestVerifyBatch(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+
+	cts := []*Ciphertext{tk.Encrypt(gmp.NewInt(1)), tk.Encrypt(gmp.NewInt(2)), tk.Encrypt(gmp.NewInt(3))}
+	pds := batchPartialDecryptions(t, tsks, cts)
+	if err := tk.VerifyBatch(pds); err != nil {
+		t.Fatal(err)
+	}
+
+	// proofs without commitments are verified one by one
+	encoded, err := pds[4].Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := tk.DecodePartialDecryptionZKP(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.A != nil {
+		t.Fatal("compact encoding carries the commitments")
+	}
+	mixed := append([]*PartialDecryptionZKP{decoded}, pds...)
+	if err := tk.VerifyBatch(mixed); err != nil {
+		t.Error(err)
+	}
+
+	// a wrong partial decryption is attributed to its server
+	bad := *pds[7]
+	bad.Decryption = new(gmp.Int).Mul(pds[7].Decryption, pds[7].Decryption)
+	bad.Decryption.Mod(bad.Decryption, tk.GetN2())
+	tampered := append([]*PartialDecryptionZKP{}, pds...)
+	tampered[7] = &bad
+	err = tk.VerifyBatch(tampered)
+	if !errors.Is(err, ErrChallengeMismatch) || !strings.Contains(err.Error(), "share 3") {
+		t.Error("expected a challenge mismatch for share 3, got ", err)
+	}
+}
+
+func TestVerifyBatchRejectsInconsistentResponse(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+	pds := batchPartialDecryptions(t, tsks, []*Ciphertext{tk.Encrypt(gmp.NewInt(9))})
+
+	// the commitments still hash to E, but Z does not match them
+	bad := *pds[1]
+	bad.Z = new(gmp.Int).Add(pds[1].Z, OneBigInt)
+	err = tk.VerifyBatch([]*PartialDecryptionZKP{pds[0], &bad, pds[2]})
+	if !errors.Is(err, ErrChallengeMismatch) || !strings.Contains(err.Error(), "share 2") {
+		t.Error("expected a challenge mismatch for share 2, got ", err)
+	}
+
+	other, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.InsecureAllowSmallKeys = true
+	otherKeys, err := other.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := otherKeys[0].PublicOnly().VerifyBatch(pds); !errors.Is(err, ErrStaleKey) {
+		t.Error("expected ErrStaleKey, got ", err)
+	}
+}
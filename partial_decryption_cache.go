@@ -0,0 +1,103 @@
+package paillier
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// partialDecryptionCache is an LRU cache of proven partial decryptions keyed
+// by the digest of the ciphertext and the options of the proof. A nil cache is
+// disabled.
+type partialDecryptionCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *partialDecryptionCacheEntry, most recent first
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type partialDecryptionCacheEntry struct {
+	key [sha256.Size]byte
+	pd  *PartialDecryptionZKP
+}
+
+// EnablePartialDecryptionCache keeps the last size proven partial decryptions
+// so that a server that is asked repeatedly for the same ciphertext, e.g., on
+// retries or by several combiners, returns the cached share and proof instead
+// of recomputing them. A size of zero disables the cache. It must not be
+// called concurrently with PartialDecryptionWithZKP.
+func (tsk *ThresholdSecretKey) EnablePartialDecryptionCache(size int) {
+	if size <= 0 {
+		tsk.cache = nil
+		return
+	}
+
+	tsk.cache = &partialDecryptionCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+func partialDecryptionCacheKey(c *gmp.Int, opts *ProofOptions) [sha256.Size]byte {
+	hash := sha256.New()
+	hash.Write(c.Bytes())
+	hash.Write([]byte{0})
+	binary.Write(hash, binary.BigEndian, uint32(opts.hash()))
+	binary.Write(hash, binary.BigEndian, uint32(opts.challengeBits()))
+	binary.Write(hash, binary.BigEndian, uint32(opts.Security.StatisticalBits))
+	binary.Write(hash, binary.BigEndian, uint32(len(opts.Domain)))
+	hash.Write([]byte(opts.Domain))
+	hash.Write(opts.Session)
+	var key [sha256.Size]byte
+	copy(key[:], hash.Sum(nil))
+	return key
+}
+
+// returns a copy of the cached partial decryption or nil
+func (c *partialDecryptionCache) get(ct *gmp.Int, opts *ProofOptions) *PartialDecryptionZKP {
+	if c == nil {
+		return nil
+	}
+
+	key := partialDecryptionCacheKey(ct, opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	c.order.MoveToFront(element)
+	pd := *element.Value.(*partialDecryptionCacheEntry).pd
+	return &pd
+}
+
+func (c *partialDecryptionCache) add(pd *PartialDecryptionZKP) {
+	if c == nil {
+		return
+	}
+
+	key := partialDecryptionCacheKey(pd.C, pd.options())
+	entry := *pd
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&partialDecryptionCacheEntry{key: key, pd: &entry})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*partialDecryptionCacheEntry).key)
+	}
+}
@@ -0,0 +1,59 @@
+package paillier
+
+import (
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestPartialDecryptionCache(t *testing.T) {
+	tsk := getThresholdPrivateKey()
+	tsk.EnablePartialDecryptionCache(2)
+
+	c1 := gmp.NewInt(876)
+	c2 := gmp.NewInt(877)
+	c3 := gmp.NewInt(878)
+
+	pd, err := tsk.PartialDecryptionWithZKP(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := tsk.PartialDecryptionWithZKP(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached == pd || cached.E.Cmp(pd.E) != 0 || cached.Z.Cmp(pd.Z) != 0 {
+		t.Error("repeated request was not served from the cache")
+	}
+	if !cached.VerifyProof() {
+		t.Error("cached proof does not verify")
+	}
+
+	// a different session is a different request
+	withSession, err := tsk.PartialDecryptionWithSession(c1, []byte("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withSession.E.Cmp(pd.E) == 0 {
+		t.Error("cached proof returned for another session")
+	}
+
+	// c1 is the least recently used entry and is evicted
+	if _, err := tsk.PartialDecryptionWithZKP(c2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tsk.PartialDecryptionWithZKP(c3); err != nil {
+		t.Fatal(err)
+	}
+	if tsk.cache.get(c1, &ProofOptions{}) != nil {
+		t.Error("least recently used entry was not evicted")
+	}
+	if tsk.cache.get(c3, &ProofOptions{}) == nil {
+		t.Error("recent entry was evicted")
+	}
+
+	tsk.EnablePartialDecryptionCache(0)
+	if tsk.cache != nil {
+		t.Error("cache was not disabled")
+	}
+}
@@ -0,0 +1,157 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Partial decryptions are the messages exchanged between the decryption
+// servers and the combiner, so they have a compact encoding in the format of
+// binary.go. Instead of the threshold public key, a PartialDecryptionZKP
+// carries the SHA-256 digest of the key's CanonicalBytes and the receiver
+// supplies the key when decoding. Proofs with a hash function, domain or
+// challenge length other than the defaults of ProofOptions have no compact
+// encoding. A PartialDecryption carries the key digest only if its
+// KeyFingerprint is set:
+//
+//	PartialDecryption:    ID || Decryption [|| key digest]
+//	PartialDecryptionZKP: ID || Decryption || key digest || C || E || Z || Session
+
+// maxSessionLength bounds the session of a decoded PartialDecryptionZKP
+const maxSessionLength = 1 << 16
+
+// Encode returns the compact binary encoding of the partial decryption
+func (pd *PartialDecryption) Encode() []byte {
+	buf := newBinaryBuffer(binaryTagPartialDecryption)
+	binary.Write(buf, binary.BigEndian, uint32(pd.ID))
+	writeBinaryInts(buf, pd.Decryption)
+	if digest, err := hex.DecodeString(pd.KeyFingerprint); err == nil && len(digest) == sha256.Size {
+		buf.Write(digest)
+	}
+	return buf.Bytes()
+}
+
+// DecodePartialDecryption decodes a partial decryption produced by Encode and
+// checks that it is well-formed for the key. It returns an error wrapping
+// ErrKeyMismatch if the encoding carries the digest of a different key.
+func (tk *ThresholdPublicKey) DecodePartialDecryption(data []byte) (*PartialDecryption, error) {
+	r, err := newBinaryReader(data, binaryTagPartialDecryption)
+	if err != nil {
+		return nil, err
+	}
+
+	pd := tk.readPartialDecryption(r)
+	var digest []byte
+	if r.err == nil && len(r.data) > 0 {
+		digest = r.readFixed(sha256.Size)
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+
+	if digest != nil {
+		if !bytes.Equal(digest, tk.Digest()) {
+			return nil, fmt.Errorf("%w: share of server %d", ErrKeyMismatch, pd.ID)
+		}
+		pd.KeyFingerprint = hex.EncodeToString(digest)
+	}
+	if err := tk.checkPartialDecryption(pd); err != nil {
+		return nil, err
+	}
+	return pd, nil
+}
+
+// Encode returns the compact binary encoding of the partial decryption and its
+// proof, which references the key by its digest
+func (pd *PartialDecryptionZKP) Encode() ([]byte, error) {
+	if pd.Key == nil || pd.Key.N == nil {
+		return nil, errors.New("partial decryption is missing its key")
+	}
+	if pd.options().hash() != crypto.SHA256 || pd.Domain != "" || pd.ChallengeBits != 0 {
+		return nil, errors.New("compact encoding requires the default proof options")
+	}
+
+	buf := newBinaryBuffer(binaryTagPartialDecryptionZKP)
+	binary.Write(buf, binary.BigEndian, uint32(pd.ID))
+	writeBinaryInts(buf, pd.Decryption)
+	buf.Write(pd.Key.Digest())
+	writeBinaryInts(buf, pd.C, pd.E, pd.Z)
+	writeCanonicalBytes(buf, pd.Session)
+	return buf.Bytes(), nil
+}
+
+// DecodePartialDecryptionZKP decodes a partial decryption produced by
+// PartialDecryptionZKP.Encode. It returns an error wrapping ErrStaleKey if the
+// encoding references a different key. The proof is not verified.
+func (tk *ThresholdPublicKey) DecodePartialDecryptionZKP(data []byte) (*PartialDecryptionZKP, error) {
+	r, err := newBinaryReader(data, binaryTagPartialDecryptionZKP)
+	if err != nil {
+		return nil, err
+	}
+
+	pd := tk.readPartialDecryption(r)
+	digest := r.readFixed(sha256.Size)
+	n2Length := len(tk.GetN2().Bytes())
+	c := r.readBoundedInt(n2Length)
+	e := r.readBoundedInt(sha256.Size)
+	// Z = r + E * delta * share with r < N^2, the other factors are below N^2 as well
+	z := r.readBoundedInt(3*n2Length + sha256.Size)
+	session := r.readBytes(maxSessionLength)
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(digest, tk.Digest()) {
+		return nil, &InvalidProofError{ID: pd.ID, Err: ErrStaleKey}
+	}
+	pd.KeyFingerprint = hex.EncodeToString(digest)
+	if err := tk.checkPartialDecryption(pd); err != nil {
+		return nil, err
+	}
+	if c == nil || e == nil || z == nil {
+		return nil, errors.New("partial decryption is missing proof values")
+	}
+	if len(session) > 0 {
+		session = append([]byte{}, session...)
+	} else {
+		session = nil
+	}
+
+	return &PartialDecryptionZKP{
+		PartialDecryption: *pd,
+		Key:               tk,
+		C:                 c,
+		E:                 e,
+		Z:                 z,
+		Session:           session,
+	}, nil
+}
+
+func (tk *ThresholdPublicKey) readPartialDecryption(r *binaryReader) *PartialDecryption {
+	id := r.readUint32()
+	decryption := r.readBoundedInt(len(tk.GetN2().Bytes()))
+	return &PartialDecryption{ID: int(id), Decryption: decryption}
+}
+
+func (tk *ThresholdPublicKey) checkPartialDecryption(pd *PartialDecryption) error {
+	if pd.ID < 1 || pd.ID > tk.TotalNumberOfDecryptionServers {
+		return errors.New("share ID is out of range")
+	}
+	if pd.Decryption == nil || pd.Decryption.Cmp(tk.GetN2()) >= 0 {
+		return errors.New("partial decryption is out of range")
+	}
+	return nil
+}
+
+// Digest returns the SHA-256 digest of the canonical encoding of the key,
+// which identifies the key in encoded partial decryptions; its hex encoding
+// is the CommitteeFingerprint
+func (tk *ThresholdPublicKey) Digest() []byte {
+	digest := sha256.Sum256(tk.CanonicalBytes())
+	return digest[:]
+}
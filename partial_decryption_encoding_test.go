@@ -0,0 +1,108 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/gob"
+	"errors"
+	"testing"
+)
+
+func TestPartialDecryptionEncoding(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	ct := tk.Encrypt(b(17))
+	var pds []*PartialDecryptionZKP
+	for _, tsk := range tsks[:3] {
+		pd, err := tsk.PartialDecryptionWithSession(ct.C, []byte("session"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := pd.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the encoding does not contain the verification keys
+		var gobBuf bytes.Buffer
+		gob.NewEncoder(&gobBuf).Encode(pd)
+		if len(data) >= gobBuf.Len()/2 {
+			t.Errorf("encoding has %d bytes, gob has %d", len(data), gobBuf.Len())
+		}
+
+		decoded, err := tk.DecodePartialDecryptionZKP(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := decoded.VerifyErr(); err != nil {
+			t.Error(err)
+		}
+		pds = append(pds, decoded)
+	}
+
+	m, err := tk.CombinePartialDecryptionsZKP(pds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 17 {
+		t.Error("wrong decryption ", m)
+	}
+
+	plain := tsks[4].PartialDecrypt(ct.C)
+	decoded, err := tk.DecodePartialDecryption(plain.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != 5 || decoded.Decryption.Cmp(plain.Decryption) != 0 {
+		t.Error("partial decryption does not match")
+	}
+}
+
+func TestPartialDecryptionEncodingStrict(t *testing.T) {
+	tkh, _ := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	tkh.InsecureAllowSmallKeys = true
+	tsks, _ := tkh.GenerateKeys()
+	tk := &tsks[0].ThresholdPublicKey
+
+	pd, _ := tsks[1].PartialDecryptionWithZKP(tk.Encrypt(b(1)).C)
+	data, _ := pd.Encode()
+
+	for i := 0; i < len(data); i++ {
+		if _, err := tk.DecodePartialDecryptionZKP(data[:i]); err == nil {
+			t.Fatalf("truncated encoding of %d bytes was accepted", i)
+		}
+	}
+	if _, err := tk.DecodePartialDecryptionZKP(append(data, 0)); err == nil {
+		t.Error("trailing data was accepted")
+	}
+	if _, err := tk.DecodePartialDecryption(data); err == nil {
+		t.Error("proof was decoded as a plain partial decryption")
+	}
+
+	// a proof under a different key is reported as stale
+	otherGen, _ := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	otherGen.InsecureAllowSmallKeys = true
+	others, _ := otherGen.GenerateKeys()
+	if _, err := others[0].ThresholdPublicKey.DecodePartialDecryptionZKP(data); !errors.Is(err, ErrStaleKey) {
+		t.Error("expected ErrStaleKey, got ", err)
+	}
+
+	oversized := &PartialDecryption{ID: 1, Decryption: tk.GetN3()}
+	if _, err := tk.DecodePartialDecryption(oversized.Encode()); err == nil {
+		t.Error("oversized partial decryption was accepted")
+	}
+	outOfRange := &PartialDecryption{ID: 4, Decryption: b(2)}
+	if _, err := tk.DecodePartialDecryption(outOfRange.Encode()); err == nil {
+		t.Error("share ID out of range was accepted")
+	}
+}
@@ -0,0 +1,288 @@
+package paillier
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// PEM block types of the encoded keys
+const (
+	PEMPublicKey          = "PAILLIER PUBLIC KEY"
+	PEMPrivateKey         = "PAILLIER PRIVATE KEY"
+	PEMThresholdPublicKey = "PAILLIER THRESHOLD PUBLIC KEY"
+	PEMThresholdKeyShare  = "PAILLIER THRESHOLD KEY SHARE"
+)
+
+// derVersion is the version field of every DER structure
+const derVersion = 1
+
+// The DER structures follow the layout of PKCS #1 RSA keys: a version followed
+// by the integers of the key. Absent values (e.g., H of a key that was not
+// generated by KeyGen) are encoded as zero.
+
+type publicKeyDER struct {
+	Version    int
+	N, G, H, K *big.Int
+}
+
+type privateKeyDER struct {
+	Version               int
+	N, G, H, K            *big.Int
+	Lambda, Lm, Mu, Order *big.Int
+}
+
+type thresholdPublicKeyDER struct {
+	Version                        int
+	N, G, H, K                     *big.Int
+	TotalNumberOfDecryptionServers int
+	Threshold                      int
+	VerificationKey                *big.Int
+	VerificationKeys               []*big.Int
+	S                              int `asn1:"optional"`
+}
+
+type thresholdKeyShareDER struct {
+	Version                        int
+	N, G, H, K                     *big.Int
+	TotalNumberOfDecryptionServers int
+	Threshold                      int
+	VerificationKey                *big.Int
+	VerificationKeys               []*big.Int
+	ID                             int
+	Share                          *big.Int
+	S                              int `asn1:"optional"`
+}
+
+// MarshalPublicKeyDER returns the ASN.1 DER encoding of the public key
+func MarshalPublicKeyDER(pk *PublicKey) ([]byte, error) {
+	return asn1.Marshal(publicKeyDER{derVersion, toDER(pk.N), toDER(pk.G), toDER(pk.H), toDER(pk.K)})
+}
+
+// ParsePublicKeyDER parses a public key encoded by MarshalPublicKeyDER
+func ParsePublicKeyDER(der []byte) (*PublicKey, error) {
+	var v publicKeyDER
+	if err := parseDER(der, &v, &v.Version); err != nil {
+		return nil, err
+	}
+
+	pk := &PublicKey{N: fromDER(v.N), G: fromDER(v.G), H: fromDER(v.H), K: fromDER(v.K)}
+	if pk.N == nil {
+		return nil, errors.New("public key is missing N")
+	}
+	return pk, nil
+}
+
+// MarshalPrivateKeyDER returns the ASN.1 DER encoding of the secret key
+func MarshalPrivateKeyDER(sk *SecretKey) ([]byte, error) {
+	return asn1.Marshal(privateKeyDER{
+		derVersion, toDER(sk.N), toDER(sk.G), toDER(sk.H), toDER(sk.K),
+		toDER(sk.Lambda), toDER(sk.Lm), toDER(sk.Mu), toDER(sk.m),
+	})
+}
+
+// ParsePrivateKeyDER parses a secret key encoded by MarshalPrivateKeyDER
+func ParsePrivateKeyDER(der []byte) (*SecretKey, error) {
+	var v privateKeyDER
+	if err := parseDER(der, &v, &v.Version); err != nil {
+		return nil, err
+	}
+
+	sk := &SecretKey{
+		PublicKey: PublicKey{N: fromDER(v.N), G: fromDER(v.G), H: fromDER(v.H), K: fromDER(v.K)},
+		Lambda:    fromDER(v.Lambda),
+		Lm:        fromDER(v.Lm),
+		Mu:        fromDER(v.Mu),
+		m:         fromDER(v.Order),
+	}
+	if sk.N == nil || sk.Lambda == nil {
+		return nil, errors.New("secret key is missing the public key or Lambda")
+	}
+	return sk, nil
+}
+
+// MarshalThresholdPublicKeyDER returns the ASN.1 DER encoding of the threshold public key
+func MarshalThresholdPublicKeyDER(tk *ThresholdPublicKey) ([]byte, error) {
+	return asn1.Marshal(*tk.toDER())
+}
+
+// ParseThresholdPublicKeyDER parses a threshold public key encoded by MarshalThresholdPublicKeyDER
+func ParseThresholdPublicKeyDER(der []byte) (*ThresholdPublicKey, error) {
+	var v thresholdPublicKeyDER
+	if err := parseDER(der, &v, &v.Version); err != nil {
+		return nil, err
+	}
+	return v.toKey()
+}
+
+// MarshalThresholdKeyShareDER returns the ASN.1 DER encoding of the key share
+// of a decryption server
+func MarshalThresholdKeyShareDER(tsk *ThresholdSecretKey) ([]byte, error) {
+	tk := tsk.ThresholdPublicKey.toDER()
+	return asn1.Marshal(thresholdKeyShareDER{
+		tk.Version, tk.N, tk.G, tk.H, tk.K,
+		tk.TotalNumberOfDecryptionServers, tk.Threshold, tk.VerificationKey, tk.VerificationKeys,
+		tsk.ID, toDER(tsk.Share), tk.S,
+	})
+}
+
+// ParseThresholdKeyShareDER parses a key share encoded by MarshalThresholdKeyShareDER
+func ParseThresholdKeyShareDER(der []byte) (*ThresholdSecretKey, error) {
+	var v thresholdKeyShareDER
+	if err := parseDER(der, &v, &v.Version); err != nil {
+		return nil, err
+	}
+
+	tk, err := (&thresholdPublicKeyDER{
+		v.Version, v.N, v.G, v.H, v.K,
+		v.TotalNumberOfDecryptionServers, v.Threshold, v.VerificationKey, v.VerificationKeys, v.S,
+	}).toKey()
+	if err != nil {
+		return nil, err
+	}
+
+	share := fromDER(v.Share)
+	if share == nil {
+		return nil, errors.New("threshold secret key is missing the share")
+	}
+	if v.ID < 1 || v.ID > tk.TotalNumberOfDecryptionServers {
+		return nil, errors.New("share ID is out of range")
+	}
+
+	return &ThresholdSecretKey{ThresholdPublicKey: *tk, ID: v.ID, Share: share}, nil
+}
+
+// EncodePEM returns the PEM encoding of a *PublicKey, *SecretKey,
+// *ThresholdPublicKey or *ThresholdSecretKey
+func EncodePEM(key interface{}) ([]byte, error) {
+	var blockType string
+	var der []byte
+	var err error
+
+	switch k := key.(type) {
+	case *PublicKey:
+		blockType = PEMPublicKey
+		der, err = MarshalPublicKeyDER(k)
+	case *SecretKey:
+		blockType = PEMPrivateKey
+		der, err = MarshalPrivateKeyDER(k)
+	case *ThresholdPublicKey:
+		blockType = PEMThresholdPublicKey
+		der, err = MarshalThresholdPublicKeyDER(k)
+	case *ThresholdSecretKey:
+		blockType = PEMThresholdKeyShare
+		der, err = MarshalThresholdKeyShareDER(k)
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), nil
+}
+
+// DecodePEM decodes the first PEM block in data, which must be of one of the
+// Paillier block types, and returns the key and the remaining data. The key
+// has the same type that was passed to EncodePEM.
+func DecodePEM(data []byte) (interface{}, []byte, error) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return nil, data, errors.New("no PEM block found")
+	}
+
+	var key interface{}
+	var err error
+
+	switch block.Type {
+	case PEMPublicKey:
+		key, err = ParsePublicKeyDER(block.Bytes)
+	case PEMPrivateKey:
+		key, err = ParsePrivateKeyDER(block.Bytes)
+	case PEMThresholdPublicKey:
+		key, err = ParseThresholdPublicKeyDER(block.Bytes)
+	case PEMThresholdKeyShare:
+		key, err = ParseThresholdKeyShareDER(block.Bytes)
+	default:
+		return nil, rest, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, rest, err
+	}
+
+	return key, rest, nil
+}
+
+func (tk *ThresholdPublicKey) toDER() *thresholdPublicKeyDER {
+	vi := make([]*big.Int, len(tk.VerificationKeys))
+	for i, v := range tk.VerificationKeys {
+		vi[i] = toDER(v)
+	}
+
+	return &thresholdPublicKeyDER{
+		derVersion, toDER(tk.N), toDER(tk.G), toDER(tk.H), toDER(tk.K),
+		tk.TotalNumberOfDecryptionServers, tk.Threshold, toDER(tk.VerificationKey), vi, tk.S,
+	}
+}
+
+func (v *thresholdPublicKeyDER) toKey() (*ThresholdPublicKey, error) {
+	var vi []*gmp.Int
+	for _, x := range v.VerificationKeys {
+		vi = append(vi, fromDER(x))
+	}
+
+	if fromDER(v.N) == nil {
+		return nil, errors.New("threshold key is missing the public key")
+	}
+	if err := checkThresholdParameters(v.TotalNumberOfDecryptionServers, v.Threshold, vi); err != nil {
+		return nil, err
+	}
+	if err := checkDamgardJurikExponent(int64(v.S)); err != nil {
+		return nil, err
+	}
+
+	return &ThresholdPublicKey{
+		PublicKey:                      PublicKey{N: fromDER(v.N), G: fromDER(v.G), H: fromDER(v.H), K: fromDER(v.K)},
+		TotalNumberOfDecryptionServers: v.TotalNumberOfDecryptionServers,
+		Threshold:                      v.Threshold,
+		VerificationKey:                fromDER(v.VerificationKey),
+		VerificationKeys:               vi,
+		S:                              v.S,
+	}, nil
+}
+
+// parseDER unmarshals der into v and checks that there is no trailing data and
+// that the version is supported
+func parseDER(der []byte, v interface{}, version *int) error {
+	rest, err := asn1.Unmarshal(der, v)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("trailing data after DER structure")
+	}
+	if *version != derVersion {
+		return errors.New("unsupported DER version")
+	}
+	return nil
+}
+
+// toDER encodes nil as zero since ASN.1 integers cannot be absent
+func toDER(x *gmp.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return ToBigInt(x)
+}
+
+// fromDER decodes zero, and invalid negative values, as absent
+func fromDER(x *big.Int) *gmp.Int {
+	if x == nil || x.Sign() <= 0 {
+		return nil
+	}
+	return ToGmpInt(x)
+}
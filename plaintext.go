@@ -1,6 +1,8 @@
 package paillier
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
 
 	gmp "github.com/ncw/gmp"
@@ -16,3 +18,75 @@ func (pk *PublicKey) EncodeFixedPoint(a *big.Float, prec int) *gmp.Int {
 	floor, _ = scaled.Int(floor)
 	return new(gmp.Int).SetBytes(floor.Bytes())
 }
+
+// EncodeSigned maps a signed integer with |a| < N/2 to Z_N, where negative
+// values are represented as N - |a|
+func (pk *PublicKey) EncodeSigned(a *big.Int) *gmp.Int {
+	m := ToGmpInt(new(big.Int).Abs(a))
+	if a.Sign() < 0 {
+		m.Sub(pk.N, m)
+	}
+	return m
+}
+
+// DecodeSigned maps a plaintext in Z_N to a signed integer, interpreting
+// values greater than N/2 as negative
+func (pk *PublicKey) DecodeSigned(m *gmp.Int) *big.Int {
+	half := new(gmp.Int).Rsh(pk.N, 1)
+	if m.Cmp(half) <= 0 {
+		return ToBigInt(m)
+	}
+	return new(big.Int).Neg(ToBigInt(new(gmp.Int).Sub(pk.N, m)))
+}
+
+// EncryptInt64 encrypts a signed integer using the encoding of EncodeSigned.
+// Keys with N < 2^64 cannot encode every int64; values with |a| > N/2 are
+// rejected with an error wrapping ErrMessageTooLarge.
+func (pk *PublicKey) EncryptInt64(a int64) (*Ciphertext, error) {
+	m := big.NewInt(a)
+	if new(big.Int).Abs(m).Cmp(ToBigInt(new(gmp.Int).Rsh(pk.N, 1))) > 0 {
+		return nil, fmt.Errorf("%w: |%d| is greater than N/2", ErrMessageTooLarge, a)
+	}
+	return pk.EncryptWithRandom(pk.EncodeSigned(m), nil)
+}
+
+// DecryptInt64 decrypts a ciphertext produced by EncryptInt64, or by
+// homomorphic operations on such ciphertexts, and returns an error if the
+// signed plaintext does not fit into an int64
+func (sk *SecretKey) DecryptInt64(ct *Ciphertext) (int64, error) {
+	m := sk.DecodeSigned(sk.Decrypt(ct))
+	if !m.IsInt64() {
+		return 0, errors.New("plaintext does not fit into an int64")
+	}
+	return m.Int64(), nil
+}
+
+// EncryptUint64 encrypts an unsigned integer as is, i.e., without the signed
+// encoding, and returns an error wrapping ErrMessageTooLarge if a is not
+// smaller than N
+func (pk *PublicKey) EncryptUint64(a uint64) (*Ciphertext, error) {
+	return pk.EncryptWithRandom(new(gmp.Int).SetUint64(a), nil)
+}
+
+// DecryptUint64 decrypts a ciphertext produced by EncryptUint64, or by
+// homomorphic operations on such ciphertexts, and returns an error if the
+// plaintext does not fit into a uint64
+func (sk *SecretKey) DecryptUint64(ct *Ciphertext) (uint64, error) {
+	m := ToBigInt(sk.Decrypt(ct))
+	if !m.IsUint64() {
+		return 0, errors.New("plaintext does not fit into a uint64")
+	}
+	return m.Uint64(), nil
+}
+
+// EAddInt64 returns an encryption of m + k, see EAddConstant. Negative
+// constants follow the encoding of EncodeSigned.
+func (pk *PublicKey) EAddInt64(ct *Ciphertext, k int64) *Ciphertext {
+	return pk.EAddConstant(ct, big.NewInt(k))
+}
+
+// ECMultInt64 returns an encryption of k*m, see ECMult. Negative constants
+// follow the encoding of EncodeSigned.
+func (pk *PublicKey) ECMultInt64(ct *Ciphertext, k int64) *Ciphertext {
+	return pk.ECMult(ct, big.NewInt(k))
+}
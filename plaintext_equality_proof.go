@@ -0,0 +1,108 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// PlaintextEqualityProof is a non-interactive proof (Fiat-Shamir heuristic)
+// that two ciphertexts c1 and c2 of the same level s encrypt the same
+// plaintext. It proves knowledge of u with c2 / c1 = u^(N^s) mod N^(s+1),
+// i.e., that c2 / c1 is an encryption of 0, without revealing the plaintext.
+type PlaintextEqualityProof struct {
+	A *gmp.Int // commitment rho^(N^s)
+	Z *gmp.Int // rho * u^e mod N
+}
+
+// RerandomizeWithProof returns a rerandomization of ct as Rerandomize
+// together with a proof that it encrypts the same plaintext as ct
+func (pk *PublicKey) RerandomizeWithProof(ct *Ciphertext) (*Ciphertext, *PlaintextEqualityProof, error) {
+	u, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, ns, ns1 := pk.getModuliForLevel(ct.Level)
+	c := new(gmp.Int).Exp(u, ns, ns1)
+	c.Mul(c, ct.C)
+	c.Mod(c, ns1)
+	rerandomized := &Ciphertext{C: c, Level: ct.Level, EncMethod: ct.EncMethod}
+
+	proof, err := pk.ProvePlaintextEquality(ct, rerandomized, u)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rerandomized, proof, nil
+}
+
+// ProvePlaintextEquality proves that ct1 and ct2 encrypt the same plaintext
+// given u with ct2 = ct1 u^(N^s) mod N^(s+1). For ct1 = g^m r1^(N^s) and
+// ct2 = g^m r2^(N^s), u = r2 / r1 mod N.
+func (pk *PublicKey) ProvePlaintextEquality(ct1, ct2 *Ciphertext, u *gmp.Int) (*PlaintextEqualityProof, error) {
+	if ct1.Level != ct2.Level {
+		return nil, errors.New("ciphertexts must have the same level")
+	}
+
+	rho, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	_, ns, ns1 := pk.getModuliForLevel(ct1.Level)
+	a := new(gmp.Int).Exp(rho, ns, ns1)
+	e := RandomOracleChallenge(pk.challengeBitLength(), pk.N, ct1.C, ct2.C, a)
+
+	z := new(gmp.Int).Exp(u, e, pk.N)
+	z.Mul(z, rho)
+	z.Mod(z, pk.N)
+
+	return &PlaintextEqualityProof{A: a, Z: z}, nil
+}
+
+// VerifyPlaintextEqualityProof returns true iff the proof shows that ct1 and
+// ct2 encrypt the same plaintext
+func (pk *PublicKey) VerifyPlaintextEqualityProof(ct1, ct2 *Ciphertext, proof *PlaintextEqualityProof) bool {
+	return pk.VerifyPlaintextEqualityProofErr(ct1, ct2, proof) == nil
+}
+
+// VerifyPlaintextEqualityProofErr verifies the proof as
+// VerifyPlaintextEqualityProof and returns an error wrapping
+// ErrMalformedProof or ErrProofPart1 (Z^(N^s) = A (c2 / c1)^e) if it is
+// rejected
+func (pk *PublicKey) VerifyPlaintextEqualityProofErr(ct1, ct2 *Ciphertext, proof *PlaintextEqualityProof) error {
+	if proof == nil || proof.A == nil || proof.Z == nil || ct1 == nil || ct1.C == nil || ct2 == nil || ct2.C == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if ct1.Level != ct2.Level {
+		return fmt.Errorf("%w: ciphertexts must have the same level", ErrMalformedProof)
+	}
+
+	_, ns, ns1 := pk.getModuliForLevel(ct1.Level)
+	for _, v := range []*gmp.Int{ct1.C, ct2.C, proof.A} {
+		if v.Sign() <= 0 || v.Cmp(ns1) >= 0 || new(gmp.Int).GCD(nil, nil, v, pk.N).Cmp(OneBigInt) != 0 {
+			return fmt.Errorf("%w: value is not a unit mod N^(s+1)", ErrMalformedProof)
+		}
+	}
+
+	if err := pk.checkUnits(proof.Z); err != nil {
+		return err
+	}
+
+	e := RandomOracleChallenge(pk.challengeBitLength(), pk.N, ct1.C, ct2.C, proof.A)
+
+	// Z^(N^s) c1^e = A c2^e
+	lhs := new(gmp.Int).Exp(proof.Z, ns, ns1)
+	lhs.Mul(lhs, new(gmp.Int).Exp(ct1.C, e, ns1))
+	lhs.Mod(lhs, ns1)
+	rhs := new(gmp.Int).Exp(ct2.C, e, ns1)
+	rhs.Mul(rhs, proof.A)
+	rhs.Mod(rhs, ns1)
+	if lhs.Cmp(rhs) != 0 {
+		return ErrProofPart1
+	}
+
+	return nil
+}
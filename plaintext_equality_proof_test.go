@@ -0,0 +1,81 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestPlaintextEqualityProof(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+		ct := pk.EncryptAtLevel(gmp.NewInt(42), level)
+		rerandomized, proof, err := pk.RerandomizeWithProof(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rerandomized.C.Cmp(ct.C) == 0 {
+			t.Error("ciphertext is not rerandomized")
+		}
+		if err := pk.VerifyPlaintextEqualityProofErr(ct, rerandomized, proof); err != nil {
+			t.Error("plaintext equality proof is not complete: ", err)
+		}
+	}
+}
+
+func TestPlaintextEqualityProofOfIndependentEncryptions(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	r1, _ := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	r2, _ := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	ct1 := pk.EncryptWithR(gmp.NewInt(7), r1)
+	ct2 := pk.EncryptWithR(gmp.NewInt(7), r2)
+
+	u := new(gmp.Int).ModInverse(r1, pk.N)
+	u.Mul(u, r2)
+	u.Mod(u, pk.N)
+	proof, err := pk.ProvePlaintextEquality(ct1, ct2, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.VerifyPlaintextEqualityProof(ct1, ct2, proof) {
+		t.Error("plaintext equality proof is not complete")
+	}
+}
+
+func TestPlaintextEqualityProofSoundness(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	r, _ := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	ct1 := pk.EncryptWithR(gmp.NewInt(7), r)
+	ct2 := pk.EncryptWithR(gmp.NewInt(8), r)
+
+	// ct2 / ct1 is an encryption of 1 and has no N-th root
+	proof, err := pk.ProvePlaintextEquality(ct1, ct2, OneBigInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.VerifyPlaintextEqualityProofErr(ct1, ct2, proof); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1 for different plaintexts, got ", err)
+	}
+
+	rerandomized, proof, err := pk.RerandomizeWithProof(ct1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.VerifyPlaintextEqualityProof(ct1, ct2, proof) {
+		t.Error("proof verifies for another ciphertext")
+	}
+	if pk.VerifyPlaintextEqualityProof(rerandomized, ct1, proof) {
+		t.Error("proof verifies for swapped ciphertexts")
+	}
+
+	if err := pk.VerifyPlaintextEqualityProofErr(ct1, pk.EncryptAtLevel(gmp.NewInt(7), EncLevelTwo), proof); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error for different levels, got ", err)
+	}
+	if err := pk.VerifyPlaintextEqualityProofErr(ct1, rerandomized, &PlaintextEqualityProof{}); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error, got ", err)
+	}
+}
@@ -0,0 +1,120 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// PlaintextKnowledgeProof is a non-interactive proof (Fiat-Shamir heuristic)
+// of knowledge of the plaintext m and randomness r of a level one ciphertext
+// c = g^m r^N mod N^2. The proof is bound to a context chosen by the
+// protocol, e.g., the identity of the encryptor and the session, so that a
+// ciphertext and its proof cannot be submitted again by another party.
+type PlaintextKnowledgeProof struct {
+	A *gmp.Int // commitment g^alpha rho^N
+	Z *gmp.Int // alpha + e*m mod N
+	W *gmp.Int // rho * r^e mod N
+}
+
+// EncryptWithProof encrypts m at level one and proves knowledge of the
+// plaintext and randomness of the ciphertext for the given context
+func (pk *PublicKey) EncryptWithProof(m *gmp.Int, context []byte) (*Ciphertext, *PlaintextKnowledgeProof, error) {
+	r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+	ct := pk.EncryptWithR(m, r)
+	proof, err := pk.ProvePlaintextKnowledge(ct, m, r, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ct, proof, nil
+}
+
+// ProvePlaintextKnowledge proves knowledge of m in [0, N) and r such that
+// ct = g^m r^N mod N^2
+func (pk *PublicKey) ProvePlaintextKnowledge(ct *Ciphertext, m, r *gmp.Int, context []byte) (*PlaintextKnowledgeProof, error) {
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("plaintext knowledge proofs are only supported for level one ciphertexts")
+	}
+
+	if m.Sign() < 0 {
+		return nil, errors.New("plaintext is negative")
+	}
+	if m.Cmp(pk.N) >= 0 {
+		return nil, fmt.Errorf("%w: plaintext is not smaller than N", ErrMessageTooLarge)
+	}
+
+	alpha, err := GetRandomNumber(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	rho, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	a := pk.EncryptWithR(alpha, rho).C
+	e := pk.plaintextKnowledgeChallenge(ct, a, context)
+
+	// g = N+1 has order N, so the response can be reduced mod N
+	z := new(gmp.Int).Mul(e, m)
+	z.Add(z, alpha)
+	z.Mod(z, pk.N)
+
+	w := new(gmp.Int).Exp(r, e, pk.N)
+	w.Mul(w, rho)
+	w.Mod(w, pk.N)
+
+	return &PlaintextKnowledgeProof{A: a, Z: z, W: w}, nil
+}
+
+// VerifyPlaintextKnowledgeProof returns true iff the proof shows that the
+// prover knows the plaintext and randomness of ct for the given context
+func (pk *PublicKey) VerifyPlaintextKnowledgeProof(ct *Ciphertext, proof *PlaintextKnowledgeProof, context []byte) bool {
+	return pk.VerifyPlaintextKnowledgeProofErr(ct, proof, context) == nil
+}
+
+// VerifyPlaintextKnowledgeProofErr verifies the proof as
+// VerifyPlaintextKnowledgeProof and returns an error wrapping
+// ErrMalformedProof or ErrProofPart1 (g^Z W^N = A c^e) if it is rejected
+func (pk *PublicKey) VerifyPlaintextKnowledgeProofErr(ct *Ciphertext, proof *PlaintextKnowledgeProof, context []byte) error {
+	if proof == nil || proof.A == nil || proof.Z == nil || proof.W == nil || ct == nil || ct.C == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if ct.Level != EncLevelOne {
+		return fmt.Errorf("%w: ciphertext must be a level one ciphertext", ErrMalformedProof)
+	}
+
+	n2 := pk.GetN2()
+	if proof.Z.Sign() < 0 || proof.Z.Cmp(pk.N) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+	if proof.A.Sign() <= 0 || proof.A.Cmp(n2) >= 0 || ct.C.Sign() <= 0 || ct.C.Cmp(n2) >= 0 {
+		return fmt.Errorf("%w: value is not in Z_N^2", ErrMalformedProof)
+	}
+
+	if err := pk.checkUnits(proof.W); err != nil {
+		return err
+	}
+
+	e := pk.plaintextKnowledgeChallenge(ct, proof.A, context)
+
+	rhs := new(gmp.Int).Exp(ct.C, e, n2)
+	rhs.Mul(rhs, proof.A)
+	rhs.Mod(rhs, n2)
+	if pk.EncryptWithR(proof.Z, proof.W).C.Cmp(rhs) != 0 {
+		return ErrProofPart1
+	}
+
+	return nil
+}
+
+func (pk *PublicKey) plaintextKnowledgeChallenge(ct *Ciphertext, a *gmp.Int, context []byte) *gmp.Int {
+	// the length is hashed as well since Bytes drops leading zeros
+	return RandomOracleChallenge(pk.challengeBitLength(), pk.N, ct.C, a,
+		gmp.NewInt(int64(len(context))), new(gmp.Int).SetBytes(context))
+}
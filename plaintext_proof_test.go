@@ -0,0 +1,64 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestPlaintextKnowledgeProofCompleteness(t *testing.T) {
+	_, pk := KeyGen(128)
+	context := []byte("voter 1")
+
+	for i := 0; i < 20; i++ {
+		m, _ := GetRandomNumber(pk.N, rand.Reader)
+		ct, proof, err := pk.EncryptWithProof(m, context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pk.VerifyPlaintextKnowledgeProofErr(ct, proof, context); err != nil {
+			t.Error("plaintext knowledge proof is not complete: ", err)
+		}
+	}
+}
+
+func TestPlaintextKnowledgeProofSoundness(t *testing.T) {
+	sk, pk := KeyGen(128)
+	context := []byte("voter 1")
+
+	ct, proof, err := pk.EncryptWithProof(gmp.NewInt(42), context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk.Decrypt(ct).Cmp(gmp.NewInt(42)) != 0 {
+		t.Fatal("wrong plaintext")
+	}
+
+	// a ciphertext and its proof cannot be submitted by another party
+	if pk.VerifyPlaintextKnowledgeProof(ct, proof, []byte("voter 2")) {
+		t.Error("proof verifies for another context")
+	}
+
+	// a mauled ciphertext does not verify with the original proof
+	mauled := pk.Add(ct, pk.Encrypt(gmp.NewInt(1)))
+	if err := pk.VerifyPlaintextKnowledgeProofErr(mauled, proof, context); !errors.Is(err, ErrProofPart1) {
+		t.Error("expected ErrProofPart1 for a mauled ciphertext, got ", err)
+	}
+
+	tampered := *proof
+	tampered.Z = new(gmp.Int).Add(proof.Z, OneBigInt)
+	tampered.Z.Mod(tampered.Z, pk.N)
+	if pk.VerifyPlaintextKnowledgeProof(ct, &tampered, context) {
+		t.Error("tampered proof verifies")
+	}
+
+	if err := pk.VerifyPlaintextKnowledgeProofErr(ct, &PlaintextKnowledgeProof{}, context); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error, got ", err)
+	}
+
+	if _, _, err := pk.EncryptWithProof(pk.N, context); err == nil {
+		t.Error("expected error for a plaintext out of range")
+	}
+}
@@ -0,0 +1,89 @@
+package paillier
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEncryptInt64(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	encrypt := func(v int64) *Ciphertext {
+		ct, err := pk.EncryptInt64(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ct
+	}
+
+	for _, v := range []int64{0, 1, -1, 42, -42, math.MaxInt64, math.MinInt64} {
+		m, err := sk.DecryptInt64(encrypt(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != v {
+			t.Error("wrong decryption ", m, " is not ", v)
+		}
+	}
+
+	// subtraction and negative constants round trip
+	diff := pk.Sub(encrypt(10), encrypt(25))
+	if m, _ := sk.DecryptInt64(pk.ConstMult(diff, b(3))); m != -45 {
+		t.Error("wrong result ", m, " is not -45")
+	}
+
+	overflow := pk.Add(encrypt(math.MaxInt64), encrypt(1))
+	if _, err := sk.DecryptInt64(overflow); err == nil {
+		t.Error("expected error for plaintext beyond int64")
+	}
+
+	small, _ := KeyGen(64)
+	if _, err := small.EncryptInt64(math.MinInt64); !errors.Is(err, ErrMessageTooLarge) {
+		t.Error("expected ErrMessageTooLarge for value beyond N/2, got ", err)
+	}
+}
+
+func TestEncryptUint64(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for _, v := range []uint64{0, 1, 42, math.MaxUint64} {
+		ct, err := pk.EncryptUint64(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := sk.DecryptUint64(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != v {
+			t.Error("wrong decryption ", m, " is not ", v)
+		}
+	}
+
+	ct, _ := pk.EncryptUint64(math.MaxUint64)
+	if _, err := sk.DecryptUint64(pk.EAddInt64(ct, 1)); err == nil {
+		t.Error("expected error for plaintext beyond uint64")
+	}
+
+	small, _ := KeyGen(64)
+	if _, err := small.EncryptUint64(math.MaxUint64); !errors.Is(err, ErrMessageTooLarge) {
+		t.Error("expected ErrMessageTooLarge for value not smaller than N, got ", err)
+	}
+}
+
+func TestInt64Constants(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	ct, err := pk.EncryptInt64(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m, _ := sk.DecryptInt64(pk.EAddInt64(ct, -10)); m != -3 {
+		t.Error("wrong sum ", m, " is not -3")
+	}
+	if m, _ := sk.DecryptInt64(pk.ECMultInt64(ct, -6)); m != -42 {
+		t.Error("wrong product ", m, " is not -42")
+	}
+}
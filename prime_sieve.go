@@ -0,0 +1,100 @@
+package paillier
+
+import (
+	"math/big"
+)
+
+// sieveBound is the bound of the small primes that candidates for safe
+// primes are sieved with before any primality test is run
+const sieveBound = 1 << 16
+
+// sieveWindow is the number of consecutive odd candidates sieved at once
+const sieveWindow = 1 << 14
+
+// sievePrimes are the odd primes below sieveBound
+var sievePrimes = oddPrimesBelow(sieveBound)
+
+// sieveGroups partition sievePrimes into groups whose products fit in a
+// uint64, so that a candidate is reduced by all primes of a group with one
+// big.Int operation
+var sieveGroups = groupSievePrimes(sievePrimes)
+
+type sieveGroup struct {
+	product *big.Int
+	primes  []uint64
+}
+
+// returns the odd primes below n with the sieve of Eratosthenes
+func oddPrimesBelow(n int) []uint64 {
+	composite := make([]bool, n)
+	primes := make([]uint64, 0, n/10)
+	for i := 3; i < n; i += 2 {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, uint64(i))
+		for j := i * i; j < n; j += 2 * i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
+
+func groupSievePrimes(primes []uint64) []sieveGroup {
+	var groups []sieveGroup
+	for len(primes) > 0 {
+		product := uint64(1)
+		i := 0
+		for ; i < len(primes) && product <= ^uint64(0)/primes[i]; i++ {
+			product *= primes[i]
+		}
+		groups = append(groups, sieveGroup{
+			product: new(big.Int).SetUint64(product),
+			primes:  primes[:i],
+		})
+		primes = primes[i:]
+	}
+	return groups
+}
+
+// sieveSafePrimeCandidates returns, in increasing order, the offsets
+// k < sieveWindow for which neither q + 2k nor 2(q + 2k) + 1 is divisible by
+// one of the sieve primes below bound; q must be odd and larger than bound so
+// that no small prime itself is sieved out.
+func sieveSafePrimeCandidates(q *big.Int, bound uint64) []uint64 {
+	composite := make([]bool, sieveWindow)
+	residue := new(big.Int)
+
+	for _, group := range sieveGroups {
+		if group.primes[0] >= bound {
+			break
+		}
+
+		m := residue.Mod(q, group.product).Uint64()
+		for _, prime := range group.primes {
+			if prime >= bound {
+				break
+			}
+
+			// q + 2k = 0 for k = -r/2 and 2(q + 2k) + 1 = 0 for k = (-1/2 - r)/2
+			r := m % prime
+			half := (prime + 1) / 2 // inverse of 2 mod prime
+			markSieve(composite, (prime-r)%prime*half%prime, prime)
+			markSieve(composite, (2*prime-half-r)%prime*half%prime, prime)
+		}
+	}
+
+	candidates := make([]uint64, 0, sieveWindow/16)
+	for k, c := range composite {
+		if !c {
+			candidates = append(candidates, uint64(k))
+		}
+	}
+	return candidates
+}
+
+func markSieve(composite []bool, start, step uint64) {
+	for k := start; k < uint64(len(composite)); k += step {
+		composite[k] = true
+	}
+}
@@ -0,0 +1,59 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestOddPrimesBelow(t *testing.T) {
+	expected := []uint64{3, 5, 7, 11, 13, 17, 19, 23, 29}
+	if primes := oddPrimesBelow(30); !reflect.DeepEqual(primes, expected) {
+		t.Error("unexpected primes ", primes)
+	}
+
+	if len(sievePrimes) != 6541 {
+		t.Error("unexpected number of sieve primes ", len(sievePrimes))
+	}
+}
+
+func TestSieveSafePrimeCandidates(t *testing.T) {
+	q, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 256))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.SetBit(q, 0, 1)
+	q.SetBit(q, 255, 1)
+
+	const bound = 1 << 10
+	candidates := make(map[uint64]bool)
+	for _, k := range sieveSafePrimeCandidates(q, bound) {
+		candidates[k] = true
+	}
+
+	candidate := new(big.Int)
+	p := new(big.Int)
+	rem := new(big.Int)
+	for k := uint64(0); k < sieveWindow; k++ {
+		candidate.Add(q, new(big.Int).SetUint64(2*k))
+		p.Lsh(candidate, 1)
+		p.Add(p, big.NewInt(1))
+
+		divisible := false
+		for _, prime := range sievePrimes {
+			if prime >= bound {
+				break
+			}
+			b := new(big.Int).SetUint64(prime)
+			if rem.Mod(candidate, b).Sign() == 0 || rem.Mod(p, b).Sign() == 0 {
+				divisible = true
+				break
+			}
+		}
+
+		if divisible == candidates[k] {
+			t.Fatal("wrong sieve result for offset ", k)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+)
+
+// minChallengeHashSize is the smallest digest size, in bytes, of a hash
+// function accepted for the challenge of a PartialDecryptionZKP
+const minChallengeHashSize = 32
+
+// ProofOptions configure the Fiat-Shamir challenge of a PartialDecryptionZKP.
+// The zero value produces the proofs of PartialDecryptionWithZKP.
+type ProofOptions struct {
+	// Hash is the hash function of the challenge, e.g., crypto.SHA3_256 or
+	// crypto.BLAKE2b_256. It must be linked into the binary, see
+	// crypto.Hash.Available, and have a digest of at least 256 bits. Zero
+	// means crypto.SHA256.
+	Hash crypto.Hash
+
+	// Domain separates the proofs of different protocols or deployments,
+	// e.g., "my-app/decryption/v1", so that a proof is never accepted in a
+	// context it was not produced for
+	Domain string
+
+	// Session binds the proof to a single request, see
+	// PartialDecryptionWithSession
+	Session []byte
+
+	// Security are the soundness and statistical hiding parameters; zero
+	// means the parameters of the key, see SetSecurityParams
+	Security SecurityParams
+}
+
+// returns the hash function of the challenge
+func (opts *ProofOptions) hash() crypto.Hash {
+	if opts.Hash == 0 {
+		return crypto.SHA256
+	}
+	return opts.Hash
+}
+
+// returns the bit length of the challenge
+func (opts *ProofOptions) challengeBits() int {
+	if opts.Security.ChallengeBits == 0 {
+		return 8 * opts.hash().Size()
+	}
+	return opts.Security.ChallengeBits
+}
+
+// checks that the hash function and the security parameters can be used for
+// the challenge
+func (opts *ProofOptions) check() error {
+	h := opts.hash()
+	if !h.Available() {
+		return fmt.Errorf("hash function %v is not available", h)
+	}
+	if h.Size() < minChallengeHashSize {
+		return fmt.Errorf("hash function %v is too short for the challenge", h)
+	}
+	return opts.Security.check(8 * h.Size())
+}
+
+// returns the options the proof was produced with; the statistical security
+// only affects the prover
+func (pd *PartialDecryptionZKP) options() *ProofOptions {
+	return &ProofOptions{
+		Hash:     pd.Hash,
+		Domain:   pd.Domain,
+		Session:  pd.Session,
+		Security: SecurityParams{ChallengeBits: pd.ChallengeBits},
+	}
+}
+
+// VerifyWithOptions verifies the proof as VerifyErr and additionally returns
+// an error wrapping ErrChallengeMismatch if it was not produced with the hash
+// function, domain, session and challenge bit length of opts, which may be nil
+// for the defaults.
+// Verifiers should use it instead of VerifyErr whenever they expect
+// non-default options, since VerifyErr accepts the options the proof claims.
+func (pd *PartialDecryptionZKP) VerifyWithOptions(opts *ProofOptions) error {
+	if opts == nil {
+		opts = &ProofOptions{}
+	}
+
+	got := pd.options()
+	if got.hash() != opts.hash() {
+		return &InvalidProofError{ID: pd.ID, Err: fmt.Errorf("%w: proof uses hash function %v instead of %v",
+			ErrChallengeMismatch, got.hash(), opts.hash())}
+	}
+	if got.challengeBits() != opts.challengeBits() {
+		return &InvalidProofError{ID: pd.ID, Err: fmt.Errorf("%w: proof has a %d-bit challenge instead of %d bits",
+			ErrChallengeMismatch, got.challengeBits(), opts.challengeBits())}
+	}
+	if got.Domain != opts.Domain {
+		return &InvalidProofError{ID: pd.ID, Err: fmt.Errorf("%w: proof is for another domain", ErrChallengeMismatch)}
+	}
+	if !bytes.Equal(got.Session, opts.Session) {
+		return &InvalidProofError{ID: pd.ID, Err: fmt.Errorf("%w: proof is for another session", ErrChallengeMismatch)}
+	}
+
+	return pd.VerifyErr()
+}
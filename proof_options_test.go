@@ -0,0 +1,105 @@
+package paillier
+
+import (
+	"crypto"
+	_ "crypto/md5"
+	"crypto/rand"
+	_ "crypto/sha3"
+	_ "crypto/sha512"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestPartialDecryptionWithOptions(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := tsks[0].Encrypt(gmp.NewInt(42)).C
+
+	for _, h := range []crypto.Hash{crypto.SHA256, crypto.SHA512, crypto.SHA3_256} {
+		opts := &ProofOptions{Hash: h, Domain: "test/decryption/v1", Session: []byte("request 7")}
+		pd, err := tsks[1].PartialDecryptionWithOptions(c, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pd.VerifyWithOptions(opts); err != nil {
+			t.Errorf("%v: proof is not complete: %v", h, err)
+		}
+
+		other := *opts
+		other.Domain = "test/decryption/v2"
+		if err := pd.VerifyWithOptions(&other); !errors.Is(err, ErrChallengeMismatch) {
+			t.Errorf("%v: expected a challenge mismatch for another domain, got %v", h, err)
+		}
+		other = *opts
+		other.Session = []byte("request 8")
+		if err := pd.VerifyWithOptions(&other); !errors.Is(err, ErrChallengeMismatch) {
+			t.Errorf("%v: expected a challenge mismatch for another session, got %v", h, err)
+		}
+
+		// rewriting the claimed domain invalidates the challenge
+		tampered := *pd
+		tampered.Domain = "test/decryption/v2"
+		if err := tampered.VerifyErr(); !errors.Is(err, ErrChallengeMismatch) {
+			t.Errorf("%v: expected a challenge mismatch for a rewritten domain, got %v", h, err)
+		}
+	}
+
+	// the default options produce the proofs of PartialDecryptionWithZKP
+	pd, err := tsks[0].PartialDecryptionWithOptions(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pd.VerifyWithOptions(&ProofOptions{Hash: crypto.SHA256}); err != nil {
+		t.Error(err)
+	}
+	if pd.VerifyWithOptions(&ProofOptions{Hash: crypto.SHA512}) == nil {
+		t.Error("proof verifies for another hash function")
+	}
+
+	if _, err := tsks[0].PartialDecryptionWithOptions(c, &ProofOptions{Hash: crypto.MD5}); err == nil {
+		t.Error("expected an error for a short hash function")
+	}
+}
+
+func TestPartialDecryptionOptionsEncoding(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &ProofOptions{Hash: crypto.SHA512, Domain: "test"}
+	pd, err := tsks[0].PartialDecryptionWithOptions(tsks[0].Encrypt(gmp.NewInt(1)).C, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pd.Encode(); err == nil {
+		t.Error("expected an error for the compact encoding of non-default options")
+	}
+
+	data, err := json.Marshal(pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PartialDecryptionZKP
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.VerifyWithOptions(opts); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,124 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNotEnoughQualified is returned when fewer parties than the threshold
+// remain qualified and a protocol cannot complete
+var ErrNotEnoughQualified = errors.New("not enough qualified parties")
+
+// RoundResult holds the messages of one round of a multi-round protocol
+type RoundResult struct {
+	Messages map[int][]byte // by ID of the sender
+	Missing  []int          // parties that did not send a message in time
+}
+
+// CollectRound receives one message of the round from each of the parties.
+// It returns when all parties sent their message or when the transport times
+// out, in which case the parties that did not respond are listed as Missing;
+// messages of other parties and second messages of a party are dropped.
+// This lets protocols complete with the parties that stay online.
+func CollectRound(t Transport, round int, parties []int) (*RoundResult, error) {
+	expected := make(map[int]bool, len(parties))
+	for _, id := range parties {
+		expected[id] = true
+	}
+
+	result := &RoundResult{Messages: make(map[int][]byte, len(parties))}
+	for len(result.Messages) < len(expected) {
+		msg, err := t.Receive(round)
+		if errors.Is(err, ErrTransportTimeout) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !expected[msg.From] {
+			continue
+		}
+		if _, ok := result.Messages[msg.From]; ok {
+			continue
+		}
+		result.Messages[msg.From] = msg.Data
+	}
+
+	for _, id := range parties {
+		if _, ok := result.Messages[id]; !ok {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	sort.Ints(result.Missing)
+
+	return result, nil
+}
+
+// QualifiedSet tracks the parties of a protocol that have not been
+// disqualified, e.g., for not responding or for a justified complaint
+type QualifiedSet struct {
+	Threshold int // minimum number of qualified parties to complete
+
+	qualified    map[int]bool
+	disqualified map[int]string // reasons
+}
+
+// NewQualifiedSet returns a set in which all parties are qualified
+func NewQualifiedSet(parties []int, threshold int) *QualifiedSet {
+	q := &QualifiedSet{
+		Threshold:    threshold,
+		qualified:    make(map[int]bool, len(parties)),
+		disqualified: make(map[int]string),
+	}
+	for _, id := range parties {
+		q.qualified[id] = true
+	}
+	return q
+}
+
+// Disqualify removes the party from the set; the first reason is kept
+func (q *QualifiedSet) Disqualify(id int, reason string) {
+	if !q.qualified[id] {
+		return
+	}
+	delete(q.qualified, id)
+	q.disqualified[id] = reason
+}
+
+// DisqualifyMissing disqualifies the parties that did not respond in the round
+func (q *QualifiedSet) DisqualifyMissing(result *RoundResult, round int) {
+	for _, id := range result.Missing {
+		q.Disqualify(id, fmt.Sprintf("no message in round %d", round))
+	}
+}
+
+// Contains returns true iff the party is qualified
+func (q *QualifiedSet) Contains(id int) bool {
+	return q.qualified[id]
+}
+
+// Members returns the qualified parties in increasing order
+func (q *QualifiedSet) Members() []int {
+	members := make([]int, 0, len(q.qualified))
+	for id := range q.qualified {
+		members = append(members, id)
+	}
+	sort.Ints(members)
+	return members
+}
+
+// Reason returns why the party was disqualified or "" if it is qualified
+func (q *QualifiedSet) Reason(id int) string {
+	return q.disqualified[id]
+}
+
+// Check returns an error wrapping ErrNotEnoughQualified if fewer than
+// Threshold parties are qualified
+func (q *QualifiedSet) Check() error {
+	if len(q.qualified) < q.Threshold {
+		return fmt.Errorf("%w: %d qualified, %d required", ErrNotEnoughQualified, len(q.qualified), q.Threshold)
+	}
+	return nil
+}
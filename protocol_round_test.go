@@ -0,0 +1,49 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCollectRound(t *testing.T) {
+	network := NewMemoryNetwork(1, 2, 3, 4, 5)
+	network.Timeout = 20 * time.Millisecond
+
+	for _, id := range []int{2, 4, 5} {
+		if err := network.Transport(id).Send(1, 7, []byte{byte(id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// second message of a party and a message of an unexpected party
+	network.Transport(2).Send(1, 7, []byte{0})
+	network.Transport(5).Send(1, 7, []byte{0})
+
+	result, err := CollectRound(network.Transport(1), 7, []int{2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 2 || result.Messages[2][0] != 2 || result.Messages[4][0] != 4 {
+		t.Error("wrong messages ", result.Messages)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != 3 {
+		t.Error("wrong missing parties ", result.Missing)
+	}
+
+	q := NewQualifiedSet([]int{2, 3, 4}, 2)
+	q.DisqualifyMissing(result, 7)
+	if q.Contains(3) || q.Reason(3) != "no message in round 7" {
+		t.Error("missing party is still qualified")
+	}
+	if err := q.Check(); err != nil {
+		t.Error(err)
+	}
+
+	q.Disqualify(4, "invalid share")
+	if members := q.Members(); len(members) != 1 || members[0] != 2 {
+		t.Error("wrong members ", members)
+	}
+	if err := q.Check(); !errors.Is(err, ErrNotEnoughQualified) {
+		t.Error("expected error for too few qualified parties, got ", err)
+	}
+}
@@ -0,0 +1,301 @@
+// Package psi implements private set intersection with Paillier encryption
+// [FNP 04]:
+//  1. the receiver, who holds the key, encrypts the coefficients of the
+//     polynomial P(X) = prod_i (X - H(a_i)) whose roots are the hashes of its
+//     set A and sends them in a Request
+//  2. the sender evaluates the encrypted polynomial at the hash of each
+//     element b of its set B with Horner's rule and returns the shuffled
+//     encryptions of r * P(H(b)) + H(b) for random r in a Response
+//  3. the receiver decrypts the response: an evaluation decrypts to the hash
+//     of one of its elements iff the element is in B, and to a random value
+//     otherwise
+//
+// The receiver learns A n B and |B|; the sender learns |A| and nothing else.
+// Both parties are assumed to follow the protocol. The cardinality variants
+// of the protocol are paillier.SetPolynomial.
+//
+//	[FNP 04]: Michael J. Freedman, Kobbi Nissim, Benny Pinkas, (2004)
+//	          Efficient Private Matching and Set Intersection
+package psi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+// hashDomain separates the hashes of the elements from other uses of SHA-256
+const hashDomain = "paillier-psi-element"
+
+// Request is the message of the receiver: its public key and the encrypted
+// coefficients of the polynomial of its set
+type Request struct {
+	Key          *paillier.PublicKey
+	Coefficients []*paillier.Ciphertext // Coefficients[k] encrypts the coefficient of X^k
+}
+
+// Response is the message of the sender: the evaluations at its elements in
+// random order
+type Response struct {
+	Evaluations []*paillier.Ciphertext
+}
+
+// the messages implement encoding.BinaryMarshaler, so gob would call their
+// methods recursively; they are encoded as these plain structs instead
+type (
+	requestGob struct {
+		Key          *paillier.PublicKey
+		Coefficients []*paillier.Ciphertext
+	}
+	responseGob struct {
+		Evaluations []*paillier.Ciphertext
+	}
+)
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (req *Request) MarshalBinary() ([]byte, error) {
+	return gobEncode((*requestGob)(req))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. The
+// request is checked by Sender.Respond.
+func (req *Request) UnmarshalBinary(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode((*requestGob)(req))
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface
+func (resp *Response) MarshalBinary() ([]byte, error) {
+	return gobEncode((*responseGob)(resp))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface
+func (resp *Response) UnmarshalBinary(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode((*responseGob)(resp))
+}
+
+func gobEncode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// protocol states of the parties
+const (
+	stateStart = iota
+	stateRequested
+	stateDone
+)
+
+// Receiver is the party that holds the key and learns the intersection
+type Receiver struct {
+	sk       *paillier.SecretKey
+	elements map[string][]byte // by hash
+	roots    []*gmp.Int
+	state    int
+}
+
+// NewReceiver returns the receiver of the set with the secret key sk.
+// Duplicate elements are removed.
+func NewReceiver(sk *paillier.SecretKey, set [][]byte) (*Receiver, error) {
+	r := &Receiver{sk: sk, elements: make(map[string][]byte, len(set))}
+	for _, element := range set {
+		h := hashElement(&sk.PublicKey, element)
+		key := string(h.Bytes())
+		if _, ok := r.elements[key]; ok {
+			continue
+		}
+		r.elements[key] = element
+		r.roots = append(r.roots, h)
+	}
+
+	if len(r.roots) == 0 {
+		return nil, errors.New("set must not be empty")
+	}
+	return r, nil
+}
+
+// Request returns the request to send to the sender; it can only be called once
+func (r *Receiver) Request() (*Request, error) {
+	if r.state != stateStart {
+		return nil, errors.New("request was already created")
+	}
+
+	pk := &r.sk.PublicKey
+	coefficients := polynomial(r.roots, pk.N)
+	req := &Request{Key: pk, Coefficients: make([]*paillier.Ciphertext, len(coefficients))}
+	for k, c := range coefficients {
+		req.Coefficients[k] = pk.Encrypt(c)
+	}
+
+	r.state = stateRequested
+	return req, nil
+}
+
+// Intersect decrypts the response of the sender and returns the elements of
+// the set of the receiver that are in the set of the sender, in no
+// particular order. It can only be called once, after Request.
+func (r *Receiver) Intersect(resp *Response) ([][]byte, error) {
+	if r.state != stateRequested {
+		return nil, errors.New("intersection requires a single response to the request")
+	}
+	if resp == nil {
+		return nil, errors.New("missing response")
+	}
+	n2 := r.sk.GetN2()
+	for i, ct := range resp.Evaluations {
+		if ct == nil || ct.C == nil || ct.C.Sign() <= 0 || ct.C.Cmp(n2) >= 0 || ct.Level != paillier.EncLevelOne {
+			return nil, fmt.Errorf("%w: evaluation %d", paillier.ErrInvalidCiphertext, i)
+		}
+	}
+	r.state = stateDone
+
+	var intersection [][]byte
+	found := make(map[string]bool)
+	for _, ct := range resp.Evaluations {
+		key := string(r.sk.Decrypt(ct).Bytes())
+		if element, ok := r.elements[key]; ok && !found[key] {
+			found[key] = true
+			intersection = append(intersection, element)
+		}
+	}
+	return intersection, nil
+}
+
+// Sender is the party that evaluates the polynomial of the receiver at its
+// elements
+type Sender struct {
+	set   [][]byte
+	state int
+}
+
+// NewSender returns the sender of the set. Duplicate elements are removed
+// when responding.
+func NewSender(set [][]byte) (*Sender, error) {
+	if len(set) == 0 {
+		return nil, errors.New("set must not be empty")
+	}
+	return &Sender{set: set}, nil
+}
+
+// Respond evaluates the polynomial of the request at the elements of the
+// sender. It can only be called once, since responses to several requests
+// would let the receiver test more elements than it committed to.
+func (s *Sender) Respond(req *Request) (*Response, error) {
+	if s.state != stateStart {
+		return nil, errors.New("sender already responded")
+	}
+	if err := checkRequest(req); err != nil {
+		return nil, err
+	}
+	s.state = stateDone
+
+	pk := req.Key
+	seen := make(map[string]bool, len(s.set))
+	var elements []*gmp.Int
+	for _, element := range s.set {
+		h := hashElement(pk, element)
+		if seen[string(h.Bytes())] {
+			continue
+		}
+		seen[string(h.Bytes())] = true
+		elements = append(elements, h)
+	}
+
+	permutation, err := randomPermutation(len(elements), pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{Evaluations: make([]*paillier.Ciphertext, len(elements))}
+	degree := len(req.Coefficients) - 1
+	for j, b := range elements {
+		// Horner's rule: P(b) = (...(c_d b + c_(d-1)) b + ...) b + c_0
+		value := req.Coefficients[degree]
+		for k := degree - 1; k >= 0; k-- {
+			value = pk.Add(pk.ConstMult(value, b), req.Coefficients[k])
+		}
+
+		r, err := paillier.GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+
+		// r * P(b) + b; the fresh encryption of b rerandomizes the evaluation
+		resp.Evaluations[permutation[j]] = pk.Add(pk.ConstMult(value, r), pk.Encrypt(b))
+	}
+
+	return resp, nil
+}
+
+// checkRequest returns an error if the request is malformed
+func checkRequest(req *Request) error {
+	if req == nil || req.Key == nil || req.Key.N == nil {
+		return errors.New("request is missing the key")
+	}
+	if len(req.Coefficients) < 2 {
+		return errors.New("polynomial must have a positive degree")
+	}
+
+	n2 := req.Key.GetN2()
+	for k, ct := range req.Coefficients {
+		if ct == nil || ct.C == nil || ct.C.Sign() <= 0 || ct.C.Cmp(n2) >= 0 || ct.Level != paillier.EncLevelOne {
+			return fmt.Errorf("%w: coefficient %d", paillier.ErrInvalidCiphertext, k)
+		}
+	}
+	return nil
+}
+
+// polynomial returns the coefficients of prod_i (X - roots[i]) mod n, the
+// coefficient of X^k at index k
+func polynomial(roots []*gmp.Int, n *gmp.Int) []*gmp.Int {
+	coefficients := []*gmp.Int{gmp.NewInt(1)}
+	for _, root := range roots {
+		next := make([]*gmp.Int, len(coefficients)+1)
+		for k := range next {
+			next[k] = new(gmp.Int)
+		}
+		for k, c := range coefficients {
+			next[k+1].Add(next[k+1], c)
+			next[k].Sub(next[k], new(gmp.Int).Mul(c, root))
+		}
+		for _, c := range next {
+			c.Mod(c, n)
+		}
+		coefficients = next
+	}
+	return coefficients
+}
+
+// hashElement returns the hash of the element in Z_N
+func hashElement(pk *paillier.PublicKey, element []byte) *gmp.Int {
+	h := sha256.New()
+	h.Write([]byte(hashDomain))
+	h.Write(element)
+	x := new(gmp.Int).SetBytes(h.Sum(nil))
+	return x.Mod(x, pk.N)
+}
+
+// randomPermutation returns a uniformly random permutation of 0..n-1
+// (Fisher-Yates)
+func randomPermutation(n int, random paillier.RandomSource) ([]int, error) {
+	permutation := make([]int, n)
+	for i := range permutation {
+		permutation[i] = i
+	}
+
+	for i := n - 1; i > 0; i-- {
+		j, err := paillier.GetRandomNumber(gmp.NewInt(int64(i+1)), random)
+		if err != nil {
+			return nil, err
+		}
+		permutation[i], permutation[j.Int64()] = permutation[j.Int64()], permutation[i]
+	}
+	return permutation, nil
+}
@@ -0,0 +1,101 @@
+package psi
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/sachaservan/paillier"
+)
+
+func toSet(elements ...string) [][]byte {
+	set := make([][]byte, len(elements))
+	for i, e := range elements {
+		set[i] = []byte(e)
+	}
+	return set
+}
+
+func TestIntersection(t *testing.T) {
+	sk, _ := paillier.KeyGen(128)
+
+	receiver, err := NewReceiver(sk, toSet("alice", "bob", "carol", "dave", "bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := NewSender(toSet("erin", "carol", "alice", "frank"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages travel serialized between the parties
+	req, err := receiver.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Coefficients) != 5 {
+		t.Errorf("polynomial has %d coefficients, expected 5", len(req.Coefficients))
+	}
+	data, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received Request
+	if err := received.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := sender.Respond(&received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = resp.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	var response Response
+	if err := response.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	intersection, err := receiver.Intersect(&response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range intersection {
+		got = append(got, string(e))
+	}
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "alice" || got[1] != "carol" {
+		t.Errorf("intersection is %v, expected [alice carol]", got)
+	}
+
+	if _, err := receiver.Intersect(&response); err == nil {
+		t.Error("expected an error for a second response")
+	}
+	if _, err := sender.Respond(&received); err == nil {
+		t.Error("expected an error for a second request")
+	}
+	if _, err := receiver.Request(); err == nil {
+		t.Error("expected an error for a second request")
+	}
+}
+
+func TestMalformedRequest(t *testing.T) {
+	sk, _ := paillier.KeyGen(128)
+	sender, err := NewSender(toSet("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &Request{Key: &sk.PublicKey, Coefficients: []*paillier.Ciphertext{sk.Encrypt(paillier.OneBigInt), {C: sk.GetN2()}}}
+	if _, err := sender.Respond(req); err == nil {
+		t.Error("expected an error for a coefficient out of range")
+	}
+	if _, err := sender.Respond(&Request{Key: &sk.PublicKey}); err == nil {
+		t.Error("expected an error for a polynomial without coefficients")
+	}
+
+	if _, err := NewReceiver(sk, nil); err == nil {
+		t.Error("expected an error for an empty set")
+	}
+}
@@ -0,0 +1,51 @@
+package paillier
+
+import (
+	gmp "github.com/ncw/gmp"
+)
+
+// PublicOnly returns a deep copy of the public key of sk. The result contains
+// no secret values and shares no memory with sk, so it can be serialized or
+// handed to other components without leaking the secret key.
+func (sk *SecretKey) PublicOnly() *PublicKey {
+	return sk.PublicKey.deepCopy()
+}
+
+// PublicOnly returns a deep copy of the threshold public key of tsk including
+// all verification keys. The result contains neither the share nor the ID
+// of the server and shares no memory with tsk.
+func (tsk *ThresholdSecretKey) PublicOnly() *ThresholdPublicKey {
+	return tsk.ThresholdPublicKey.deepCopy()
+}
+
+func (tk *ThresholdPublicKey) deepCopy() *ThresholdPublicKey {
+	verificationKeys := make([]*gmp.Int, len(tk.VerificationKeys))
+	for i, vi := range tk.VerificationKeys {
+		verificationKeys[i] = copyInt(vi)
+	}
+
+	return &ThresholdPublicKey{
+		PublicKey:                      *tk.PublicKey.deepCopy(),
+		TotalNumberOfDecryptionServers: tk.TotalNumberOfDecryptionServers,
+		Threshold:                      tk.Threshold,
+		VerificationKey:                copyInt(tk.VerificationKey),
+		VerificationKeys:               verificationKeys,
+		S:                              tk.S,
+	}
+}
+
+func (pk *PublicKey) deepCopy() *PublicKey {
+	return &PublicKey{
+		N: copyInt(pk.N),
+		G: copyInt(pk.G),
+		H: copyInt(pk.H),
+		K: copyInt(pk.K),
+	}
+}
+
+func copyInt(x *gmp.Int) *gmp.Int {
+	if x == nil {
+		return nil
+	}
+	return new(gmp.Int).Set(x)
+}
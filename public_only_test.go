@@ -0,0 +1,46 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/gob"
+	"testing"
+)
+
+func TestPublicOnly(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	public := sk.PublicOnly()
+	if public.N.Cmp(pk.N) != 0 || public.H.Cmp(pk.H) != 0 {
+		t.Fatal("public values were not copied")
+	}
+
+	public.N.Add(public.N, OneBigInt)
+	if sk.N.Cmp(pk.N) != 0 {
+		t.Error("public key shares memory with the secret key")
+	}
+
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tk := tpks[0].PublicOnly()
+	tk.VerificationKeys[0].Add(tk.VerificationKeys[0], OneBigInt)
+	if tk.VerificationKeys[0].Cmp(tpks[0].VerificationKeys[0]) == 0 {
+		t.Error("threshold public key shares memory with the secret key")
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(tpks[1].PublicOnly()); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), tpks[1].Share.Bytes()) {
+		t.Error("encoding of the public key contains the share")
+	}
+}
@@ -0,0 +1,250 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+// bit lengths of the challenge and of the statistical hiding of the proofs
+// of correct share encryption
+const (
+	pvssChallengeBits         = 128
+	pvssStatisticalSecurity   = 128
+	pvssRecipientModulusSlack = pvssChallengeBits + pvssStatisticalSecurity + 2
+)
+
+// PVSSDealing is a publicly verifiable dealing of a threshold key: the share
+// s_i of server i is encrypted to the Paillier key of its recipient and comes
+// with a proof that it is the discrete logarithm of the public verification
+// key V_i = V^(delta*s_i). Together with a check that the verification keys
+// lie on a polynomial of degree Threshold-1, anyone can verify from the
+// dealing alone that the dealer handed out consistent shares.
+//
+// Shares are encrypted at level two and the proofs need room for statistical
+// hiding, so the modulus of every recipient key must be about 130 bits longer
+// than N, e.g., 2304-bit recipient keys for a 2048-bit committee key.
+type PVSSDealing struct {
+	Key             *ThresholdPublicKey
+	EncryptedShares []*Ciphertext
+	Proofs          []*ShareEncryptionProof
+}
+
+// ShareEncryptionProof is a non-interactive proof (Fiat-Shamir heuristic) that
+// a level two ciphertext E = g^s r^(N_i^2) mod N_i^3 under the recipient key
+// encrypts the logarithm s of V_i to the base V^delta mod N^2
+type ShareEncryptionProof struct {
+	E *gmp.Int // the challenge
+	Z *gmp.Int // response for the share
+	W *gmp.Int // response for the randomness of the encryption
+}
+
+// DealPVSS encrypts the shares of the threshold secret keys, ordered by ID,
+// to the recipient keys, recipients[i] receiving the share of server i+1
+func DealPVSS(tsks []*ThresholdSecretKey, recipients []*PublicKey) (*PVSSDealing, error) {
+	if len(tsks) == 0 || len(tsks) != len(recipients) {
+		return nil, errors.New("there must be one recipient key per share")
+	}
+
+	dealing := &PVSSDealing{
+		Key:             tsks[0].PublicOnly(),
+		EncryptedShares: make([]*Ciphertext, len(tsks)),
+		Proofs:          make([]*ShareEncryptionProof, len(tsks)),
+	}
+
+	for i, tsk := range tsks {
+		if tsk.ID != i+1 {
+			return nil, errors.New("threshold secret keys must be ordered by ID")
+		}
+
+		recipient := recipients[i]
+		if err := dealing.checkRecipient(recipient); err != nil {
+			return nil, fmt.Errorf("recipient %d: %w", tsk.ID, err)
+		}
+
+		r, err := GetRandomNumberInMultiplicativeGroup(recipient.N, recipient.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+
+		dealing.EncryptedShares[i] = recipient.EncryptWithRAtLevel(tsk.Share, r, EncLevelTwo)
+		dealing.Proofs[i], err = dealing.proveShareEncryption(tsk.ID, recipient, tsk.Share, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dealing, nil
+}
+
+// Verify checks the dealing against the recipient keys: every encrypted share
+// has a valid proof and the verification keys are the evaluations of a
+// polynomial of degree Threshold-1 in the exponent
+func (d *PVSSDealing) Verify(recipients []*PublicKey) error {
+	tk := d.Key
+	n := tk.TotalNumberOfDecryptionServers
+	if tk.Threshold < 1 || tk.Threshold > n || len(tk.VerificationKeys) != n {
+		return errors.New("invalid threshold parameters")
+	}
+
+	if len(d.EncryptedShares) != n || len(d.Proofs) != n || len(recipients) != n {
+		return errors.New("there must be one encrypted share, proof and recipient key per server")
+	}
+
+	for i := range d.EncryptedShares {
+		if err := d.verifyShareEncryption(i+1, recipients[i]); err != nil {
+			return fmt.Errorf("share %d: %w", i+1, err)
+		}
+	}
+
+	// V_i^delta = prod_{j <= t} V_j^lambda_j(i) for the servers i > t
+	xs := make([]int, tk.Threshold)
+	for j := range xs {
+		xs[j] = j + 1
+	}
+	for i := tk.Threshold + 1; i <= n; i++ {
+		expected := gmp.NewInt(1)
+		for _, j := range xs {
+			lambda := shamir.LagrangeCoefficientAt(j, i, xs, tk.delta())
+			expected.Mul(expected, tk.exp(tk.VerificationKeys[j-1], lambda, tk.GetN2()))
+			expected.Mod(expected, tk.GetN2())
+		}
+
+		actual := new(gmp.Int).Exp(tk.VerificationKeys[i-1], tk.delta(), tk.GetN2())
+		if actual.Cmp(expected) != 0 {
+			return fmt.Errorf("verification key %d is not on the sharing polynomial", i)
+		}
+	}
+
+	return nil
+}
+
+// DecryptShare decrypts the share of server id with the secret key of its
+// recipient and returns the threshold secret key of the server
+func (d *PVSSDealing) DecryptShare(id int, sk *SecretKey) (*ThresholdSecretKey, error) {
+	if id < 1 || id > len(d.EncryptedShares) || id > len(d.Key.VerificationKeys) {
+		return nil, errors.New("share ID is out of range")
+	}
+
+	share := sk.Decrypt(d.EncryptedShares[id-1])
+
+	tk := d.Key
+	vi := new(gmp.Int).Exp(d.base(), share, tk.GetN2())
+	if vi.Cmp(tk.VerificationKeys[id-1]) != 0 {
+		return nil, errors.New("decrypted share does not match the verification key")
+	}
+
+	tsk := &ThresholdSecretKey{
+		ThresholdPublicKey: *tk.deepCopy(),
+		ID:                 id,
+		Share:              share,
+	}
+	return tsk, nil
+}
+
+// returns V^delta mod N^2, the base of the verification keys
+func (d *PVSSDealing) base() *gmp.Int {
+	return new(gmp.Int).Exp(d.Key.VerificationKey, d.Key.delta(), d.Key.GetN2())
+}
+
+// the plaintext space N_i^2 of the recipient must hold the responses
+func (d *PVSSDealing) checkRecipient(recipient *PublicKey) error {
+	if recipient == nil || recipient.N == nil {
+		return errors.New("missing recipient key")
+	}
+
+	if 2*recipient.N.BitLen() < d.Key.GetN2().BitLen()+pvssRecipientModulusSlack+2 {
+		return errors.New("recipient modulus is too short for the committee modulus")
+	}
+
+	return nil
+}
+
+// proves that the level two encryption of share with randomness r under the
+// recipient key encrypts the logarithm of V_id
+func (d *PVSSDealing) proveShareEncryption(id int, recipient *PublicKey, share, r *gmp.Int) (*ShareEncryptionProof, error) {
+	n2 := d.Key.GetN2()
+
+	// rho hides e*share statistically
+	bound := new(gmp.Int).Lsh(OneBigInt, uint(n2.BitLen()+pvssRecipientModulusSlack-2))
+	rho, err := GetRandomNumber(bound, recipient.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+	omega, err := GetRandomNumberInMultiplicativeGroup(recipient.N, recipient.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	a := recipient.EncryptWithRAtLevel(rho, omega, EncLevelTwo).C
+	b := new(gmp.Int).Exp(d.base(), rho, n2)
+
+	e := d.shareEncryptionChallenge(id, recipient, a, b)
+
+	// z = rho + e*share over the integers and w = omega * r^e mod N_i
+	z := new(gmp.Int).Mul(e, share)
+	z.Add(z, rho)
+	w := new(gmp.Int).Exp(r, e, recipient.N)
+	w.Mul(w, omega)
+	w.Mod(w, recipient.N)
+
+	return &ShareEncryptionProof{E: e, Z: z, W: w}, nil
+}
+
+func (d *PVSSDealing) verifyShareEncryption(id int, recipient *PublicKey) error {
+	if err := d.checkRecipient(recipient); err != nil {
+		return err
+	}
+
+	proof := d.Proofs[id-1]
+	ct := d.EncryptedShares[id-1]
+	if proof == nil || proof.E == nil || proof.Z == nil || proof.W == nil || ct == nil || ct.C == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if ct.Level != EncLevelTwo {
+		return fmt.Errorf("%w: share must be encrypted at level two", ErrMalformedProof)
+	}
+
+	n3 := recipient.GetN3()
+	n2 := d.Key.GetN2()
+	if proof.Z.Sign() < 0 || proof.Z.BitLen() > n2.BitLen()+pvssRecipientModulusSlack {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+	if ct.C.Sign() <= 0 || ct.C.Cmp(n3) >= 0 || new(gmp.Int).GCD(nil, nil, ct.C, recipient.N).Cmp(OneBigInt) != 0 {
+		return fmt.Errorf("%w: encrypted share is not a unit", ErrMalformedProof)
+	}
+	if proof.W.Sign() <= 0 || proof.W.Cmp(recipient.N) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+
+	// a = Enc(z; w) / E^e mod N_i^3 and b = (V^delta)^z / V_i^e mod N^2
+	a := new(gmp.Int).Exp(ct.C, proof.E, n3)
+	a.ModInverse(a, n3)
+	a.Mul(a, recipient.EncryptWithRAtLevel(proof.Z, proof.W, EncLevelTwo).C)
+	a.Mod(a, n3)
+
+	vi := d.Key.VerificationKeys[id-1]
+	if vi.Sign() <= 0 || vi.Cmp(n2) >= 0 || new(gmp.Int).GCD(nil, nil, vi, d.Key.N).Cmp(OneBigInt) != 0 {
+		return fmt.Errorf("%w: verification key is not a unit", ErrMalformedProof)
+	}
+	b := new(gmp.Int).Exp(vi, proof.E, n2)
+	b.ModInverse(b, n2)
+	b.Mul(b, new(gmp.Int).Exp(d.base(), proof.Z, n2))
+	b.Mod(b, n2)
+
+	if proof.E.Cmp(d.shareEncryptionChallenge(id, recipient, a, b)) != 0 {
+		return ErrChallengeMismatch
+	}
+
+	return nil
+}
+
+func (d *PVSSDealing) shareEncryptionChallenge(id int, recipient *PublicKey, a, b *gmp.Int) *gmp.Int {
+	tk := d.Key
+	return RandomOracleChallenge(pvssChallengeBits,
+		tk.N, tk.VerificationKey, tk.VerificationKeys[id-1], gmp.NewInt(int64(id)),
+		recipient.N, d.EncryptedShares[id-1].C, a, b)
+}
@@ -0,0 +1,90 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestPVSSDealing(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 4, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sks []*SecretKey
+	var recipients []*PublicKey
+	for range tsks {
+		sk, pk := KeyGen(256)
+		sks = append(sks, sk)
+		recipients = append(recipients, pk)
+	}
+
+	dealing, err := DealPVSS(tsks, recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dealing.Verify(recipients); err != nil {
+		t.Fatal(err)
+	}
+
+	// the recipients recover working threshold keys
+	key := &tsks[0].ThresholdPublicKey
+	ct := key.Encrypt(gmp.NewInt(55))
+	var shares []*PartialDecryptionZKP
+	for _, id := range []int{2, 4} {
+		tsk, err := dealing.DecryptShare(id, sks[id-1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, err := tsk.PartialDecryptionWithZKP(ct.C)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, share)
+	}
+	if m, err := key.CombinePartialDecryptionsZKP(shares); err != nil || m.Int64() != 55 {
+		t.Error("wrong decryption ", m, err)
+	}
+
+	if _, err := dealing.DecryptShare(1, sks[1]); err == nil {
+		t.Error("expected error for share decrypted with the wrong key")
+	}
+
+	// a share encrypted to the wrong recipient is detected
+	swapped := *dealing
+	swapped.EncryptedShares = append([]*Ciphertext{}, dealing.EncryptedShares...)
+	swapped.EncryptedShares[0] = recipients[0].EncryptAtLevel(tsks[1].Share, EncLevelTwo)
+	if err := swapped.Verify(recipients); !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected challenge mismatch for wrong share, got ", err)
+	}
+
+	// a dealer that hands out a share that is not on the polynomial is detected
+	// even though the share matches its verification key
+	cheat := append([]*ThresholdSecretKey{}, tsks...)
+	forged := *tsks[3]
+	forged.Share = new(gmp.Int).Add(tsks[3].Share, OneBigInt)
+	forged.VerificationKeys = append([]*gmp.Int{}, tsks[3].VerificationKeys...)
+	forged.VerificationKeys[3] = new(gmp.Int).Exp(dealing.base(), forged.Share, key.GetN2())
+	cheat[0] = &ThresholdSecretKey{ThresholdPublicKey: *forged.ThresholdPublicKey.deepCopy(), ID: 1, Share: tsks[0].Share}
+	cheat[3] = &forged
+	cheating, err := DealPVSS(cheat, recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cheating.Verify(recipients); err == nil || errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected error for share off the polynomial, got ", err)
+	}
+
+	_, small := KeyGen(64)
+	if _, err := DealPVSS(tsks, []*PublicKey{small, small, small, small}); err == nil {
+		t.Error("expected error for short recipient keys")
+	}
+}
@@ -0,0 +1,255 @@
+package paillier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// The Python* functions interoperate with the python-paillier library (phe).
+// phe uses the same ciphertexts as this package (g = N+1, level one), but
+// represents plaintexts as EncodedNumbers: a signed mantissa together with an
+// exponent such that value = mantissa * 16^exponent. Mantissas are encoded in
+// Z_N where values above N - PythonMaxInt() are negative, and encrypted numbers
+// carry their exponent in the clear. Homomorphic operations on encrypted numbers
+// with different exponents first lower the larger exponent, exactly as phe does,
+// so results can be decrypted on either side.
+//
+// The wire formats are those of the phe command line tools: public keys are JSON
+// Web Keys with kty "DAJ" and encrypted numbers are JSON objects with the
+// decimal ciphertext "v" and the exponent "e".
+
+// PythonBase is the base of the exponent of phe encoded numbers
+const PythonBase = 16
+
+// pythonFloatMantissaBits is the precision of an IEEE 754 double
+const pythonFloatMantissaBits = 53
+
+// PythonEncoding is the equivalent of phe.EncodedNumber
+type PythonEncoding struct {
+	Encoding *gmp.Int // mantissa in Z_N
+	Exponent int
+}
+
+// PythonEncryptedNumber is the equivalent of phe.EncryptedNumber
+type PythonEncryptedNumber struct {
+	Ciphertext *Ciphertext
+	Exponent   int
+}
+
+type pythonEncryptedNumberJSON struct {
+	V string `json:"v"`
+	E int    `json:"e"`
+}
+
+type pythonPublicKeyJWK struct {
+	Kty    string   `json:"kty"`
+	Alg    string   `json:"alg"`
+	KeyOps []string `json:"key_ops"`
+	N      string   `json:"n"`
+	Kid    string   `json:"kid,omitempty"`
+}
+
+// PythonMaxInt returns the largest absolute mantissa, floor(N/3) - 1
+func (pk *PublicKey) PythonMaxInt() *big.Int {
+	max := new(big.Int).Quo(ToBigInt(pk.N), big.NewInt(3))
+	return max.Sub(max, big.NewInt(1))
+}
+
+// PythonEncodeInt encodes an integer with exponent zero
+func (pk *PublicKey) PythonEncodeInt(x *big.Int) (*PythonEncoding, error) {
+	return pk.pythonEncodeMantissa(x, 0)
+}
+
+// PythonEncodeFloat encodes a float with the precision phe uses by default,
+// which represents the float exactly
+func (pk *PublicKey) PythonEncodeFloat(x float64) (*PythonEncoding, error) {
+	return pk.pythonEncodeFloat(x, math.MaxInt32)
+}
+
+// pythonEncodeFloat encodes x with an exponent of at most maxExponent, see the
+// max_exponent argument of phe.EncodedNumber.encode
+func (pk *PublicKey) pythonEncodeFloat(x float64, maxExponent int) (*PythonEncoding, error) {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return nil, errors.New("cannot encode infinity or NaN")
+	}
+
+	_, binExponent := math.Frexp(x)
+	// the log2 of the base is 4, so the exponent is floor((e - 53) / 4)
+	exponent := floorDiv(binExponent-pythonFloatMantissaBits, 4)
+	if exponent > maxExponent {
+		exponent = maxExponent
+	}
+
+	// x * 16^-exponent is an integer since exponent is at most the exponent
+	// of the least significant bit of x divided by 4
+	mantissa, accuracy := new(big.Float).SetMantExp(big.NewFloat(x), -4*exponent).Int(nil)
+	if accuracy != big.Exact {
+		return nil, errors.New("float cannot be encoded exactly")
+	}
+
+	return pk.pythonEncodeMantissa(mantissa, exponent)
+}
+
+// PythonDecode returns the exact value of the encoding
+func (pk *PublicKey) PythonDecode(e *PythonEncoding) (*big.Rat, error) {
+	n := ToBigInt(pk.N)
+	encoding := ToBigInt(e.Encoding)
+	maxInt := pk.PythonMaxInt()
+
+	var mantissa *big.Int
+	switch {
+	case encoding.Cmp(n) >= 0:
+		return nil, errors.New("attempted to decode corrupted number")
+	case encoding.Cmp(maxInt) <= 0:
+		mantissa = encoding
+	case encoding.Cmp(new(big.Int).Sub(n, maxInt)) >= 0:
+		mantissa = encoding.Sub(encoding, n)
+	default:
+		return nil, errors.New("overflow detected in decoded number")
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(PythonBase), big.NewInt(int64(absInt(e.Exponent))), nil)
+	if e.Exponent >= 0 {
+		return new(big.Rat).SetInt(mantissa.Mul(mantissa, scale)), nil
+	}
+	return new(big.Rat).SetFrac(mantissa, scale), nil
+}
+
+// PythonDecodeFloat returns the value of the encoding rounded to a float
+func (pk *PublicKey) PythonDecodeFloat(e *PythonEncoding) (float64, error) {
+	value, err := pk.PythonDecode(e)
+	if err != nil {
+		return 0, err
+	}
+	f, _ := value.Float64()
+	return f, nil
+}
+
+// PythonEncrypt encrypts the encoding
+func (pk *PublicKey) PythonEncrypt(e *PythonEncoding) *PythonEncryptedNumber {
+	return &PythonEncryptedNumber{Ciphertext: pk.Encrypt(e.Encoding), Exponent: e.Exponent}
+}
+
+// PythonDecrypt decrypts an encrypted number to its encoding
+func (sk *SecretKey) PythonDecrypt(x *PythonEncryptedNumber) *PythonEncoding {
+	return &PythonEncoding{Encoding: sk.Decrypt(x.Ciphertext), Exponent: x.Exponent}
+}
+
+// PythonAdd homomorphically adds encrypted numbers
+func (pk *PublicKey) PythonAdd(a, b *PythonEncryptedNumber) *PythonEncryptedNumber {
+	a, b = pk.pythonAlign(a, b)
+	return &PythonEncryptedNumber{Ciphertext: pk.Add(a.Ciphertext, b.Ciphertext), Exponent: a.Exponent}
+}
+
+// PythonMul homomorphically multiplies an encrypted number by a plaintext encoding
+func (pk *PublicKey) PythonMul(a *PythonEncryptedNumber, e *PythonEncoding) *PythonEncryptedNumber {
+	return &PythonEncryptedNumber{
+		Ciphertext: pk.ConstMult(a.Ciphertext, e.Encoding),
+		Exponent:   a.Exponent + e.Exponent,
+	}
+}
+
+// PythonJWK returns the public key as a phe JSON Web Key
+func (pk *PublicKey) PythonJWK() ([]byte, error) {
+	return json.Marshal(&pythonPublicKeyJWK{
+		Kty:    "DAJ",
+		Alg:    "PAI-GN1",
+		KeyOps: []string{"encrypt"},
+		N:      encodeJSONInt(pk.N),
+	})
+}
+
+// ParsePythonJWK parses a public key exported as a phe JSON Web Key
+func ParsePythonJWK(data []byte) (*PublicKey, error) {
+	var v pythonPublicKeyJWK
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v.Kty != "DAJ" {
+		return nil, fmt.Errorf("unsupported key type %q", v.Kty)
+	}
+
+	n, err := decodeJSONInt(v.N)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, errors.New("public key is missing N")
+	}
+
+	return &PublicKey{N: n, G: new(gmp.Int).Add(n, OneBigInt)}, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (x *PythonEncryptedNumber) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&pythonEncryptedNumberJSON{V: x.Ciphertext.C.String(), E: x.Exponent})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (x *PythonEncryptedNumber) UnmarshalJSON(data []byte) error {
+	var v pythonEncryptedNumberJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	c, ok := new(gmp.Int).SetString(v.V, 10)
+	if !ok || c.Sign() <= 0 {
+		return errors.New("invalid ciphertext")
+	}
+
+	*x = PythonEncryptedNumber{
+		Ciphertext: &Ciphertext{C: c, Level: EncLevelOne, EncMethod: RegularEncryption},
+		Exponent:   v.E,
+	}
+	return nil
+}
+
+func (pk *PublicKey) pythonEncodeMantissa(mantissa *big.Int, exponent int) (*PythonEncoding, error) {
+	if new(big.Int).Abs(mantissa).Cmp(pk.PythonMaxInt()) > 0 {
+		return nil, errors.New("value is too large to encode")
+	}
+
+	encoding := new(big.Int).Mod(mantissa, ToBigInt(pk.N))
+	return &PythonEncoding{Encoding: ToGmpInt(encoding), Exponent: exponent}, nil
+}
+
+// pythonAlign lowers the exponent of the number with the larger exponent to
+// the smaller one
+func (pk *PublicKey) pythonAlign(a, b *PythonEncryptedNumber) (*PythonEncryptedNumber, *PythonEncryptedNumber) {
+	if a.Exponent < b.Exponent {
+		return a, pk.pythonLowerExponent(b, a.Exponent)
+	}
+	return pk.pythonLowerExponent(a, b.Exponent), b
+}
+
+// pythonLowerExponent returns x with an exponent of at most exponent, see
+// phe.EncryptedNumber.decrease_exponent_to
+func (pk *PublicKey) pythonLowerExponent(x *PythonEncryptedNumber, exponent int) *PythonEncryptedNumber {
+	if x.Exponent <= exponent {
+		return x
+	}
+
+	factor := new(gmp.Int).Exp(gmp.NewInt(PythonBase), gmp.NewInt(int64(x.Exponent-exponent)), pk.N)
+	return &PythonEncryptedNumber{Ciphertext: pk.ConstMult(x.Ciphertext, factor), Exponent: exponent}
+}
+
+// floorDiv returns floor(a / b) for b > 0
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
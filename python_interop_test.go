@@ -0,0 +1,100 @@
+package paillier
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestPythonEncoding(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	// encodings computed with phe.EncodedNumber.encode
+	tests := []struct {
+		value    float64
+		encoding string
+		exponent int
+	}{
+		{0.5, "36028797018963968", -14},
+		{1.5, "6755399441055744", -13},
+		{-0.5, "-36028797018963968", -14},
+		{0, "0", -14},
+	}
+
+	for _, test := range tests {
+		e, err := pk.PythonEncodeFloat(test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, _ := new(big.Int).SetString(test.encoding, 10)
+		expected.Mod(expected, ToBigInt(pk.N))
+		if ToBigInt(e.Encoding).Cmp(expected) != 0 || e.Exponent != test.exponent {
+			t.Errorf("wrong encoding of %v: %v * 16^%d", test.value, e.Encoding, e.Exponent)
+		}
+
+		if f, err := pk.PythonDecodeFloat(e); err != nil || f != test.value {
+			t.Errorf("decoded %v instead of %v (%v)", f, test.value, err)
+		}
+	}
+
+	if _, err := pk.PythonEncodeInt(new(big.Int).Add(pk.PythonMaxInt(), big.NewInt(1))); err == nil {
+		t.Error("too large integer was encoded")
+	}
+
+	overflow := &PythonEncoding{Encoding: ToGmpInt(new(big.Int).Quo(ToBigInt(pk.N), big.NewInt(2)))}
+	if _, err := pk.PythonDecode(overflow); err == nil {
+		t.Error("overflow was not detected")
+	}
+}
+
+func TestPythonHomomorphism(t *testing.T) {
+	sk, pk := KeyGen(256)
+
+	a, _ := pk.PythonEncodeFloat(3.25)
+	b, _ := pk.PythonEncodeInt(big.NewInt(-7))
+	k, _ := pk.PythonEncodeFloat(0.5)
+
+	// (3.25 + -7) * 0.5 with different exponents on every operand
+	sum := pk.PythonAdd(pk.PythonEncrypt(a), pk.PythonEncrypt(b))
+	product := pk.PythonMul(sum, k)
+
+	value, err := pk.PythonDecode(sk.PythonDecrypt(product))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Cmp(big.NewRat(-15, 8)) != 0 {
+		t.Error("wrong result ", value)
+	}
+}
+
+func TestPythonWireFormat(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	jwk, err := pk.PythonJWK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePythonJWK(jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, _ := parsed.PythonEncodeFloat(-2.75)
+	data, err := json.Marshal(parsed.PythonEncrypt(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var x PythonEncryptedNumber
+	if err := json.Unmarshal(data, &x); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := pk.PythonDecodeFloat(sk.PythonDecrypt(&x)); err != nil || f != -2.75 {
+		t.Error("wrong decryption ", f, err)
+	}
+
+	if _, err := ParsePythonJWK([]byte(`{"kty":"RSA","n":"AQAB"}`)); err == nil {
+		t.Error("RSA key was accepted")
+	}
+}
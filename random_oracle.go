@@ -30,3 +30,35 @@ func RandomOracleDigest(values ...*gmp.Int) []byte {
 	res := sha256.Sum256(hashData)
 	return res[:]
 }
+
+// RandomOracleChallenge hashes the input values to a challenge of the given
+// bit length (at most 256 bits) for use in Fiat-Shamir transformed proofs.
+// Each value is length-prefixed so that distinct inputs cannot collide
+// by concatenation.
+func RandomOracleChallenge(bits int, values ...*gmp.Int) *gmp.Int {
+
+	hash := sha256.New()
+	for _, v := range values {
+		b := v.Bytes()
+		hash.Write([]byte{byte(len(b) >> 24), byte(len(b) >> 16), byte(len(b) >> 8), byte(len(b))})
+		hash.Write(b)
+	}
+
+	e := new(gmp.Int).SetBytes(hash.Sum(nil))
+	if bits < 256 {
+		e.Rsh(e, uint(256-bits))
+	}
+
+	return e
+}
+
+// challengeBitLength returns the bit length of Fiat-Shamir challenges for
+// proofs over the key: challenges must be smaller than the prime factors of N
+// for the proofs to be sound.
+func (pk *PublicKey) challengeBitLength() int {
+	bits := pk.N.BitLen()/2 - 1
+	if bits > 256 {
+		bits = 256
+	}
+	return bits
+}
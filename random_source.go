@@ -0,0 +1,247 @@
+package paillier
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"sync"
+)
+
+// RandomSource is a source of cryptographically secure random bytes.
+// Name identifies the source in audit logs.
+type RandomSource interface {
+	Read(p []byte) (int, error)
+	Name() string
+}
+
+// SystemRandom draws randomness from crypto/rand
+type SystemRandom struct{}
+
+// Read implements io.Reader
+func (SystemRandom) Read(p []byte) (int, error) {
+	return rand.Read(p)
+}
+
+// Name implements RandomSource
+func (SystemRandom) Name() string {
+	return "crypto/rand"
+}
+
+// DefaultRandomSource is used by keys without their own random source
+var DefaultRandomSource RandomSource = SystemRandom{}
+
+// SetRandomSource selects the random source used by all operations of the
+// key, e.g., encryption, randomization and proofs. Passing nil restores
+// DefaultRandomSource.
+func (pk *PublicKey) SetRandomSource(random RandomSource) {
+	pk.random = random
+}
+
+// WithRandomSource returns a shallow copy of the key that uses random for its
+// operations, which allows selecting the random source per operation:
+//
+//	ct := pk.WithRandomSource(drbg).Encrypt(m)
+func (pk *PublicKey) WithRandomSource(random RandomSource) *PublicKey {
+	copy := *pk
+	copy.random = random
+	return &copy
+}
+
+// RandomSource returns the random source used by the key
+func (pk *PublicKey) RandomSource() RandomSource {
+	if pk.random == nil {
+		return DefaultRandomSource
+	}
+	return pk.random
+}
+
+// HSMRandomGenerator is implemented by hardware security module sessions that
+// can generate random bytes, e.g., wrapping PKCS #11 C_GenerateRandom
+type HSMRandomGenerator interface {
+	GenerateRandom(length int) ([]byte, error)
+}
+
+// HSMRandomSource draws randomness from a hardware security module
+type HSMRandomSource struct {
+	HSM   HSMRandomGenerator
+	Label string // identifies the HSM in audit logs
+}
+
+// NewHSMRandomSource returns a random source backed by the HSM session
+func NewHSMRandomSource(hsm HSMRandomGenerator, label string) *HSMRandomSource {
+	return &HSMRandomSource{
+		HSM:   hsm,
+		Label: label,
+	}
+}
+
+// Read implements io.Reader
+func (h *HSMRandomSource) Read(p []byte) (int, error) {
+	buf, err := h.HSM.GenerateRandom(len(p))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(buf) != len(p) {
+		return 0, errors.New("HSM returned the wrong number of random bytes")
+	}
+
+	return copy(p, buf), nil
+}
+
+// Name implements RandomSource
+func (h *HSMRandomSource) Name() string {
+	return "hsm:" + h.Label
+}
+
+const (
+	ctrDRBGKeyLength   = 32
+	ctrDRBGSeedLength  = ctrDRBGKeyLength + aes.BlockSize
+	ctrDRBGMaxRequest  = 1 << 16 // 2^19 bits
+	ctrDRBGReseedLimit = 1 << 48
+)
+
+// CTRDRBG is the deterministic random bit generator CTR_DRBG of
+// NIST SP 800-90A instantiated with AES-256 and without derivation function.
+// It is seedable, which makes it suitable for reproducible tests and for
+// deployments that must document the source of their randomness;
+// the entropy input must come from an approved entropy source.
+type CTRDRBG struct {
+	mutex   sync.Mutex
+	block   cipher.Block
+	v       [aes.BlockSize]byte
+	counter uint64
+}
+
+// NewCTRDRBG instantiates the generator from 48 bytes of entropy input and
+// an optional personalization string of at most 48 bytes
+func NewCTRDRBG(entropy, personalization []byte) (*CTRDRBG, error) {
+	seed, err := ctrDRBGSeedMaterial(entropy, personalization)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &CTRDRBG{}
+	d.block, _ = aes.NewCipher(make([]byte, ctrDRBGKeyLength))
+	d.update(seed)
+	d.counter = 1
+	return d, nil
+}
+
+// NewDeterministicReader returns a CTR_DRBG whose entropy input is the
+// SHA-384 digest of seed. Its output only depends on the seed, which makes
+// key generation (KeyGenWithRandom, NewThresholdKeyGenerator), encryption and
+// proofs (PublicKey.WithRandomSource) reproducible in tests and regression
+// fixtures. It must never be used to generate production keys.
+func NewDeterministicReader(seed []byte) *CTRDRBG {
+	entropy := sha512.Sum384(seed)
+	d, err := NewCTRDRBG(entropy[:], []byte("paillier deterministic reader"))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Reseed mixes 48 bytes of fresh entropy input and optional additional
+// input of at most 48 bytes into the state of the generator
+func (d *CTRDRBG) Reseed(entropy, additional []byte) error {
+	seed, err := ctrDRBGSeedMaterial(entropy, additional)
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.update(seed)
+	d.counter = 1
+	return nil
+}
+
+// Read implements io.Reader. Requests larger than the maximum number of
+// bytes per request of the standard are served by several generate calls.
+func (d *CTRDRBG) Read(p []byte) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for n := 0; n < len(p); n += ctrDRBGMaxRequest {
+		end := n + ctrDRBGMaxRequest
+		if end > len(p) {
+			end = len(p)
+		}
+
+		if err := d.generate(p[n:end]); err != nil {
+			return n, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Name implements RandomSource
+func (d *CTRDRBG) Name() string {
+	return "ctr-drbg-aes256"
+}
+
+func (d *CTRDRBG) generate(out []byte) error {
+	if d.counter > ctrDRBGReseedLimit {
+		return errors.New("CTR_DRBG must be reseeded")
+	}
+
+	var block [aes.BlockSize]byte
+	for n := 0; n < len(out); n += aes.BlockSize {
+		d.incrementV()
+		d.block.Encrypt(block[:], d.v[:])
+		copy(out[n:], block[:])
+	}
+
+	d.update(make([]byte, ctrDRBGSeedLength))
+	d.counter++
+	return nil
+}
+
+// update is the CTR_DRBG_Update function of SP 800-90A, section 10.2.1.2
+func (d *CTRDRBG) update(provided []byte) {
+	temp := make([]byte, ctrDRBGSeedLength)
+	for n := 0; n < ctrDRBGSeedLength; n += aes.BlockSize {
+		d.incrementV()
+		d.block.Encrypt(temp[n:], d.v[:])
+	}
+
+	for i := range temp {
+		temp[i] ^= provided[i]
+	}
+
+	d.block, _ = aes.NewCipher(temp[:ctrDRBGKeyLength])
+	copy(d.v[:], temp[ctrDRBGKeyLength:])
+}
+
+func (d *CTRDRBG) incrementV() {
+	for i := len(d.v) - 1; i >= 0; i-- {
+		d.v[i]++
+		if d.v[i] != 0 {
+			return
+		}
+	}
+}
+
+// returns entropy XOR input where input is padded with zeros to the seed length
+func ctrDRBGSeedMaterial(entropy, input []byte) ([]byte, error) {
+	if len(entropy) != ctrDRBGSeedLength {
+		return nil, errors.New("CTR_DRBG entropy input must be 48 bytes")
+	}
+
+	if len(input) > ctrDRBGSeedLength {
+		return nil, errors.New("CTR_DRBG input must be at most 48 bytes")
+	}
+
+	seed := make([]byte, ctrDRBGSeedLength)
+	copy(seed, entropy)
+	for i, x := range input {
+		seed[i] ^= x
+	}
+
+	return seed, nil
+}
@@ -0,0 +1,216 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+type fakeHSM struct {
+	fail bool
+}
+
+func (h *fakeHSM) GenerateRandom(length int) ([]byte, error) {
+	if h.fail {
+		return nil, errors.New("HSM unavailable")
+	}
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	return buf, err
+}
+
+func newTestDRBG(t *testing.T, seed byte) *CTRDRBG {
+	drbg, err := NewCTRDRBG(bytes.Repeat([]byte{seed}, 48), []byte("paillier test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return drbg
+}
+
+func TestCTRDRBG(t *testing.T) {
+	out1 := make([]byte, 100000)
+	out2 := make([]byte, 100000)
+
+	if _, err := newTestDRBG(t, 1).Read(out1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestDRBG(t, 1).Read(out2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out1, out2) {
+		t.Error("generator is not deterministic")
+	}
+
+	drbg := newTestDRBG(t, 1)
+	if err := drbg.Reseed(bytes.Repeat([]byte{2}, 48), nil); err != nil {
+		t.Fatal(err)
+	}
+	drbg.Read(out2)
+	if bytes.Equal(out1, out2) {
+		t.Error("reseeding did not change the output")
+	}
+
+	if bytes.Equal(out1[:16], out1[16:32]) {
+		t.Error("consecutive blocks are equal")
+	}
+
+	if _, err := NewCTRDRBG(make([]byte, 32), nil); err == nil {
+		t.Error("expected error for short entropy input")
+	}
+}
+
+func TestRandomSourcePerKey(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	if pk.RandomSource().Name() != "crypto/rand" {
+		t.Error("wrong default random source")
+	}
+
+	ct1 := pk.WithRandomSource(newTestDRBG(t, 3)).Encrypt(b(5))
+	pk.SetRandomSource(newTestDRBG(t, 3))
+	ct2 := pk.Encrypt(b(5))
+
+	if ct1.C.Cmp(ct2.C) != 0 {
+		t.Error("encryptions with the same seed differ")
+	}
+
+	if m := sk.Decrypt(ct2); n(m) != 5 {
+		t.Error("wrong decryption ", m, " is not 5")
+	}
+
+	hsm := NewHSMRandomSource(&fakeHSM{}, "test")
+	if hsm.Name() != "hsm:test" {
+		t.Error("wrong HSM source name")
+	}
+	if _, _, err := pk.WithRandomSource(hsm).EncryptBitWithProof(1); err != nil {
+		t.Error(err)
+	}
+
+	hsm.HSM = &fakeHSM{fail: true}
+	if _, _, err := pk.WithRandomSource(hsm).Encapsulate(16); err == nil {
+		t.Error("expected error when the HSM fails")
+	}
+}
+
+func TestDeterministicReader(t *testing.T) {
+	sk1, pk1, err := KeyGenWithRandom(128, NewDeterministicReader([]byte("fixture")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk2, pk2, err := KeyGenWithRandom(128, NewDeterministicReader([]byte("fixture")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pk1.N.Cmp(pk2.N) != 0 || pk1.H.Cmp(pk2.H) != 0 || sk1.Lambda.Cmp(sk2.Lambda) != 0 {
+		t.Fatal("keys generated from the same seed differ")
+	}
+
+	ct1 := pk1.WithRandomSource(NewDeterministicReader([]byte("encryption"))).Encrypt(b(9))
+	ct2 := pk2.WithRandomSource(NewDeterministicReader([]byte("encryption"))).Encrypt(b(9))
+	if ct1.C.Cmp(ct2.C) != 0 {
+		t.Error("encryptions with the same seed differ")
+	}
+
+	shares := make([][]*ThresholdSecretKey, 2)
+	for i := range shares {
+		tkh, err := NewThresholdKeyGenerator(64, 3, 2, NewDeterministicReader([]byte("committee")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tkh.InsecureAllowSmallKeys = true
+		if shares[i], err = tkh.GenerateKeys(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := range shares[0] {
+		if shares[0][i].Share.Cmp(shares[1][i].Share) != 0 {
+			t.Error("threshold keys generated from the same seed differ")
+		}
+	}
+
+	if _, _, err := KeyGenWithRandom(63, rand.Reader); err == nil {
+		t.Error("expected error for odd security parameter")
+	}
+}
+
+// known answers of key generation from fixed seeds; a change of these values
+// breaks the fixtures of all users of KeyGenFromSeed and deterministic readers
+func TestKeyGenKnownAnswers(t *testing.T) {
+	sk, pk, err := KeyGenFromSeed(256, []byte("paillier known answer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHex(t, "N", pk.N, "d0badc11620b97c3862ac4ceadc301dd289916b2d59f36aab4414524d8d8e8dd")
+	expectHex(t, "H", pk.H, "4c189d2f8264e5798c9b9c79f0da3502796e1e4c6b82ef2bf7362a32bdb3f697")
+	expectHex(t, "Lambda", sk.Lambda, "d0badc11620b97c3862ac4ceadc301db58f6c119075258d415c5db3bf912b1dc")
+
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, NewDeterministicReader([]byte("paillier threshold known answer")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHex(t, "threshold N", tsks[0].N, "e7f17390093dca21")
+	expectHex(t, "verification key", tsks[0].VerificationKey, "4fbd9c5382e736b26820f642cb02963c")
+	expectHex(t, "share 1", tsks[0].Share, "03753d90fe55058df817f0a39deaccc3")
+}
+
+// onlyReader hides the type of a deterministic reader
+type onlyReader struct {
+	io.Reader
+}
+
+func TestDeterministicKeyGenWithAnyReader(t *testing.T) {
+	keyGen := func() *PublicKey {
+		t.Helper()
+		opts := &KeyGenOptions{
+			Random:        onlyReader{NewDeterministicReader([]byte("any reader"))},
+			SafePrimes:    true,
+			Deterministic: true,
+		}
+		_, pk, err := KeyGenWithOptions(128, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pk
+	}
+	if keyGen().N.Cmp(keyGen().N) != 0 {
+		t.Error("deterministic key generation from the same reader differs")
+	}
+}
+
+func TestGetRandomNumberMatchesCryptoRand(t *testing.T) {
+	for _, n := range []*gmp.Int{gmp.NewInt(1), gmp.NewInt(255), gmp.NewInt(256), gmp.NewInt(1000003)} {
+		ours, err := GetRandomNumber(n, NewDeterministicReader([]byte("sampling")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		theirs, err := rand.Int(NewDeterministicReader([]byte("sampling")), ToBigInt(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ToBigInt(ours).Cmp(theirs) != 0 {
+			t.Errorf("bound %v: sampled %v instead of %v", n, ours, theirs)
+		}
+	}
+	if _, err := GetRandomNumber(gmp.NewInt(0), rand.Reader); err == nil {
+		t.Error("expected an error for a zero bound")
+	}
+}
+
+func expectHex(t *testing.T, name string, x *gmp.Int, expected string) {
+	t.Helper()
+	if got := hex.EncodeToString(x.Bytes()); got != expected {
+		t.Errorf("%s is %s instead of %s", name, got, expected)
+	}
+}
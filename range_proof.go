@@ -0,0 +1,131 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// bit lengths of the challenge and of the statistical hiding of the proofs
+// with slack, i.e., RangeProof and AffineProof
+const (
+	slackChallengeBits       = 128
+	slackStatisticalSecurity = 40
+	slackBits                = slackChallengeBits + slackStatisticalSecurity
+)
+
+// RangeProof is a non-interactive proof (Fiat-Shamir heuristic) of knowledge
+// of the plaintext x and randomness r of a level one ciphertext
+// c = g^x r^N mod N^2 with 0 <= x < Bound. The proof has a slack: it only
+// convinces the verifier that |x| < Bound * 2^168, which suffices for
+// protocols that leave room for the slack, e.g., GenerateTriples.
+// See [Lin 17], section 6 for a similar proof.
+//
+//	[Lin 17]: Yehuda Lindell, (2017)
+//	          Fast Secure Two-Party ECDSA Signing
+type RangeProof struct {
+	A *gmp.Int // commitment g^alpha rho^N
+	Z *gmp.Int // alpha + e*x over the integers
+	W *gmp.Int // rho * r^e mod N
+}
+
+// ProveRange proves that ct = g^x r^N mod N^2 encrypts x in [0, bound)
+func (pk *PublicKey) ProveRange(ct *Ciphertext, x, r, bound *gmp.Int) (*RangeProof, error) {
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("range proofs are only supported for level one ciphertexts")
+	}
+
+	if x.Sign() < 0 || x.Cmp(bound) >= 0 {
+		return nil, errors.New("value is out of range")
+	}
+
+	zBound := new(gmp.Int).Lsh(bound, slackBits)
+	for {
+		alpha, err := GetRandomNumber(zBound, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		rho, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+
+		a := pk.EncryptWithR(alpha, rho).C
+		e := RandomOracleChallenge(slackChallengeBits, pk.N, ct.C, bound, a)
+
+		// retry in the rare case that the response would reveal x
+		z := new(gmp.Int).Mul(e, x)
+		z.Add(z, alpha)
+		if z.Cmp(zBound) >= 0 {
+			continue
+		}
+
+		w := new(gmp.Int).Exp(r, e, pk.N)
+		w.Mul(w, rho)
+		w.Mod(w, pk.N)
+
+		return &RangeProof{A: a, Z: z, W: w}, nil
+	}
+}
+
+// VerifyRangeProof returns true iff the proof shows that ct encrypts a value
+// in [0, bound) up to the slack of the proof
+func (pk *PublicKey) VerifyRangeProof(ct *Ciphertext, bound *gmp.Int, proof *RangeProof) bool {
+	return pk.VerifyRangeProofErr(ct, bound, proof) == nil
+}
+
+// VerifyRangeProofErr verifies the proof as VerifyRangeProof and returns an
+// error wrapping ErrMalformedProof or ErrProofPart1 (g^Z W^N = A c^e) if it
+// is rejected
+func (pk *PublicKey) VerifyRangeProofErr(ct *Ciphertext, bound *gmp.Int, proof *RangeProof) error {
+	if proof == nil || proof.A == nil || proof.Z == nil || proof.W == nil || ct == nil || ct.C == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if ct.Level != EncLevelOne {
+		return fmt.Errorf("%w: ciphertext must be a level one ciphertext", ErrMalformedProof)
+	}
+
+	if proof.Z.Sign() < 0 || proof.Z.Cmp(new(gmp.Int).Lsh(bound, slackBits)) >= 0 {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+
+	if err := pk.checkUnits(proof.W); err != nil {
+		return err
+	}
+
+	n2 := pk.GetN2()
+	e := RandomOracleChallenge(slackChallengeBits, pk.N, ct.C, bound, proof.A)
+
+	rhs := new(gmp.Int).Exp(ct.C, e, n2)
+	rhs.Mul(rhs, proof.A)
+	rhs.Mod(rhs, n2)
+	if pk.EncryptWithR(proof.Z, proof.W).C.Cmp(rhs) != 0 {
+		return ErrProofPart1
+	}
+
+	return nil
+}
+
+// checks that the responses are units of Z_N
+func (pk *PublicKey) checkUnits(values ...*gmp.Int) error {
+	for _, w := range values {
+		if w.Sign() <= 0 || w.Cmp(pk.N) >= 0 || new(gmp.Int).GCD(nil, nil, w, pk.N).Cmp(OneBigInt) != 0 {
+			return fmt.Errorf("%w: response is not a unit mod N", ErrMalformedProof)
+		}
+	}
+	return nil
+}
+
+// checks that the values are units of Z_N^2, e.g., ciphertexts and
+// commitments
+func (pk *PublicKey) checkUnitsModN2(values ...*gmp.Int) error {
+	n2 := pk.GetN2()
+	for _, x := range values {
+		if x.Sign() <= 0 || x.Cmp(n2) >= 0 || new(gmp.Int).GCD(nil, nil, x, pk.N).Cmp(OneBigInt) != 0 {
+			return fmt.Errorf("%w: value is not a unit mod N^2", ErrMalformedProof)
+		}
+	}
+	return nil
+}
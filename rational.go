@@ -0,0 +1,113 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// EncryptedRat is an encrypted rational number with a fixed denominator. The
+// plaintext is the signed numerator r * Denominator.
+type EncryptedRat struct {
+	Denominator *big.Int
+	Ciphertext  *Ciphertext
+}
+
+// RatCodec encrypts rational numbers as numerators over a denominator that
+// all parties agreed on, e.g., 100 for cents or 3 for thirds. Values are
+// encoded exactly (see EncodeSigned) and homomorphic operations refuse to mix
+// values with different denominators.
+type RatCodec struct {
+	Key         *PublicKey
+	Denominator *big.Int
+}
+
+// NewRatCodec returns a codec for the positive denominator
+func (pk *PublicKey) NewRatCodec(denominator *big.Int) (*RatCodec, error) {
+	if denominator == nil || denominator.Sign() <= 0 {
+		return nil, errors.New("denominator must be positive")
+	}
+	if denominator.Cmp(new(big.Int).Rsh(ToBigInt(pk.N), 1)) >= 0 {
+		return nil, errors.New("denominator is too large for the public key")
+	}
+
+	return &RatCodec{Key: pk, Denominator: new(big.Int).Set(denominator)}, nil
+}
+
+// Encrypt encrypts r, which must be a multiple of 1/Denominator
+func (rc *RatCodec) Encrypt(r *big.Rat) (*EncryptedRat, error) {
+	numerator := new(big.Rat).Mul(r, new(big.Rat).SetInt(rc.Denominator))
+	if !numerator.IsInt() {
+		return nil, fmt.Errorf("%s is not a multiple of 1/%s", r.RatString(), rc.Denominator)
+	}
+
+	half := new(big.Int).Rsh(ToBigInt(rc.Key.N), 1)
+	if new(big.Int).Abs(numerator.Num()).Cmp(half) >= 0 {
+		return nil, fmt.Errorf("%w: value does not fit into the public key", ErrMessageTooLarge)
+	}
+
+	return rc.wrap(rc.Key.Encrypt(rc.Key.EncodeSigned(numerator.Num()))), nil
+}
+
+// DecryptRat returns the exact rational number
+func (rc *RatCodec) DecryptRat(d Decrypter, x *EncryptedRat) (*big.Rat, error) {
+	if err := rc.check(x); err != nil {
+		return nil, err
+	}
+
+	m, err := d.TryDecrypt(x.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	numerator := rc.Key.DecodeSigned(m)
+	return new(big.Rat).SetFrac(numerator, rc.Denominator), nil
+}
+
+// Add homomorphically adds values with the codec's denominator
+func (rc *RatCodec) Add(xs ...*EncryptedRat) (*EncryptedRat, error) {
+	if len(xs) == 0 {
+		return nil, errors.New("no values provided")
+	}
+
+	cts := make([]*Ciphertext, len(xs))
+	for i, x := range xs {
+		if err := rc.check(x); err != nil {
+			return nil, err
+		}
+		cts[i] = x.Ciphertext
+	}
+
+	return rc.wrap(rc.Key.Add(cts...)), nil
+}
+
+// Sub homomorphically subtracts b from a
+func (rc *RatCodec) Sub(a, b *EncryptedRat) (*EncryptedRat, error) {
+	if err := rc.check(a); err != nil {
+		return nil, err
+	}
+	if err := rc.check(b); err != nil {
+		return nil, err
+	}
+
+	return rc.wrap(rc.Key.Sub(a.Ciphertext, b.Ciphertext)), nil
+}
+
+// MulInt homomorphically multiplies the value with a (possibly negative) integer
+func (rc *RatCodec) MulInt(x *EncryptedRat, k *big.Int) (*EncryptedRat, error) {
+	if err := rc.check(x); err != nil {
+		return nil, err
+	}
+
+	return rc.wrap(rc.Key.ConstMult(x.Ciphertext, rc.Key.EncodeSigned(k))), nil
+}
+
+func (rc *RatCodec) wrap(ct *Ciphertext) *EncryptedRat {
+	return &EncryptedRat{Denominator: rc.Denominator, Ciphertext: ct}
+}
+
+func (rc *RatCodec) check(x *EncryptedRat) error {
+	if x.Denominator == nil || x.Denominator.Cmp(rc.Denominator) != 0 {
+		return fmt.Errorf("denominator mismatch: %v is not %s", x.Denominator, rc.Denominator)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestRatCodec(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	thirds, err := pk.NewRatCodec(big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := thirds.Encrypt(big.NewRat(7, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := thirds.Encrypt(big.NewRat(-5, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, _ := thirds.Add(a, b, a)
+	if r, err := thirds.DecryptRat(sk, sum); err != nil || r.Cmp(big.NewRat(-1, 3)) != 0 {
+		t.Error("wrong sum ", r, err)
+	}
+
+	diff, _ := thirds.Sub(b, a)
+	product, _ := thirds.MulInt(diff, big.NewInt(-3))
+	if r, _ := thirds.DecryptRat(sk, product); r.Cmp(big.NewRat(22, 1)) != 0 {
+		t.Error("wrong product ", r)
+	}
+
+	if _, err := thirds.Encrypt(big.NewRat(1, 2)); err == nil {
+		t.Error("1/2 was encoded with denominator 3")
+	}
+
+	halves, _ := pk.NewRatCodec(big.NewInt(2))
+	c, _ := halves.Encrypt(big.NewRat(1, 2))
+	if _, err := thirds.Add(a, c); err == nil {
+		t.Error("values with different denominators were added")
+	}
+	if _, err := thirds.DecryptRat(sk, c); err == nil {
+		t.Error("value was decrypted with the wrong denominator")
+	}
+
+	if _, err := pk.NewRatCodec(big.NewInt(0)); err == nil {
+		t.Error("zero denominator was accepted")
+	}
+}
+
+func TestRatCodecThreshold(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewThresholdClient(&tsks[0].ThresholdPublicKey, []PartialDecrypter{tsks[0], tsks[2]})
+
+	cents, _ := tsks[0].ThresholdPublicKey.NewRatCodec(big.NewInt(100))
+	x, _ := cents.Encrypt(big.NewRat(1999, 100))
+	if r, err := cents.DecryptRat(client, x); err != nil || r.Cmp(big.NewRat(1999, 100)) != 0 {
+		t.Error("wrong threshold decryption ", r, err)
+	}
+}
@@ -0,0 +1,61 @@
+package paillier
+
+import (
+	gmp "github.com/ncw/gmp"
+)
+
+// recoveryTable holds the powers of N and the inverses of the factorials that
+// the moduli of higher levels and recoveryAlgorithm need, so that they are not
+// recomputed on every encryption and decryption. A table for s serves every
+// level up to s.
+type recoveryTable struct {
+	powers        []*gmp.Int   // N^0, ..., N^(s+1)
+	invFactorials [][]*gmp.Int // invFactorials[j][k] = (k!)^-1 mod N^j for 2 <= k <= j <= s
+}
+
+// recoveryConstants returns a table for at least s. Goroutines racing on a
+// larger s may each compute a table; the cache keeps the largest one.
+func (pk *PublicKey) recoveryConstants(s int) *recoveryTable {
+	cached, _ := pk.recovery.Load().(*recoveryTable)
+	if cached != nil && len(cached.powers) > s+1 {
+		return cached
+	}
+
+	// an empty atomic.Value only swaps from the untyped nil
+	var old interface{}
+	if cached != nil {
+		old = cached
+	}
+
+	table := newRecoveryTable(pk.N, s)
+	for !pk.recovery.CompareAndSwap(old, table) {
+		cached = pk.recovery.Load().(*recoveryTable)
+		if len(cached.powers) >= len(table.powers) {
+			return cached
+		}
+		old = cached
+	}
+	return table
+}
+
+func newRecoveryTable(n *gmp.Int, s int) *recoveryTable {
+	table := &recoveryTable{
+		powers:        make([]*gmp.Int, s+2),
+		invFactorials: make([][]*gmp.Int, s+1),
+	}
+
+	table.powers[0] = gmp.NewInt(1)
+	for j := 1; j <= s+1; j++ {
+		table.powers[j] = new(gmp.Int).Mul(table.powers[j-1], n)
+	}
+
+	for j := 2; j <= s; j++ {
+		table.invFactorials[j] = make([]*gmp.Int, j+1)
+		factorial := gmp.NewInt(1)
+		for k := 2; k <= j; k++ {
+			factorial.Mul(factorial, gmp.NewInt(int64(k)))
+			table.invFactorials[j][k] = new(gmp.Int).ModInverse(factorial, table.powers[j])
+		}
+	}
+	return table
+}
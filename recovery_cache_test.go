@@ -0,0 +1,36 @@
+package paillier
+
+import (
+	"sync"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestRecoveryCache(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	// decryptions at increasing and decreasing levels share one table
+	var wg sync.WaitGroup
+	for _, level := range []EncryptionLevel{EncLevelOne, 4, EncLevelTwo, 6, 3} {
+		wg.Add(1)
+		go func(level EncryptionLevel) {
+			defer wg.Done()
+			ct := pk.EncryptAtLevel(gmp.NewInt(int64(level)+100), level)
+			if m := sk.Decrypt(ct); n(m) != int(level)+100 {
+				t.Errorf("level %v: wrong plaintext %v", level, m)
+			}
+		}(level)
+	}
+	wg.Wait()
+
+	table := pk.recoveryConstants(2)
+	if len(table.powers) < 8 {
+		t.Errorf("cached table has %d powers, expected at least 8", len(table.powers))
+	}
+	s, ns, ns1 := pk.getModuliForLevel(3)
+	expected := new(gmp.Int).Exp(pk.N, gmp.NewInt(int64(s+1)), nil)
+	if ns1.Cmp(expected) != 0 || new(gmp.Int).Mul(ns, pk.N).Cmp(ns1) != 0 {
+		t.Error("wrong moduli for s = ", s)
+	}
+}
@@ -0,0 +1,275 @@
+package paillier
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// RegressionModel selects the model trained by a RegressionTrainer
+type RegressionModel int
+
+const (
+	// LinearRegression minimizes the squared error of x.w
+	LinearRegression RegressionModel = iota
+	// LogisticRegression approximates the sigmoid of x.w by its first order
+	// Taylor polynomial 1/2 + x.w/4, which is accurate for |x.w| < 2
+	LogisticRegression
+)
+
+// RegressionConfig holds the parameters of regression training
+type RegressionConfig struct {
+	Model          RegressionModel
+	FractionalBits int     // fixed-point precision of features and weights
+	LearningRate   float64 // step size of gradient descent
+}
+
+// TruncationRequest is sent by the trainer to the key holder and contains
+// values [v + shift + r] blinded with random masks r that must be divided by
+// Divisor
+type TruncationRequest struct {
+	Values  []*Ciphertext
+	Divisor *gmp.Int
+}
+
+// TruncationResponse is returned by the key holder and contains the
+// encryptions of floor((v + shift + r) / Divisor)
+type TruncationResponse struct {
+	Values []*Ciphertext
+}
+
+// RegressionTrainer trains a linear or logistic regression model by batch
+// gradient descent on plaintext features and labels encrypted by the key
+// holder, who assists in every step without learning the labels, the
+// gradients or the model. The weights stay encrypted under the key.
+//
+// Features x and weights w are fixed-point numbers with FractionalBits
+// fractional bits, i.e., scaled by s = 2^FractionalBits, and labels are
+// scaled by s^2 (see EncryptRegressionLabels). In every step the trainer
+// homomorphically computes the residuals [e_i] = [x_i.w - y_i] at scale s^2
+// and the scaled gradient steps [v_j] = eta*s * sum_i x_ij [e_i] at scale
+// s^4 * m for m samples. It then masks the v_j with random r_j that hide
+// them statistically and the key holder decrypts v_j + r_j, divides by
+// s^3 * m and encrypts the result again, which rescales the steps to the
+// scale of the weights. Removing the masks leaves the steps with an error of
+// at most one unit in the last place.
+//
+// The intermediate values v_j must be smaller than 2^(|N| - 43) in absolute
+// value; features and labels should be normalized accordingly. An intercept
+// is trained by adding a constant feature of one.
+type RegressionTrainer struct {
+	pk       *PublicKey
+	config   RegressionConfig
+	features [][]*gmp.Int // fixed-point, encoded in Z_N
+	labels   []*Ciphertext
+	weights  []*Ciphertext
+
+	divisor *gmp.Int
+	masks   []*gmp.Int // of the pending request
+}
+
+// EncryptRegressionLabels encrypts the labels at the fixed-point scale of
+// the configuration
+func (pk *PublicKey) EncryptRegressionLabels(labels []float64, config RegressionConfig) []*Ciphertext {
+	cts := make([]*Ciphertext, len(labels))
+	for i, y := range labels {
+		cts[i] = pk.Encrypt(pk.EncodeSigned(scaleFloat(y, 2*config.FractionalBits)))
+	}
+	return cts
+}
+
+// NewRegressionTrainer returns a trainer for the features of m samples and
+// their encrypted labels. The weights are initialized with encryptions of
+// zero.
+func (pk *PublicKey) NewRegressionTrainer(features [][]float64, labels []*Ciphertext, config RegressionConfig) (*RegressionTrainer, error) {
+	if len(features) == 0 || len(features) != len(labels) {
+		return nil, errors.New("there must be one label per sample")
+	}
+
+	if config.FractionalBits <= 0 || config.LearningRate <= 0 {
+		return nil, errors.New("fractional bits and learning rate must be positive")
+	}
+
+	if config.Model != LinearRegression && config.Model != LogisticRegression {
+		return nil, errors.New("unknown regression model")
+	}
+
+	if regressionValueBits(pk) < 4*config.FractionalBits {
+		return nil, errors.New("public key is too small for the fixed-point precision")
+	}
+
+	dimension := len(features[0])
+	encoded := make([][]*gmp.Int, len(features))
+	for i, x := range features {
+		if len(x) != dimension || dimension == 0 {
+			return nil, errors.New("all samples must have the same non-zero number of features")
+		}
+		encoded[i] = make([]*gmp.Int, dimension)
+		for j, xj := range x {
+			encoded[i][j] = pk.EncodeSigned(scaleFloat(xj, config.FractionalBits))
+		}
+	}
+
+	weights := make([]*Ciphertext, dimension)
+	for j := range weights {
+		weights[j] = pk.EncryptZero()
+	}
+
+	// s^3 * m, and 4 times that for the residuals of logistic regression
+	divisor := new(gmp.Int).Lsh(gmp.NewInt(int64(len(features))), uint(3*config.FractionalBits))
+	if config.Model == LogisticRegression {
+		divisor.Lsh(divisor, 2)
+	}
+
+	return &RegressionTrainer{
+		pk:       pk,
+		config:   config,
+		features: encoded,
+		labels:   labels,
+		weights:  weights,
+		divisor:  divisor,
+	}, nil
+}
+
+// Weights returns the encrypted weights at scale 2^FractionalBits, see
+// DecodeRegressionWeights
+func (rt *RegressionTrainer) Weights() []*Ciphertext {
+	return rt.weights
+}
+
+// Step computes the masked gradient steps of the current weights and returns
+// the request for the key holder, whose response is applied by Update
+func (rt *RegressionTrainer) Step() (*TruncationRequest, error) {
+	pk := rt.pk
+	s2 := new(gmp.Int).Lsh(OneBigInt, uint(2*rt.config.FractionalBits))
+
+	residuals := make([]*Ciphertext, len(rt.features))
+	for i, x := range rt.features {
+		terms := make([]*Ciphertext, len(x))
+		for j, xj := range x {
+			terms[j] = pk.ConstMult(rt.weights[j], xj)
+		}
+		prediction := pk.Add(terms...)
+
+		switch rt.config.Model {
+		case LinearRegression:
+			residuals[i] = pk.Sub(prediction, rt.labels[i])
+		case LogisticRegression:
+			// 4 * (1/2 + x.w/4 - y) = x.w + 2 - 4y at scale s^2
+			offset := pk.Encrypt(new(gmp.Int).Lsh(s2, 1))
+			residuals[i] = pk.Sub(pk.Add(prediction, offset), pk.ConstMult(rt.labels[i], FourBigInt))
+		}
+	}
+
+	eta := pk.EncodeSigned(scaleFloat(rt.config.LearningRate, rt.config.FractionalBits))
+	bits := regressionValueBits(pk)
+	shift := rt.shift(bits)
+	maskBound := new(gmp.Int).Lsh(OneBigInt, uint(bits+DefaultAlertStatisticalSecurity))
+
+	req := &TruncationRequest{
+		Values:  make([]*Ciphertext, len(rt.weights)),
+		Divisor: rt.divisor,
+	}
+	rt.masks = make([]*gmp.Int, len(rt.weights))
+	for j := range rt.weights {
+		terms := make([]*Ciphertext, len(rt.features))
+		for i, x := range rt.features {
+			terms[i] = pk.ConstMult(residuals[i], x[j])
+		}
+		v := pk.ConstMult(pk.Add(terms...), eta)
+
+		r, err := GetRandomNumber(maskBound, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		rt.masks[j] = r
+
+		offset := new(gmp.Int).Add(shift, r)
+		req.Values[j] = pk.Add(v, pk.Encrypt(offset))
+	}
+
+	return req, nil
+}
+
+// Update removes the masks from the rescaled gradient steps of the response
+// and subtracts them from the weights
+func (rt *RegressionTrainer) Update(resp *TruncationResponse) error {
+	if rt.masks == nil {
+		return errors.New("no pending truncation request")
+	}
+
+	if len(resp.Values) != len(rt.weights) {
+		return errors.New("wrong number of truncated values")
+	}
+
+	pk := rt.pk
+	shift := new(gmp.Int).Div(rt.shift(regressionValueBits(pk)), rt.divisor)
+	for j, ct := range resp.Values {
+		if ct == nil || ct.Level != EncLevelOne {
+			return errors.New("invalid truncated value")
+		}
+
+		// step = floor((v + shift + r) / D) - floor(r / D) - shift / D
+		offset := new(gmp.Int).Div(rt.masks[j], rt.divisor)
+		offset.Add(offset, shift)
+		step := pk.Sub(ct, pk.Encrypt(offset))
+		rt.weights[j] = pk.Sub(rt.weights[j], step)
+	}
+
+	rt.masks = nil
+	return nil
+}
+
+// returns the multiple of the divisor that makes the values positive
+func (rt *RegressionTrainer) shift(bits int) *gmp.Int {
+	shift := new(gmp.Int).Lsh(OneBigInt, uint(bits))
+	shift.Div(shift, rt.divisor)
+	shift.Add(shift, OneBigInt)
+	return shift.Mul(shift, rt.divisor)
+}
+
+// AssistTruncation is run by the key holder on a request of a trainer. The
+// key holder only learns the masked values.
+func (sk *SecretKey) AssistTruncation(req *TruncationRequest) (*TruncationResponse, error) {
+	if req.Divisor == nil || req.Divisor.Sign() <= 0 {
+		return nil, errors.New("divisor must be positive")
+	}
+
+	resp := &TruncationResponse{Values: make([]*Ciphertext, len(req.Values))}
+	for i, ct := range req.Values {
+		if ct == nil || ct.Level != EncLevelOne {
+			return nil, errors.New("truncation is only supported for level one ciphertexts")
+		}
+
+		m := sk.Decrypt(ct)
+		resp.Values[i] = sk.Encrypt(m.Div(m, req.Divisor))
+	}
+
+	return resp, nil
+}
+
+// DecodeRegressionWeights decodes decrypted weights of a RegressionTrainer
+func (pk *PublicKey) DecodeRegressionWeights(weights []*gmp.Int, config RegressionConfig) []float64 {
+	decoded := make([]float64, len(weights))
+	for j, w := range weights {
+		f, _ := new(big.Float).SetInt(pk.DecodeSigned(w)).Float64()
+		decoded[j] = math.Ldexp(f, -config.FractionalBits)
+	}
+	return decoded
+}
+
+// returns the bit length of the values that the masks of truncation hide
+// statistically without overflowing N/2
+func regressionValueBits(pk *PublicKey) int {
+	return pk.N.BitLen() - DefaultAlertStatisticalSecurity - 3
+}
+
+// returns round(x * 2^bits)
+func scaleFloat(x float64, bits int) *big.Int {
+	scaled := new(big.Float).SetMantExp(big.NewFloat(x), bits)
+	scaled.Add(scaled, big.NewFloat(math.Copysign(0.5, x)))
+	i, _ := scaled.Int(nil)
+	return i
+}
@@ -0,0 +1,109 @@
+package paillier
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func trainRegression(t *testing.T, sk *SecretKey, features [][]float64, labels []float64, config RegressionConfig, steps int) []float64 {
+	pk := &sk.PublicKey
+	trainer, err := pk.NewRegressionTrainer(features, pk.EncryptRegressionLabels(labels, config), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < steps; i++ {
+		req, err := trainer.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := sk.AssistTruncation(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := trainer.Update(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	weights := make([]*gmp.Int, len(trainer.Weights()))
+	for j, ct := range trainer.Weights() {
+		weights[j] = sk.Decrypt(ct)
+	}
+	return pk.DecodeRegressionWeights(weights, config)
+}
+
+func TestLinearRegression(t *testing.T) {
+	sk, _ := KeyGen(256)
+	random := rand.New(rand.NewSource(1))
+
+	// y = 2 x1 - x2 + 0.5 with an intercept feature
+	features := make([][]float64, 20)
+	labels := make([]float64, len(features))
+	for i := range features {
+		x1, x2 := 2*random.Float64()-1, 2*random.Float64()-1
+		features[i] = []float64{x1, x2, 1}
+		labels[i] = 2*x1 - x2 + 0.5
+	}
+
+	config := RegressionConfig{Model: LinearRegression, FractionalBits: 16, LearningRate: 0.5}
+	weights := trainRegression(t, sk, features, labels, config, 150)
+
+	for j, expected := range []float64{2, -1, 0.5} {
+		if math.Abs(weights[j]-expected) > 0.05 {
+			t.Error("weight ", j, " did not converge: ", weights[j])
+		}
+	}
+}
+
+func TestLogisticRegression(t *testing.T) {
+	sk, _ := KeyGen(256)
+	random := rand.New(rand.NewSource(2))
+
+	// the class is 1 iff x1 > x2, with a margin
+	features := make([][]float64, 0, 20)
+	labels := make([]float64, 0, 20)
+	for len(features) < cap(features) {
+		x1, x2 := 2*random.Float64()-1, 2*random.Float64()-1
+		if math.Abs(x1-x2) < 0.2 {
+			continue
+		}
+		features = append(features, []float64{x1, x2, 1})
+		if x1 > x2 {
+			labels = append(labels, 1)
+		} else {
+			labels = append(labels, 0)
+		}
+	}
+
+	config := RegressionConfig{Model: LogisticRegression, FractionalBits: 16, LearningRate: 1}
+	weights := trainRegression(t, sk, features, labels, config, 30)
+
+	for i, x := range features {
+		z := weights[0]*x[0] + weights[1]*x[1] + weights[2]*x[2]
+		if (z > 0) != (labels[i] == 1) {
+			t.Error("sample ", i, " is misclassified with weights ", weights)
+		}
+	}
+}
+
+func TestRegressionParameters(t *testing.T) {
+	_, pk := KeyGen(64)
+	config := RegressionConfig{Model: LinearRegression, FractionalBits: 16, LearningRate: 0.5}
+	labels := pk.EncryptRegressionLabels([]float64{1}, config)
+
+	if _, err := pk.NewRegressionTrainer([][]float64{{1}}, labels, config); err == nil {
+		t.Error("expected error for a key that is too small")
+	}
+
+	_, pk = KeyGen(256)
+	if _, err := pk.NewRegressionTrainer([][]float64{{1}, {2}}, labels, config); err == nil {
+		t.Error("expected error for a missing label")
+	}
+	if _, err := pk.NewRegressionTrainer([][]float64{{1}}, labels, RegressionConfig{FractionalBits: 16}); err == nil {
+		t.Error("expected error for learning rate 0")
+	}
+}
@@ -0,0 +1,290 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+// reshareStatisticalSecurity is the number of bits by which the random
+// coefficients of a resharing exceed the value they hide
+const reshareStatisticalSecurity = 40
+
+// ReshareCommitment is broadcast by an old server and holds the Feldman
+// commitments V^a_k mod N^2 to the polynomial sharing its contribution
+type ReshareCommitment struct {
+	From        int
+	Dealers     []int // IDs of the old servers taking part, in increasing order
+	Commitments []*gmp.Int
+}
+
+// ReshareShare is sent privately from the old server From to the new
+// server To and holds the evaluation of From's polynomial at To
+type ReshareShare struct {
+	From, To int
+	Share    *gmp.Int
+}
+
+// Reshare shares the server's share of the decryption exponent among a new
+// committee of totalNumberOfDecryptionServers servers with the given
+// threshold. Every server in dealers, which must contain at least Threshold
+// old servers, calls Reshare with the same arguments; the new servers then
+// combine the results with CombineReshares. The secret is never
+// reconstructed and N, G and the verification key V stay the same, so
+// ciphertexts under the old key can be decrypted by the new committee.
+//
+// Server i shares z_i = u lambda_i s_i over the integers, where lambda_i is
+// its Lagrange coefficient and u = delta^-1 mod N^S, so that the new secret
+// sum_i z_i is 0 mod m and 1 mod N^S like the old one. The commitment to z_i
+// is checked against V_i by the new servers. The new shares are longer than
+// the old ones by about the bit length of N plus 100 bits.
+//
+// The shares must be sent over confidential channels and the old shares
+// should be destroyed once the new committee accepted its keys.
+func (tsk *ThresholdSecretKey) Reshare(dealers []int, totalNumberOfDecryptionServers, threshold int, random io.Reader) (*ReshareCommitment, []*ReshareShare, error) {
+	if err := tsk.checkServerSet(dealers); err != nil {
+		return nil, nil, err
+	}
+	if !containsID(dealers, tsk.ID) {
+		return nil, nil, errors.New("server is not one of the dealers")
+	}
+	if err := checkReshareThreshold(totalNumberOfDecryptionServers, threshold); err != nil {
+		return nil, nil, err
+	}
+
+	z := tsk.reshareContribution(dealers)
+
+	// the coefficients hide z statistically and their sum over the dealers
+	// exceeds |sum_i z_i| so that the new shares are positive, both except
+	// with probability about 2^-reshareStatisticalSecurity
+	delta := Factorial(totalNumberOfDecryptionServers)
+	bound := new(gmp.Int).Abs(z)
+	bound.Add(bound, OneBigInt)
+	bound.Lsh(bound, uint(reshareStatisticalSecurity+delta.BitLen()))
+
+	coefficients := make([]*gmp.Int, threshold)
+	coefficients[0] = z
+	for k := 1; k < threshold; k++ {
+		var err error
+		if coefficients[k], err = GetRandomNumber(bound, random); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	n2 := tsk.GetN2()
+	commitment := &ReshareCommitment{
+		From:        tsk.ID,
+		Dealers:     append([]int{}, dealers...),
+		Commitments: make([]*gmp.Int, threshold),
+	}
+	for k, a := range coefficients {
+		commitment.Commitments[k] = expSigned(tsk.VerificationKey, a, n2)
+	}
+
+	shares := make([]*ReshareShare, totalNumberOfDecryptionServers)
+	for j := range shares {
+		shares[j] = &ReshareShare{From: tsk.ID, To: j + 1, Share: evaluateIntegerPolynomial(coefficients, j+1)}
+	}
+
+	return commitment, shares, nil
+}
+
+// ResharedPublicKey returns the threshold public key of the new committee
+// from the commitments of all dealers, which are checked against the
+// verification keys of the old committee
+func (tk *ThresholdPublicKey) ResharedPublicKey(totalNumberOfDecryptionServers, threshold int, commitments []*ReshareCommitment) (*ThresholdPublicKey, error) {
+	if err := checkReshareThreshold(totalNumberOfDecryptionServers, threshold); err != nil {
+		return nil, err
+	}
+	if err := tk.checkReshareCommitments(threshold, commitments); err != nil {
+		return nil, err
+	}
+
+	n2 := tk.GetN2()
+	aggregated := make([]*gmp.Int, threshold)
+	for k := range aggregated {
+		aggregated[k] = gmp.NewInt(1)
+		for _, c := range commitments {
+			aggregated[k].Mul(aggregated[k], c.Commitments[k])
+			aggregated[k].Mod(aggregated[k], n2)
+		}
+	}
+
+	// v_j = V^(delta' f(j)) for the sum f of the polynomials of the dealers
+	delta := Factorial(totalNumberOfDecryptionServers)
+	verificationKeys := make([]*gmp.Int, totalNumberOfDecryptionServers)
+	for j := range verificationKeys {
+		verificationKeys[j] = new(gmp.Int).Exp(evaluateInExponent(aggregated, j+1, n2), delta, n2)
+	}
+
+	ret := &ThresholdPublicKey{
+		PublicKey:                      *tk.PublicKey.deepCopy(),
+		TotalNumberOfDecryptionServers: totalNumberOfDecryptionServers,
+		Threshold:                      threshold,
+		VerificationKey:                copyInt(tk.VerificationKey),
+		VerificationKeys:               verificationKeys,
+		S:                              tk.S,
+	}
+	return ret, nil
+}
+
+// CombineReshares returns the threshold secret key of new server id from
+// the commitments of all dealers and the shares they sent to the server.
+// Every share is verified against the commitments of its dealer.
+func (tk *ThresholdPublicKey) CombineReshares(id, totalNumberOfDecryptionServers, threshold int, commitments []*ReshareCommitment, shares []*ReshareShare) (*ThresholdSecretKey, error) {
+	if id < 1 || id > totalNumberOfDecryptionServers {
+		return nil, errors.New("server ID is out of range")
+	}
+
+	newKey, err := tk.ResharedPublicKey(totalNumberOfDecryptionServers, threshold, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	byDealer := make(map[int]*ReshareCommitment, len(commitments))
+	for _, c := range commitments {
+		byDealer[c.From] = c
+	}
+
+	n2 := tk.GetN2()
+	share := new(gmp.Int)
+	received := make(map[int]bool, len(shares))
+	for _, s := range shares {
+		if s == nil || s.Share == nil {
+			return nil, errors.New("missing reshare share")
+		}
+		if s.To != id {
+			return nil, fmt.Errorf("share of dealer %d is for server %d", s.From, s.To)
+		}
+		c, ok := byDealer[s.From]
+		if !ok || received[s.From] {
+			return nil, fmt.Errorf("unexpected share of dealer %d", s.From)
+		}
+		received[s.From] = true
+
+		if expSigned(tk.VerificationKey, s.Share, n2).Cmp(evaluateInExponent(c.Commitments, id, n2)) != 0 {
+			return nil, fmt.Errorf("share of dealer %d does not match its commitments", s.From)
+		}
+		share.Add(share, s.Share)
+	}
+	if len(received) != len(byDealer) {
+		return nil, errors.New("missing the share of a dealer")
+	}
+	if share.Sign() <= 0 {
+		return nil, errors.New("reshared share is not positive, the resharing must be repeated")
+	}
+
+	return &ThresholdSecretKey{ThresholdPublicKey: *newKey, ID: id, Share: share}, nil
+}
+
+// returns z_i = u lambda_i s_i with u = delta^-1 mod N^S
+func (tsk *ThresholdSecretKey) reshareContribution(dealers []int) *gmp.Int {
+	z := shamir.LagrangeCoefficient(tsk.ID, dealers, tsk.delta())
+	z.Mul(z, tsk.deltaInverse())
+	return z.Mul(z, tsk.Share)
+}
+
+// returns delta^-1 mod N^S
+func (tk *ThresholdPublicKey) deltaInverse() *gmp.Int {
+	_, ns, _ := tk.getModuliForLevel(tk.MaxLevel())
+	return new(gmp.Int).ModInverse(tk.delta(), ns)
+}
+
+// checks that the IDs are distinct servers of the committee in increasing
+// order and at least Threshold of them
+func (tk *ThresholdPublicKey) checkServerSet(ids []int) error {
+	if len(ids) < tk.Threshold {
+		return errors.New("fewer servers than the threshold")
+	}
+	if !sort.IntsAreSorted(ids) {
+		return errors.New("server IDs must be in increasing order")
+	}
+	for i, id := range ids {
+		if id < 1 || id > tk.TotalNumberOfDecryptionServers {
+			return errors.New("server ID is out of range")
+		}
+		if i > 0 && ids[i-1] == id {
+			return errors.New("two servers have the same ID")
+		}
+	}
+	return nil
+}
+
+// checks that there is exactly one commitment of every dealer, that all
+// commitments agree on the dealers and that the commitment to the
+// contribution z_i of dealer i matches V_i, i.e., C_i0^delta = V_i^(u lambda_i)
+func (tk *ThresholdPublicKey) checkReshareCommitments(threshold int, commitments []*ReshareCommitment) error {
+	if len(commitments) == 0 || commitments[0] == nil {
+		return errors.New("no reshare commitments")
+	}
+
+	dealers := commitments[0].Dealers
+	if err := tk.checkServerSet(dealers); err != nil {
+		return err
+	}
+	if len(commitments) != len(dealers) {
+		return errors.New("number of commitments does not match the dealers")
+	}
+
+	n2 := tk.GetN2()
+	u := tk.deltaInverse()
+	seen := make(map[int]bool, len(commitments))
+	for _, c := range commitments {
+		if c == nil || !equalIDs(c.Dealers, dealers) {
+			return errors.New("commitments disagree on the dealers")
+		}
+		if !containsID(dealers, c.From) || seen[c.From] {
+			return fmt.Errorf("unexpected commitment of server %d", c.From)
+		}
+		seen[c.From] = true
+
+		if len(c.Commitments) != threshold {
+			return fmt.Errorf("commitment of dealer %d has %d values", c.From, len(c.Commitments))
+		}
+		for _, value := range c.Commitments {
+			if value == nil || value.Sign() <= 0 || value.Cmp(n2) >= 0 {
+				return fmt.Errorf("commitment of dealer %d is malformed", c.From)
+			}
+		}
+
+		exp := shamir.LagrangeCoefficient(c.From, dealers, tk.delta())
+		exp.Mul(exp, u)
+		expected := expSigned(tk.VerificationKeys[c.From-1], exp, n2)
+		if new(gmp.Int).Exp(c.Commitments[0], tk.delta(), n2).Cmp(expected) != 0 {
+			return fmt.Errorf("commitment of dealer %d does not match its verification key", c.From)
+		}
+	}
+	return nil
+}
+
+func checkReshareThreshold(totalNumberOfDecryptionServers, threshold int) error {
+	if threshold < 2 || threshold > totalNumberOfDecryptionServers {
+		return errors.New("new threshold must be between 2 and the number of new servers")
+	}
+	return nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// reshares the keys of the dealers to a new committee
+func reshare(t *testing.T, old []*ThresholdSecretKey, dealers []int, total, threshold int) []*ThresholdSecretKey {
+	var commitments []*ReshareCommitment
+	sharesTo := make([][]*ReshareShare, total)
+	for _, id := range dealers {
+		c, shares, err := old[id-1].Reshare(dealers, total, threshold, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commitments = append(commitments, c)
+		for j, s := range shares {
+			sharesTo[j] = append(sharesTo[j], s)
+		}
+	}
+
+	tk := old[0].PublicOnly()
+	keys := make([]*ThresholdSecretKey, total)
+	for j := range keys {
+		var err error
+		if keys[j], err = tk.CombineReshares(j+1, total, threshold, commitments, sharesTo[j]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func checkThresholdDecryption(t *testing.T, keys []*ThresholdSecretKey, ct *Ciphertext, expected int64) {
+	tk := keys[0].PublicKey()
+	var shares []*PartialDecryptionZKP
+	for _, key := range keys[len(keys)-keys[0].Threshold:] {
+		pd, err := key.PartialDecryptionWithZKP(ct.C)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pd.VerifyErrWithKey(tk); err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, pd)
+	}
+
+	m, err := tk.CombinePartialDecryptionsZKP(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != expected {
+		t.Error("wrong decryption ", m)
+	}
+}
+
+func TestReshare(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	old, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := old[0].Encrypt(gmp.NewInt(4242))
+
+	keys := reshare(t, old, []int{1, 3, 5}, 4, 2)
+	if keys[0].N.Cmp(old[0].N) != 0 || keys[0].VerificationKey.Cmp(old[0].VerificationKey) != 0 {
+		t.Fatal("public key changed")
+	}
+	if keys[0].TotalNumberOfDecryptionServers != 4 || keys[0].Threshold != 2 {
+		t.Fatal("wrong committee parameters")
+	}
+	checkThresholdDecryption(t, keys, ct, 4242)
+
+	// the new committee can reshare again
+	keys = reshare(t, keys, []int{2, 3, 4}, 3, 3)
+	checkThresholdDecryption(t, keys, ct, 4242)
+}
+
+func TestReshareDKGKeys(t *testing.T) {
+	old := runDKGSteps(t, newDKGParticipants(t, 128, 3, 2))
+	ct := old[0].Encrypt(gmp.NewInt(77))
+
+	keys := reshare(t, old, []int{1, 2}, 5, 4)
+	checkThresholdDecryption(t, keys, ct, 77)
+}
+
+func TestReshareLevelTwo(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tkg.S = 2
+	old, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := reshare(t, old, []int{1, 2, 3}, 3, 2)
+	if keys[0].S != 2 {
+		t.Fatal("Damgard-Jurik exponent was not kept")
+	}
+
+	m := new(gmp.Int).Add(keys[0].GetN2(), gmp.NewInt(-5))
+	ct := keys[0].EncryptAtLevel(m, EncLevelTwo)
+	var pds []*PartialDecryption
+	for _, key := range keys[:2] {
+		pd, err := key.PartialDecryptAtLevel(ct.C, EncLevelTwo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pds = append(pds, pd)
+	}
+	decrypted, err := keys[0].CombinePartialDecryptionsAtLevel(pds, EncLevelTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Cmp(m) != 0 {
+		t.Error("wrong decryption at level two")
+	}
+}
+
+func TestReshareRejectsInvalidDealings(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	old, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := old[0].PublicOnly()
+	dealers := []int{1, 2}
+
+	if _, _, err := old[2].Reshare(dealers, 3, 2, rand.Reader); err == nil {
+		t.Error("expected an error for a server that is not a dealer")
+	}
+	if _, _, err := old[0].Reshare([]int{1}, 3, 2, rand.Reader); err == nil {
+		t.Error("expected an error for fewer dealers than the threshold")
+	}
+	if _, _, err := old[0].Reshare(dealers, 3, 1, rand.Reader); err == nil {
+		t.Error("expected an error for a threshold of one")
+	}
+
+	c1, s1, err := old[0].Reshare(dealers, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a dealer that uses a wrong share is caught by its verification key
+	cheater := *old[1]
+	cheater.Share = new(gmp.Int).Add(old[1].Share, OneBigInt)
+	c2, s2, err := cheater.Reshare(dealers, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitments := []*ReshareCommitment{c1, c2}
+	if _, err := tk.CombineReshares(1, 3, 2, commitments, []*ReshareShare{s1[0], s2[0]}); err == nil {
+		t.Error("expected an error for a dealer with a wrong share")
+	}
+
+	c2, s2, err = old[1].Reshare(dealers, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitments = []*ReshareCommitment{c1, c2}
+	if _, err := tk.CombineReshares(1, 3, 2, commitments, []*ReshareShare{s1[0], s2[0]}); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := *s2[0]
+	tampered.Share = new(gmp.Int).Add(s2[0].Share, OneBigInt)
+	if _, err := tk.CombineReshares(1, 3, 2, commitments, []*ReshareShare{s1[0], &tampered}); err == nil {
+		t.Error("expected an error for a share that does not match its commitments")
+	}
+	if _, err := tk.CombineReshares(1, 3, 2, commitments, []*ReshareShare{s1[0]}); err == nil {
+		t.Error("expected an error for a missing share")
+	}
+	if _, err := tk.CombineReshares(1, 3, 2, commitments, []*ReshareShare{s1[0], s2[1]}); err == nil {
+		t.Error("expected an error for a share for another server")
+	}
+}
@@ -0,0 +1,110 @@
+package paillier
+
+import (
+	"fmt"
+	"math/big"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// SafeCiphertext is a ciphertext bound to the public key it was produced
+// under by the Fingerprint of the key. The homomorphic operations and
+// decryptions of safe ciphertexts return an error wrapping
+// ErrCiphertextKeyMismatch if an operand is bound to another key, instead of
+// the meaningless result of mixing ciphertexts of different moduli, e.g., of
+// two tenants of a service.
+//
+// The fingerprint is not authenticated: it catches mistakes, not an attacker
+// who relabels a ciphertext.
+type SafeCiphertext struct {
+	*Ciphertext
+	KeyFingerprint string
+}
+
+// Bind returns ct bound to the key
+func (pk *PublicKey) Bind(ct *Ciphertext) *SafeCiphertext {
+	return &SafeCiphertext{ct, pk.Fingerprint()}
+}
+
+// EncryptSafe encrypts m as Encrypt and binds the ciphertext to the key
+func (pk *PublicKey) EncryptSafe(m *gmp.Int) *SafeCiphertext {
+	return pk.Bind(pk.Encrypt(m))
+}
+
+// EAddSafe homomorphically adds the ciphertexts as Add
+func (pk *PublicKey) EAddSafe(cts ...*SafeCiphertext) (*SafeCiphertext, error) {
+	if len(cts) == 0 {
+		return nil, fmt.Errorf("%w: no ciphertexts to add", ErrInvalidCiphertext)
+	}
+	plain, err := pk.unbind(cts...)
+	if err != nil {
+		return nil, err
+	}
+	return pk.Bind(pk.Add(plain...)), nil
+}
+
+// ESubSafe returns an encryption of m1 - m2 as ESub
+func (pk *PublicKey) ESubSafe(ct1, ct2 *SafeCiphertext) (*SafeCiphertext, error) {
+	plain, err := pk.unbind(ct1, ct2)
+	if err != nil {
+		return nil, err
+	}
+	return pk.Bind(pk.ESub(plain[0], plain[1])), nil
+}
+
+// ECMultSafe returns an encryption of k*m as ECMult
+func (pk *PublicKey) ECMultSafe(ct *SafeCiphertext, k *big.Int) (*SafeCiphertext, error) {
+	plain, err := pk.unbind(ct)
+	if err != nil {
+		return nil, err
+	}
+	return pk.Bind(pk.ECMult(plain[0], k)), nil
+}
+
+// DecryptSafe decrypts a ciphertext bound to the key of sk
+func (sk *SecretKey) DecryptSafe(ct *SafeCiphertext) (*gmp.Int, error) {
+	plain, err := sk.unbind(ct)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Decrypt(plain[0]), nil
+}
+
+// PartialDecryptSafe returns the partial decryption of a ciphertext bound to
+// the public key of tsk, at the level of the ciphertext
+func (tsk *ThresholdSecretKey) PartialDecryptSafe(ct *SafeCiphertext) (*PartialDecryption, error) {
+	plain, err := tsk.unbind(ct)
+	if err != nil {
+		return nil, err
+	}
+	return tsk.PartialDecryptAtLevel(plain[0].C, plain[0].Level)
+}
+
+// CombinePartialDecryptionsSafe combines the partial decryptions of a
+// ciphertext bound to the public key of tk as
+// CombinePartialDecryptionsAtLevel, which also rejects shares of another
+// threshold key with an error wrapping ErrKeyMismatch
+func (tk *ThresholdPublicKey) CombinePartialDecryptionsSafe(ct *SafeCiphertext, shares []*PartialDecryption) (*gmp.Int, error) {
+	plain, err := tk.unbind(ct)
+	if err != nil {
+		return nil, err
+	}
+	return tk.CombinePartialDecryptionsAtLevel(shares, plain[0].Level)
+}
+
+// unbind returns the ciphertexts after checking that they are all bound to
+// the key
+func (pk *PublicKey) unbind(cts ...*SafeCiphertext) ([]*Ciphertext, error) {
+	fingerprint := pk.Fingerprint()
+	plain := make([]*Ciphertext, len(cts))
+	for i, ct := range cts {
+		if ct == nil || ct.Ciphertext == nil || ct.C == nil {
+			return nil, fmt.Errorf("%w: ciphertext %d is missing", ErrInvalidCiphertext, i)
+		}
+		if ct.KeyFingerprint != fingerprint {
+			return nil, fmt.Errorf("%w: ciphertext %d is of key %.16s", ErrCiphertextKeyMismatch, i, ct.KeyFingerprint)
+		}
+		plain[i] = ct.Ciphertext
+	}
+	return plain, nil
+}
@@ -0,0 +1,83 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestSafeCiphertext(t *testing.T) {
+	sk, pk := KeyGen(128)
+	_, other := KeyGen(128)
+
+	a, b := pk.EncryptSafe(gmp.NewInt(30)), pk.EncryptSafe(gmp.NewInt(12))
+	sum, err := pk.EAddSafe(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := pk.ESubSafe(sum, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := pk.ECMultSafe(diff, big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := sk.DecryptSafe(product); err != nil || n(m) != 90 {
+		t.Error("wrong result ", m, " is not 90: ", err)
+	}
+
+	foreign := other.EncryptSafe(gmp.NewInt(1))
+	if _, err := pk.EAddSafe(a, foreign); !errors.Is(err, ErrCiphertextKeyMismatch) {
+		t.Error("expected ErrCiphertextKeyMismatch, got ", err)
+	}
+	if _, err := pk.ECMultSafe(foreign, big.NewInt(2)); !errors.Is(err, ErrCiphertextKeyMismatch) {
+		t.Error("expected ErrCiphertextKeyMismatch, got ", err)
+	}
+	if _, err := sk.DecryptSafe(foreign); !errors.Is(err, ErrCiphertextKeyMismatch) {
+		t.Error("expected ErrCiphertextKeyMismatch, got ", err)
+	}
+	if _, err := pk.EAddSafe(a, &SafeCiphertext{Ciphertext: b.Ciphertext}); !errors.Is(err, ErrCiphertextKeyMismatch) {
+		t.Error("expected ErrCiphertextKeyMismatch for an unbound ciphertext, got ", err)
+	}
+}
+
+func TestSafeCiphertextThreshold(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tsks[0].PublicOnly()
+
+	ct := tk.EncryptSafe(gmp.NewInt(17))
+	shares := make([]*PartialDecryption, 2)
+	for i := range shares {
+		if shares[i], err = tsks[i].PartialDecryptSafe(ct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := tk.CombinePartialDecryptionsSafe(ct, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 17 {
+		t.Error("wrong decryption ", m)
+	}
+
+	_, other := KeyGen(128)
+	foreign := other.EncryptSafe(gmp.NewInt(17))
+	if _, err := tsks[0].PartialDecryptSafe(foreign); !errors.Is(err, ErrCiphertextKeyMismatch) {
+		t.Error("expected ErrCiphertextKeyMismatch, got ", err)
+	}
+	if _, err := tk.CombinePartialDecryptionsSafe(foreign, shares); !errors.Is(err, ErrCiphertextKeyMismatch) {
+		t.Error("expected ErrCiphertextKeyMismatch, got ", err)
+	}
+}
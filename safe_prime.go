@@ -18,21 +18,6 @@ import (
 	"time"
 )
 
-// smallPrimes is a list of small, prime numbers that allows us to rapidly
-// exclude some fraction of composite candidates when searching for a random
-// prime. This list is truncated at the point where smallPrimesProduct exceeds
-// a uint64. It does not include two because we ensure that the candidates are
-// odd by construction.
-var smallPrimes = []uint8{
-	3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53,
-}
-
-// smallPrimesProduct is the product of the values in smallPrimes and allows us
-// to reduce a candidate prime by this number and then determine whether it's
-// coprime to all the elements of smallPrimes without further big.Int
-// operations.
-var smallPrimesProduct = new(big.Int).SetUint64(16294579238595022365)
-
 // GenerateSafePrime tries to find a safe prime concurrently.
 // The returned result is a safe prime `p` and prime `q` such that `p=2q+1`.
 // Concurrency level can be controlled with the `concurrencyLevel` parameter.
@@ -64,8 +49,76 @@ func GenerateSafePrime(
 	timeout time.Duration,
 	random io.Reader,
 ) (*big.Int, *big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	p, q, err := GenerateSafePrimeContext(ctx, bitLen, concurrencyLevel, random)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, nil, fmt.Errorf("generator timed out after %v", timeout)
+	}
+	return p, q, err
+}
+
+// GenerateSafePrimeContext is GenerateSafePrime without a timeout: the search
+// is aborted when ctx is done, in which case ctx.Err() is returned. All
+// search goroutines have stopped when it returns.
+func GenerateSafePrimeContext(
+	ctx context.Context,
+	bitLen int,
+	concurrencyLevel int,
+	random io.Reader,
+) (*big.Int, *big.Int, error) {
+	var result safePrime
+	err := searchSafePrimes(ctx, bitLen, concurrencyLevel, random, nil, func(found safePrime) bool {
+		result = found
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.p, result.q, nil
+}
+
+// generateSafePrimePair searches for two safe primes p = 2p' + 1 and
+// q = 2q' + 1 with one pool of goroutines and returns the first pair found
+// with p != q, p != q' and p' != q. The search is reported to progress.
+func generateSafePrimePair(
+	ctx context.Context,
+	bitLen int,
+	concurrencyLevel int,
+	random io.Reader,
+	progress *keyGenProgress,
+) (safePrime, safePrime, error) {
+	var found []safePrime
+	var first, second safePrime
+	err := searchSafePrimes(ctx, bitLen, concurrencyLevel, random, progress, func(candidate safePrime) bool {
+		for _, other := range found {
+			if candidate.p.Cmp(other.p) != 0 && candidate.p.Cmp(other.q) != 0 && candidate.q.Cmp(other.p) != 0 {
+				first, second = other, candidate
+				return true
+			}
+		}
+		found = append(found, candidate)
+		return false
+	})
+	return first, second, err
+}
+
+// searchSafePrimes runs concurrencyLevel goroutines searching for safe primes
+// and passes the safe primes they find to accept until it returns true. It
+// returns ctx.Err() if ctx is done first and the error of a goroutine that
+// fails. All goroutines have stopped when it returns. The tested candidates
+// and the safe primes found are reported to progress, which may be nil.
+func searchSafePrimes(
+	ctx context.Context,
+	bitLen int,
+	concurrencyLevel int,
+	random io.Reader,
+	progress *keyGenProgress,
+	accept func(safePrime) bool,
+) error {
 	if bitLen < 6 {
-		return nil, nil, errors.New("safe prime size must be at least 6 bits")
+		return errors.New("safe prime size must be at least 6 bits")
 	}
 
 	primeChan := make(chan safePrime, concurrencyLevel)
@@ -77,30 +130,29 @@ func GenerateSafePrime(
 	defer close(errChan)
 	defer waitGroup.Wait()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// stops the search before waiting for the goroutines
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	for i := 0; i < concurrencyLevel; i++ {
 		waitGroup.Add(1)
 		runGenPrimeRoutine(
-			ctx, primeChan, errChan, waitGroup, random, bitLen,
+			ctx, primeChan, errChan, waitGroup, random, bitLen, progress,
 		)
 	}
 
-	// Cancel after the specified timeout.
-	go func() {
-		time.Sleep(timeout)
-		cancel()
-	}()
-
-	select {
-	case result := <-primeChan:
-		cancel()
-		return result.p, result.q, nil
-	case err := <-errChan:
-		cancel()
-		return nil, nil, err
-	case <-ctx.Done():
-		return nil, nil, fmt.Errorf("generator timed out after %v", timeout)
+	for {
+		select {
+		case result := <-primeChan:
+			progress.primeFound()
+			if accept(result) {
+				return nil
+			}
+		case err := <-errChan:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 }
 
@@ -109,41 +161,31 @@ type safePrime struct {
 	q *big.Int
 }
 
-// Starts a Goroutine searching for a safe prime of the specified `pBitLen`.
-// If succeeds, writes prime `p` and prime `q` such that `p = 2q+1` to the
-// `primeChan`. Prime `p` has a bit length equal to `pBitLen` and prime `q` has
-// a bit length equal to `pBitLen-1`.
+// Starts a Goroutine searching for safe primes of the specified `pBitLen`.
+// Whenever it succeeds, it writes prime `p` and prime `q` such that `p = 2q+1`
+// to the `primeChan` and continues until `ctx` is done. Prime `p` has a bit
+// length equal to `pBitLen` and prime `q` has a bit length equal to
+// `pBitLen-1`.
 //
 // The algorithm is as follows:
 // 1. Generate a random odd number `q` of length `pBitLen-1` with two the most
 //    significant bits set to `1`.
-// 2. Execute preliminary primality test on `q` checking whether it is coprime
-//    to all the elements of `smallPrimes`. It allows to eliminate trivial
-//    cases quickly, when `q` is obviously no prime, without running an
-//    expensive final primality tests.
-//    If `q` is coprime to all of the `smallPrimes`, then go to the point 3.
-//    If not, add `2` and try again. Do it at most 10 times.
-// 3. Check the potentially prime `q`, whether `q = 1 (mod 3)`. This will
-//    happen for 50% of cases.
-//    If it is, then `p = 2q+1` will be a multiple of 3, so it will be obviously
-//    not a prime number. In this case, add `2` and try again. Do it at most 10
-//    times. If `q != 1 (mod 3)`, go to the point 4.
-// 4. Now we know `q` is potentially prime and `p = 2q+1` is not a multiple of
-//    3. We execute a preliminary primality test on `p`, checking whether
-//    it is coprime to all the elements of `smallPrimes` just like we did for
-//    `q` in point 2. If `p` is not coprime to at least one element of the
-//    `smallPrimes`, then go back to point 1.
-//    If `p` is coprime to all the elements of `smallPrimes`, go to point 5.
-// 5. At this point, we know `q` is potentially prime, and `p=q+1` is also
-//    potentially prime. We need to execute a final primality test for `q`.
-//    We apply Miller-Rabin and Baillie-PSW tests. If they succeed, it means
-//    that `q` is prime with a very high probability. Knowing `q` is prime,
-//    we use Pocklington's criterion to prove the primality of `p=2q+1`, that
-//    is, we execute Fermat primality test to base 2 checking whether
-//    `2^{p-1} = 1 (mod p)`. It's significantly faster than running full
-//    Miller-Rabin and Baillie-PSW for `p`.
-//    If `q` and `p` are found to be prime, return them as a result. If not, go
-//    back to the point 1.
+// 2. Sieve the window of candidates `q + 2k` with the odd primes below
+//    `sieveBound`, crossing out every `k` for which `q + 2k` or
+//    `p = 2(q + 2k) + 1` has a small prime factor. This eliminates the vast
+//    majority of candidates, e.g., those for which `p` is a multiple of 3,
+//    with a few operations on machine words and without any big number
+//    arithmetic per candidate.
+// 3. For the remaining candidates in increasing order, execute the final
+//    primality tests. Knowing `q` is prime, we use Pocklington's criterion
+//    to prove the primality of `p=2q+1`, that is, we execute Fermat
+//    primality test to base 2 checking whether `2^{p-1} = 1 (mod p)`. It is
+//    significantly cheaper than Miller-Rabin and Baillie-PSW, so it is run
+//    first and rejects most of the candidates. If it succeeds, we apply
+//    Miller-Rabin and Baillie-PSW tests to `q`. If they succeed, it means
+//    that `q` is prime with a very high probability.
+//    If `q` and `p` are found to be prime, write them to `primeChan`. In any
+//    case, go back to the point 1.
 func runGenPrimeRoutine(
 	ctx context.Context,
 	primeChan chan safePrime,
@@ -151,6 +193,7 @@ func runGenPrimeRoutine(
 	waitGroup *sync.WaitGroup,
 	rand io.Reader,
 	pBitLen int,
+	progress *keyGenProgress,
 ) {
 	qBitLen := pBitLen - 1
 	b := uint(qBitLen % 8)
@@ -162,7 +205,7 @@ func runGenPrimeRoutine(
 	p := new(big.Int)
 	q := new(big.Int)
 
-	bigMod := new(big.Int)
+	candidate := new(big.Int)
 
 	go func() {
 		defer waitGroup.Done()
@@ -201,65 +244,45 @@ func runGenPrimeRoutine(
 
 				q.SetBytes(bytes)
 
-				// Calculate the value mod the product of smallPrimes. If it's
-				// a multiple of any of these primes we add two until it isn't.
-				// The probability of overflowing is minimal and can be ignored
-				// because we still perform Miller-Rabin tests on the result.
-				bigMod.Mod(q, smallPrimesProduct)
-				mod := bigMod.Uint64()
+				// Sieve primes as large as q would cross out q itself.
+				bound := uint64(sieveBound)
+				if qBitLen <= 17 {
+					bound = 1 << uint(qBitLen-1)
+				}
 
-			NextDelta:
-				for delta := uint64(0); delta < 1<<20; delta += 2 {
-					m := mod + delta
-					for _, prime := range smallPrimes {
-						if m%uint64(prime) == 0 && (qBitLen > 6 || m != uint64(prime)) {
-							continue NextDelta
-						}
+				tested := 0
+				for _, k := range sieveSafePrimeCandidates(q, bound) {
+					if ctx.Err() != nil {
+						progress.candidatesTested(tested)
+						return
 					}
 
-					if delta > 0 {
-						bigMod.SetUint64(delta)
-						q.Add(q, bigMod)
-					}
+					candidate.SetUint64(2 * k)
+					candidate.Add(q, candidate)
 
-					// If `q = 1 (mod 3)`, then `p` is a multiple of `3` so it's
-					// obviously no prime and such `q` should be rejected.
-					// This will happen in 50% of cases and we should detect
-					// and eliminate them early.
-					//
-					// Explanation:
-					// If q = 1 (mod 3) then there exists a q' such that:
-					// q = 3q' + 1
-					//
-					// Since p = 2q + 1:
-					// p = 2q + 1 = 2(3q' + 1) + 1 = 6q' + 2 + 1 = 6q' + 3 =
-					//   = 3(2q' + 1)
-					// So `p` is a multiple of `3`.
-					qMod3 := new(big.Int).Mod(q, big.NewInt(3))
-					if qMod3.Cmp(big.NewInt(1)) == 0 {
-						continue NextDelta
+					// Adding 2k may make the candidate one bit too long.
+					if candidate.BitLen() != qBitLen {
+						break
 					}
 
 					// p = 2q+1
-					p.Mul(q, big.NewInt(2))
+					p.Lsh(candidate, 1)
 					p.Add(p, big.NewInt(1))
-					if !isPrimeCandidate(p) {
-						continue NextDelta
-					}
-
-					break
-				}
-
-				// There is a tiny possibility that, by adding delta, we caused
-				// the number to be one bit too long. Thus we check BitLen
-				// here.
-				if q.ProbablyPrime(20) &&
-					isPocklingtonCriterionSatisfied(p) &&
-					q.BitLen() == qBitLen {
 
-					primeChan <- safePrime{p, q}
-					return
+					tested++
+					if isPocklingtonCriterionSatisfied(p) && candidate.ProbablyPrime(20) {
+						progress.candidatesTested(tested)
+						tested = 0
+						select {
+						case primeChan <- safePrime{new(big.Int).Set(p), new(big.Int).Set(candidate)}:
+						case <-ctx.Done():
+							return
+						}
+						// continue with a new random window
+						break
+					}
 				}
+				progress.candidatesTested(tested)
 			}
 		}
 	}()
@@ -276,15 +299,3 @@ func isPocklingtonCriterionSatisfied(p *big.Int) bool {
 		p,
 	).Cmp(big.NewInt(1)) == 0
 }
-
-func isPrimeCandidate(number *big.Int) bool {
-	m := new(big.Int).Mod(number, smallPrimesProduct).Uint64()
-
-	for _, prime := range smallPrimes {
-		if m%uint64(prime) == 0 && m != uint64(prime) {
-			return false
-		}
-	}
-
-	return true
-}
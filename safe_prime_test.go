@@ -1,8 +1,10 @@
 package paillier
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
+	"math/big"
 	"reflect"
 	"testing"
 	"time"
@@ -65,3 +67,23 @@ func TestAsyncGenerator(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSafePrimePair(t *testing.T) {
+	for _, bitLen := range []int{9, 256} {
+		p, q, err := generateSafePrimePair(context.Background(), bitLen, 4, rand.Reader, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sp := range []safePrime{p, q} {
+			expected := new(big.Int).Lsh(sp.q, 1)
+			expected.Add(expected, big.NewInt(1))
+			if sp.p.Cmp(expected) != 0 || !sp.p.ProbablyPrime(20) || !sp.q.ProbablyPrime(20) || sp.p.BitLen() != bitLen {
+				t.Errorf("%d bits: %v is not a safe prime of %v", bitLen, sp.p, sp.q)
+			}
+		}
+		if p.p.Cmp(q.p) == 0 || p.p.Cmp(q.q) == 0 || p.q.Cmp(q.p) == 0 {
+			t.Errorf("%d bits: safe primes %v and %v are not a good pair", bitLen, p.p, q.p)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+// domain separation tags of the secure channel
+var (
+	channelHelloTag = []byte("paillier-channel-hello-v1")
+	channelKeysTag  = []byte("paillier-channel-keys-v1")
+)
+
+// ChannelHello is the handshake message of a secure channel: an ephemeral
+// X25519 key signed with the Ed25519 identity key of the sender
+type ChannelHello struct {
+	From, To  int
+	Ephemeral []byte
+	Signature []byte
+}
+
+// ChannelMessage is a message encrypted and authenticated by a SecureChannel
+type ChannelMessage struct {
+	From, To   int
+	Round      int    // protocol round, authenticated with the message
+	Sequence   uint64 // strictly increasing per direction
+	Ciphertext []byte
+}
+
+// ChannelHandshake is a pending handshake of a secure channel
+type ChannelHandshake struct {
+	self, peer int
+	peerKey    ed25519.PublicKey
+	ephemeral  *ecdh.PrivateKey
+	hello      *ChannelHello
+}
+
+// SecureChannel protects the point-to-point messages of two parties of a
+// multi-party protocol, e.g., key generation or share refresh, which are only
+// secure over authenticated and private channels.
+//
+// The parties know each other's Ed25519 identity keys in advance. They
+// exchange signed ephemeral X25519 keys (NewChannelHandshake and Finish) and
+// derive one AES-256-GCM key per direction with HKDF-SHA256 from the shared
+// secret and the handshake transcript, which gives forward secrecy. Messages
+// are bound to the sender, the recipient, the round and a sequence number;
+// replayed and reordered messages are rejected. It is safe for concurrent use.
+type SecureChannel struct {
+	self, peer int
+
+	mu       sync.Mutex
+	send     cipher.AEAD
+	receive  cipher.AEAD
+	sendNext uint64
+	recvNext uint64
+}
+
+// NewChannelHandshake starts a handshake of party self with party peer whose
+// identity key is peerKey and returns the hello to send to the peer
+func NewChannelHandshake(self int, key ed25519.PrivateKey, peer int, peerKey ed25519.PublicKey) (*ChannelHandshake, *ChannelHello, error) {
+	if self == peer {
+		return nil, nil, errors.New("party cannot open a channel to itself")
+	}
+
+	if len(peerKey) != ed25519.PublicKeySize {
+		return nil, nil, errors.New("invalid identity key of the peer")
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hello := &ChannelHello{
+		From:      self,
+		To:        peer,
+		Ephemeral: ephemeral.PublicKey().Bytes(),
+	}
+	hello.Signature = ed25519.Sign(key, hello.signedBytes())
+
+	h := &ChannelHandshake{
+		self:      self,
+		peer:      peer,
+		peerKey:   peerKey,
+		ephemeral: ephemeral,
+		hello:     hello,
+	}
+
+	return h, hello, nil
+}
+
+// Finish verifies the hello of the peer and returns the established channel
+func (h *ChannelHandshake) Finish(peerHello *ChannelHello) (*SecureChannel, error) {
+	if peerHello.From != h.peer || peerHello.To != h.self {
+		return nil, errors.New("hello is not from the peer to this party")
+	}
+
+	if !ed25519.Verify(h.peerKey, peerHello.signedBytes(), peerHello.Signature) {
+		return nil, errors.New("invalid signature on the hello of the peer")
+	}
+
+	peerEphemeral, err := ecdh.X25519().NewPublicKey(peerHello.Ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := h.ephemeral.ECDH(peerEphemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	// the party with the lower ID sends with the first key
+	first, second := h.hello, peerHello
+	if h.self > h.peer {
+		first, second = second, first
+	}
+	transcript := sha256.New()
+	transcript.Write(first.signedBytes())
+	transcript.Write(second.signedBytes())
+
+	keys := hkdf(secret, transcript.Sum(nil), channelKeysTag, 64)
+	lower, err := newChannelAEAD(keys[:32])
+	if err != nil {
+		return nil, err
+	}
+	higher, err := newChannelAEAD(keys[32:])
+	if err != nil {
+		return nil, err
+	}
+
+	c := &SecureChannel{self: h.self, peer: h.peer, send: lower, receive: higher}
+	if h.self > h.peer {
+		c.send, c.receive = higher, lower
+	}
+
+	return c, nil
+}
+
+// Seal encrypts and authenticates the message for the given round
+func (c *SecureChannel) Seal(round int, plaintext []byte) *ChannelMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := &ChannelMessage{From: c.self, To: c.peer, Round: round, Sequence: c.sendNext}
+	c.sendNext++
+
+	msg.Ciphertext = c.send.Seal(nil, msg.nonce(), plaintext, msg.associatedData())
+	return msg
+}
+
+// Open authenticates and decrypts a message of the peer
+func (c *SecureChannel) Open(msg *ChannelMessage) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if msg.From != c.peer || msg.To != c.self {
+		return nil, errors.New("message is not from the peer to this party")
+	}
+
+	if msg.Sequence < c.recvNext {
+		return nil, errors.New("message was replayed or reordered")
+	}
+
+	plaintext, err := c.receive.Open(nil, msg.nonce(), msg.Ciphertext, msg.associatedData())
+	if err != nil {
+		return nil, errors.New("message authentication failed")
+	}
+
+	c.recvNext = msg.Sequence + 1
+	return plaintext, nil
+}
+
+func newChannelAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (h *ChannelHello) signedBytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(channelHelloTag)
+	binary.Write(&buf, binary.BigEndian, int64(h.From))
+	binary.Write(&buf, binary.BigEndian, int64(h.To))
+	writeCanonicalBytes(&buf, h.Ephemeral)
+	return buf.Bytes()
+}
+
+// every key is only used in one direction, so the sequence number is a unique nonce
+func (m *ChannelMessage) nonce() []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], m.Sequence)
+	return nonce
+}
+
+func (m *ChannelMessage) associatedData() []byte {
+	data := make([]byte, 32)
+	binary.BigEndian.PutUint64(data, uint64(m.From))
+	binary.BigEndian.PutUint64(data[8:], uint64(m.To))
+	binary.BigEndian.PutUint64(data[16:], uint64(m.Round))
+	binary.BigEndian.PutUint64(data[24:], m.Sequence)
+	return data
+}
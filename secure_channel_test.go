@@ -0,0 +1,75 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+func TestSecureChannel(t *testing.T) {
+	pub1, key1, _ := ed25519.GenerateKey(rand.Reader)
+	pub2, key2, _ := ed25519.GenerateKey(rand.Reader)
+	_, key3, _ := ed25519.GenerateKey(rand.Reader)
+
+	h1, hello1, err := NewChannelHandshake(1, key1, 2, pub2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, hello2, err := NewChannelHandshake(2, key2, 1, pub1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a hello signed by a third party is rejected
+	_, forged, err := NewChannelHandshake(3, key3, 1, pub1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.From = 2
+	if _, err := h1.Finish(forged); err == nil {
+		t.Error("expected error for hello signed by another party")
+	}
+
+	c1, err := h1.Finish(hello2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := h2.Finish(hello1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := c1.Seal(1, []byte("share for party 2"))
+	if bytes.Contains(msg.Ciphertext, []byte("share")) {
+		t.Error("message is not encrypted")
+	}
+	plaintext, err := c2.Open(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "share for party 2" {
+		t.Error("wrong plaintext ", string(plaintext))
+	}
+
+	if _, err := c2.Open(msg); err == nil {
+		t.Error("expected error for replayed message")
+	}
+
+	reply := c2.Seal(2, []byte("complaint"))
+	reply.Round = 3
+	if _, err := c1.Open(reply); err == nil {
+		t.Error("expected error for message moved to another round")
+	}
+	reply.Round = 2
+	if plaintext, err := c1.Open(reply); err != nil || string(plaintext) != "complaint" {
+		t.Error("wrong reply ", string(plaintext), err)
+	}
+
+	// a message cannot be reflected to its sender
+	reflected := c1.Seal(1, []byte("ping"))
+	reflected.From, reflected.To = 2, 1
+	if _, err := c1.Open(reflected); err == nil {
+		t.Error("expected error for reflected message")
+	}
+}
@@ -0,0 +1,64 @@
+package paillier
+
+import (
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// SecurityParams are the soundness and statistical hiding parameters of a
+// PartialDecryptionZKP. The zero value keeps the parameters of
+// PartialDecryptionWithZKP, i.e., a challenge of the full digest and a
+// random mask below N^2.
+type SecurityParams struct {
+	// ChallengeBits is the bit length of the Fiat-Shamir challenge, so a
+	// cheating prover succeeds with probability about 2^-ChallengeBits. Zero
+	// means the full digest of the hash function.
+	ChallengeBits int
+
+	// StatisticalBits is the number of bits by which the mask of the response
+	// exceeds the value it hides, so the response is within statistical
+	// distance 2^-StatisticalBits of a simulated one
+	StatisticalBits int
+}
+
+// Security parameters for 80, 112 and 128 bits of soundness and statistical
+// hiding; small values such as SecurityParams{ChallengeBits: 16} make tests
+// faster but must not be used in deployments
+var (
+	SecurityLevel80  = SecurityParams{ChallengeBits: 80, StatisticalBits: 80}
+	SecurityLevel112 = SecurityParams{ChallengeBits: 112, StatisticalBits: 112}
+	SecurityLevel128 = SecurityParams{ChallengeBits: 128, StatisticalBits: 128}
+)
+
+// checks the parameters for a challenge hash with digests of digestBits bits
+func (params SecurityParams) check(digestBits int) error {
+	if params.ChallengeBits < 0 || params.ChallengeBits > digestBits {
+		return fmt.Errorf("challenge bit length must be between 0 and %d, got %d", digestBits, params.ChallengeBits)
+	}
+	if params.StatisticalBits < 0 {
+		return fmt.Errorf("statistical security must not be negative, got %d", params.StatisticalBits)
+	}
+	return nil
+}
+
+// SetSecurityParams sets the security parameters of the proofs of
+// PartialDecryptionWithZKP and PartialDecryptionWithSession. Keys returned by
+// ThresholdKeyGenerator.GenerateKeys start with the generator's Security.
+func (tsk *ThresholdSecretKey) SetSecurityParams(params SecurityParams) {
+	tsk.security = params
+}
+
+// returns the exclusive bound of the random mask r of the response
+// Z = r + E * delta * share, where E has challengeBits bits and the share is
+// below N^s * m < N^(s+1)
+func (tk *ThresholdPublicKey) maskBound(params SecurityParams, challengeBits int) *gmp.Int {
+	if params == (SecurityParams{}) {
+		return tk.GetN2()
+	}
+
+	_, ns, _ := tk.getModuliForLevel(tk.MaxLevel())
+	bound := new(gmp.Int).Mul(ns, tk.N)
+	bound.Mul(bound, tk.delta())
+	return bound.Lsh(bound, uint(challengeBits+params.StatisticalBits))
+}
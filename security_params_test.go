@@ -0,0 +1,67 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestSecurityParams(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tkg.Security = SecurityLevel80
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := tsks[0].Encrypt(gmp.NewInt(3)).C
+
+	pd, err := tsks[0].PartialDecryptionWithZKP(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pd.ChallengeBits != 80 || pd.E.BitLen() > 80 {
+		t.Errorf("expected an 80-bit challenge, got %d bits", pd.E.BitLen())
+	}
+	if err := pd.VerifyWithOptions(&ProofOptions{Security: SecurityLevel80}); err != nil {
+		t.Error(err)
+	}
+	if err := pd.VerifyWithOptions(nil); !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected a challenge mismatch for the default challenge length, got ", err)
+	}
+
+	// a shorter claimed challenge invalidates the proof
+	tampered := *pd
+	tampered.ChallengeBits = 40
+	if err := tampered.VerifyErr(); !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected a challenge mismatch for a rewritten challenge length, got ", err)
+	}
+
+	// the options of the request take precedence over those of the key
+	small := SecurityParams{ChallengeBits: 16}
+	pd, err = tsks[1].PartialDecryptionWithOptions(c, &ProofOptions{Security: small})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pd.VerifyWithOptions(&ProofOptions{Security: small}); err != nil {
+		t.Error(err)
+	}
+
+	tsks[2].SetSecurityParams(SecurityParams{})
+	pd, err = tsks[2].PartialDecryptionWithZKP(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pd.VerifyWithOptions(nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := tsks[0].PartialDecryptionWithOptions(c, &ProofOptions{Security: SecurityParams{ChallengeBits: 300}}); err == nil {
+		t.Error("expected an error for a challenge longer than the digest")
+	}
+}
@@ -0,0 +1,150 @@
+package paillier
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// SetPolynomial is the encrypted polynomial P(X) = prod_i (X - H(a_i)) of a
+// set, whose roots are the hashes of the elements. It is sent by the key
+// holder to the other party of the cardinality protocols.
+type SetPolynomial struct {
+	Coefficients []*Ciphertext // Coefficients[k] encrypts the coefficient of X^k
+}
+
+// SetEvaluation holds the blinded evaluations [r_j * P(H(b_j))] of the
+// polynomial of the key holder at the elements of the other party, in random
+// order. An evaluation encrypts zero iff the element is in both sets.
+type SetEvaluation struct {
+	Values []*Ciphertext
+}
+
+// NewSetPolynomial encrypts the polynomial of the set of the key holder for
+// the intersection and union cardinality protocols [FNP 04]:
+//  1. the key holder sends the encrypted polynomial of its set A
+//  2. the other party evaluates it at the elements of its set B, blinds
+//     every evaluation with a random unit and shuffles them (Evaluate)
+//  3. the key holder decrypts the evaluations and counts the zeros, which is
+//     |A n B| (IntersectionCardinality), and obtains |A u B| by
+//     inclusion-exclusion as |A| + |B| - |A n B| (UnionCardinality)
+//
+// The key holder learns the cardinalities and |B| but no elements of B; the
+// other party learns |A| and nothing else. Both parties are assumed to follow
+// the protocol. Elements are hashed to Z_N and duplicates are removed.
+//
+//	[FNP 04]: Michael J. Freedman, Kobbi Nissim, Benny Pinkas, (2004)
+//	          Efficient Private Matching and Set Intersection
+func (pk *PublicKey) NewSetPolynomial(set [][]byte) (*SetPolynomial, error) {
+	roots := pk.hashSet(set)
+	if len(roots) == 0 {
+		return nil, errors.New("set must not be empty")
+	}
+
+	// multiply the coefficients by (X - root) for every root
+	coefficients := []*gmp.Int{gmp.NewInt(1)}
+	for _, root := range roots {
+		next := make([]*gmp.Int, len(coefficients)+1)
+		next[len(coefficients)] = gmp.NewInt(0)
+		for k := range coefficients {
+			next[k] = gmp.NewInt(0)
+		}
+		for k, c := range coefficients {
+			next[k+1].Add(next[k+1], c)
+			next[k].Sub(next[k], new(gmp.Int).Mul(c, root))
+			next[k].Mod(next[k], pk.N)
+		}
+		next[len(coefficients)].Mod(next[len(coefficients)], pk.N)
+		coefficients = next
+	}
+
+	sp := &SetPolynomial{Coefficients: make([]*Ciphertext, len(coefficients))}
+	for k, c := range coefficients {
+		sp.Coefficients[k] = pk.Encrypt(c)
+	}
+	return sp, nil
+}
+
+// Evaluate is run by the other party on the polynomial of the key holder and
+// returns the blinded and shuffled evaluations at the elements of its set
+func (sp *SetPolynomial) Evaluate(pk *PublicKey, set [][]byte) (*SetEvaluation, error) {
+	if len(sp.Coefficients) < 2 {
+		return nil, errors.New("polynomial must have a positive degree")
+	}
+
+	for _, ct := range sp.Coefficients {
+		if ct == nil || ct.Level != EncLevelOne {
+			return nil, errors.New("set polynomials are only supported for level one ciphertexts")
+		}
+	}
+
+	elements := pk.hashSet(set)
+	permutation, err := randomPermutation(len(elements), pk.RandomSource())
+	if err != nil {
+		return nil, err
+	}
+
+	eval := &SetEvaluation{Values: make([]*Ciphertext, len(elements))}
+	for j, b := range elements {
+		// Horner's rule: P(b) = (...(c_d b + c_(d-1)) b + ...) b + c_0
+		degree := len(sp.Coefficients) - 1
+		value := sp.Coefficients[degree]
+		for k := degree - 1; k >= 0; k-- {
+			value = pk.Add(pk.ConstMult(value, b), sp.Coefficients[k])
+		}
+
+		r, err := GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+
+		eval.Values[permutation[j]] = pk.Randomize(pk.ConstMult(value, r))
+	}
+
+	return eval, nil
+}
+
+// IntersectionCardinality returns the number of elements that the set of
+// the other party shares with the set of the key holder
+func (sk *SecretKey) IntersectionCardinality(eval *SetEvaluation) (int, error) {
+	count := 0
+	for _, ct := range eval.Values {
+		if ct == nil || ct.Level != EncLevelOne {
+			return 0, errors.New("set evaluations are only supported for level one ciphertexts")
+		}
+		if sk.Decrypt(ct).Sign() == 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// UnionCardinality returns the number of elements in the union of the set of
+// the key holder, which must be the set of the polynomial, and the set of the
+// other party
+func (sk *SecretKey) UnionCardinality(set [][]byte, eval *SetEvaluation) (int, error) {
+	intersection, err := sk.IntersectionCardinality(eval)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(sk.hashSet(set)) + len(eval.Values) - intersection, nil
+}
+
+// returns the distinct SHA-256 hashes of the elements mod N
+func (pk *PublicKey) hashSet(set [][]byte) []*gmp.Int {
+	seen := make(map[[sha256.Size]byte]bool, len(set))
+	hashes := make([]*gmp.Int, 0, len(set))
+	for _, element := range set {
+		digest := sha256.Sum256(element)
+		if seen[digest] {
+			continue
+		}
+		seen[digest] = true
+
+		h := new(gmp.Int).SetBytes(digest[:])
+		hashes = append(hashes, h.Mod(h, pk.N))
+	}
+	return hashes
+}
@@ -0,0 +1,61 @@
+package paillier
+
+import (
+	"testing"
+)
+
+func toSet(elements ...string) [][]byte {
+	set := make([][]byte, len(elements))
+	for i, e := range elements {
+		set[i] = []byte(e)
+	}
+	return set
+}
+
+func TestSetCardinality(t *testing.T) {
+	sk, pk := KeyGen(256)
+
+	a := toSet("alice", "bob", "carol", "dave", "bob")
+	b := toSet("carol", "erin", "bob", "frank", "grace")
+
+	sp, err := pk.NewSetPolynomial(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sp.Coefficients) != 5 {
+		t.Error("duplicates were not removed, degree ", len(sp.Coefficients)-1)
+	}
+
+	eval, err := sp.Evaluate(pk, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intersection, err := sk.IntersectionCardinality(eval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intersection != 2 {
+		t.Error("wrong intersection cardinality ", intersection)
+	}
+
+	union, err := sk.UnionCardinality(a, eval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if union != 7 {
+		t.Error("wrong union cardinality ", union)
+	}
+
+	disjoint, err := sp.Evaluate(pk, toSet("x", "y"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if union, _ := sk.UnionCardinality(a, disjoint); union != 6 {
+		t.Error("wrong union cardinality of disjoint sets ", union)
+	}
+
+	if _, err := pk.NewSetPolynomial(nil); err == nil {
+		t.Error("expected error for an empty set")
+	}
+}
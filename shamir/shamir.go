@@ -0,0 +1,266 @@
+// Package shamir implements Shamir secret sharing [Sha 79] over Z_M together
+// with Feldman commitments [Fel 87] to the sharing polynomial.
+//
+// A secret s is shared with a random polynomial
+//
+//	f(X) = s + a_1 X + ... + a_(t-1) X^(t-1) mod M
+//
+// and the share of party x is f(x) for x = 1, 2, ..., n; any t shares
+// determine the secret. Reconstruct interpolates f(0) modulo M and requires
+// the differences of the share indices to be invertible mod M, e.g., M prime.
+// When M is secret or not known to the combiner, as for the threshold
+// Paillier keys of the parent package, LagrangeCoefficient returns the
+// integer coefficients scaled by delta = n! which are used in the exponent
+// instead.
+//
+//	[Sha 79]: Adi Shamir, (1979)
+//	          How to Share a Secret
+//	[Fel 87]: Paul Feldman, (1987)
+//	          A Practical Scheme for Non-interactive Verifiable Secret Sharing
+package shamir
+
+import (
+	"errors"
+	"io"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Share is the evaluation Y = f(X) of the sharing polynomial at index X > 0
+type Share struct {
+	X int
+	Y *gmp.Int
+}
+
+// Polynomial is a sharing polynomial over Z_Modulus; Coefficients[0] is the
+// secret and the threshold is the number of coefficients
+type Polynomial struct {
+	Coefficients []*gmp.Int
+	Modulus      *gmp.Int
+}
+
+// Commitments are Feldman commitments Values[i] = G^a_i mod Modulus to the
+// coefficients of a sharing polynomial. G must have an order dividing the
+// modulus of the polynomial for the shares to verify.
+type Commitments struct {
+	G       *gmp.Int
+	Modulus *gmp.Int
+	Values  []*gmp.Int
+}
+
+// NewPolynomial returns a polynomial of degree threshold-1 with constant
+// term secret and the other coefficients chosen uniformly from Z_modulus
+func NewPolynomial(secret *gmp.Int, threshold int, modulus *gmp.Int, random io.Reader) (*Polynomial, error) {
+	if threshold < 1 {
+		return nil, errors.New("threshold must be at least 1")
+	}
+
+	if modulus.Sign() <= 0 {
+		return nil, errors.New("modulus must be positive")
+	}
+
+	coefficients := make([]*gmp.Int, threshold)
+	coefficients[0] = secret
+	for i := 1; i < threshold; i++ {
+		coefficient, err := randomBelow(modulus, random)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = coefficient
+	}
+
+	return &Polynomial{Coefficients: coefficients, Modulus: modulus}, nil
+}
+
+// randomBelow returns a random value less than the positive n by rejection
+// sampling like GetRandomNumber of the parent package, so that a polynomial
+// drawn from a deterministic reader does not change between versions
+func randomBelow(n *gmp.Int, random io.Reader) (*gmp.Int, error) {
+	bitLen := new(gmp.Int).Sub(n, gmp.NewInt(1)).BitLen()
+	if bitLen == 0 {
+		return gmp.NewInt(0), nil
+	}
+	b := uint(bitLen % 8)
+	if b == 0 {
+		b = 8
+	}
+
+	bytes := make([]byte, (bitLen+7)/8)
+	r := new(gmp.Int)
+	for {
+		if _, err := io.ReadFull(random, bytes); err != nil {
+			return nil, err
+		}
+		bytes[0] &= uint8(int(1<<b) - 1)
+
+		r.SetBytes(bytes)
+		if r.Cmp(n) < 0 {
+			return r, nil
+		}
+	}
+}
+
+// Split shares secret among n parties such that any threshold of them can
+// reconstruct it; the returned polynomial can be used to compute commitments
+// and must be discarded afterwards
+func Split(secret *gmp.Int, threshold, n int, modulus *gmp.Int, random io.Reader) ([]*Share, *Polynomial, error) {
+	if threshold > n {
+		return nil, nil, errors.New("threshold must not exceed the number of shares")
+	}
+
+	p, err := NewPolynomial(secret, threshold, modulus, random)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p.Shares(n), p, nil
+}
+
+// Threshold returns the number of shares needed to reconstruct the secret
+func (p *Polynomial) Threshold() int {
+	return len(p.Coefficients)
+}
+
+// Evaluate returns f(x) mod Modulus
+func (p *Polynomial) Evaluate(x int) *gmp.Int {
+	result := gmp.NewInt(0)
+	xi := gmp.NewInt(1)
+	bx := gmp.NewInt(int64(x))
+	for _, a := range p.Coefficients {
+		result.Add(result, new(gmp.Int).Mul(a, xi))
+		xi.Mul(xi, bx)
+	}
+	return result.Mod(result, p.Modulus)
+}
+
+// Shares returns the shares of the parties 1 to n
+func (p *Polynomial) Shares(n int) []*Share {
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{X: i + 1, Y: p.Evaluate(i + 1)}
+	}
+	return shares
+}
+
+// Commit returns the Feldman commitments to the coefficients of the polynomial
+// in the group generated by g mod modulus
+func (p *Polynomial) Commit(g, modulus *gmp.Int) *Commitments {
+	values := make([]*gmp.Int, len(p.Coefficients))
+	for i, a := range p.Coefficients {
+		values[i] = new(gmp.Int).Exp(g, a, modulus)
+	}
+	return &Commitments{G: g, Modulus: modulus, Values: values}
+}
+
+// Verify returns true iff the share is consistent with the committed
+// polynomial, i.e., G^Y = prod_i Values[i]^(X^i) mod Modulus
+func (c *Commitments) Verify(share *Share) bool {
+	if share == nil || share.Y == nil || share.X <= 0 || len(c.Values) == 0 {
+		return false
+	}
+
+	expected := gmp.NewInt(1)
+	xi := gmp.NewInt(1)
+	bx := gmp.NewInt(int64(share.X))
+	for _, value := range c.Values {
+		expected.Mul(expected, new(gmp.Int).Exp(value, xi, c.Modulus))
+		expected.Mod(expected, c.Modulus)
+		xi.Mul(xi, bx)
+	}
+
+	actual := new(gmp.Int).Exp(c.G, share.Y, c.Modulus)
+	return actual.Cmp(expected) == 0
+}
+
+// Reconstruct interpolates the secret f(0) mod modulus from the shares, which
+// must contain at least threshold shares with distinct indices. The result is
+// only correct if enough shares are given.
+func Reconstruct(shares []*Share, modulus *gmp.Int) (*gmp.Int, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares provided")
+	}
+
+	xs, err := indices(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := gmp.NewInt(0)
+	for _, share := range shares {
+		num := gmp.NewInt(1)
+		denom := gmp.NewInt(1)
+		for _, x := range xs {
+			if x != share.X {
+				num.Mul(num, gmp.NewInt(int64(-x)))
+				denom.Mul(denom, gmp.NewInt(int64(share.X-x)))
+			}
+		}
+
+		denom.Mod(denom, modulus)
+		if new(gmp.Int).GCD(nil, nil, denom, modulus).Cmp(gmp.NewInt(1)) != 0 {
+			return nil, errors.New("share indices are not invertible modulo the modulus")
+		}
+
+		lambda := new(gmp.Int).ModInverse(denom, modulus)
+		lambda.Mul(lambda, num)
+		secret.Add(secret, lambda.Mul(lambda, share.Y))
+	}
+
+	return secret.Mod(secret, modulus), nil
+}
+
+// Delta returns n!, the factor by which LagrangeCoefficient scales the
+// coefficients of n parties to make them integers
+func Delta(n int) *gmp.Int {
+	delta := gmp.NewInt(1)
+	for i := 2; i <= n; i++ {
+		delta.Mul(delta, gmp.NewInt(int64(i)))
+	}
+	return delta
+}
+
+// LagrangeCoefficient returns the integer delta * prod_{j != x} -j / (x - j)
+// over the indices xs, where delta is Delta(n) for indices in 1..n.
+// Combining the shares with these coefficients yields delta * f(0) without
+// knowledge of the modulus, e.g., in the exponent of a group of unknown order.
+func LagrangeCoefficient(x int, xs []int, delta *gmp.Int) *gmp.Int {
+	return LagrangeCoefficientAt(x, 0, xs, delta)
+}
+
+// LagrangeCoefficientAt returns the integer delta * prod_{j != x} (at - j) / (x - j)
+// which combines the shares of the indices xs to delta * f(at), e.g., to check
+// in the exponent that a further share lies on the same polynomial
+func LagrangeCoefficientAt(x, at int, xs []int, delta *gmp.Int) *gmp.Int {
+	lambda := new(gmp.Int).Set(delta)
+	for _, j := range xs {
+		if j != x {
+			lambda = lagrangeStep(x, j, at, lambda)
+		}
+	}
+	return lambda
+}
+
+// multiplies lambda by (at - j) / (x - j); the division is exact for the
+// partial products of delta = n! and indices in 1..n
+func lagrangeStep(x, j, at int, lambda *gmp.Int) *gmp.Int {
+	num := new(gmp.Int).Mul(lambda, gmp.NewInt(int64(at-j)))
+	denom := gmp.NewInt(int64(x - j))
+	return new(gmp.Int).Div(num, denom)
+}
+
+// returns the indices of the shares or an error if they are not distinct
+func indices(shares []*Share) ([]int, error) {
+	xs := make([]int, len(shares))
+	seen := make(map[int]bool)
+	for i, share := range shares {
+		if share == nil || share.Y == nil || share.X <= 0 {
+			return nil, errors.New("invalid share")
+		}
+		if seen[share.X] {
+			return nil, errors.New("two shares have the same index")
+		}
+		seen[share.X] = true
+		xs[i] = share.X
+	}
+	return xs, nil
+}
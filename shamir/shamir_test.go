@@ -0,0 +1,103 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestSplitReconstruct(t *testing.T) {
+	modulus := gmp.NewInt(2147483647) // prime
+	secret := gmp.NewInt(123456789)
+
+	shares, _, err := Split(secret, 3, 5, modulus, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, subset := range [][]*Share{shares[:3], shares[2:], {shares[4], shares[0], shares[2]}, shares} {
+		actual, err := Reconstruct(subset, modulus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Cmp(secret) != 0 {
+			t.Error("wrong secret ", actual)
+		}
+	}
+
+	if _, err := Reconstruct([]*Share{shares[0], shares[0], shares[1]}, modulus); err == nil {
+		t.Error("expected error for duplicate shares")
+	}
+
+	if _, _, err := Split(secret, 6, 5, modulus, rand.Reader); err == nil {
+		t.Error("expected error for threshold above the number of shares")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p := &Polynomial{
+		Coefficients: []*gmp.Int{gmp.NewInt(29), gmp.NewInt(88), gmp.NewInt(51)},
+		Modulus:      gmp.NewInt(103),
+	}
+	if y := p.Evaluate(3); y.Int64() != 31 {
+		t.Error("wrong evaluation ", y)
+	}
+}
+
+func TestLagrangeCoefficient(t *testing.T) {
+	if lambda := lagrangeStep(3, 7, 0, gmp.NewInt(11)); lambda.Int64() != 20 {
+		t.Error("wrong lambda ", lambda)
+	}
+
+	// delta * f(0) over the integers for f(X) = 5 + 2X + 3X^2
+	f := &Polynomial{
+		Coefficients: []*gmp.Int{gmp.NewInt(5), gmp.NewInt(2), gmp.NewInt(3)},
+		Modulus:      gmp.NewInt(1000000),
+	}
+	xs := []int{1, 3, 4}
+	delta := Delta(4)
+
+	sum := gmp.NewInt(0)
+	for _, x := range xs {
+		lambda := LagrangeCoefficient(x, xs, delta)
+		sum.Add(sum, lambda.Mul(lambda, f.Evaluate(x)))
+	}
+	if sum.Int64() != 5*24 {
+		t.Error("wrong scaled secret ", sum)
+	}
+
+	// delta * f(2) = 24 * 21
+	sum.SetInt64(0)
+	for _, x := range xs {
+		lambda := LagrangeCoefficientAt(x, 2, xs, delta)
+		sum.Add(sum, lambda.Mul(lambda, f.Evaluate(x)))
+	}
+	if sum.Int64() != 24*21 {
+		t.Error("wrong scaled evaluation ", sum)
+	}
+}
+
+func TestCommitments(t *testing.T) {
+	// g = 4 generates the subgroup of order q = 11 of Z_23^*
+	q := gmp.NewInt(11)
+	p := gmp.NewInt(23)
+	g := gmp.NewInt(4)
+
+	shares, polynomial, err := Split(gmp.NewInt(7), 3, 5, q, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commitments := polynomial.Commit(g, p)
+	for _, share := range shares {
+		if !commitments.Verify(share) {
+			t.Error("valid share rejected ", share.X)
+		}
+	}
+
+	forged := &Share{X: shares[0].X, Y: new(gmp.Int).Add(shares[0].Y, gmp.NewInt(1))}
+	if commitments.Verify(forged) {
+		t.Error("forged share accepted")
+	}
+}
@@ -0,0 +1,64 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+// GenerateKeysWithCommitments is GenerateKeys that also returns the Feldman
+// commitments V^a_k mod N^2 to the coefficients of the sharing polynomial.
+// The dealer publishes the commitments with the public key so that every
+// server can check its share with VerifyShare before accepting it.
+func (tkg *ThresholdKeyGenerator) GenerateKeysWithCommitments() ([]*ThresholdSecretKey, *shamir.Commitments, error) {
+	tsks, err := tkg.GenerateKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	return tsks, tkg.polynomial.Commit(tkg.v, tkg.n2), nil
+}
+
+// VerifyCommitments checks that the commitments are to a polynomial of
+// degree Threshold-1 in the group of V and that the verification keys are
+// its evaluations, i.e., V_i = (prod_k C_k^(i^k))^delta mod N^2. Since V has
+// order dividing N*m, the check holds for shares reduced modulo N^S*m.
+func (tk *ThresholdPublicKey) VerifyCommitments(c *shamir.Commitments) error {
+	n2 := tk.GetN2()
+	if c == nil || c.G == nil || c.Modulus == nil || c.G.Cmp(tk.VerificationKey) != 0 || c.Modulus.Cmp(n2) != 0 {
+		return errors.New("commitments are not in the group of the verification key")
+	}
+	if len(c.Values) != tk.Threshold {
+		return fmt.Errorf("expected %d commitments, got %d", tk.Threshold, len(c.Values))
+	}
+	for _, value := range c.Values {
+		if value == nil || value.Sign() <= 0 || value.Cmp(n2) >= 0 {
+			return errors.New("commitment is not in Z_N^2")
+		}
+	}
+	if len(tk.VerificationKeys) != tk.TotalNumberOfDecryptionServers {
+		return errors.New("public key is missing verification keys")
+	}
+
+	for i, vi := range tk.VerificationKeys {
+		expected := new(gmp.Int).Exp(evaluateInExponent(c.Values, i+1, n2), tk.delta(), n2)
+		if vi.Cmp(expected) != 0 {
+			return fmt.Errorf("verification key %d does not match the commitments", i+1)
+		}
+	}
+	return nil
+}
+
+// VerifyShare checks the commitments against the public key with
+// VerifyCommitments and the server's share against the commitments, so a
+// dealer cannot hand out a share that is inconsistent with the published key
+func (tsk *ThresholdSecretKey) VerifyShare(c *shamir.Commitments) error {
+	if err := tsk.VerifyCommitments(c); err != nil {
+		return err
+	}
+	if !c.Verify(&shamir.Share{X: tsk.ID, Y: tsk.Share}) {
+		return fmt.Errorf("share of server %d does not match the commitments", tsk.ID)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+func TestGenerateKeysWithCommitments(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tsks, commitments, err := tkg.GenerateKeysWithCommitments()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tsk := range tsks {
+		if err := tsk.VerifyShare(commitments); err != nil {
+			t.Error(err)
+		}
+	}
+
+	bad := *tsks[2]
+	bad.Share = new(gmp.Int).Add(tsks[2].Share, OneBigInt)
+	if err := bad.VerifyShare(commitments); err == nil {
+		t.Error("expected an error for an inconsistent share")
+	}
+
+	tk := tsks[0].PublicOnly()
+	tk.VerificationKeys[4] = tk.VerificationKeys[3]
+	if err := tk.VerifyCommitments(commitments); err == nil {
+		t.Error("expected an error for an inconsistent verification key")
+	}
+
+	short := &shamir.Commitments{G: commitments.G, Modulus: commitments.Modulus, Values: commitments.Values[:2]}
+	if err := tsks[0].VerifyCommitments(short); err == nil {
+		t.Error("expected an error for a polynomial of the wrong degree")
+	}
+}
+
+func TestGenerateKeysWithCommitmentsLevelTwo(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tkg.S = 2
+	tsks, commitments, err := tkg.GenerateKeysWithCommitments()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tsk := range tsks {
+		if err := tsk.VerifyShare(commitments); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package paillier
+
+import (
+	gmp "github.com/ncw/gmp"
+)
+
+// ShareDecrypter performs the operations of a ThresholdSecretKey that need
+// its secret share, so that the share can be kept in a hardware security
+// module, a PKCS#11 token or a cloud KMS instead of the memory of the
+// process. Partial decryptions and their proofs, including the aggregated
+// proofs of batches, only use the share through these two operations.
+// Implementations must be safe for concurrent use.
+type ShareDecrypter interface {
+	// ExpShare returns c^(k*share) mod m for the public values c, k and m
+	ExpShare(c, k, m *gmp.Int) (*gmp.Int, error)
+
+	// MulAddShare returns r + k*share over the integers, the response of the
+	// proofs of partial decryptions. The random mask r is much longer than
+	// k*share and must not be revealed.
+	MulAddShare(r, k *gmp.Int) (*gmp.Int, error)
+}
+
+// SetShareDecrypter delegates the operations that need the share to d, in
+// which case Share may be nil. Passing nil restores the software
+// implementation, which uses Share and honors EnableHardening.
+// Operations on the share itself, e.g., Reshare, NewShareRecovery,
+// VerifyShare and the encodings of the key, still require Share.
+func (tsk *ThresholdSecretKey) SetShareDecrypter(d ShareDecrypter) {
+	tsk.decrypter = d
+}
+
+func (tsk *ThresholdSecretKey) shareDecrypter() ShareDecrypter {
+	if tsk.decrypter == nil {
+		return softwareShareDecrypter{tsk}
+	}
+	return tsk.decrypter
+}
+
+// softwareShareDecrypter is the default ShareDecrypter using the share in
+// memory
+type softwareShareDecrypter struct {
+	tsk *ThresholdSecretKey
+}
+
+func (s softwareShareDecrypter) ExpShare(c, k, m *gmp.Int) (*gmp.Int, error) {
+	exp := new(gmp.Int).Mul(s.tsk.Share, k)
+	if s.tsk.hardened {
+		return s.tsk.splitExp(c, exp, m), nil
+	}
+	return new(gmp.Int).Exp(c, exp, m), nil
+}
+
+func (s softwareShareDecrypter) MulAddShare(r, k *gmp.Int) (*gmp.Int, error) {
+	tmp := new(gmp.Int).Mul(k, s.tsk.Share)
+	return tmp.Add(tmp, r), nil
+}
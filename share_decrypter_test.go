@@ -0,0 +1,93 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// testShareDecrypter stands in for an HSM holding the share
+type testShareDecrypter struct {
+	share *gmp.Int
+	calls int64
+	err   error
+}
+
+func (d *testShareDecrypter) ExpShare(c, k, m *gmp.Int) (*gmp.Int, error) {
+	atomic.AddInt64(&d.calls, 1)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return new(gmp.Int).Exp(c, new(gmp.Int).Mul(k, d.share), m), nil
+}
+
+func (d *testShareDecrypter) MulAddShare(r, k *gmp.Int) (*gmp.Int, error) {
+	atomic.AddInt64(&d.calls, 1)
+	if d.err != nil {
+		return nil, d.err
+	}
+	tmp := new(gmp.Int).Mul(k, d.share)
+	return tmp.Add(tmp, r), nil
+}
+
+func TestShareDecrypter(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	// the share only lives in the decrypter
+	hsm := &testShareDecrypter{share: tsks[1].Share}
+	tsks[1].Share = nil
+	tsks[1].SetShareDecrypter(hsm)
+
+	ct := tk.Encrypt(b(21))
+	share1, err := tsks[0].PartialDecryptionWithZKP(ct.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share2, err := tsks[1].PartialDecryptionWithZKP(ct.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hsm.calls != 2 {
+		t.Error("share decrypter was called ", hsm.calls, " times")
+	}
+
+	m, err := tk.CombinePartialDecryptionsZKP([]*PartialDecryptionZKP{share1, share2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 21 {
+		t.Error("wrong decryption ", m)
+	}
+
+	cts := []*gmp.Int{ct.C, tk.Encrypt(b(3)).C}
+	pds, proof, err := tsks[1].PartialDecryptBatchWithProof(cts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tk.VerifyAggregatedDecryptionProof(cts, pds, proof); err != nil {
+		t.Error(err)
+	}
+
+	hsm.err = errors.New("token removed")
+	if _, err := tsks[1].PartialDecryptionWithZKP(ct.C); !errors.Is(err, hsm.err) {
+		t.Error("expected the error of the share decrypter, got ", err)
+	}
+	if _, err := tsks[1].PartialDecryptAtLevel(ct.C, EncLevelOne); !errors.Is(err, hsm.err) {
+		t.Error("expected the error of the share decrypter, got ", err)
+	}
+	if tsks[1].PartialDecrypt(ct.C) != nil {
+		t.Error("expected no partial decryption if the share decrypter fails")
+	}
+}
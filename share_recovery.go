@@ -0,0 +1,172 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
+)
+
+// recoveryStatisticalSecurity is the number of bits by which the masks of a
+// share recovery exceed the contributions they hide
+const recoveryStatisticalSecurity = 40
+
+// ErrShareNotRecoverable is returned by RecoverShare if the combined
+// contributions do not determine the lost share. This is the case for keys
+// dealt by ThresholdKeyGenerator or SplitIntoThresholdShares, whose shares
+// are reduced modulo a secret modulus; resharing them to the same committee
+// once with Reshare yields shares that can be recovered.
+var ErrShareNotRecoverable = errors.New("lost share cannot be recovered from the shares of the helpers")
+
+// RecoveryMask is sent privately between two helpers of a share recovery
+type RecoveryMask struct {
+	From, To int
+	Mask     *gmp.Int
+}
+
+// RecoveryContribution is sent privately from a helper to the server that
+// lost its share and holds the helper's masked Lagrange term
+type RecoveryContribution struct {
+	From, To int
+	Value    *gmp.Int
+}
+
+// ShareRecovery holds the state of a helper in the recovery of the share of
+// a server that lost its key:
+//  1. every helper i sends a random mask r_ij to every other helper j
+//  2. helper i sends lambda_i s_i + sum_j r_ij - sum_j r_ji to the lost
+//     server, where lambda_i are the Lagrange coefficients at the lost ID
+//     scaled by delta
+//  3. the lost server adds the contributions, in which the masks cancel, and
+//     divides the result delta s_lost by delta
+//
+// The lost server learns only its share and the helpers learn nothing. All
+// messages must be sent over confidential channels.
+type ShareRecovery struct {
+	tsk     *ThresholdSecretKey
+	lost    int
+	helpers []int
+	masks   []*RecoveryMask // sent by the helper
+}
+
+// NewShareRecovery starts the recovery of the share of server lost with the
+// given helpers, which must be at least Threshold servers in increasing
+// order, and returns the masks for the other helpers
+func (tsk *ThresholdSecretKey) NewShareRecovery(lost int, helpers []int, random io.Reader) (*ShareRecovery, []*RecoveryMask, error) {
+	if err := tsk.checkRecovery(lost, helpers); err != nil {
+		return nil, nil, err
+	}
+	if !containsID(helpers, tsk.ID) {
+		return nil, nil, errors.New("server is not one of the helpers")
+	}
+
+	term := tsk.recoveryTerm(lost, helpers)
+	bound := new(gmp.Int).Lsh(OneBigInt, uint(term.BitLen()+recoveryStatisticalSecurity))
+
+	masks := make([]*RecoveryMask, 0, len(helpers)-1)
+	for _, id := range helpers {
+		if id == tsk.ID {
+			continue
+		}
+		mask, err := GetRandomNumber(bound, random)
+		if err != nil {
+			return nil, nil, err
+		}
+		masks = append(masks, &RecoveryMask{From: tsk.ID, To: id, Mask: mask})
+	}
+
+	rec := &ShareRecovery{tsk: tsk, lost: lost, helpers: append([]int{}, helpers...), masks: masks}
+	return rec, masks, nil
+}
+
+// Contribute takes the masks sent to the helper by all other helpers and
+// returns its contribution for the lost server
+func (rec *ShareRecovery) Contribute(received []*RecoveryMask) (*RecoveryContribution, error) {
+	value := rec.tsk.recoveryTerm(rec.lost, rec.helpers)
+	for _, mask := range rec.masks {
+		value.Add(value, mask.Mask)
+	}
+
+	seen := make(map[int]bool, len(received))
+	for _, mask := range received {
+		if mask == nil || mask.Mask == nil {
+			return nil, errors.New("missing recovery mask")
+		}
+		if mask.To != rec.tsk.ID {
+			return nil, fmt.Errorf("mask of helper %d is for server %d", mask.From, mask.To)
+		}
+		if mask.From == rec.tsk.ID || !containsID(rec.helpers, mask.From) || seen[mask.From] {
+			return nil, fmt.Errorf("unexpected mask of server %d", mask.From)
+		}
+		seen[mask.From] = true
+		value.Sub(value, mask.Mask)
+	}
+	if len(seen) != len(rec.helpers)-1 {
+		return nil, errors.New("missing the mask of a helper")
+	}
+
+	return &RecoveryContribution{From: rec.tsk.ID, To: rec.lost, Value: value}, nil
+}
+
+// RecoverShare returns the threshold secret key of server lost from the
+// contributions of all helpers. The recovered share is checked against the
+// verification key of the server.
+func (tk *ThresholdPublicKey) RecoverShare(lost int, helpers []int, contributions []*RecoveryContribution) (*ThresholdSecretKey, error) {
+	if err := tk.checkRecovery(lost, helpers); err != nil {
+		return nil, err
+	}
+
+	sum := new(gmp.Int)
+	seen := make(map[int]bool, len(contributions))
+	for _, c := range contributions {
+		if c == nil || c.Value == nil {
+			return nil, errors.New("missing recovery contribution")
+		}
+		if c.To != lost {
+			return nil, fmt.Errorf("contribution of helper %d is for server %d", c.From, c.To)
+		}
+		if !containsID(helpers, c.From) || seen[c.From] {
+			return nil, fmt.Errorf("unexpected contribution of server %d", c.From)
+		}
+		seen[c.From] = true
+		sum.Add(sum, c.Value)
+	}
+	if len(seen) != len(helpers) {
+		return nil, errors.New("missing the contribution of a helper")
+	}
+
+	share, remainder := new(gmp.Int).DivMod(sum, tk.delta(), new(gmp.Int))
+	if remainder.Sign() != 0 || share.Sign() <= 0 {
+		return nil, ErrShareNotRecoverable
+	}
+
+	n2 := tk.GetN2()
+	vi := new(gmp.Int).Exp(tk.VerificationKey, new(gmp.Int).Mul(tk.delta(), share), n2)
+	if vi.Cmp(tk.VerificationKeys[lost-1]) != 0 {
+		return nil, ErrShareNotRecoverable
+	}
+
+	return &ThresholdSecretKey{ThresholdPublicKey: *tk.deepCopy(), ID: lost, Share: share}, nil
+}
+
+// returns lambda_i s_i for the Lagrange coefficient lambda_i at lost,
+// scaled by delta
+func (tsk *ThresholdSecretKey) recoveryTerm(lost int, helpers []int) *gmp.Int {
+	lambda := shamir.LagrangeCoefficientAt(tsk.ID, lost, helpers, tsk.delta())
+	return lambda.Mul(lambda, tsk.Share)
+}
+
+func (tk *ThresholdPublicKey) checkRecovery(lost int, helpers []int) error {
+	if lost < 1 || lost > tk.TotalNumberOfDecryptionServers {
+		return errors.New("ID of the lost server is out of range")
+	}
+	if len(tk.VerificationKeys) != tk.TotalNumberOfDecryptionServers {
+		return errors.New("public key is missing verification keys")
+	}
+	if containsID(helpers, lost) {
+		return errors.New("the lost server cannot be a helper")
+	}
+	return tk.checkServerSet(helpers)
+}
@@ -0,0 +1,138 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// runs the recovery of the share of lost with the helpers
+func recoverShare(t *testing.T, keys []*ThresholdSecretKey, lost int, helpers []int) (*ThresholdSecretKey, error) {
+	recs := make(map[int]*ShareRecovery)
+	masksTo := make(map[int][]*RecoveryMask)
+	for _, id := range helpers {
+		rec, masks, err := keys[id-1].NewShareRecovery(lost, helpers, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs[id] = rec
+		for _, mask := range masks {
+			masksTo[mask.To] = append(masksTo[mask.To], mask)
+		}
+	}
+
+	var contributions []*RecoveryContribution
+	for _, id := range helpers {
+		c, err := recs[id].Contribute(masksTo[id])
+		if err != nil {
+			t.Fatal(err)
+		}
+		contributions = append(contributions, c)
+	}
+
+	return keys[0].PublicOnly().RecoverShare(lost, helpers, contributions)
+}
+
+func TestRecoverShare(t *testing.T) {
+	keys := runDKGSteps(t, newDKGParticipants(t, 128, 5, 3))
+
+	recovered, err := recoverShare(t, keys, 4, []int{1, 2, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered.ID != 4 || recovered.Share.Cmp(keys[3].Share) != 0 {
+		t.Fatal("wrong share recovered")
+	}
+
+	ct := keys[0].Encrypt(gmp.NewInt(31))
+	checkThresholdDecryption(t, []*ThresholdSecretKey{keys[0], keys[1], recovered}, ct, 31)
+}
+
+func TestRecoverDealtShare(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 4, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	dealt, err := tkg.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// dealt shares are reduced modulo N*m and can be recovered only by chance
+	recovered, err := recoverShare(t, dealt, 1, []int{2, 3})
+	if err != nil && !errors.Is(err, ErrShareNotRecoverable) {
+		t.Fatal(err)
+	}
+	if err == nil {
+		checkThresholdDecryption(t, []*ThresholdSecretKey{recovered, dealt[1]}, dealt[0].Encrypt(gmp.NewInt(5)), 5)
+	}
+
+	// after resharing to the same committee, shares can be recovered
+	keys := reshare(t, dealt, []int{1, 2}, 4, 2)
+	recovered, err = recoverShare(t, keys, 1, []int{2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkThresholdDecryption(t, []*ThresholdSecretKey{keys[3], recovered}, dealt[0].Encrypt(gmp.NewInt(5)), 5)
+}
+
+func TestRecoverShareRejectsInvalidInput(t *testing.T) {
+	keys := runDKGSteps(t, newDKGParticipants(t, 128, 3, 2))
+	helpers := []int{1, 2}
+
+	if _, _, err := keys[2].NewShareRecovery(3, helpers, rand.Reader); err == nil {
+		t.Error("expected an error for the lost server as a helper")
+	}
+	if _, _, err := keys[0].NewShareRecovery(3, []int{1}, rand.Reader); err == nil {
+		t.Error("expected an error for fewer helpers than the threshold")
+	}
+	if _, _, err := keys[0].NewShareRecovery(3, []int{1, 3}, rand.Reader); err == nil {
+		t.Error("expected an error for the lost server among the helpers")
+	}
+
+	rec1, masks1, err := keys[0].NewShareRecovery(3, helpers, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec2, masks2, err := keys[1].NewShareRecovery(3, helpers, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec1.Contribute(nil); err == nil {
+		t.Error("expected an error for a missing mask")
+	}
+	if _, err := rec1.Contribute(masks1); err == nil {
+		t.Error("expected an error for a mask for another helper")
+	}
+
+	c1, err := rec1.Contribute(masks2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := rec2.Contribute(masks1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tk := keys[0].PublicOnly()
+	if _, err := tk.RecoverShare(3, helpers, []*RecoveryContribution{c1}); err == nil {
+		t.Error("expected an error for a missing contribution")
+	}
+
+	tampered := *c2
+	tampered.Value = new(gmp.Int).Add(c2.Value, tk.delta())
+	if _, err := tk.RecoverShare(3, helpers, []*RecoveryContribution{c1, &tampered}); !errors.Is(err, ErrShareNotRecoverable) {
+		t.Error("expected ErrShareNotRecoverable for a wrong contribution, got ", err)
+	}
+
+	recovered, err := tk.RecoverShare(3, helpers, []*RecoveryContribution{c1, c2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered.Share.Cmp(keys[2].Share) != 0 {
+		t.Error("wrong share recovered")
+	}
+}
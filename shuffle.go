@@ -0,0 +1,250 @@
+package paillier
+
+import (
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// ShuffleRounds is the number of cut-and-choose rounds of a ShuffleProof; a
+// cheating prover succeeds with probability 2^-ShuffleRounds per evaluation
+// of the random oracle
+const ShuffleRounds = 128
+
+// ShuffleProof is a non-interactive proof (Fiat-Shamir heuristic) that the
+// outputs of Shuffle are a permutation of rerandomized inputs. It is the
+// cut-and-choose shuffle proof of [SK 95]: in every round the prover
+// commits to an intermediate shuffle of the inputs and, depending on a bit
+// of the challenge, opens either the shuffle from the inputs to the
+// intermediate ciphertexts or the one from the intermediate ciphertexts to
+// the outputs. Opening one of them reveals nothing about the permutation of
+// the outputs.
+//
+// The proof has ShuffleRounds * len(inputs) ciphertexts, so it suits the
+// small batches of surveys and elections rather than large mixnets.
+//
+//	[SK 95]: Kazue Sako, Joe Kilian, (1995)
+//	         Receipt-Free Mix-Type Voting Scheme
+type ShuffleProof struct {
+	Intermediates [][]*gmp.Int // per round, the ciphertexts of the intermediate shuffle
+	Permutations  [][]int      // per round, the opened permutation
+	Randomness    [][]*gmp.Int // per round, the opened rerandomization units in Z_N^*
+}
+
+// Shuffle returns the ciphertexts in a uniformly random order, each
+// rerandomized, together with a proof of correct shuffle. The ciphertexts
+// must all be at the same level.
+func (pk *PublicKey) Shuffle(cts []*Ciphertext) ([]*Ciphertext, *ShuffleProof, error) {
+	level, err := shuffleLevel(cts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	random := pk.RandomSource()
+	permutation, randomness, err := pk.randomShuffle(len(cts), random)
+	if err != nil {
+		return nil, nil, err
+	}
+	outputs := pk.applyShuffle(cts, permutation, randomness, level)
+
+	intermediates := make([][]*Ciphertext, ShuffleRounds)
+	permutations := make([][]int, ShuffleRounds)
+	randomnesses := make([][]*gmp.Int, ShuffleRounds)
+	for j := range intermediates {
+		if permutations[j], randomnesses[j], err = pk.randomShuffle(len(cts), random); err != nil {
+			return nil, nil, err
+		}
+		intermediates[j] = pk.applyShuffle(cts, permutations[j], randomnesses[j], level)
+	}
+
+	proof := &ShuffleProof{
+		Intermediates: make([][]*gmp.Int, ShuffleRounds),
+		Permutations:  make([][]int, ShuffleRounds),
+		Randomness:    make([][]*gmp.Int, ShuffleRounds),
+	}
+	for j, round := range intermediates {
+		proof.Intermediates[j] = make([]*gmp.Int, len(round))
+		for k, ct := range round {
+			proof.Intermediates[j][k] = ct.C
+		}
+	}
+
+	e := pk.shuffleChallenge(cts, outputs, proof.Intermediates)
+	for j := range intermediates {
+		if e.Bit(j) == 0 {
+			// open inputs -> intermediates
+			proof.Permutations[j] = permutations[j]
+			proof.Randomness[j] = randomnesses[j]
+			continue
+		}
+
+		// open intermediates -> outputs: intermediate k = pi_j(i) moves to
+		// pi(i) with randomness r_i / rho_(j,i)
+		sigma := make([]int, len(cts))
+		tau := make([]*gmp.Int, len(cts))
+		for i := range cts {
+			k := permutations[j][i]
+			sigma[k] = permutation[i]
+			tau[k] = new(gmp.Int).ModInverse(randomnesses[j][i], pk.N)
+			tau[k].Mul(tau[k], randomness[i])
+			tau[k].Mod(tau[k], pk.N)
+		}
+		proof.Permutations[j] = sigma
+		proof.Randomness[j] = tau
+	}
+
+	return outputs, proof, nil
+}
+
+// VerifyShuffle returns true iff the proof shows that outputs are a
+// rerandomized permutation of inputs
+func (pk *PublicKey) VerifyShuffle(inputs, outputs []*Ciphertext, proof *ShuffleProof) bool {
+	return pk.VerifyShuffleErr(inputs, outputs, proof) == nil
+}
+
+// VerifyShuffleErr verifies the proof as VerifyShuffle and returns an error
+// wrapping ErrMalformedProof, ErrProofPart1 (an opened shuffle of the inputs
+// does not match its intermediate ciphertexts) or ErrProofPart2 (an opened
+// shuffle of intermediate ciphertexts does not match the outputs) if it is
+// rejected
+func (pk *PublicKey) VerifyShuffleErr(inputs, outputs []*Ciphertext, proof *ShuffleProof) error {
+	level, err := shuffleLevel(inputs)
+	if err != nil {
+		return err
+	}
+	if len(outputs) != len(inputs) {
+		return fmt.Errorf("%w: %d outputs for %d inputs", ErrMalformedProof, len(outputs), len(inputs))
+	}
+	for i, ct := range outputs {
+		if ct == nil || ct.C == nil || ct.Level != level {
+			return fmt.Errorf("%w: output %d", ErrInvalidCiphertext, i)
+		}
+	}
+	if proof == nil || len(proof.Intermediates) != ShuffleRounds ||
+		len(proof.Permutations) != ShuffleRounds || len(proof.Randomness) != ShuffleRounds {
+		return fmt.Errorf("%w: proof must have %d rounds", ErrMalformedProof, ShuffleRounds)
+	}
+
+	_, _, ns1 := pk.getModuliForLevel(level)
+	for j := 0; j < ShuffleRounds; j++ {
+		if len(proof.Intermediates[j]) != len(inputs) || len(proof.Randomness[j]) != len(inputs) ||
+			!isPermutation(proof.Permutations[j], len(inputs)) {
+			return fmt.Errorf("%w: round %d", ErrMalformedProof, j)
+		}
+		for k := range inputs {
+			c, r := proof.Intermediates[j][k], proof.Randomness[j][k]
+			if c == nil || c.Sign() <= 0 || c.Cmp(ns1) >= 0 || r == nil {
+				return fmt.Errorf("%w: round %d", ErrMalformedProof, j)
+			}
+			if err := pk.checkUnits(r); err != nil {
+				return fmt.Errorf("round %d: %w", j, err)
+			}
+		}
+	}
+
+	e := pk.shuffleChallenge(inputs, outputs, proof.Intermediates)
+	for j := 0; j < ShuffleRounds; j++ {
+		intermediates := make([]*Ciphertext, len(inputs))
+		for k, c := range proof.Intermediates[j] {
+			intermediates[k] = &Ciphertext{c, level, MixedEncryption}
+		}
+
+		from, to, part := inputs, intermediates, ErrProofPart1
+		if e.Bit(j) == 1 {
+			from, to, part = intermediates, outputs, ErrProofPart2
+		}
+
+		expected := pk.applyShuffle(from, proof.Permutations[j], proof.Randomness[j], level)
+		for k, ct := range expected {
+			if ct.C.Cmp(to[k].C) != 0 {
+				return fmt.Errorf("round %d: %w", j, part)
+			}
+		}
+	}
+
+	return nil
+}
+
+// randomShuffle returns a random permutation of n ciphertexts and random
+// units to rerandomize them
+func (pk *PublicKey) randomShuffle(n int, random RandomSource) ([]int, []*gmp.Int, error) {
+	permutation, err := randomPermutation(n, random)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	randomness := make([]*gmp.Int, n)
+	for i := range randomness {
+		if randomness[i], err = GetRandomNumberInMultiplicativeGroup(pk.N, random); err != nil {
+			return nil, nil, err
+		}
+	}
+	return permutation, randomness, nil
+}
+
+// applyShuffle returns the ciphertexts with cts[i] * randomness[i]^(N^s) at
+// position permutation[i]
+func (pk *PublicKey) applyShuffle(cts []*Ciphertext, permutation []int, randomness []*gmp.Int, level EncryptionLevel) []*Ciphertext {
+	_, ns, ns1 := pk.getModuliForLevel(level)
+
+	shuffled := make([]*Ciphertext, len(cts))
+	for i, ct := range cts {
+		c := new(gmp.Int).Exp(randomness[i], ns, ns1)
+		c.Mul(c, ct.C)
+		shuffled[permutation[i]] = &Ciphertext{c.Mod(c, ns1), level, MixedEncryption}
+	}
+	return shuffled
+}
+
+// shuffleChallenge returns the Fiat-Shamir challenge whose bit j selects the
+// shuffle opened in round j
+func (pk *PublicKey) shuffleChallenge(inputs, outputs []*Ciphertext, intermediates [][]*gmp.Int) *gmp.Int {
+	values := []*gmp.Int{pk.N, gmp.NewInt(int64(inputs[0].Level)), gmp.NewInt(int64(len(inputs)))}
+	for _, ct := range inputs {
+		values = append(values, ct.C)
+	}
+	for _, ct := range outputs {
+		values = append(values, ct.C)
+	}
+	for _, round := range intermediates {
+		values = append(values, round...)
+	}
+	return RandomOracleChallenge(ShuffleRounds, values...)
+}
+
+// shuffleLevel returns the level of the ciphertexts, which must be non-empty
+// and all at the same level
+func shuffleLevel(cts []*Ciphertext) (EncryptionLevel, error) {
+	if len(cts) == 0 {
+		return 0, fmt.Errorf("%w: no ciphertexts to shuffle", ErrInvalidCiphertext)
+	}
+
+	level := EncryptionLevel(0)
+	for i, ct := range cts {
+		if ct == nil || ct.C == nil {
+			return 0, fmt.Errorf("%w: ciphertext %d is missing", ErrInvalidCiphertext, i)
+		}
+		if i == 0 {
+			level = ct.Level
+		}
+		if ct.Level != level || level < EncLevelOne || level > MaxEncryptionLevel {
+			return 0, fmt.Errorf("%w: ciphertexts must be at the same level", ErrInvalidCiphertext)
+		}
+	}
+	return level, nil
+}
+
+// isPermutation returns true iff p is a permutation of 0..n-1
+func isPermutation(p []int, n int) bool {
+	if len(p) != n {
+		return false
+	}
+	seen := make([]bool, n)
+	for _, v := range p {
+		if v < 0 || v >= n || seen[v] {
+			return false
+		}
+		seen[v] = true
+	}
+	return true
+}
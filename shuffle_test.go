@@ -0,0 +1,105 @@
+package paillier
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestShuffle(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+		inputs := make([]*Ciphertext, 6)
+		for i := range inputs {
+			inputs[i] = pk.EncryptAtLevel(gmp.NewInt(int64(10*i)), level)
+		}
+
+		outputs, proof, err := pk.Shuffle(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pk.VerifyShuffleErr(inputs, outputs, proof); err != nil {
+			t.Fatal(err)
+		}
+
+		plaintexts := make([]int, len(outputs))
+		for i, ct := range outputs {
+			if ct.C.Cmp(inputs[i].C) == 0 {
+				t.Error("output ", i, " was not rerandomized")
+			}
+			plaintexts[i] = n(sk.Decrypt(ct))
+		}
+		sort.Ints(plaintexts)
+		for i, m := range plaintexts {
+			if m != 10*i {
+				t.Errorf("shuffled plaintexts are %v, expected a permutation of the inputs", plaintexts)
+				break
+			}
+		}
+	}
+}
+
+func TestShuffleProofSoundness(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	inputs := make([]*Ciphertext, 4)
+	for i := range inputs {
+		inputs[i] = pk.Encrypt(gmp.NewInt(int64(i)))
+	}
+
+	outputs, proof, err := pk.Shuffle(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an output replaced by the encryption of another plaintext
+	cheating := append([]*Ciphertext{}, outputs...)
+	cheating[2] = pk.Encrypt(gmp.NewInt(7))
+	if err := pk.VerifyShuffleErr(inputs, cheating, proof); err == nil {
+		t.Error("expected an error for a replaced output")
+	}
+
+	// the proof of another shuffle
+	_, other, err := pk.Shuffle(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.VerifyShuffle(inputs, outputs, other) {
+		t.Error("expected an error for the proof of another shuffle")
+	}
+
+	if err := pk.VerifyShuffleErr(inputs, outputs[:3], proof); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected ErrMalformedProof, got ", err)
+	}
+
+	truncated := *proof
+	truncated.Permutations = proof.Permutations[1:]
+	if err := pk.VerifyShuffleErr(inputs, outputs, &truncated); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected ErrMalformedProof, got ", err)
+	}
+
+	broken := *proof
+	broken.Permutations = append([][]int{{0, 0, 1, 2}}, proof.Permutations[1:]...)
+	if err := pk.VerifyShuffleErr(inputs, outputs, &broken); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected ErrMalformedProof, got ", err)
+	}
+
+	// opened rerandomizers must be units of Z_N
+	for _, r := range []*gmp.Int{b(0), sk.crtParameters().p, pk.N, new(gmp.Int).Add(pk.N, b(1))} {
+		nonUnit := *proof
+		nonUnit.Randomness = append([][]*gmp.Int{}, proof.Randomness...)
+		nonUnit.Randomness[1] = append([]*gmp.Int{r}, proof.Randomness[1][1:]...)
+		if err := pk.VerifyShuffleErr(inputs, outputs, &nonUnit); !errors.Is(err, ErrMalformedProof) {
+			t.Error("expected ErrMalformedProof for the rerandomizer ", r, ", got ", err)
+		}
+	}
+
+	mixed := append([]*Ciphertext{}, inputs...)
+	mixed[1] = pk.NestedEncrypt(gmp.NewInt(1))
+	if _, _, err := pk.Shuffle(mixed); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext, got ", err)
+	}
+}
@@ -0,0 +1,110 @@
+package paillier
+
+import (
+	"errors"
+)
+
+// WindowStore persists the state of a SlidingWindow, e.g., to a database,
+// so that an aggregator can be restarted without losing the window
+type WindowStore interface {
+	// Save is called after every update of the window
+	Save(state *SlidingWindowState) error
+	// Load returns the last saved state or nil if there is none
+	Load() (*SlidingWindowState, error)
+}
+
+// SlidingWindowState is the persisted state of a SlidingWindow.
+// Entries are ordered from oldest to newest.
+type SlidingWindowState struct {
+	Size    int
+	Entries []*Ciphertext
+	Sum     *Ciphertext
+}
+
+// SlidingWindow maintains the encrypted sum of the last `Size` ciphertexts
+// of a time series. Every call to Push adds the new ciphertext to the sum
+// and subtracts the ciphertext that leaves the window.
+type SlidingWindow struct {
+	Key   *PublicKey
+	Size  int
+	store WindowStore
+
+	entries []*Ciphertext
+	sum     *Ciphertext
+}
+
+// NewSlidingWindow creates a window over the last `size` ciphertexts.
+// If store is not nil, the window is restored from the last saved state and
+// every update is persisted.
+func NewSlidingWindow(pk *PublicKey, size int, store WindowStore) (*SlidingWindow, error) {
+	if size <= 0 {
+		return nil, errors.New("window size must be positive")
+	}
+
+	sw := &SlidingWindow{
+		Key:     pk,
+		Size:    size,
+		store:   store,
+		entries: make([]*Ciphertext, 0, size),
+		sum:     pk.EncryptZero(),
+	}
+
+	if store == nil {
+		return sw, nil
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if state != nil {
+		if state.Size != size || len(state.Entries) > size || state.Sum == nil {
+			return nil, errors.New("stored window state does not match the window size")
+		}
+		sw.entries = append(sw.entries, state.Entries...)
+		sw.sum = state.Sum
+	}
+
+	return sw, nil
+}
+
+// Push adds the ciphertext to the window and returns the ciphertext that
+// expired from the window, if any
+func (sw *SlidingWindow) Push(ct *Ciphertext) (*Ciphertext, error) {
+	if ct.Level != sw.sum.Level {
+		return nil, errors.New("ciphertext level does not match the window")
+	}
+
+	var expired *Ciphertext
+	sum := sw.Key.Add(sw.sum, ct)
+	entries := append(sw.entries, ct)
+
+	if len(entries) > sw.Size {
+		expired = entries[0]
+		sum = sw.Key.Sub(sum, expired)
+		entries = entries[1:]
+	}
+
+	if sw.store != nil {
+		state := &SlidingWindowState{Size: sw.Size, Entries: entries, Sum: sum}
+		if err := sw.store.Save(state); err != nil {
+			return nil, err
+		}
+	}
+
+	sw.entries = entries
+	sw.sum = sum
+
+	return expired, nil
+}
+
+// Sum returns the encrypted sum of the ciphertexts in the window
+func (sw *SlidingWindow) Sum() *Ciphertext {
+	return sw.sum
+}
+
+// Len returns the number of ciphertexts currently in the window
+func (sw *SlidingWindow) Len() int {
+	return len(sw.entries)
+}
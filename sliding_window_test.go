@@ -0,0 +1,94 @@
+package paillier
+
+import (
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+type memoryWindowStore struct {
+	state *SlidingWindowState
+	fail  bool
+}
+
+func (s *memoryWindowStore) Save(state *SlidingWindowState) error {
+	if s.fail {
+		return errors.New("store unavailable")
+	}
+	s.state = state
+	return nil
+}
+
+func (s *memoryWindowStore) Load() (*SlidingWindowState, error) {
+	return s.state, nil
+}
+
+func TestSlidingWindow(t *testing.T) {
+	sk, pk := KeyGen(64)
+
+	sw, err := NewSlidingWindow(pk, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{1, 3, 6, 9, 12, 15}
+	for i := 1; i <= 6; i++ {
+		expired, err := sw.Push(pk.Encrypt(gmp.NewInt(int64(i))))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (i > 3) != (expired != nil) {
+			t.Error("unexpected expired ciphertext at step ", i)
+		}
+
+		if m := sk.Decrypt(sw.Sum()); n(m) != expected[i-1] {
+			t.Error("wrong window sum ", m, " is not ", expected[i-1])
+		}
+	}
+
+	if sw.Len() != 3 {
+		t.Error("wrong window length ", sw.Len())
+	}
+}
+
+func TestSlidingWindowPersistence(t *testing.T) {
+	sk, pk := KeyGen(64)
+	store := &memoryWindowStore{}
+
+	sw, err := NewSlidingWindow(pk, 2, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := sw.Push(pk.Encrypt(gmp.NewInt(int64(i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// restart the aggregator
+	restored, err := NewSlidingWindow(pk, 2, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.Push(pk.Encrypt(gmp.NewInt(10))); err != nil {
+		t.Fatal(err)
+	}
+	if m := sk.Decrypt(restored.Sum()); n(m) != 13 {
+		t.Error("wrong window sum after restore ", m, " is not 13")
+	}
+
+	// failed saves do not modify the window
+	store.fail = true
+	if _, err := restored.Push(pk.Encrypt(gmp.NewInt(100))); err == nil {
+		t.Error("expected error from the store")
+	}
+	if m := sk.Decrypt(restored.Sum()); n(m) != 13 {
+		t.Error("window was modified by a failed update")
+	}
+
+	if _, err := NewSlidingWindow(pk, 3, &memoryWindowStore{state: store.state}); err == nil {
+		t.Error("expected error for mismatching window size")
+	}
+}
@@ -0,0 +1,417 @@
+package paillier
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// The UnmarshalBinary, UnmarshalJSON and Decode methods only check that their
+// input can be decoded, which suits keys and messages from trusted storage.
+// The strict parsers below are meant for bytes received from the network:
+// besides decoding, they check every value against the ranges of the key, so
+// that a malformed input is rejected before any arithmetic uses it, and they
+// reject trailing data and binary encodings that are not the canonical
+// encoding of the decoded value, e.g., integers with leading zero bytes.
+// All of them return a *ParseError.
+
+// Default bounds of ParseLimits
+const (
+	// DefaultMaxPublicKeyBitLength bounds the modulus of parsed keys, which
+	// bounds the cost of the arithmetic on attacker-controlled values
+	DefaultMaxPublicKeyBitLength = 16384
+
+	// DefaultMaxDecryptionServers bounds the committee of parsed threshold keys
+	DefaultMaxDecryptionServers = 1024
+)
+
+// maxStatisticalBits bounds the statistical security of the proofs accepted
+// by the strict parsers, which the verifier cannot learn from the proof
+const maxStatisticalBits = 256
+
+// ParseLimits bound the keys accepted by the strict parsers. A nil
+// *ParseLimits or zero fields mean the defaults.
+type ParseLimits struct {
+	// MinBitLength is the minimum bit length of N; zero means
+	// DefaultMinPublicKeyBitLength
+	MinBitLength int
+
+	// MaxBitLength is the maximum bit length of N; zero means
+	// DefaultMaxPublicKeyBitLength
+	MaxBitLength int
+
+	// MaxDecryptionServers is the maximum TotalNumberOfDecryptionServers of
+	// a threshold key; zero means DefaultMaxDecryptionServers
+	MaxDecryptionServers int
+}
+
+func (limits *ParseLimits) minBitLength() int {
+	if limits == nil || limits.MinBitLength == 0 {
+		return DefaultMinPublicKeyBitLength
+	}
+	return limits.MinBitLength
+}
+
+func (limits *ParseLimits) maxBitLength() int {
+	if limits == nil || limits.MaxBitLength == 0 {
+		return DefaultMaxPublicKeyBitLength
+	}
+	return limits.MaxBitLength
+}
+
+func (limits *ParseLimits) maxDecryptionServers() int {
+	if limits == nil || limits.MaxDecryptionServers == 0 {
+		return DefaultMaxDecryptionServers
+	}
+	return limits.MaxDecryptionServers
+}
+
+// ParsePublicKey decodes the binary encoding of a public key and checks that
+// N is a well-formed modulus within the limits, G is N+1, H is a unit mod
+// N^2 and K is a power of two below N, if they are set
+func ParsePublicKey(data []byte, limits *ParseLimits) (*PublicKey, error) {
+	pk := new(PublicKey)
+	if err := parseBinary(data, pk); err != nil {
+		return nil, &ParseError{"public key", err}
+	}
+	if err := limits.checkPublicKey(pk); err != nil {
+		return nil, &ParseError{"public key", err}
+	}
+	return pk, nil
+}
+
+// ParseSecretKey decodes the binary encoding of a secret key, checks its
+// public key as ParsePublicKey and checks that it decrypts correctly
+func ParseSecretKey(data []byte, limits *ParseLimits) (*SecretKey, error) {
+	sk := new(SecretKey)
+	if err := parseBinary(data, sk); err != nil {
+		return nil, &ParseError{"secret key", err}
+	}
+	if err := limits.checkSecretKey(sk); err != nil {
+		return nil, &ParseError{"secret key", err}
+	}
+	return sk, nil
+}
+
+// ParseThresholdPublicKey decodes the binary encoding of a threshold public
+// key and checks it as Validate with the minimum bit length of the limits.
+// The committee must have at most MaxDecryptionServers servers.
+func ParseThresholdPublicKey(data []byte, limits *ParseLimits) (*ThresholdPublicKey, error) {
+	tk := new(ThresholdPublicKey)
+	if err := parseBinary(data, tk); err != nil {
+		return nil, &ParseError{"threshold public key", err}
+	}
+	if err := limits.checkThresholdPublicKey(tk); err != nil {
+		return nil, &ParseError{"threshold public key", err}
+	}
+	return tk, nil
+}
+
+// ParseThresholdSecretKey decodes the binary encoding of a threshold secret
+// key, checks its public key as ParseThresholdPublicKey and checks that the
+// share matches the verification key of its server
+func ParseThresholdSecretKey(data []byte, limits *ParseLimits) (*ThresholdSecretKey, error) {
+	tsk := new(ThresholdSecretKey)
+	if err := parseBinary(data, tsk); err != nil {
+		return nil, &ParseError{"threshold secret key", err}
+	}
+	if err := limits.checkThresholdSecretKey(tsk); err != nil {
+		return nil, &ParseError{"threshold secret key", err}
+	}
+	return tsk, nil
+}
+
+// ParseThresholdPublicKeyJSON is ParseThresholdPublicKey for the JSON
+// encoding
+func ParseThresholdPublicKeyJSON(data []byte, limits *ParseLimits) (*ThresholdPublicKey, error) {
+	tk := new(ThresholdPublicKey)
+	if err := json.Unmarshal(data, tk); err != nil {
+		return nil, &ParseError{"threshold public key", err}
+	}
+	if err := limits.checkThresholdPublicKey(tk); err != nil {
+		return nil, &ParseError{"threshold public key", err}
+	}
+	return tk, nil
+}
+
+// ParseThresholdSecretKeyJSON is ParseThresholdSecretKey for the JSON
+// encoding
+func ParseThresholdSecretKeyJSON(data []byte, limits *ParseLimits) (*ThresholdSecretKey, error) {
+	tsk := new(ThresholdSecretKey)
+	if err := json.Unmarshal(data, tsk); err != nil {
+		return nil, &ParseError{"threshold secret key", err}
+	}
+	if err := limits.checkThresholdSecretKey(tsk); err != nil {
+		return nil, &ParseError{"threshold secret key", err}
+	}
+	return tsk, nil
+}
+
+// ParsePEM decodes a single PEM block as DecodePEM and checks the key as the
+// strict parser of its type. Anything but whitespace after the block is
+// rejected.
+func ParsePEM(data []byte, limits *ParseLimits) (interface{}, error) {
+	key, rest, err := DecodePEM(data)
+	if err != nil {
+		return nil, &ParseError{"PEM block", err}
+	}
+	if len(bytes.TrimSpace(rest)) != 0 {
+		return nil, &ParseError{"PEM block", errors.New("trailing data after PEM block")}
+	}
+
+	switch k := key.(type) {
+	case *PublicKey:
+		err = limits.checkPublicKey(k)
+	case *SecretKey:
+		err = limits.checkSecretKey(k)
+	case *ThresholdPublicKey:
+		err = limits.checkThresholdPublicKey(k)
+	case *ThresholdSecretKey:
+		err = limits.checkThresholdSecretKey(k)
+	}
+	if err != nil {
+		return nil, &ParseError{fmt.Sprintf("PEM %T", key), err}
+	}
+	return key, nil
+}
+
+// ParseCiphertext decodes the binary encoding of a ciphertext and checks that
+// C is a unit mod N^(s+1) for its level. The errors wrap ErrInvalidCiphertext
+// if C is out of range.
+func (pk *PublicKey) ParseCiphertext(data []byte) (*Ciphertext, error) {
+	ct := new(Ciphertext)
+	if err := parseBinary(data, ct); err != nil {
+		return nil, &ParseError{"ciphertext", err}
+	}
+
+	_, _, ns1 := pk.getModuliForLevel(ct.Level)
+	if ct.C.Sign() <= 0 || ct.C.Cmp(ns1) >= 0 {
+		return nil, &ParseError{"ciphertext", fmt.Errorf("%w: C is out of range", ErrInvalidCiphertext)}
+	}
+	if new(gmp.Int).GCD(nil, nil, ct.C, pk.N).Cmp(OneBigInt) != 0 {
+		return nil, &ParseError{"ciphertext", fmt.Errorf("%w: C is not a unit", ErrInvalidCiphertext)}
+	}
+	return ct, nil
+}
+
+// ParsePartialDecryption decodes a partial decryption as
+// DecodePartialDecryption, rejects non-canonical encodings and checks that
+// the decryption share is a unit mod N^2
+func (tk *ThresholdPublicKey) ParsePartialDecryption(data []byte) (*PartialDecryption, error) {
+	pd, err := tk.DecodePartialDecryption(data)
+	if err == nil {
+		err = checkCanonical(data, pd.Encode())
+	}
+	if err == nil {
+		err = tk.checkPartialDecryptionStrict(pd)
+	}
+	if err != nil {
+		return nil, &ParseError{"partial decryption", err}
+	}
+	return pd, nil
+}
+
+// ParsePartialDecryptionZKP decodes a partial decryption as
+// DecodePartialDecryptionZKP, rejects non-canonical encodings and checks the
+// ranges of the values of the proof. The proof is not verified.
+func (tk *ThresholdPublicKey) ParsePartialDecryptionZKP(data []byte) (*PartialDecryptionZKP, error) {
+	pd, err := tk.DecodePartialDecryptionZKP(data)
+	if err == nil {
+		var encoded []byte
+		if encoded, err = pd.Encode(); err == nil {
+			err = checkCanonical(data, encoded)
+		}
+	}
+	if err == nil {
+		err = tk.checkPartialDecryptionZKP(pd)
+	}
+	if err != nil {
+		return nil, &ParseError{"partial decryption", err}
+	}
+	return pd, nil
+}
+
+// ParsePartialDecryptionJSON is ParsePartialDecryption for the JSON encoding.
+// It returns an error wrapping ErrKeyMismatch if the share carries the
+// fingerprint of a different key.
+func (tk *ThresholdPublicKey) ParsePartialDecryptionJSON(data []byte) (*PartialDecryption, error) {
+	pd := new(PartialDecryption)
+	err := json.Unmarshal(data, pd)
+	if err == nil && pd.KeyFingerprint != "" && pd.KeyFingerprint != tk.CommitteeFingerprint() {
+		err = fmt.Errorf("%w: share of server %d", ErrKeyMismatch, pd.ID)
+	}
+	if err == nil {
+		err = tk.checkPartialDecryptionStrict(pd)
+	}
+	if err != nil {
+		return nil, &ParseError{"partial decryption", err}
+	}
+	return pd, nil
+}
+
+// ParsePartialDecryptionZKPJSON is ParsePartialDecryptionZKP for the JSON
+// encoding, which may carry proof options other than the defaults. The key
+// the share carries, if any, must be equal to tk and is replaced by it.
+func (tk *ThresholdPublicKey) ParsePartialDecryptionZKPJSON(data []byte) (*PartialDecryptionZKP, error) {
+	pd := new(PartialDecryptionZKP)
+	err := json.Unmarshal(data, pd)
+	if err == nil && pd.Key != nil && !pd.Key.Equal(tk) {
+		err = &InvalidProofError{ID: pd.ID, Err: ErrStaleKey}
+	}
+	if err == nil {
+		pd.Key = tk
+		err = tk.checkPartialDecryptionZKP(pd)
+	}
+	if err != nil {
+		return nil, &ParseError{"partial decryption", err}
+	}
+	return pd, nil
+}
+
+func (limits *ParseLimits) checkPublicKey(pk *PublicKey) error {
+	if err := pk.validateModulus(limits.minBitLength()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if pk.N.BitLen() > limits.maxBitLength() {
+		return fmt.Errorf("%w: N has %d bits, the maximum is %d", ErrInvalidKey, pk.N.BitLen(), limits.maxBitLength())
+	}
+	if pk.H != nil {
+		if err := pk.validateUnit(pk.H); err != nil {
+			return fmt.Errorf("%w: H %v", ErrInvalidKey, err)
+		}
+	}
+	if pk.K != nil && (pk.K.Sign() <= 0 || pk.K.Cmp(pk.N) >= 0 ||
+		new(gmp.Int).Lsh(OneBigInt, uint(pk.K.BitLen()-1)).Cmp(pk.K) != 0) {
+		return fmt.Errorf("%w: K is not a power of two below N", ErrInvalidKey)
+	}
+	return nil
+}
+
+func (limits *ParseLimits) checkSecretKey(sk *SecretKey) error {
+	if err := limits.checkPublicKey(&sk.PublicKey); err != nil {
+		return err
+	}
+
+	if sk.Lambda.Sign() <= 0 || sk.Lambda.Cmp(sk.N) >= 0 {
+		return fmt.Errorf("%w: Lambda is out of range", ErrInvalidKey)
+	}
+	for _, x := range []*gmp.Int{sk.Lm, sk.Mu, sk.m} {
+		if x != nil && (x.Sign() < 0 || x.Cmp(sk.GetN2()) >= 0) {
+			return fmt.Errorf("%w: secret value is out of range", ErrInvalidKey)
+		}
+	}
+	if new(gmp.Int).GCD(nil, nil, sk.Lambda, sk.N).Cmp(OneBigInt) != 0 {
+		return fmt.Errorf("%w: Lambda is not a unit", ErrInvalidKey)
+	}
+
+	// Lambda must be a multiple of the order of the random unit r^N of an
+	// encryption, which fails with overwhelming probability otherwise
+	probe := new(gmp.Int).Sub(sk.N, OneBigInt)
+	if sk.Decrypt(sk.Encrypt(probe)).Cmp(probe) != 0 {
+		return fmt.Errorf("%w: secret key does not decrypt", ErrInvalidKey)
+	}
+	return nil
+}
+
+func (limits *ParseLimits) checkThresholdPublicKey(tk *ThresholdPublicKey) error {
+	if tk.TotalNumberOfDecryptionServers > limits.maxDecryptionServers() {
+		return fmt.Errorf("%w: %d decryption servers, the maximum is %d", ErrInvalidKey,
+			tk.TotalNumberOfDecryptionServers, limits.maxDecryptionServers())
+	}
+	if err := limits.checkPublicKey(&tk.PublicKey); err != nil {
+		return err
+	}
+	if err := tk.validateCommittee(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return nil
+}
+
+func (limits *ParseLimits) checkThresholdSecretKey(tsk *ThresholdSecretKey) error {
+	if err := limits.checkThresholdPublicKey(&tsk.ThresholdPublicKey); err != nil {
+		return err
+	}
+
+	if tsk.Share.Sign() <= 0 || tsk.Share.Cmp(tsk.GetN2()) >= 0 {
+		return fmt.Errorf("%w: share is out of range", ErrInvalidKey)
+	}
+	if err := tsk.checkShare(tsk); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return nil
+}
+
+// checkPartialDecryptionStrict is checkPartialDecryption that also rejects
+// decryption shares that are not units
+func (tk *ThresholdPublicKey) checkPartialDecryptionStrict(pd *PartialDecryption) error {
+	if err := tk.checkPartialDecryption(pd); err != nil {
+		return err
+	}
+	if err := tk.validateUnit(pd.Decryption); err != nil {
+		return fmt.Errorf("partial decryption %v", err)
+	}
+	return nil
+}
+
+func (tk *ThresholdPublicKey) checkPartialDecryptionZKP(pd *PartialDecryptionZKP) error {
+	if err := tk.checkPartialDecryptionStrict(&pd.PartialDecryption); err != nil {
+		return err
+	}
+
+	opts := pd.options()
+	if err := opts.check(); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProof, err)
+	}
+	if len(pd.Session) > maxSessionLength || len(pd.Domain) > maxSessionLength {
+		return fmt.Errorf("%w: session or domain is too long", ErrMalformedProof)
+	}
+
+	if err := tk.validateUnit(pd.C); err != nil {
+		return fmt.Errorf("%w: C %v", ErrMalformedProof, err)
+	}
+	for _, x := range []*gmp.Int{pd.A, pd.B} {
+		if x == nil {
+			continue
+		}
+		if err := tk.validateUnit(x); err != nil {
+			return fmt.Errorf("%w: commitment %v", ErrMalformedProof, err)
+		}
+	}
+	if pd.E == nil || pd.E.Sign() < 0 || pd.E.BitLen() > opts.challengeBits() {
+		return fmt.Errorf("%w: challenge is out of range", ErrMalformedProof)
+	}
+	// Z = r + E * delta * share is below twice the mask bound of the prover
+	params := SecurityParams{ChallengeBits: opts.challengeBits(), StatisticalBits: maxStatisticalBits}
+	maxZ := tk.maskBound(params, params.ChallengeBits).BitLen() + 1
+	if pd.Z == nil || pd.Z.Sign() < 0 || pd.Z.BitLen() > maxZ {
+		return fmt.Errorf("%w: response is out of range", ErrMalformedProof)
+	}
+	return nil
+}
+
+// parseBinary unmarshals data into v, which rejects truncated and trailing
+// data, and checks that data is the canonical encoding of v
+func parseBinary(data []byte, v interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}) error {
+	if err := v.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	encoded, err := v.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return checkCanonical(data, encoded)
+}
+
+func checkCanonical(data, encoded []byte) error {
+	if !bytes.Equal(data, encoded) {
+		return errors.New("encoding is not canonical")
+	}
+	return nil
+}
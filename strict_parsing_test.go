@@ -0,0 +1,295 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+var testParseLimits = &ParseLimits{MinBitLength: 64}
+
+func getParsingKeys(t testing.TB) []*ThresholdSecretKey {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tsks
+}
+
+func TestParseKeys(t *testing.T) {
+	sk, pk := KeyGen(128)
+	tsks := getParsingKeys(t)
+
+	data, _ := pk.MarshalBinary()
+	if _, err := ParsePublicKey(data, testParseLimits); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParsePublicKey(data, nil); !errors.Is(err, ErrInvalidKey) {
+		t.Error("expected ErrInvalidKey for a key below the default minimum, got ", err)
+	}
+	if _, err := ParsePublicKey(data, &ParseLimits{MinBitLength: 64, MaxBitLength: 96}); !errors.Is(err, ErrInvalidKey) {
+		t.Error("expected ErrInvalidKey for a key above the maximum, got ", err)
+	}
+
+	data, _ = sk.MarshalBinary()
+	parsed, err := ParseSecretKey(data, testParseLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(parsed.Decrypt(pk.Encrypt(gmp.NewInt(9)))) != 9 {
+		t.Error("parsed secret key does not decrypt")
+	}
+	wrong := *sk
+	wrong.Lambda = new(gmp.Int).Add(sk.Lambda, TwoBigInt)
+	data, _ = wrong.MarshalBinary()
+	if _, err := ParseSecretKey(data, testParseLimits); !errors.Is(err, ErrInvalidKey) {
+		t.Error("expected ErrInvalidKey for a wrong Lambda, got ", err)
+	}
+
+	data, _ = tsks[0].PublicOnly().MarshalBinary()
+	if _, err := ParseThresholdPublicKey(data, testParseLimits); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseThresholdPublicKey(data, &ParseLimits{MinBitLength: 64, MaxDecryptionServers: 2}); !errors.Is(err, ErrInvalidKey) {
+		t.Error("expected ErrInvalidKey for too many servers, got ", err)
+	}
+
+	data, _ = tsks[1].MarshalBinary()
+	if _, err := ParseThresholdSecretKey(data, testParseLimits); err != nil {
+		t.Error(err)
+	}
+	swapped := *tsks[1]
+	swapped.ID = 3
+	data, _ = swapped.MarshalBinary()
+	if _, err := ParseThresholdSecretKey(data, testParseLimits); !errors.Is(err, ErrInvalidKey) {
+		t.Error("expected ErrInvalidKey for a share of another server, got ", err)
+	}
+
+	data, _ = json.Marshal(tsks[2])
+	if _, err := ParseThresholdSecretKeyJSON(data, testParseLimits); err != nil {
+		t.Error(err)
+	}
+	data, _ = json.Marshal(tsks[2].PublicOnly())
+	if _, err := ParseThresholdPublicKeyJSON(data, testParseLimits); err != nil {
+		t.Error(err)
+	}
+
+	data, _ = EncodePEM(sk)
+	if _, err := ParsePEM(data, testParseLimits); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParsePEM(append(data, data...), testParseLimits); !errors.Is(err, ErrMalformedEncoding) {
+		t.Error("expected ErrMalformedEncoding for trailing data, got ", err)
+	}
+}
+
+func TestParseNonCanonical(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	data, _ := pk.MarshalBinary()
+	// prefix N with a zero byte and increase its length
+	padded := append([]byte{}, data[:2]...)
+	padded = append(padded, data[2], data[3], data[4], data[5]+1, 0)
+	padded = append(padded, data[6:]...)
+
+	var decoded PublicKey
+	if err := decoded.UnmarshalBinary(padded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePublicKey(padded, testParseLimits); !errors.Is(err, ErrMalformedEncoding) {
+		t.Error("expected ErrMalformedEncoding for a non-canonical integer, got ", err)
+	}
+	if _, err := ParsePublicKey(append(data, 0), testParseLimits); !errors.Is(err, ErrMalformedEncoding) {
+		t.Error("expected ErrMalformedEncoding for trailing data, got ", err)
+	}
+}
+
+func TestParseCiphertext(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	for _, level := range []EncryptionLevel{EncLevelOne, EncLevelTwo} {
+		data, _ := pk.EncryptAtLevel(gmp.NewInt(5), level).MarshalBinary()
+		ct, err := pk.ParseCiphertext(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct.Level != level {
+			t.Errorf("parsed level %v, expected %v", ct.Level, level)
+		}
+	}
+
+	for _, c := range []*gmp.Int{pk.GetN2(), pk.N, new(gmp.Int).Add(pk.GetN2(), OneBigInt)} {
+		data, _ := (&Ciphertext{c, EncLevelOne, RegularEncryption}).MarshalBinary()
+		_, err := pk.ParseCiphertext(data)
+		if !errors.Is(err, ErrInvalidCiphertext) || !errors.Is(err, ErrMalformedEncoding) {
+			t.Error("expected ErrInvalidCiphertext, got ", err)
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Type != "ciphertext" {
+			t.Error("expected a *ParseError of a ciphertext, got ", err)
+		}
+	}
+}
+
+func TestParsePartialDecryption(t *testing.T) {
+	tsks := getParsingKeys(t)
+	tk := tsks[0].PublicOnly()
+	ct := tk.Encrypt(gmp.NewInt(3))
+
+	pd, err := tsks[1].PartialDecryptionWithZKP(ct.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := pd.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := tk.ParsePartialDecryptionZKP(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.VerifyErr(); err != nil {
+		t.Error(err)
+	}
+
+	data, _ = json.Marshal(pd)
+	if _, err := tk.ParsePartialDecryptionZKPJSON(data); err != nil {
+		t.Error(err)
+	}
+	other := getParsingKeys(t)[0].PublicOnly()
+	if _, err := other.ParsePartialDecryptionZKPJSON(data); !errors.Is(err, ErrStaleKey) {
+		t.Error("expected ErrStaleKey, got ", err)
+	}
+
+	broken := *pd
+	broken.E = new(gmp.Int).Lsh(OneBigInt, 300)
+	data, _ = json.Marshal(&broken)
+	if _, err := tk.ParsePartialDecryptionZKPJSON(data); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected ErrMalformedProof for a challenge out of range, got ", err)
+	}
+
+	data = pd.PartialDecryption.Encode()
+	if _, err := tk.ParsePartialDecryption(data); err != nil {
+		t.Error(err)
+	}
+	notUnit := &PartialDecryption{ID: 1, Decryption: tk.N}
+	if _, err := tk.ParsePartialDecryption(notUnit.Encode()); !errors.Is(err, ErrMalformedEncoding) {
+		t.Error("expected ErrMalformedEncoding for a decryption share that is not a unit, got ", err)
+	}
+	data, _ = json.Marshal(&PartialDecryption{ID: 4, Decryption: pd.Decryption})
+	if _, err := tk.ParsePartialDecryptionJSON(data); !errors.Is(err, ErrMalformedEncoding) {
+		t.Error("expected ErrMalformedEncoding for an ID out of range, got ", err)
+	}
+}
+
+// The fuzz targets check that the strict parsers never panic and that
+// whatever they accept is the canonical encoding of a value that passes
+// their checks again.
+
+func FuzzParsePublicKey(f *testing.F) {
+	sk, pk := KeyGen(128)
+	data, _ := pk.MarshalBinary()
+	f.Add(data)
+	data, _ = sk.MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		pk, err := ParsePublicKey(data, testParseLimits)
+		if err != nil {
+			return
+		}
+		encoded, _ := pk.MarshalBinary()
+		if _, err := ParsePublicKey(encoded, testParseLimits); err != nil {
+			t.Error("accepted key does not parse again: ", err)
+		}
+	})
+}
+
+func FuzzParseSecretKey(f *testing.F) {
+	sk, _ := KeyGen(128)
+	data, _ := sk.MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sk, err := ParseSecretKey(data, testParseLimits)
+		if err != nil {
+			return
+		}
+		m := gmp.NewInt(12345)
+		if sk.Decrypt(sk.Encrypt(m)).Cmp(m) != 0 {
+			t.Error("accepted secret key does not decrypt")
+		}
+	})
+}
+
+func FuzzParseThresholdKeys(f *testing.F) {
+	tsks := getParsingKeys(f)
+	data, _ := tsks[0].PublicOnly().MarshalBinary()
+	f.Add(data)
+	data, _ = tsks[1].MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if tk, err := ParseThresholdPublicKey(data, testParseLimits); err == nil {
+			if err := tk.Validate(testParseLimits.MinBitLength); err != nil {
+				t.Error("accepted key does not validate: ", err)
+			}
+		}
+		if tsk, err := ParseThresholdSecretKey(data, testParseLimits); err == nil {
+			if tsk.ID < 1 || tsk.ID > tsk.TotalNumberOfDecryptionServers {
+				t.Error("accepted key has an ID out of range: ", tsk.ID)
+			}
+		}
+	})
+}
+
+func FuzzParseCiphertext(f *testing.F) {
+	_, pk := KeyGen(128)
+	data, _ := pk.Encrypt(gmp.NewInt(1)).MarshalBinary()
+	f.Add(data)
+	data, _ = pk.EncryptAtLevel(gmp.NewInt(1), EncLevelTwo).MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ct, err := pk.ParseCiphertext(data)
+		if err != nil {
+			return
+		}
+		if ct.C.Sign() <= 0 || ct.Level > MaxEncryptionLevel {
+			t.Error("accepted ciphertext is out of range")
+		}
+	})
+}
+
+func FuzzParsePartialDecryption(f *testing.F) {
+	tsks := getParsingKeys(f)
+	tk := tsks[0].PublicOnly()
+	pd, err := tsks[0].PartialDecryptionWithZKP(tk.Encrypt(gmp.NewInt(1)).C)
+	if err != nil {
+		f.Fatal(err)
+	}
+	data, _ := pd.Encode()
+	f.Add(data)
+	f.Add(pd.PartialDecryption.Encode())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if pd, err := tk.ParsePartialDecryption(data); err == nil {
+			if pd.ID < 1 || pd.ID > tk.TotalNumberOfDecryptionServers {
+				t.Error("accepted partial decryption has an ID out of range: ", pd.ID)
+			}
+		}
+		if pd, err := tk.ParsePartialDecryptionZKP(data); err == nil {
+			// must not panic on any accepted proof
+			pd.VerifyErr()
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// DefaultAlertStatisticalSecurity is the statistical security in bits of the
+// masks of the comparison protocols, e.g., of the masked difference the key
+// holder decrypts in a Comparator run
+const DefaultAlertStatisticalSecurity = 40
+
+// ThresholdAlert flags encrypted values that exceed a public limit without
+// revealing the values themselves to the decryption committee.
+//
+// Given [x] with 0 <= x < 2^BitLength and the public limit T, the monitor
+// runs the bitwise comparison of Comparator on [x] and [T + 1] with a
+// ComparisonHelper backed by the committee, e.g., with a ThresholdClient, and
+// obtains the encrypted bit [x > T]. The committee threshold decrypts three
+// kinds of values, see Comparator for the details:
+//
+//   - the difference x - T - 1 + 2^(BitLength+1) plus a random mask of
+//     BitLength + DefaultAlertStatisticalSecurity + 2 bits, which hides
+//     x - T statistically,
+//   - the blinded bitwise comparison, i.e., BitLength + 2 values in random
+//     order that are either zero or random units; whether one of them is
+//     zero is hidden by a random bit of the monitor,
+//   - the result [x > T], which is the only value the alert reveals.
+type ThresholdAlert struct {
+	Key       *ThresholdPublicKey
+	Limit     *gmp.Int // public limit T
+	BitLength int      // values are in [0, 2^BitLength)
+}
+
+// AlertQuery holds the state of the monitor for the comparison of one
+// encrypted value with the limit. The request must be sent to the helper,
+// whose answers are processed by Challenge and Finalize.
+type AlertQuery struct {
+	Request *ComparisonRequest
+
+	comparator *Comparator
+	result     *Ciphertext // [x > T] once finalized
+}
+
+// NewThresholdAlert creates a ThresholdAlert for values in [0, 2^bitLength)
+// compared against the public limit.
+// Returns an error if the key is too small to compare values of bitLength bits
+// with DefaultAlertStatisticalSecurity bits of masking.
+func NewThresholdAlert(key *ThresholdPublicKey, limit *gmp.Int, bitLength int) (*ThresholdAlert, error) {
+	ta := &ThresholdAlert{
+		Key:       key,
+		Limit:     limit,
+		BitLength: bitLength,
+	}
+
+	if err := ta.validate(); err != nil {
+		return nil, err
+	}
+
+	return ta, nil
+}
+
+func (ta *ThresholdAlert) validate() error {
+	if ta.BitLength <= 0 {
+		return errors.New("bit length must be positive")
+	}
+	if ta.Limit.Cmp(ZeroBigInt) < 0 || ta.Limit.BitLen() > ta.BitLength {
+		return errors.New("limit must be in the range [0, 2^bitLength)")
+	}
+
+	// T + 1 may be 2^BitLength, so the values are compared with one more bit
+	if DefaultAlertStatisticalSecurity+ta.comparisonBits()+2 >= ta.Key.N.BitLen() {
+		return errors.New("public key is too small for the requested bit length")
+	}
+
+	return nil
+}
+
+// comparisonBits returns the bit length of the Comparator run
+func (ta *ThresholdAlert) comparisonBits() int {
+	return ta.BitLength + 1
+}
+
+// Blind starts the comparison of the encrypted value ct with the limit and
+// returns the query whose request must be sent to the helper.
+func (ta *ThresholdAlert) Blind(ct *Ciphertext) (*AlertQuery, error) {
+	if ct == nil || ct.C == nil {
+		return nil, ErrInvalidCiphertext
+	}
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("threshold alerts are only supported for level one ciphertexts")
+	}
+
+	if err := ta.validate(); err != nil {
+		return nil, err
+	}
+
+	pk := &ta.Key.PublicKey
+	limit := pk.trivialEncryption(new(gmp.Int).Add(ta.Limit, OneBigInt))
+	comparator, req, err := pk.NewComparator(ct, limit, ta.comparisonBits())
+	if err != nil {
+		return nil, err
+	}
+
+	return &AlertQuery{Request: req, comparator: comparator}, nil
+}
+
+// Challenge processes the encrypted bits returned by the helper and returns
+// the challenge for the helper
+func (q *AlertQuery) Challenge(bits *ComparisonBits) (*ComparisonChallenge, error) {
+	return q.comparator.Challenge(bits)
+}
+
+// Finalize processes the response of the helper and returns [x > T], the
+// only ciphertext of the query the committee must threshold decrypt
+func (q *AlertQuery) Finalize(resp *ComparisonResponse) (*Ciphertext, error) {
+	result, err := q.comparator.Finalize(resp)
+	if err != nil {
+		return nil, err
+	}
+	q.result = result
+	return result, nil
+}
+
+// Exceeds combines the committee's partial decryptions of the finalized
+// query and returns true iff the encrypted value is strictly greater than
+// the limit.
+func (ta *ThresholdAlert) Exceeds(query *AlertQuery, shares []*PartialDecryption) (bool, error) {
+	if query.result == nil {
+		return false, errors.New("query is not finalized")
+	}
+
+	bit, err := ta.Key.CombinePartialDecryptions(shares)
+	if err != nil {
+		return false, err
+	}
+
+	return alertBit(bit)
+}
+
+// ExceedsZKP is like Exceeds but only accepts partial decryptions with a
+// valid proof of correct decryption of the result of the query.
+func (ta *ThresholdAlert) ExceedsZKP(query *AlertQuery, shares []*PartialDecryptionZKP) (bool, error) {
+	if query.result == nil {
+		return false, errors.New("query is not finalized")
+	}
+	for _, share := range shares {
+		if share.C.Cmp(query.result.C) != 0 {
+			return false, errors.New("partial decryption is not for the query result")
+		}
+	}
+
+	bit, err := ta.Key.CombinePartialDecryptionsZKP(shares)
+	if err != nil {
+		return false, err
+	}
+
+	return alertBit(bit)
+}
+
+// Check runs the whole comparison of ct with the limit, using the client
+// both as the helper of the comparison and to decrypt the result
+func (ta *ThresholdAlert) Check(ct *Ciphertext, client *ThresholdClient) (bool, error) {
+	query, err := ta.Blind(ct)
+	if err != nil {
+		return false, err
+	}
+
+	helper := NewComparisonHelper(&ta.Key.PublicKey, client)
+	bits, err := helper.Decompose(query.Request)
+	if err != nil {
+		return false, err
+	}
+	challenge, err := query.Challenge(bits)
+	if err != nil {
+		return false, err
+	}
+	resp, err := helper.Evaluate(challenge)
+	if err != nil {
+		return false, err
+	}
+	result, err := query.Finalize(resp)
+	if err != nil {
+		return false, err
+	}
+
+	bit, err := client.TryDecrypt(result)
+	if err != nil {
+		return false, err
+	}
+	return alertBit(bit)
+}
+
+// alertBit returns the decrypted result of a query as a bool
+func alertBit(bit *gmp.Int) (bool, error) {
+	if bit.Cmp(OneBigInt) > 0 || bit.Sign() < 0 {
+		return false, fmt.Errorf("alert result %v is not a bit", bit)
+	}
+	return bit.Sign() == 1, nil
+}
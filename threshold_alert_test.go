@@ -0,0 +1,111 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestThresholdAlert(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(128, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ta, err := NewThresholdAlert(&tpks[0].ThresholdPublicKey, b(1000), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tk := tpks[0].PublicOnly()
+	client := NewThresholdClient(tk, []PartialDecrypter{tpks[0], tpks[2]})
+	helper := NewComparisonHelper(&tk.PublicKey, client)
+
+	for _, value := range []int{0, 1, 999, 1000, 1001, 5000, 65535} {
+		ct := tpks[0].Encrypt(b(value))
+
+		query, err := ta.Blind(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bits, err := helper.Decompose(query.Request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		challenge, err := query.Challenge(bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := helper.Evaluate(challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := query.Finalize(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// only the result bit is threshold decrypted
+		share1, err := tpks[0].PartialDecryptionWithZKP(result.C)
+		if err != nil {
+			t.Fatal(err)
+		}
+		share2, err := tpks[2].PartialDecryptionWithZKP(result.C)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exceeds, err := ta.ExceedsZKP(query, []*PartialDecryptionZKP{share1, share2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exceeds != (value > 1000) {
+			t.Errorf("wrong alert for %v: got %v", value, exceeds)
+		}
+
+		if exceeds, err := ta.Check(ct, client); err != nil || exceeds != (value > 1000) {
+			t.Errorf("wrong alert for %v: got %v, %v", value, exceeds, err)
+		}
+	}
+
+	// the largest limit is compared with 2^BitLength
+	top, err := NewThresholdAlert(tk, b(65535), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exceeds, err := top.Check(tpks[0].Encrypt(b(65535)), client); err != nil || exceeds {
+		t.Error("wrong alert for the largest value: ", exceeds, err)
+	}
+
+	query, err := ta.Blind(tpks[0].Encrypt(b(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ta.Exceeds(query, nil); err == nil {
+		t.Error("expected an error for a query that is not finalized")
+	}
+}
+
+func TestThresholdAlertParameters(t *testing.T) {
+	tk := new(ThresholdPublicKey)
+	tk.N = gmp.NewInt(0).Lsh(OneBigInt, 63)
+
+	if _, err := NewThresholdAlert(tk, b(10), 32); err == nil {
+		t.Error("expected key too small error")
+	}
+
+	if _, err := NewThresholdAlert(tk, b(10), 3); err == nil {
+		t.Error("expected limit out of range error")
+	}
+
+	if _, err := NewThresholdAlert(tk, b(10), 16); err != nil {
+		t.Error(err)
+	}
+}
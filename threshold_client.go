@@ -0,0 +1,79 @@
+package paillier
+
+import (
+	"errors"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// PartialDecrypter is implemented by the decryption servers of a threshold
+// committee, e.g., *ThresholdSecretKey or a client stub for a remote server.
+type PartialDecrypter interface {
+	PartialDecryptionWithZKP(c *gmp.Int) (*PartialDecryptionZKP, error)
+}
+
+// ThresholdClient orchestrates threshold decryption on behalf of an application.
+// It requests proven partial decryptions from the decryption servers until
+// `Threshold` valid shares have been collected and combines them.
+type ThresholdClient struct {
+	Key     *ThresholdPublicKey
+	Servers []PartialDecrypter
+}
+
+// NewThresholdClient returns a client for the committee that holds the
+// secret shares of key
+func NewThresholdClient(key *ThresholdPublicKey, servers []PartialDecrypter) *ThresholdClient {
+	return &ThresholdClient{
+		Key:     key,
+		Servers: servers,
+	}
+}
+
+// Encrypt a plaintext under the committee's public key
+func (tc *ThresholdClient) Encrypt(m *gmp.Int) *Ciphertext {
+	return tc.Key.Encrypt(m)
+}
+
+// TryDecrypt decrypts a ciphertext with the help of the committee.
+// Servers that fail or return an invalid proof are skipped, and an error is
+// returned if fewer than Threshold valid partial decryptions were collected.
+func (tc *ThresholdClient) TryDecrypt(ct *Ciphertext) (*gmp.Int, error) {
+	if ct == nil || ct.C == nil {
+		return nil, ErrInvalidCiphertext
+	}
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("threshold decryption is only supported for level one ciphertexts")
+	}
+
+	shares := make([]*PartialDecryptionZKP, 0, tc.Key.Threshold)
+	seen := make(map[int]bool)
+	for _, server := range tc.Servers {
+		if len(shares) == tc.Key.Threshold {
+			break
+		}
+
+		share, err := server.PartialDecryptionWithZKP(ct.C)
+		if err != nil {
+			continue
+		}
+
+		if share.ID < 1 || share.ID > len(tc.Key.VerificationKeys) || seen[share.ID] {
+			continue
+		}
+
+		// verify against the client's key rather than the one sent by the server
+		share.Key = tc.Key
+		if share.C.Cmp(ct.C) != 0 || !share.VerifyProof() {
+			continue
+		}
+
+		seen[share.ID] = true
+		shares = append(shares, share)
+	}
+
+	if len(shares) < tc.Key.Threshold {
+		return nil, errors.New("not enough valid partial decryptions")
+	}
+
+	return tc.Key.CombinePartialDecryptionsZKP(shares)
+}
@@ -0,0 +1,87 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+type failingPartialDecrypter struct{}
+
+func (failingPartialDecrypter) PartialDecryptionWithZKP(c *gmp.Int) (*PartialDecryptionZKP, error) {
+	return nil, errors.New("server unavailable")
+}
+
+func getThresholdClient(t *testing.T) (*ThresholdClient, []*ThresholdSecretKey) {
+	tkh, err := NewThresholdKeyGenerator(32, 4, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servers := []PartialDecrypter{failingPartialDecrypter{}, tpks[1], tpks[1], tpks[3]}
+	return NewThresholdClient(&tpks[0].ThresholdPublicKey, servers), tpks
+}
+
+func TestThresholdClientDecrypt(t *testing.T) {
+	client, _ := getThresholdClient(t)
+
+	ct := client.Encrypt(b(100))
+	m, err := client.TryDecrypt(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n(m) != 100 {
+		t.Error("wrong decryption ", m, " is not 100")
+	}
+}
+
+func TestThresholdClientNotEnoughShares(t *testing.T) {
+	client, _ := getThresholdClient(t)
+	client.Servers = client.Servers[:3]
+
+	if _, err := client.TryDecrypt(client.Encrypt(b(100))); err == nil {
+		t.Error("expected error when only one server is available")
+	}
+}
+
+func TestEncrypterDecrypterInterfaces(t *testing.T) {
+	client, _ := getThresholdClient(t)
+	sk, pk := KeyGen(64)
+
+	pairs := []struct {
+		enc Encrypter
+		dec Decrypter
+	}{
+		{pk, sk},
+		{sk, sk},
+		{client.Key, client},
+		{client, client},
+	}
+
+	for _, pair := range pairs {
+		if m, err := pair.dec.TryDecrypt(pair.enc.Encrypt(gmp.NewInt(42))); err != nil || n(m) != 42 {
+			t.Error("wrong decryption ", m, " is not 42: ", err)
+		}
+	}
+
+	// a committee that does not answer and a missing ciphertext are errors
+	var offline Decrypter = NewThresholdClient(client.Key, nil)
+	if _, err := offline.TryDecrypt(client.Encrypt(gmp.NewInt(42))); err == nil {
+		t.Error("expected an error for a committee without servers")
+	}
+	if _, err := sk.TryDecrypt(nil); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext, got ", err)
+	}
+	if _, err := sk.TryDecrypt(&Ciphertext{pk.GetN2(), EncLevelOne, RegularEncryption}); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Error("expected ErrInvalidCiphertext, got ", err)
+	}
+}
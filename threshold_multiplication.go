@@ -0,0 +1,145 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ThresholdMultiplicationRequest is sent by the multiplier to every
+// decryption server and contains the blinded factor [x+a]
+type ThresholdMultiplicationRequest struct {
+	Blinded *Ciphertext
+}
+
+// ThresholdMultiplicationResponse is returned by a decryption server and
+// contains its proven partial decryption of the blinded factor
+type ThresholdMultiplicationResponse struct {
+	Share *PartialDecryptionZKP
+}
+
+// ThresholdMultiplier holds the state of the party that wants to compute
+// [x*y] from [x] and [y] with one round of threshold decryption, without
+// the party or the decryption servers learning x or y (see
+// ThresholdSecretKey.AssistThresholdMultiplication).
+//
+// The protocol is the multiplication of [CDN 01]:
+//  1. the multiplier picks a random a in Z_N, keeps [a*y] = [y]^a and sends
+//     [x+a] to the servers
+//  2. the servers return proven partial decryptions of [x+a]
+//  3. the multiplier combines Threshold of them to x+a and outputs the
+//     rerandomized [y]^(x+a) * [a*y]^-1 = [x*y]
+//
+// Since a is uniform, x+a reveals nothing about x. Partial decryptions with
+// invalid proofs are rejected, so up to Threshold-1 malicious servers can
+// only withhold their shares; the multiplier is assumed to follow the
+// protocol. Run one multiplier per multiplication.
+//
+//	[CDN 01]: Ronald Cramer, Ivan Damgard, Jesper Buus Nielsen, (2001)
+//	          Multiparty Computation from Threshold Homomorphic Encryption
+type ThresholdMultiplier struct {
+	key      *ThresholdPublicKey
+	y        *Ciphertext
+	ay       *Ciphertext
+	combiner *Combiner
+	done     bool
+}
+
+// NewThresholdMultiplier blinds x and returns the multiplier state together
+// with the request for the decryption servers
+func (tk *ThresholdPublicKey) NewThresholdMultiplier(x, y *Ciphertext) (*ThresholdMultiplier, *ThresholdMultiplicationRequest, error) {
+	if x == nil || y == nil || x.Level != EncLevelOne || y.Level != EncLevelOne {
+		return nil, nil, errors.New("multiplication is only supported for level one ciphertexts")
+	}
+
+	pk := &tk.PublicKey
+	a, err := GetRandomNumber(pk.N, pk.RandomSource())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := &ThresholdMultiplicationRequest{
+		Blinded: pk.Randomize(pk.addConstant(x, a)),
+	}
+
+	tm := &ThresholdMultiplier{
+		key:      tk,
+		y:        y,
+		ay:       pk.ConstMult(y, a),
+		combiner: tk.NewCombiner(req.Blinded.C),
+	}
+
+	return tm, req, nil
+}
+
+// AssistThresholdMultiplication is run by every decryption server on a
+// request of the multiplier. The server only learns the blinded factor x+a
+// once Threshold shares are combined.
+func (tsk *ThresholdSecretKey) AssistThresholdMultiplication(req *ThresholdMultiplicationRequest) (*ThresholdMultiplicationResponse, error) {
+	if req == nil || req.Blinded == nil || req.Blinded.C == nil || req.Blinded.Level != EncLevelOne {
+		return nil, errors.New("multiplication is only supported for level one ciphertexts")
+	}
+
+	share, err := tsk.PartialDecryptionWithZKP(req.Blinded.C)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ThresholdMultiplicationResponse{Share: share}, nil
+}
+
+// Add verifies the response of a decryption server and adds its share. The
+// share is not added if its proof is rejected or the server already
+// responded; see Combiner.AddZKP for the errors.
+func (tm *ThresholdMultiplier) Add(resp *ThresholdMultiplicationResponse) error {
+	if resp == nil {
+		return fmt.Errorf("%w: missing response", ErrMalformedProof)
+	}
+	return tm.combiner.AddZKP(resp.Share)
+}
+
+// Ready returns true once Threshold valid shares were added
+func (tm *ThresholdMultiplier) Ready() bool {
+	return tm.combiner.Ready()
+}
+
+// Finalize combines the shares and returns [x*y]. It returns an error
+// wrapping ErrTooFewShares if the multiplier is not Ready.
+func (tm *ThresholdMultiplier) Finalize() (*Ciphertext, error) {
+	if tm.done {
+		return nil, errors.New("multiplication was already finalized")
+	}
+
+	blinded, err := tm.combiner.Combine()
+	if err != nil {
+		return nil, err
+	}
+	tm.done = true
+
+	pk := &tm.key.PublicKey
+	xy := pk.Sub(pk.ConstMult(tm.y, blinded), tm.ay)
+	return pk.Randomize(xy), nil
+}
+
+// ThresholdMultiply runs the protocol with local decryption servers, e.g.,
+// in tests and simulations
+func (tk *ThresholdPublicKey) ThresholdMultiply(x, y *Ciphertext, servers []*ThresholdSecretKey) (*Ciphertext, error) {
+	tm, req, err := tk.NewThresholdMultiplier(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tsk := range servers {
+		if tm.Ready() {
+			break
+		}
+		resp, err := tsk.AssistThresholdMultiplication(req)
+		if err != nil {
+			return nil, err
+		}
+		if err := tm.Add(resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return tm.Finalize()
+}
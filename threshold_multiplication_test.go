@@ -0,0 +1,137 @@
+package paillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/gob"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func getMultiplicationKeys(t *testing.T) []*ThresholdSecretKey {
+	tkh, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tsks
+}
+
+func TestThresholdMultiplication(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicOnly()
+	client := NewThresholdClient(tk, []PartialDecrypter{tsks[0], tsks[2]})
+
+	for i := 0; i < 20; i++ {
+		x := tk.Encrypt(gmp.NewInt(int64(i)))
+		y := tk.Encrypt(gmp.NewInt(int64(5*i + 3)))
+
+		xy, err := tk.ThresholdMultiply(x, y, tsks[i%2:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m, err := client.TryDecrypt(xy); err != nil || n(m) != i*(5*i+3) {
+			t.Error("wrong multiplication ", m, " is not ", i*(5*i+3))
+		}
+	}
+}
+
+func TestThresholdMultiplicationMessages(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicOnly()
+	client := NewThresholdClient(tk, []PartialDecrypter{tsks[0], tsks[1]})
+
+	multiplier, req, err := tk.NewThresholdMultiplier(tk.Encrypt(gmp.NewInt(12)), tk.Encrypt(gmp.NewInt(34)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages cross the network as interface values
+	var buf bytes.Buffer
+	var sent interface{} = req
+	if err := gob.NewEncoder(&buf).Encode(&sent); err != nil {
+		t.Fatal(err)
+	}
+	var received interface{}
+	if err := gob.NewDecoder(&buf).Decode(&received); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := multiplier.Finalize(); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+
+	for _, tsk := range tsks[1:] {
+		resp, err := tsk.AssistThresholdMultiplication(received.(*ThresholdMultiplicationRequest))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded ThresholdMultiplicationResponse
+		buf.Reset()
+		if err := gob.NewEncoder(&buf).Encode(resp); err != nil {
+			t.Fatal(err)
+		}
+		if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+			t.Fatal(err)
+		}
+		if err := multiplier.Add(&decoded); err != nil {
+			t.Fatal(err)
+		}
+		if err := multiplier.Add(&decoded); !errors.Is(err, ErrDuplicateShareID) {
+			t.Error("expected ErrDuplicateShareID, got ", err)
+		}
+	}
+
+	xy, err := multiplier.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := client.TryDecrypt(xy); err != nil || n(m) != 12*34 {
+		t.Error("wrong multiplication ", m, " is not ", 12*34)
+	}
+	if _, err := multiplier.Finalize(); err == nil {
+		t.Error("expected an error for a second finalization")
+	}
+}
+
+func TestThresholdMultiplicationRejectsInvalidShares(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicOnly()
+
+	multiplier, req, err := tk.NewThresholdMultiplier(tk.Encrypt(gmp.NewInt(2)), tk.Encrypt(gmp.NewInt(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := tsks[0].AssistThresholdMultiplication(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Share.Decryption = new(gmp.Int).Add(resp.Share.Decryption, OneBigInt)
+	if err := multiplier.Add(resp); !errors.Is(err, ErrInvalidProof) {
+		t.Error("expected ErrInvalidProof, got ", err)
+	}
+
+	other, err := tsks[1].PartialDecryptionWithZKP(tk.Encrypt(gmp.NewInt(5)).C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := multiplier.Add(&ThresholdMultiplicationResponse{Share: other}); err == nil {
+		t.Error("expected an error for a share of another ciphertext")
+	}
+	if multiplier.Ready() {
+		t.Error("multiplier must not be ready without valid shares")
+	}
+
+	if _, _, err := tk.NewThresholdMultiplier(tk.Encrypt(gmp.NewInt(2)), tk.NestedEncrypt(gmp.NewInt(3))); err == nil {
+		t.Error("expected an error for a level two ciphertext")
+	}
+}
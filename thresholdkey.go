@@ -1,11 +1,14 @@
 package paillier
 
 import (
+	"crypto"
 	"crypto/rand"
-	"crypto/sha256"
+	"encoding/binary"
 	"errors"
+	"fmt"
 
 	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
 )
 
 // ThresholdPublicKey for the threshold Paillier scheme
@@ -16,6 +19,9 @@ import (
 // `Vi` is an array of verification keys for each decryption server `i` used to
 // execute a zero-knowledge proof of a received share decryption.
 //
+// `S` is the largest Damgard-Jurik exponent the shares can decrypt, i.e.,
+// ciphertexts up to level LevelForS(S) can be decrypted; zero means one.
+//
 // Key generation, encryption, share decryption and combining for the threshold
 // Paillier scheme has been described in [DJN 10], section 5.1.
 //
@@ -29,6 +35,13 @@ type ThresholdPublicKey struct {
 	Threshold                      int
 	VerificationKey                *gmp.Int // needed for ZKP
 	VerificationKeys               []*gmp.Int
+	S                              int
+
+	deltaCache   lazyInt // cache value of delta
+	combineCache lazyInt // cache value of the share combining constant
+
+	tables   *verificationTables // see EnableVerificationTables
+	lagrange *lagrangeCache      // see EnableLagrangeCache
 }
 
 // ThresholdSecretKey is the key for a threshold Paillier scheme.
@@ -39,12 +52,22 @@ type ThresholdSecretKey struct {
 	ThresholdPublicKey
 	ID    int
 	Share *gmp.Int
+
+	cache     *partialDecryptionCache // see EnablePartialDecryptionCache
+	hardened  bool                    // see EnableHardening
+	security  SecurityParams          // see SetSecurityParams
+	decrypter ShareDecrypter          // see SetShareDecrypter
 }
 
 // PartialDecryption contains a partially decrypted ciphertext
 type PartialDecryption struct {
 	ID         int
 	Decryption *gmp.Int
+
+	// KeyFingerprint is the CommitteeFingerprint of the threshold public key
+	// of the server, so that combining rejects shares of another key instead of
+	// returning a wrong plaintext; it is empty if unknown
+	KeyFingerprint string
 }
 
 // PartialDecryptionZKP is a non-interactive ZKP based on the Fiat–Shamir heuristic
@@ -55,55 +78,82 @@ type PartialDecryptionZKP struct {
 	E   *gmp.Int            // the challenge
 	Z   *gmp.Int            // the value needed to check to verify the decryption
 	C   *gmp.Int            // the input cypher text
+
+	// Session is optional context bound into the challenge, e.g., the digest
+	// of the approvals of the decryption request
+	Session []byte
+
+	// Hash and Domain select the hash function of the challenge and a domain
+	// separation tag, see ProofOptions; zero values mean SHA-256 without a tag
+	Hash   crypto.Hash
+	Domain string
+
+	// ChallengeBits is the bit length of the challenge E, see SecurityParams;
+	// zero means the full digest
+	ChallengeBits int
+
+	// A and B are the commitments of the proof. They are redundant for
+	// VerifyErr, which recomputes them, but allow VerifyBatch to check many
+	// proofs at once. They are nil for proofs decoded from the compact
+	// encoding of Encode.
+	A, B *gmp.Int
 }
 
 // Returns the value of [(4*delta^2)]^-1  mod n.
 // It is a constant value for the given `ThresholdKey` and is used in the last
 // step of share combining.
 func (tk *ThresholdPublicKey) combineSharesConstant() *gmp.Int {
-	tmp := new(gmp.Int).Mul(FourBigInt, new(gmp.Int).Mul(tk.delta(), tk.delta()))
-	return (&gmp.Int{}).ModInverse(tmp, tk.N)
+	return tk.combineCache.get(func() *gmp.Int {
+		tmp := new(gmp.Int).Mul(FourBigInt, new(gmp.Int).Mul(tk.delta(), tk.delta()))
+		return (&gmp.Int{}).ModInverse(tmp, tk.N)
+	})
 }
 
 // Returns the factorial of the number of `TotalNumberOfDecryptionServers`.
 // It is a contant value for the given `ThresholdKey`.
 func (tk *ThresholdPublicKey) delta() *gmp.Int {
-	return Factorial(tk.TotalNumberOfDecryptionServers)
+	return tk.deltaCache.get(func() *gmp.Int {
+		return Factorial(tk.TotalNumberOfDecryptionServers)
+	})
 }
 
 // Checks if the number of received, unique shares is less than the
-// required threshold.
+// required threshold. A duplicate ID would corrupt the Lagrange
+// interpolation.
 // This method does not execute ZKP on received shares.
 func (tk *ThresholdPublicKey) verifyPartialDecryptions(shares []*PartialDecryption) error {
 	if len(shares) < tk.Threshold {
-		return errors.New("Threshold not meet")
+		return fmt.Errorf("%w: got %d, threshold is %d", ErrTooFewShares, len(shares), tk.Threshold)
 	}
 	tmp := make(map[int]bool)
+	var fingerprint string
 	for _, share := range shares {
+		if tmp[share.ID] {
+			return fmt.Errorf("%w: two shares has been created by server %d", ErrDuplicateShareID, share.ID)
+		}
 		tmp[share.ID] = true
-	}
-	if len(tmp) != len(shares) {
-		return errors.New("two shares has been created by the same server")
+
+		if share.KeyFingerprint == "" {
+			continue
+		}
+		if fingerprint == "" {
+			fingerprint = tk.CommitteeFingerprint()
+		}
+		if share.KeyFingerprint != fingerprint {
+			return fmt.Errorf("%w: share of server %d", ErrKeyMismatch, share.ID)
+		}
 	}
 	return nil
 }
 
-func (tk *ThresholdPublicKey) updateLambda(share1, share2 *PartialDecryption, lambda *gmp.Int) *gmp.Int {
-	num := new(gmp.Int).Mul(lambda, gmp.NewInt(int64(-share2.ID)))
-	denom := gmp.NewInt(int64(share1.ID - share2.ID))
-	return new(gmp.Int).Div(num, denom)
-}
-
 // Evaluates lambda parameter for each decrypted share. See second figure in the
 // "Share combining" paragraph in [DJK 10], section 5.2.
 func (tk *ThresholdPublicKey) computeLambda(share *PartialDecryption, shares []*PartialDecryption) *gmp.Int {
-	lambda := tk.delta()
-	for _, share2 := range shares {
-		if share2.ID != share.ID {
-			lambda = tk.updateLambda(share, share2, lambda)
-		}
+	ids := make([]int, len(shares))
+	for i, share2 := range shares {
+		ids[i] = share2.ID
 	}
-	return lambda
+	return shamir.LagrangeCoefficient(share.ID, ids, tk.delta())
 }
 
 // Used to evaluate c' parameter which combines individual share decryptions.
@@ -147,26 +197,92 @@ func (tk *ThresholdPublicKey) computeDecryption(cprime *gmp.Int) *gmp.Int {
 
 // CombinePartialDecryptions merges several partial decryptions to produce a plaintext
 func (tk *ThresholdPublicKey) CombinePartialDecryptions(shares []*PartialDecryption) (*gmp.Int, error) {
+	done := startOperation(OpCombine)
 	if err := tk.verifyPartialDecryptions(shares); err != nil {
+		done(false)
 		return nil, err
 	}
 
-	cprime := OneBigInt
-	for _, share := range shares {
-		lambda := tk.computeLambda(share, shares)
-		cprime = tk.updateCprime(cprime, lambda, share)
+	m := tk.combine(shares)
+	done(true)
+	return m, nil
+}
+
+// CombinePartialDecryptionsAtLevel merges partial decryptions of a ciphertext
+// at the given level, which must not exceed MaxLevel, to produce a plaintext
+// in Z_{N^s}. The shares must have been produced by PartialDecryptAtLevel.
+func (tk *ThresholdPublicKey) CombinePartialDecryptionsAtLevel(shares []*PartialDecryption, level EncryptionLevel) (*gmp.Int, error) {
+	if level == EncLevelOne {
+		return tk.CombinePartialDecryptions(shares)
+	}
+	if err := tk.checkLevel(level); err != nil {
+		return nil, err
 	}
 
-	return tk.computeDecryption(cprime), nil
+	done := startOperation(OpCombine)
+	if err := tk.verifyPartialDecryptions(shares); err != nil {
+		done(false)
+		return nil, err
+	}
+
+	s, ns, ns1 := tk.getModuliForLevel(level)
+	cprime := tk.combineShares(shares, ns1)
+
+	// cprime = (1+N)^(4*delta^2*m) mod N^(s+1) since d = 1 mod N^s
+	ml := tk.recoveryAlgorithm(cprime, s)
+	tmp := new(gmp.Int).Mul(FourBigInt, new(gmp.Int).Mul(tk.delta(), tk.delta()))
+	m := new(gmp.Int).Mul(ml, tmp.ModInverse(tmp, ns))
+	done(true)
+	return m.Mod(m, ns), nil
 }
 
-// CombinePartialDecryptionsZKP merges several ZKP for partial decryptions
+// MaxLevel returns the highest level of ciphertexts the shares can decrypt
+func (tk *ThresholdPublicKey) MaxLevel() EncryptionLevel {
+	if tk.S <= 1 {
+		return EncLevelOne
+	}
+	return LevelForS(tk.S)
+}
+
+func (tk *ThresholdPublicKey) checkLevel(level EncryptionLevel) error {
+	if level < EncLevelOne || level > tk.MaxLevel() {
+		return fmt.Errorf("level %d exceeds the levels supported by the key shares", level)
+	}
+	return nil
+}
+
+// CombinePartialDecryptionsZKP merges several ZKP for partial decryptions.
+// Any number of shares may be given: the first Threshold shares with valid
+// proofs from distinct servers are combined, and invalid shares and further
+// shares of the same server are skipped. If fewer than Threshold valid shares
+// remain, the error is an *InvalidSharesError that identifies the servers
+// whose proofs failed.
 func (tk *ThresholdPublicKey) CombinePartialDecryptionsZKP(shares []*PartialDecryptionZKP) (*gmp.Int, error) {
-	ret := make([]*PartialDecryption, 0)
+	ret := make([]*PartialDecryption, 0, tk.Threshold)
+	seen := make(map[int]bool)
+	invalid := new(InvalidSharesError)
 	for _, share := range shares {
-		if share.VerifyProof() {
-			ret = append(ret, &share.PartialDecryption)
+		if len(ret) == tk.Threshold {
+			break
+		}
+		if share == nil {
+			invalid.IDs = append(invalid.IDs, 0)
+			invalid.Errs = append(invalid.Errs, fmt.Errorf("%w: missing partial decryption", ErrMalformedProof))
+			continue
+		}
+		if seen[share.ID] {
+			continue
 		}
+		if err := share.VerifyErr(); err != nil {
+			invalid.IDs = append(invalid.IDs, share.ID)
+			invalid.Errs = append(invalid.Errs, err)
+			continue
+		}
+		seen[share.ID] = true
+		ret = append(ret, &share.PartialDecryption)
+	}
+	if len(invalid.IDs) > 0 && len(ret) < tk.Threshold {
+		return nil, invalid
 	}
 	return tk.CombinePartialDecryptions(ret)
 }
@@ -182,24 +298,46 @@ func (tk *ThresholdPublicKey) VerifyDecryption(encryptedMessage, decryptedMessag
 	if err != nil {
 		return err
 	}
-	if res.Cmp(decryptedMessage) != 0 {
+	if !constantTimeEqual(res, decryptedMessage) {
 		return errors.New("The decrypted message is not the same than the one in the shares")
 	}
 	return nil
 }
 
-// PartialDecrypt returns the partial decryption of the ciphertext
+// PartialDecrypt returns the partial decryption of the ciphertext, or nil if
+// the ShareDecrypter of the key fails; PartialDecryptAtLevel returns the error.
 func (tsk *ThresholdSecretKey) PartialDecrypt(c *gmp.Int) *PartialDecryption {
-	ret := new(PartialDecryption)
-	ret.ID = tsk.ID
-	exp := new(gmp.Int).Mul(tsk.Share, new(gmp.Int).Mul(TwoBigInt, tsk.delta()))
-	gmpExp := gmp.NewInt(0).SetBytes(exp.Bytes())
-	gmpC := gmp.NewInt(0).SetBytes(c.Bytes())
-	gmpN2 := gmp.NewInt(0).SetBytes(tsk.GetN2().Bytes())
-	ret.Decryption = gmp.NewInt(0).SetBytes(new(gmp.Int).Exp(gmpC, gmpExp, gmpN2).Bytes())
+	ret, _ := tsk.partialDecrypt(c, tsk.GetN2())
 	return ret
 }
 
+// PartialDecryptAtLevel returns the partial decryption of a ciphertext at the
+// given level, which must not exceed MaxLevel
+func (tsk *ThresholdSecretKey) PartialDecryptAtLevel(c *gmp.Int, level EncryptionLevel) (*PartialDecryption, error) {
+	if err := tsk.checkLevel(level); err != nil {
+		return nil, err
+	}
+
+	_, _, ns1 := tsk.getModuliForLevel(level)
+	return tsk.partialDecrypt(c, ns1)
+}
+
+// partialDecrypt returns the partial decryption of c mod m
+func (tsk *ThresholdSecretKey) partialDecrypt(c, m *gmp.Int) (*PartialDecryption, error) {
+	done := startOperation(OpPartialDecrypt)
+	decryption, err := tsk.shareExp(c, m)
+	done(err == nil)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialDecryption{ID: tsk.ID, Decryption: decryption, KeyFingerprint: tsk.CommitteeFingerprint()}, nil
+}
+
+// shareExp returns c^(2*delta*share) mod m
+func (tsk *ThresholdSecretKey) shareExp(c, m *gmp.Int) (*gmp.Int, error) {
+	return tsk.shareDecrypter().ExpShare(c, new(gmp.Int).Mul(TwoBigInt, tsk.delta()), m)
+}
+
 func (tsk *ThresholdSecretKey) copyVerificationKeys() []*gmp.Int {
 	ret := make([]*gmp.Int, len(tsk.VerificationKeys))
 	for i, vi := range tsk.VerificationKeys {
@@ -217,20 +355,60 @@ func (tsk *ThresholdSecretKey) PublicKey() *ThresholdPublicKey {
 	ret.VerificationKey = tsk.VerificationKey
 	ret.VerificationKeys = tsk.copyVerificationKeys()
 	ret.N = new(gmp.Int).Add(tsk.N, gmp.NewInt(0))
+	ret.S = tsk.S
 	return ret
 }
 
 // PartialDecryptionWithZKP produces a partial decryption of the ciphertext
 // along with a zero-knowledge proof that it was performed correctly.
 func (tsk *ThresholdSecretKey) PartialDecryptionWithZKP(c *gmp.Int) (*PartialDecryptionZKP, error) {
+	return tsk.PartialDecryptionWithSession(c, nil)
+}
+
+// PartialDecryptionWithSession is PartialDecryptionWithZKP with the session
+// bound into the challenge of the proof, so the proof does not verify for
+// any other session
+func (tsk *ThresholdSecretKey) PartialDecryptionWithSession(c *gmp.Int, session []byte) (*PartialDecryptionZKP, error) {
+	return tsk.PartialDecryptionWithOptions(c, &ProofOptions{Session: session})
+}
+
+// PartialDecryptionWithOptions is PartialDecryptionWithZKP with the hash
+// function, domain, session and security parameters of the proof taken from
+// opts, which may be nil for the defaults
+func (tsk *ThresholdSecretKey) PartialDecryptionWithOptions(c *gmp.Int, opts *ProofOptions) (*PartialDecryptionZKP, error) {
+	logEvent(EventDecryptionRequested, &tsk.ThresholdPublicKey, tsk.ID, nil)
+
+	var effective ProofOptions
+	if opts != nil {
+		effective = *opts
+	}
+	if effective.Security == (SecurityParams{}) {
+		effective.Security = tsk.security
+	}
+	opts = &effective
+	if err := opts.check(); err != nil {
+		return nil, err
+	}
+
+	if pd := tsk.cache.get(c, opts); pd != nil {
+		return pd, nil
+	}
+
 	pd := new(PartialDecryptionZKP)
 	pd.Key = tsk.PublicKey()
 	pd.C = c
-	pd.ID = tsk.ID
-	pd.Decryption = tsk.PartialDecrypt(c).Decryption
+	pd.Session = opts.Session
+	pd.Hash = opts.Hash
+	pd.Domain = opts.Domain
+	pd.ChallengeBits = opts.Security.ChallengeBits
+	partial, err := tsk.partialDecrypt(c, tsk.GetN2())
+	if err != nil {
+		return nil, err
+	}
+	pd.PartialDecryption = *partial
 
 	// choose random number
-	rBig, err := rand.Int(rand.Reader, ToBigInt(tsk.GetN2()))
+	rBig, err := rand.Int(tsk.RandomSource(), ToBigInt(tsk.maskBound(opts.Security, opts.challengeBits())))
 	if err != nil {
 		return nil, err
 	}
@@ -247,16 +425,21 @@ func (tsk *ThresholdSecretKey) PartialDecryptionWithZKP(c *gmp.Int) (*PartialDec
 	// compute hash
 	ci2 := new(gmp.Int).Exp(pd.Decryption, gmp.NewInt(2), nil)
 
-	pd.E = tsk.computeHash(a, b, c4, ci2)
+	pd.E = computeHash(pd.options(), a, b, c4, ci2)
 
-	pd.Z = tsk.computeZ(r, pd.E)
+	pd.Z, err = tsk.computeZ(r, pd.E)
+	if err != nil {
+		return nil, err
+	}
+	pd.A, pd.B = a, b
 
+	tsk.cache.add(pd)
 	return pd, nil
 }
 
 // VerifyPartialDecryption checks if the partial decryption is valid
 func (tsk *ThresholdSecretKey) VerifyPartialDecryption() error {
-	m, err := rand.Int(rand.Reader, ToBigInt(tsk.N))
+	m, err := rand.Int(tsk.RandomSource(), ToBigInt(tsk.N))
 	if err != nil {
 		return err
 	}
@@ -269,25 +452,115 @@ func (tsk *ThresholdSecretKey) VerifyPartialDecryption() error {
 		return err
 	}
 	if !proof.VerifyProof() {
-		return errors.New("Invalid share")
+		err = errors.New("Invalid share")
 	}
-	return nil
+	logEvent(EventShareValidated, &tsk.ThresholdPublicKey, tsk.ID, err)
+	return err
 }
 
 // VerifyProof returns true if and only if the proof is correct
 func (pd *PartialDecryptionZKP) VerifyProof() bool {
+	return pd.VerifyErr() == nil
+}
+
+// VerifyErrWithKey verifies the proof as VerifyErr and additionally returns an
+// error wrapping ErrStaleKey if the proof was produced for a key other than tk,
+// e.g., by a server that still holds a share of a rotated key.
+func (pd *PartialDecryptionZKP) VerifyErrWithKey(tk *ThresholdPublicKey) error {
+	if err := pd.checkKey(tk); err != nil {
+		return err
+	}
+	return pd.VerifyErr()
+}
+
+// returns an error wrapping ErrStaleKey if the proof was produced for a key
+// other than tk
+func (pd *PartialDecryptionZKP) checkKey(tk *ThresholdPublicKey) error {
+	if pd.Key == nil || pd.Key.N == nil || pd.Key.VerificationKey == nil {
+		return &InvalidProofError{ID: pd.ID, Err: ErrMalformedProof}
+	}
+
+	if pd.Key.N.Cmp(tk.N) != 0 || pd.Key.VerificationKey.Cmp(tk.VerificationKey) != 0 ||
+		len(pd.Key.VerificationKeys) != len(tk.VerificationKeys) {
+		return &InvalidProofError{ID: pd.ID, Err: ErrStaleKey}
+	}
+
+	for i, vi := range tk.VerificationKeys {
+		if pd.Key.VerificationKeys[i].Cmp(vi) != 0 {
+			return &InvalidProofError{ID: pd.ID, Err: ErrStaleKey}
+		}
+	}
+
+	return nil
+}
+
+// VerifyErr returns nil if the proof is correct and otherwise an error wrapping
+// ErrMalformedProof or ErrChallengeMismatch that explains why it was rejected.
+// Both verification equations are bound by a single Fiat-Shamir challenge,
+// so a mismatch cannot be attributed to one of them.
+func (pd *PartialDecryptionZKP) VerifyErr() error {
+	done := startOperation(OpVerifyProof)
+
+	err := pd.verify()
+	done(err == nil)
+	if err != nil {
+		err = &InvalidProofError{ID: pd.ID, Err: err}
+		if pd.Key != nil && pd.Key.N != nil {
+			logEvent(EventProofFailed, pd.Key, pd.ID, err)
+		}
+	}
+
+	return err
+}
+
+func (pd *PartialDecryptionZKP) verify() error {
+	if err := pd.checkValues(); err != nil {
+		return err
+	}
+
 	a := pd.verifyPart1()
 	b := pd.verifyPart2()
-	hash := sha256.New()
-	hash.Write(a.Bytes())
-	hash.Write(b.Bytes())
 	c4 := new(gmp.Int).Exp(pd.C, FourBigInt, nil)
-	hash.Write(c4.Bytes())
 	ci2 := new(gmp.Int).Exp(pd.Decryption, TwoBigInt, nil)
-	hash.Write(ci2.Bytes())
 
-	expectedE := new(gmp.Int).SetBytes(hash.Sum([]byte{}))
-	return pd.E.Cmp(expectedE) == 0
+	opts := pd.options()
+	if err := opts.check(); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProof, err)
+	}
+
+	expectedE := computeHash(opts, a, b, c4, ci2)
+	if pd.E.Cmp(expectedE) != 0 {
+		return ErrChallengeMismatch
+	}
+
+	return nil
+}
+
+// checks that all values of the proof are present and that the values that
+// are inverted during verification are units mod N^2
+func (pd *PartialDecryptionZKP) checkValues() error {
+	if pd.Key == nil || pd.Key.N == nil || pd.Key.VerificationKey == nil ||
+		pd.Decryption == nil || pd.C == nil || pd.E == nil || pd.Z == nil {
+		return fmt.Errorf("%w: missing values", ErrMalformedProof)
+	}
+
+	if pd.ID < 1 || pd.ID > len(pd.Key.VerificationKeys) {
+		return fmt.Errorf("%w: share ID is out of range", ErrMalformedProof)
+	}
+
+	if pd.E.Sign() < 0 || pd.Z.Sign() < 0 {
+		return fmt.Errorf("%w: negative challenge or response", ErrMalformedProof)
+	}
+
+	n2 := pd.Key.GetN2()
+	values := []*gmp.Int{pd.C, pd.Decryption, pd.Key.VerificationKey, pd.Key.VerificationKeys[pd.ID-1]}
+	for _, x := range values {
+		if x.Sign() <= 0 || x.Cmp(n2) >= 0 || new(gmp.Int).GCD(nil, nil, x, pd.Key.N).Cmp(OneBigInt) != 0 {
+			return fmt.Errorf("%w: value is not a unit mod N^2", ErrMalformedProof)
+		}
+	}
+
+	return nil
 }
 
 func (pd *PartialDecryptionZKP) verifyPart1() *gmp.Int {
@@ -302,25 +575,40 @@ func (pd *PartialDecryptionZKP) verifyPart1() *gmp.Int {
 }
 
 func (pd *PartialDecryptionZKP) verifyPart2() *gmp.Int {
-	vi := pd.Key.VerificationKeys[pd.ID-1]                               // servers are indexed from 1
-	b1 := new(gmp.Int).Exp(pd.Key.VerificationKey, pd.Z, pd.Key.GetN2()) // V^Z
-	b2 := new(gmp.Int).Exp(vi, pd.E, pd.Key.GetN2())                     // (v_i)^E
+	b1 := pd.Key.expV(pd.Z)         // V^Z
+	b2 := pd.Key.expVi(pd.ID, pd.E) // (v_i)^E, servers are indexed from 1
 	b2 = new(gmp.Int).ModInverse(b2, pd.Key.GetN2())
 	b := new(gmp.Int).Mod(new(gmp.Int).Mul(b1, b2), pd.Key.GetN2())
 	return b
 }
 
-func (tsk *ThresholdSecretKey) computeZ(r, e *gmp.Int) *gmp.Int {
-	tmp := new(gmp.Int).Mul(e, tsk.delta())
-	tmp = new(gmp.Int).Mul(tmp, tsk.Share)
-	return new(gmp.Int).Add(r, tmp)
+// computeZ returns r + e*delta*share
+func (tsk *ThresholdSecretKey) computeZ(r, e *gmp.Int) (*gmp.Int, error) {
+	return tsk.shareDecrypter().MulAddShare(r, new(gmp.Int).Mul(e, tsk.delta()))
 }
 
-func (tsk *ThresholdSecretKey) computeHash(a, b, c4, ci2 *gmp.Int) *gmp.Int {
-	hash := sha256.New()
+// the session is only hashed if present so that proofs without a session
+// keep their challenge; it is followed by its length so that it cannot be
+// shifted into ci2. With a domain, the session and its length are always
+// hashed, so the domain's length is never in the position of the session's.
+// opts must have been checked.
+func computeHash(opts *ProofOptions, a, b, c4, ci2 *gmp.Int) *gmp.Int {
+	hash := opts.hash().New()
 	hash.Write(a.Bytes())
 	hash.Write(b.Bytes())
 	hash.Write(c4.Bytes())
 	hash.Write(ci2.Bytes())
-	return new(gmp.Int).SetBytes(hash.Sum([]byte{}))
+	if len(opts.Session) > 0 || opts.Domain != "" {
+		hash.Write(opts.Session)
+		binary.Write(hash, binary.BigEndian, uint32(len(opts.Session)))
+	}
+	if opts.Domain != "" {
+		hash.Write([]byte(opts.Domain))
+		binary.Write(hash, binary.BigEndian, uint32(len(opts.Domain)))
+	}
+	e := new(gmp.Int).SetBytes(hash.Sum([]byte{}))
+	if bits := opts.challengeBits(); bits < 8*hash.Size() {
+		e.Rsh(e, uint(8*hash.Size()-bits))
+	}
+	return e
 }
@@ -1,15 +1,19 @@
 package paillier
 
 import (
-	"crypto/rand"
+	"context"
 	"errors"
+	"fmt"
 	"io"
-	"math/big"
-	"time"
 
 	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
 )
 
+// DefaultMinPublicKeyBitLength is the shortest public key GenerateKeys
+// generates unless the generator allows insecure keys
+const DefaultMinPublicKeyBitLength = 2048
+
 // ThresholdKeyGenerator generates a threshold Paillier key with an algorithm based on [DJN 10],
 // section 5.1, "Key generation".
 //     [DJN 10]: Ivan Damgard, Mads Jurik, Jesper Buus Nielsen, (2010)
@@ -22,6 +26,42 @@ type ThresholdKeyGenerator struct {
 	Threshold                      int
 	random                         io.Reader
 
+	// S is the Damgard-Jurik exponent of the generated keys, which can then
+	// decrypt ciphertexts up to level LevelForS(S); zero means one. It may be
+	// set before calling GenerateKeys.
+	S int
+
+	// Security are the security parameters of the partial decryption proofs
+	// of the generated keys, see SetSecurityParams. It may be set before
+	// calling GenerateKeys.
+	Security SecurityParams
+
+	// Progress, if set, receives the progress of GenerateKeys: the search
+	// for the safe primes and the dealing of the shares. It may be set
+	// before calling GenerateKeys.
+	Progress KeyGenProgressFunc
+	progress *keyGenProgress
+
+	// MinPublicKeyBitLength is the shortest public key GenerateKeys
+	// generates; zero means DefaultMinPublicKeyBitLength.
+	MinPublicKeyBitLength int
+
+	// RequireHonestMajority rejects committees that do not meet
+	// HonestMajority. It may be set before calling GenerateKeys.
+	RequireHonestMajority bool
+
+	// InsecureAllowSmallKeys lets GenerateKeys generate public keys shorter
+	// than MinPublicKeyBitLength, which can be factored. It is meant for
+	// tests only.
+	InsecureAllowSmallKeys bool
+
+	// Deterministic searches for the safe primes on a single goroutine so
+	// that the keys only depend on the bytes read from the random source of
+	// the generator, see KeyGenFromSeed. It is implied if the source is a
+	// *CTRDRBG, e.g., NewDeterministicReader. It may be set before calling
+	// GenerateKeys.
+	Deterministic bool
+
 	p *gmp.Int // p is prime of `PublicKeyBitLength/2` bits and `p = 2*p1 + 1`
 	q *gmp.Int // q is prime of `PublicKeyBitLength/2` bits and `q = 2*q1 + 1`
 
@@ -31,33 +71,56 @@ type ThresholdKeyGenerator struct {
 	n  *gmp.Int // n=p*q and is of `PublicKeyBitLength` bits
 	m  *gmp.Int // m = p1*q1
 	n2 *gmp.Int // n2 = n*n
-	nm *gmp.Int // nm = n*m
+	nm *gmp.Int // nm = n^s*m
 
-	// As specified in the paper, d must satify d=1 mod n and d=0 mod m
+	// As specified in the paper, d must satify d=1 mod n^s and d=0 mod m
 	d *gmp.Int
 
 	// A generator of QR in Z_{n^2}
 	v *gmp.Int
 
-	// The polynomial coefficients to hide a secret. See Shamir.
-	polynomialCoefficients []*gmp.Int
+	// The polynomial to hide a secret. See Shamir.
+	polynomial *shamir.Polynomial
 }
 
 // GenerateKeys returns as set of thrshold secret keys
 func (tkg *ThresholdKeyGenerator) GenerateKeys() ([]*ThresholdSecretKey, error) {
-	if err := tkg.initNumerialValues(); err != nil {
+	return tkg.GenerateKeysContext(context.Background())
+}
+
+// GenerateKeysContext is GenerateKeys but aborts the search for the safe
+// primes when ctx is done, in which case ctx.Err() is returned
+func (tkg *ThresholdKeyGenerator) GenerateKeysContext(ctx context.Context) ([]*ThresholdSecretKey, error) {
+	done := startOperation(OpKeyGen)
+	tkg.progress = newKeyGenProgress(tkg.Progress)
+	if err := tkg.checkParameters(); err != nil {
+		done(false)
+		return nil, err
+	}
+	if err := tkg.initNumerialValues(ctx); err != nil {
+		done(false)
 		return nil, err
 	}
 	if err := tkg.generateHidingPolynomial(); err != nil {
+		done(false)
 		return nil, err
 	}
-	return tkg.createPrivateKeys(), nil
+	tsks := tkg.createPrivateKeys()
+	done(true)
+	logEvent(EventKeyGenerated, &tsks[0].ThresholdPublicKey, 0, nil)
+	return tsks, nil
 }
 
 // NewThresholdKeyGenerator is a preferable way to construct the ThresholdKeyGenerator.
 // Due to the various properties that must be met for the threshold key to be
 // considered valid, the minimum public key `N` bit length is 18 bits and the
 // public key bit length should be an even number.
+// The threshold must be between 1 and the number of decryption servers, and
+// the number of servers must be smaller than the prime factors of p-1 and q-1
+// so that delta = n! is invertible; parameters violating these relations are
+// rejected here instead of failing later during key generation or decryption.
+// GenerateKeys additionally rejects public keys shorter than
+// DefaultMinPublicKeyBitLength unless InsecureAllowSmallKeys is set.
 // The plaintext space for the key will be `Z_N`.
 func NewThresholdKeyGenerator(
 	publicKeyBitLength int,
@@ -65,32 +128,127 @@ func NewThresholdKeyGenerator(
 	threshold int,
 	random io.Reader,
 ) (*ThresholdKeyGenerator, error) {
-	if publicKeyBitLength%2 == 1 {
+	tkg := &ThresholdKeyGenerator{
+		PublicKeyBitLength:             publicKeyBitLength,
+		TotalNumberOfDecryptionServers: totalNumberOfDecryptionServers,
+		Threshold:                      threshold,
+		random:                         random,
+	}
+	if err := tkg.validate(); err != nil {
+		return nil, err
+	}
+	return tkg, nil
+}
+
+// validate checks the relations between the parameters of the generator,
+// which are exported and may be changed after NewThresholdKeyGenerator
+func (tkg *ThresholdKeyGenerator) validate() error {
+	if tkg.PublicKeyBitLength%2 == 1 {
 		// For an odd n-bit number, we can't find two n/2-bit numbers with two
 		// the most significant bits set on which multiplied gives an n-bit
 		// number.
-		return nil, errors.New("Public key bit length must be an even number")
+		return errors.New("Public key bit length must be an even number")
 	}
-	if publicKeyBitLength < 18 {
+	if tkg.PublicKeyBitLength < 18 {
 		// We need to find two n/2-bit safe primes, P and Q which are not equal.
 		// This is not possible for n<18.
-		return nil, errors.New("Public key bit length must be at least 18 bits")
+		return errors.New("Public key bit length must be at least 18 bits")
+	}
+	if err := CheckThresholdParameters(tkg.TotalNumberOfDecryptionServers, tkg.Threshold); err != nil {
+		return err
+	}
+	if tkg.RequireHonestMajority && !HonestMajority(tkg.TotalNumberOfDecryptionServers, tkg.Threshold) {
+		return fmt.Errorf(
+			"Threshold %d of %d decryption servers does not assume an honest majority, the maximum is %d",
+			tkg.Threshold, tkg.TotalNumberOfDecryptionServers, (tkg.TotalNumberOfDecryptionServers+1)/2,
+		)
+	}
+	// p1 and q1 have PublicKeyBitLength/2-1 bits, so every server index and
+	// hence delta = n! is coprime to m if n < 2^(PublicKeyBitLength/2-2)
+	if tkg.PublicKeyBitLength/2-2 < 31 && tkg.TotalNumberOfDecryptionServers >= 1<<uint(tkg.PublicKeyBitLength/2-2) {
+		return fmt.Errorf(
+			"Public key bit length %d is too short for %d decryption servers",
+			tkg.PublicKeyBitLength, tkg.TotalNumberOfDecryptionServers,
+		)
+	}
+	if tkg.random == nil {
+		return errors.New("Random source must not be nil")
 	}
 
-	return &ThresholdKeyGenerator{
-		PublicKeyBitLength:             publicKeyBitLength,
-		TotalNumberOfDecryptionServers: totalNumberOfDecryptionServers,
-		Threshold:                      threshold,
-		random:                         random,
-	}, nil
+	return nil
 }
 
-func (tkg *ThresholdKeyGenerator) generateSafePrimes() (*gmp.Int, *gmp.Int, error) {
-	concurrencyLevel := 4
-	timeout := 120 * time.Second
-	safePrimeBitLength := tkg.PublicKeyBitLength / 2
+// CheckThresholdParameters returns an error unless a committee of total
+// decryption servers can have the threshold, i.e., unless
+// 1 <= threshold <= total. NewThresholdKeyGenerator and GenerateKeys perform
+// the same check, which lets configurations be validated before the
+// (expensive) key generation or before the parameters are sent to a dealer.
+func CheckThresholdParameters(total, threshold int) error {
+	if total < 1 {
+		return fmt.Errorf("Number of decryption servers must be at least 1, got %d", total)
+	}
+	if threshold < 1 {
+		return fmt.Errorf("Threshold must be at least 1, got %d", threshold)
+	}
+	if threshold > total {
+		return fmt.Errorf("Threshold %d exceeds the number of decryption servers %d", threshold, total)
+	}
+	return nil
+}
 
-	p, q, err := GenerateSafePrime(safePrimeBitLength, concurrencyLevel, timeout, tkg.random)
+// HonestMajority reports whether a committee of total decryption servers with
+// the threshold meets the assumption of the security proof of [DJN 10],
+// section 5.2, that the adversary corrupts a minority of the servers: the
+// threshold-1 servers that may be corrupted without revealing plaintexts are
+// fewer than half of the servers, i.e., total >= 2*threshold - 1. Only then
+// can the honest servers always decrypt without the corrupted ones, so that
+// the scheme is robust. A committee that fails it still keeps plaintexts
+// secret from fewer than threshold servers, but a few refusing or cheating
+// servers can prevent decryption.
+func HonestMajority(total, threshold int) bool {
+	return CheckThresholdParameters(total, threshold) == nil && total >= 2*threshold-1
+}
+
+// checkParameters checks the parameters of the generator before any key
+// material is generated
+func (tkg *ThresholdKeyGenerator) checkParameters() error {
+	if err := tkg.validate(); err != nil {
+		return err
+	}
+	if err := tkg.checkKeySize(); err != nil {
+		return err
+	}
+	if tkg.S < 0 || tkg.S > MaxEncryptionLevel.S() {
+		return fmt.Errorf("Damgard-Jurik exponent must be between 1 and %d, got %d", MaxEncryptionLevel.S(), tkg.S)
+	}
+	return nil
+}
+
+// checkKeySize rejects public keys shorter than MinPublicKeyBitLength unless
+// InsecureAllowSmallKeys is set
+func (tkg *ThresholdKeyGenerator) checkKeySize() error {
+	if tkg.InsecureAllowSmallKeys {
+		return nil
+	}
+	min := tkg.MinPublicKeyBitLength
+	if min == 0 {
+		min = DefaultMinPublicKeyBitLength
+	}
+	if tkg.PublicKeyBitLength < min {
+		return fmt.Errorf(
+			"Public key bit length %d is below the minimum of %d bits; set InsecureAllowSmallKeys to generate it anyway",
+			tkg.PublicKeyBitLength, min,
+		)
+	}
+	return nil
+}
+
+func (tkg *ThresholdKeyGenerator) safePrimeConcurrency() int {
+	return safePrimeConcurrency(tkg.random, tkg.Deterministic)
+}
+
+func (tkg *ThresholdKeyGenerator) generateSafePrimes(ctx context.Context) (*gmp.Int, *gmp.Int, error) {
+	p, q, err := generateSafePrimeWithTimeout(ctx, tkg.PublicKeyBitLength/2, tkg.safePrimeConcurrency(), tkg.random)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -98,15 +256,15 @@ func (tkg *ThresholdKeyGenerator) generateSafePrimes() (*gmp.Int, *gmp.Int, erro
 	return ToGmpInt(p), ToGmpInt(q), nil
 }
 
-func (tkg *ThresholdKeyGenerator) initPandP1() error {
+func (tkg *ThresholdKeyGenerator) initPandP1(ctx context.Context) error {
 	var err error
-	tkg.p, tkg.p1, err = tkg.generateSafePrimes()
+	tkg.p, tkg.p1, err = tkg.generateSafePrimes(ctx)
 	return err
 }
 
-func (tkg *ThresholdKeyGenerator) initQandQ1() error {
+func (tkg *ThresholdKeyGenerator) initQandQ1(ctx context.Context) error {
 	var err error
-	tkg.q, tkg.q1, err = tkg.generateSafePrimes()
+	tkg.q, tkg.q1, err = tkg.generateSafePrimes(ctx)
 	return err
 }
 
@@ -114,7 +272,15 @@ func (tkg *ThresholdKeyGenerator) initShortcuts() {
 	tkg.n = new(gmp.Int).Mul(tkg.p, tkg.q)
 	tkg.m = new(gmp.Int).Mul(tkg.p1, tkg.q1)
 	tkg.n2 = new(gmp.Int).Mul(tkg.n, tkg.n)
-	tkg.nm = new(gmp.Int).Mul(tkg.n, tkg.m)
+	tkg.nm = new(gmp.Int).Mul(tkg.ns(), tkg.m)
+}
+
+// ns returns n^s
+func (tkg *ThresholdKeyGenerator) ns() *gmp.Int {
+	if tkg.S <= 1 {
+		return tkg.n
+	}
+	return new(gmp.Int).Exp(tkg.n, gmp.NewInt(int64(tkg.S)), nil)
 }
 
 func (tkg *ThresholdKeyGenerator) arePsAndQsGood() bool {
@@ -130,15 +296,18 @@ func (tkg *ThresholdKeyGenerator) arePsAndQsGood() bool {
 	return true
 }
 
-func (tkg *ThresholdKeyGenerator) initPsAndQs() error {
-	if err := tkg.initPandP1(); err != nil {
-		return err
-	}
-	if err := tkg.initQandQ1(); err != nil {
+// p and q are searched for by one pool of goroutines on all cores, which
+// returns the first pair of safe primes that is good
+func (tkg *ThresholdKeyGenerator) initPsAndQs(ctx context.Context) error {
+	p, q, err := generateSafePrimePairWithTimeout(ctx, tkg.PublicKeyBitLength/2, tkg.safePrimeConcurrency(), tkg.random, tkg.progress)
+	if err != nil {
 		return err
 	}
+
+	tkg.p, tkg.p1 = ToGmpInt(p.p), ToGmpInt(p.q)
+	tkg.q, tkg.q1 = ToGmpInt(q.p), ToGmpInt(q.q)
 	if !tkg.arePsAndQsGood() {
-		return tkg.initPsAndQs()
+		return tkg.initPsAndQs(ctx)
 	}
 	return nil
 }
@@ -150,7 +319,8 @@ func (tkg *ThresholdKeyGenerator) computeV() error {
 	return err
 }
 
-// Choose d such that d=0 (mod m) and d=1 (mod n).
+// Choose d such that d=0 (mod m) and d=1 (mod n^s). The derivation below is
+// for s=1; for larger s, n is replaced by n^s.
 //
 // From Chinese Remainder Theorem:
 // x = a1 (mod n1)
@@ -175,12 +345,12 @@ func (tkg *ThresholdKeyGenerator) computeV() error {
 //
 // x = a2*y2*z2 = 1 * m * [m^-1 mod n]
 func (tkg *ThresholdKeyGenerator) initD() {
-	mInverse := new(gmp.Int).ModInverse(tkg.m, tkg.n)
+	mInverse := new(gmp.Int).ModInverse(tkg.m, tkg.ns())
 	tkg.d = new(gmp.Int).Mul(mInverse, tkg.m)
 }
 
-func (tkg *ThresholdKeyGenerator) initNumerialValues() error {
-	if err := tkg.initPsAndQs(); err != nil {
+func (tkg *ThresholdKeyGenerator) initNumerialValues(ctx context.Context) error {
+	if err := tkg.initPsAndQs(ctx); err != nil {
 		return err
 	}
 	tkg.initShortcuts()
@@ -195,31 +365,16 @@ func (tkg *ThresholdKeyGenerator) initNumerialValues() error {
 // `a_i` - random value from {0, ... nm - 1} for 0<i<w
 // `a_0` is always equal `d`
 func (tkg *ThresholdKeyGenerator) generateHidingPolynomial() error {
-	tkg.polynomialCoefficients = make([]*gmp.Int, tkg.Threshold)
-	tkg.polynomialCoefficients[0] = tkg.d
-	for i := 1; i < tkg.Threshold; i++ {
-		randInt, err := rand.Int(tkg.random, new(big.Int).SetBytes(tkg.nm.Bytes()))
-		if err != nil {
-			return err
-		}
-		tkg.polynomialCoefficients[i] = new(gmp.Int).SetBytes(randInt.Bytes())
-
-	}
-	return nil
+	var err error
+	tkg.polynomial, err = shamir.NewPolynomial(tkg.d, tkg.Threshold, tkg.nm, tkg.random)
+	return err
 }
 
 // The secred share of the i'th authority is `f(i) mod nm`, where `f` is
 // the polynomial we generated in `GenerateHidingPolynomial` function.
 func (tkg *ThresholdKeyGenerator) computeShare(index int) *gmp.Int {
-	share := gmp.NewInt(0)
-	for i := 0; i < tkg.Threshold; i++ {
-		a := tkg.polynomialCoefficients[i]
-		// we index authorities from 1, that's why we do index+1 here
-		b := new(gmp.Int).Exp(gmp.NewInt(int64(index+1)), gmp.NewInt(int64(i)), nil)
-		tmp := new(gmp.Int).Mul(a, b)
-		share = new(gmp.Int).Add(share, tmp)
-	}
-	return new(gmp.Int).Mod(share, tkg.nm)
+	// we index authorities from 1, that's why we do index+1 here
+	return tkg.polynomial.Evaluate(index + 1)
 }
 
 func (tkg *ThresholdKeyGenerator) createShares() []*gmp.Int {
@@ -264,6 +419,8 @@ func (tkg *ThresholdKeyGenerator) createSecretKey(i int, share *gmp.Int, verific
 	ret.Share = share
 	ret.ID = i + 1
 	ret.VerificationKeys = verificationKeys
+	ret.S = tkg.S
+	ret.security = tkg.Security
 	return ret
 }
 
@@ -273,6 +430,7 @@ func (tkg *ThresholdKeyGenerator) createPrivateKeys() []*ThresholdSecretKey {
 	ret := make([]*ThresholdSecretKey, tkg.TotalNumberOfDecryptionServers)
 	for i := 0; i < tkg.TotalNumberOfDecryptionServers; i++ {
 		ret[i] = tkg.createSecretKey(i, shares[i], verificationKeys)
+		tkg.progress.shareDealt()
 	}
 	return ret
 }
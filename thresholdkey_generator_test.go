@@ -1,12 +1,15 @@
 package paillier
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier/shamir"
 )
 
 var MockGenerateSafePrimes = func() (*gmp.Int, *gmp.Int, error) {
@@ -48,6 +51,35 @@ func TestCreateThresholdKeyGenerator(t *testing.T) {
 			threshold:                      3,
 			expectedError:                  errors.New("Public key bit length must be at least 18 bits"),
 		},
+		"generator can't be created without decryption servers": {
+			publicKeyBitLength:             20,
+			totalNumberOfDecryptionServers: 0,
+			threshold:                      0,
+			expectedError:                  errors.New("Number of decryption servers must be at least 1, got 0"),
+		},
+		"generator can't be created for threshold 0": {
+			publicKeyBitLength:             20,
+			totalNumberOfDecryptionServers: 4,
+			threshold:                      0,
+			expectedError:                  errors.New("Threshold must be at least 1, got 0"),
+		},
+		"generator can't be created for threshold above the number of servers": {
+			publicKeyBitLength:             20,
+			totalNumberOfDecryptionServers: 4,
+			threshold:                      5,
+			expectedError:                  errors.New("Threshold 5 exceeds the number of decryption servers 4"),
+		},
+		"generator can't be created for too many servers": {
+			publicKeyBitLength:             18,
+			totalNumberOfDecryptionServers: 128,
+			threshold:                      3,
+			expectedError:                  errors.New("Public key bit length 18 is too short for 128 decryption servers"),
+		},
+		"generator successfully created for 127 servers and 18 bit key length": {
+			publicKeyBitLength:             18,
+			totalNumberOfDecryptionServers: 127,
+			threshold:                      3,
+		},
 	}
 
 	for testName, test := range tests {
@@ -124,7 +156,7 @@ func TestGenerateNumbersOfCorrectBitLength(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			err = gen.initNumerialValues()
+			err = gen.initNumerialValues(context.Background())
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -166,7 +198,7 @@ func TestInitPandP1(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tkh.initPandP1()
+	tkh.initPandP1(context.Background())
 	IsSafePrime(ToBigInt(tkh.p), ToBigInt(tkh.p1), 16, t)
 }
 
@@ -176,7 +208,7 @@ func TestInitQandQ1(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tkh.initQandQ1()
+	tkh.initQandQ1(context.Background())
 	IsSafePrime(ToBigInt(tkh.q), ToBigInt(tkh.q1), 16, t)
 }
 
@@ -186,7 +218,7 @@ func TestInitPsAndQs(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tkh.initPsAndQs()
+	tkh.initPsAndQs(context.Background())
 
 	IsSafePrime(ToBigInt(tkh.p), ToBigInt(tkh.p1), 16, t)
 	IsSafePrime(ToBigInt(tkh.q), ToBigInt(tkh.q1), 16, t)
@@ -248,7 +280,7 @@ func TestInitNumerialValues(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(err)
 	}
 }
@@ -259,13 +291,13 @@ func TestGenerateHidingPolynomial(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(err)
 	}
 	if err := tkh.generateHidingPolynomial(); err != nil {
 		t.Error(err)
 	}
-	p := tkh.polynomialCoefficients
+	p := tkh.polynomial.Coefficients
 	if len(p) != tkh.Threshold {
 		t.Fail()
 	}
@@ -285,8 +317,10 @@ func TestComputeShare(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tkh.nm = b(103)
-	tkh.polynomialCoefficients = []*gmp.Int{b(29), b(88), b(51)}
+	tkh.polynomial = &shamir.Polynomial{
+		Coefficients: []*gmp.Int{b(29), b(88), b(51)},
+		Modulus:      b(103),
+	}
 	share := tkh.computeShare(2)
 	if n(share) != 31 {
 		t.Error("error computing a share.  ", share)
@@ -299,7 +333,7 @@ func TestCreateShares(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(err)
 	}
 	if err := tkh.generateHidingPolynomial(); err != nil {
@@ -328,17 +362,55 @@ func TestGetThresholdKeyGenerator(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
-	if err := tkh.initNumerialValues(); err != nil {
+	if err := tkh.initNumerialValues(context.Background()); err != nil {
 		t.Error(nil)
 	}
 }
 
+func TestCheckThresholdParameters(t *testing.T) {
+	for _, c := range []struct {
+		total, threshold int
+		valid, majority  bool
+	}{
+		{1, 1, true, true},
+		{3, 2, true, true},
+		{4, 3, true, false},
+		{5, 3, true, true},
+		{10, 6, true, false},
+		{0, 0, false, false},
+		{3, 0, false, false},
+		{3, 4, false, false},
+	} {
+		if err := CheckThresholdParameters(c.total, c.threshold); (err == nil) != c.valid {
+			t.Errorf("%d of %d: unexpected error %v", c.threshold, c.total, err)
+		}
+		if HonestMajority(c.total, c.threshold) != c.majority {
+			t.Errorf("%d of %d: expected HonestMajority to be %v", c.threshold, c.total, c.majority)
+		}
+		if _, err := NewThresholdKeyGenerator(64, c.total, c.threshold, rand.Reader); (err == nil) != c.valid {
+			t.Errorf("%d of %d: unexpected error %v", c.threshold, c.total, err)
+		}
+	}
+
+	tkg, err := NewThresholdKeyGenerator(64, 4, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkg.InsecureAllowSmallKeys = true
+	tkg.RequireHonestMajority = true
+	if _, err := tkg.GenerateKeys(); err == nil {
+		t.Error("expected an error for a committee without an honest majority")
+	}
+}
+
 func TestGenerate(t *testing.T) {
 	tkh, err := NewThresholdKeyGenerator(32, 10, 6, rand.Reader)
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, err := tkh.GenerateKeys()
 	if err != nil {
@@ -387,3 +459,30 @@ func TestComputeV(t *testing.T) {
 	than it was taken in the range 0...n**2 -1
 	`)
 }
+
+func TestGenerateKeysRejectsSmallKeys(t *testing.T) {
+	tkg, err := NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tkg.GenerateKeys(); err == nil || !strings.Contains(err.Error(), "below the minimum of 2048 bits") {
+		t.Error("expected a 64-bit key to be rejected, got ", err)
+	}
+
+	tkg.MinPublicKeyBitLength = 64
+	if _, err := tkg.GenerateKeys(); err != nil {
+		t.Error("expected a 64-bit key to be accepted with a lower floor, got ", err)
+	}
+
+	tkg.MinPublicKeyBitLength = 4096
+	tkg.InsecureAllowSmallKeys = true
+	if _, err := tkg.GenerateKeys(); err != nil {
+		t.Error("expected a small key to be accepted when allowed, got ", err)
+	}
+
+	// the exported parameters are validated again
+	tkg.Threshold = 4
+	if _, err := tkg.GenerateKeys(); err == nil || err.Error() != "Threshold 4 exceeds the number of decryption servers 3" {
+		t.Error("expected the threshold to be rejected, got ", err)
+	}
+}
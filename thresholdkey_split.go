@@ -0,0 +1,214 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// SplitIntoThresholdShares converts an existing secret key into a set of
+// threshold secret keys such that any `threshold` out of
+// `totalNumberOfDecryptionServers` servers can decrypt ciphertexts that were
+// encrypted under the original public key. Existing ciphertexts therefore
+// do not need to be re-encrypted when moving to committee custody.
+//
+// The sharing follows [DJN 10], section 5.1, with m set to Lambda of the
+// secret key: d is chosen such that d=0 mod Lambda and d=1 mod N and is then
+// shared with a random polynomial over Z_{N*Lambda}. A fresh verification key
+// V and the per-server verification keys Vi = V^(delta*s_i) are derived
+// for the partial decryption proofs.
+//
+// The security argument of [DJN 10] assumes that N is a product of safe primes;
+// for keys generated by KeyGen the sharing is correct but the statistical
+// hiding of the shares relies on Lambda having no small prime factors.
+// The original secret key should be destroyed once the shares have been
+// distributed.
+func SplitIntoThresholdShares(sk *SecretKey, threshold, totalNumberOfDecryptionServers int, random io.Reader) ([]*ThresholdSecretKey, error) {
+	if threshold < 1 || threshold > totalNumberOfDecryptionServers {
+		return nil, errors.New("threshold must be between 1 and the total number of decryption servers")
+	}
+
+	if new(gmp.Int).GCD(nil, nil, sk.Lambda, sk.N).Cmp(OneBigInt) != 0 {
+		return nil, errors.New("Lambda and N must be relatively prime")
+	}
+
+	tkg := &ThresholdKeyGenerator{
+		PublicKeyBitLength:             sk.N.BitLen(),
+		TotalNumberOfDecryptionServers: totalNumberOfDecryptionServers,
+		Threshold:                      threshold,
+		random:                         random,
+	}
+
+	tkg.n = new(gmp.Int).Set(sk.N)
+	tkg.m = new(gmp.Int).Set(sk.Lambda)
+	tkg.n2 = new(gmp.Int).Mul(tkg.n, tkg.n)
+	tkg.nm = new(gmp.Int).Mul(tkg.n, tkg.m)
+	tkg.initD()
+
+	if err := tkg.computeV(); err != nil {
+		return nil, err
+	}
+	if err := tkg.generateHidingPolynomial(); err != nil {
+		return nil, err
+	}
+
+	tsks := tkg.createPrivateKeys()
+	for _, tsk := range tsks {
+		// keep the parameters for alternative encryption
+		tsk.H = sk.H
+		tsk.K = sk.K
+	}
+
+	return tsks, nil
+}
+
+// DealThresholdKey is SplitIntoThresholdShares with the arguments in the
+// order of NewThresholdKeyGenerator: the secret key is shared among
+// totalNumberOfDecryptionServers servers of which threshold can decrypt
+func DealThresholdKey(sk *SecretKey, totalNumberOfDecryptionServers, threshold int, random io.Reader) ([]*ThresholdSecretKey, error) {
+	return SplitIntoThresholdShares(sk, threshold, totalNumberOfDecryptionServers, random)
+}
+
+// KeyReconstructionConfirmation must be passed to ReconstructSecretKey to
+// confirm that the shares of the committee should be recombined into a
+// single secret key, which removes the protection offered by threshold custody.
+const KeyReconstructionConfirmation = "I understand that this reconstructs the full secret key"
+
+// ReconstructSecretKey recovers a standard secret key from at least `Threshold`
+// threshold secret keys, e.g., for break-glass recovery or for decommissioning
+// a committee. The operator must pass KeyReconstructionConfirmation as
+// `confirmation` to acknowledge the operation.
+//
+// Every share is checked against its verification key. The shares are then
+// combined with the Lagrange coefficients used for share combining, which
+// yields delta*d + k*N*m for some integer k. Since d=0 mod m this value is a
+// multiple of m and is used to factor N, from which the secret key is
+// recomputed. This holds for dealt keys as well as for keys from the
+// distributed key generation or a resharing, whose d is a multiple of phi.
+func ReconstructSecretKey(shares []*ThresholdSecretKey, confirmation string) (*SecretKey, error) {
+	if confirmation != KeyReconstructionConfirmation {
+		return nil, errors.New("key reconstruction was not confirmed")
+	}
+
+	if len(shares) == 0 {
+		return nil, errors.New("no shares provided")
+	}
+
+	tk := &shares[0].ThresholdPublicKey
+	ids := make([]*PartialDecryption, len(shares))
+	for i, share := range shares {
+		if share.N.Cmp(tk.N) != 0 ||
+			share.Threshold != tk.Threshold ||
+			share.TotalNumberOfDecryptionServers != tk.TotalNumberOfDecryptionServers {
+			return nil, errors.New("shares belong to different threshold keys")
+		}
+		ids[i] = &PartialDecryption{ID: share.ID}
+	}
+
+	if err := tk.verifyPartialDecryptions(ids); err != nil {
+		return nil, err
+	}
+
+	for _, share := range shares {
+		if err := tk.checkShare(share); err != nil {
+			return nil, err
+		}
+	}
+
+	// x = sum lambda_i * s_i = delta*d (mod N*m)
+	x := gmp.NewInt(0)
+	for i, share := range shares {
+		lambda := tk.computeLambda(ids[i], ids)
+		x.Add(x, new(gmp.Int).Mul(lambda, share.Share))
+	}
+
+	// 4x is a multiple of the Carmichael function of N
+	x.Abs(x)
+	x.Mul(x, FourBigInt)
+
+	p, err := factorWithMultipleOfCarmichael(tk.N, x)
+	if err != nil {
+		return nil, err
+	}
+	q := new(gmp.Int).Div(tk.N, p)
+
+	pk := &PublicKey{
+		N: new(gmp.Int).Set(tk.N),
+		G: new(gmp.Int).Add(tk.N, OneBigInt),
+		H: shares[0].H,
+		K: shares[0].K,
+	}
+
+	sk := &SecretKey{
+		PublicKey: *pk,
+		Lambda:    computePhi(p, q),
+		m:         new(gmp.Int).Set(tk.N),
+	}
+
+	return sk, nil
+}
+
+// checks the share against its verification key V_i = V^(delta*s_i) so that
+// a corrupted share is reported as such rather than as a failure to factor
+func (tk *ThresholdPublicKey) checkShare(share *ThresholdSecretKey) error {
+	if share.ID < 1 || share.ID > len(tk.VerificationKeys) || tk.VerificationKey == nil {
+		return fmt.Errorf("no verification key for the share of server %d", share.ID)
+	}
+
+	exp := new(gmp.Int).Mul(tk.delta(), share.Share)
+	if new(gmp.Int).Exp(tk.VerificationKey, exp, tk.GetN2()).Cmp(tk.VerificationKeys[share.ID-1]) != 0 {
+		return fmt.Errorf("share of server %d does not match its verification key", share.ID)
+	}
+	return nil
+}
+
+// factorWithMultipleOfCarmichael returns a non-trivial factor of n given
+// a positive multiple e of the Carmichael function of n, using the
+// probabilistic algorithm of Miller: for e = 2^s * t with t odd and random w,
+// the sequence w^t, w^2t, ..., w^(2^s t) = 1 mod n contains a non-trivial
+// square root of 1 with probability at least 1/2.
+func factorWithMultipleOfCarmichael(n, e *gmp.Int) (*gmp.Int, error) {
+	if e.Sign() == 0 {
+		return nil, errors.New("shares are inconsistent")
+	}
+
+	t := new(gmp.Int).Set(e)
+	s := 0
+	for t.Bit(0) == 0 {
+		t.Rsh(t, 1)
+		s++
+	}
+
+	nMinusOne := minusOne(n)
+	for attempt := 0; attempt < 128; attempt++ {
+		w, err := GetRandomNumber(n, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		if w.Cmp(OneBigInt) <= 0 {
+			continue
+		}
+
+		if g := new(gmp.Int).GCD(nil, nil, w, n); g.Cmp(OneBigInt) != 0 {
+			return g, nil
+		}
+
+		x := new(gmp.Int).Exp(w, t, n)
+		for i := 0; i < s; i++ {
+			if x.Cmp(OneBigInt) == 0 || x.Cmp(nMinusOne) == 0 {
+				break
+			}
+
+			y := new(gmp.Int).Exp(x, TwoBigInt, n)
+			if y.Cmp(OneBigInt) == 0 {
+				return new(gmp.Int).GCD(nil, nil, minusOne(x), n), nil
+			}
+			x = y
+		}
+	}
+
+	return nil, errors.New("shares are inconsistent")
+}
@@ -0,0 +1,183 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestSplitIntoThresholdShares(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	tsks, err := SplitIntoThresholdShares(sk, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tsks) != 5 {
+		t.Fatal("wrong number of shares ", len(tsks))
+	}
+
+	for i := 0; i < 20; i++ {
+		// ciphertexts created before the split
+		ct := pk.Encrypt(gmp.NewInt(int64(i * 1000)))
+
+		shares := make([]*PartialDecryptionZKP, 0)
+		for _, tsk := range tsks[i%3 : i%3+3] {
+			if tsk.N.Cmp(pk.N) != 0 {
+				t.Fatal("threshold key does not match the public key")
+			}
+
+			share, err := tsk.PartialDecryptionWithZKP(ct.C)
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares = append(shares, share)
+		}
+
+		m, err := tsks[0].CombinePartialDecryptionsZKP(shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n(m) != i*1000 {
+			t.Error("wrong decryption ", m, " is not ", i*1000)
+		}
+	}
+}
+
+func TestDealThresholdKey(t *testing.T) {
+	sk, pk := KeyGen(128)
+	ct := pk.Encrypt(gmp.NewInt(555))
+
+	tsks, err := DealThresholdKey(sk, 4, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tsks) != 4 || tsks[0].Threshold != 2 || tsks[0].TotalNumberOfDecryptionServers != 4 {
+		t.Fatal("wrong committee parameters")
+	}
+
+	shares := []*PartialDecryption{tsks[1].PartialDecrypt(ct.C), tsks[3].PartialDecrypt(ct.C)}
+	m, err := tsks[0].CombinePartialDecryptions(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n(m) != 555 {
+		t.Error("wrong decryption ", m)
+	}
+
+	if _, err := DealThresholdKey(sk, 2, 3, rand.Reader); err == nil {
+		t.Error("expected an error for a threshold above the number of servers")
+	}
+}
+
+func TestSplitIntoThresholdSharesParameters(t *testing.T) {
+	sk, _ := KeyGen(64)
+
+	if _, err := SplitIntoThresholdShares(sk, 0, 5, rand.Reader); err == nil {
+		t.Error("expected error for threshold 0")
+	}
+
+	if _, err := SplitIntoThresholdShares(sk, 6, 5, rand.Reader); err == nil {
+		t.Error("expected error for threshold larger than the number of servers")
+	}
+}
+
+func TestReconstructSecretKey(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		sk, pk := KeyGen(128)
+
+		tsks, err := SplitIntoThresholdShares(sk, 3, 5, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reconstructed, err := ReconstructSecretKey(tsks[1:4], KeyReconstructionConfirmation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reconstructed.Lambda.Cmp(sk.Lambda) != 0 {
+			t.Error("reconstructed Lambda ", reconstructed.Lambda, " is not ", sk.Lambda)
+		}
+
+		ct := pk.EncryptAtLevel(gmp.NewInt(int64(i)), EncLevelTwo)
+		if m := reconstructed.Decrypt(ct); n(m) != i {
+			t.Error("wrong decryption ", m, " is not ", i)
+		}
+	}
+}
+
+func TestReconstructSecretKeyFromGeneratedKeys(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sk, err := ReconstructSecretKey(tsks, KeyReconstructionConfirmation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct := tsks[0].Encrypt(b(1234))
+	if m := sk.Decrypt(ct); n(m) != 1234 {
+		t.Error("wrong decryption ", m, " is not 1234")
+	}
+}
+
+func TestReconstructSecretKeyFromDistributedKeys(t *testing.T) {
+	keys := runDKGSteps(t, newDKGParticipants(t, 128, 3, 2))
+	ct := keys[0].Encrypt(b(4321))
+
+	sk, err := ReconstructSecretKey(keys[1:], KeyReconstructionConfirmation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := sk.Decrypt(ct); n(m) != 4321 {
+		t.Error("wrong decryption ", m, " is not 4321")
+	}
+
+	reshared := reshare(t, keys, []int{1, 3}, 4, 3)
+	sk, err = ReconstructSecretKey(reshared[:3], KeyReconstructionConfirmation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := sk.Decrypt(ct); n(m) != 4321 {
+		t.Error("wrong decryption after resharing ", m, " is not 4321")
+	}
+}
+
+func TestReconstructSecretKeyErrors(t *testing.T) {
+	sk, _ := KeyGen(128)
+
+	tsks, err := SplitIntoThresholdShares(sk, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReconstructSecretKey(tsks, ""); err == nil {
+		t.Error("expected error without confirmation")
+	}
+
+	if _, err := ReconstructSecretKey(tsks[:2], KeyReconstructionConfirmation); err == nil {
+		t.Error("expected error with fewer shares than the threshold")
+	}
+
+	if _, err := ReconstructSecretKey([]*ThresholdSecretKey{tsks[0], tsks[0], tsks[1]}, KeyReconstructionConfirmation); err == nil {
+		t.Error("expected error for duplicate shares")
+	}
+
+	tsks[2].Share = new(gmp.Int).Add(tsks[2].Share, OneBigInt)
+	if _, err := ReconstructSecretKey(tsks[:3], KeyReconstructionConfirmation); err == nil ||
+		err.Error() != "share of server 3 does not match its verification key" {
+		t.Error("expected error for a corrupted share, got ", err)
+	}
+}
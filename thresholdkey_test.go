@@ -2,6 +2,7 @@ package paillier
 
 import (
 	"crypto/rand"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -13,6 +14,7 @@ func getThresholdPrivateKey() *ThresholdSecretKey {
 	if err != nil {
 		panic(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, err := tkh.GenerateKeys()
 	if err != nil {
@@ -134,6 +136,46 @@ func TestVerifyPart2(t *testing.T) {
 	}
 }
 
+func TestVerifyErr(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := tpks[0].Encrypt(gmp.NewInt(876))
+	proof, err := tpks[0].PartialDecryptionWithZKP(c.C)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := proof.VerifyErrWithKey(&tpks[1].ThresholdPublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.VerifyErrWithKey(&other[0].ThresholdPublicKey); !errors.Is(err, ErrStaleKey) {
+		t.Error("expected stale key error, got ", err)
+	}
+
+	proof.E.Add(proof.E, OneBigInt)
+	if err := proof.VerifyErr(); !errors.Is(err, ErrChallengeMismatch) {
+		t.Error("expected challenge mismatch, got ", err)
+	}
+
+	proof.Decryption = gmp.NewInt(0)
+	if err := proof.VerifyErr(); !errors.Is(err, ErrMalformedProof) {
+		t.Error("expected malformed proof error, got ", err)
+	}
+}
+
 func TestPartialDecryptionWithZKP(t *testing.T) {
 	pd := getThresholdPrivateKey()
 	c := pd.Encrypt(gmp.NewInt(876))
@@ -165,23 +207,12 @@ func TestMakeVerificationBeforeCombiningPartialDecryptions(t *testing.T) {
 	}
 }
 
-func TestUpdateLambda(t *testing.T) {
-	tk := new(ThresholdPublicKey)
-	lambda := b(11)
-	share1 := &PartialDecryption{3, b(5)}
-	share2 := &PartialDecryption{7, b(3)}
-	res := tk.updateLambda(share1, share2, lambda)
-	if n(res) != 20 {
-		t.Error("wrong lambda", n(res))
-	}
-}
-
 func TestUpdateCprime(t *testing.T) {
 	tk := new(ThresholdPublicKey)
 	tk.N = b(99)
 	cprime := b(77)
 	lambda := b(52)
-	share := &PartialDecryption{3, b(5)}
+	share := &PartialDecryption{ID: 3, Decryption: b(5)}
 	cprime = tk.updateCprime(cprime, lambda, share)
 	if n(cprime) != 8558 {
 		t.Error("wrong cprime", cprime)
@@ -194,6 +225,7 @@ func TestEncryptingDecryptingSimple(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, err := tkh.GenerateKeys()
 	if err != nil {
@@ -217,6 +249,7 @@ func TestEncryptingDecrypting(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, err := tkh.GenerateKeys()
 	if err != nil {
@@ -236,11 +269,56 @@ func TestEncryptingDecrypting(t *testing.T) {
 	}
 }
 
+func TestDamgardJurikThresholdDecryption(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tkh.S = 3
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpks[0].MaxLevel() != LevelForS(3) {
+		t.Fatal("wrong maximum level ", tpks[0].MaxLevel())
+	}
+
+	for level := EncLevelOne; level <= LevelForS(3); level++ {
+		_, ns, _ := tpks[0].getModuliForLevel(level)
+		message := new(gmp.Int).Sub(ns, b(3))
+		c := tpks[0].Add(tpks[0].EncryptAtLevel(message, level), tpks[1].EncryptAtLevel(b(1), level))
+
+		share1, err := tpks[0].PartialDecryptAtLevel(c.C, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		share3, err := tpks[2].PartialDecryptAtLevel(c.C, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := tpks[1].CombinePartialDecryptionsAtLevel([]*PartialDecryption{share1, share3}, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Cmp(new(gmp.Int).Add(message, b(1))) != 0 {
+			t.Error("wrong decryption at level ", level)
+		}
+	}
+
+	if _, err := tpks[0].PartialDecryptAtLevel(b(1), LevelForS(4)); err == nil {
+		t.Error("level above the exponent of the key was accepted")
+	}
+}
+
 func TestHomomorphicThresholdEncryption(t *testing.T) {
 	tkh, err := NewThresholdKeyGenerator(32, 2, 2, rand.Reader)
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, _ := tkh.GenerateKeys()
 
@@ -266,8 +344,8 @@ func TestHomomorphicThresholdEncryption(t *testing.T) {
 
 func TestDecryption(t *testing.T) {
 	// test the correct decryption of '100'.
-	share1 := &PartialDecryption{1, b(384111638639)}
-	share2 := &PartialDecryption{2, b(235243761043)}
+	share1 := &PartialDecryption{ID: 1, Decryption: b(384111638639)}
+	share2 := &PartialDecryption{ID: 2, Decryption: b(235243761043)}
 	tk := new(ThresholdPublicKey)
 	tk.Threshold = 2
 	tk.TotalNumberOfDecryptionServers = 2
@@ -296,6 +374,7 @@ func TestCombinePartialDecryptionsZKP(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, err := tkh.GenerateKeys()
 	if err != nil {
@@ -331,6 +410,7 @@ func TestCombinePartialDecryptionsWith100Shares(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, err := tkh.GenerateKeys()
 	if err != nil {
@@ -359,6 +439,7 @@ func TestVerifyDecryption(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 
 	tpks, err := tkh.GenerateKeys()
 
@@ -398,6 +479,7 @@ func BenchmarkThresholdDecrypt(b *testing.B) {
 	if err != nil {
 		b.Error(err)
 	}
+	tkh.InsecureAllowSmallKeys = true
 	tpks, err := tkh.GenerateKeys()
 	if err != nil {
 		b.Fatal(err)
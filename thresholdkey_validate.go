@@ -0,0 +1,100 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// Validate checks that the threshold public key is well-formed, e.g., before
+// a combiner uses a key received from the network: N is an odd modulus of at
+// least minBitLength bits that is neither a square nor divisible by a small
+// prime, G is N+1 if set, 1 <= Threshold <= TotalNumberOfDecryptionServers,
+// and V and one verification key V_i per server are units mod N^2. A
+// minBitLength of zero means DefaultMinPublicKeyBitLength. The errors wrap
+// ErrInvalidKey.
+//
+// Validate cannot tell whether N is the product of two safe primes or
+// whether the V_i match the shares, see VerifyPartialDecryption for the latter.
+func (tk *ThresholdPublicKey) Validate(minBitLength int) error {
+	if minBitLength == 0 {
+		minBitLength = DefaultMinPublicKeyBitLength
+	}
+
+	if err := tk.validateModulus(minBitLength); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if err := tk.validateCommittee(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return nil
+}
+
+func (pk *PublicKey) validateModulus(minBitLength int) error {
+	n := pk.N
+	if n == nil {
+		return errors.New("N is missing")
+	}
+	if n.BitLen() < minBitLength {
+		return fmt.Errorf("N has %d bits, the minimum is %d", n.BitLen(), minBitLength)
+	}
+	if n.Bit(0) == 0 {
+		return errors.New("N is even")
+	}
+	if hasSmallPrimeFactor(ToBigInt(n)) {
+		return errors.New("N has a small prime factor")
+	}
+	root := new(gmp.Int).Sqrt(n)
+	if root.Mul(root, root).Cmp(n) == 0 {
+		return errors.New("N is a square")
+	}
+
+	if pk.G != nil && pk.G.Cmp(new(gmp.Int).Add(n, OneBigInt)) != 0 {
+		return errors.New("G is not N+1")
+	}
+	return nil
+}
+
+func (tk *ThresholdPublicKey) validateCommittee() error {
+	if tk.TotalNumberOfDecryptionServers < 1 {
+		return errors.New("committee has no decryption servers")
+	}
+	if tk.Threshold < 1 || tk.Threshold > tk.TotalNumberOfDecryptionServers {
+		return fmt.Errorf("threshold %d is not between 1 and %d", tk.Threshold, tk.TotalNumberOfDecryptionServers)
+	}
+	if tk.S < 0 {
+		return errors.New("S is negative")
+	}
+
+	if err := tk.validateUnit(tk.VerificationKey); err != nil {
+		return fmt.Errorf("V %v", err)
+	}
+	if tk.VerificationKey.Cmp(OneBigInt) == 0 {
+		return errors.New("V is one")
+	}
+
+	if len(tk.VerificationKeys) != tk.TotalNumberOfDecryptionServers {
+		return fmt.Errorf("%d verification keys for %d servers", len(tk.VerificationKeys), tk.TotalNumberOfDecryptionServers)
+	}
+	for i, vi := range tk.VerificationKeys {
+		if err := tk.validateUnit(vi); err != nil {
+			return fmt.Errorf("V_%d %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// returns an error if x is not in Z*_{N^2}
+func (pk *PublicKey) validateUnit(x *gmp.Int) error {
+	if x == nil {
+		return errors.New("is missing")
+	}
+	if x.Sign() <= 0 || x.Cmp(pk.GetN2()) >= 0 {
+		return errors.New("is out of range")
+	}
+	if new(gmp.Int).GCD(nil, nil, x, pk.N).Cmp(OneBigInt) != 0 {
+		return errors.New("is not a unit")
+	}
+	return nil
+}
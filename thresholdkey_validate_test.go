@@ -0,0 +1,62 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestThresholdPublicKeyValidate(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(64, 5, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := &tsks[0].ThresholdPublicKey
+
+	if err := tk.Validate(64); err != nil {
+		t.Error(err)
+	}
+	if err := tk.Validate(0); !errors.Is(err, ErrInvalidKey) {
+		t.Error("expected error for a key below the default minimum, got ", err)
+	}
+
+	withVi := func(i int, vi *gmp.Int) []*gmp.Int {
+		vis := append([]*gmp.Int{}, tk.VerificationKeys...)
+		vis[i] = vi
+		return vis
+	}
+
+	malformed := map[string]func(k *ThresholdPublicKey){
+		"even N":             func(k *ThresholdPublicKey) { k.N = new(gmp.Int).Add(tk.N, OneBigInt) },
+		"small factor":       func(k *ThresholdPublicKey) { k.N = new(gmp.Int).Mul(tk.N, b(3)) },
+		"square N":           func(k *ThresholdPublicKey) { k.N = new(gmp.Int).Mul(b(65537*3+2), b(65537*3+2)) },
+		"wrong G":            func(k *ThresholdPublicKey) { k.G = b(2) },
+		"threshold too high": func(k *ThresholdPublicKey) { k.Threshold = 6 },
+		"zero threshold":     func(k *ThresholdPublicKey) { k.Threshold = 0 },
+		"missing V_i":        func(k *ThresholdPublicKey) { k.VerificationKeys = k.VerificationKeys[:4] },
+		"V_i out of range":   func(k *ThresholdPublicKey) { k.VerificationKeys = withVi(2, tk.GetN2()) },
+		"V_i not a unit":     func(k *ThresholdPublicKey) { k.VerificationKeys = withVi(2, tk.N) },
+		"missing V":          func(k *ThresholdPublicKey) { k.VerificationKey = nil },
+		"V is one":           func(k *ThresholdPublicKey) { k.VerificationKey = b(1) },
+	}
+	for name, modify := range malformed {
+		k := &ThresholdPublicKey{
+			PublicKey:                      PublicKey{N: tk.N, G: tk.G},
+			TotalNumberOfDecryptionServers: tk.TotalNumberOfDecryptionServers,
+			Threshold:                      tk.Threshold,
+			VerificationKey:                tk.VerificationKey,
+			VerificationKeys:               tk.VerificationKeys,
+		}
+		modify(k)
+		if err := k.Validate(32); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: expected ErrInvalidKey, got %v", name, err)
+		}
+	}
+}
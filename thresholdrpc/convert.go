@@ -0,0 +1,187 @@
+package thresholdrpc
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"math"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+// FromThresholdPublicKey returns the message of tk
+func FromThresholdPublicKey(tk *paillier.ThresholdPublicKey) *ThresholdPublicKey {
+	m := &ThresholdPublicKey{
+		N:                              intBytes(tk.N),
+		G:                              intBytes(tk.G),
+		H:                              intBytes(tk.H),
+		K:                              intBytes(tk.K),
+		TotalNumberOfDecryptionServers: uint32(tk.TotalNumberOfDecryptionServers),
+		Threshold:                      uint32(tk.Threshold),
+		VerificationKey:                intBytes(tk.VerificationKey),
+		S:                              uint32(tk.S),
+	}
+	for _, vi := range tk.VerificationKeys {
+		m.VerificationKeys = append(m.VerificationKeys, intBytes(vi))
+	}
+	return m
+}
+
+// ThresholdPublicKey returns the key of the message. The key is not
+// validated beyond its parameters; call Validate on keys from untrusted
+// sources.
+func (m *ThresholdPublicKey) ThresholdPublicKey() (*paillier.ThresholdPublicKey, error) {
+	if len(m.N) == 0 {
+		return nil, errors.New("threshold key is missing the public key")
+	}
+	if m.Threshold < 1 || m.Threshold > m.TotalNumberOfDecryptionServers || m.TotalNumberOfDecryptionServers > math.MaxInt32 {
+		return nil, errors.New("threshold must be between 1 and the total number of decryption servers")
+	}
+	if len(m.VerificationKeys) > 0 && len(m.VerificationKeys) != int(m.TotalNumberOfDecryptionServers) {
+		return nil, errors.New("number of verification keys does not match the number of decryption servers")
+	}
+	if m.S > uint32(paillier.MaxEncryptionLevel.S()) {
+		return nil, errors.New("unsupported Damgard-Jurik exponent")
+	}
+
+	tk := &paillier.ThresholdPublicKey{
+		PublicKey:                      paillier.PublicKey{N: toInt(m.N), G: toInt(m.G), H: toInt(m.H), K: toInt(m.K)},
+		TotalNumberOfDecryptionServers: int(m.TotalNumberOfDecryptionServers),
+		Threshold:                      int(m.Threshold),
+		VerificationKey:                toInt(m.VerificationKey),
+		S:                              int(m.S),
+	}
+	for _, vi := range m.VerificationKeys {
+		tk.VerificationKeys = append(tk.VerificationKeys, toInt(vi))
+	}
+	return tk, nil
+}
+
+// FromCiphertext returns the message of ct
+func FromCiphertext(ct *paillier.Ciphertext) *Ciphertext {
+	return &Ciphertext{
+		C:         intBytes(ct.C),
+		Level:     uint32(ct.Level),
+		EncMethod: uint32(ct.EncMethod),
+	}
+}
+
+// Ciphertext returns the ciphertext of the message
+func (m *Ciphertext) Ciphertext() (*paillier.Ciphertext, error) {
+	if len(m.C) == 0 {
+		return nil, paillier.ErrInvalidCiphertext
+	}
+	if m.Level > uint32(paillier.MaxEncryptionLevel) {
+		return nil, errors.New("unsupported encryption level")
+	}
+	if m.EncMethod > math.MaxInt32 {
+		return nil, errors.New("unsupported encryption method")
+	}
+
+	return &paillier.Ciphertext{
+		C:         toInt(m.C),
+		Level:     paillier.EncryptionLevel(m.Level),
+		EncMethod: paillier.EncryptionMethod(m.EncMethod),
+	}, nil
+}
+
+// FromPartialDecryption returns the message of pd
+func FromPartialDecryption(pd *paillier.PartialDecryption) *PartialDecryption {
+	return &PartialDecryption{
+		Id:             uint32(pd.ID),
+		Decryption:     intBytes(pd.Decryption),
+		KeyFingerprint: pd.KeyFingerprint,
+	}
+}
+
+// PartialDecryption returns the partial decryption of the message
+func (m *PartialDecryption) PartialDecryption() (*paillier.PartialDecryption, error) {
+	if m.Id == 0 || m.Id > math.MaxInt32 {
+		return nil, errors.New("share ID is out of range")
+	}
+	if len(m.Decryption) == 0 {
+		return nil, errors.New("partial decryption is missing the decryption")
+	}
+
+	return &paillier.PartialDecryption{
+		ID:             int(m.Id),
+		Decryption:     toInt(m.Decryption),
+		KeyFingerprint: m.KeyFingerprint,
+	}, nil
+}
+
+// FromPartialDecryptionZKP returns the message of pd, which references the
+// key of the proof by its digest
+func FromPartialDecryptionZKP(pd *paillier.PartialDecryptionZKP) (*PartialDecryptionZKP, error) {
+	if pd.Key == nil {
+		return nil, errors.New("partial decryption is missing the key")
+	}
+
+	return &PartialDecryptionZKP{
+		PartialDecryption: FromPartialDecryption(&pd.PartialDecryption),
+		KeyDigest:         pd.Key.Digest(),
+		C:                 intBytes(pd.C),
+		E:                 intBytes(pd.E),
+		Z:                 intBytes(pd.Z),
+		Session:           pd.Session,
+		Hash:              uint32(pd.Hash),
+		Domain:            pd.Domain,
+		ChallengeBits:     uint32(pd.ChallengeBits),
+	}, nil
+}
+
+// PartialDecryptionZKP returns the partial decryption of the message for
+// the key tk. It returns an error wrapping paillier.ErrStaleKey if the
+// message references a different key. The proof is not verified.
+func (m *PartialDecryptionZKP) PartialDecryptionZKP(tk *paillier.ThresholdPublicKey) (*paillier.PartialDecryptionZKP, error) {
+	if m.PartialDecryption == nil {
+		return nil, errors.New("message is missing the partial decryption")
+	}
+	pd, err := m.PartialDecryption.PartialDecryption()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(m.KeyDigest, tk.Digest()) {
+		return nil, &paillier.InvalidProofError{ID: pd.ID, Err: paillier.ErrStaleKey}
+	}
+	if len(m.C) == 0 || len(m.E) == 0 || len(m.Z) == 0 {
+		return nil, errors.New("partial decryption is missing proof values")
+	}
+	if m.ChallengeBits > math.MaxInt32 {
+		return nil, fmt.Errorf("challenge length of %d bits is out of range", m.ChallengeBits)
+	}
+
+	var session []byte
+	if len(m.Session) > 0 {
+		session = append(session, m.Session...)
+	}
+
+	return &paillier.PartialDecryptionZKP{
+		PartialDecryption: *pd,
+		Key:               tk,
+		C:                 toInt(m.C),
+		E:                 toInt(m.E),
+		Z:                 toInt(m.Z),
+		Session:           session,
+		Hash:              crypto.Hash(m.Hash),
+		Domain:            m.Domain,
+		ChallengeBits:     int(m.ChallengeBits),
+	}, nil
+}
+
+func intBytes(x *gmp.Int) []byte {
+	if x == nil {
+		return nil
+	}
+	return x.Bytes()
+}
+
+// toInt returns the integer of the big-endian bytes b, or nil if b is empty
+func toInt(b []byte) *gmp.Int {
+	if len(b) == 0 {
+		return nil
+	}
+	return new(gmp.Int).SetBytes(b)
+}
@@ -0,0 +1,282 @@
+// Package thresholdrpc defines the messages and the DecryptionServer service
+// of decryption.proto, so that decryption servers of a threshold committee
+// can run as separate processes in any language.
+//
+// The Go types are written by hand against the proto3 wire format, since the
+// module has no dependencies other than gmp; they encode the same bytes as
+// types generated by protoc-gen-go and interoperate with gRPC peers that use
+// the generated code. The reference transport in this package posts the
+// encoded messages over HTTP to the gRPC method paths.
+package thresholdrpc
+
+// ThresholdPublicKey is the message of a paillier.ThresholdPublicKey
+type ThresholdPublicKey struct {
+	N                              []byte
+	G                              []byte
+	TotalNumberOfDecryptionServers uint32
+	Threshold                      uint32
+	VerificationKey                []byte
+	VerificationKeys               [][]byte
+	S                              uint32
+	H                              []byte
+	K                              []byte
+}
+
+// Marshal returns the protobuf encoding of m
+func (m *ThresholdPublicKey) Marshal() ([]byte, error) {
+	var b []byte
+	b = appendBytesField(b, 1, m.N)
+	b = appendBytesField(b, 2, m.G)
+	b = appendUint32Field(b, 3, m.TotalNumberOfDecryptionServers)
+	b = appendUint32Field(b, 4, m.Threshold)
+	b = appendBytesField(b, 5, m.VerificationKey)
+	for _, vk := range m.VerificationKeys {
+		b = appendRepeatedBytes(b, 6, vk)
+	}
+	b = appendUint32Field(b, 7, m.S)
+	b = appendBytesField(b, 8, m.H)
+	b = appendBytesField(b, 9, m.K)
+	return b, nil
+}
+
+// Unmarshal decodes the protobuf encoding of m
+func (m *ThresholdPublicKey) Unmarshal(data []byte) error {
+	*m = ThresholdPublicKey{}
+	return unmarshalFields(data, func(num int) interface{} {
+		switch num {
+		case 1:
+			return &m.N
+		case 2:
+			return &m.G
+		case 3:
+			return &m.TotalNumberOfDecryptionServers
+		case 4:
+			return &m.Threshold
+		case 5:
+			return &m.VerificationKey
+		case 6:
+			return &m.VerificationKeys
+		case 7:
+			return &m.S
+		case 8:
+			return &m.H
+		case 9:
+			return &m.K
+		}
+		return nil
+	})
+}
+
+// Ciphertext is the message of a paillier.Ciphertext
+type Ciphertext struct {
+	C         []byte
+	Level     uint32
+	EncMethod uint32
+}
+
+// Marshal returns the protobuf encoding of m
+func (m *Ciphertext) Marshal() ([]byte, error) {
+	var b []byte
+	b = appendBytesField(b, 1, m.C)
+	b = appendUint32Field(b, 2, m.Level)
+	b = appendUint32Field(b, 3, m.EncMethod)
+	return b, nil
+}
+
+// Unmarshal decodes the protobuf encoding of m
+func (m *Ciphertext) Unmarshal(data []byte) error {
+	*m = Ciphertext{}
+	return unmarshalFields(data, func(num int) interface{} {
+		switch num {
+		case 1:
+			return &m.C
+		case 2:
+			return &m.Level
+		case 3:
+			return &m.EncMethod
+		}
+		return nil
+	})
+}
+
+// PartialDecryption is the message of a paillier.PartialDecryption
+type PartialDecryption struct {
+	Id             uint32
+	Decryption     []byte
+	KeyFingerprint string
+}
+
+// Marshal returns the protobuf encoding of m
+func (m *PartialDecryption) Marshal() ([]byte, error) {
+	var b []byte
+	b = appendUint32Field(b, 1, m.Id)
+	b = appendBytesField(b, 2, m.Decryption)
+	b = appendStringField(b, 3, m.KeyFingerprint)
+	return b, nil
+}
+
+// Unmarshal decodes the protobuf encoding of m
+func (m *PartialDecryption) Unmarshal(data []byte) error {
+	*m = PartialDecryption{}
+	return unmarshalFields(data, func(num int) interface{} {
+		switch num {
+		case 1:
+			return &m.Id
+		case 2:
+			return &m.Decryption
+		case 3:
+			return &m.KeyFingerprint
+		}
+		return nil
+	})
+}
+
+// PartialDecryptionZKP is the message of a paillier.PartialDecryptionZKP.
+// The key is referenced by KeyDigest, the SHA-256 digest of its canonical
+// encoding.
+type PartialDecryptionZKP struct {
+	PartialDecryption *PartialDecryption
+	KeyDigest         []byte
+	C                 []byte
+	E                 []byte
+	Z                 []byte
+	Session           []byte
+	Hash              uint32
+	Domain            string
+	ChallengeBits     uint32
+}
+
+// Marshal returns the protobuf encoding of m
+func (m *PartialDecryptionZKP) Marshal() ([]byte, error) {
+	var b []byte
+	if m.PartialDecryption != nil {
+		pd, err := m.PartialDecryption.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendRepeatedBytes(b, 1, pd)
+	}
+	b = appendBytesField(b, 2, m.KeyDigest)
+	b = appendBytesField(b, 3, m.C)
+	b = appendBytesField(b, 4, m.E)
+	b = appendBytesField(b, 5, m.Z)
+	b = appendBytesField(b, 6, m.Session)
+	b = appendUint32Field(b, 7, m.Hash)
+	b = appendStringField(b, 8, m.Domain)
+	b = appendUint32Field(b, 9, m.ChallengeBits)
+	return b, nil
+}
+
+// Unmarshal decodes the protobuf encoding of m
+func (m *PartialDecryptionZKP) Unmarshal(data []byte) error {
+	*m = PartialDecryptionZKP{}
+	return unmarshalFields(data, func(num int) interface{} {
+		switch num {
+		case 1:
+			return func(data []byte) error {
+				m.PartialDecryption = new(PartialDecryption)
+				return m.PartialDecryption.Unmarshal(data)
+			}
+		case 2:
+			return &m.KeyDigest
+		case 3:
+			return &m.C
+		case 4:
+			return &m.E
+		case 5:
+			return &m.Z
+		case 6:
+			return &m.Session
+		case 7:
+			return &m.Hash
+		case 8:
+			return &m.Domain
+		case 9:
+			return &m.ChallengeBits
+		}
+		return nil
+	})
+}
+
+// GetPublicKeyRequest is the request of DecryptionServer.GetPublicKey
+type GetPublicKeyRequest struct{}
+
+// Marshal returns the protobuf encoding of m
+func (m *GetPublicKeyRequest) Marshal() ([]byte, error) {
+	return nil, nil
+}
+
+// Unmarshal decodes the protobuf encoding of m
+func (m *GetPublicKeyRequest) Unmarshal(data []byte) error {
+	return unmarshalFields(data, func(int) interface{} { return nil })
+}
+
+// PartialDecryptRequest is the request of DecryptionServer.PartialDecrypt
+type PartialDecryptRequest struct {
+	Ciphertext *Ciphertext
+	Session    []byte
+}
+
+// Marshal returns the protobuf encoding of m
+func (m *PartialDecryptRequest) Marshal() ([]byte, error) {
+	var b []byte
+	if m.Ciphertext != nil {
+		ct, err := m.Ciphertext.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendRepeatedBytes(b, 1, ct)
+	}
+	b = appendBytesField(b, 2, m.Session)
+	return b, nil
+}
+
+// Unmarshal decodes the protobuf encoding of m
+func (m *PartialDecryptRequest) Unmarshal(data []byte) error {
+	*m = PartialDecryptRequest{}
+	return unmarshalFields(data, func(num int) interface{} {
+		switch num {
+		case 1:
+			return func(data []byte) error {
+				m.Ciphertext = new(Ciphertext)
+				return m.Ciphertext.Unmarshal(data)
+			}
+		case 2:
+			return &m.Session
+		}
+		return nil
+	})
+}
+
+// PartialDecryptResponse is the response of DecryptionServer.PartialDecrypt
+type PartialDecryptResponse struct {
+	PartialDecryption *PartialDecryptionZKP
+}
+
+// Marshal returns the protobuf encoding of m
+func (m *PartialDecryptResponse) Marshal() ([]byte, error) {
+	var b []byte
+	if m.PartialDecryption != nil {
+		pd, err := m.PartialDecryption.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendRepeatedBytes(b, 1, pd)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes the protobuf encoding of m
+func (m *PartialDecryptResponse) Unmarshal(data []byte) error {
+	*m = PartialDecryptResponse{}
+	return unmarshalFields(data, func(num int) interface{} {
+		switch num {
+		case 1:
+			return func(data []byte) error {
+				m.PartialDecryption = new(PartialDecryptionZKP)
+				return m.PartialDecryption.Unmarshal(data)
+			}
+		}
+		return nil
+	})
+}
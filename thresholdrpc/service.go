@@ -0,0 +1,273 @@
+package thresholdrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+// Method paths of the DecryptionServer service, as used by gRPC
+const (
+	GetPublicKeyMethod   = "/paillier.threshold.v1.DecryptionServer/GetPublicKey"
+	PartialDecryptMethod = "/paillier.threshold.v1.DecryptionServer/PartialDecrypt"
+)
+
+// ContentType is the content type of the request and response bodies
+const ContentType = "application/x-protobuf"
+
+// maxMessageSize bounds the bodies read by the handler and the client
+const maxMessageSize = 4 << 20
+
+// DecryptionServer is the DecryptionServer service of decryption.proto
+type DecryptionServer interface {
+	GetPublicKey(ctx context.Context, req *GetPublicKeyRequest) (*ThresholdPublicKey, error)
+	PartialDecrypt(ctx context.Context, req *PartialDecryptRequest) (*PartialDecryptResponse, error)
+}
+
+// Server is the reference DecryptionServer of a single key share
+type Server struct {
+	Key *paillier.ThresholdSecretKey
+
+	// Options configure the proofs of the server; the session of a request
+	// replaces the session of the options. Nil means the defaults.
+	Options *paillier.ProofOptions
+}
+
+// NewServer returns a DecryptionServer that partially decrypts with tsk
+func NewServer(tsk *paillier.ThresholdSecretKey) *Server {
+	return &Server{Key: tsk}
+}
+
+// GetPublicKey returns the threshold public key of the share
+func (s *Server) GetPublicKey(ctx context.Context, req *GetPublicKeyRequest) (*ThresholdPublicKey, error) {
+	return FromThresholdPublicKey(&s.Key.ThresholdPublicKey), nil
+}
+
+// PartialDecrypt returns the proven partial decryption of the ciphertext of
+// the request
+func (s *Server) PartialDecrypt(ctx context.Context, req *PartialDecryptRequest) (*PartialDecryptResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req.Ciphertext == nil {
+		return nil, errors.New("request is missing the ciphertext")
+	}
+	ct, err := req.Ciphertext.Ciphertext()
+	if err != nil {
+		return nil, err
+	}
+	if ct.Level != paillier.EncLevelOne {
+		return nil, errors.New("threshold decryption is only supported for level one ciphertexts")
+	}
+	if ct.C.Cmp(s.Key.GetN2()) >= 0 {
+		return nil, paillier.ErrInvalidCiphertext
+	}
+
+	var opts paillier.ProofOptions
+	if s.Options != nil {
+		opts = *s.Options
+	}
+	opts.Session = req.Session
+
+	pd, err := s.Key.PartialDecryptionWithOptions(ct.C, &opts)
+	if err != nil {
+		return nil, err
+	}
+	m, err := FromPartialDecryptionZKP(pd)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialDecryptResponse{PartialDecryption: m}, nil
+}
+
+// message is implemented by the messages of decryption.proto
+type message interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// NewHandler returns an HTTP handler serving srv. Requests are POSTed to the
+// method paths with the encoded request message as the body and answered
+// with the encoded response message; failures are answered with an error
+// status and a text message.
+func NewHandler(srv DecryptionServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(GetPublicKeyMethod, handle(func(ctx context.Context, body []byte) (message, error) {
+		var req GetPublicKeyRequest
+		if err := req.Unmarshal(body); err != nil {
+			return nil, badRequest(err)
+		}
+		return srv.GetPublicKey(ctx, &req)
+	}))
+	mux.Handle(PartialDecryptMethod, handle(func(ctx context.Context, body []byte) (message, error) {
+		var req PartialDecryptRequest
+		if err := req.Unmarshal(body); err != nil {
+			return nil, badRequest(err)
+		}
+		return srv.PartialDecrypt(ctx, &req)
+	}))
+	return mux
+}
+
+// badRequestError marks errors of malformed requests
+type badRequestError struct {
+	err error
+}
+
+func badRequest(err error) error {
+	return &badRequestError{err: err}
+}
+
+func (e *badRequestError) Error() string {
+	return "malformed request: " + e.err.Error()
+}
+
+func handle(call func(ctx context.Context, body []byte) (message, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+		if err != nil {
+			http.Error(w, "request is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		resp, err := call(r.Context(), body)
+		if err == nil {
+			body, err = resp.Marshal()
+		}
+		if err != nil {
+			var bad *badRequestError
+			if errors.As(err, &bad) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(body)
+	})
+}
+
+// Client calls a DecryptionServer served by NewHandler. It implements
+// paillier.PartialDecrypter, so that a paillier.ThresholdClient can combine
+// the partial decryptions of remote servers.
+type Client struct {
+	URL        string       // base URL of the server, without the method path
+	HTTPClient *http.Client // nil means http.DefaultClient
+	Key        *paillier.ThresholdPublicKey
+}
+
+// NewClient returns a client of the server at url for the committee of key
+func NewClient(url string, key *paillier.ThresholdPublicKey) *Client {
+	return &Client{URL: url, Key: key}
+}
+
+// GetPublicKey calls the GetPublicKey method of the server
+func (c *Client) GetPublicKey(ctx context.Context, req *GetPublicKeyRequest) (*ThresholdPublicKey, error) {
+	var resp ThresholdPublicKey
+	if err := c.call(ctx, GetPublicKeyMethod, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PartialDecrypt calls the PartialDecrypt method of the server
+func (c *Client) PartialDecrypt(ctx context.Context, req *PartialDecryptRequest) (*PartialDecryptResponse, error) {
+	var resp PartialDecryptResponse
+	if err := c.call(ctx, PartialDecryptMethod, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PartialDecryptionWithZKP requests the partial decryption of the level one
+// ciphertext ct. The proof is decoded for the key of the client but not
+// verified; paillier.ThresholdClient verifies it before combining.
+func (c *Client) PartialDecryptionWithZKP(ct *gmp.Int) (*paillier.PartialDecryptionZKP, error) {
+	return c.PartialDecryptionWithSession(context.Background(), ct, nil)
+}
+
+// PartialDecryptionWithSession is PartialDecryptionWithZKP with a context
+// and the session bound into the proof of the server
+func (c *Client) PartialDecryptionWithSession(ctx context.Context, ct *gmp.Int, session []byte) (*paillier.PartialDecryptionZKP, error) {
+	if c.Key == nil {
+		return nil, errors.New("client is missing the threshold public key")
+	}
+
+	resp, err := c.PartialDecrypt(ctx, &PartialDecryptRequest{
+		Ciphertext: &Ciphertext{C: intBytes(ct)},
+		Session:    session,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.PartialDecryption == nil {
+		return nil, errors.New("response is missing the partial decryption")
+	}
+	return resp.PartialDecryption.PartialDecryptionZKP(c.Key)
+}
+
+// RequestPartialDecryption implements paillier.DecryptionTransport, so that
+// a paillier.Coordinator can decrypt with remote servers
+func (c *Client) RequestPartialDecryption(ctx context.Context, ct *gmp.Int, session []byte) (*paillier.PartialDecryptionZKP, error) {
+	return c.PartialDecryptionWithSession(ctx, ct, session)
+}
+
+func (c *Client) call(ctx context.Context, method string, req, resp message) error {
+	body, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", ContentType)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	body, err = io.ReadAll(io.LimitReader(httpResp.Body, maxMessageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxMessageSize {
+		return errors.New("response is too large")
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s: %s", method, httpResp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp.Unmarshal(body)
+}
+
+// NewCombiner returns a paillier.ThresholdClient that combines the partial
+// decryptions of the servers at urls for the committee of key
+func NewCombiner(key *paillier.ThresholdPublicKey, urls []string, httpClient *http.Client) *paillier.ThresholdClient {
+	servers := make([]paillier.PartialDecrypter, len(urls))
+	for i, url := range urls {
+		servers[i] = &Client{URL: url, HTTPClient: httpClient, Key: key}
+	}
+	return paillier.NewThresholdClient(key, servers)
+}
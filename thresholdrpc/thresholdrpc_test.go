@@ -0,0 +1,223 @@
+package thresholdrpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+func getThresholdKeys(t *testing.T) []*paillier.ThresholdSecretKey {
+	tkh, err := paillier.NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tpks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tpks
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	msgs := []struct {
+		in, out message
+	}{
+		{
+			&ThresholdPublicKey{N: []byte{1, 2, 3}, TotalNumberOfDecryptionServers: 3, Threshold: 2, VerificationKeys: [][]byte{{4}, {}, {44, 1}}, S: 2},
+			new(ThresholdPublicKey),
+		},
+		{&Ciphertext{C: []byte{0xff, 0}, Level: 1}, new(Ciphertext)},
+		{
+			&PartialDecryptResponse{PartialDecryption: &PartialDecryptionZKP{
+				PartialDecryption: &PartialDecryption{Id: 300, Decryption: []byte{9}, KeyFingerprint: "ab"},
+				KeyDigest:         []byte{1},
+				Domain:            "test",
+				ChallengeBits:     128,
+			}},
+			new(PartialDecryptResponse),
+		},
+		{&PartialDecryptRequest{Ciphertext: &Ciphertext{}}, new(PartialDecryptRequest)},
+	}
+
+	for i, msg := range msgs {
+		data, err := msg.in.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := msg.out.Unmarshal(data); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(msg.in, msg.out) {
+			t.Errorf("message %d: got %+v, expected %+v", i, msg.out, msg.in)
+		}
+	}
+}
+
+func TestMessageEncoding(t *testing.T) {
+	// the encoding of protoc-gen-go for Ciphertext{c: 0x0102, level: 1}
+	data, err := (&Ciphertext{C: []byte{1, 2}, Level: 1}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte{0x0a, 0x02, 0x01, 0x02, 0x10, 0x01}; !bytes.Equal(data, expected) {
+		t.Errorf("got %x, expected %x", data, expected)
+	}
+
+	// unknown fields of every wire type are skipped
+	data = append(data, 0x58, 0x05, 0x61, 1, 2, 3, 4, 5, 6, 7, 8, 0x6a, 0x01, 0xff, 0x75, 1, 2, 3, 4)
+	var ct Ciphertext
+	if err := ct.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ct.C, []byte{1, 2}) || ct.Level != 1 {
+		t.Errorf("unexpected message %+v", ct)
+	}
+
+	// truncated values, a zero field number and a varint of eleven bytes
+	malformed := [][]byte{
+		{0x0a, 0x05, 0x01},
+		{0x0a},
+		{0x10},
+		{0x00, 0x01},
+		{0x10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+	}
+	for _, data := range malformed {
+		if err := ct.Unmarshal(data); err == nil {
+			t.Errorf("expected an error decoding %x", data)
+		}
+	}
+	// level is a varint, not length-delimited
+	if err := ct.Unmarshal([]byte{0x12, 0x00}); err == nil {
+		t.Error("expected an error for the wrong wire type")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tpks := getThresholdKeys(t)
+	tk := &tpks[0].ThresholdPublicKey
+
+	key, err := FromThresholdPublicKey(tk).ThresholdPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(tk) {
+		t.Error("threshold public key does not round trip")
+	}
+
+	ct := tk.Encrypt(gmp.NewInt(42))
+	pd, err := tpks[1].PartialDecryptionWithSession(ct.C, []byte("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := FromPartialDecryptionZKP(pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := m.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PartialDecryptionZKP
+	if err := decoded.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := decoded.PartialDecryptionZKP(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.VerifyProof() {
+		t.Error("decoded proof does not verify")
+	}
+
+	others := getThresholdKeys(t)
+	if _, err := decoded.PartialDecryptionZKP(&others[0].ThresholdPublicKey); !errors.Is(err, paillier.ErrStaleKey) {
+		t.Error("expected ErrStaleKey, got ", err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	tpks := getThresholdKeys(t)
+	tk := &tpks[0].ThresholdPublicKey
+
+	var urls []string
+	for _, tsk := range tpks {
+		server := httptest.NewServer(NewHandler(NewServer(tsk)))
+		defer server.Close()
+		urls = append(urls, server.URL)
+	}
+
+	client := NewClient(urls[0], tk)
+	m, err := client.GetPublicKey(context.Background(), &GetPublicKeyRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := m.ThresholdPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(tk) {
+		t.Error("server returned a different key")
+	}
+
+	combiner := NewCombiner(key, urls, nil)
+	ct := key.Encrypt(gmp.NewInt(876))
+	decrypted, err := combiner.TryDecrypt(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Cmp(gmp.NewInt(876)) != 0 {
+		t.Error("decrypted ", decrypted, " expected 876")
+	}
+
+	pd, err := client.PartialDecryptionWithSession(context.Background(), ct.C, []byte("session"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pd.Session, []byte("session")) || !pd.VerifyProof() {
+		t.Error("partial decryption is not bound to the session")
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	tpks := getThresholdKeys(t)
+	server := httptest.NewServer(NewHandler(NewServer(tpks[0])))
+	defer server.Close()
+
+	client := NewClient(server.URL, &tpks[0].ThresholdPublicKey)
+	if _, err := client.PartialDecrypt(context.Background(), &PartialDecryptRequest{}); err == nil || !strings.Contains(err.Error(), "missing the ciphertext") {
+		t.Error("expected an error for a request without ciphertext, got ", err)
+	}
+	if _, err := client.PartialDecryptionWithZKP(tpks[0].GetN2()); err == nil {
+		t.Error("expected an error for a ciphertext out of range")
+	}
+
+	resp, err := http.Post(server.URL+PartialDecryptMethod, ContentType, strings.NewReader("\x0a\x05"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Error("expected status 400 for a malformed request, got ", resp.Status)
+	}
+
+	resp, err = http.Get(server.URL + GetPublicKeyMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Error("expected status 405 for GET, got ", resp.Status)
+	}
+}
@@ -0,0 +1,199 @@
+package thresholdrpc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The messages implement the proto3 binary encoding of decryption.proto:
+// every field is a varint key (field number << 3 | wire type) followed by a
+// varint or a length-delimited value. Fields with default values are omitted
+// and unknown fields are skipped, as by generated code.
+
+// wire types of the protocol buffer encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendKey(b []byte, num, wireType int) []byte {
+	return appendVarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+func appendBytesField(b []byte, num int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	return appendRepeatedBytes(b, num, v)
+}
+
+// appendRepeatedBytes appends v even if it is empty, as for elements of
+// repeated fields and present messages
+func appendRepeatedBytes(b []byte, num int, v []byte) []byte {
+	b = appendKey(b, num, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendStringField(b []byte, num int, v string) []byte {
+	return appendBytesField(b, num, []byte(v))
+}
+
+func appendUint32Field(b []byte, num int, v uint32) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendKey(b, num, wireVarint)
+	return appendVarint(b, uint64(v))
+}
+
+// decoder reads the fields of an encoded message
+type decoder struct {
+	data []byte
+}
+
+// next returns the number and wire type of the next field; done is true at
+// the end of the message
+func (d *decoder) next() (num, wireType int, done bool, err error) {
+	if len(d.data) == 0 {
+		return 0, 0, true, nil
+	}
+	key, err := d.varint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if key>>3 == 0 || key>>3 > math.MaxInt32 {
+		return 0, 0, false, errors.New("invalid field number")
+	}
+	return int(key >> 3), int(key & 7), false, nil
+}
+
+func (d *decoder) varint() (uint64, error) {
+	var v uint64
+	for i := 0; i < 10; i++ {
+		if i >= len(d.data) {
+			return 0, errors.New("unexpected end of message")
+		}
+		c := d.data[i]
+		v |= uint64(c&0x7f) << (7 * i)
+		if c < 0x80 {
+			d.data = d.data[i+1:]
+			return v, nil
+		}
+	}
+	return 0, errors.New("varint is too long")
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)) {
+		return nil, errors.New("unexpected end of message")
+	}
+	v := d.data[:n:n]
+	d.data = d.data[n:]
+	return v, nil
+}
+
+// field decodes the value of the field num with the given wire type into
+// one of *[]byte, *string, *uint32 or a func([]byte) error for messages
+func (d *decoder) field(num, wireType int, dst interface{}) error {
+	switch dst := dst.(type) {
+	case *uint32:
+		if wireType != wireVarint {
+			return fmt.Errorf("field %d has wire type %d, expected a varint", num, wireType)
+		}
+		v, err := d.varint()
+		if err != nil {
+			return err
+		}
+		if v > math.MaxUint32 {
+			return fmt.Errorf("field %d is out of range", num)
+		}
+		*dst = uint32(v)
+		return nil
+	}
+
+	if wireType != wireBytes {
+		return fmt.Errorf("field %d has wire type %d, expected length-delimited", num, wireType)
+	}
+	v, err := d.bytes()
+	if err != nil {
+		return err
+	}
+	switch dst := dst.(type) {
+	case *[]byte:
+		*dst = append([]byte{}, v...)
+	case *[][]byte:
+		*dst = append(*dst, append([]byte{}, v...))
+	case *string:
+		*dst = string(v)
+	case func([]byte) error:
+		return dst(v)
+	default:
+		panic("thresholdrpc: unsupported field type")
+	}
+	return nil
+}
+
+// skip skips the value of an unknown field
+func (d *decoder) skip(wireType int) error {
+	var n int
+	switch wireType {
+	case wireVarint:
+		_, err := d.varint()
+		return err
+	case wireBytes:
+		_, err := d.bytes()
+		return err
+	case wireFixed64:
+		n = 8
+	case wireFixed32:
+		n = 4
+	default:
+		return fmt.Errorf("unsupported wire type %d", wireType)
+	}
+	if n > len(d.data) {
+		return errors.New("unexpected end of message")
+	}
+	d.data = d.data[n:]
+	return nil
+}
+
+// unmarshalFields decodes data, calling fields with the number of every
+// field to get its destination; fields returns nil for unknown fields
+func unmarshalFields(data []byte, fields func(num int) interface{}) error {
+	d := &decoder{data: data}
+	for {
+		num, wireType, done, err := d.next()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		dst := fields(num)
+		if dst == nil {
+			err = d.skip(wireType)
+		} else {
+			err = d.field(num, wireType, dst)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
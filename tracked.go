@@ -0,0 +1,110 @@
+package paillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// TrackedCiphertext is a ciphertext with a public bound on the absolute value
+// of its (signed) plaintext. The homomorphic operations on tracked
+// ciphertexts propagate the bound and return an error wrapping
+// ErrPossibleOverflow once it reaches N^s/2, beyond which the plaintext may
+// have wrapped around mod N^s, e.g., when aggregating many large values. As
+// long as no error is returned, the plaintext of a ciphertext at level one is
+// recovered with DecodeSigned, or directly if all values are non-negative.
+//
+// The bound is metadata of the caller and is not checked against the
+// plaintext, so it must not be taken from an untrusted party.
+type TrackedCiphertext struct {
+	*Ciphertext
+	Bound *big.Int // |m| <= Bound
+}
+
+// EncryptTracked encrypts m, which is EncodeSigned if negative, and tracks
+// the bound, which must be at least |m|
+func (pk *PublicKey) EncryptTracked(m, bound *big.Int) (*TrackedCiphertext, error) {
+	if new(big.Int).Abs(m).Cmp(bound) > 0 {
+		return nil, fmt.Errorf("%w: |%v| is greater than the bound %v", ErrMessageTooLarge, m, bound)
+	}
+	if err := pk.checkBound(bound, EncLevelOne); err != nil {
+		return nil, err
+	}
+	return &TrackedCiphertext{pk.Encrypt(pk.EncodeSigned(m)), new(big.Int).Set(bound)}, nil
+}
+
+// Track returns ct with the bound on the absolute value of its plaintext
+func (pk *PublicKey) Track(ct *Ciphertext, bound *big.Int) (*TrackedCiphertext, error) {
+	if bound.Sign() < 0 {
+		return nil, fmt.Errorf("negative bound %v", bound)
+	}
+	if err := pk.checkBound(bound, ct.Level); err != nil {
+		return nil, err
+	}
+	return &TrackedCiphertext{ct, new(big.Int).Set(bound)}, nil
+}
+
+// EAddTracked homomorphically adds the ciphertexts, which must all be at the
+// same level, as EAddMany. The bound of the sum is the sum of the bounds.
+func (pk *PublicKey) EAddTracked(cts ...*TrackedCiphertext) (*TrackedCiphertext, error) {
+	if len(cts) == 0 {
+		return nil, fmt.Errorf("%w: no ciphertexts to add", ErrInvalidCiphertext)
+	}
+
+	bound := new(big.Int)
+	plain := make([]*Ciphertext, len(cts))
+	for i, ct := range cts {
+		if ct.Level != cts[0].Level {
+			return nil, fmt.Errorf("%w: ciphertexts must be at the same level", ErrInvalidCiphertext)
+		}
+		bound.Add(bound, ct.Bound)
+		plain[i] = ct.Ciphertext
+	}
+	if err := pk.checkBound(bound, cts[0].Level); err != nil {
+		return nil, err
+	}
+	return &TrackedCiphertext{pk.EAddMany(plain...), bound}, nil
+}
+
+// ESubTracked returns an encryption of m1 - m2 as ESub. The bound of the
+// difference is the sum of the bounds.
+func (pk *PublicKey) ESubTracked(ct1, ct2 *TrackedCiphertext) (*TrackedCiphertext, error) {
+	if ct1.Level != ct2.Level {
+		return nil, fmt.Errorf("%w: ciphertexts must be at the same level", ErrInvalidCiphertext)
+	}
+
+	bound := new(big.Int).Add(ct1.Bound, ct2.Bound)
+	if err := pk.checkBound(bound, ct1.Level); err != nil {
+		return nil, err
+	}
+	return &TrackedCiphertext{pk.ESub(ct1.Ciphertext, ct2.Ciphertext), bound}, nil
+}
+
+// ECMultTracked returns an encryption of k*m as ECMult. The bound of the
+// product is |k| times the bound.
+func (pk *PublicKey) ECMultTracked(ct *TrackedCiphertext, k *big.Int) (*TrackedCiphertext, error) {
+	bound := new(big.Int).Mul(ct.Bound, new(big.Int).Abs(k))
+	if err := pk.checkBound(bound, ct.Level); err != nil {
+		return nil, err
+	}
+	return &TrackedCiphertext{pk.ECMult(ct.Ciphertext, k), bound}, nil
+}
+
+// EAddConstantTracked returns an encryption of m + k as EAddConstant. The
+// bound of the sum is the bound plus |k|.
+func (pk *PublicKey) EAddConstantTracked(ct *TrackedCiphertext, k *big.Int) (*TrackedCiphertext, error) {
+	bound := new(big.Int).Add(ct.Bound, new(big.Int).Abs(k))
+	if err := pk.checkBound(bound, ct.Level); err != nil {
+		return nil, err
+	}
+	return &TrackedCiphertext{pk.EAddConstant(ct.Ciphertext, k), bound}, nil
+}
+
+// checkBound returns an error wrapping ErrPossibleOverflow unless
+// 2 * bound < N^s
+func (pk *PublicKey) checkBound(bound *big.Int, level EncryptionLevel) error {
+	_, ns, _ := pk.getModuliForLevel(level)
+	if ToGmpInt(new(big.Int).Lsh(bound, 1)).Cmp(ns) >= 0 {
+		return fmt.Errorf("%w: bound of %d bits for a plaintext space of %d bits", ErrPossibleOverflow, bound.BitLen(), ns.BitLen())
+	}
+	return nil
+}
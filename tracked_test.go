@@ -0,0 +1,80 @@
+package paillier
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestTrackedCiphertext(t *testing.T) {
+	sk, pk := KeyGen(128)
+
+	a, err := pk.EncryptTracked(big.NewInt(-20), big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pk.EncryptTracked(big.NewInt(50), big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := pk.EAddTracked(a, b, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := pk.ESubTracked(sum, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := pk.ECMultTracked(diff, big.NewInt(-3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shifted, err := pk.EAddConstantTracked(product, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := pk.DecodeSigned(sk.Decrypt(shifted.Ciphertext)); m.Int64() != -293 {
+		t.Error("wrong result ", m, " is not -293")
+	}
+	if shifted.Bound.Int64() != 1207 {
+		t.Error("wrong bound ", shifted.Bound, " is not 1207")
+	}
+
+	if _, err := pk.EncryptTracked(big.NewInt(101), big.NewInt(100)); !errors.Is(err, ErrMessageTooLarge) {
+		t.Error("expected ErrMessageTooLarge, got ", err)
+	}
+}
+
+func TestTrackedCiphertextOverflow(t *testing.T) {
+	_, pk := KeyGen(128)
+
+	// the bound of N/4 can be doubled once
+	quarter := ToBigInt(pk.N)
+	quarter.Rsh(quarter, 2)
+	ct, err := pk.EncryptTracked(big.NewInt(1), quarter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pk.EAddTracked(ct, ct); err != nil {
+		t.Error(err)
+	}
+	if _, err := pk.EAddTracked(ct, ct, ct); !errors.Is(err, ErrPossibleOverflow) {
+		t.Error("expected ErrPossibleOverflow, got ", err)
+	}
+	if _, err := pk.ECMultTracked(ct, big.NewInt(-3)); !errors.Is(err, ErrPossibleOverflow) {
+		t.Error("expected ErrPossibleOverflow, got ", err)
+	}
+
+	// the same bound has room at level two
+	tracked, err := pk.Track(pk.EncryptAtLevel(gmp.NewInt(1), EncLevelTwo), quarter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pk.ECMultTracked(tracked, big.NewInt(1<<20)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,240 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// ErrTransportTimeout is returned by Transport.Receive if no message arrived
+// within the timeout of the transport
+var ErrTransportTimeout = errors.New("timed out waiting for a message")
+
+// TransportMessage is a message received by a Transport
+type TransportMessage struct {
+	From  int
+	Round int
+	Data  []byte
+}
+
+// Transport delivers the messages of multi-round protocols between parties
+// identified by their IDs, e.g., over gRPC, libp2p or a message queue.
+// Protocols only use this interface so that they are independent of the
+// network. Implementations must be safe for concurrent use.
+type Transport interface {
+	// Send delivers the message for the given round to the party to
+	Send(to, round int, msg []byte) error
+	// Receive blocks until a message for the given round arrives and returns
+	// it; messages of other rounds are kept for later calls
+	Receive(round int) (*TransportMessage, error)
+}
+
+// MemoryNetwork connects parties within one process, e.g., for tests
+type MemoryNetwork struct {
+	// Timeout of Receive; Receive blocks until a message arrives if zero
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	queues  map[int]map[int][]*TransportMessage // party, round -> messages
+	arrived *sync.Cond
+}
+
+type memoryTransport struct {
+	network *MemoryNetwork
+	self    int
+}
+
+// NewMemoryNetwork returns a network for the parties with the given IDs
+func NewMemoryNetwork(ids ...int) *MemoryNetwork {
+	n := &MemoryNetwork{queues: make(map[int]map[int][]*TransportMessage)}
+	n.arrived = sync.NewCond(&n.mu)
+	for _, id := range ids {
+		n.queues[id] = make(map[int][]*TransportMessage)
+	}
+	return n
+}
+
+// Transport returns the transport of the party with the given ID
+func (n *MemoryNetwork) Transport(id int) Transport {
+	return &memoryTransport{network: n, self: id}
+}
+
+func (t *memoryTransport) Send(to, round int, msg []byte) error {
+	n := t.network
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	queue, ok := n.queues[to]
+	if !ok {
+		return fmt.Errorf("party %d is not on the network", to)
+	}
+
+	data := append([]byte{}, msg...)
+	queue[round] = append(queue[round], &TransportMessage{From: t.self, Round: round, Data: data})
+	n.arrived.Broadcast()
+	return nil
+}
+
+func (t *memoryTransport) Receive(round int) (*TransportMessage, error) {
+	n := t.network
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var expired bool
+	if n.Timeout > 0 {
+		timer := time.AfterFunc(n.Timeout, func() {
+			n.mu.Lock()
+			expired = true
+			n.mu.Unlock()
+			n.arrived.Broadcast()
+		})
+		defer timer.Stop()
+	}
+
+	queue := n.queues[t.self]
+	for len(queue[round]) == 0 {
+		if expired {
+			return nil, ErrTransportTimeout
+		}
+		n.arrived.Wait()
+	}
+
+	msg := queue[round][0]
+	queue[round] = queue[round][1:]
+	return msg, nil
+}
+
+// SecureTransport encrypts and authenticates every message of an underlying
+// transport with the SecureChannel to the respective party
+type SecureTransport struct {
+	Transport Transport
+	Channels  map[int]*SecureChannel // by ID of the peer
+}
+
+// Send seals the message with the channel to the party to
+func (t *SecureTransport) Send(to, round int, msg []byte) error {
+	channel, ok := t.Channels[to]
+	if !ok {
+		return fmt.Errorf("no secure channel to party %d", to)
+	}
+
+	data, err := gobEncode(channel.Seal(round, msg))
+	if err != nil {
+		return err
+	}
+
+	return t.Transport.Send(to, round, data)
+}
+
+// Receive opens the next message of the round; messages that fail
+// authentication are returned as errors
+func (t *SecureTransport) Receive(round int) (*TransportMessage, error) {
+	msg, err := t.Transport.Receive(round)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, ok := t.Channels[msg.From]
+	if !ok {
+		return nil, fmt.Errorf("no secure channel to party %d", msg.From)
+	}
+
+	var sealed ChannelMessage
+	if err := gobDecode(msg.Data, &sealed); err != nil {
+		return nil, err
+	}
+
+	if sealed.Round != round {
+		return nil, fmt.Errorf("message of party %d is for another round", msg.From)
+	}
+
+	data, err := channel.Open(&sealed)
+	if err != nil {
+		return nil, fmt.Errorf("message of party %d: %w", msg.From, err)
+	}
+
+	return &TransportMessage{From: msg.From, Round: round, Data: data}, nil
+}
+
+// ServeDecryptionRound answers one decryption request received in round with
+// the proven partial decryption in round+1
+func ServeDecryptionRound(t Transport, tsk *ThresholdSecretKey, round int) error {
+	msg, err := t.Receive(round)
+	if err != nil {
+		return err
+	}
+
+	c := new(gmp.Int)
+	if err := gobDecode(msg.Data, c); err != nil {
+		return err
+	}
+
+	pd, err := tsk.PartialDecryptionWithZKP(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := gobEncode(pd)
+	if err != nil {
+		return err
+	}
+
+	return t.Send(msg.From, round+1, data)
+}
+
+// DecryptOverTransport sends the ciphertext to the servers, whose IDs on the
+// transport are the IDs of their shares, in round and combines the first
+// Threshold valid partial decryptions received in round+1.
+// Invalid responses are skipped; an error is returned if the transport fails
+// before enough valid partial decryptions arrived.
+func DecryptOverTransport(t Transport, key *ThresholdPublicKey, servers []int, round int, ct *Ciphertext) (*gmp.Int, error) {
+	if ct.Level != EncLevelOne {
+		return nil, errors.New("threshold decryption is only supported for level one ciphertexts")
+	}
+
+	if len(servers) < key.Threshold {
+		return nil, errors.New("fewer servers than the threshold")
+	}
+
+	request, err := gobEncode(ct.C)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, server := range servers {
+		if err := t.Send(server, round, request); err != nil {
+			return nil, err
+		}
+	}
+
+	combiner := key.NewCombiner(ct.C)
+	for responses := 0; responses < len(servers) && !combiner.Ready(); responses++ {
+		msg, err := t.Receive(round + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		share := new(PartialDecryptionZKP)
+		if err := gobDecode(msg.Data, share); err != nil {
+			continue
+		}
+
+		if share.ID != msg.From {
+			continue
+		}
+
+		// verify against the client's key rather than the one sent by the
+		// server; invalid and duplicate shares are not added
+		share.Key = key
+		combiner.AddZKP(share)
+	}
+
+	if !combiner.Ready() {
+		return nil, errors.New("not enough valid partial decryptions")
+	}
+
+	return combiner.Combine()
+}
@@ -0,0 +1,95 @@
+package paillier
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestDecryptOverTransport(t *testing.T) {
+	tkh, err := NewThresholdKeyGenerator(32, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &tsks[0].ThresholdPublicKey
+
+	network := NewMemoryNetwork(0, 1, 2, 3)
+	errs := make(chan error, len(tsks))
+	for _, tsk := range tsks {
+		go func(tsk *ThresholdSecretKey) {
+			errs <- ServeDecryptionRound(network.Transport(tsk.ID), tsk, 1)
+		}(tsk)
+	}
+
+	m, err := DecryptOverTransport(network.Transport(0), key, []int{1, 2, 3}, 1, key.Encrypt(gmp.NewInt(99)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != 99 {
+		t.Error("wrong decryption ", m)
+	}
+	for range tsks {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	network.Timeout = 10 * time.Millisecond
+	if _, err := network.Transport(1).Receive(5); !errors.Is(err, ErrTransportTimeout) {
+		t.Error("expected timeout, got ", err)
+	}
+}
+
+func TestSecureTransport(t *testing.T) {
+	network := NewMemoryNetwork(1, 2)
+
+	pub1, key1, _ := ed25519.GenerateKey(rand.Reader)
+	pub2, key2, _ := ed25519.GenerateKey(rand.Reader)
+	h1, hello1, err := NewChannelHandshake(1, key1, 2, pub2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, hello2, err := NewChannelHandshake(2, key2, 1, pub1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, err := h1.Finish(hello2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := h2.Finish(hello1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t1 := &SecureTransport{network.Transport(1), map[int]*SecureChannel{2: c1}}
+	t2 := &SecureTransport{network.Transport(2), map[int]*SecureChannel{1: c2}}
+
+	if err := t1.Send(2, 4, []byte("round four")); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := t2.Receive(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.From != 1 || string(msg.Data) != "round four" {
+		t.Error("wrong message ", msg)
+	}
+
+	// a plaintext message injected into the underlying transport is rejected
+	if err := network.Transport(1).Send(2, 4, []byte("forged")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := t2.Receive(4); err == nil {
+		t.Error("expected error for unauthenticated message")
+	}
+}
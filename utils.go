@@ -1,7 +1,7 @@
 package paillier
 
 import (
-	"crypto/rand"
+	"errors"
 	"io"
 	"math/big"
 
@@ -22,14 +22,39 @@ func Factorial(n int) *gmp.Int {
 	return ret
 }
 
-// GetRandomNumber returns a random value less than n
+// GetRandomNumber returns a random value less than n, which must be positive.
+// It reads as many bytes as n - 1 takes in big-endian order, clears the bits
+// above the bit length of n - 1 and returns the value if it is less than n,
+// and otherwise repeats with the next bytes. This is the algorithm of
+// crypto/rand.Int, which is fixed here so that deterministic readers yield
+// the same values, e.g., keys, in all versions of the package.
 func GetRandomNumber(n *gmp.Int, random io.Reader) (*gmp.Int, error) {
-	r, err := rand.Int(random, ToBigInt(n))
-	if err != nil {
-		return nil, err
+	if n.Sign() <= 0 {
+		return nil, errors.New("upper bound must be positive")
 	}
 
-	return ToGmpInt(r), nil
+	bitLen := new(gmp.Int).Sub(n, OneBigInt).BitLen()
+	if bitLen == 0 {
+		return gmp.NewInt(0), nil
+	}
+	b := uint(bitLen % 8)
+	if b == 0 {
+		b = 8
+	}
+
+	bytes := make([]byte, (bitLen+7)/8)
+	r := new(gmp.Int)
+	for {
+		if _, err := io.ReadFull(random, bytes); err != nil {
+			return nil, err
+		}
+		bytes[0] &= uint8(int(1<<b) - 1)
+
+		r.SetBytes(bytes)
+		if r.Cmp(n) < 0 {
+			return r, nil
+		}
+	}
 }
 
 // GetRandomNumberInMultiplicativeGroup returns a random element in the group of all the elements in Z/nZ that
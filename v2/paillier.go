@@ -0,0 +1,376 @@
+// Package paillier is the second version of the API of the Paillier and
+// Damgard-Jurik cryptosystems.
+//
+// Compared to the first version, every operation takes a context, reports
+// invalid inputs with errors instead of panics or silently wrong results,
+// keys are interfaces and all values have a byte-oriented encoding through
+// MarshalBinary and the Parse functions. The implementation is shared with
+// the first version: FromV1PublicKey, FromV1SecretKey and FromV1Ciphertext
+// convert existing values and the V1 methods give access to the first version,
+// e.g., for the threshold scheme and the protocols that are not part of this
+// package yet.
+package paillier
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	gmp "github.com/ncw/gmp"
+	v1 "github.com/sachaservan/paillier"
+)
+
+// Level is the level s of the Damgard-Jurik scheme; plaintexts are in Z_{N^s}
+type Level int
+
+const (
+	// LevelOne is the original Paillier scheme
+	LevelOne Level = 1
+
+	// LevelTwo encrypts plaintexts in Z_{N^2}
+	LevelTwo Level = 2
+)
+
+// DefaultKeyBits is the bit length of N used when KeyOptions.Bits is zero
+const DefaultKeyBits = 2048
+
+// binaryVersion is the first byte of every binary encoding of the package
+const binaryVersion byte = 2
+
+// KeyOptions configures key generation; the zero value selects the defaults
+type KeyOptions struct {
+	Bits   int       // bit length of N, DefaultKeyBits if zero
+	Random io.Reader // source of randomness, crypto/rand if nil
+}
+
+// EncryptOptions configures encryption; nil selects the defaults
+type EncryptOptions struct {
+	Level Level // LevelOne if zero
+}
+
+// PublicKey encrypts plaintexts and evaluates homomorphic operations
+type PublicKey interface {
+	Encrypt(ctx context.Context, m *gmp.Int, opts *EncryptOptions) (*Ciphertext, error)
+	Add(ctx context.Context, cts ...*Ciphertext) (*Ciphertext, error)
+	Sub(ctx context.Context, a, b *Ciphertext) (*Ciphertext, error)
+	MulConst(ctx context.Context, ct *Ciphertext, k *gmp.Int) (*Ciphertext, error)
+	Randomize(ctx context.Context, ct *Ciphertext) (*Ciphertext, error)
+	Modulus() *gmp.Int
+	MarshalBinary() ([]byte, error)
+	V1() *v1.PublicKey
+}
+
+// SecretKey decrypts ciphertexts
+type SecretKey interface {
+	Public() PublicKey
+	Decrypt(ctx context.Context, ct *Ciphertext) (*gmp.Int, error)
+	MarshalBinary() ([]byte, error)
+	V1() *v1.SecretKey
+}
+
+// Ciphertext is an encrypted value; the zero value is not a valid ciphertext
+type Ciphertext struct {
+	ct *v1.Ciphertext
+}
+
+type publicKey struct {
+	pk *v1.PublicKey
+}
+
+type secretKey struct {
+	sk *v1.SecretKey
+}
+
+// GenerateKey generates a new secret key
+func GenerateKey(ctx context.Context, opts KeyOptions) (SecretKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if opts.Bits == 0 {
+		opts.Bits = DefaultKeyBits
+	}
+	if opts.Random == nil {
+		opts.Random = rand.Reader
+	}
+
+	sk, _, err := v1.KeyGenWithRandom(opts.Bits, opts.Random)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretKey{sk}, nil
+}
+
+// FromV1PublicKey converts a public key of the first version
+func FromV1PublicKey(pk *v1.PublicKey) PublicKey {
+	return &publicKey{pk}
+}
+
+// FromV1SecretKey converts a secret key of the first version
+func FromV1SecretKey(sk *v1.SecretKey) SecretKey {
+	return &secretKey{sk}
+}
+
+// FromV1Ciphertext converts a ciphertext of the first version
+func FromV1Ciphertext(ct *v1.Ciphertext) *Ciphertext {
+	return &Ciphertext{ct}
+}
+
+// ParsePublicKey decodes a public key encoded with MarshalBinary
+func ParsePublicKey(data []byte) (PublicKey, error) {
+	pk := new(v1.PublicKey)
+	if err := pk.GobDecode(data); err != nil {
+		return nil, err
+	}
+	return &publicKey{pk}, nil
+}
+
+// ParseSecretKey decodes a secret key encoded with MarshalBinary
+func ParseSecretKey(data []byte) (SecretKey, error) {
+	sk := new(v1.SecretKey)
+	if err := sk.GobDecode(data); err != nil {
+		return nil, err
+	}
+	return &secretKey{sk}, nil
+}
+
+// ParseCiphertext decodes a ciphertext encoded with MarshalBinary
+func ParseCiphertext(data []byte) (*Ciphertext, error) {
+	ct := new(Ciphertext)
+	if err := ct.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+func (pk *publicKey) Encrypt(ctx context.Context, m *gmp.Int, opts *EncryptOptions) (*Ciphertext, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	level := LevelOne
+	if opts != nil && opts.Level != 0 {
+		level = opts.Level
+	}
+
+	v1Level, err := toV1Level(level)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := new(gmp.Int).Exp(pk.pk.N, gmp.NewInt(int64(level)), nil)
+	if m.Sign() < 0 || m.Cmp(ns) >= 0 {
+		return nil, errors.New("plaintext is out of range")
+	}
+
+	return &Ciphertext{pk.pk.EncryptAtLevel(m, v1Level)}, nil
+}
+
+func (pk *publicKey) Add(ctx context.Context, cts ...*Ciphertext) (*Ciphertext, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(cts) == 0 {
+		return nil, errors.New("no ciphertexts to add")
+	}
+
+	inputs := make([]*v1.Ciphertext, len(cts))
+	for i, ct := range cts {
+		if err := pk.check(ct, cts[0]); err != nil {
+			return nil, err
+		}
+		inputs[i] = ct.ct
+	}
+
+	return &Ciphertext{pk.pk.Add(inputs...)}, nil
+}
+
+func (pk *publicKey) Sub(ctx context.Context, a, b *Ciphertext) (*Ciphertext, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := pk.check(a, a); err != nil {
+		return nil, err
+	}
+	if err := pk.check(b, a); err != nil {
+		return nil, err
+	}
+
+	return &Ciphertext{pk.pk.Sub(a.ct, b.ct)}, nil
+}
+
+func (pk *publicKey) MulConst(ctx context.Context, ct *Ciphertext, k *gmp.Int) (*Ciphertext, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := pk.check(ct, ct); err != nil {
+		return nil, err
+	}
+
+	if k.Sign() < 0 {
+		return nil, errors.New("constant must be non-negative")
+	}
+
+	return &Ciphertext{pk.pk.ConstMult(ct.ct, k)}, nil
+}
+
+// Randomize re-randomizes the ciphertext with a fresh encryption of zero at
+// the level of the ciphertext
+func (pk *publicKey) Randomize(ctx context.Context, ct *Ciphertext) (*Ciphertext, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := pk.check(ct, ct); err != nil {
+		return nil, err
+	}
+
+	zero := pk.pk.EncryptZeroAtLevel(ct.ct.Level)
+	return &Ciphertext{pk.pk.Add(ct.ct, zero)}, nil
+}
+
+func (pk *publicKey) Modulus() *gmp.Int {
+	return new(gmp.Int).Set(pk.pk.N)
+}
+
+func (pk *publicKey) MarshalBinary() ([]byte, error) {
+	return pk.pk.GobEncode()
+}
+
+func (pk *publicKey) V1() *v1.PublicKey {
+	return pk.pk
+}
+
+// check returns an error unless ct is a valid ciphertext under the key at the
+// same level as ref
+func (pk *publicKey) check(ct, ref *Ciphertext) error {
+	if ct == nil || ct.ct == nil || ct.ct.C == nil {
+		return errors.New("invalid ciphertext")
+	}
+
+	if ct.ct.Level != ref.ct.Level {
+		return errors.New("ciphertexts are at different levels")
+	}
+
+	if _, err := fromV1Level(ct.ct.Level); err != nil {
+		return err
+	}
+
+	var mod *gmp.Int
+	if ct.ct.Level == v1.EncLevelOne {
+		mod = pk.pk.GetN2()
+	} else {
+		mod = pk.pk.GetN3()
+	}
+
+	if ct.ct.C.Sign() <= 0 || ct.ct.C.Cmp(mod) >= 0 {
+		return errors.New("ciphertext is out of range")
+	}
+
+	return nil
+}
+
+func (sk *secretKey) Public() PublicKey {
+	return &publicKey{&sk.sk.PublicKey}
+}
+
+func (sk *secretKey) Decrypt(ctx context.Context, ct *Ciphertext) (*gmp.Int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pk := &publicKey{&sk.sk.PublicKey}
+	if err := pk.check(ct, ct); err != nil {
+		return nil, err
+	}
+
+	return sk.sk.Decrypt(ct.ct), nil
+}
+
+func (sk *secretKey) MarshalBinary() ([]byte, error) {
+	return sk.sk.GobEncode()
+}
+
+func (sk *secretKey) V1() *v1.SecretKey {
+	return sk.sk
+}
+
+// Level returns the level of the ciphertext
+func (ct *Ciphertext) Level() Level {
+	level, _ := fromV1Level(ct.ct.Level)
+	return level
+}
+
+// V1 returns the ciphertext of the first version
+func (ct *Ciphertext) V1() *v1.Ciphertext {
+	return ct.ct
+}
+
+// MarshalBinary encodes the ciphertext as a version byte, the level,
+// the encryption method and the big-endian value of the ciphertext
+func (ct *Ciphertext) MarshalBinary() ([]byte, error) {
+	if ct.ct == nil || ct.ct.C == nil {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	level, err := fromV1Level(ct.ct.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{binaryVersion, byte(level), byte(ct.ct.EncMethod)}
+	return append(data, ct.ct.C.Bytes()...), nil
+}
+
+// UnmarshalBinary decodes a ciphertext encoded with MarshalBinary
+func (ct *Ciphertext) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("ciphertext encoding is too short")
+	}
+
+	if data[0] != binaryVersion {
+		return errors.New("unsupported encoding version")
+	}
+
+	level, err := toV1Level(Level(data[1]))
+	if err != nil {
+		return err
+	}
+
+	method := v1.EncryptionMethod(data[2])
+	if method > v1.MixedEncryption {
+		return errors.New("unknown encryption method")
+	}
+
+	ct.ct = &v1.Ciphertext{
+		C:         new(gmp.Int).SetBytes(data[3:]),
+		Level:     level,
+		EncMethod: method,
+	}
+	return nil
+}
+
+func toV1Level(level Level) (v1.EncryptionLevel, error) {
+	switch level {
+	case LevelOne:
+		return v1.EncLevelOne, nil
+	case LevelTwo:
+		return v1.EncLevelTwo, nil
+	}
+	return 0, errors.New("unsupported level")
+}
+
+func fromV1Level(level v1.EncryptionLevel) (Level, error) {
+	switch level {
+	case v1.EncLevelOne:
+		return LevelOne, nil
+	case v1.EncLevelTwo:
+		return LevelTwo, nil
+	}
+	return 0, errors.New("unsupported level")
+}
@@ -0,0 +1,134 @@
+package paillier
+
+import (
+	"context"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+	v1 "github.com/sachaservan/paillier"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+
+	sk, err := GenerateKey(ctx, KeyOptions{Bits: 128})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+
+	a, err := pk.Encrypt(ctx, gmp.NewInt(30), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pk.Encrypt(ctx, gmp.NewInt(12), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := pk.Add(ctx, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := pk.Sub(ctx, sum, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, err := pk.MulConst(ctx, diff, gmp.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, err = pk.Randomize(ctx, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := sk.Decrypt(ctx, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Int64() != 90 {
+		t.Error("wrong decryption ", m, " is not 90")
+	}
+
+	if _, err := pk.Encrypt(ctx, pk.Modulus(), nil); err == nil {
+		t.Error("expected error for plaintext out of range")
+	}
+
+	c, err := pk.Encrypt(ctx, pk.Modulus(), &EncryptOptions{Level: LevelTwo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pk.Add(ctx, a, c); err == nil {
+		t.Error("expected error for ciphertexts at different levels")
+	}
+
+	c, err = pk.Randomize(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := sk.Decrypt(ctx, c); err != nil || m.Cmp(pk.Modulus()) != 0 {
+		t.Error("wrong level two decryption ", m, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := pk.Encrypt(cancelled, gmp.NewInt(1), nil); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	ctx := context.Background()
+
+	v1sk, _ := v1.KeyGen(128)
+	sk := FromV1SecretKey(v1sk)
+
+	skData, err := sk.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkData, err := sk.Public().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedSk, err := ParseSecretKey(skData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedPk, err := ParsePublicKey(pkData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, err := parsedPk.Encrypt(ctx, gmp.NewInt(77), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctData, err := ct.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedCt, err := ParseCiphertext(ctData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := v1sk.Decrypt(parsedCt.V1()); m.Int64() != 77 {
+		t.Error("wrong decryption ", m, " is not 77")
+	}
+	if m, err := parsedSk.Decrypt(ctx, parsedCt); err != nil || m.Int64() != 77 {
+		t.Error("wrong decryption ", m, err)
+	}
+
+	ctData[1] = 3
+	if _, err := ParseCiphertext(ctData); err == nil {
+		t.Error("expected error for unsupported level")
+	}
+
+	if _, err := sk.Decrypt(ctx, FromV1Ciphertext(&v1.Ciphertext{C: gmp.NewInt(0)})); err == nil {
+		t.Error("expected error for invalid ciphertext")
+	}
+}
@@ -0,0 +1,25 @@
+package paillier
+
+import "errors"
+
+// Errors wrapped by the error returning proof verification methods,
+// e.g., PartialDecryptionZKP.VerifyErr; test for them with errors.Is
+var (
+	// ErrMalformedProof -- the proof has missing or out of range values
+	ErrMalformedProof = errors.New("malformed proof")
+
+	// ErrStaleKey -- the proof was produced under a different key
+	ErrStaleKey = errors.New("proof was produced under a different key")
+
+	// ErrChallengeMismatch -- the Fiat-Shamir challenge does not match the proof
+	ErrChallengeMismatch = errors.New("proof challenge does not match")
+
+	// ErrProofPart1 -- the first verification equation does not hold
+	ErrProofPart1 = errors.New("first verification equation does not hold")
+
+	// ErrProofPart2 -- the second verification equation does not hold
+	ErrProofPart2 = errors.New("second verification equation does not hold")
+
+	// ErrProofPart3 -- the third verification equation does not hold
+	ErrProofPart3 = errors.New("third verification equation does not hold")
+)
@@ -0,0 +1,310 @@
+// Package voting runs elections and polls with a threshold committee:
+// voters encrypt one bit per option with a proof that it is 0 or 1 and,
+// optionally, a proof that they selected at most MaxChoices options; the
+// tally multiplies the ballots homomorphically, and the trustees decrypt
+// only the encrypted counts with published proofs.
+//
+// The published ballots and tally form a paillier.ElectionTranscript that
+// anyone can check with Verify.
+package voting
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+// Election are the public parameters of an election
+type Election struct {
+	Key     *paillier.ThresholdPublicKey
+	Options int
+
+	// MaxChoices is the largest number of options a voter may select, e.g.,
+	// one for a single-choice election; zero allows any number (approval
+	// voting)
+	MaxChoices int
+}
+
+// Ballot is the encrypted vote of a voter. Limit proves that at most
+// MaxChoices options were selected and is nil if the election has no limit.
+type Ballot struct {
+	paillier.Ballot
+	Limit *paillier.BitRangeProof
+}
+
+// NewElection returns the parameters of an election with the given number
+// of options, of which voters select at most maxChoices or any number if
+// maxChoices is zero
+func NewElection(key *paillier.ThresholdPublicKey, options, maxChoices int) (*Election, error) {
+	if options < 1 {
+		return nil, errors.New("election must have at least one option")
+	}
+	if maxChoices < 0 || maxChoices > options {
+		return nil, errors.New("maximum number of choices is out of range")
+	}
+	return &Election{Key: key, Options: options, MaxChoices: maxChoices}, nil
+}
+
+// Vote encrypts the choices of a voter, 0 or 1 per option, with the proofs
+// of the election
+func (e *Election) Vote(choices []int) (*Ballot, error) {
+	if len(choices) != e.Options {
+		return nil, fmt.Errorf("ballot has %d choices, expected %d", len(choices), e.Options)
+	}
+
+	pk := &e.Key.PublicKey
+	ballot := &Ballot{Ballot: paillier.Ballot{
+		Choices: make([]*paillier.Ciphertext, len(choices)),
+		Proofs:  make([]*paillier.BinaryProof, len(choices)),
+	}}
+
+	// the product of the choices encrypts the number of selected options
+	// with the product of their randomness
+	selected := 0
+	rs := gmp.NewInt(1)
+	for i, choice := range choices {
+		if choice != 0 && choice != 1 {
+			return nil, fmt.Errorf("choice %d must be 0 or 1", i)
+		}
+		selected += choice
+
+		r, err := paillier.GetRandomNumberInMultiplicativeGroup(pk.N, pk.RandomSource())
+		if err != nil {
+			return nil, err
+		}
+		ballot.Choices[i] = pk.EncryptWithR(gmp.NewInt(int64(choice)), r)
+		if ballot.Proofs[i], err = pk.ProveBinary(ballot.Choices[i], choice, r); err != nil {
+			return nil, err
+		}
+		rs.Mul(rs, r)
+		rs.Mod(rs, pk.N)
+	}
+
+	if e.MaxChoices > 0 {
+		if selected > e.MaxChoices {
+			return nil, fmt.Errorf("ballot selects %d options, at most %d are allowed", selected, e.MaxChoices)
+		}
+		var err error
+		ballot.Limit, err = pk.ProveBitRange(e.selected(&ballot.Ballot), gmp.NewInt(int64(selected)), rs, e.limitBound())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ballot, nil
+}
+
+// VerifyBallot returns an error if the ballot does not have one ciphertext
+// per option with a valid binary proof or violates the limit of the election
+func (e *Election) VerifyBallot(ballot *Ballot) error {
+	if ballot == nil || len(ballot.Choices) != e.Options || len(ballot.Proofs) != e.Options {
+		return errors.New("wrong number of choices")
+	}
+
+	pk := &e.Key.PublicKey
+	for i, ct := range ballot.Choices {
+		if ct == nil || ct.C == nil {
+			return fmt.Errorf("option %d: missing ciphertext", i)
+		}
+		if err := pk.VerifyBinaryProofErr(ct, ballot.Proofs[i]); err != nil {
+			return fmt.Errorf("option %d: %w", i, err)
+		}
+	}
+
+	if e.MaxChoices > 0 {
+		if ballot.Limit == nil {
+			return errors.New("ballot is missing the proof of the number of choices")
+		}
+		if err := pk.VerifyBitRangeProofErr(e.selected(&ballot.Ballot), e.limitBound(), ballot.Limit); err != nil {
+			return fmt.Errorf("number of choices: %w", err)
+		}
+	}
+	return nil
+}
+
+// selected returns the encryption of the number of selected options
+func (e *Election) selected(ballot *paillier.Ballot) *paillier.Ciphertext {
+	return e.Key.PublicKey.Add(ballot.Choices...)
+}
+
+// limitBound returns the exclusive bound of the number of selected options
+func (e *Election) limitBound() *gmp.Int {
+	return gmp.NewInt(int64(e.MaxChoices + 1))
+}
+
+// Tally accumulates the verified ballots of an election. It is safe for
+// concurrent use.
+type Tally struct {
+	election *Election
+
+	mu      sync.Mutex
+	voters  map[string]bool
+	ballots []*Ballot
+	counts  []*paillier.Ciphertext
+}
+
+// NewTally returns a tally without ballots
+func (e *Election) NewTally() *Tally {
+	return &Tally{election: e, voters: make(map[string]bool)}
+}
+
+// Cast verifies the ballot of the voter and adds it to the encrypted counts.
+// It returns an error if the ballot is invalid or the voter already voted.
+func (t *Tally) Cast(voter string, ballot *Ballot) error {
+	if err := t.election.VerifyBallot(ballot); err != nil {
+		return fmt.Errorf("voter %s: %w", voter, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.voters[voter] {
+		return fmt.Errorf("voter %s already voted", voter)
+	}
+	t.voters[voter] = true
+	t.ballots = append(t.ballots, ballot)
+
+	pk := &t.election.Key.PublicKey
+	for i, ct := range ballot.Choices {
+		if t.counts == nil {
+			t.counts = make([]*paillier.Ciphertext, t.election.Options)
+		}
+		if t.counts[i] == nil {
+			t.counts[i] = ct.Clone()
+		} else {
+			t.counts[i] = pk.Add(t.counts[i], ct)
+		}
+	}
+	return nil
+}
+
+// Counts returns the encrypted count of every option, which the trustees
+// decrypt with PartialDecrypt
+func (t *Tally) Counts() []*paillier.Ciphertext {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.ballots) == 0 {
+		// the tally of no ballots, see paillier.TallyCiphertexts
+		return paillier.TallyCiphertexts(&t.election.Key.PublicKey, nil, t.election.Options)
+	}
+	return append([]*paillier.Ciphertext{}, t.counts...)
+}
+
+// Ballots returns the ballots in the order they were cast, e.g., to publish
+// them with the result
+func (t *Tally) Ballots() []*Ballot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Ballot{}, t.ballots...)
+}
+
+// DecryptionShare holds the proven partial decryptions of the encrypted
+// counts by one trustee, one per option
+type DecryptionShare struct {
+	ID                 int
+	PartialDecryptions []*paillier.PartialDecryptionZKP
+}
+
+// PartialDecrypt is run by every trustee on the encrypted counts
+func PartialDecrypt(tsk *paillier.ThresholdSecretKey, counts []*paillier.Ciphertext) (*DecryptionShare, error) {
+	share := &DecryptionShare{ID: tsk.ID, PartialDecryptions: make([]*paillier.PartialDecryptionZKP, len(counts))}
+	for i, ct := range counts {
+		if ct == nil || ct.C == nil {
+			return nil, fmt.Errorf("%w: count %d", paillier.ErrInvalidCiphertext, i)
+		}
+		var err error
+		if share.PartialDecryptions[i], err = tsk.PartialDecryptionWithZKP(ct.C); err != nil {
+			return nil, err
+		}
+	}
+	return share, nil
+}
+
+// Result verifies the decryption shares of the trustees and returns the
+// published tally with the counts and the partial decryptions of the
+// trustees with valid shares. Trustees whose share is invalid for any
+// option are left out; it returns an error if fewer than Threshold remain.
+func (e *Election) Result(counts []*paillier.Ciphertext, shares []*DecryptionShare) (*paillier.ElectionTally, error) {
+	if len(counts) != e.Options {
+		return nil, fmt.Errorf("tally has %d counts, expected %d", len(counts), e.Options)
+	}
+
+	var valid []*DecryptionShare
+	var invalid error
+	seen := make(map[int]bool)
+	for _, share := range shares {
+		if err := e.checkShare(counts, share); err != nil {
+			invalid = err
+			continue
+		}
+		if seen[share.ID] {
+			continue
+		}
+		seen[share.ID] = true
+		valid = append(valid, share)
+	}
+	if len(valid) < e.Key.Threshold {
+		if invalid != nil {
+			return nil, fmt.Errorf("%w: %d valid shares, threshold is %d: %v", paillier.ErrTooFewShares, len(valid), e.Key.Threshold, invalid)
+		}
+		return nil, fmt.Errorf("%w: %d valid shares, threshold is %d", paillier.ErrTooFewShares, len(valid), e.Key.Threshold)
+	}
+
+	tally := &paillier.ElectionTally{
+		Counts:             make([]*gmp.Int, e.Options),
+		PartialDecryptions: make([][]*paillier.PartialDecryptionZKP, e.Options),
+	}
+	for i := range counts {
+		for _, share := range valid {
+			tally.PartialDecryptions[i] = append(tally.PartialDecryptions[i], share.PartialDecryptions[i])
+		}
+
+		var err error
+		if tally.Counts[i], err = e.Key.CombinePartialDecryptionsZKP(tally.PartialDecryptions[i][:e.Key.Threshold]); err != nil {
+			return nil, fmt.Errorf("option %d: %w", i, err)
+		}
+	}
+	return tally, nil
+}
+
+// checkShare returns an error if a partial decryption of the share is not
+// of the respective count or has an invalid proof
+func (e *Election) checkShare(counts []*paillier.Ciphertext, share *DecryptionShare) error {
+	if share == nil || len(share.PartialDecryptions) != len(counts) {
+		return errors.New("share must have a partial decryption for every option")
+	}
+	for i, pd := range share.PartialDecryptions {
+		if pd == nil || pd.ID != share.ID {
+			return fmt.Errorf("trustee %d, option %d: missing partial decryption", share.ID, i)
+		}
+		if pd.C == nil || counts[i] == nil || pd.C.Cmp(counts[i].C) != 0 {
+			return fmt.Errorf("trustee %d, option %d: partial decryption is not of the count", share.ID, i)
+		}
+		if err := pd.VerifyErrWithKey(e.Key); err != nil {
+			return fmt.Errorf("trustee %d, option %d: %w", share.ID, i, err)
+		}
+	}
+	return nil
+}
+
+// Verify re-checks the published ballots and tally of the election: every
+// ballot must be valid for the election, see VerifyBallot, and the
+// transcript of the ballots and the tally must verify, see
+// paillier.VerifyElectionTranscript
+func (e *Election) Verify(ballots []*Ballot, tally *paillier.ElectionTally) error {
+	tr := &paillier.ElectionTranscript{Key: e.Key, Tally: tally}
+	for i, ballot := range ballots {
+		if err := e.VerifyBallot(ballot); err != nil {
+			return fmt.Errorf("ballot %d: %w", i, err)
+		}
+		tr.Ballots = append(tr.Ballots, &ballot.Ballot)
+	}
+	if tally == nil || len(tally.Counts) != e.Options {
+		return errors.New("tally must have a count for every option")
+	}
+	return paillier.VerifyElectionTranscript(tr)
+}
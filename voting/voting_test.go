@@ -0,0 +1,204 @@
+package voting
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+	"github.com/sachaservan/paillier"
+)
+
+func getTrustees(t *testing.T) []*paillier.ThresholdSecretKey {
+	tkh, err := paillier.NewThresholdKeyGenerator(64, 3, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkh.InsecureAllowSmallKeys = true
+
+	tsks, err := tkh.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tsks
+}
+
+func TestElection(t *testing.T) {
+	tsks := getTrustees(t)
+	election, err := NewElection(&tsks[0].ThresholdPublicKey, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	votes := [][]int{{1, 0, 0}, {0, 1, 0}, {0, 1, 0}, {0, 0, 0}, {0, 1, 0}}
+	expected := []int64{1, 3, 0}
+
+	tally := election.NewTally()
+	var wg sync.WaitGroup
+	for i, choices := range votes {
+		ballot, err := election.Vote(choices)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func(voter string, ballot *Ballot) {
+			defer wg.Done()
+			if err := tally.Cast(voter, ballot); err != nil {
+				t.Error(err)
+			}
+		}(fmt.Sprint("voter-", i), ballot)
+	}
+	wg.Wait()
+
+	counts := tally.Counts()
+	var shares []*DecryptionShare
+	for _, tsk := range tsks {
+		share, err := PartialDecrypt(tsk, counts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, share)
+	}
+
+	// a trustee that lies about one count is left out
+	shares[1].PartialDecryptions[2].Decryption = new(gmp.Int).Add(shares[1].PartialDecryptions[2].Decryption, paillier.OneBigInt)
+
+	result, err := election.Result(counts, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, count := range result.Counts {
+		if count.Cmp(gmp.NewInt(expected[i])) != 0 {
+			t.Errorf("option %d has %v votes, expected %d", i, count, expected[i])
+		}
+		if len(result.PartialDecryptions[i]) != 2 {
+			t.Errorf("option %d has %d partial decryptions, expected 2", i, len(result.PartialDecryptions[i]))
+		}
+	}
+
+	published := tally.Ballots()
+	if err := election.Verify(published, result); err != nil {
+		t.Fatal(err)
+	}
+
+	result.Counts[1] = gmp.NewInt(4)
+	if err := election.Verify(published, result); err == nil {
+		t.Error("expected an error for a wrong count")
+	}
+	result.Counts[1] = gmp.NewInt(3)
+
+	if err := election.Verify(published[1:], result); err == nil {
+		t.Error("expected an error for a missing ballot")
+	}
+
+	if _, err := election.Result(counts, shares[1:2]); !errors.Is(err, paillier.ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+}
+
+func TestEmptyElection(t *testing.T) {
+	tsks := getTrustees(t)
+	election, err := NewElection(&tsks[0].ThresholdPublicKey, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := election.NewTally().Counts()
+	var shares []*DecryptionShare
+	for _, tsk := range tsks[:2] {
+		share, err := PartialDecrypt(tsk, counts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares = append(shares, share)
+	}
+
+	result, err := election.Result(counts, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, count := range result.Counts {
+		if count.Sign() != 0 {
+			t.Errorf("option %d has %v votes, expected 0", i, count)
+		}
+	}
+	if err := election.Verify(nil, result); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTallyRejects(t *testing.T) {
+	tsks := getTrustees(t)
+	election, err := NewElection(&tsks[0].ThresholdPublicKey, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := election.Vote([]int{1, 0, 1}); err == nil {
+		t.Error("expected an error for too many choices")
+	}
+	if _, err := election.Vote([]int{2, 0, 0}); err == nil {
+		t.Error("expected an error for a choice other than 0 or 1")
+	}
+	if _, err := election.Vote([]int{1, 0}); err == nil {
+		t.Error("expected an error for the wrong number of choices")
+	}
+
+	tally := election.NewTally()
+	ballot, err := election.Vote([]int{0, 0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tally.Cast("alice", ballot); err != nil {
+		t.Fatal(err)
+	}
+	if err := tally.Cast("alice", ballot); err == nil {
+		t.Error("expected an error for a second vote")
+	}
+
+	// valid binary proofs for a ballot that selects two options
+	pk := &election.Key.PublicKey
+	double, err := paillier.NewBallot(pk, []int{1, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tally.Cast("bob", &Ballot{Ballot: *double}); err == nil {
+		t.Error("expected an error for a ballot without the limit proof")
+	}
+	if err := tally.Cast("bob", &Ballot{Ballot: *double, Limit: ballot.Limit}); err == nil {
+		t.Error("expected an error for a ballot with the limit proof of another ballot")
+	}
+
+	cheating, err := election.Vote([]int{0, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cheating.Choices[1] = pk.Encrypt(gmp.NewInt(5))
+	if err := tally.Cast("carol", cheating); err == nil {
+		t.Error("expected an error for an invalid binary proof")
+	}
+
+	// without a limit, only the binary proofs keep a choice of 5 out of an
+	// approval vote
+	approval, err := NewElection(election.Key, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := approval.Vote([]int{0, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero := gmp.NewInt(0)
+	forged.Choices[1] = pk.Encrypt(gmp.NewInt(5))
+	forged.Proofs[1] = &paillier.BinaryProof{A0: zero, A1: zero, E0: zero, E1: zero, Z0: zero, Z1: zero}
+	if err := approval.NewTally().Cast("dave", forged); !errors.Is(err, paillier.ErrMalformedProof) {
+		t.Error("expected a malformed proof error for a zeroed binary proof, got ", err)
+	}
+
+	if len(tally.Ballots()) != 1 {
+		t.Errorf("tally has %d ballots, expected 1", len(tally.Ballots()))
+	}
+}
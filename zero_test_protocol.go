@@ -0,0 +1,357 @@
+package paillier
+
+import (
+	"errors"
+	"fmt"
+
+	gmp "github.com/ncw/gmp"
+)
+
+// ZeroTestRequest is sent by the tester to the decryption servers. It holds
+// the ciphertext [x] under test and the blindings of the servers so far; the
+// last blinding is the ciphertext that is decrypted.
+type ZeroTestRequest struct {
+	Input     *Ciphertext
+	Blindings []*ZeroTestBlinding
+}
+
+// ZeroTestBlinding is returned by a decryption server in the blinding round.
+// Blinded = prev^a rho^N mod N^2, where prev is the previous ciphertext of the
+// request, Commitment = g^a r^N commits to a and the proof shows that Blinded
+// was computed with the committed a and without an additive term, which is
+// committed by Zero = Randomness^N.
+type ZeroTestBlinding struct {
+	ID         int
+	Blinded    *Ciphertext
+	Commitment *Ciphertext
+	Randomness *gmp.Int
+	Proof      *AffineOperationProof
+}
+
+// ZeroTestResponse is returned by a decryption server in the decryption round
+// and contains its proven partial decryption of the blinded ciphertext
+type ZeroTestResponse struct {
+	Share *PartialDecryptionZKP
+}
+
+// ThresholdZeroTester holds the state of the party that wants to learn
+// whether [x] encrypts zero, or whether [x] and [y] encrypt the same value,
+// without the party or the decryption servers learning anything else about x
+// or y (see ThresholdSecretKey.BlindZeroTest and AssistZeroTest).
+//
+// The protocol is the blinding based zero test of [CDN 01]:
+//  1. the tester sends [x] to Threshold decryption servers in turn; each of
+//     them scales the last ciphertext by a secret a_i and proves that it
+//     did so (see AffineOperationProof)
+//  2. the same servers check that their own blinding is part of the request
+//     and return proven partial decryptions of [x * a_1 * ... * a_k]
+//  3. the tester combines the shares and outputs x = 0 iff the result is zero
+//
+// A server derives a_i from its share and the ciphertext it blinds, so that
+// it recognizes its own blinding in the decryption round without keeping
+// state. Since every server that decrypts has scaled the plaintext by a
+// uniform a_i that is unknown to everyone else, the result is uniformly
+// distributed over the units of Z_N unless x = 0, as long as one of the
+// servers follows the protocol. Shares and blindings with invalid proofs are
+// rejected; a malicious server can still make the result zero by blinding
+// with a_i = 0, so the tester learns nothing it should not, but may be told
+// that different values are equal.
+//
+//	[CDN 01]: Ronald Cramer, Ivan Damgard, Jesper Buus Nielsen, (2001)
+//	          Multiparty Computation from Threshold Homomorphic Encryption
+type ThresholdZeroTester struct {
+	key      *ThresholdPublicKey
+	request  *ZeroTestRequest
+	blinders map[int]bool
+	combiner *Combiner
+	done     bool
+}
+
+// NewZeroTester returns the tester state for [x] together with the request
+// for the first decryption server
+func (tk *ThresholdPublicKey) NewZeroTester(x *Ciphertext) (*ThresholdZeroTester, *ZeroTestRequest, error) {
+	if x == nil || x.C == nil || x.Level != EncLevelOne {
+		return nil, nil, errors.New("zero tests are only supported for level one ciphertexts")
+	}
+
+	req := &ZeroTestRequest{Input: x}
+	zt := &ThresholdZeroTester{key: tk, request: req, blinders: make(map[int]bool)}
+	return zt, req, nil
+}
+
+// NewEqualityTester returns the tester state for the zero test of [x-y],
+// which encrypts zero iff x = y mod N
+func (tk *ThresholdPublicKey) NewEqualityTester(x, y *Ciphertext) (*ThresholdZeroTester, *ZeroTestRequest, error) {
+	if x == nil || y == nil || x.Level != EncLevelOne || y.Level != EncLevelOne {
+		return nil, nil, errors.New("zero tests are only supported for level one ciphertexts")
+	}
+	return tk.NewZeroTester(tk.Sub(x, y))
+}
+
+// BlindZeroTest is run by a decryption server in the blinding round. It
+// scales the last ciphertext of the request by its secret factor.
+func (tsk *ThresholdSecretKey) BlindZeroTest(req *ZeroTestRequest) (*ZeroTestBlinding, error) {
+	if err := tsk.verifyZeroTestRequest(req); err != nil {
+		return nil, err
+	}
+	for _, b := range req.Blindings {
+		if b.ID == tsk.ID {
+			return nil, fmt.Errorf("server %d already blinded the ciphertext", tsk.ID)
+		}
+	}
+
+	pk := &tsk.ThresholdPublicKey.PublicKey
+	prev := req.last()
+	a, rho, err := tsk.zeroTestFactor(prev)
+	if err != nil {
+		return nil, err
+	}
+
+	random := pk.RandomSource()
+	ra, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := GetRandomNumberInMultiplicativeGroup(pk.N, random)
+	if err != nil {
+		return nil, err
+	}
+
+	blinded := tsk.zeroTestBlind(prev, a, rho)
+	commitment := pk.EncryptWithR(a, ra)
+	proof, err := pk.ProveAffineOperation(prev, blinded, commitment, pk.EncryptWithR(ZeroBigInt, rb), a, ra, ZeroBigInt, rb, rho)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZeroTestBlinding{ID: tsk.ID, Blinded: blinded, Commitment: commitment, Randomness: rb, Proof: proof}, nil
+}
+
+// AssistZeroTest is run by a decryption server in the decryption round. The
+// server only decrypts requests that contain its own blinding, so that the
+// plaintext is blinded by a factor it chose.
+func (tsk *ThresholdSecretKey) AssistZeroTest(req *ZeroTestRequest) (*ZeroTestResponse, error) {
+	if err := tsk.verifyZeroTestRequest(req); err != nil {
+		return nil, err
+	}
+
+	prev := req.Input
+	blinded := false
+	for _, b := range req.Blindings {
+		if b.ID == tsk.ID {
+			a, rho, err := tsk.zeroTestFactor(prev)
+			if err != nil {
+				return nil, err
+			}
+			if tsk.zeroTestBlind(prev, a, rho).C.Cmp(b.Blinded.C) != 0 {
+				return nil, fmt.Errorf("blinding of server %d was not computed by this server", tsk.ID)
+			}
+			blinded = true
+		}
+		prev = b.Blinded
+	}
+	if !blinded {
+		return nil, fmt.Errorf("server %d did not blind the ciphertext", tsk.ID)
+	}
+
+	share, err := tsk.PartialDecryptionWithZKP(prev.C)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZeroTestResponse{Share: share}, nil
+}
+
+// AddBlinding verifies the blinding of a decryption server and returns the
+// request for the next server. Once Threshold servers have blinded, the
+// request is sent to each of them for the decryption round.
+func (zt *ThresholdZeroTester) AddBlinding(b *ZeroTestBlinding) (*ZeroTestRequest, error) {
+	if zt.combiner != nil {
+		return nil, errors.New("blindings cannot be added once the decryption round started")
+	}
+	if err := zt.key.verifyZeroTestBlinding(zt.request.last(), b); err != nil {
+		return nil, err
+	}
+	if zt.blinders[b.ID] {
+		return nil, fmt.Errorf("%w: server %d already blinded the ciphertext", ErrDuplicateShareID, b.ID)
+	}
+
+	zt.blinders[b.ID] = true
+	zt.request = &ZeroTestRequest{
+		Input:     zt.request.Input,
+		Blindings: append(append([]*ZeroTestBlinding{}, zt.request.Blindings...), b),
+	}
+	return zt.request, nil
+}
+
+// Blinded returns true once Threshold servers have blinded the ciphertext
+func (zt *ThresholdZeroTester) Blinded() bool {
+	return len(zt.blinders) >= zt.key.Threshold
+}
+
+// Add verifies the response of a decryption server that blinded the
+// ciphertext and adds its share. See Combiner.AddZKP for the errors.
+func (zt *ThresholdZeroTester) Add(resp *ZeroTestResponse) error {
+	if resp == nil || resp.Share == nil {
+		return fmt.Errorf("%w: missing response", ErrMalformedProof)
+	}
+	if !zt.Blinded() {
+		return fmt.Errorf("%d of %d servers blinded the ciphertext", len(zt.blinders), zt.key.Threshold)
+	}
+	if !zt.blinders[resp.Share.ID] {
+		return fmt.Errorf("server %d did not blind the ciphertext", resp.Share.ID)
+	}
+
+	if zt.combiner == nil {
+		zt.combiner = zt.key.NewCombiner(zt.request.last().C)
+	}
+	return zt.combiner.AddZKP(resp.Share)
+}
+
+// Ready returns true once Threshold valid shares were added
+func (zt *ThresholdZeroTester) Ready() bool {
+	return zt.combiner != nil && zt.combiner.Ready()
+}
+
+// Finalize combines the shares and returns true iff the ciphertext encrypts
+// zero. It returns an error wrapping ErrTooFewShares if the tester is not
+// Ready.
+func (zt *ThresholdZeroTester) Finalize() (bool, error) {
+	if zt.done {
+		return false, errors.New("zero test was already finalized")
+	}
+	if zt.combiner == nil {
+		return false, fmt.Errorf("%w: no partial decryptions were added", ErrTooFewShares)
+	}
+
+	m, err := zt.combiner.Combine()
+	if err != nil {
+		return false, err
+	}
+	zt.done = true
+
+	return m.Sign() == 0, nil
+}
+
+// ZeroTest runs the protocol with local decryption servers, e.g., in tests
+// and simulations, and returns true iff x encrypts zero
+func (tk *ThresholdPublicKey) ZeroTest(x *Ciphertext, servers []*ThresholdSecretKey) (bool, error) {
+	zt, req, err := tk.NewZeroTester(x)
+	if err != nil {
+		return false, err
+	}
+	return zt.run(req, servers)
+}
+
+// EqualityTest runs the protocol with local decryption servers, e.g., in
+// tests and simulations, and returns true iff x and y encrypt the same value
+func (tk *ThresholdPublicKey) EqualityTest(x, y *Ciphertext, servers []*ThresholdSecretKey) (bool, error) {
+	zt, req, err := tk.NewEqualityTester(x, y)
+	if err != nil {
+		return false, err
+	}
+	return zt.run(req, servers)
+}
+
+func (zt *ThresholdZeroTester) run(req *ZeroTestRequest, servers []*ThresholdSecretKey) (bool, error) {
+	if len(servers) < zt.key.Threshold {
+		return false, fmt.Errorf("%w: %d of %d servers", ErrTooFewShares, len(servers), zt.key.Threshold)
+	}
+	servers = servers[:zt.key.Threshold]
+
+	for _, tsk := range servers {
+		b, err := tsk.BlindZeroTest(req)
+		if err != nil {
+			return false, err
+		}
+		if req, err = zt.AddBlinding(b); err != nil {
+			return false, err
+		}
+	}
+
+	for _, tsk := range servers {
+		resp, err := tsk.AssistZeroTest(req)
+		if err != nil {
+			return false, err
+		}
+		if err := zt.Add(resp); err != nil {
+			return false, err
+		}
+	}
+
+	return zt.Finalize()
+}
+
+// last returns the ciphertext to blind or decrypt next
+func (req *ZeroTestRequest) last() *Ciphertext {
+	if len(req.Blindings) == 0 {
+		return req.Input
+	}
+	return req.Blindings[len(req.Blindings)-1].Blinded
+}
+
+// verifyZeroTestRequest checks the input and every blinding of the request
+func (tk *ThresholdPublicKey) verifyZeroTestRequest(req *ZeroTestRequest) error {
+	if req == nil || req.Input == nil || req.Input.C == nil || req.Input.Level != EncLevelOne {
+		return errors.New("zero tests are only supported for level one ciphertexts")
+	}
+	if err := tk.validateUnit(req.Input.C); err != nil {
+		return fmt.Errorf("%w: ciphertext %v", ErrInvalidCiphertext, err)
+	}
+
+	seen := make(map[int]bool)
+	prev := req.Input
+	for _, b := range req.Blindings {
+		if err := tk.verifyZeroTestBlinding(prev, b); err != nil {
+			return err
+		}
+		if seen[b.ID] {
+			return fmt.Errorf("%w: server %d blinded the ciphertext twice", ErrDuplicateShareID, b.ID)
+		}
+		seen[b.ID] = true
+		prev = b.Blinded
+	}
+	return nil
+}
+
+// verifyZeroTestBlinding checks that b scales prev by the committed factor
+func (tk *ThresholdPublicKey) verifyZeroTestBlinding(prev *Ciphertext, b *ZeroTestBlinding) error {
+	if b == nil || b.Blinded == nil || b.Commitment == nil || b.Randomness == nil {
+		return fmt.Errorf("%w: missing blinding", ErrMalformedProof)
+	}
+	if b.ID < 1 || b.ID > tk.TotalNumberOfDecryptionServers {
+		return fmt.Errorf("blinding ID %d is out of range", b.ID)
+	}
+	if err := tk.checkUnits(b.Randomness); err != nil {
+		return err
+	}
+
+	zero := tk.EncryptWithR(ZeroBigInt, b.Randomness)
+	if err := tk.VerifyAffineOperationProofErr(prev, b.Blinded, b.Commitment, zero, b.Proof); err != nil {
+		return &InvalidProofError{ID: b.ID, Err: err}
+	}
+	return nil
+}
+
+// zeroTestFactor derives the blinding factor a and the randomness rho of the
+// server for the ciphertext from its share
+func (tsk *ThresholdSecretKey) zeroTestFactor(ct *Ciphertext) (*gmp.Int, *gmp.Int, error) {
+	size := (tsk.N.BitLen()+7)/8 + 16
+	info := append([]byte("paillier zero test"), ct.C.Bytes()...)
+	okm := hkdf(tsk.Share.Bytes(), tsk.N.Bytes(), info, 2*size)
+
+	a := new(gmp.Int).Mod(new(gmp.Int).SetBytes(okm[:size]), tsk.N)
+	rho := new(gmp.Int).Mod(new(gmp.Int).SetBytes(okm[size:]), tsk.N)
+	if tsk.checkUnits(a, rho) != nil {
+		return nil, nil, errors.New("derived blinding factor is not a unit mod N")
+	}
+	return a, rho, nil
+}
+
+// zeroTestBlind returns ct^a rho^N mod N^2
+func (tsk *ThresholdSecretKey) zeroTestBlind(ct *Ciphertext, a, rho *gmp.Int) *Ciphertext {
+	blinded := tsk.ConstMult(ct, a)
+	blinded.C.Mul(blinded.C, tsk.EncryptWithR(ZeroBigInt, rho).C)
+	blinded.C.Mod(blinded.C, tsk.GetN2())
+	return blinded
+}
@@ -0,0 +1,170 @@
+package paillier
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+
+	gmp "github.com/ncw/gmp"
+)
+
+func TestThresholdZeroTest(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicOnly()
+
+	for i, x := range []int64{0, 1, 7, 0} {
+		zero, err := tk.ZeroTest(tk.Encrypt(gmp.NewInt(x)), tsks[i%2:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if zero != (x == 0) {
+			t.Errorf("zero test of %d returned %v", x, zero)
+		}
+	}
+
+	for _, c := range []struct {
+		x, y  int64
+		equal bool
+	}{{5, 5, true}, {5, 6, false}, {0, 9, false}} {
+		equal, err := tk.EqualityTest(tk.Encrypt(gmp.NewInt(c.x)), tk.Encrypt(gmp.NewInt(c.y)), tsks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if equal != c.equal {
+			t.Errorf("equality test of %d and %d returned %v", c.x, c.y, equal)
+		}
+	}
+}
+
+func TestThresholdZeroTestMessages(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicOnly()
+
+	tester, req, err := tk.NewEqualityTester(tk.Encrypt(gmp.NewInt(4)), tk.Encrypt(gmp.NewInt(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tsk := range tsks[1:] {
+		b, err := tsk.BlindZeroTest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded ZeroTestBlinding
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+			t.Fatal(err)
+		}
+		if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+			t.Fatal(err)
+		}
+		if req, err = tester.AddBlinding(&decoded); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !tester.Blinded() {
+		t.Fatal("tester must be blinded by Threshold servers")
+	}
+	if _, err := tester.Finalize(); !errors.Is(err, ErrTooFewShares) {
+		t.Error("expected ErrTooFewShares, got ", err)
+	}
+
+	// the decryption request crosses the network as an interface value
+	var buf bytes.Buffer
+	var sent interface{} = req
+	if err := gob.NewEncoder(&buf).Encode(&sent); err != nil {
+		t.Fatal(err)
+	}
+	var received interface{}
+	if err := gob.NewDecoder(&buf).Decode(&received); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tsks[0].AssistZeroTest(received.(*ZeroTestRequest)); err == nil {
+		t.Error("expected an error for a server that did not blind the ciphertext")
+	}
+	for _, tsk := range tsks[1:] {
+		resp, err := tsk.AssistZeroTest(received.(*ZeroTestRequest))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tester.Add(resp); err != nil {
+			t.Fatal(err)
+		}
+		if err := tester.Add(resp); !errors.Is(err, ErrDuplicateShareID) {
+			t.Error("expected ErrDuplicateShareID, got ", err)
+		}
+	}
+
+	equal, err := tester.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Error("equal values were not recognized")
+	}
+	if _, err := tester.Finalize(); err == nil {
+		t.Error("expected an error for a second finalization")
+	}
+}
+
+func TestThresholdZeroTestRejectsCheating(t *testing.T) {
+	tsks := getMultiplicationKeys(t)
+	tk := tsks[0].PublicOnly()
+	x := tk.Encrypt(gmp.NewInt(42))
+
+	tester, req, err := tk.NewZeroTester(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a blinding that replaces the ciphertext is rejected
+	b, err := tsks[0].BlindZeroTest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := *b
+	forged.Blinded = tk.Encrypt(gmp.NewInt(0))
+	if _, err := tester.AddBlinding(&forged); !errors.Is(err, ErrInvalidProof) {
+		t.Error("expected ErrInvalidProof, got ", err)
+	}
+	if req, err = tester.AddBlinding(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tester.AddBlinding(b); err == nil {
+		t.Error("expected an error for a second blinding of the same server")
+	}
+	if _, err := tsks[0].BlindZeroTest(req); err == nil {
+		t.Error("expected an error for a server that blinds twice")
+	}
+
+	// the servers only decrypt requests with their own blinding, so the
+	// tester cannot blind on behalf of a server with a factor it knows
+	a, ra, rb, rho := gmp.NewInt(3), gmp.NewInt(5), gmp.NewInt(7), gmp.NewInt(11)
+	last := req.Blindings[0].Blinded
+	blinded := tk.ConstMult(last, a)
+	blinded.C.Mul(blinded.C, tk.EncryptWithR(ZeroBigInt, rho).C)
+	blinded.C.Mod(blinded.C, tk.GetN2())
+	commitment := tk.EncryptWithR(a, ra)
+	proof, err := tk.ProveAffineOperation(last, blinded, commitment, tk.EncryptWithR(ZeroBigInt, rb), a, ra, ZeroBigInt, rb, rho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged = ZeroTestBlinding{ID: 2, Blinded: blinded, Commitment: commitment, Randomness: rb, Proof: proof}
+	if _, err := tester.AddBlinding(&forged); err != nil {
+		t.Fatal("a valid blinding must be accepted by the tester: ", err)
+	}
+	impersonated := &ZeroTestRequest{Input: x, Blindings: append(req.Blindings, &forged)}
+	if _, err := tsks[1].AssistZeroTest(impersonated); err == nil {
+		t.Error("expected an error for a blinding with a factor of the tester")
+	}
+
+	if _, err := tsks[0].AssistZeroTest(&ZeroTestRequest{Input: x}); err == nil {
+		t.Error("expected an error for a request without blindings")
+	}
+	if _, _, err := tk.NewZeroTester(tk.NestedEncrypt(gmp.NewInt(3))); err == nil {
+		t.Error("expected an error for a level two ciphertext")
+	}
+}